@@ -0,0 +1,90 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// aaaaxy-ghostrelay forwards ghost racing packets between players in the same room.
+//
+// Players connect to it by passing -netghost_relay=host:port and a shared
+// -netghost_room to the game.
+package main
+
+import (
+	"net"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/netghost"
+)
+
+var (
+	listen      = flag.String("listen", ":23416", "UDP address to listen on")
+	peerTimeout = flag.Duration("peer_timeout", 10*time.Second, "time after which a silent player leaves its room")
+)
+
+type peer struct {
+	room     uint32
+	lastSeen time.Time
+}
+
+func main() {
+	flag.Parse(flag.NoConfig)
+	addr, err := net.ResolveUDPAddr("udp", *listen)
+	if err != nil {
+		log.Fatalf("could not resolve listen address: %v", err)
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		log.Fatalf("could not listen: %v", err)
+	}
+	log.Infof("relaying ghosts on %v", conn.LocalAddr())
+	peers := map[string]*peer{}
+	addrs := map[string]*net.UDPAddr{}
+	buf := make([]byte, netghost.PacketSize+1)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			log.Fatalf("could not receive: %v", err)
+		}
+		room, _, err := netghost.ParsePacket(buf[:n])
+		if err != nil {
+			continue
+		}
+		now := time.Now()
+		key := from.String()
+		p := peers[key]
+		if p == nil {
+			log.Infof("%v joined room %08x", key, room)
+			p = &peer{}
+			peers[key] = p
+			addrs[key] = from
+		}
+		p.room = room
+		p.lastSeen = now
+		for other, op := range peers {
+			if now.Sub(op.lastSeen) > *peerTimeout {
+				log.Infof("%v left room %08x", other, op.room)
+				delete(peers, other)
+				delete(addrs, other)
+				continue
+			}
+			if other == key || op.room != room {
+				continue
+			}
+			_, err := conn.WriteToUDP(buf[:n], addrs[other])
+			if err != nil {
+				log.Warningf("could not forward to %v: %v", other, err)
+			}
+		}
+	}
+}
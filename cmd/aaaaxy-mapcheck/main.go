@@ -0,0 +1,294 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// aaaaxy-mapcheck validates maps and reports all problems found.
+//
+// Arguments are level names (loaded from the assets) or paths to .tmx files.
+// Tilesets and images are always looked up in the assets, so map packs
+// should be checked with -cheat_replace_embedded_assets or from a source
+// checkout containing them.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	jsonOutput = flag.Bool("json", false, "write the problems found as JSON")
+)
+
+type Problem struct {
+	Map     string `json:"map"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+type checker struct {
+	mapName  string
+	problems []Problem
+}
+
+func (c *checker) report(check string, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{
+		Map:     c.mapName,
+		Check:   check,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *checker) loaderProblem(err error) {
+	switch {
+	case errors.Is(err, level.ErrUnpairedWarpZone):
+		c.report("warpzone", "%v", err)
+	case errors.Is(err, level.ErrOutsideMap):
+		c.report("bounds", "%v", err)
+	default:
+		c.report("load", "%v", err)
+	}
+}
+
+func assetExists(purpose, name string) bool {
+	r, err := vfs.Load(purpose, name)
+	if err != nil {
+		return false
+	}
+	r.Close()
+	return true
+}
+
+// spawnables returns all spawnables of the level in ID order.
+func spawnables(lvl *level.Level) []*level.Spawnable {
+	byID := map[level.EntityID]*level.Spawnable{}
+	lvl.ForEachTile(func(_ m.Pos, t *level.LevelTile) {
+		for _, sp := range t.Tile.Spawnables {
+			byID[sp.ID] = sp
+		}
+	})
+	if lvl.Player != nil {
+		byID[lvl.Player.ID] = lvl.Player
+	}
+	sps := make([]*level.Spawnable, 0, len(byID))
+	for _, sp := range byID {
+		sps = append(sps, sp)
+	}
+	sort.Slice(sps, func(a, b int) bool {
+		return sps[a].ID < sps[b].ID
+	})
+	return sps
+}
+
+func (c *checker) checkResources(lvl *level.Level) {
+	fonts := map[string]bool{}
+	for _, name := range font.Names() {
+		fonts[name] = true
+	}
+	for _, sp := range spawnables(lvl) {
+		if fontName := propmap.StringOr(sp.Properties, "text_font", ""); fontName != "" && !fonts[fontName] {
+			c.report("font", "entity %d (%s) uses unknown font %q", sp.ID, sp.EntityType, fontName)
+		}
+		imgSrc := propmap.StringOr(sp.Properties, "image", "")
+		if imgSrc == "" {
+			continue
+		}
+		directory := propmap.StringOr(sp.Properties, "image_dir", "sprites")
+		srcs := []string{imgSrc}
+		byOrientation, err := level.ParseImageSrcByOrientation(imgSrc, sp.Properties)
+		if err != nil {
+			c.report("image", "entity %d (%s): %v", sp.ID, sp.EntityType, err)
+		}
+		for _, src := range byOrientation {
+			srcs = append(srcs, src)
+		}
+		sort.Strings(srcs)
+		for i, src := range srcs {
+			if src == "" || (i > 0 && srcs[i-1] == src) {
+				continue
+			}
+			if !assetExists(directory, src) {
+				c.report("image", "entity %d (%s) references missing image %s/%s", sp.ID, sp.EntityType, directory, src)
+			}
+		}
+	}
+}
+
+func (c *checker) checkPlayer(lvl *level.Level) {
+	if lvl.Player == nil {
+		c.report("bounds", "map has no Player")
+		return
+	}
+	if lvl.Tile(lvl.Player.LevelPos) == nil {
+		c.report("bounds", "Player is outside map bounds: %v", lvl.Player.LevelPos)
+	}
+}
+
+// checkCheckpoints verifies that all checkpoints can be reached in the checkpoint graph
+// from the checkpoint closest to the player start.
+func (c *checker) checkCheckpoints(lvl *level.Level) {
+	if lvl.Player == nil {
+		return
+	}
+	center := func(sp *level.Spawnable) m.Pos {
+		return sp.LevelPos.Mul(level.TileSize).Add(sp.RectInTile.Center().Delta(m.Pos{}))
+	}
+	names := make([]string, 0, len(lvl.Checkpoints))
+	id2name := map[level.EntityID]string{}
+	for name, sp := range lvl.Checkpoints {
+		if name == "" {
+			// Not a real CP, but the player initial spawn.
+			continue
+		}
+		names = append(names, name)
+		id2name[sp.ID] = name
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+	neighbors := map[string][]string{}
+	for _, name := range names {
+		sp := lvl.Checkpoints[name]
+		for _, propname := range []string{"next_left", "next_right", "next_up", "next_down"} {
+			id, err := propmap.ValueOr(sp.Properties, propname, -1)
+			if err != nil {
+				c.report("checkpoint", "checkpoint %q has invalid %s: %v", name, propname, err)
+				continue
+			}
+			if id == -1 {
+				continue
+			}
+			other := id2name[level.EntityID(id)]
+			if other == "" {
+				c.report("checkpoint", "checkpoint %q property %s points at entity %d which is not a checkpoint", name, propname, id)
+				continue
+			}
+			neighbors[name] = append(neighbors[name], other)
+			neighbors[other] = append(neighbors[other], name)
+		}
+	}
+	playerPos := center(lvl.Player)
+	start := ""
+	bestDist := int64(0)
+	for _, name := range names {
+		d := center(lvl.Checkpoints[name]).Delta(playerPos).Length2()
+		if start == "" || d < bestDist {
+			start, bestDist = name, d
+		}
+	}
+	reached := map[string]bool{start: true}
+	queue := []string{start}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, other := range neighbors[name] {
+			if !reached[other] {
+				reached[other] = true
+				queue = append(queue, other)
+			}
+		}
+	}
+	for _, name := range names {
+		if !reached[name] {
+			c.report("checkpoint", "checkpoint %q is not reachable from %q in the checkpoint graph", name, start)
+		}
+	}
+}
+
+func (c *checker) checkSaveGameVersion(lvl *level.Level, versions map[int][]string) {
+	if lvl.SaveGameVersion != level.CurrentSaveGameVersion {
+		c.report("save_game_version", "save_game_version is %d, but this version of the game only supports %d", lvl.SaveGameVersion, level.CurrentSaveGameVersion)
+	}
+	versions[lvl.SaveGameVersion] = append(versions[lvl.SaveGameVersion], c.mapName)
+}
+
+func checkMap(arg string, versions map[int][]string) []Problem {
+	var loader *level.Loader
+	c := &checker{mapName: arg}
+	if strings.HasSuffix(arg, ".tmx") {
+		loader = level.NewLoader(strings.TrimSuffix(filepath.Base(arg), ".tmx")).FromFile(arg)
+	} else {
+		loader = level.NewLoader(arg)
+	}
+	// Checkpoint locations are derived data; the checkpoint graph is checked below instead.
+	lvl, err := loader.SkipCheckpointLocations(true).ReportProblems(c.loaderProblem).Load()
+	if err != nil {
+		c.report("load", "%v", err)
+		return c.problems
+	}
+	c.checkPlayer(lvl)
+	c.checkResources(lvl)
+	c.checkCheckpoints(lvl)
+	c.checkSaveGameVersion(lvl, versions)
+	return c.problems
+}
+
+func main() {
+	log.Debugf("initializing VFS...")
+	err := vfs.Init()
+	if err != nil {
+		log.Fatalf("could not initialize VFS: %v", err)
+	}
+	log.Debugf("parsing flags...")
+	flag.Parse(flag.NoConfig)
+	maps := flag.Args()
+	if len(maps) == 0 {
+		maps = []string{"level"}
+	}
+	problems := []Problem{}
+	versions := map[int][]string{}
+	for _, arg := range maps {
+		log.Debugf("checking %v...", arg)
+		problems = append(problems, checkMap(arg, versions)...)
+	}
+	if len(versions) > 1 {
+		var parts []string
+		for v, names := range versions {
+			parts = append(parts, fmt.Sprintf("%d (%s)", v, strings.Join(names, ", ")))
+		}
+		sort.Strings(parts)
+		problems = append(problems, Problem{
+			Check:   "save_game_version",
+			Message: fmt.Sprintf("maps disagree on save_game_version: %s", strings.Join(parts, "; ")),
+		})
+	}
+	if *jsonOutput {
+		j := json.NewEncoder(os.Stdout)
+		j.SetIndent("", "\t")
+		err = j.Encode(problems)
+		if err != nil {
+			log.Fatalf("could not write problems: %v", err)
+		}
+	} else {
+		for _, p := range problems {
+			fmt.Printf("%s: %s: %s\n", p.Map, p.Check, p.Message)
+		}
+	}
+	log.Debugf("done.")
+	if len(problems) != 0 {
+		os.Exit(1)
+	}
+}
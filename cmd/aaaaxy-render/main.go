@@ -0,0 +1,156 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// aaaaxy-render renders demo files to video files without playing them in real time.
+//
+// Arguments are paths to demo files. Each demo is played back by a separate
+// aaaaxy process that dumps through the regular -dump_media pipeline, and
+// several of these processes run at the same time. The screen filter is
+// applied by FFmpeg and directly outputs the requested video size.
+//
+// This is not a headless renderer: the game still opens a window and needs a
+// display to render to. On a server without one, the tool runs the game using
+// xvfb-run if DISPLAY is not set.
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+var (
+	binary             = flag.String("binary", "", "path to the aaaaxy binary; by default, the one next to this tool or in PATH is used")
+	outputDir          = flag.String("output_dir", ".", "directory to write the videos to; each video is named like its demo file")
+	outputExt          = flag.String("output_ext", ".mkv", "file extension of the videos, which selects the container format")
+	screenFilter       = flag.String("screen_filter", "linear2xcrt", "screen filter to render with; see the same flag of aaaaxy")
+	videoSize          = flag.String("video_size", "", "if set, the screen filter outputs video of this size, given as WIDTHxHEIGHT")
+	videoCodecSettings = flag.String("video_codec_settings", "-codec:v mjpeg -q:v 4", "FFmpeg settings for video encoding")
+	jobs               = flag.Int("jobs", runtime.NumCPU(), "number of demos to render at the same time")
+	xvfb               = flag.Bool("xvfb", true, "run the game using xvfb-run if there is no display")
+)
+
+// findBinary returns the path of the game binary.
+func findBinary() (string, error) {
+	if *binary != "" {
+		return *binary, nil
+	}
+	name := "aaaaxy"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	self, err := os.Executable()
+	if err == nil {
+		candidate := filepath.Join(filepath.Dir(self), name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return exec.LookPath(name)
+}
+
+// renderCommand returns the command line that renders the given demo to the given video file.
+func renderCommand(game, demo, output string) []string {
+	cmd := []string{
+		game,
+		"-batch",
+		"-load_config=false",
+		"-save_config=false",
+		"-fullscreen=false",
+		"-vsync=false",
+		"-demo_play=" + demo,
+		"-dump_media=" + output,
+		"-dump_video_codec_settings=" + *videoCodecSettings,
+		"-dump_video_size=" + *videoSize,
+		"-screen_filter=" + *screenFilter,
+	}
+	if *xvfb && runtime.GOOS == "linux" && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		if xvfbRun, err := exec.LookPath("xvfb-run"); err == nil {
+			cmd = append([]string{xvfbRun, "-a"}, cmd...)
+		}
+	}
+	return cmd
+}
+
+// render renders a single demo, writing the game's output to a log file next to the video.
+func render(game, demo string) error {
+	base := strings.TrimSuffix(filepath.Base(demo), filepath.Ext(demo))
+	output := filepath.Join(*outputDir, base+*outputExt)
+	logFile, err := os.Create(filepath.Join(*outputDir, base+".log"))
+	if err != nil {
+		return fmt.Errorf("could not create log file: %w", err)
+	}
+	defer logFile.Close()
+	cmdLine := renderCommand(game, demo, output)
+	log.Infof("rendering %v to %v", demo, output)
+	cmd := exec.Command(cmdLine[0], cmdLine[1:]...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("could not render %v (see %v): %w", demo, logFile.Name(), err)
+	}
+	return nil
+}
+
+func main() {
+	flag.Parse(flag.NoConfig)
+	demos := flag.Args()
+	if len(demos) == 0 {
+		log.Fatalf("usage: %v [flags] demo.dem...", os.Args[0])
+	}
+	game, err := findBinary()
+	if err != nil {
+		log.Fatalf("could not find the aaaaxy binary; please pass -binary: %v", err)
+	}
+	err = os.MkdirAll(*outputDir, 0o755)
+	if err != nil {
+		log.Fatalf("could not create output directory: %v", err)
+	}
+	todo := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := 0
+	for i := 0; i < max(*jobs, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for demo := range todo {
+				err := render(game, demo)
+				if err != nil {
+					log.Errorf("%v", err)
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, demo := range demos {
+		todo <- demo
+	}
+	close(todo)
+	wg.Wait()
+	if failed > 0 {
+		log.Fatalf("%d of %d demos failed to render", failed, len(demos))
+	}
+	log.Infof("rendered %d demos", len(demos))
+}
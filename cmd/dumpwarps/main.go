@@ -0,0 +1,156 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// dumpwarps lists the orientations warpzones apply in a map, and which img.<orientation>
+// tile images are used when looking through them.
+//
+// Tiles with img.<orientation> properties are shown with their alternate image
+// when seen through a warp whose transform matches; otherwise the default image
+// is rotated. This tool helps finding tiles that are missing an image for some
+// transform, or have images for transforms that never happen.
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	mapName = flag.String("map", "level", "name of the map to inspect")
+)
+
+type warpKey struct {
+	name      string
+	transform m.Orientation
+}
+
+type imageKey struct {
+	imageSrc    string
+	orientation m.Orientation
+}
+
+func main() {
+	log.Debugf("initializing VFS...")
+	err := vfs.Init()
+	if err != nil {
+		log.Fatalf("could not initialize VFS: %v", err)
+	}
+	log.Debugf("parsing flags...")
+	flag.Parse(flag.NoConfig)
+	log.Debugf("loading level...")
+	lvl, err := level.NewLoader(*mapName).SkipCheckpointLocations(true).Load()
+	if err != nil {
+		log.Fatalf("could not load level: %v", err)
+	}
+
+	log.Debugf("collecting warpzones and tiles...")
+	warpTiles := map[warpKey][]m.Pos{}
+	images := map[imageKey]map[m.Orientation]string{}
+	lvl.ForEachTile(func(pos m.Pos, t *level.LevelTile) {
+		if !t.Valid {
+			return
+		}
+		for _, w := range t.WarpZones {
+			k := warpKey{name: w.Name, transform: w.Transform}
+			warpTiles[k] = append(warpTiles[k], pos)
+		}
+		if byO := t.Tile.ImageSrcByOrientation(); len(byO) != 0 {
+			images[imageKey{imageSrc: t.Tile.ImageSrc, orientation: t.Tile.Orientation}] = byO
+		}
+	})
+
+	log.Debugf("listing warpzones...")
+	warps := make([]warpKey, 0, len(warpTiles))
+	for k := range warpTiles {
+		warps = append(warps, k)
+	}
+	sort.Slice(warps, func(a, b int) bool {
+		if warps[a].name != warps[b].name {
+			return warps[a].name < warps[b].name
+		}
+		return warps[a].transform.String() < warps[b].transform.String()
+	})
+	fmt.Printf("warpzones:\n")
+	for _, k := range warps {
+		tiles := warpTiles[k]
+		fmt.Printf("  %s: transform %v (%s) on %d tiles, first at %v\n", k.name, k.transform, k.transform.Name(), len(tiles), tiles[0])
+	}
+
+	// Looking through several warps in a row composes their transforms.
+	log.Debugf("composing transforms...")
+	reachable := map[m.Orientation]bool{m.Identity(): true}
+	for changed := true; changed; {
+		changed = false
+		for o := range reachable {
+			for _, k := range warps {
+				c := o.Concat(k.transform)
+				if !reachable[c] {
+					reachable[c] = true
+					changed = true
+				}
+			}
+		}
+	}
+	var transforms m.Orientations
+	for _, o := range m.AllOrientations {
+		if reachable[o] {
+			transforms = append(transforms, o)
+		}
+	}
+	fmt.Printf("reachable transforms:")
+	for _, o := range transforms {
+		fmt.Printf(" %v (%s)", o, o.Name())
+	}
+	fmt.Printf("\n")
+
+	log.Debugf("checking tile images...")
+	keys := make([]imageKey, 0, len(images))
+	for k := range images {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].imageSrc != keys[b].imageSrc {
+			return keys[a].imageSrc < keys[b].imageSrc
+		}
+		return keys[a].orientation.String() < keys[b].orientation.String()
+	})
+	fmt.Printf("tiles with img.<orientation> images:\n")
+	for _, k := range keys {
+		byO := images[k]
+		fmt.Printf("  %s at orientation %v:\n", k.imageSrc, k.orientation)
+		used := map[m.Orientation]bool{}
+		for _, transform := range transforms {
+			src, o := level.ResolveImage(transform, k.orientation, k.imageSrc, byO)
+			// Same math as in ResolveImage, to report what was looked up.
+			sprite := transform.Concat(k.orientation).Inverse().Concat(k.orientation)
+			if _, found := byO[sprite]; found {
+				used[sprite] = true
+				fmt.Printf("    through %v: img.%v = %s, drawn at %v\n", transform, sprite, src, o)
+			} else {
+				fmt.Printf("    through %v: no img.%v, rotating %s to %v\n", transform, sprite, src, o)
+			}
+		}
+		for _, o := range m.AllOrientations {
+			if src, found := byO[o]; found && !used[o] {
+				fmt.Printf("    img.%v = %s is never used\n", o, src)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aaaaxy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/divVerent/aaaaxy/internal/exitstatus"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/timing"
+)
+
+var (
+	benchmarkFrames = flag.Int("benchmark", 0, "if nonzero, run this many frames of world updates (including visibility computation and the CPU side of rendering) without opening a window or audio device, then print timing statistics as JSON to stdout; combine with -demo_play for reproducible input")
+)
+
+// Benchmarking returns whether a headless benchmark was requested.
+func Benchmarking() bool {
+	return *benchmarkFrames > 0
+}
+
+// RunBenchmark initializes the game without a window and runs the benchmark.
+// Rendering is limited to its CPU side, i.e. building the vertices of the
+// tiles, entities and visibility polygon; no draw calls are issued.
+func (g *Game) RunBenchmark() error {
+	err := flag.Set("audio", false)
+	if err != nil {
+		return fmt.Errorf("could not disable audio: %w", err)
+	}
+	err = g.InitEarly()
+	if err != nil {
+		return err
+	}
+	err = g.InitFull()
+	if err != nil {
+		return err
+	}
+	log.Infof("running benchmark for %d frames", *benchmarkFrames)
+	timing.StartBenchmark()
+	frames := 0
+	for frames < *benchmarkFrames {
+		err := g.benchmarkFrame()
+		if errors.Is(err, exitstatus.ErrRegularTermination) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		frames++
+	}
+	timing.Update()
+	log.Infof("benchmark finished after %d frames", frames)
+	return timing.WriteReportJSON(os.Stdout)
+}
+
+// benchmarkFrame runs a single frame the same way Update does.
+func (g *Game) benchmarkFrame() error {
+	timing.Update()
+
+	defer timing.Group()()
+	timing.Section("update")
+	err := func() error {
+		defer timing.Group()()
+		return g.updateFrame()
+	}()
+	if err != nil {
+		return err
+	}
+
+	timing.Section("render_prepare")
+	if g.Menu.World.Initialized() {
+		g.Menu.World.PrepareDraw()
+	}
+	return nil
+}
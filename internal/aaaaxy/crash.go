@@ -0,0 +1,97 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aaaaxy
+
+import (
+	"encoding/json"
+	"errors"
+	"runtime"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/version"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// crashReport is written to the config directory when the game crashes.
+type crashReport struct {
+	Time     string
+	Reason   string
+	Stack    string
+	Version  string
+	Platform string
+	Flags    map[string]string
+	Log      []log.Entry
+	DemoTail []json.RawMessage `json:",omitempty"`
+	SaveGame *level.SaveGame   `json:",omitempty"`
+}
+
+// saveGameForCrash returns the current save game, if the world is in a state where it can be saved.
+func (g *Game) saveGameForCrash() (save *level.SaveGame) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("could not include save game in crash report: %v", r)
+			save = nil
+		}
+	}()
+	if !g.Menu.World.Initialized() {
+		return nil
+	}
+	save, err := g.Menu.World.Level.SaveGame()
+	if err != nil {
+		log.Errorf("could not include save game in crash report: %v", err)
+		return nil
+	}
+	return save
+}
+
+// WriteCrashReport writes a report about a crash with the given reason and stack trace.
+// Returns the path of the report.
+func (g *Game) WriteCrashReport(reason string, stack []byte) (string, error) {
+	if demo.Playing() {
+		// Demo playback must not write anything.
+		return "", errors.New("not writing crash report during demo playback")
+	}
+	now := time.Now().UTC()
+	report := crashReport{
+		Time:     now.Format(time.RFC3339),
+		Reason:   reason,
+		Stack:    string(stack),
+		Version:  version.Revision(),
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+		Flags:    flag.Sanitized(),
+		Log:      log.Entries(),
+		DemoTail: demo.Tail(),
+		SaveGame: g.saveGameForCrash(),
+	}
+	data, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return "", err
+	}
+	name := now.Format("crashes/crash-2006-01-02T15-04-05Z.json")
+	err = vfs.WriteState(vfs.Config, name, data)
+	if err != nil {
+		return "", err
+	}
+	path, err := vfs.StatePath(vfs.Config, name)
+	if err != nil {
+		// The report got written, we just can't tell where.
+		return name, nil
+	}
+	return path, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aaaaxy
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/dump"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/fun"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+var (
+	dumpOverlayTimer  = flag.Bool("dump_overlay_timer", false, "when dumping, burn the speedrun timer into the video")
+	dumpOverlaySplits = flag.Int("dump_overlay_splits", 0, "when dumping, burn this many of the most recent checkpoint splits into the video")
+	dumpOverlayInput  = flag.Bool("dump_overlay_input", false, "when dumping, burn the input display into the video")
+)
+
+const (
+	dumpOverlayX          = 4
+	dumpOverlayY          = 4
+	dumpOverlayLineHeight = 10
+)
+
+// dumpSplit is the time at which a checkpoint was reached.
+type dumpSplit struct {
+	checkpoint string
+	frames     int // Game time when reaching the checkpoint.
+	segment    int // Game time since the previous split.
+}
+
+// dumpOverlay draws the timer and checkpoint splits into dumped video frames,
+// so verification videos need no post-editing.
+// It is drawn into the game image, so the screen filter applies to it too.
+type dumpOverlay struct {
+	started        bool
+	lastCheckpoint string
+	splits         []dumpSplit
+}
+
+// update records a split whenever a new checkpoint is reached.
+// Must be called after every game tick.
+func (o *dumpOverlay) update(w *engine.World) {
+	if !dump.Active() || *dumpOverlaySplits <= 0 {
+		return
+	}
+	ps := &w.PlayerState
+	frames := ps.Frames()
+	if n := len(o.splits); n > 0 && frames < o.splits[n-1].frames {
+		// A different save game was loaded.
+		o.splits = nil
+	}
+	cp := ps.LastCheckpoint()
+	if !o.started {
+		// Do not count the checkpoint the run started at.
+		o.started = true
+		o.lastCheckpoint = cp
+		return
+	}
+	if cp == o.lastCheckpoint {
+		return
+	}
+	o.lastCheckpoint = cp
+	if cp == "" || !w.TimerStarted {
+		return
+	}
+	prev := 0
+	if n := len(o.splits); n > 0 {
+		prev = o.splits[n-1].frames
+	}
+	name := cp
+	if sp := w.Level.Checkpoints[cp]; sp != nil {
+		text := propmap.StringOr(sp.Properties, "text", cp)
+		name = strings.ReplaceAll(fun.FormatText(ps, text), "\n", " ")
+	}
+	o.splits = append(o.splits, dumpSplit{
+		checkpoint: name,
+		frames:     frames,
+		segment:    frames - prev,
+	})
+	if len(o.splits) > *dumpOverlaySplits {
+		o.splits = o.splits[len(o.splits)-*dumpOverlaySplits:]
+	}
+}
+
+// draw draws the timer and the splits at the bottom right, newest split at the bottom.
+func (o *dumpOverlay) draw(screen *ebiten.Image, w *engine.World) {
+	if !dump.Active() {
+		return
+	}
+	fg := palette.EGA(palette.White, 255)
+	bg := palette.EGA(palette.Black, 255)
+	f := font.ByName["Small"]
+	x := engine.GameWidth - dumpOverlayX
+	y := engine.GameHeight - dumpOverlayY
+	if *dumpOverlayTimer {
+		f.Draw(screen, fun.FormatGameTime(w.PlayerState.Frames()), m.Pos{X: x, Y: y}, font.Right, fg, bg)
+		y -= dumpOverlayLineHeight
+	}
+	if *dumpOverlaySplits > 0 {
+		for i := len(o.splits) - 1; i >= 0; i-- {
+			s := &o.splits[i]
+			f.Draw(screen,
+				locale.G.Get("%s: %s (+%s)", s.checkpoint, fun.FormatGameTime(s.frames), fun.FormatGameTime(s.segment)),
+				m.Pos{X: x, Y: y}, font.Right, fg, bg)
+			y -= dumpOverlayLineHeight
+		}
+	}
+}
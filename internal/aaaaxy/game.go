@@ -30,6 +30,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/audiowrap"
 	"github.com/divVerent/aaaaxy/internal/demo"
 	"github.com/divVerent/aaaaxy/internal/dump"
+	"github.com/divVerent/aaaaxy/internal/effects"
 	"github.com/divVerent/aaaaxy/internal/engine"
 	"github.com/divVerent/aaaaxy/internal/exitstatus"
 	"github.com/divVerent/aaaaxy/internal/flag"
@@ -45,7 +46,11 @@ import (
 	"github.com/divVerent/aaaaxy/internal/noise"
 	"github.com/divVerent/aaaaxy/internal/offscreen"
 	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/platform"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+	"github.com/divVerent/aaaaxy/internal/quality"
 	"github.com/divVerent/aaaaxy/internal/shader"
+	"github.com/divVerent/aaaaxy/internal/telemetry"
 	"github.com/divVerent/aaaaxy/internal/timing"
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
@@ -55,10 +60,15 @@ var (
 		"android/*": "linear2x",
 		"js/*":      "linear2x",
 		"*/*":       "linear2xcrt",
-	}), "filter to use for rendering the screen; current possible values are 'nearest', 'linear', 'linear2x' and 'linear2xcrt'")
+	}), "filter to use for rendering the screen; current possible values are 'nearest', 'linear', 'linear2x' and 'linear2xcrt', or the name of a user filter <name>.kage in the filters directory of the config folder")
 	screenFilterScanLines   = flag.Float64("screen_filter_scan_lines", 0.1, "strength of the scan line effect in the linear2xcrt filters")
 	screenFilterCRTStrength = flag.Float64("screen_filter_crt_strength", 0.5, "strength of CRT deformation in the linear2xcrt filters")
+	screenFilterCRTK1       = flag.Float64("screen_filter_crt_k1", -1, "if not negative, k1 parameter of CRT deformation in the linear2xcrt filters (as in FFmpeg lenscorrection); overrides screen_filter_crt_strength")
+	screenFilterCRTK2       = flag.Float64("screen_filter_crt_k2", -1, "if not negative, k2 parameter of CRT deformation in the linear2xcrt filters (as in FFmpeg lenscorrection); overrides screen_filter_crt_strength")
+	screenFilterVignette    = flag.Float64("screen_filter_vignette", 0, "strength of darkening towards the corners in the linear2xcrt filters")
+	screenFilterMask        = flag.String("screen_filter_mask", "none", "phosphor mask to simulate in the linear2xcrt filters; current possible values are 'none' and 'aperture'")
 	screenStretch           = flag.Bool("screen_stretch", false, "stretch screen content instead of letterboxing")
+	screenIntegerScaling    = flag.Bool("screen_integer_scaling", false, "only scale screen content by integer factors when letterboxing; ignored if screen_stretch is set")
 	paletteFlag             = flag.String("palette", flag.SystemDefault(map[string]string{
 		"android/*": "none",
 		"js/*":      "none",
@@ -68,11 +78,13 @@ var (
 	paletteRemapColors           = flag.Bool("palette_remap_colors", true, "remap input colors to close palette colors on load (less dither but wrong colors)")
 	paletteDitherSize            = flag.Int("palette_dither_size", 4, "dither pattern size (really should be a power of two when using the bayer dither mode)")
 	paletteDitherMode            = flag.String("palette_dither_mode", "plastic2", "dither type (none, bayer, bayer2, checker, checker2, diamond, diamond2, halftone, halftone2, hybrid, hybrid2, plastic, plastic2, random, random2, square or square2)")
+	paletteSingleLUT             = flag.Bool("palette_single_lut", false, "use a single color palette LUT even in the two-color dither modes (faster, but more banding)")
 	paletteDitherWorldAligned    = flag.Bool("palette_dither_world_aligned", true, "align dither pattern to world as opposed to screen")
 	debugEnableDrawing           = flag.Bool("debug_enable_drawing", true, "enable drawing the display; set to false for faster demo processing or similar")
 	showFPS                      = flag.Bool("show_fps", false, "show fps counter")
 	showTime                     = flag.Bool("show_time", false, "show game time")
 	showPos                      = flag.Bool("show_pos", false, "show player position")
+	showInput                    = flag.Bool("show_input", false, "show current input state; during demo playback, shows the recorded input")
 	debugLoadingScreenCpuprofile = flag.String("debug_loading_screen_cpuprofile", "", "write CPU profile of loading screen to file")
 	debugShowGC                  = flag.Bool("debug_show_gc", false, "show garbage collector pause info")
 )
@@ -110,6 +122,12 @@ type Game struct {
 	screenWidth  int
 	screenHeight int
 
+	// integerScaleZoom is how much the integer scaled screen was shrunk compared to letterboxing; updated by DrawFinalScreen().
+	integerScaleZoom float64
+
+	// deviceScaleFactor is the device scale factor the window was sized for; zero if the window size is not managed.
+	deviceScaleFactor float64
+
 	offscreenTokens   chan int
 	offscreenReturns  chan *ebiten.Image
 	offscreenIndexes  map[*ebiten.Image]int
@@ -130,7 +148,22 @@ type Game struct {
 
 	framesToDump int
 
+	tas tasState
+
+	perfHUD perfHUD
+
+	dumpOverlay dumpOverlay
+
 	debugLoadingScreenCpuprofileF io.WriteCloser
+
+	// tps is the tick rate last passed to Ebitengine by updateTPS.
+	tps int
+
+	// lastDraw is when the previous frame was drawn, for measuring frame times.
+	lastDraw time.Time
+
+	// lastUpdate is when the game last ticked, for render interpolation.
+	lastUpdate time.Time
 }
 
 var _ ebiten.Game = &Game{}
@@ -143,7 +176,7 @@ func NewGame() *Game {
 
 func (g *Game) updateFrame() error {
 	timing.Section("input")
-	input.Update(g.screenWidth, g.screenHeight, engine.GameWidth, engine.GameHeight, crtK1(), crtK2())
+	input.Update(g.screenWidth, g.screenHeight, engine.GameWidth, engine.GameHeight, g.pointerZoom(), crtK1(), crtK2())
 
 	timing.Section("demo_pre")
 	if demo.Update() {
@@ -170,6 +203,14 @@ func (g *Game) updateFrame() error {
 		return err
 	}
 
+	timing.Section("effects")
+	effects.Update()
+
+	if g.Menu.World.Initialized() {
+		timing.Section("platform")
+		platform.Update(&g.Menu.World.PlayerState)
+	}
+
 	// As the world's Update method may change the sound system info,
 	// run this part last to reduce sound latency.
 
@@ -187,6 +228,8 @@ func (g *Game) Update() error {
 		return nil
 	}
 
+	g.checkDeviceScaleFactor()
+
 	if !g.init.done {
 		if !g.canInit {
 			return nil
@@ -215,15 +258,22 @@ func (g *Game) Update() error {
 	}
 	g.canDraw = true
 
+	if !g.tas.update() {
+		return nil
+	}
+
 	g.framesToDump++
 
 	timing.Update()
+	g.perfHUD.update()
 
 	defer timing.Group()()
 	timing.Section("update")
 
 	defer timing.Group()()
 
+	g.updateTPS()
+
 	for frame := 0; frame < *fpsDivisor; frame++ {
 		if err := g.updateFrame(); err != nil {
 			if errors.Is(err, exitstatus.ErrRegularTermination) {
@@ -233,11 +283,42 @@ func (g *Game) Update() error {
 			}
 			return err
 		}
+		g.dumpOverlay.update(&g.Menu.World)
 	}
+	g.lastUpdate = time.Now()
 
 	return nil
 }
 
+// updateTPS adjusts the tick rate according to the game speed and modifiers while playing.
+// Menus always run at normal speed.
+func (g *Game) updateTPS() {
+	if dump.Slow() {
+		// Already running one tick per frame; the dumped audio follows game time anyway,
+		// but live audio can follow the actual speed the dump runs at.
+		audiowrap.SetSpeed(ebiten.ActualTPS() * float64(*fpsDivisor) / engine.GameTPS)
+		return
+	}
+	if demo.Timedemo() {
+		// Already running one tick per frame.
+		return
+	}
+	speed := 1.0
+	if g.Menu.Screen == nil {
+		speed = engine.GameSpeed()
+		if g.Menu.World.Initialized() && g.Menu.World.PlayerState.Modifiers().ContainAll(playerstate.DoubleSpeedModifier) {
+			speed *= 2
+		}
+	}
+	audiowrap.SetSpeed(speed)
+	tps := m.Rint(float64(engine.GameTPS) * speed / float64(*fpsDivisor))
+	if tps == g.tps {
+		return
+	}
+	ebiten.SetTPS(tps)
+	g.tps = tps
+}
+
 func (g *Game) palettePrepare(maybeScreen *ebiten.Image, tmp *ebiten.Image) (*ebiten.Image, func() *ebiten.Image) {
 	// This is an extra pass so it can still run at low-res.
 	pal := palette.ByName(*paletteFlag)
@@ -311,6 +392,28 @@ func (g *Game) palettePrepare(maybeScreen *ebiten.Image, tmp *ebiten.Image) (*eb
 		ditherMode = bayerDither
 	}
 
+	if *paletteSingleLUT {
+		// Two-color modes need twice the LUT lookups; use their single color counterparts.
+		switch ditherMode {
+		case bayer2Dither:
+			ditherMode = bayerDither
+		case checker2Dither:
+			ditherMode = checkerDither
+		case diamond2Dither:
+			ditherMode = diamondDither
+		case halftone2Dither:
+			ditherMode = halftoneDither
+		case hybrid2Dither:
+			ditherMode = hybridDither
+		case plastic2Dither:
+			ditherMode = plasticDither
+		case random2Dither:
+			ditherMode = randomDither
+		case square2Dither:
+			ditherMode = squareDither
+		}
+	}
+
 	// Need images?
 	if g.paletteLUT == nil {
 		g.paletteLUT = ebiten.NewImage(engine.GameWidth, engine.GameHeight)
@@ -437,7 +540,7 @@ func (g *Game) drawAtGameSizeThenReturnTo(maybeScreen *ebiten.Image, to chan *eb
 	drawDest, finishDrawing := g.palettePrepare(maybeScreen, tmp)
 
 	if drawDest.Bounds() != go_image.Rect(0, 0, engine.GameWidth, engine.GameHeight) {
-		log.Infof("skipping frame as sizes do not match up: got %vx%v, want %vx%v",
+		log.Infof("skipping frame as sizes do not match up: got %v, want %vx%v",
 			drawDest.Bounds(), engine.GameWidth, engine.GameHeight)
 		screen := finishDrawing()
 		to <- screen
@@ -500,6 +603,18 @@ func (g *Game) drawAtGameSizeThenReturnTo(maybeScreen *ebiten.Image, to chan *eb
 			m.Pos{X: 0, Y: engine.GameHeight - 4}, font.Left,
 			palette.EGA(palette.White, 255), palette.EGA(palette.Black, 255))
 	}
+	if *showInput || demo.Attracting() || (dump.Active() && *dumpOverlayInput) {
+		timing.Section("input")
+		input.DrawOverlay(drawDest, m.Pos{X: 4, Y: engine.GameHeight - 52})
+	}
+	if *tasFlag {
+		timing.Section("tas")
+		g.tas.draw(drawDest, g.Menu.World.Player)
+	}
+	if *debugPerfHUD {
+		timing.Section("perf_hud")
+		g.perfHUD.draw(drawDest, &g.Menu.World)
+	}
 	if *debugShowGC {
 		timing.Section("gc")
 		now := time.Now()
@@ -517,6 +632,9 @@ func (g *Game) drawAtGameSizeThenReturnTo(maybeScreen *ebiten.Image, to chan *eb
 		}
 	}
 
+	timing.Section("dump_overlay")
+	g.dumpOverlay.draw(drawDest, &g.Menu.World)
+
 	timing.Section("demo_postdraw")
 	demo.PostDraw(drawDest)
 
@@ -564,6 +682,9 @@ func crtK1() float64 {
 	if *screenFilter != "linear2xcrt" {
 		return 0
 	}
+	if *screenFilterCRTK1 >= 0 {
+		return *screenFilterCRTK1
+	}
 	return 1.0 / 6.0 * math.Pow(*screenFilterCRTStrength, 2)
 }
 
@@ -571,9 +692,26 @@ func crtK2() float64 {
 	if *screenFilter != "linear2xcrt" {
 		return 0
 	}
+	if *screenFilterCRTK2 >= 0 {
+		return *screenFilterCRTK2
+	}
 	return 3.0 / 40.0 * math.Pow(*screenFilterCRTStrength, 4)
 }
 
+// crtApertureMask returns the strength of the aperture grille effect.
+func crtApertureMask() float64 {
+	switch *screenFilterMask {
+	case "none":
+		return 0
+	case "aperture":
+		return 1
+	default:
+		log.Errorf("unknown screen filter mask: %q; reverted to none", *screenFilterMask)
+		*screenFilterMask = "none"
+		return 0
+	}
+}
+
 func assertOrigin(img ebiten.FinalScreen) {
 	if img.Bounds().Min != (go_image.Point{}) {
 		log.Fatalf("did not get zero origin: %v", img.Bounds())
@@ -616,6 +754,10 @@ DoneDisposing:
 		return
 	}
 
+	quality.Frame()
+	g.recordFrameTime()
+	g.updateRenderFraction()
+
 	if !dump.Active() {
 		// No offscreen needed. Just render.
 		g.drawAtGameSizeThenReturnTo(screen, make(chan *ebiten.Image, 1), nil)
@@ -636,12 +778,35 @@ DoneDisposing:
 	screen.DrawImage(srcImage, options)
 }
 
+// updateRenderFraction tells the world how far into the current tick this frame is drawn.
+// Dumps and timedemos draw exactly one frame per tick, so they never interpolate.
+func (g *Game) updateRenderFraction() {
+	if !engine.RenderInterpolation() || dump.Active() || demo.Timedemo() || *fpsDivisor != 1 || g.tps <= 0 {
+		g.Menu.World.SetRenderFraction(1)
+		return
+	}
+	g.Menu.World.SetRenderFraction(time.Since(g.lastUpdate).Seconds() * float64(g.tps))
+}
+
+// recordFrameTime passes the time since the previous frame to telemetry.
+func (g *Game) recordFrameTime() {
+	if !telemetry.Enabled() {
+		return
+	}
+	now := time.Now()
+	if !g.lastDraw.IsZero() {
+		telemetry.RecordFrame(now.Sub(g.lastDraw))
+	}
+	g.lastDraw = now
+}
+
 func (g *Game) DrawFinalScreen(screen ebiten.FinalScreen, offscreen *ebiten.Image, geoM ebiten.GeoM) {
 	defer timing.Group()()
 	timing.Section("drawfinal")
 	defer timing.Group()()
 
 	assertOrigin(screen)
+	telemetry.SetResolution(screen.Bounds().Dx(), screen.Bounds().Dy())
 	offscreen = ensureRect(offscreen, go_image.Rect(0, 0, engine.GameWidth, engine.GameHeight))
 
 	if *screenStretch {
@@ -655,6 +820,19 @@ func (g *Game) DrawFinalScreen(screen ebiten.FinalScreen, offscreen *ebiten.Imag
 		fh := float64(sh) / float64(engine.GameHeight)
 		geoM.Reset()
 		geoM.Scale(fw, fh)
+	} else if *screenIntegerScaling {
+		// Works in device pixels, so this stays sharp when the device scale factor changes.
+		ssz := screen.Bounds().Size()
+		sw, sh := ssz.X, ssz.Y
+		fit := math.Min(float64(sw)/float64(engine.GameWidth), float64(sh)/float64(engine.GameHeight))
+		f := math.Floor(fit)
+		g.integerScaleZoom = 1
+		if f >= 1 {
+			g.integerScaleZoom = fit / f
+			geoM.Reset()
+			geoM.Scale(f, f)
+			geoM.Translate(math.Floor((float64(sw)-f*float64(engine.GameWidth))/2), math.Floor((float64(sh)-f*float64(engine.GameHeight))/2))
+		}
 	}
 
 	switch *screenFilter {
@@ -721,14 +899,43 @@ func (g *Game) DrawFinalScreen(screen ebiten.FinalScreen, offscreen *ebiten.Imag
 				"ScanLineEffect": float32(*screenFilterScanLines * 2.0),
 				"CRTK1":          float32(crtK1()),
 				"CRTK2":          float32(crtK2()),
+				"Vignette":       float32(*screenFilterVignette),
+				"ApertureMask":   float32(crtApertureMask()),
 			},
 			GeoM: geoM,
 		}
 		screen.DrawRectShader(engine.GameWidth, engine.GameHeight, g.linear2xCRTShader, options)
 	default:
-		log.Errorf("unknown screen filter type: %q; reverted to simple", *screenFilter)
-		*screenFilter = "linear2x"
+		userShader, err := shader.LoadUserFilter(*screenFilter)
+		if err != nil {
+			log.Errorf("unknown screen filter type: %q; reverted to simple: %v", *screenFilter, err)
+			*screenFilter = "linear2x"
+			return
+		}
+		options := &ebiten.DrawRectShaderOptions{
+			Blend: ebiten.BlendCopy,
+			Images: [4]*ebiten.Image{
+				offscreen,
+				nil,
+				nil,
+				nil,
+			},
+			Uniforms: map[string]interface{}{
+				"ScanLineEffect": float32(*screenFilterScanLines * 2.0),
+				"CRTStrength":    float32(*screenFilterCRTStrength),
+			},
+			GeoM: geoM,
+		}
+		screen.DrawRectShader(engine.GameWidth, engine.GameHeight, userShader, options)
+	}
+}
+
+// pointerZoom returns how much larger the area pointer positions refer to is than the game display.
+func (g *Game) pointerZoom() float64 {
+	if *screenStretch || !*screenIntegerScaling || g.integerScaleZoom == 0 {
+		return 1
 	}
+	return g.integerScaleZoom
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
@@ -22,6 +22,7 @@ import (
 
 	"github.com/hajimehoshi/ebiten/v2"
 
+	_ "github.com/divVerent/aaaaxy/internal/ambient" // Registers the ambient sound player.
 	"github.com/divVerent/aaaaxy/internal/audiowrap"
 	"github.com/divVerent/aaaaxy/internal/credits"
 	"github.com/divVerent/aaaaxy/internal/demo"
@@ -32,15 +33,20 @@ import (
 	"github.com/divVerent/aaaaxy/internal/font"
 	"github.com/divVerent/aaaaxy/internal/image"
 	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/level"
 	"github.com/divVerent/aaaaxy/internal/locale"
 	"github.com/divVerent/aaaaxy/internal/locale/initlocale"
 	"github.com/divVerent/aaaaxy/internal/log"
 	m "github.com/divVerent/aaaaxy/internal/math"
 	"github.com/divVerent/aaaaxy/internal/noise"
 	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/platform"
+	_ "github.com/divVerent/aaaaxy/internal/platform/steam" // Registers the Steam integration in steam builds.
 	"github.com/divVerent/aaaaxy/internal/sound"
 	"github.com/divVerent/aaaaxy/internal/splash"
+	"github.com/divVerent/aaaaxy/internal/telemetry"
 	"github.com/divVerent/aaaaxy/internal/timing"
+	"github.com/divVerent/aaaaxy/internal/updatecheck"
 	"github.com/divVerent/aaaaxy/internal/version"
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
@@ -54,17 +60,27 @@ var (
 		"js/*": true,
 		"*/*":  false,
 	}), "keep running the game even when not focused")
-	dumpLoadingFractions = flag.String("dump_loading_fractions", "", "file name to dump actual loading fractions to")
-	debugJustInit        = flag.Bool("debug_just_init", false, "just init everything, then quit right away")
-	fpsDivisor           = flag.Int("fps_divisor", 1, "framerate divisor (use on very low systems, but this may make the game unwinnable or harder as it restricts input; must be a divisor of "+fmt.Sprint(engine.GameTPS))
-	debugGoGCPercent     = flag.Int("debug_go_gc_percent", 0, "if set, replaces the GOGC environment variable; roughly defines the GC overhead, with higher numbers meaning longer but fewer GC pauses and more memory usage, but lower CPU load")
+	dumpLoadingFractions    = flag.String("dump_loading_fractions", "", "file name to dump actual loading fractions to")
+	dumpCheckpointLocations = flag.String("dump_checkpoint_locations", "", "generate the checkpoint locations of the level, write them to the given file and exit")
+	logToFile               = flag.Bool("log_to_file", flag.SystemDefault(map[string]bool{
+		// Platforms where there usually is no console to see the log on.
+		"android/*": true,
+		"ios/*":     true,
+		"windows/*": true,
+		"*/*":       false,
+	}), "write a structured log to the cache directory")
+	debugJustInit    = flag.Bool("debug_just_init", false, "just init everything, then quit right away")
+	fpsDivisor       = flag.Int("fps_divisor", 1, "framerate divisor (use on very low systems, but this may make the game unwinnable or harder as it restricts input; must be a divisor of "+fmt.Sprint(engine.GameTPS))
+	debugGoGCPercent = flag.Int("debug_go_gc_percent", 0, "if set, replaces the GOGC environment variable; roughly defines the GC overhead, with higher numbers meaning longer but fewer GC pauses and more memory usage, but lower CPU load")
 )
 
 func LoadConfig() (*flag.Config, error) {
 	return engine.LoadConfig()
 }
 
-func setWindowSize() {
+// setWindowSize sets the window size to an integer multiple of the game size on the current monitor.
+// Returns the device scale factor it used.
+func setWindowSize() float64 {
 	logicalF := *windowScaleFactor
 	log.Infof("requested logical scale factor: %v", logicalF)
 	dscale := ebiten.Monitor().DeviceScaleFactor()
@@ -77,7 +93,7 @@ func setWindowSize() {
 		maxw, maxh := screenw-128, screenh-128
 		log.Infof("max size: %vx%v", maxw, maxh)
 		// Compute max scaling factors.
-		maxwf, maxhf := float64(maxw)*dscale/engine.GameWidth, float64(maxh)*dscale/engine.GameHeight
+		maxwf, maxhf := float64(maxw)*dscale/float64(engine.GameWidth), float64(maxh)*dscale/float64(engine.GameHeight)
 		log.Infof("max physical scale factors: %v, %v", maxwf, maxhf)
 		physicalF = math.Min(maxwf, maxhf)
 	} else {
@@ -93,9 +109,23 @@ func setWindowSize() {
 	// Convert back to logical scale factor as Ebitengine needs that.
 	logicalF = physicalF / dscale
 	log.Infof("chosen logical pixel scale factor: %v", logicalF)
-	w, h := m.Rint(engine.GameWidth*logicalF), m.Rint(engine.GameHeight*logicalF)
+	w, h := m.Rint(float64(engine.GameWidth)*logicalF), m.Rint(float64(engine.GameHeight)*logicalF)
 	log.Infof("chosen window size: %vx%v", w, h)
 	ebiten.SetWindowSize(w, h)
+	return dscale
+}
+
+// checkDeviceScaleFactor resizes the window when it got moved to a monitor with a different device scale factor,
+// so that game pixels keep mapping to an integer number of device pixels.
+func (g *Game) checkDeviceScaleFactor() {
+	if g.deviceScaleFactor == 0 || ebiten.IsFullscreen() {
+		return
+	}
+	if ebiten.Monitor().DeviceScaleFactor() == g.deviceScaleFactor {
+		return
+	}
+	log.Infof("device scale factor changed, resizing window")
+	g.deviceScaleFactor = setWindowSize()
 }
 
 // NOTE: This function only runs on desktop systems.
@@ -103,7 +133,12 @@ func setWindowSize() {
 func (g *Game) InitEbitengine() error {
 	ebiten.SetWindowDecorated(true)
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
-	setWindowSize()
+	// The window size depends on the game size.
+	err := engine.InitGameSize()
+	if err != nil {
+		return fmt.Errorf("could not initialize game size: %w", err)
+	}
+	g.deviceScaleFactor = setWindowSize()
 	return g.InitEarly()
 }
 
@@ -118,7 +153,10 @@ func (g *Game) InitEarly() error {
 		debug.SetGCPercent(*debugGoGCPercent)
 	}
 
-	ebiten.SetFullscreen(*fullscreen)
+	// Platform integrations may affect the window mode, so start them first.
+	platform.Init()
+
+	ebiten.SetFullscreen(*fullscreen || platform.ForceFullscreen())
 	ebiten.SetScreenClearedEveryFrame(false)
 	ebiten.SetVsyncEnabled(*vsync)
 	ebiten.SetWindowTitle("AAAAXY")
@@ -129,10 +167,23 @@ func (g *Game) InitEarly() error {
 	}
 
 	// Initialize some stuff that is needed early.
-	err := vfs.Init()
+	err := engine.InitGameSize()
+	if err != nil {
+		return fmt.Errorf("could not initialize game size: %w", err)
+	}
+	err = vfs.Init()
 	if err != nil {
 		return fmt.Errorf("could not initialize VFS: %w", err)
 	}
+	if *logToFile {
+		path, err := vfs.StatePath(vfs.Cache, "logs/aaaaxy.jsonl")
+		if err != nil {
+			log.Errorf("could not find where to write the log to: %v", err)
+		} else {
+			log.SetStructuredLogFile(path)
+			log.Infof("writing log to %v", path)
+		}
+	}
 	err = initlocale.Init()
 	if err != nil {
 		return fmt.Errorf("could not initialize locale: %w", err)
@@ -141,16 +192,36 @@ func (g *Game) InitEarly() error {
 	if err != nil {
 		return fmt.Errorf("could not initialize version: %w", err)
 	}
+	if *dumpCheckpointLocations != "" {
+		h, err := level.DumpCheckpointLocations("level", *dumpCheckpointLocations)
+		if err != nil {
+			return fmt.Errorf("could not dump checkpoint locations: %w", err)
+		}
+		fmt.Printf("checkpoint_locations_hash = %d\n", h)
+		return exitstatus.ErrRegularTermination
+	}
 	err = demo.Init()
 	if err != nil {
 		return fmt.Errorf("could not initialize demo: %w", err)
 	}
+	if demo.Playing() {
+		// The demo may have been recorded at a different game size.
+		err = engine.InitGameSize()
+		if err != nil {
+			return fmt.Errorf("could not initialize game size from demo: %w", err)
+		}
+		if g.deviceScaleFactor != 0 {
+			g.deviceScaleFactor = setWindowSize()
+		}
+	}
 	err = dump.InitEarly(dump.Params{
 		FPSDivisor:            *fpsDivisor,
 		ScreenFilter:          *screenFilter,
 		ScreenFilterScanLines: *screenFilterScanLines,
 		CRTK1:                 crtK1(),
 		CRTK2:                 crtK2(),
+		CRTVignette:           *screenFilterVignette,
+		CRTApertureMask:       crtApertureMask(),
 	})
 	if err != nil {
 		return fmt.Errorf("could not preinitialize dumping: %w", err)
@@ -167,7 +238,10 @@ func (g *Game) InitEarly() error {
 	}
 
 	// Pause when unfocused, except when recording demos.
-	ebiten.SetRunnableOnUnfocused(*runnableWhenUnfocused || (demo.Playing() && dump.Active()))
+	ebiten.SetRunnableOnUnfocused(*runnableWhenUnfocused || (demo.Playing() && dump.Active()) || platform.RunnableOnUnfocused())
+
+	// Look for a newer release while the game loads, if the player opted in.
+	updatecheck.Start()
 
 	log.Infof("finished early initialization")
 
@@ -284,5 +358,7 @@ func (g *Game) BeforeExit() error {
 	if err != nil {
 		return fmt.Errorf("could not finalize demo: %w", err)
 	}
+	platform.Shutdown()
+	telemetry.Finish()
 	return nil
 }
@@ -0,0 +1,172 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aaaaxy
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/timing"
+)
+
+var (
+	debugPerfHUD = flag.Bool("debug_perf_hud", false, "show a performance HUD with a graph of per-section frame times, entity counts and GC pauses")
+)
+
+const (
+	perfHUDX          = 4
+	perfHUDY          = 24
+	perfHUDHeight     = 64
+	perfHUDLineHeight = 9
+	// perfHUDFrameTime is the height of the graph, in time units.
+	perfHUDFrameTime = 2 * time.Second / engine.GameTPS
+)
+
+// perfHUDColors are the colors used for the sections of the graph, in order.
+var perfHUDColors = []palette.EGAIndex{
+	palette.LightRed,
+	palette.LightGreen,
+	palette.LightBlue,
+	palette.Yellow,
+	palette.LightCyan,
+	palette.LightMagenta,
+	palette.Brown,
+	palette.Green,
+	palette.Cyan,
+	palette.Red,
+	palette.Magenta,
+	palette.Blue,
+}
+
+// perfHUD is the state of the performance HUD.
+type perfHUD struct {
+	numGC    int64
+	gcFrames [timing.HistoryLength]bool
+	gcPos    int
+	gcStats  debug.GCStats
+	samples  []time.Duration
+	totals   []time.Duration
+}
+
+// perfHUDSections returns the sections to show: the direct children of update and draw.
+func perfHUDSections() []string {
+	var sections []string
+	for _, section := range timing.HistorySections() {
+		if strings.Count(section, "/") != 2 {
+			continue
+		}
+		if !strings.HasPrefix(section, "/update/") && !strings.HasPrefix(section, "/draw/") {
+			continue
+		}
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// update records the per-frame data of the performance HUD.
+func (h *perfHUD) update() {
+	timing.KeepHistory(*debugPerfHUD)
+	if !*debugPerfHUD {
+		return
+	}
+	debug.ReadGCStats(&h.gcStats)
+	h.gcPos = (h.gcPos + 1) % timing.HistoryLength
+	h.gcFrames[h.gcPos] = h.gcStats.NumGC != h.numGC
+	h.numGC = h.gcStats.NumGC
+}
+
+// draw draws the performance HUD.
+func (h *perfHUD) draw(screen *ebiten.Image, world *engine.World) {
+	if !*debugPerfHUD {
+		return
+	}
+	bg := palette.EGA(palette.Black, 160)
+	fg := palette.EGA(palette.White, 255)
+	ol := palette.EGA(palette.Black, 255)
+	vector.DrawFilledRect(screen, perfHUDX, perfHUDY, timing.HistoryLength, perfHUDHeight, bg, false)
+
+	// Stack the sections per frame.
+	sections := perfHUDSections()
+	if len(h.totals) != timing.HistoryLength {
+		h.totals = make([]time.Duration, timing.HistoryLength)
+	}
+	for i := range h.totals {
+		h.totals[i] = 0
+	}
+	legendY := perfHUDY + perfHUDLineHeight
+	for i, section := range sections {
+		c := palette.EGA(perfHUDColors[i%len(perfHUDColors)], 255)
+		h.samples = timing.History(section, h.samples)
+		var sum time.Duration
+		for x, d := range h.samples {
+			y0 := perfHUDHeight * h.totals[x] / perfHUDFrameTime
+			h.totals[x] += d
+			y1 := perfHUDHeight * h.totals[x] / perfHUDFrameTime
+			sum += d
+			if y0 >= perfHUDHeight || y1 == y0 {
+				continue
+			}
+			if y1 > perfHUDHeight {
+				y1 = perfHUDHeight
+			}
+			vector.DrawFilledRect(screen, float32(perfHUDX+x), float32(perfHUDY+perfHUDHeight-int(y1)), 1, float32(y1-y0), c, false)
+		}
+		if sum == 0 {
+			continue
+		}
+		avg := sum / timing.HistoryLength
+		font.ByName["DebugSmall"].Draw(screen,
+			fmt.Sprintf("%-20s %6.2fms", strings.TrimPrefix(section, "/"), avg.Seconds()*1000),
+			m.Pos{X: perfHUDX + timing.HistoryLength + 4, Y: legendY}, font.Left, c, ol)
+		legendY += perfHUDLineHeight
+	}
+
+	// Mark the frame time budget.
+	vector.DrawFilledRect(screen, perfHUDX, perfHUDY+perfHUDHeight/2, timing.HistoryLength, 1, palette.EGA(palette.LightGrey, 255), false)
+
+	// Mark GC passes.
+	gc := palette.EGA(palette.White, 255)
+	for i := 0; i < timing.HistoryLength; i++ {
+		if h.gcFrames[(h.gcPos+1+i)%timing.HistoryLength] {
+			vector.DrawFilledRect(screen, float32(perfHUDX+i), perfHUDY, 1, 4, gc, false)
+		}
+	}
+
+	// Text info below the graph.
+	y := perfHUDY + perfHUDHeight + perfHUDLineHeight
+	all, opaque := world.EntityCounts()
+	font.ByName["DebugSmall"].Draw(screen,
+		fmt.Sprintf("entities: %d (%d opaque)", all, opaque),
+		m.Pos{X: perfHUDX, Y: y}, font.Left, fg, ol)
+	y += perfHUDLineHeight
+	if len(h.gcStats.Pause) > 0 {
+		font.ByName["DebugSmall"].Draw(screen,
+			fmt.Sprintf("GC: %d passes, last pause %.2fms, total %.1fms",
+				h.gcStats.NumGC,
+				h.gcStats.Pause[0].Seconds()*1000,
+				h.gcStats.PauseTotal.Seconds()*1000),
+			m.Pos{X: perfHUDX, Y: y}, font.Left, fg, ol)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aaaaxy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/game/constants"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+var (
+	tasFlag            = flag.Bool("tas", false, "enable TAS tools: pausing, frame advance, slow motion and a frame/position/velocity readout; as this is not realtime play, saves using them are permanently reported as assisted (assist option)")
+	tasPauseKey        = flag.Text("tas_pause_key", ebiten.KeyF5, "key to pause or unpause the game when TAS tools are enabled")
+	tasFrameAdvanceKey = flag.Text("tas_frame_advance_key", ebiten.KeyF6, "key to advance by one frame while paused when TAS tools are enabled")
+	tasSlowdownKey     = flag.Text("tas_slowdown_key", ebiten.KeyF7, "key to cycle through the slowdown factors when TAS tools are enabled")
+	tasSlowdownFactors = flag.String("tas_slowdown_factors", "1 2 4 8", "space separated list of slowdown factors to cycle through when TAS tools are enabled")
+)
+
+func init() {
+	// An assist rather than a speedrun restriction, so turning it off again
+	// does not turn the run back into an unassisted one.
+	flag.MarkAssist("tas")
+	flag.RecordInDemo("tas")
+}
+
+// tasState is the state of the TAS tools.
+type tasState struct {
+	paused      bool
+	slowdownIdx int
+	slowdown    int
+	ticks       int
+	frames      int
+}
+
+// tasParseSlowdownFactors returns the configured slowdown factors.
+func tasParseSlowdownFactors() []int {
+	var factors []int
+	for _, f := range strings.Fields(*tasSlowdownFactors) {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 1 {
+			log.Errorf("invalid TAS slowdown factor %q; ignoring", f)
+			continue
+		}
+		factors = append(factors, n)
+	}
+	if len(factors) == 0 {
+		factors = []int{1}
+	}
+	return factors
+}
+
+// update handles the TAS hotkeys and returns whether the next game frame should run.
+func (t *tasState) update() bool {
+	if !*tasFlag {
+		return true
+	}
+	if t.slowdown == 0 {
+		t.slowdown = tasParseSlowdownFactors()[0]
+	}
+	if inpututil.IsKeyJustPressed(*tasPauseKey) {
+		t.paused = !t.paused
+	}
+	if inpututil.IsKeyJustPressed(*tasSlowdownKey) {
+		factors := tasParseSlowdownFactors()
+		t.slowdownIdx = (t.slowdownIdx + 1) % len(factors)
+		t.slowdown = factors[t.slowdownIdx]
+		t.ticks = 0
+	}
+	run := false
+	if t.paused {
+		run = inpututil.IsKeyJustPressed(*tasFrameAdvanceKey)
+	} else {
+		t.ticks++
+		if t.ticks >= t.slowdown {
+			t.ticks = 0
+			run = true
+		}
+	}
+	if run {
+		t.frames++
+	}
+	return run
+}
+
+// draw draws the TAS readout.
+func (t *tasState) draw(screen *ebiten.Image, player *engine.Entity) {
+	if !*tasFlag {
+		return
+	}
+	status := "running"
+	if t.paused {
+		status = "paused"
+	}
+	text := fmt.Sprintf("frame %d | %s | 1/%d speed", t.frames, status, t.slowdown)
+	if player != nil {
+		xi, yi, vxi, vyi := player.Impl.(engine.PlayerEntityImpl).DebugPos64()
+		x := float64(xi) / constants.SubPixelScale
+		y := float64(yi) / constants.SubPixelScale
+		vx := float64(vxi) / constants.SubPixelScale * engine.GameTPS
+		vy := float64(vyi) / constants.SubPixelScale * engine.GameTPS
+		text += "\n" + fmt.Sprintf("pos (%.5f %.5f) vel (%.4f %.4f)", x, y, vx, vy)
+	}
+	font.ByName["Small"].Draw(screen, text,
+		m.Pos{X: 0, Y: 24}, font.Left,
+		palette.EGA(palette.White, 255), palette.EGA(palette.Black, 255))
+}
@@ -18,8 +18,11 @@
 package alert
 
 import (
-	"github.com/ncruces/zenity"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/ncruces/zenity"
 )
 
 func Show(msg string) {
@@ -58,3 +61,27 @@ func Show(msg string) {
 	// No further fallbacks; eventually all we can do is to log to stderr,
 	// which we've already done before calling Show.
 }
+
+// ShowCrash shows an error message and offers to open the folder containing the given crash report.
+func ShowCrash(msg, report string) {
+	msg += "\n\nA crash report has been written to:\n" + report
+	err := zenity.Question(msg, zenity.Title("AAAAXY - Crash"), zenity.ErrorIcon, zenity.OKLabel("Open Folder"), zenity.CancelLabel("Close"))
+	if err == zenity.ErrCanceled {
+		return
+	}
+	if err != nil {
+		Show(msg)
+		return
+	}
+	var opener string
+	switch runtime.GOOS {
+	case "windows":
+		opener = "explorer"
+	case "darwin":
+		opener = "open"
+	default:
+		opener = "xdg-open"
+	}
+	// Failure is fine here; the path is in the message anyway.
+	_ = exec.Command(opener, filepath.Dir(report)).Start()
+}
@@ -24,3 +24,8 @@ import (
 func Show(msg string) {
 	js.Global().Call("alert", js.ValueOf(msg))
 }
+
+// ShowCrash shows an error message mentioning the given crash report.
+func ShowCrash(msg, report string) {
+	Show(msg + "\n\nA crash report has been written to:\n" + report)
+}
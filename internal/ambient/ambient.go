@@ -0,0 +1,101 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ambient plays the looping ambient sound a map declares in its ambient property.
+// It is a separate channel from the music, with its own volume.
+package ambient
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
+
+	"github.com/divVerent/aaaaxy/internal/audiowrap"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/mute"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	ambientVolume   = flag.Float64("ambient_volume", 0.5, "ambient sound volume (0..1)")
+	ambientFadeTime = flag.Duration("ambient_fade_time", 2*time.Second, "ambient sound fade time")
+)
+
+var (
+	currentName string
+	player      *audiowrap.Player
+)
+
+// Switch fades from the current ambient sound to the given one.
+// Passing an empty string means fading to silence.
+func Switch(name string) {
+	if name == currentName || mute.Active() {
+		return
+	}
+	if player != nil {
+		player.FadeOutIn(*ambientFadeTime)
+		player = nil
+	}
+	currentName = name
+	if name == "" {
+		return
+	}
+	p, err := newPlayer(name)
+	if err != nil {
+		log.Errorf("could not start playing ambient sound %q: %v", name, err)
+		return
+	}
+	player = p
+	player.SetVolume(*ambientVolume)
+	player.FadeIn(*ambientFadeTime)
+	player.Play()
+}
+
+// newPlayer creates a looping player for the given sound.
+func newPlayer(name string) (*audiowrap.Player, error) {
+	return audiowrap.NewPlayer(func() (io.ReadCloser, error) {
+		handle, err := vfs.Load("sounds", name)
+		if err != nil {
+			return nil, fmt.Errorf("could not load: %w", err)
+		}
+		data, err := vorbis.DecodeWithSampleRate(audiowrap.SampleRate(), handle)
+		if err != nil {
+			handle.Close()
+			return nil, fmt.Errorf("could not start decoding: %w", err)
+		}
+		return readCloser{audio.NewInfiniteLoop(data, data.Length()), handle}, nil
+	})
+}
+
+// readCloser reads from the decoded loop and closes the underlying file.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ambientPlayer implements engine.AmbientPlayer.
+type ambientPlayer struct{}
+
+func (ambientPlayer) Switch(name string) {
+	Switch(name)
+}
+
+func init() {
+	engine.RegisterAmbientPlayer(ambientPlayer{})
+}
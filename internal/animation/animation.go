@@ -23,7 +23,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/engine"
 	"github.com/divVerent/aaaaxy/internal/image"
 	m "github.com/divVerent/aaaaxy/internal/math"
-	"github.com/divVerent/aaaaxy/internal/music"
+	"github.com/divVerent/aaaaxy/internal/sound"
 )
 
 type Group struct {
@@ -34,21 +34,47 @@ type Group struct {
 	WaitFinish        bool          // Set if this anim shouldn't be interrupted.
 	NextAnim          string        // Name of next animation.
 	SyncToMusicOffset time.Duration // Time in music to sync to frame 0.
+	SyncGroup         string        // Name of a frame clock shared with other entities. Requires State.World.
+
+	// Frame numbers below count the whole sequence, including the reversed part of Symmetric groups.
+	Events   map[int][]Event // Events to run when entering a frame.
+	Hitboxes map[int]m.Rect  // Entity rect per frame, in image coordinates. Requires State.World and a RenderOffset. Frames not listed use the entity's original rect.
 
 	// These will be filled in by Init.
 	Images    []*ebiten.Image // One image per frame.
 	NextGroup *Group          // Pointer to same.
 }
 
+// Event is something that happens when an animation enters a frame.
+type Event struct {
+	Name  string // Passed to State.OnEvent, if not empty.
+	Sound string // Sound to play, if not empty.
+
+	// This will be filled in by Init.
+	sound *sound.Sound
+}
+
 type State struct {
 	// Global state.
 	Groups map[string]*Group
 
+	// Optional hooks.
+	World   *engine.World     // Needed to apply hitboxes and for sync groups.
+	OnEvent func(name string) // Called for named events.
+
 	// Current status.
 	Group     *Group
 	Frame     int
 	WantNext  bool
 	NextGroup *Group
+
+	// Last frame shown, to detect frame changes.
+	shownGroup *Group
+	shownFrame int
+
+	// Entity rect relative to the image while a hitbox override is active.
+	defaultHitbox    m.Rect
+	hitboxOverridden bool
 }
 
 func (s *State) Init(spritePrefix string, groups map[string]*Group, initialGroup string) error {
@@ -67,6 +93,33 @@ func (s *State) Init(spritePrefix string, groups map[string]*Group, initialGroup
 			// Odd count: 0 1 2 3 -> 0 1 2 3 3 2 1, so 7 -> 4
 			images = images/2 + 1
 		}
+		if group.SyncGroup == "" && group.SyncToMusicOffset != 0 {
+			group.SyncGroup = musicSyncGroupName(group)
+		}
+		if group.SyncGroup != "" {
+			if s.World == nil {
+				return fmt.Errorf("animation group %q is in sync group %q but has no world to take the clock from", name, group.SyncGroup)
+			}
+			err := joinSyncGroup(name, group)
+			if err != nil {
+				return err
+			}
+		}
+		if len(group.Hitboxes) != 0 && s.World == nil {
+			return fmt.Errorf("animation group %q has hitboxes but no world to apply them in", name)
+		}
+		for frame, events := range group.Events {
+			for i := range events {
+				if events[i].Sound == "" {
+					continue
+				}
+				var err error
+				events[i].sound, err = sound.Load(events[i].Sound)
+				if err != nil {
+					return fmt.Errorf("could not load sound %v for frame %d of group %q: %w", events[i].Sound, frame, name, err)
+				}
+			}
+		}
 		group.Images = make([]*ebiten.Image, images)
 		for i := range group.Images {
 			var spriteName string
@@ -131,9 +184,8 @@ func (s *State) Update(e *engine.Entity) {
 	if frame >= s.Group.Frames {
 		frame = s.Group.Frames - 1
 	}
-	if s.Group.SyncToMusicOffset != 0 {
-		absFrame := int((music.Now() - s.Group.SyncToMusicOffset) * engine.GameTPS / (time.Second * time.Duration(s.Group.FrameInterval)))
-		frame = m.Mod(absFrame, s.Group.Frames)
+	if s.Group.SyncGroup != "" {
+		frame = syncGroups[s.Group.SyncGroup].frameAt(s.World.PlayerState.Frames())
 	}
 	image := frame
 	if s.Group.Symmetric {
@@ -143,4 +195,43 @@ func (s *State) Update(e *engine.Entity) {
 		}
 	}
 	e.Image = s.Group.Images[image]
+	if s.Group != s.shownGroup || frame != s.shownFrame {
+		s.shownGroup, s.shownFrame = s.Group, frame
+		s.enterFrame(e, frame)
+	}
+}
+
+// enterFrame runs the events and applies the hitbox of a newly shown frame.
+func (s *State) enterFrame(e *engine.Entity, frame int) {
+	for _, event := range s.Group.Events[frame] {
+		if event.sound != nil {
+			event.sound.Play()
+		}
+		if event.Name != "" && s.OnEvent != nil {
+			s.OnEvent(event.Name)
+		}
+	}
+	if s.World == nil {
+		return
+	}
+	var hitbox m.Rect
+	if h, found := s.Group.Hitboxes[frame]; found {
+		if !s.hitboxOverridden {
+			s.defaultHitbox = m.Rect{Origin: m.Pos{}.Sub(e.RenderOffset), Size: e.Rect.Size}
+			s.hitboxOverridden = true
+		}
+		// Hitboxes are given in image space; rotate them like the renderer rotates the image.
+		sz := e.Image.Bounds().Size()
+		imageRect := e.Orientation.ApplyToRect2(m.Pos{}, m.Rect{Size: m.Delta{DX: sz.X, DY: sz.Y}})
+		hitbox = e.Orientation.ApplyToRect2(m.Pos{}, h)
+		hitbox.Origin = hitbox.Origin.Sub(imageRect.Origin.Delta(m.Pos{}))
+	} else if s.hitboxOverridden {
+		hitbox = s.defaultHitbox
+		s.hitboxOverridden = false
+	} else {
+		return
+	}
+	imagePos := e.Rect.Origin.Add(e.RenderOffset)
+	s.World.SetRect(e, m.Rect{Origin: imagePos.Add(hitbox.Origin.Delta(m.Pos{})), Size: hitbox.Size})
+	e.RenderOffset = m.Pos{}.Delta(hitbox.Origin)
 }
@@ -0,0 +1,98 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package animation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/music"
+)
+
+// Sync groups make animations of multiple entities, e.g. a row of blinking
+// lights, share a single frame clock. The clock is kept here rather than per
+// entity, so entities spawned at different times still show the same frame.
+
+// syncGroup is the shared clock of a named sync group.
+type syncGroup struct {
+	frames            int
+	frameInterval     int
+	syncToMusicOffset time.Duration
+
+	// Frame computed for the current game frame, so all members agree even if
+	// the music position advances between their updates.
+	valid     bool
+	gameFrame int
+	frame     int
+}
+
+// syncGroups contains all sync groups by name.
+var syncGroups = map[string]*syncGroup{}
+
+// musicSyncGroupName returns the implicit sync group name of an animation
+// group that only sets SyncToMusicOffset.
+func musicSyncGroupName(group *Group) string {
+	return fmt.Sprintf("music:%v:%d:%d", group.SyncToMusicOffset, group.Frames, group.FrameInterval)
+}
+
+// joinSyncGroup registers an animation group with its sync group.
+func joinSyncGroup(name string, group *Group) error {
+	if group.FrameInterval <= 0 {
+		return fmt.Errorf("animation group %q is in sync group %q but has no frame interval", name, group.SyncGroup)
+	}
+	sg := syncGroups[group.SyncGroup]
+	if sg == nil {
+		syncGroups[group.SyncGroup] = &syncGroup{
+			frames:            group.Frames,
+			frameInterval:     group.FrameInterval,
+			syncToMusicOffset: group.SyncToMusicOffset,
+		}
+		return nil
+	}
+	if sg.frames != group.Frames || sg.frameInterval != group.FrameInterval || sg.syncToMusicOffset != group.SyncToMusicOffset {
+		return fmt.Errorf("animation group %q does not match the timing of sync group %q: got %d frames every %d ticks at music offset %v, want %d frames every %d ticks at music offset %v",
+			name, group.SyncGroup, group.Frames, group.FrameInterval, group.SyncToMusicOffset, sg.frames, sg.frameInterval, sg.syncToMusicOffset)
+	}
+	return nil
+}
+
+// frameAt returns the frame the sync group shows at the given game frame.
+func (sg *syncGroup) frameAt(gameFrame int) int {
+	if sg.valid && sg.gameFrame == gameFrame {
+		return sg.frame
+	}
+	var absFrame int
+	if sg.syncToMusicOffset != 0 {
+		absFrame = int((musicNow(gameFrame) - sg.syncToMusicOffset) * engine.GameTPS / (time.Second * time.Duration(sg.frameInterval)))
+	} else {
+		absFrame = gameFrame / sg.frameInterval
+	}
+	sg.valid = true
+	sg.gameFrame = gameFrame
+	sg.frame = m.Mod(absFrame, sg.frames)
+	return sg.frame
+}
+
+// musicNow returns the music position to sync animations to.
+// Demos must not depend on audio timing, so they use the game frame count instead.
+func musicNow(gameFrame int) time.Duration {
+	if demo.Playing() || demo.Recording() {
+		return time.Duration(gameFrame) * time.Second / engine.GameTPS
+	}
+	return music.Now()
+}
@@ -0,0 +1,119 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package announce passes menu focus changes and game texts to a screen reader or text-to-speech system.
+package announce
+
+import (
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/mute"
+)
+
+var (
+	screenReader = flag.Bool("screen_reader", false, "announce focused menu items, dialog text and messages using text-to-speech")
+)
+
+// backend speaks text.
+type backend interface {
+	// name identifies the backend for logging.
+	name() string
+	// speak says the given text. If interrupt is set, anything still being said is stopped first.
+	// Must not block.
+	speak(text string, interrupt bool)
+}
+
+var (
+	active    backend
+	triedInit bool
+	lastFocus string
+)
+
+// get returns the backend to use, or nil if announcing is off.
+func get() backend {
+	if !*screenReader {
+		return nil
+	}
+	if !triedInit {
+		triedInit = true
+		var err error
+		active, err = newBackend()
+		if err != nil {
+			log.Warningf("could not initialize text-to-speech, only logging announcements: %v", err)
+			active = logBackend{}
+		}
+		log.Infof("announcing using %v", active.name())
+	}
+	return active
+}
+
+// clean turns a text as drawn on screen into a single line suitable for speaking.
+func clean(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Focus announces the newly focused menu item.
+// It may be called every frame; the text is only spoken again once it changed.
+// Speaking a new focus interrupts whatever is being said.
+func Focus(text string) {
+	if text == lastFocus {
+		return
+	}
+	lastFocus = text
+	b := get()
+	if b == nil {
+		return
+	}
+	text = clean(text)
+	if text == "" {
+		return
+	}
+	b.speak(text, true)
+}
+
+// Message announces a text shown to the player, such as a dialog line or a centerprint.
+// Messages are queued after anything still being said.
+func Message(text string) {
+	if mute.Active() {
+		return
+	}
+	b := get()
+	if b == nil {
+		return
+	}
+	text = clean(text)
+	if text == "" {
+		return
+	}
+	b.speak(text, false)
+}
+
+// Reset forgets the focused item, so it is announced again even if it does not change.
+// Should be called when switching menu screens.
+func Reset() {
+	lastFocus = ""
+}
+
+// logBackend is the fallback when no text-to-speech system is available.
+type logBackend struct{}
+
+func (logBackend) name() string {
+	return "log"
+}
+
+func (logBackend) speak(text string, interrupt bool) {
+	log.Infof("announce: %s", text)
+}
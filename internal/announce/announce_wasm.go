@@ -0,0 +1,47 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build wasm
+// +build wasm
+
+package announce
+
+import (
+	"errors"
+	"syscall/js"
+)
+
+// speechSynthesisBackend speaks using the Web Speech API.
+type speechSynthesisBackend struct {
+	synth js.Value
+}
+
+func newBackend() (backend, error) {
+	synth := js.Global().Get("speechSynthesis")
+	if synth.IsUndefined() || synth.IsNull() {
+		return nil, errors.New("speechSynthesis not supported by browser")
+	}
+	return &speechSynthesisBackend{synth: synth}, nil
+}
+
+func (b *speechSynthesisBackend) name() string {
+	return "speechSynthesis"
+}
+
+func (b *speechSynthesisBackend) speak(text string, interrupt bool) {
+	if interrupt {
+		b.synth.Call("cancel")
+	}
+	b.synth.Call("speak", js.Global().Get("SpeechSynthesisUtterance").New(text))
+}
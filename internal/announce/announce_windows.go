@@ -0,0 +1,88 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package announce
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"syscall"
+
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+// speechScript reads lines from stdin and speaks them; a leading ! stops speaking first.
+const speechScript = `[Console]::InputEncoding = [System.Text.Encoding]::UTF8
+Add-Type -AssemblyName System.Speech
+$s = New-Object System.Speech.Synthesis.SpeechSynthesizer
+while (($l = [Console]::In.ReadLine()) -ne $null) {
+	if ($l.StartsWith('!')) {
+		$s.SpeakAsyncCancelAll()
+	}
+	$s.SpeakAsync($l.Substring(1)) | Out-Null
+}`
+
+// speechBackend speaks using System.Speech via a PowerShell process kept running in the background.
+type speechBackend struct {
+	stdin io.WriteCloser
+	lines chan string
+}
+
+func newBackend() (backend, error) {
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", speechScript)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("could not create pipe to PowerShell: %w", err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("could not start PowerShell: %w", err)
+	}
+	b := &speechBackend{
+		stdin: stdin,
+		lines: make(chan string, 16),
+	}
+	go b.run()
+	return b, nil
+}
+
+func (b *speechBackend) name() string {
+	return "System.Speech"
+}
+
+func (b *speechBackend) speak(text string, interrupt bool) {
+	prefix := " "
+	if interrupt {
+		prefix = "!"
+	}
+	// The text was cleaned, so it contains no line breaks.
+	select {
+	case b.lines <- prefix + text:
+	default:
+		log.Warningf("text-to-speech is too slow, dropping: %s", text)
+	}
+}
+
+// run writes the lines to PowerShell so speak never blocks on the pipe.
+func (b *speechBackend) run() {
+	for l := range b.lines {
+		_, err := io.WriteString(b.stdin, l+"\n")
+		if err != nil {
+			log.Warningf("could not write to PowerShell: %v", err)
+			return
+		}
+	}
+}
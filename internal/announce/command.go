@@ -0,0 +1,126 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !wasm && !windows && !android && !ios
+// +build !wasm,!windows,!android,!ios
+
+package announce
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"sync"
+
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+// newBackend looks for a known text-to-speech command.
+func newBackend() (backend, error) {
+	if runtime.GOOS == "darwin" {
+		if _, err := exec.LookPath("say"); err == nil {
+			return newCommandBackend("say", nil, nil), nil
+		}
+	}
+	// Prefer speech-dispatcher, as it uses the user's configured voice.
+	if _, err := exec.LookPath("spd-say"); err == nil {
+		return newCommandBackend("spd-say", []string{"--wait", "--"}, []string{"--cancel"}), nil
+	}
+	for _, program := range []string{"espeak-ng", "espeak"} {
+		if _, err := exec.LookPath(program); err == nil {
+			return newCommandBackend(program, []string{"--"}, nil), nil
+		}
+	}
+	return nil, errors.New("none of say, spd-say, espeak-ng or espeak found")
+}
+
+// commandBackend speaks by running an external command for every text.
+type commandBackend struct {
+	program string
+	args    []string
+	cancel  []string // If set, arguments to run the program with to stop speaking.
+
+	mu      sync.Mutex
+	queue   []string
+	current *exec.Cmd
+	wake    chan struct{}
+}
+
+func newCommandBackend(program string, args, cancel []string) *commandBackend {
+	b := &commandBackend{
+		program: program,
+		args:    args,
+		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
+	}
+	go b.run()
+	return b
+}
+
+func (b *commandBackend) name() string {
+	return b.program
+}
+
+func (b *commandBackend) speak(text string, interrupt bool) {
+	b.mu.Lock()
+	if interrupt {
+		b.queue = b.queue[:0]
+		if b.current != nil && b.current.Process != nil {
+			b.current.Process.Kill()
+		}
+	}
+	b.queue = append(b.queue, text)
+	b.mu.Unlock()
+	if interrupt && b.cancel != nil {
+		cmd := exec.Command(b.program, b.cancel...)
+		err := cmd.Start()
+		if err != nil {
+			log.Warningf("could not stop %v: %v", b.program, err)
+		} else {
+			// Reap the process so it does not stay around as a zombie.
+			go cmd.Wait()
+		}
+	}
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run speaks the queued texts one after the other.
+func (b *commandBackend) run() {
+	for range b.wake {
+		for {
+			b.mu.Lock()
+			if len(b.queue) == 0 {
+				b.current = nil
+				b.mu.Unlock()
+				break
+			}
+			text := b.queue[0]
+			b.queue = b.queue[1:]
+			cmd := exec.Command(b.program, append(append([]string{}, b.args...), text)...)
+			err := cmd.Start()
+			if err != nil {
+				b.mu.Unlock()
+				log.Warningf("could not run %v: %v", b.program, err)
+				continue
+			}
+			b.current = cmd
+			b.mu.Unlock()
+			// Errors are expected when interrupted.
+			_ = cmd.Wait()
+		}
+	}
+}
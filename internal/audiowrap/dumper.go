@@ -49,6 +49,11 @@ func DumpFrame(dumpFile io.Writer, toTime time.Duration) error {
 	return dumpSamples(dumpFile, samples)
 }
 
+// DumpedDuration returns the total length of audio dumped so far.
+func DumpedDuration() time.Duration {
+	return time.Duration(sampleIndex) * time.Second / time.Duration(SampleRate())
+}
+
 func dumpSamples(dumpFile io.Writer, samples int) error {
 	buf := make([]int16, 2*samples)
 	toClose := []*dumper{}
@@ -113,7 +113,7 @@ func ebiPlayer(src io.Reader) (*ebiaudio.Player, error) {
 	if !*audio {
 		return nil, nil
 	}
-	return ebiaudio.CurrentContext().NewPlayer(src)
+	return ebiaudio.CurrentContext().NewPlayer(maybeResample(src))
 }
 
 func NewPlayer(src func() (io.ReadCloser, error)) (*Player, error) {
@@ -150,6 +150,13 @@ func ebiPlayerFromBytes(src []byte) *ebiaudio.Player {
 	if !*audio {
 		return nil
 	}
+	if *audioFollowGameSpeed {
+		ebi, err := ebiaudio.CurrentContext().NewPlayer(maybeResample(bytes.NewReader(src)))
+		if err == nil {
+			return ebi
+		}
+		log.Errorf("could not create resampling player, playing in real time: %v", err)
+	}
 	return ebiaudio.CurrentContext().NewPlayerFromBytes(src)
 }
 
@@ -227,6 +234,20 @@ func (p *Player) FadeOutIn(d time.Duration) *FadeHandle {
 	}
 }
 
+// FadeIn fades the player in from silence to its volume in the given time.
+// Must be called after SetVolume.
+func (p *Player) FadeIn(d time.Duration) {
+	frames := toFrames(d)
+	if frames < 1 {
+		return
+	}
+	delete(fadingOutPlayers, p)
+	p.fadeFrame = 0
+	p.fadeFrames = frames
+	p.setVolume(0)
+	fadingInPlayers[p] = struct{}{}
+}
+
 func (f *FadeHandle) RestoreIn(d time.Duration) *Player {
 	frames := toFrames(d)
 	p := f.player
@@ -0,0 +1,137 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audiowrap
+
+import (
+	"encoding/binary"
+	"io"
+	"sync/atomic"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+)
+
+var (
+	audioFollowGameSpeed = flag.Bool("audio_follow_game_speed", false, "when the game runs slower or faster than normal, play music and sounds at the same speed (changing their pitch); otherwise audio is kept real-time")
+)
+
+const (
+	// resampleScale is the fixed point scale of resampling positions and steps.
+	resampleScale = 1 << 16
+
+	// bytesPerFrame is the size of one stereo 16-bit sample frame.
+	bytesPerFrame = 4
+
+	// minSpeed and maxSpeed limit the playback speed.
+	minSpeed = 0.25
+	maxSpeed = 4.0
+)
+
+// resampleStep is the current playback speed in units of 1/resampleScale.
+// It is read from the audio thread, so it is atomic.
+var resampleStep atomic.Int64
+
+func init() {
+	resampleStep.Store(resampleScale)
+}
+
+// SetSpeed sets the speed at which audio is played back relative to normal.
+// Only has an effect if -audio_follow_game_speed is set; players created
+// before enabling that flag keep playing in real time.
+func SetSpeed(speed float64) {
+	if !*audioFollowGameSpeed {
+		speed = 1
+	}
+	if speed < minSpeed {
+		speed = minSpeed
+	}
+	if speed > maxSpeed {
+		speed = maxSpeed
+	}
+	resampleStep.Store(int64(speed*resampleScale + 0.5))
+}
+
+// resampler plays back 16-bit stereo audio at the current speed using linear interpolation.
+// Like a tape played at a different speed, this changes tempo and pitch together.
+type resampler struct {
+	src io.Reader
+
+	started   bool
+	cur, next [2]int16
+	frac      int64 // Position between cur and next, in units of 1/resampleScale.
+	err       error // Deferred error to return on the next Read.
+}
+
+var _ io.Reader = &resampler{}
+
+// maybeResample wraps the given source in a resampler if audio is to follow the game speed.
+func maybeResample(src io.Reader) io.Reader {
+	if !*audioFollowGameSpeed {
+		return src
+	}
+	return &resampler{src: src}
+}
+
+// advance moves to the next input sample frame.
+func (r *resampler) advance() error {
+	var buf [bytesPerFrame]byte
+	_, err := io.ReadFull(r.src, buf[:])
+	if err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return err
+	}
+	r.cur = r.next
+	r.next[0] = int16(binary.LittleEndian.Uint16(buf[0:]))
+	r.next[1] = int16(binary.LittleEndian.Uint16(buf[2:]))
+	return nil
+}
+
+func (r *resampler) Read(b []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if !r.started {
+		// Load the first two frames.
+		for i := 0; i < 2; i++ {
+			if err := r.advance(); err != nil {
+				return 0, err
+			}
+		}
+		r.started = true
+	}
+	step := resampleStep.Load()
+	n := 0
+	for n+bytesPerFrame <= len(b) {
+		for r.frac >= resampleScale {
+			if err := r.advance(); err != nil {
+				if n == 0 {
+					return 0, err
+				}
+				r.err = err
+				return n, nil
+			}
+			r.frac -= resampleScale
+		}
+		for ch := 0; ch < 2; ch++ {
+			a, c := int64(r.cur[ch]), int64(r.next[ch])
+			v := a + (c-a)*r.frac/resampleScale
+			binary.LittleEndian.PutUint16(b[n+2*ch:], uint16(int16(v)))
+		}
+		n += bytesPerFrame
+		r.frac += step
+	}
+	return n, nil
+}
@@ -22,14 +22,21 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/colorm"
 
+	"github.com/divVerent/aaaaxy/internal/announce"
 	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/locale"
 	"github.com/divVerent/aaaaxy/internal/log"
 	m "github.com/divVerent/aaaaxy/internal/math"
 	"github.com/divVerent/aaaaxy/internal/palette"
 )
 
+// Centerprint is a message shown in the upper part of the screen.
+//
+// Centerprints are queued per InitialPosition; a new message replaces the
+// current one at the same position unless the current one is Important and
+// has not been fully shown yet, in which case it waits.
 type Centerprint struct {
-	text       string
+	lines      []layoutLine
 	bounds     m.Rect
 	bgColor    color.Color
 	fgColor    color.Color
@@ -37,19 +44,32 @@ type Centerprint struct {
 	waitFade   bool
 	face       *font.Face
 	pos        InitialPosition
+	imp        Importance
 
 	alphaFrames int
 	alphaFrame  int
 	scrollPos   int
 	fadeOut     bool
+	replaced    bool
 	active      bool
 }
 
+const (
+	// wrapMargin is the minimum distance of wrapped text from the screen edges.
+	wrapMargin = 16
+)
+
 var (
-	screenWidth, screenHeight int
-	centerprints              []*Centerprint
+	screenWidth, screenHeight = 640, 360
+
+	// centerprints are the currently shown centerprints.
+	centerprints []*Centerprint
+
+	// queue contains centerprints waiting to be shown, in order.
+	queue []*Centerprint
 )
 
+// Importance is the priority of a centerprint.
 type Importance int
 
 const (
@@ -119,7 +139,28 @@ func BigFont() *font.Face {
 }
 
 func Reset() {
+	for _, cp := range queue {
+		cp.active = false
+	}
 	centerprints = centerprints[:0]
+	queue = queue[:0]
+}
+
+// State is the set of shown and queued centerprints.
+type State struct {
+	centerprints []*Centerprint
+	queue        []*Centerprint
+}
+
+// SwapState replaces all centerprints by the given state and returns the previous one.
+// Allows simulating a second world without disturbing the centerprints shown to the player.
+func SwapState(s State) State {
+	prev := State{
+		centerprints: centerprints,
+		queue:        queue,
+	}
+	centerprints, queue = s.centerprints, s.queue
+	return prev
 }
 
 func New(txt string, imp Importance, pos InitialPosition, face *font.Face, fgColor color.Color, fadeTime time.Duration) *Centerprint {
@@ -132,26 +173,87 @@ func NewWithBG(txt string, imp Importance, pos InitialPosition, face *font.Face,
 		frames = 1
 	}
 	cp := &Centerprint{
-		text:        txt,
 		bgColor:     bgColor,
 		fgColor:     fgColor,
 		waitScroll:  imp == Important,
 		waitFade:    true,
 		face:        face,
 		pos:         pos,
+		imp:         imp,
 		alphaFrame:  1,
 		alphaFrames: frames,
 		active:      true,
 	}
-	cp.bounds = cp.face.BoundString(txt)
+	cp.lines = layout(face, parseMarkup(txt), screenWidth-2*wrapMargin)
+	text := plainText(cp.lines)
+	cp.bounds = cp.face.BoundString(text)
+	announce.Message(text)
 	if pos == Middle {
 		cp.scrollPos = cp.targetPos()
 	}
-	centerprints = append(centerprints, cp)
+	cp.enqueue()
 	return cp
 }
 
+// enqueue adds the centerprint to the queue and shows it as soon as possible.
+func (cp *Centerprint) enqueue() {
+	// Pending unimportant messages are superseded by newer ones.
+	n := 0
+	for _, q := range queue {
+		if q.pos == cp.pos && q.imp == NotImportant {
+			q.active = false
+			continue
+		}
+		queue[n] = q
+		n++
+	}
+	queue = append(queue[:n], cp)
+	promote()
+}
+
+// yields returns whether the centerprint may be replaced by a newer one.
+func (cp *Centerprint) yields() bool {
+	return cp.imp == NotImportant || (cp.fadeOut && !cp.waitFade && !cp.waitScroll)
+}
+
+// promote shows queued centerprints whose position is not blocked.
+func promote() {
+	n := 0
+	blocked := map[InitialPosition]bool{}
+	for _, q := range queue {
+		if !blocked[q.pos] {
+			for _, cp := range centerprints {
+				if cp.pos == q.pos && !cp.replaced && !cp.yields() {
+					blocked[q.pos] = true
+					break
+				}
+			}
+		}
+		if blocked[q.pos] {
+			queue[n] = q
+			n++
+			continue
+		}
+		for _, cp := range centerprints {
+			if cp.pos == q.pos && !cp.replaced {
+				cp.replaced = true
+				cp.fadeOut = true
+				cp.waitFade = false
+				cp.waitScroll = false
+			}
+		}
+		centerprints = append(centerprints, q)
+		// Only one new centerprint per position at a time.
+		blocked[q.pos] = true
+	}
+	queue = queue[:n]
+}
+
 func (cp *Centerprint) SetFadeOut(fadeOut bool) {
+	if cp.replaced {
+		// Already replaced by a newer message; do not come back.
+		return
+	}
 	cp.fadeOut = fadeOut
 }
 
@@ -209,11 +311,41 @@ func (cp *Centerprint) draw(screen *ebiten.Image) {
 	}
 	var alphaM colorm.ColorM
 	alphaM.Scale(1.0, 1.0, 1.0, a)
-	fg := alphaM.Apply(cp.fgColor)
 	bg := alphaM.Apply(cp.bgColor)
-	x := screenWidth / 2
+	// A drop shadow keeps the text readable on busy backgrounds.
+	shadow := alphaM.Apply(palette.EGA(palette.Black, 255))
 	y := cp.scrollPos - cp.bounds.Size.DY - cp.bounds.Origin.Y
-	cp.face.Draw(screen, cp.text, m.Pos{X: x, Y: y}, font.Center, fg, bg)
+	lineHeight := cp.face.LineHeight()
+	rtl := locale.ActiveIsRightToLeft()
+	for _, l := range cp.lines {
+		x0 := screenWidth/2 - l.width/2
+		for _, item := range l.items {
+			if rtl {
+				// Runs are laid out in reading order.
+				item.x = l.width - item.x - item.w
+			}
+			if item.icon != nil {
+				sz := item.icon.Bounds().Size()
+				opts := &ebiten.DrawImageOptions{}
+				opts.GeoM.Scale(float64(item.w)/float64(sz.X), float64(lineHeight)/float64(sz.Y))
+				opts.GeoM.Translate(float64(x0+item.x), float64(y-cp.face.Ascent()))
+				opts.ColorScale.ScaleAlpha(float32(a))
+				opts.Filter = ebiten.FilterLinear
+				screen.DrawImage(item.icon, opts)
+				continue
+			}
+			fgColor := item.fg
+			if fgColor == nil {
+				fgColor = cp.fgColor
+			}
+			cp.face.DrawStyled(screen, item.text, m.Pos{X: x0 + item.x, Y: y}, font.Left, font.Style{
+				FG:      alphaM.Apply(fgColor),
+				Outline: bg,
+				Shadow:  shadow,
+			})
+		}
+		y += lineHeight
+	}
 }
 
 func (cp *Centerprint) Active() bool {
@@ -221,13 +353,15 @@ func (cp *Centerprint) Active() bool {
 }
 
 func Update() {
-	offscreens := 0
-	for i, cp := range centerprints {
-		if !cp.update() && i == offscreens {
-			offscreens++
+	n := 0
+	for _, cp := range centerprints {
+		if cp.update() {
+			centerprints[n] = cp
+			n++
 		}
 	}
-	centerprints = centerprints[offscreens:]
+	centerprints = centerprints[:n]
+	promote()
 }
 
 func Draw(screen *ebiten.Image) {
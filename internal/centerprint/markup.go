@@ -0,0 +1,212 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package centerprint
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/fun"
+	"github.com/divVerent/aaaaxy/internal/image"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+// Markup supported in centerprint texts:
+//
+//	[color=yellow]text[/color] draws text in an EGA color.
+//	[button=exit] and [button=action] show the button for the current input device.
+//
+// Anything else in brackets is shown as is.
+
+// token is a word, space, line break or icon of a parsed text.
+type token struct {
+	text    string
+	fg      color.Color // nil means the default color.
+	icon    *ebiten.Image
+	space   bool
+	newline bool
+}
+
+// layoutItem is a run of text or an icon placed on a line.
+type layoutItem struct {
+	x    int
+	text string
+	fg   color.Color
+	icon *ebiten.Image
+	w    int
+}
+
+type layoutLine struct {
+	width int
+	items []layoutItem
+}
+
+// buttonIcon returns the image or, if there is none, the name of the given button.
+func buttonIcon(name string) (*ebiten.Image, string) {
+	prompts := input.PreferredPrompts()
+	var imgName string
+	switch name {
+	case "exit":
+		imgName = prompts.ExitIcon
+		if imgName == "" {
+			return nil, fun.ExitButtonName()
+		}
+	case "action":
+		imgName = prompts.ActionIcon
+		if imgName == "" {
+			return nil, fun.ActionButtonName()
+		}
+	default:
+		log.Errorf("unknown button in centerprint markup: %q", name)
+		return nil, name
+	}
+	img, err := image.Load("sprites", imgName)
+	if err != nil {
+		log.Errorf("could not load button icon %v: %v", imgName, err)
+		return nil, name
+	}
+	return img, ""
+}
+
+// parseMarkup splits the given text into tokens.
+func parseMarkup(txt string) []token {
+	var tokens []token
+	var fg color.Color
+	word := ""
+	flush := func() {
+		if word != "" {
+			tokens = append(tokens, token{text: word, fg: fg})
+			word = ""
+		}
+	}
+	for len(txt) > 0 {
+		if txt[0] == '[' {
+			if end := strings.IndexByte(txt, ']'); end > 0 {
+				tag := txt[1:end]
+				handled := true
+				switch {
+				case strings.HasPrefix(tag, "color="):
+					c, found := palette.EGAByName(strings.ToLower(tag[len("color="):]))
+					if found {
+						flush()
+						fg = palette.EGA(c, 255)
+					} else {
+						handled = false
+					}
+				case tag == "/color":
+					flush()
+					fg = nil
+				case strings.HasPrefix(tag, "button="):
+					flush()
+					img, name := buttonIcon(strings.ToLower(tag[len("button="):]))
+					if img != nil {
+						tokens = append(tokens, token{icon: img})
+					} else {
+						tokens = append(tokens, token{text: name, fg: fg})
+					}
+				default:
+					handled = false
+				}
+				if handled {
+					txt = txt[end+1:]
+					continue
+				}
+			}
+		}
+		switch txt[0] {
+		case ' ':
+			flush()
+			tokens = append(tokens, token{space: true})
+		case '\n':
+			flush()
+			tokens = append(tokens, token{newline: true})
+		default:
+			word += txt[:1]
+		}
+		txt = txt[1:]
+	}
+	flush()
+	return tokens
+}
+
+// iconWidth returns the width of an icon scaled to the line height.
+func iconWidth(face *font.Face, img *ebiten.Image) int {
+	sz := img.Bounds().Size()
+	return (sz.X*face.LineHeight() + sz.Y - 1) / sz.Y
+}
+
+// layout word wraps the given tokens to the given width.
+func layout(face *font.Face, tokens []token, width int) []layoutLine {
+	lines := []layoutLine{{}}
+	spaceWidth := face.Advance(" ")
+	pendingSpace := false
+	for _, t := range tokens {
+		l := &lines[len(lines)-1]
+		switch {
+		case t.newline:
+			lines = append(lines, layoutLine{})
+			pendingSpace = false
+			continue
+		case t.space:
+			pendingSpace = len(l.items) > 0
+			continue
+		}
+		var w int
+		if t.icon != nil {
+			w = iconWidth(face, t.icon)
+		} else {
+			w = face.Advance(t.text)
+		}
+		if pendingSpace && l.width+spaceWidth+w > width {
+			lines = append(lines, layoutLine{})
+			l = &lines[len(lines)-1]
+			pendingSpace = false
+		}
+		sep, x := "", l.width
+		if pendingSpace {
+			sep, x = " ", x+spaceWidth
+		}
+		pendingSpace = false
+		if n := len(l.items); n > 0 && t.icon == nil && l.items[n-1].icon == nil && l.items[n-1].fg == t.fg {
+			// Extend the previous run of text.
+			prev := &l.items[n-1]
+			prev.text += sep + t.text
+			prev.w = face.Advance(prev.text)
+			l.width = prev.x + prev.w
+			continue
+		}
+		l.items = append(l.items, layoutItem{x: x, text: t.text, fg: t.fg, icon: t.icon, w: w})
+		l.width = x + w
+	}
+	return lines
+}
+
+// plainText returns the text of the given lines without icons and markup.
+func plainText(lines []layoutLine) string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		var b strings.Builder
+		for _, item := range l.items {
+			b.WriteString(item.text)
+		}
+		out[i] = b.String()
+	}
+	return strings.Join(out, "\n")
+}
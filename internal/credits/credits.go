@@ -16,58 +16,285 @@ package credits
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
+	"time"
 
+	"github.com/divVerent/aaaaxy/internal/locale"
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
 
 var (
-	Lines    []string
 	Licenses []string
 
+	// blocks are the loaded credits files in order.
+	blocks []block
+
+	// manifests are the attributions of third party asset packs.
+	manifests []Manifest
+
 	wordWrapRE = regexp.MustCompile(`(?:^\s*|\b)\S.{1,80}(?:\b|$)|^$`)
 )
 
-func concatenateLines(dir string) ([]string, error) {
+// Marker requests a credits line to be centered on the screen at a given music time.
+type Marker struct {
+	Line int
+	At   time.Duration
+}
+
+// block is one credits file; either plain text lines or structured.
+type block struct {
+	lines      []string
+	structured *Structured
+}
+
+// Localized is a string with optional translations.
+// In JSON it is either a plain string or an object mapping language names to strings,
+// where the empty key is the untranslated text.
+type Localized map[locale.Lingua]string
+
+func (l *Localized) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = Localized{"": s}
+		return nil
+	}
+	var m map[locale.Lingua]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*l = m
+	return nil
+}
+
+// String returns the text in the active language.
+func (l Localized) String() string {
+	if s, found := l[locale.Active]; found {
+		return s
+	}
+	return l[""]
+}
+
+// Duration is a time.Duration parsed from a string like "1m23s".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	t, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(t)
+	return nil
+}
+
+// Entry is a credited item, e.g. a library or asset, and who made it.
+type Entry struct {
+	Title Localized `json:"title"`
+	Role  Localized `json:"role,omitempty"`
+	Names []string  `json:"names,omitempty"`
+	URL   string    `json:"url,omitempty"`
+}
+
+// Section is a group of entries under a common heading.
+type Section struct {
+	Heading Localized `json:"heading"`
+	// At, if set, is the music time at which the heading should be centered on screen in the final credits.
+	At      *Duration `json:"at,omitempty"`
+	Entries []Entry   `json:"entries,omitempty"`
+}
+
+// Structured is the content of a .json credits file.
+type Structured struct {
+	Sections []Section `json:"sections"`
+}
+
+// Manifest describes a third party asset pack. Read from manifests/*.json.
+type Manifest struct {
+	Name    string `json:"name"`
+	Author  string `json:"author"`
+	License string `json:"license"`
+	Origin  string `json:"origin,omitempty"`
+}
+
+func wrapLine(line string) []string {
+	return wordWrapRE.FindAllString(line, -1)
+}
+
+func readStructured(dir, file string) (*Structured, error) {
+	rd, err := vfs.Load(dir, file)
+	if err != nil {
+		return nil, fmt.Errorf("could not load file %v in %v: %w", file, dir, err)
+	}
+	defer rd.Close()
+	var s Structured
+	err = json.NewDecoder(rd).Decode(&s)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode file %v in %v: %w", file, dir, err)
+	}
+	return &s, nil
+}
+
+func readLines(dir, file string) ([]string, error) {
+	rd, err := vfs.Load(dir, file)
+	if err != nil {
+		return nil, fmt.Errorf("could not load file %v in %v: %w", file, dir, err)
+	}
+	defer rd.Close()
+	var lines []string
+	scanner := bufio.NewScanner(rd)
+	scanner.Split(bufio.ScanLines)
+	for scanner.Scan() {
+		lines = append(lines, wrapLine(scanner.Text())...)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not scan file %v in %v: %w", file, dir, err)
+	}
+	return lines, nil
+}
+
+func loadBlocks(dir string) ([]block, error) {
 	files, err := vfs.ReadDir(dir)
 	if err != nil {
 		return nil, fmt.Errorf("could not list files in %v: %w", dir, err)
 	}
-	var lines []string
+	var out []block
 	for _, file := range files {
-		rd, err := vfs.Load(dir, file)
+		if strings.HasSuffix(file, ".json") {
+			s, err := readStructured(dir, file)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, block{structured: s})
+			continue
+		}
+		lines, err := readLines(dir, file)
 		if err != nil {
-			return nil, fmt.Errorf("could not load file %v in %v: %w", file, dir, err)
+			return nil, err
+		}
+		out = append(out, block{lines: lines})
+	}
+	return out, nil
+}
+
+func loadManifests() ([]Manifest, error) {
+	files, err := vfs.ReadDir("manifests")
+	if err != nil {
+		return nil, fmt.Errorf("could not list files in manifests: %w", err)
+	}
+	var out []Manifest
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".json") {
+			continue
 		}
-		defer rd.Close()
-		scanner := bufio.NewScanner(rd)
-		scanner.Split(bufio.ScanLines)
-		for scanner.Scan() {
-			lines = append(lines, wordWrapRE.FindAllString(scanner.Text(), -1)...)
+		rd, err := vfs.Load("manifests", file)
+		if err != nil {
+			return nil, fmt.Errorf("could not load manifest %v: %w", file, err)
 		}
-		if err = scanner.Err(); err != nil {
-			return nil, fmt.Errorf("could not scan file %v in %v: %w", file, dir, err)
+		var mf Manifest
+		err = json.NewDecoder(rd).Decode(&mf)
+		rd.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not decode manifest %v: %w", file, err)
 		}
-		// Make sure items are separated by empty lines.
-		if len(lines) > 0 && lines[len(lines)-1] != "" {
+		out = append(out, mf)
+	}
+	return out, nil
+}
+
+// appendSeparator makes sure items are separated by empty lines.
+func appendSeparator(lines []string) []string {
+	if len(lines) > 0 && lines[len(lines)-1] != "" {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// appendNames adds the "by" and "and" lines the credits screen knows how to localize.
+func appendNames(lines []string, names []string) []string {
+	for i, name := range names {
+		if i == 0 {
+			lines = append(lines, wrapLine("by "+name)...)
+		} else {
+			lines = append(lines, wrapLine("and "+name)...)
+		}
+	}
+	return lines
+}
+
+func (s *Structured) appendTo(lines []string, markers []Marker) ([]string, []Marker) {
+	for _, sec := range s.Sections {
+		if sec.At != nil {
+			markers = append(markers, Marker{Line: len(lines), At: time.Duration(*sec.At)})
+		}
+		lines = append(lines, wrapLine(sec.Heading.String())...)
+		lines = append(lines, "")
+		for _, e := range sec.Entries {
+			lines = append(lines, wrapLine(e.Title.String())...)
+			if role := e.Role.String(); role != "" {
+				lines = append(lines, wrapLine(role)...)
+			}
+			lines = appendNames(lines, e.Names)
+			if e.URL != "" {
+				lines = append(lines, e.URL)
+			}
 			lines = append(lines, "")
 		}
 	}
-	return lines, nil
+	return lines, markers
 }
 
-func loadCredits() ([]string, error) {
-	return concatenateLines("credits")
+// Build returns the credits lines in the active language, and the music sync markers.
+func Build() ([]string, []Marker) {
+	var lines []string
+	var markers []Marker
+	for _, b := range blocks {
+		if b.structured != nil {
+			lines, markers = b.structured.appendTo(lines, markers)
+		} else {
+			lines = append(lines, b.lines...)
+		}
+		lines = appendSeparator(lines)
+	}
+	if len(manifests) != 0 {
+		lines = append(lines, locale.G.Get("Third Party Assets"), "")
+		for _, mf := range manifests {
+			lines = append(lines, wrapLine(mf.Name)...)
+			lines = appendNames(lines, []string{mf.Author})
+			lines = append(lines, wrapLine(mf.License)...)
+			if mf.Origin != "" {
+				lines = append(lines, mf.Origin)
+			}
+			lines = append(lines, "")
+		}
+	}
+	return lines, markers
 }
 
 func loadLicenses() ([]string, error) {
-	return concatenateLines("licenses")
+	licenses, err := loadBlocks("licenses")
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, b := range licenses {
+		lines = appendSeparator(append(lines, b.lines...))
+	}
+	return lines, nil
 }
 
 func Precache() error {
 	var err error
-	Lines, err = loadCredits()
+	blocks, err = loadBlocks("credits")
+	if err != nil {
+		return err
+	}
+	manifests, err = loadManifests()
 	if err != nil {
 		return err
 	}
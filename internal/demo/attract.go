@@ -0,0 +1,97 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package demo
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	demoAttracting  bool
+	demoAttractDone bool
+)
+
+// StartAttract starts playing back a bundled demo for attract mode.
+//
+// Unlike regular demo playback, the demo can be interrupted by any input,
+// and desyncs are not treated as regressions.
+//
+// The world should be reloaded after the next call to Update so it picks up
+// the save game from the demo.
+func StartAttract(name string) error {
+	if demoPlayer != nil {
+		return fmt.Errorf("cannot start attract mode demo %v: already playing a demo", name)
+	}
+	f, err := vfs.LoadPath("demos", name)
+	if err != nil {
+		return fmt.Errorf("could not open attract mode demo %v: %w", name, err)
+	}
+	demoPlayerFile = f
+	demoPlayer = json.NewDecoder(demoPlayerFile)
+	demoPlayerFrame = frame{}
+	demoPlayerFrameIdx = 0
+	demoAttracting = true
+	demoAttractDone = false
+	log.Infof("starting attract mode demo %v", name)
+	return nil
+}
+
+// StopAttract ends attract mode playback, if any.
+func StopAttract() {
+	if !demoAttracting {
+		return
+	}
+	err := demoPlayerFile.Close()
+	if err != nil {
+		log.Errorf("failed to close attract mode demo: %v", err)
+	}
+	demoPlayerFile = nil
+	demoPlayer = nil
+	demoPlayerFrame = frame{}
+	demoAttracting = false
+	demoAttractDone = true
+}
+
+// Attracting returns whether an attract mode demo is currently playing.
+func Attracting() bool {
+	return demoAttracting
+}
+
+// AttractJustEnded returns whether attract mode has ended since the last call.
+// The caller is then expected to reload the world and return to the menu.
+func AttractJustEnded() bool {
+	done := demoAttractDone
+	demoAttractDone = false
+	return done
+}
+
+func attractFrame() {
+	if input.AnyJustHit() {
+		log.Infof("attract mode demo interrupted by user")
+		StopAttract()
+		return
+	}
+	if !playReadFrame() {
+		log.Infof("attract mode demo ended")
+		StopAttract()
+		return
+	}
+	input.LoadFromDemo(demoPlayerFrame.Input)
+}
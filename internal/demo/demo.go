@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
@@ -30,6 +31,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/level"
 	"github.com/divVerent/aaaaxy/internal/log"
 	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/mute"
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
 
@@ -41,13 +43,20 @@ var (
 )
 
 type frame struct {
-	SaveGame *level.SaveGame  `json:",omitempty"`
-	Input    *input.DemoState `json:",omitempty"`
+	// InitialFlags is only set in the first frame, which is a header and not played back as a game frame.
+	InitialFlags map[string]string `json:",omitempty"`
+
+	SaveGame *level.SaveGame   `json:",omitempty"`
+	Input    *input.DemoState  `json:",omitempty"`
+	RNGSeed  *int              `json:",omitempty"`
+	Flags    map[string]string `json:",omitempty"`
 
 	// The following data is not actually played back, but compared at playback time.
 	SaveGames     []uint64        `json:",omitempty"`
+	Effects       []string        `json:",omitempty"`
 	FinalSaveGame *level.SaveGame `json:",omitempty"`
 	PlayerPos     *m.Pos          `json:",omitempty"`
+	AssetDigest   string          `json:",omitempty"`
 }
 
 var (
@@ -60,8 +69,22 @@ var (
 	demoRecorderFile          io.WriteCloser
 	demoRecorderFinalSaveGame *level.SaveGame
 	demoRecorder              *json.Encoder
+	demoRecorderFlags         map[string]string
+	demoTail                  [tailFrames]tailFrame
+	demoTailNext              int
+	demoTailLen               int
 )
 
+// tailFrame is a frame remembered for Tail.
+// It is only encoded when Tail is called, as that normally never happens.
+type tailFrame struct {
+	input     *input.DemoState
+	playerPos *m.Pos
+}
+
+// tailFrames is how many frames Tail returns at most.
+const tailFrames = 600
+
 func Init() error {
 	if *demoPlay != "" {
 		var err error
@@ -73,6 +96,10 @@ func Init() error {
 				return fmt.Errorf("could not open demo %v: local error: %v, VFS error: %v", *demoPlay, err, verr)
 			}
 		}
+		err = playApplyInitialFlags()
+		if err != nil {
+			return fmt.Errorf("could not apply flags of demo %v: %w", *demoPlay, err)
+		}
 		demoPlayer = json.NewDecoder(demoPlayerFile)
 		vfs.CrashOnWrite("demo playback")
 	}
@@ -97,15 +124,42 @@ func Init() error {
 		}
 		demoRecorder = json.NewEncoder(demoRecorderFile)
 		demoRecorder.SetIndent("", "")
+		demoRecorderFlags = flag.DemoFlags()
+		err = demoRecorder.Encode(&frame{
+			InitialFlags: demoRecorderFlags,
+		})
+		if err != nil {
+			return fmt.Errorf("could not encode demo header: %w", err)
+		}
 		log.Infof("recording demo to %v", demoRecordName)
 	}
 	return nil
 }
 
+// playApplyInitialFlags applies the flags from the header of the played demo.
+// Must be called before anything depending on these flags is initialized.
+func playApplyInitialFlags() error {
+	header := frame{}
+	err := json.NewDecoder(demoPlayerFile).Decode(&header)
+	if err != nil {
+		return fmt.Errorf("could not decode first demo frame: %w", err)
+	}
+	_, err = demoPlayerFile.Seek(0, io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("could not rewind demo: %w", err)
+	}
+	if header.InitialFlags == nil {
+		// Old demo without a header; it was recorded with default flags.
+		return flag.SetDemoFlags(map[string]string{})
+	}
+	return flag.SetDemoFlags(header.InitialFlags)
+}
+
 func BeforeExit() error {
 	if demoRecorder != nil {
 		demoRecorderFrame = frame{
 			FinalSaveGame: demoRecorderFinalSaveGame,
+			AssetDigest:   vfs.Digest(),
 		}
 		err := demoRecorder.Encode(&demoRecorderFrame)
 		if err != nil {
@@ -116,6 +170,7 @@ func BeforeExit() error {
 			return fmt.Errorf("failed to save demo to %v: %w", *demoRecord, err)
 		}
 	}
+	StopAttract()
 	if demoPlayer != nil {
 		if playReadFrame() {
 			regression(highPrio, "game ended but demo would still go on")
@@ -136,13 +191,19 @@ func Playing() bool {
 	return demoPlayer != nil
 }
 
+func Recording() bool {
+	return demoRecorder != nil
+}
+
 func Timedemo() bool {
-	return Playing() && *demoTimedemo
+	return Playing() && !demoAttracting && *demoTimedemo
 }
 
 func Update() bool {
 	wantQuit := false
-	if demoPlayer != nil {
+	if demoAttracting {
+		attractFrame()
+	} else if demoPlayer != nil {
 		wantQuit = playFrame()
 	}
 	if demoRecorder != nil {
@@ -175,6 +236,10 @@ func playReadFrame() bool {
 		if err != nil {
 			log.Fatalf("could not decode demo frame: %v", err)
 		}
+		if demoPlayerFrame.InitialFlags != nil {
+			// The header got applied at initialization.
+			continue
+		}
 		if demoPlayerFrame.FinalSaveGame == nil {
 			// Restore save game, so loading always succeeds even if we've regressed.
 			if demoPlayerFrame.SaveGame == nil {
@@ -184,6 +249,9 @@ func playReadFrame() bool {
 			}
 			return true
 		}
+		if d := demoPlayerFrame.AssetDigest; d != "" && d != vfs.Digest() {
+			log.Warningf("demo was recorded with asset digest %v but we have %v; differences may be caused by changed assets", d, vfs.Digest())
+		}
 		diff := cmp.Diff(demoPlayerFrame.FinalSaveGame.State, s.State)
 		if diff != "" {
 			regression(highPrio, "difference in final save state (-want +got):\n%v", diff)
@@ -197,6 +265,12 @@ func playFrame() bool {
 		regression(highPrio, "demo ended but game didn't quit")
 		return true
 	}
+	if demoPlayerFrame.Flags != nil {
+		err := flag.SetDemoFlags(demoPlayerFrame.Flags)
+		if err != nil {
+			log.Fatalf("could not apply demo flags: %v", err)
+		}
+	}
 	input.LoadFromDemo(demoPlayerFrame.Input)
 	return false
 }
@@ -205,6 +279,9 @@ func postPlayFrame(playerPos m.Pos) {
 	if len(demoPlayerFrame.SaveGames) != 0 {
 		regression(mediumPrio, "save game: got no saves, want %v", demoPlayerFrame.SaveGames)
 	}
+	if len(demoPlayerFrame.Effects) != 0 {
+		regression(mediumPrio, "effects: got none, want %v", demoPlayerFrame.Effects)
+	}
 	if demoPlayerFrame.PlayerPos != nil && playerPos != *demoPlayerFrame.PlayerPos {
 		d := playerPos.Delta(*demoPlayerFrame.PlayerPos).Norm1()
 		dlog := 0
@@ -217,12 +294,19 @@ func postPlayFrame(playerPos m.Pos) {
 	}
 	regressionPostPlayFrame()
 	demoPlayerFrameIdx++
+	// Skip the save game, which playback repeats in every frame.
+	addTailFrame(demoPlayerFrame.Input, demoPlayerFrame.PlayerPos)
 }
 
 func recordFrame() {
 	demoRecorderFrame = frame{
 		Input: input.SaveToDemo(),
 	}
+	// Flags may change during the game, e.g. from the assist menu.
+	if flags := flag.DemoFlags(); !maps.Equal(flags, demoRecorderFlags) {
+		demoRecorderFrame.Flags = flags
+		demoRecorderFlags = flags
+	}
 }
 
 func postRecordFrame(playerPos m.Pos) {
@@ -231,6 +315,38 @@ func postRecordFrame(playerPos m.Pos) {
 	if err != nil {
 		log.Fatalf("could not encode demo frame: %v", err)
 	}
+	addTailFrame(demoRecorderFrame.Input, demoRecorderFrame.PlayerPos)
+}
+
+// addTailFrame remembers the given frame for Tail.
+// The frame data must not be modified afterwards.
+func addTailFrame(in *input.DemoState, playerPos *m.Pos) {
+	demoTail[demoTailNext] = tailFrame{
+		input:     in,
+		playerPos: playerPos,
+	}
+	demoTailNext = (demoTailNext + 1) % tailFrames
+	if demoTailLen < tailFrames {
+		demoTailLen++
+	}
+}
+
+// Tail returns the most recently recorded or played demo frames, oldest first.
+func Tail() []json.RawMessage {
+	tail := make([]json.RawMessage, 0, demoTailLen)
+	for i := 0; i < demoTailLen; i++ {
+		f := &demoTail[(demoTailNext-demoTailLen+i+tailFrames)%tailFrames]
+		data, err := json.Marshal(&frame{
+			Input:     f.input,
+			PlayerPos: f.playerPos,
+		})
+		if err != nil {
+			log.Errorf("could not encode demo frame: %v", err)
+			continue
+		}
+		tail = append(tail, data)
+	}
+	return tail
 }
 
 func InterceptSaveGame(save *level.SaveGame) bool {
@@ -261,6 +377,26 @@ func InterceptSaveGame(save *level.SaveGame) bool {
 	return false
 }
 
+// InterceptEffect records a gameplay effect such as a hitstop, or while playing back checks it against the demo.
+func InterceptEffect(effect string) {
+	if mute.Active() {
+		return
+	}
+	if demoRecorder != nil {
+		demoRecorderFrame.Effects = append(demoRecorderFrame.Effects, effect)
+	}
+	if demoPlayer != nil {
+		if len(demoPlayerFrame.Effects) == 0 {
+			regression(mediumPrio, "effect: got %v, want none", effect)
+			return
+		}
+		if effect != demoPlayerFrame.Effects[0] {
+			regression(mediumPrio, "effect: got %v, want %v", effect, demoPlayerFrame.Effects[0])
+		}
+		demoPlayerFrame.Effects = demoPlayerFrame.Effects[1:]
+	}
+}
+
 func InterceptPreLoadGame() (*level.SaveGame, bool) {
 	// While playing back, we always return the last save game from the demo.
 	if demoPlayer != nil {
@@ -281,3 +417,19 @@ func InterceptPostLoadGame(save *level.SaveGame) {
 		demoRecorderFrame.SaveGame = save
 	}
 }
+
+func InterceptNewRNGSeed(seed int) int {
+	// While playing back, use the seed from the demo.
+	// Older demos have none; still be deterministic then.
+	if demoPlayer != nil {
+		if demoPlayerFrame.RNGSeed == nil {
+			return 0
+		}
+		return *demoPlayerFrame.RNGSeed
+	}
+	// While recording, store the seed.
+	if demoRecorder != nil {
+		demoRecorderFrame.RNGSeed = &seed
+	}
+	return seed
+}
@@ -61,6 +61,10 @@ func (p prio) WithParam(q int) prio {
 }
 
 func regression(prio prio, format string, args ...interface{}) {
+	if demoAttracting {
+		// Attract mode demos may desync; nobody cares.
+		return
+	}
 	regression := fmt.Sprintf(format, args...)
 	log.Errorf("REGRESSION: %s", regression)
 	regressionsThisFrame = append(regressionsThisFrame, regression)
@@ -0,0 +1,306 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dialog
+
+import (
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/divVerent/aaaaxy/internal/announce"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+var (
+	dialogTextSpeed   = flag.Int("dialog_text_speed", 40, "characters per second of the dialog typewriter effect; zero shows each line at once")
+	dialogAutoAdvance = flag.Duration("dialog_auto_advance", 0, "time after which a fully shown dialog line advances automatically; zero waits for input")
+)
+
+const (
+	boxMargin     = 8
+	boxPadding    = 6
+	boxHeight     = 84
+	portraitSize  = 64
+	speakerHeight = 16
+)
+
+// Line is one text box of a dialog.
+type Line struct {
+	// Speaker is the name shown above the text, if any.
+	Speaker string
+	// Portrait is shown left of the text, if set.
+	Portrait *ebiten.Image
+	// Text is the already formatted and localized text of the line.
+	Text string
+}
+
+// Options control how a dialog proceeds.
+type Options struct {
+	// AutoAdvance, if nonzero, advances fully shown lines after this time.
+	// Overrides the user setting.
+	AutoAdvance time.Duration
+	// Skippable allows the player to skip the remaining dialog using the action key.
+	Skippable bool
+}
+
+// Dialog is a running sequence of text boxes.
+type Dialog struct {
+	lines []Line
+	opts  Options
+
+	line      int
+	wrapped   []rune
+	shown     int
+	charFrame int
+	waitFrame int
+	active    bool
+	fresh     bool
+}
+
+var (
+	screenWidth, screenHeight = 640, 360
+	current                   *Dialog
+)
+
+// Start begins a new dialog, replacing any currently running one.
+func Start(lines []Line, opts Options) *Dialog {
+	d := &Dialog{
+		lines:  lines,
+		opts:   opts,
+		line:   -1,
+		active: true,
+		fresh:  true,
+	}
+	d.next()
+	current = d
+	return d
+}
+
+// Active returns whether the dialog is still running.
+func (d *Dialog) Active() bool {
+	return d != nil && d.active
+}
+
+// Stop ends the dialog immediately.
+func (d *Dialog) Stop() {
+	if d == nil {
+		return
+	}
+	d.active = false
+	if current == d {
+		current = nil
+	}
+}
+
+// Current returns the currently running dialog, if any.
+func Current() *Dialog {
+	return current
+}
+
+// SwapCurrent replaces the current dialog by the given one and returns the previous one.
+// Allows simulating a second world without disturbing the dialog shown to the player.
+func SwapCurrent(d *Dialog) *Dialog {
+	prev := current
+	current = d
+	return prev
+}
+
+// Reset ends the current dialog, e.g. on respawning.
+func Reset() {
+	current.Stop()
+}
+
+func textFace() *font.Face {
+	return font.ByName["Regular"]
+}
+
+func speakerFace() *font.Face {
+	return font.ByName["Bold"]
+}
+
+// textWidth returns the width available for text of the current line.
+func (d *Dialog) textWidth(screenWidth int) int {
+	w := screenWidth - 2*boxMargin - 2*boxPadding
+	if d.lines[d.line].Portrait != nil {
+		w -= portraitSize + boxPadding
+	}
+	return w
+}
+
+// wrap splits the text into lines fitting into the given width.
+func wrap(face *font.Face, txt string, width int) string {
+	var out []string
+	for _, para := range strings.Split(txt, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			if line == "" {
+				line = word
+				continue
+			}
+			if face.BoundString(line+" "+word).Size.DX > width {
+				out = append(out, line)
+				line = word
+			} else {
+				line += " " + word
+			}
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// next advances to the next line, or ends the dialog.
+func (d *Dialog) next() {
+	d.line++
+	if d.line >= len(d.lines) {
+		d.Stop()
+		return
+	}
+	l := &d.lines[d.line]
+	d.wrapped = []rune(wrap(textFace(), l.Text, d.textWidth(screenWidth)))
+	if l.Speaker != "" {
+		announce.Message(l.Speaker + ": " + l.Text)
+	} else {
+		announce.Message(l.Text)
+	}
+	d.shown = 0
+	d.charFrame = 0
+	d.waitFrame = 0
+	if *dialogTextSpeed <= 0 {
+		d.shown = len(d.wrapped)
+	}
+}
+
+// autoAdvanceFrames returns after how many frames a fully shown line advances, or 0 to never.
+func (d *Dialog) autoAdvanceFrames() int {
+	t := d.opts.AutoAdvance
+	if t == 0 {
+		t = *dialogAutoAdvance
+	}
+	if t <= 0 {
+		return 0
+	}
+	frames := int(t * 60 / time.Second)
+	if frames < 1 {
+		frames = 1
+	}
+	return frames
+}
+
+func (d *Dialog) update() {
+	if d.fresh {
+		// Ignore the key presses of the frame that started the dialog.
+		d.fresh = false
+		return
+	}
+	if d.opts.Skippable && input.Action.JustHit {
+		d.Stop()
+		return
+	}
+	if d.shown < len(d.wrapped) {
+		if input.Jump.JustHit {
+			// First press completes the line.
+			d.shown = len(d.wrapped)
+			return
+		}
+		d.charFrame += *dialogTextSpeed
+		for d.charFrame >= 60 && d.shown < len(d.wrapped) {
+			d.charFrame -= 60
+			d.shown++
+		}
+		return
+	}
+	if input.Jump.JustHit {
+		d.next()
+		return
+	}
+	if frames := d.autoAdvanceFrames(); frames > 0 {
+		d.waitFrame++
+		if d.waitFrame >= frames {
+			d.next()
+		}
+	}
+}
+
+func (d *Dialog) draw(screen *ebiten.Image) {
+	l := &d.lines[d.line]
+	x := boxMargin
+	y := screenHeight - boxMargin - boxHeight
+	w := screenWidth - 2*boxMargin
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(w), boxHeight, palette.EGA(palette.Black, 224), false)
+	vector.StrokeRect(screen, float32(x)+0.5, float32(y)+0.5, float32(w)-1, boxHeight-1, 1, palette.EGA(palette.LightGrey, 255), false)
+
+	// Lay out left to right, then mirror for right-to-left locales.
+	rtl := locale.ActiveIsRightToLeft()
+	layoutX := func(x, w int) int {
+		if rtl {
+			return screenWidth - x - w
+		}
+		return x
+	}
+	align := font.Left
+	if rtl {
+		align = font.Right
+	}
+
+	x += boxPadding
+	y += boxPadding
+	if l.Portrait != nil {
+		opts := &ebiten.DrawImageOptions{}
+		sz := l.Portrait.Bounds().Size()
+		opts.GeoM.Scale(float64(portraitSize)/float64(sz.X), float64(portraitSize)/float64(sz.Y))
+		opts.GeoM.Translate(float64(layoutX(x, portraitSize)), float64(y+(boxHeight-2*boxPadding-portraitSize)/2))
+		screen.DrawImage(l.Portrait, opts)
+		x += portraitSize + boxPadding
+	}
+	if l.Speaker != "" {
+		speakerFace().Draw(screen, l.Speaker, m.Pos{X: layoutX(x, 0), Y: y + speakerHeight - 4}, align,
+			palette.EGA(palette.Yellow, 255), palette.EGA(palette.Black, 255))
+		y += speakerHeight
+	}
+	textFace().Draw(screen, string(d.wrapped[:d.shown]), m.Pos{X: layoutX(x, 0), Y: y + speakerHeight - 4}, align,
+		palette.EGA(palette.White, 255), palette.EGA(palette.Black, 255))
+	if d.shown == len(d.wrapped) && d.autoAdvanceFrames() == 0 {
+		// Show that we are waiting for input.
+		ax := float32(layoutX(boxMargin+w-boxPadding-4, 4))
+		ay := float32(screenHeight - boxMargin - boxPadding - 4)
+		vector.DrawFilledRect(screen, ax, ay, 4, 4, palette.EGA(palette.LightGrey, 255), false)
+	}
+}
+
+// Update advances the current dialog. Must be called once per frame.
+func Update() {
+	if !current.Active() {
+		return
+	}
+	current.update()
+}
+
+// Draw draws the current dialog, if any.
+func Draw(screen *ebiten.Image) {
+	sz := screen.Bounds().Size()
+	screenWidth, screenHeight = sz.X, sz.Y
+	if !current.Active() {
+		return
+	}
+	current.draw(screen)
+}
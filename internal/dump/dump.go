@@ -19,7 +19,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"strings"
 	"sync"
 	"time"
@@ -33,20 +32,21 @@ import (
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/log"
 	m "github.com/divVerent/aaaaxy/internal/math"
-	"github.com/divVerent/aaaaxy/internal/namedpipe"
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
 
 var (
 	dumpVideo               = flag.String("dump_video", "", "filename prefix to dump game frames to")
 	dumpVideoFpsDivisor     = flag.Int("dump_video_fps_divisor", 1, "frame rate divisor (try 2 for faster dumping)")
+	dumpVideoSize           = flag.String("dump_video_size", "", "if set, the screen filter of -dump_media outputs video of this size, given as WIDTHxHEIGHT")
 	dumpAudio               = flag.String("dump_audio", "", "filename to dump game audio to")
-	dumpMedia               = flag.String("dump_media", "", "filename to dump game media to; exclusive with dump_video and dump_audio; when not changing any dump_*_settings, this should have a .mkv, .mov, .avi or .nut extension")
+	dumpMedia               = flag.String("dump_media", "", "filename to dump game media to; exclusive with dump_video and dump_audio; when not changing any dump_*_settings, this should have a .mkv, .mov, .avi or .nut extension; in the browser, media is recorded as WebM and offered as a download under this name")
 	dumpVideoCodecSettings  = flag.String("dump_video_codec_settings", "-codec:v mjpeg -q:v 4", "FFmpeg settings for video encoding; set to \"\" to disable the video stream for -dump_media")
 	dumpAudioCodecSettings  = flag.String("dump_audio_codec_settings", "-codec:a pcm_s16le", "FFmpeg settings for audio encoding; set to \"\" to disable the audio stream for -dump_media")
 	dumpMediaFormatSettings = flag.String("dump_media_format_settings", "-vsync vfr", "FFmpeg flags for muxing")
 	cheatDumpSlowAndGood    = flag.Bool("cheat_dump_slow_and_good", false, "non-realtime video dumping (slows down the game, thus considered a cheat))")
 	dumpMediaFrameTimeout   = flag.Duration("dump_media_frame_timeout", 300*time.Second, "maximum processing time per frame; after this time it is assumed that ffmpeg died and dumping ends")
+	dumpWorkflow            = flag.String("dump_workflow", "", "how -dump_media is produced; empty to encode live by piping to FFmpeg, or two_pass to write lossless intermediate files first, then encode them with FFmpeg when dumping ends and delete them")
 )
 
 type Params struct {
@@ -55,6 +55,8 @@ type Params struct {
 	ScreenFilterScanLines float64
 	CRTK1                 float64
 	CRTK2                 float64
+	CRTVignette           float64
+	CRTApertureMask       float64
 }
 
 type WriteCloserAt interface {
@@ -64,49 +66,60 @@ type WriteCloserAt interface {
 }
 
 var (
-	frameCount   = int64(0)
-	videoWriter  WriteCloserAt
-	audioWriter  WriteCloserAt
-	videoPipe    *namedpipe.Fifo
-	audioPipe    *namedpipe.Fifo
-	mediaCmd     *exec.Cmd
-	mediaCmdDone chan struct{}
-	params       Params
-)
-
-const (
-	dumpVideoFrameSize = engine.GameWidth * engine.GameHeight * 4
+	frameCount  = int64(0)
+	videoFrames = int64(0)
+	maxDrift    time.Duration
+	videoWriter WriteCloserAt
+	audioWriter WriteCloserAt
+	params      Params
 )
 
 var (
 	dumpVideoWg sync.WaitGroup
 )
 
+// dumpVideoFrameSize returns the size of a single dumped RGBA frame.
+func dumpVideoFrameSize() int {
+	return engine.GameWidth * engine.GameHeight * 4
+}
+
+// twoPass returns whether -dump_media is encoded from intermediate files after dumping.
+func twoPass() bool {
+	return *dumpWorkflow == "two_pass"
+}
+
 func InitEarly(p Params) error {
 	params = p
 
-	if *dumpMedia != "" {
+	switch *dumpWorkflow {
+	case "", "two_pass":
+	default:
+		return fmt.Errorf("invalid -dump_workflow: got %q, want empty or two_pass", *dumpWorkflow)
+	}
+
+	if twoPass() {
+		if *dumpMedia == "" {
+			return errors.New("-dump_workflow=two_pass requires -dump_media")
+		}
 		if *dumpVideo != "" || *dumpAudio != "" {
 			return errors.New("-dump_media is mutually exclusive with -dump_video/-dump_audio")
 		}
 		if *dumpAudioCodecSettings == "" && *dumpVideoCodecSettings == "" {
 			return errors.New("not both of -dump_audio_codec_settings and -dump_video_codec_settings may be empty - we need at least one stream")
 		}
-		var err error
-		if *dumpAudioCodecSettings != "" {
-			audioPipe, err = namedpipe.New("aaaaxy-audio", 120, 4*96000, *dumpMediaFrameTimeout)
-			if err != nil {
-				return fmt.Errorf("could not create audio pipe: %w", err)
-			}
-			audioWriter = namedpipe.NewWriteCloserAt(audioPipe)
-			audiowrap.InitDumping()
+		return initTwoPass()
+	}
+
+	if *dumpMedia != "" {
+		if *dumpVideo != "" || *dumpAudio != "" {
+			return errors.New("-dump_media is mutually exclusive with -dump_video/-dump_audio")
 		}
-		if *dumpVideoCodecSettings != "" {
-			videoPipe, err = namedpipe.New("aaaaxy-video", 120, dumpVideoFrameSize, *dumpMediaFrameTimeout)
-			if err != nil {
-				return fmt.Errorf("could not create video pipe: %w", err)
-			}
-			videoWriter = namedpipe.NewWriteCloserAt(videoPipe)
+		if *dumpAudioCodecSettings == "" && *dumpVideoCodecSettings == "" {
+			return errors.New("not both of -dump_audio_codec_settings and -dump_video_codec_settings may be empty - we need at least one stream")
+		}
+		err := initMedia()
+		if err != nil {
+			return err
 		}
 	}
 
@@ -131,34 +144,8 @@ func InitEarly(p Params) error {
 }
 
 func InitLate() error {
-	if *dumpMedia != "" {
-		audioPath := ""
-		if audioPipe != nil {
-			audioPath = audioPipe.Path()
-		}
-		videoPath := ""
-		if videoPipe != nil {
-			videoPath = videoPipe.Path()
-		}
-		cmdLine, _, err := ffmpegCommand(audioPath, videoPath, *dumpMedia, params.ScreenFilter)
-		if err != nil {
-			return err
-		}
-		mediaCmd := exec.Command(cmdLine[0], cmdLine[1:]...)
-		mediaCmd.Stdout = os.Stdout
-		mediaCmd.Stderr = os.Stderr
-		err = mediaCmd.Start()
-		if err != nil {
-			return fmt.Errorf("could not launch FFmpeg: %w", err)
-		}
-		mediaCmdDone = make(chan struct{})
-		go func() {
-			err := mediaCmd.Wait()
-			if err != nil {
-				log.Fatalf("FFmpeg died: %v", err)
-			}
-			close(mediaCmdDone)
-		}()
+	if *dumpMedia != "" && !twoPass() {
+		return startMedia()
 	}
 
 	return nil
@@ -172,6 +159,34 @@ func Slow() bool {
 	return Active() && (*cheatDumpSlowAndGood || demo.Playing())
 }
 
+// frameTime returns the game time at the end of the given number of rendered frames.
+func frameTime(frames int64) time.Duration {
+	return time.Duration(frames*int64(params.FPSDivisor)) * time.Second / engine.GameTPS
+}
+
+// drift returns how far the dumped audio is ahead of the dumped video.
+// As video frames cover a whole video frame duration, audio may legitimately be ahead by less than one video frame.
+func drift() time.Duration {
+	return audiowrap.DumpedDuration() - frameTime(videoFrames*int64(*dumpVideoFpsDivisor))
+}
+
+// checkDrift logs when audio and video got out of sync by more than one video frame.
+func checkDrift() {
+	if audioWriter == nil || videoWriter == nil {
+		return
+	}
+	d := drift()
+	if d < 0 {
+		d = -d
+	}
+	tolerance := frameTime(int64(*dumpVideoFpsDivisor))
+	if d <= tolerance || d <= maxDrift {
+		return
+	}
+	maxDrift = d
+	log.Warningf("media dump: audio/video desync of %v after %v frames", drift(), frameCount)
+}
+
 func ProcessFrameThenReturnTo(screen *ebiten.Image, to chan *ebiten.Image, frames int) {
 	if !Active() || frames == 0 {
 		to <- screen
@@ -185,14 +200,15 @@ func ProcessFrameThenReturnTo(screen *ebiten.Image, to chan *ebiten.Image, frame
 		cnt := dumpVideoFrameEnd - dumpVideoFrameBegin
 		if cnt > 0 {
 			if cnt > 1 {
-				log.Infof("video dump: %v frames dropped", cnt-1)
+				log.Infof("video dump: %v frames dropped; repeating the current one", cnt-1)
 			}
+			videoFrames = dumpVideoFrameEnd
 			dumpVideoWg.Add(1)
 			dumpPixelsRGBA(screen, func(pix []byte, err error) {
 				to <- screen
 				if err == nil {
 					for i := dumpVideoFrameBegin; i < dumpVideoFrameEnd; i++ {
-						_, err = videoWriter.WriteAt(pix, i*dumpVideoFrameSize)
+						_, err = videoWriter.WriteAt(pix, i*int64(dumpVideoFrameSize()))
 						if err != nil {
 							break
 						}
@@ -213,13 +229,14 @@ func ProcessFrameThenReturnTo(screen *ebiten.Image, to chan *ebiten.Image, frame
 		to <- screen
 	}
 	if audioWriter != nil {
-		err := audiowrap.DumpFrame(audioWriter, time.Duration(frameCount)*time.Second/engine.GameTPS)
+		err := audiowrap.DumpFrame(audioWriter, frameTime(frameCount))
 		if err != nil {
 			log.Errorf("failed to encode audio - expect corruption: %v", err)
 			audioWriter.Close()
 			audioWriter = nil
 		}
 	}
+	checkDrift()
 }
 
 func ffmpegCommand(audio, video, output, screenFilter string) ([]string, string, error) {
@@ -231,13 +248,17 @@ func ffmpegCommand(audio, video, output, screenFilter string) ([]string, string,
 		fps := float64(engine.GameTPS) / (float64(params.FPSDivisor) * float64(*dumpVideoFpsDivisor))
 		inputs = append(inputs, "-f", "rawvideo", "-pixel_format", "rgba", "-video_size", fmt.Sprintf("%dx%d", engine.GameWidth, engine.GameHeight), "-r", fmt.Sprint(fps), "-i", video)
 		filterComplex := "[0:v]premultiply=inplace=1,format=gbrp[lowres]; "
+		// Output scale factors are relative to the game size; at the default size, 3x is 1080p.
+		scale2x := fmt.Sprintf("%d:%d", 2*engine.GameWidth, 2*engine.GameHeight)
+		scale3x := fmt.Sprintf("%d:%d", 3*engine.GameWidth, 3*engine.GameHeight)
+		scale6x := fmt.Sprintf("%d:%d", 6*engine.GameWidth, 6*engine.GameHeight)
 		switch screenFilter {
 		case "linear":
-			filterComplex += "[lowres]scale=1920:1080"
+			filterComplex += "[lowres]scale=" + scale3x
 		case "linear2x":
 			// Note: the two step upscale simulates the effect of the linear2xcrt shader.
 			// "simple" does the same as "linear2x" if the screen res is exactly 1080p.
-			filterComplex += "[lowres]scale=1280:720:flags=neighbor,scale=1920:1080"
+			filterComplex += "[lowres]scale=" + scale2x + ":flags=neighbor,scale=" + scale3x
 		case "linear2xcrt":
 			// For 3x scale, pattern is: 1 (1-2/3*f) 1.
 			// darkened := m.Rint(255 * (1.0 - 2.0/3.0**screenFilterScanLines))
@@ -245,8 +266,6 @@ func ffmpegCommand(audio, video, output, screenFilter string) ([]string, string,
 			// Then second scale is to 1920:1080.
 			// But for the lens correction, we gotta do better.
 			// For 6x scale, pattern is: (1-5/6*f) (1-3/6*f) (1-1/6*f) (1-1/6*f) (1-3/6*f) (1-5/6*f).
-			pnmHeader1 := []byte("P2\n")
-			pnmHeader2 := []byte("1 2160 255\n")
 			pnmLine := []byte(fmt.Sprintf("%d %d %d %d %d %d\n",
 				m.Rint(255*(1.0-5.0/6.0*params.ScreenFilterScanLines)),
 				m.Rint(255*(1.0-3.0/6.0*params.ScreenFilterScanLines)),
@@ -254,37 +273,45 @@ func ffmpegCommand(audio, video, output, screenFilter string) ([]string, string,
 				m.Rint(255*(1.0-1.0/6.0*params.ScreenFilterScanLines)),
 				m.Rint(255*(1.0-3.0/6.0*params.ScreenFilterScanLines)),
 				m.Rint(255*(1.0-5.0/6.0*params.ScreenFilterScanLines))))
-			tempFile, err := os.CreateTemp("", "aaaaxy-*")
-			if err != nil {
-				return nil, "", err
-			}
-			atexit.Delete(tempFile.Name())
-			_, err = tempFile.Write(pnmHeader1)
+			scanLinesFile, scanLinesCmd, err := writeRepeatedPNM("P2", fmt.Sprintf("1 %d 255", 6*engine.GameHeight), pnmLine, engine.GameHeight)
 			if err != nil {
 				return nil, "", err
 			}
-			_, err = tempFile.Write(pnmHeader2)
-			if err != nil {
-				return nil, "", err
-			}
-			for range make([]struct{}, 360) {
-				_, err = tempFile.Write(pnmLine)
+			precmd += scanLinesCmd
+			inputs = append(inputs, "-f", "pgm_pipe", "-i", scanLinesFile)
+			filterComplex += fmt.Sprintf("[lowres]scale=%s:flags=neighbor,scale=%s[scaled]; [1:v]scale=%s:flags=neighbor,format=gbrp[scanlines]; [scaled][scanlines]blend=all_mode=multiply", scale2x, scale6x, scale6x)
+			if params.CRTApertureMask > 0 {
+				// For 6x scale, pattern per texel is two columns each of red, green and blue,
+				// where each column keeps 2/3 of the other channels at full effect.
+				o := m.Rint(255 * (1.0 - 1.0/3.0*params.CRTApertureMask))
+				maskLine := []byte(fmt.Sprintf("255 %d %d 255 %d %d %d 255 %d %d 255 %d %d %d 255 %d %d 255\n", o, o, o, o, o, o, o, o, o, o, o, o))
+				maskFile, maskCmd, err := writeRepeatedPNM("P3", fmt.Sprintf("%d 1 255", 6*engine.GameWidth), maskLine, engine.GameWidth)
 				if err != nil {
 					return nil, "", err
 				}
+				precmd += maskCmd
+				inputs = append(inputs, "-f", "ppm_pipe", "-i", maskFile)
+				filterComplex += fmt.Sprintf("[lined]; [2:v]scale=%s:flags=neighbor,format=gbrp[mask]; [lined][mask]blend=all_mode=multiply", scale6x)
 			}
-			err = tempFile.Close()
-			if err != nil {
-				return nil, "", err
+			filterComplex += fmt.Sprintf(",lenscorrection=i=bilinear:k1=%f:k2=%f", params.CRTK1, params.CRTK2)
+			if params.CRTVignette > 0 {
+				// Same as in the shader: corners are multiplied by 1 - vignette.
+				f := fmt.Sprintf("(1-%f*((X-W/2)*(X-W/2)+(Y-H/2)*(Y-H/2))/((W/2)*(W/2)+(H/2)*(H/2)))", params.CRTVignette)
+				filterComplex += fmt.Sprintf(",geq=r='r(X,Y)*%s':g='g(X,Y)*%s':b='b(X,Y)*%s'", f, f, f)
 			}
-			precmd = fmt.Sprintf("{ echo '%s'; echo '%s'; for i in `seq 1 360`; do echo '%s'; done } > '%s'; ", pnmHeader1[:len(pnmHeader1)-1], pnmHeader2[:len(pnmHeader2)-1], pnmLine[:len(pnmLine)-1], tempFile.Name())
-			inputs = append(inputs, "-f", "pgm_pipe", "-i", tempFile.Name())
-			filterComplex += fmt.Sprintf("[lowres]scale=1280:720:flags=neighbor,scale=3840:2160[scaled]; [1:v]scale=3840:2160:flags=neighbor,format=gbrp[scanlines]; [scaled][scanlines]blend=all_mode=multiply,lenscorrection=i=bilinear:k1=%f:k2=%f", params.CRTK1, params.CRTK2)
 		case "nearest":
-			filterComplex += "[lowres]scale=1920:1080:flags=neighbor"
+			filterComplex += "[lowres]scale=" + scale3x + ":flags=neighbor"
 		case "":
 			filterComplex += "[lowres]copy"
 		}
+		if *dumpVideoSize != "" {
+			var w, h int
+			_, err := fmt.Sscanf(*dumpVideoSize, "%dx%d", &w, &h)
+			if err != nil || w <= 0 || h <= 0 {
+				return nil, "", fmt.Errorf("invalid -dump_video_size: got %q, want WIDTHxHEIGHT", *dumpVideoSize)
+			}
+			filterComplex += fmt.Sprintf(",scale=%d:%d", w, h)
+		}
 		// Note: using high quality, fast settings and many keyframes
 		// as the assumption is that the output file will be further edited.
 		// Note: disabling 8x8 DCT here as some older FFmpeg versions -
@@ -312,6 +339,32 @@ func ffmpegCommand(audio, video, output, screenFilter string) ([]string, string,
 	return cmd, precmd, nil
 }
 
+// writeRepeatedPNM writes a PNM file consisting of the given header and line repeated count times.
+// Returns the file name and a shell command that recreates it.
+func writeRepeatedPNM(magic, size string, line []byte, count int) (string, string, error) {
+	tempFile, err := os.CreateTemp("", "aaaaxy-*")
+	if err != nil {
+		return "", "", err
+	}
+	atexit.Delete(tempFile.Name())
+	_, err = fmt.Fprintf(tempFile, "%s\n%s\n", magic, size)
+	if err != nil {
+		return "", "", err
+	}
+	for range make([]struct{}, count) {
+		_, err = tempFile.Write(line)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	err = tempFile.Close()
+	if err != nil {
+		return "", "", err
+	}
+	precmd := fmt.Sprintf("{ echo '%s'; echo '%s'; for i in `seq 1 %d`; do echo '%s'; done } > '%s'; ", magic, size, count, line[:len(line)-1], tempFile.Name())
+	return tempFile.Name(), precmd, nil
+}
+
 func printCommand(cmd []string) string {
 	r := []string{}
 	for _, arg := range cmd {
@@ -327,6 +380,9 @@ func Finish() error {
 	if videoWriter != nil {
 		dumpVideoWg.Wait()
 	}
+	if audioWriter != nil && videoWriter != nil {
+		log.Infof("media dump: final audio/video offset is %v", drift())
+	}
 	// Closing audio and video file concurrently, which helps in case they're pipes, as it's unclear in which state FFmpeg tries to read them.
 	var wg sync.WaitGroup
 	var videoErr, audioErr error
@@ -353,12 +409,14 @@ func Finish() error {
 	if videoErr != nil {
 		return fmt.Errorf("failed to close video - expect corruption: %w", videoErr)
 	}
-	if mediaCmd != nil {
-		log.Infof("waiting for FFmpeg to exit...")
-		<-mediaCmdDone
-		mediaCmdDone = nil
+	err := finishMedia()
+	if err != nil {
+		return err
 	}
 	log.Infof("media has been dumped")
+	if twoPass() {
+		return encodeTwoPass()
+	}
 	if *dumpAudio != "" || *dumpVideo != "" {
 		log.Infof("to create a preview file (DO NOT UPLOAD):")
 		cmd, precmd, err := ffmpegCommand(*dumpAudio, *dumpVideo, "video-preview.mkv", "")
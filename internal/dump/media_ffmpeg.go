@@ -0,0 +1,100 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !wasm
+// +build !wasm
+
+package dump
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/divVerent/aaaaxy/internal/audiowrap"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/namedpipe"
+)
+
+var (
+	videoPipe    *namedpipe.Fifo
+	audioPipe    *namedpipe.Fifo
+	mediaCmd     *exec.Cmd
+	mediaCmdDone chan struct{}
+)
+
+// initMedia creates the pipes -dump_media sends its streams to FFmpeg through.
+func initMedia() error {
+	var err error
+	if *dumpAudioCodecSettings != "" {
+		audioPipe, err = namedpipe.New("aaaaxy-audio", 120, 4*96000, *dumpMediaFrameTimeout)
+		if err != nil {
+			return fmt.Errorf("could not create audio pipe: %w", err)
+		}
+		audioWriter = namedpipe.NewWriteCloserAt(audioPipe)
+		audiowrap.InitDumping()
+	}
+	if *dumpVideoCodecSettings != "" {
+		videoPipe, err = namedpipe.New("aaaaxy-video", 120, dumpVideoFrameSize(), *dumpMediaFrameTimeout)
+		if err != nil {
+			return fmt.Errorf("could not create video pipe: %w", err)
+		}
+		videoWriter = namedpipe.NewWriteCloserAt(videoPipe)
+	}
+	return nil
+}
+
+// startMedia launches FFmpeg reading from the pipes.
+func startMedia() error {
+	audioPath := ""
+	if audioPipe != nil {
+		audioPath = audioPipe.Path()
+	}
+	videoPath := ""
+	if videoPipe != nil {
+		videoPath = videoPipe.Path()
+	}
+	cmdLine, _, err := ffmpegCommand(audioPath, videoPath, *dumpMedia, params.ScreenFilter)
+	if err != nil {
+		return err
+	}
+	mediaCmd = exec.Command(cmdLine[0], cmdLine[1:]...)
+	mediaCmd.Stdout = os.Stdout
+	mediaCmd.Stderr = os.Stderr
+	err = mediaCmd.Start()
+	if err != nil {
+		return fmt.Errorf("could not launch FFmpeg: %w", err)
+	}
+	mediaCmdDone = make(chan struct{})
+	go func() {
+		err := mediaCmd.Wait()
+		if err != nil {
+			log.Fatalf("FFmpeg died: %v", err)
+		}
+		close(mediaCmdDone)
+	}()
+	return nil
+}
+
+// finishMedia waits for FFmpeg to write the output file.
+// Must be called after closing the pipes.
+func finishMedia() error {
+	if mediaCmd != nil {
+		log.Infof("waiting for FFmpeg to exit...")
+		<-mediaCmdDone
+		mediaCmdDone = nil
+		mediaCmd = nil
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build wasm
+// +build wasm
+
+package dump
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"syscall/js"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/audiowrap"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+// In the browser, there is no FFmpeg to pipe to.
+// Instead, video frames are drawn to a canvas and audio is played into a MediaStream,
+// which a MediaRecorder encodes to WebM in memory; the result is offered as a download when dumping ends.
+
+const (
+	// audioLatency is how far ahead of the audio clock dumped audio gets scheduled.
+	audioLatency = 0.1
+
+	// recorderTimeslice is the interval in milliseconds in which the MediaRecorder hands out encoded data.
+	recorderTimeslice = 1000
+
+	// finishTimeout is how long to wait for the MediaRecorder to flush its data.
+	finishTimeout = 30 * time.Second
+)
+
+var (
+	browserVideo *canvasWriter
+	browserAudio *audioStreamWriter
+	recorder     js.Value
+	recorderDone chan struct{}
+	recorderFns  []js.Func
+)
+
+// canvasWriter draws dumped frames to a canvas whose stream is being recorded.
+type canvasWriter struct {
+	canvas    js.Value
+	ctx       js.Value
+	pix       js.Value
+	imageData js.Value
+	track     js.Value
+}
+
+func (w *canvasWriter) Write(p []byte) (int, error) {
+	if len(p) != dumpVideoFrameSize() {
+		return 0, fmt.Errorf("unexpected frame size: got %d, want %d", len(p), dumpVideoFrameSize())
+	}
+	js.CopyBytesToJS(w.pix, p)
+	w.ctx.Call("putImageData", w.imageData, 0, 0)
+	w.track.Call("requestFrame")
+	return len(p), nil
+}
+
+func (w *canvasWriter) WriteAt(p []byte, off int64) (int, error) {
+	// Frames arrive in order, and the canvas stream only cares about the current one.
+	return w.Write(p)
+}
+
+func (w *canvasWriter) Close() error {
+	return nil
+}
+
+// audioStreamWriter plays dumped s16le stereo audio into a MediaStream being recorded.
+type audioStreamWriter struct {
+	ctx      js.Value
+	dest     js.Value
+	rate     int
+	nextTime float64
+	rest     []byte
+}
+
+func (w *audioStreamWriter) Write(p []byte) (int, error) {
+	if w.ctx.IsUndefined() {
+		// Not started yet.
+		return len(p), nil
+	}
+	w.rest = append(w.rest, p...)
+	samples := len(w.rest) / 4
+	if samples == 0 {
+		return len(p), nil
+	}
+	buf := w.ctx.Call("createBuffer", 2, samples, w.rate)
+	for ch := 0; ch < 2; ch++ {
+		data := make([]byte, 4*samples)
+		for i := 0; i < samples; i++ {
+			s := int16(binary.LittleEndian.Uint16(w.rest[4*i+2*ch:]))
+			binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(float32(s)/32768))
+		}
+		bytes := js.Global().Get("Uint8Array").New(len(data))
+		js.CopyBytesToJS(bytes, data)
+		buf.Call("copyToChannel", js.Global().Get("Float32Array").New(bytes.Get("buffer")), ch)
+	}
+	w.rest = w.rest[4*samples:]
+	src := w.ctx.Call("createBufferSource")
+	src.Set("buffer", buf)
+	src.Call("connect", w.dest)
+	if now := w.ctx.Get("currentTime").Float(); w.nextTime < now {
+		// Fell behind, e.g. as the game was paused; leave a gap.
+		w.nextTime = now + audioLatency
+	}
+	src.Call("start", w.nextTime)
+	w.nextTime += float64(samples) / float64(w.rate)
+	return len(p), nil
+}
+
+func (w *audioStreamWriter) WriteAt(p []byte, off int64) (int, error) {
+	return w.Write(p)
+}
+
+func (w *audioStreamWriter) Close() error {
+	return nil
+}
+
+// initMedia checks that the browser can record media and sets up the writers.
+func initMedia() error {
+	if js.Global().Get("MediaRecorder").IsUndefined() {
+		return errors.New("this browser does not support MediaRecorder")
+	}
+	if *dumpAudioCodecSettings != "" {
+		if js.Global().Get("AudioContext").IsUndefined() {
+			return errors.New("this browser does not support AudioContext")
+		}
+		browserAudio = &audioStreamWriter{}
+		audioWriter = browserAudio
+		audiowrap.InitDumping()
+	}
+	if *dumpVideoCodecSettings != "" {
+		canvas := js.Global().Get("document").Call("createElement", "canvas")
+		if canvas.Get("captureStream").IsUndefined() {
+			return errors.New("this browser does not support capturing canvas streams")
+		}
+		canvas.Set("width", engine.GameWidth)
+		canvas.Set("height", engine.GameHeight)
+		pix := js.Global().Get("Uint8ClampedArray").New(dumpVideoFrameSize())
+		browserVideo = &canvasWriter{
+			canvas:    canvas,
+			ctx:       canvas.Call("getContext", "2d"),
+			pix:       pix,
+			imageData: js.Global().Get("ImageData").New(pix, engine.GameWidth, engine.GameHeight),
+		}
+		videoWriter = browserVideo
+	}
+	return nil
+}
+
+// recorderMimeType picks the best supported WebM flavor.
+func recorderMimeType() string {
+	mediaRecorder := js.Global().Get("MediaRecorder")
+	var candidates []string
+	if browserVideo != nil {
+		candidates = []string{"video/webm;codecs=vp9,opus", "video/webm;codecs=vp8,opus", "video/webm"}
+	} else {
+		candidates = []string{"audio/webm;codecs=opus", "audio/webm"}
+	}
+	for _, c := range candidates {
+		if mediaRecorder.Call("isTypeSupported", c).Bool() {
+			return c
+		}
+	}
+	// Let the browser decide.
+	return ""
+}
+
+// startMedia connects the streams to a MediaRecorder and starts recording.
+func startMedia() error {
+	stream := js.Global().Get("MediaStream").New()
+	if browserVideo != nil {
+		// Frame rate zero means frames are only captured on requestFrame.
+		videoStream := browserVideo.canvas.Call("captureStream", 0)
+		browserVideo.track = videoStream.Call("getVideoTracks").Index(0)
+		stream.Call("addTrack", browserVideo.track)
+	}
+	if browserAudio != nil {
+		browserAudio.rate = audiowrap.SampleRate()
+		browserAudio.ctx = js.Global().Get("AudioContext").New(map[string]interface{}{
+			"sampleRate": browserAudio.rate,
+		})
+		browserAudio.dest = browserAudio.ctx.Call("createMediaStreamDestination")
+		browserAudio.ctx.Call("resume")
+		stream.Call("addTrack", browserAudio.dest.Get("stream").Call("getAudioTracks").Index(0))
+	}
+	options := map[string]interface{}{}
+	if mimeType := recorderMimeType(); mimeType != "" {
+		options["mimeType"] = mimeType
+	}
+	recorder = js.Global().Get("MediaRecorder").New(stream, options)
+	chunks := js.Global().Get("Array").New()
+	done := make(chan struct{})
+	recorderDone = done
+	onData := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		chunks.Call("push", args[0].Get("data"))
+		return nil
+	})
+	onStop := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		download(chunks, recorder.Get("mimeType").String())
+		close(done)
+		return nil
+	})
+	recorderFns = append(recorderFns, onData, onStop)
+	recorder.Call("addEventListener", "dataavailable", onData)
+	recorder.Call("addEventListener", "stop", onStop)
+	recorder.Call("start", recorderTimeslice)
+	log.Infof("recording media as %v", recorder.Get("mimeType").String())
+	return nil
+}
+
+// download offers the recorded chunks as a file download.
+func download(chunks js.Value, mimeType string) {
+	blob := js.Global().Get("Blob").New(chunks, map[string]interface{}{
+		"type": mimeType,
+	})
+	url := js.Global().Get("URL").Call("createObjectURL", blob)
+	doc := js.Global().Get("document")
+	a := doc.Call("createElement", "a")
+	a.Set("href", url)
+	a.Set("download", path.Base(*dumpMedia))
+	doc.Get("body").Call("appendChild", a)
+	a.Call("click")
+	doc.Get("body").Call("removeChild", a)
+	var revoke js.Func
+	revoke = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		js.Global().Get("URL").Call("revokeObjectURL", url)
+		revoke.Release()
+		return nil
+	})
+	// The download needs the URL for a while after clicking.
+	js.Global().Call("setTimeout", revoke, 60000)
+}
+
+// finishMedia stops recording and waits for the download to be offered.
+func finishMedia() error {
+	if recorderDone == nil {
+		return nil
+	}
+	recorder.Call("stop")
+	defer func() {
+		for _, f := range recorderFns {
+			f.Release()
+		}
+		recorderFns = nil
+		recorderDone = nil
+	}()
+	select {
+	case <-recorderDone:
+	case <-time.After(finishTimeout):
+		return errors.New("timed out waiting for MediaRecorder to finish")
+	}
+	if browserAudio != nil {
+		browserAudio.ctx.Call("close")
+	}
+	log.Infof("media has been offered for download as %v", path.Base(*dumpMedia))
+	return nil
+}
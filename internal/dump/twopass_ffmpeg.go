@@ -0,0 +1,144 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !wasm
+// +build !wasm
+
+package dump
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/audiowrap"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// In the two pass workflow, raw video and audio get written to lossless
+// intermediate files next to the output file. When dumping ends, FFmpeg
+// encodes them to the -dump_media file, and they get deleted once that worked.
+// If encoding fails, they are kept, and the command to retry is logged.
+
+const (
+	// twoPassProgressStep is the percentage after which encoding progress is reported again.
+	twoPassProgressStep = 5
+)
+
+var (
+	twoPassVideo string
+	twoPassAudio string
+)
+
+// initTwoPass creates the intermediate files.
+func initTwoPass() error {
+	var err error
+	if *dumpAudioCodecSettings != "" {
+		twoPassAudio = *dumpMedia + ".audio.s16le"
+		audioWriter, err = vfs.OSCreate(vfs.WorkDir, twoPassAudio)
+		if err != nil {
+			return fmt.Errorf("could not create intermediate audio file: %w", err)
+		}
+		audiowrap.InitDumping()
+	}
+	if *dumpVideoCodecSettings != "" {
+		twoPassVideo = *dumpMedia + ".video.rgba"
+		videoWriter, err = vfs.OSCreate(vfs.WorkDir, twoPassVideo)
+		if err != nil {
+			return fmt.Errorf("could not create intermediate video file: %w", err)
+		}
+	}
+	return nil
+}
+
+// encodeTwoPass encodes the intermediate files with FFmpeg, then deletes them.
+// Must be called after closing the intermediate files.
+func encodeTwoPass() error {
+	cmdLine, precmd, err := ffmpegCommand(twoPassAudio, twoPassVideo, *dumpMedia, params.ScreenFilter)
+	if err != nil {
+		return err
+	}
+	// Report progress in machine readable form on stdout.
+	cmdLine = append([]string{cmdLine[0], "-hide_banner", "-nostats", "-progress", "pipe:1"}, cmdLine[1:]...)
+	retry := func() {
+		log.Errorf("keeping the intermediate files; to retry encoding, run:")
+		log.Errorf("  %v%v", precmd, printCommand(cmdLine))
+	}
+	log.Infof("encoding %v...", *dumpMedia)
+	cmd := exec.Command(cmdLine[0], cmdLine[1:]...)
+	cmd.Stderr = os.Stderr
+	progress, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("could not read FFmpeg progress: %w", err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		retry()
+		return fmt.Errorf("could not launch FFmpeg: %w", err)
+	}
+	total := audiowrap.DumpedDuration()
+	if twoPassVideo != "" {
+		total = frameTime(videoFrames * int64(*dumpVideoFpsDivisor))
+	}
+	reportTwoPassProgress(progress, total)
+	err = cmd.Wait()
+	if err != nil {
+		retry()
+		return fmt.Errorf("FFmpeg failed: %w", err)
+	}
+	log.Infof("media has been encoded to %v", *dumpMedia)
+	var errs []error
+	for _, name := range []string{twoPassVideo, twoPassAudio} {
+		if name == "" {
+			continue
+		}
+		err := os.Remove(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not delete intermediate file: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reportTwoPassProgress logs the progress FFmpeg reports until it is done.
+func reportTwoPassProgress(r io.Reader, total time.Duration) {
+	next := 0
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		key, value, found := strings.Cut(s.Text(), "=")
+		if !found || key != "out_time_us" || total <= 0 {
+			continue
+		}
+		us, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			// FFmpeg writes N/A before the first frame.
+			continue
+		}
+		percent := int(time.Duration(us) * time.Microsecond * 100 / total)
+		if percent < next {
+			continue
+		}
+		log.Infof("encoding %v: %d%%", *dumpMedia, min(percent, 100))
+		next = (percent/twoPassProgressStep + 1) * twoPassProgressStep
+	}
+	// Drain the pipe in case scanning stopped early, so FFmpeg does not block.
+	io.Copy(io.Discard, r)
+}
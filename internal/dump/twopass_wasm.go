@@ -0,0 +1,32 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build wasm
+// +build wasm
+
+package dump
+
+import (
+	"errors"
+)
+
+// initTwoPass fails, as there is no FFmpeg to encode with in the browser.
+func initTwoPass() error {
+	return errors.New("-dump_workflow=two_pass is not supported in the browser")
+}
+
+// encodeTwoPass is never reached, as initTwoPass fails.
+func encodeTwoPass() error {
+	return nil
+}
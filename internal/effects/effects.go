@@ -0,0 +1,75 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package effects is the central place deciding how strongly flashing and motion effects are shown.
+package effects
+
+import (
+	"github.com/divVerent/aaaaxy/internal/flag"
+)
+
+var (
+	reduceFlashing = flag.Bool("reduce_flashing", false, "reduce flashing, strobing and shaking effects")
+)
+
+const (
+	// slowBlinkFrames is the number of frames per blink phase in reduced mode.
+	// This is two blinks per second, well below the three flashes per second
+	// photosensitivity guidelines warn about.
+	slowBlinkFrames = 15
+)
+
+// frame counts the frames for the slow blinking of reduced mode.
+var frame int
+
+// Update advances the blink timer. Must be called once per frame.
+func Update() {
+	frame++
+}
+
+// Reduced returns whether flashing and motion effects shall be reduced.
+func Reduced() bool {
+	return *reduceFlashing
+}
+
+// SetReduced turns reduced mode on or off.
+func SetReduced(reduced bool) {
+	flag.Set("reduce_flashing", reduced)
+}
+
+// Motion returns the given shake or jitter offset, or zero in reduced mode.
+func Motion(d int) int {
+	if *reduceFlashing {
+		return 0
+	}
+	return d
+}
+
+// Blink returns whether a blinking element is in its "on" phase.
+// on is the state the element would normally have, which may change every frame;
+// in reduced mode it is replaced by a slow regular blink.
+func Blink(on bool) bool {
+	if !*reduceFlashing {
+		return on
+	}
+	return (frame/slowBlinkFrames)%2 == 0
+}
+
+// Attenuate returns v, or in reduced mode the neutral value it flickers around.
+func Attenuate(v, neutral float64) float64 {
+	if *reduceFlashing {
+		return neutral
+	}
+	return v
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// testProp is an entity that does nothing.
+type testProp struct{}
+
+func init() {
+	engine.RegisterEntityType(&testProp{})
+}
+
+func (p *testProp) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	return nil
+}
+
+func (p *testProp) Despawn() {}
+
+func (p *testProp) Update() {}
+
+func (p *testProp) Touch(other *engine.Entity) {}
+
+var allocTestMap = []string{
+	"##########",
+	"#        #",
+	"#        #",
+	"#   /\\   #",
+	"# P/##\\  #",
+	"##########",
+}
+
+func newAllocTestWorld(tb testing.TB) *engine.World {
+	tb.Helper()
+	w := newTestWorld(tb, allocTestMap, nil)
+	// Settle.
+	for i := 0; i < 10; i++ {
+		err := w.Update()
+		if err != nil {
+			tb.Fatalf("could not update world: %v", err)
+		}
+	}
+	return w
+}
+
+// traceForAllocs runs traces that hit a wall, a slope and an entity.
+func traceForAllocs(w *engine.World) {
+	center := w.Player.Rect.Center()
+	w.TraceBox(w.Player.Rect, center.Add(m.Delta{DX: 200, DY: -40}), engine.TraceOptions{
+		Contents:  level.PlayerSolidContents,
+		IgnoreEnt: w.Player,
+		ForEnt:    w.Player,
+	})
+	w.TraceLine(center.Add(m.Delta{DX: 100}), center, engine.TraceOptions{
+		Contents: level.SolidContents,
+	})
+	w.ResetTraceBuffers()
+}
+
+func TestTraceDoesNotAllocate(t *testing.T) {
+	w := newAllocTestWorld(t)
+	w.SetSolid(w.Player, true)
+	allocs := testing.AllocsPerRun(100, func() {
+		traceForAllocs(w)
+	})
+	if allocs != 0 {
+		t.Errorf("tracing allocated: got %v allocs per run, want 0", allocs)
+	}
+}
+
+func BenchmarkWorldUpdate(b *testing.B) {
+	w := newAllocTestWorld(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := w.Update()
+		if err != nil {
+			b.Fatalf("could not update world: %v", err)
+		}
+	}
+}
+
+func BenchmarkTrace(b *testing.B) {
+	w := newAllocTestWorld(b)
+	w.SetSolid(w.Player, true)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		traceForAllocs(w)
+	}
+}
+
+func BenchmarkSpawnDespawn(b *testing.B) {
+	w := newAllocTestWorld(b)
+	sp := &level.SpawnableProps{
+		EntityType:      "testProp",
+		Orientation:     m.Identity(),
+		Properties:      propmap.New(),
+		PersistentState: propmap.New(),
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e, err := w.SpawnDetached(sp, w.Player.Rect, m.Identity(), w.Player)
+		if err != nil {
+			b.Fatalf("could not spawn: %v", err)
+		}
+		w.Despawn(e)
+		err = w.Update()
+		if err != nil {
+			b.Fatalf("could not update world: %v", err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+// AmbientPlayer plays the looping ambient sound of a level.
+// The engine cannot import the audio packages, so the ambient package registers itself here.
+type AmbientPlayer interface {
+	// Switch fades from the current ambient sound to the given one; "" means silence.
+	Switch(name string)
+}
+
+var ambientPlayer AmbientPlayer
+
+// RegisterAmbientPlayer sets the player used for the ambient sound of the level.
+func RegisterAmbientPlayer(p AmbientPlayer) {
+	ambientPlayer = p
+}
+
+// switchAmbient starts the ambient sound of the level.
+func (w *World) switchAmbient() {
+	if w.isShadow || ambientPlayer == nil {
+		return
+	}
+	ambientPlayer.Switch(w.Level.Ambient)
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+
+	"github.com/divVerent/aaaaxy/internal/image"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// backgroundOrigin returns the screen position of the background image along one axis.
+func backgroundOrigin(offset, center, scroll int, parallax float64) int {
+	return offset + center - int(math.Floor(float64(scroll)*parallax))
+}
+
+// precacheBackgrounds loads the images of the level's backgrounds.
+func precacheBackgrounds(lvl *level.Level) error {
+	for _, bg := range lvl.Backgrounds {
+		_, err := image.Load(bg.ImageDir, bg.ImageSrc)
+		if err != nil {
+			return fmt.Errorf("could not load image %q for background: %w", bg.ImageSrc, err)
+		}
+	}
+	return nil
+}
+
+// drawBackgrounds draws the level's parallax backgrounds.
+func (r *renderer) drawBackgrounds(screen *ebiten.Image) {
+	for _, bg := range r.world.Level.Backgrounds {
+		img, err := image.Load(bg.ImageDir, bg.ImageSrc)
+		if err != nil {
+			log.Errorf("could not load already cached image %q for background: %v", bg.ImageSrc, err)
+			continue
+		}
+		sz := img.Bounds().Size()
+		x0 := backgroundOrigin(bg.Offset.DX, GameWidth/2, r.world.scrollPos.X, bg.ParallaxX)
+		y0 := backgroundOrigin(bg.Offset.DY, GameHeight/2, r.world.scrollPos.Y, bg.ParallaxY)
+		yEnd := y0 + 1
+		if bg.WrapY {
+			y0 = m.Mod(y0, sz.Y) - sz.Y
+			yEnd = GameHeight
+		}
+		for y := y0; y < yEnd; y += sz.Y {
+			for x := m.Mod(x0, sz.X) - sz.X; x < GameWidth; x += sz.X {
+				r.drawBackgroundImage(screen, img, bg, x, y)
+			}
+		}
+	}
+}
+
+// drawBackgroundImage draws a single copy of a background image.
+func (r *renderer) drawBackgroundImage(screen, img *ebiten.Image, bg *level.Background, x, y int) {
+	if r.world.GlobalColorMSet {
+		opts := colorm.DrawImageOptions{
+			Blend:  ebiten.BlendSourceOver,
+			Filter: ebiten.FilterNearest,
+		}
+		opts.GeoM.Translate(float64(x), float64(y))
+		colorM := r.world.GlobalColorM
+		colorM.Scale(1.0, 1.0, 1.0, bg.Alpha)
+		colorm.DrawImage(screen, img, colorM, &opts)
+	} else {
+		opts := ebiten.DrawImageOptions{
+			Blend:  ebiten.BlendSourceOver,
+			Filter: ebiten.FilterNearest,
+		}
+		opts.GeoM.Translate(float64(x), float64(y))
+		opts.ColorScale.ScaleAlpha(float32(bg.Alpha))
+		screen.DrawImage(img, &opts)
+	}
+}
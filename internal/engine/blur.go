@@ -27,7 +27,8 @@ import (
 )
 
 var (
-	drawBlurs = flag.Bool("draw_blurs", true, "perform blur effects; requires draw_visibility_mask")
+	drawBlurs   = flag.Bool("draw_blurs", true, "perform blur effects; requires draw_visibility_mask")
+	blurMaxSize = flag.Int("blur_max_size", 0, "if positive, limit the radius of blur effects to this many pixels (speeds up rendering)")
 )
 
 func blurPassFixedFunction(img, out *ebiten.Image, mode ebiten.Blend, dx, dy int, scale, darken float64) {
@@ -105,9 +106,18 @@ func BlurExpandImage(name string, img, out *ebiten.Image, blurSize, expandSize i
 	if !*drawBlurs {
 		blurSize = 0
 	}
+	blurSize = limitBlurSize(blurSize)
 	size := blurSize + expandSize
 	scale *= (2*float64(size) + 1) / (2*float64(blurSize) + 1)
-	BlurImage(name, img, out, size, scale, darken, 1.0)
+	blurImage(name, img, out, size, scale, darken, 1.0)
+}
+
+// limitBlurSize applies the blur_max_size flag.
+func limitBlurSize(size int) int {
+	if *blurMaxSize > 0 && size > *blurMaxSize {
+		return *blurMaxSize
+	}
+	return size
 }
 
 var (
@@ -115,6 +125,10 @@ var (
 )
 
 func BlurImage(name string, img, out *ebiten.Image, size int, scale, darken, blurFade float64) {
+	blurImage(name, img, out, limitBlurSize(size), scale, darken, blurFade)
+}
+
+func blurImage(name string, img, out *ebiten.Image, size int, scale, darken, blurFade float64) {
 	sz := img.Bounds().Size()
 	scale *= scale * blurFade
 	scale += 1 - blurFade
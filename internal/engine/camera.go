@@ -0,0 +1,146 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// Camera decides which part of the world is shown.
+//
+// By default it smoothly follows the focus point of the player. Entities can
+// constrain it by bounding it to a region, locking it to a position or
+// changing how it follows; all of these only last for the frame they are set
+// in, so entities need to renew them in every Update.
+type Camera struct {
+	// region bounds the visible area while hasRegion is set.
+	region    m.Rect
+	hasRegion bool
+	// lockPos is the position to move to while hasLock is set.
+	lockPos m.Pos
+	hasLock bool
+	// lookaheadFrames is how many frames of player movement to look ahead.
+	lookaheadFrames int
+	// damping is the fraction of the distance to the target to move each frame; zero means default.
+	damping m.Fixed
+
+	// prevPlayerOrigin is where the player was last frame, to measure velocity.
+	prevPlayerOrigin m.Pos
+}
+
+// SetRegion bounds the visible area to the given world space rectangle for this frame.
+// Areas smaller than the screen get centered.
+func (c *Camera) SetRegion(r m.Rect) {
+	c.region = r
+	c.hasRegion = true
+}
+
+// Lock makes the camera pan to and stay at the given world position for this frame.
+// While locked, the player is allowed to leave the screen.
+func (c *Camera) Lock(pos m.Pos) {
+	c.lockPos = pos
+	c.hasLock = true
+}
+
+// SetLookahead makes the camera look ahead by the given number of frames of player movement for this frame.
+func (c *Camera) SetLookahead(frames int) {
+	c.lookaheadFrames = frames
+}
+
+// SetDamping sets the fraction of the remaining distance the camera moves each frame for this frame.
+func (c *Camera) SetDamping(damping m.Fixed) {
+	c.damping = damping
+}
+
+// endFrame drops all per-frame constraints.
+func (c *Camera) endFrame() {
+	c.hasRegion = false
+	c.hasLock = false
+	c.lookaheadFrames = 0
+	c.damping = 0
+}
+
+// reset forgets all movement history, e.g. after respawning.
+func (c *Camera) reset(playerOrigin m.Pos) {
+	c.endFrame()
+	c.prevPlayerOrigin = playerOrigin
+}
+
+// updateCamera moves the camera towards the given focus point.
+func (w *World) updateCamera(focus m.Pos) {
+	c := &w.Camera
+	defer c.endFrame()
+
+	target := focus
+	if c.lookaheadFrames > 0 {
+		velocity := w.Player.Rect.Origin.Delta(c.prevPlayerOrigin)
+		target = target.Add(velocity.Mul(c.lookaheadFrames).WithMaxLengthFixed(m.NewFixed(maxLookahead)))
+	}
+	c.prevPlayerOrigin = w.Player.Rect.Origin
+	if c.hasLock {
+		target = c.lockPos
+	}
+	if c.hasRegion {
+		// Keep the screen within the region.
+		half := m.Delta{DX: GameWidth / 2, DY: GameHeight / 2}
+		screen := m.Rect{Origin: target.Sub(half), Size: half.Mul(2)}
+		target = c.region.ClampRect(screen).Origin.Add(half)
+	}
+
+	// Slowly move towards the target.
+	damping := c.damping
+	if damping == 0 {
+		damping = m.NewFixedFloat64(scrollPerFrame)
+	}
+	targetDelta := target.Delta(w.scrollPos)
+	scrollDelta := targetDelta.MulFixed(damping)
+	if scrollDelta.DX == 0 {
+		if targetDelta.DX > 0 {
+			scrollDelta.DX = +1
+		}
+		if targetDelta.DX < 0 {
+			scrollDelta.DX = -1
+		}
+	}
+	if scrollDelta.DY == 0 {
+		if targetDelta.DY > 0 {
+			scrollDelta.DY = +1
+		}
+		if targetDelta.DY < 0 {
+			scrollDelta.DY = -1
+		}
+	}
+	target = w.scrollPos.Add(scrollDelta)
+
+	if c.hasLock {
+		w.setScrollPos(target)
+		return
+	}
+
+	// Ensure player is onscreen.
+	if target.X < w.Player.Rect.OppositeCorner().X-GameWidth/2+scrollMinDistance {
+		target.X = w.Player.Rect.OppositeCorner().X - GameWidth/2 + scrollMinDistance
+	}
+	if target.X > w.Player.Rect.Origin.X+GameWidth/2-scrollMinDistance {
+		target.X = w.Player.Rect.Origin.X + GameWidth/2 - scrollMinDistance
+	}
+	if target.Y < w.Player.Rect.OppositeCorner().Y-GameHeight/2+scrollMinDistance {
+		target.Y = w.Player.Rect.OppositeCorner().Y - GameHeight/2 + scrollMinDistance
+	}
+	if target.Y > w.Player.Rect.Origin.Y+GameHeight/2-scrollMinDistance {
+		target.Y = w.Player.Rect.Origin.Y + GameHeight/2 - scrollMinDistance
+	}
+	w.setScrollPos(target)
+}
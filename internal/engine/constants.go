@@ -20,10 +20,6 @@ import (
 )
 
 const (
-	// GameWidth is the width of the displayed game area.
-	GameWidth = 640
-	// GameHeight is the height of the displayed game area.
-	GameHeight = 360
 	// GameTPS is the game ticks per second.
 	GameTPS = 60
 
@@ -51,9 +47,8 @@ const (
 	scrollPerFrame = 0.1
 	// Minimum distance from screen edge when scrolling.
 	scrollMinDistance = 2 * level.TileSize
-
-	// Fully "fade in" in one second.
-	pixelsPerSpawnFrame = (GameWidth / 2) / 60
+	// Maximum distance the camera looks ahead of the player.
+	maxLookahead = 6 * level.TileSize
 
 	// Amount of pixels to trace downwards when spawning from a checkpoint.
 	// Must be at least half the max of all checkpoint widths or heights.
@@ -71,11 +66,6 @@ const (
 	// Must also include spawnDownTracePixels to support player spawning.
 	// _TileMod or non-physics entities can be ignored.
 	borderWindowHeight = 64 + spawnDownTracePixels + 2*level.TileSize
-
-	// tileWindowWidth is the maximum known width in tiles.
-	tileWindowWidth = (GameWidth+2*borderWindowWidth+level.TileSize-2)/level.TileSize + 1
-	// tileWindowHeight is the maximum known width in tiles.
-	tileWindowHeight = (GameHeight+2*borderWindowHeight+level.TileSize-2)/level.TileSize + 1
 )
 
 // expandStep is a single expansion step.
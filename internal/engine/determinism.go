@@ -0,0 +1,168 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/divVerent/aaaaxy/internal/centerprint"
+	"github.com/divVerent/aaaaxy/internal/dialog"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/mute"
+	"github.com/divVerent/aaaaxy/internal/rng"
+)
+
+var (
+	debugDeterminismCheck = flag.Bool("debug_determinism_check", false, "simulate every game tick a second time on a shadow copy of the world and report when the two differ; slow")
+)
+
+// The determinism check keeps a shadow world that starts out like the real one
+// whenever the player is spawned from outside gameplay (new game, loading, menu respawns).
+// Every tick, the shadow is updated with the same input and random number state,
+// and the state hashes of both worlds are compared. Any difference means that
+// some gameplay code depends on something other than the inputs, such as map
+// iteration order, pointer values or timing, and demos would not verify.
+//
+// The shadow runs with sounds, speech and saving muted,
+// and with its own centerprints and dialogs.
+
+// determinismShadow is a second world simulated in lockstep to check determinism.
+type determinismShadow struct {
+	world        *World
+	centerprints centerprint.State
+	dialog       *dialog.Dialog
+}
+
+// run calls f with the global state switched to the shadow world.
+func (s *determinismShadow) run(f func()) {
+	cps := centerprint.SwapState(s.centerprints)
+	d := dialog.SwapCurrent(s.dialog)
+	mute.Do(f)
+	s.centerprints = centerprint.SwapState(cps)
+	s.dialog = dialog.SwapCurrent(d)
+}
+
+// startShadow restarts the determinism check from the current state.
+// Must be called right after respawning the player.
+func (w *World) startShadow(checkpointName string, newGameSection bool) {
+	w.stopShadow("")
+	if !*debugDeterminismCheck || w.isShadow {
+		return
+	}
+	s := &determinismShadow{
+		world: &World{
+			isShadow: true,
+		},
+	}
+	rngState := rng.SaveState()
+	var err error
+	s.run(func() {
+		err = s.world.initWithLevel(w.Level.Clone(), w.saveState)
+		if err != nil {
+			return
+		}
+		err = s.world.RespawnPlayer(checkpointName, newGameSection)
+	})
+	rng.LoadState(rngState)
+	if err != nil {
+		log.Errorf("could not start determinism check: %v", err)
+		s.run(s.world.clearEntities)
+		return
+	}
+	w.shadow = s
+}
+
+// stopShadow ends the determinism check until the player is spawned from outside gameplay again.
+// If reason is not empty, it is logged.
+func (w *World) stopShadow(reason string) {
+	s := w.shadow
+	if s == nil {
+		return
+	}
+	w.shadow = nil
+	if reason != "" {
+		log.Infof("determinism check paused until the player is spawned from outside gameplay again: %s", reason)
+	}
+	// Despawn everything so grouped sounds and emitters are released.
+	s.run(s.world.clearEntities)
+}
+
+// updateWithShadow updates the world, then repeats the update on the shadow world and compares.
+func (w *World) updateWithShadow() error {
+	rngBefore := rng.SaveState()
+	err := w.update()
+	if err != nil || w.shadow == nil {
+		return err
+	}
+	want := w.StateHash()
+	rngAfter := rng.SaveState()
+	rng.LoadState(rngBefore)
+	var got uint64
+	var shadowErr error
+	w.shadow.run(func() {
+		shadowErr = w.shadow.world.Update()
+		if shadowErr == nil {
+			got = w.shadow.world.StateHash()
+		}
+	})
+	rng.LoadState(rngAfter)
+	if shadowErr != nil {
+		log.Errorf("determinism check failed %d frames after spawn: shadow world update failed: %v", w.FramesSinceSpawn, shadowErr)
+		w.stopShadow("shadow world failed")
+		return nil
+	}
+	if got != want {
+		log.Errorf("determinism check failed %d frames after spawn: got state hash %016x on rerun, want %016x", w.FramesSinceSpawn, got, want)
+		w.stopShadow("state diverged")
+	}
+	return nil
+}
+
+// StateHash returns a hash of the gameplay relevant state of the world.
+// This covers all entities with their positions, orientations, alpha,
+// persistent and snapshotted state, as well as all random number streams.
+// Rendering only state, such as images and render offsets, is not included.
+func (w *World) StateHash() uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "frame %d scroll %v timer %v %v\n", w.FramesSinceSpawn, w.scrollPos, w.TimerStarted, w.TimerStopped)
+	if p, ok := w.Player.Impl.(PlayerEntityImpl); ok {
+		x, y, vx, vy := p.DebugPos64()
+		fmt.Fprintf(h, "player %d %d %d %d\n", x, y, vx, vy)
+	}
+	w.entities.forEach(func(e *Entity) error {
+		fmt.Fprintf(h, "entity %v %v %v %v %v", e.Incarnation, e.Rect, e.Orientation, e.Alpha, e.contents)
+		// Printing maps sorts their keys.
+		fmt.Fprintf(h, " persistent %v", e.persistentState)
+		if snap, ok := e.Impl.(Snapshotter); ok {
+			fmt.Fprintf(h, " %+v", snap.SaveSnapshot())
+		}
+		fmt.Fprintln(h)
+		return nil
+	})
+	fmt.Fprintf(h, "warpzones %v\n", w.WarpZoneStates)
+	fmt.Fprintf(h, "rng seed %d\n", rng.Seed())
+	rng.SaveState().ForEachStream(func(name string, state uint64) {
+		fmt.Fprintf(h, "rng %q %016x\n", name, state)
+	})
+	save, err := w.Level.SaveGame()
+	if err != nil {
+		log.Errorf("could not hash save game: %v", err)
+	} else {
+		fmt.Fprintf(h, "save %d\n", save.StateHash)
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+func TestDeterminismCheck(t *testing.T) {
+	if err := flag.Set("debug_determinism_check", true); err != nil {
+		t.Fatalf("could not enable determinism check: %v", err)
+	}
+	defer flag.Set("debug_determinism_check", false)
+	w := newTestWorld(t, interpolateTestMap, nil)
+	if !w.DeterminismChecking() {
+		t.Fatalf("determinism check did not start")
+	}
+	for i := 0; i < 30; i++ {
+		err := w.Update()
+		if err != nil {
+			t.Fatalf("could not update world: %v", err)
+		}
+	}
+	if !w.DeterminismChecking() {
+		t.Fatalf("determinism check failed on a deterministic world")
+	}
+
+	// Changing the world behind the check's back must be noticed.
+	w.SetOrigin(w.Player, w.Player.Rect.Origin.Add(m.Delta{DX: 1, DY: 0}))
+	err := w.Update()
+	if err != nil {
+		t.Fatalf("could not update world: %v", err)
+	}
+	if w.DeterminismChecking() {
+		t.Errorf("determinism check did not notice a difference")
+	}
+}
+
+func TestDeterminismCheckPersistentState(t *testing.T) {
+	if err := flag.Set("debug_determinism_check", true); err != nil {
+		t.Fatalf("could not enable determinism check: %v", err)
+	}
+	defer flag.Set("debug_determinism_check", false)
+	w := newTestWorld(t, interpolateTestMap, nil)
+	err := w.Update()
+	if err != nil {
+		t.Fatalf("could not update world: %v", err)
+	}
+
+	// Persistent state is part of the compared state too.
+	propmap.Set(w.Level.Player.PersistentState, "test", "changed")
+	err = w.Update()
+	if err != nil {
+		t.Fatalf("could not update world: %v", err)
+	}
+	if w.DeterminismChecking() {
+		t.Errorf("determinism check did not notice a persistent state difference")
+	}
+}
+
+func TestDeterminismCheckOverlay(t *testing.T) {
+	if err := flag.Set("debug_determinism_check", true); err != nil {
+		t.Fatalf("could not enable determinism check: %v", err)
+	}
+	defer flag.Set("debug_determinism_check", false)
+	w := newTestWorld(t, interpolateTestMap, nil)
+
+	// Overlays only exist in the real world and must not be compared.
+	ghost := engine.NewOverlay()
+	ghost.Rect = w.Player.Rect
+	w.Overlays = append(w.Overlays, ghost)
+	for i := 0; i < 30; i++ {
+		ghost.Rect.Origin.X++
+		err := w.Update()
+		if err != nil {
+			t.Fatalf("could not update world: %v", err)
+		}
+	}
+	if !w.DeterminismChecking() {
+		t.Errorf("determinism check failed because of an overlay")
+	}
+}
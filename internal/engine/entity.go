@@ -40,6 +40,9 @@ type Entity struct {
 	name         string        // Possibly searched for.
 	RequireTiles bool          // Entity requires tiles to be loaded.
 
+	// persistentState is the state the entity keeps across respawns, if any.
+	persistentState level.PersistentState
+
 	// Info needed for rendering.
 	Orientation  m.Orientation
 	Image        *ebiten.Image
@@ -48,11 +51,19 @@ type Entity struct {
 	Alpha        float64
 	ColorAdd     [4]float64
 	ColorMod     [4]float64
+	Light        *Light // If set, the entity emits light. Requires draw_lights.
 	zIndex       int
 
+	// Info needed for render interpolation.
+	prevOrigin    m.Pos
+	hasPrevOrigin bool
+
 	// Intrusive list state.
 	indexInListPlusOne [numLists]int
 
+	// Spatial hash state.
+	index entityIndexState
+
 	// Entity's own state.
 	Impl EntityImpl
 }
@@ -146,6 +157,9 @@ func (w *World) spawnAt(sp *level.SpawnableProps, rect m.Rect, transform, tInv m
 	if eTmpl == nil {
 		return nil, fmt.Errorf("unknown entity type %q", sp.EntityType)
 	}
+	// Entities are deliberately not pooled: entities keep plain pointers to
+	// other entities (ground entity, originators, trace hits) across frames,
+	// and nothing would notice if the target got reused for another entity.
 	eImplVal := reflect.New(reflect.TypeOf(eTmpl).Elem())
 	eImplVal.Elem().Set(reflect.ValueOf(eTmpl).Elem())
 	eImpl := eImplVal.Interface().(EntityImpl)
@@ -153,6 +167,7 @@ func (w *World) spawnAt(sp *level.SpawnableProps, rect m.Rect, transform, tInv m
 		Incarnation:      incarnation,
 		Transform:        transform,
 		name:             propmap.StringOr(sp.Properties, "name", ""),
+		persistentState:  sp.PersistentState,
 		Impl:             eImpl,
 		Rect:             rect,
 		Orientation:      tInv.Concat(sp.Orientation),
@@ -169,6 +184,9 @@ func (w *World) spawnAt(sp *level.SpawnableProps, rect m.Rect, transform, tInv m
 		w.unlink(e)
 		return nil, err
 	}
+	w.restorePendingSnapshot(e)
+	// Spawn and the snapshot may have changed the rect.
+	w.entityIndex.update(e)
 	return e, nil
 }
 
@@ -203,6 +221,24 @@ func (w *World) Despawn(e *Entity) {
 	w.unlink(e)
 }
 
+// RespawnByID despawns all live incarnations of the given spawnables.
+// Their tiles spawn them again, in their initial state, on the next visibility update.
+// Entities that detached from their spawnable are not affected.
+func (w *World) RespawnByID(ids ...level.EntityID) {
+	w.entities.forEach(func(e *Entity) error {
+		if e == w.Player || !e.Incarnation.IsValid() {
+			return nil
+		}
+		for _, id := range ids {
+			if e.Incarnation.ID == id {
+				w.Despawn(e)
+				break
+			}
+		}
+		return nil
+	})
+}
+
 // MutateContents mutates an entity's contents.
 func (w *World) MutateContents(e *Entity, mask, set level.Contents) {
 	if e.contents&mask == set {
@@ -0,0 +1,199 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+var (
+	entityIndexEnabled    = flag.Bool("entity_index", true, "use a spatial hash to find entities hit by traces")
+	debugCheckEntityIndex = flag.Bool("debug_check_entity_index", false, "if set, we verify that the spatial hash is up to date whenever it is used")
+)
+
+const (
+	// entityIndexCellSize is the size of a spatial hash cell in pixels.
+	entityIndexCellSize = 64
+)
+
+// entityIndex is a spatial hash of all linked entities by their rect.
+//
+// Entities changing their Rect must call World.SetRect so the index can be
+// updated. As a safety net, the index is resynchronized once per frame.
+type entityIndex struct {
+	cells map[m.Pos][]*Entity
+	// queryMark is incremented by every query and used to deduplicate entities spanning multiple cells.
+	queryMark uint64
+	// candidates is a scratch buffer for query results.
+	candidates []*Entity
+}
+
+// entityIndexState is the per-entity state of the spatial hash.
+type entityIndexState struct {
+	// rect is the rect the entity has been indexed by.
+	rect m.Rect
+	// indexed is set if the entity is in the index.
+	indexed bool
+	// queryMark is the last query the entity has been returned by.
+	queryMark uint64
+}
+
+// entityIndexCells returns the range of cells covered by the given rect.
+func entityIndexCells(r m.Rect) (m.Pos, m.Pos) {
+	tl := r.Origin.Div(entityIndexCellSize)
+	br := r.OppositeCorner().Div(entityIndexCellSize)
+	return tl, br
+}
+
+// insert adds an entity to the index.
+func (x *entityIndex) insert(e *Entity) {
+	if e.index.indexed {
+		log.Fatalf("inserting entity %v into the index twice", e)
+	}
+	if x.cells == nil {
+		x.cells = map[m.Pos][]*Entity{}
+	}
+	e.index.rect = e.Rect
+	e.index.indexed = true
+	tl, br := entityIndexCells(e.Rect)
+	for y := tl.Y; y <= br.Y; y++ {
+		for x0 := tl.X; x0 <= br.X; x0++ {
+			p := m.Pos{X: x0, Y: y}
+			x.cells[p] = append(x.cells[p], e)
+		}
+	}
+}
+
+// remove removes an entity from the index.
+func (x *entityIndex) remove(e *Entity) {
+	if !e.index.indexed {
+		log.Fatalf("removing entity %v from the index but it is not in there", e)
+	}
+	tl, br := entityIndexCells(e.index.rect)
+	for y := tl.Y; y <= br.Y; y++ {
+		for x0 := tl.X; x0 <= br.X; x0++ {
+			p := m.Pos{X: x0, Y: y}
+			cell := x.cells[p]
+			for i, other := range cell {
+				if other != e {
+					continue
+				}
+				n := len(cell) - 1
+				cell[i] = cell[n]
+				cell[n] = nil
+				cell = cell[:n]
+				break
+			}
+			if len(cell) == 0 {
+				delete(x.cells, p)
+			} else {
+				x.cells[p] = cell
+			}
+		}
+	}
+	e.index.indexed = false
+}
+
+// update moves an entity in the index if its rect changed.
+func (x *entityIndex) update(e *Entity) {
+	if !e.index.indexed || e.index.rect == e.Rect {
+		return
+	}
+	// Skip the work if the entity stays within the same cells.
+	tl0, br0 := entityIndexCells(e.index.rect)
+	tl1, br1 := entityIndexCells(e.Rect)
+	if tl0 == tl1 && br0 == br1 {
+		e.index.rect = e.Rect
+		return
+	}
+	x.remove(e)
+	x.insert(e)
+}
+
+// resync updates all entities of the given list in the index.
+// If check is set, entities that moved without World.SetRect are fatal.
+func (x *entityIndex) resync(l *entityList, check bool) {
+	for _, e := range l.items {
+		if e == nil {
+			continue
+		}
+		if check && e.index.indexed && e.index.rect != e.Rect {
+			log.Fatalf("entity %v moved from %v to %v without World.SetRect", e, e.index.rect, e.Rect)
+		}
+		x.update(e)
+	}
+}
+
+// query returns all entities of the given list that may intersect the given rect,
+// in the order of the list. The result is only valid until the next query.
+//
+// Returns false if the list should rather be scanned directly.
+func (x *entityIndex) query(r m.Rect, l *entityList) ([]*Entity, bool) {
+	if !*entityIndexEnabled {
+		return nil, false
+	}
+	if *debugCheckEntityIndex {
+		x.resync(l, true)
+	}
+	tl, br := entityIndexCells(r)
+	numCells := (br.X - tl.X + 1) * (br.Y - tl.Y + 1)
+	if numCells > len(l.items) {
+		// Cheaper to just scan.
+		return nil, false
+	}
+	x.queryMark++
+	out := x.candidates[:0]
+	for y := tl.Y; y <= br.Y; y++ {
+		for x0 := tl.X; x0 <= br.X; x0++ {
+			for _, e := range x.cells[m.Pos{X: x0, Y: y}] {
+				if e.index.queryMark == x.queryMark {
+					continue
+				}
+				e.index.queryMark = x.queryMark
+				if e.indexInListPlusOne[l.index] == 0 {
+					// Not in this list.
+					continue
+				}
+				out = append(out, e)
+			}
+		}
+	}
+	// Restore list order, so results are the same as without the index.
+	// Insertion sort, as there are usually very few candidates.
+	for i := 1; i < len(out); i++ {
+		for j := i; j > 0 && out[j].indexInListPlusOne[l.index] < out[j-1].indexInListPlusOne[l.index]; j-- {
+			out[j], out[j-1] = out[j-1], out[j]
+		}
+	}
+	x.candidates = out
+	return out, true
+}
+
+// SetRect changes the rect of an entity.
+//
+// Entities should use this instead of assigning to Rect directly, so the
+// spatial hash used by traces stays up to date. Changing Rect directly and
+// then calling SetRect with the same rect is fine too.
+func (w *World) SetRect(e *Entity, r m.Rect) {
+	e.Rect = r
+	w.entityIndex.update(e)
+}
+
+// SetOrigin moves an entity to the given origin, keeping its size.
+func (w *World) SetOrigin(e *Entity, o m.Pos) {
+	w.SetRect(e, m.Rect{Origin: o, Size: e.Rect.Size})
+}
@@ -0,0 +1,179 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine_test
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+const (
+	indexTestWidth  = 40
+	indexTestHeight = 20
+)
+
+func newIndexTestWorld(tb testing.TB, numProps int, rnd *rand.Rand) (*engine.World, []*engine.Entity) {
+	tb.Helper()
+	rows := []string{strings.Repeat("#", indexTestWidth)}
+	for i := 2; i < indexTestHeight; i++ {
+		rows = append(rows, "#"+strings.Repeat(" ", indexTestWidth-2)+"#")
+	}
+	rows = append(rows, strings.Repeat("#", indexTestWidth))
+	rows[indexTestHeight-2] = "# P" + rows[indexTestHeight-2][3:]
+	w := newTestWorld(tb, rows, nil)
+	sp := &level.SpawnableProps{
+		EntityType:      "testProp",
+		Orientation:     m.Identity(),
+		Properties:      propmap.New(),
+		PersistentState: propmap.New(),
+	}
+	props := make([]*engine.Entity, 0, numProps)
+	for i := 0; i < numProps; i++ {
+		e, err := w.SpawnDetached(sp, randomIndexTestRect(rnd), m.Identity(), w.Player)
+		if err != nil {
+			tb.Fatalf("could not spawn: %v", err)
+		}
+		w.SetSolid(e, true)
+		if i%3 == 0 {
+			w.SetOpaque(e, true)
+		}
+		e.BorderPixels = rnd.Intn(3)
+		props = append(props, e)
+	}
+	return w, props
+}
+
+func randomIndexTestRect(rnd *rand.Rand) m.Rect {
+	return m.Rect{
+		Origin: m.Pos{
+			X: level.TileSize + rnd.Intn((indexTestWidth-4)*level.TileSize),
+			Y: level.TileSize + rnd.Intn((indexTestHeight-4)*level.TileSize),
+		},
+		Size: m.Delta{DX: 1 + rnd.Intn(40), DY: 1 + rnd.Intn(40)},
+	}
+}
+
+type indexTestTrace struct {
+	box      bool
+	rect     m.Rect
+	to       m.Pos
+	contents level.Contents
+}
+
+func (tr *indexTestTrace) run(w *engine.World) engine.TraceResult {
+	o := engine.TraceOptions{
+		Contents: tr.contents,
+		ForEnt:   w.Player,
+	}
+	if tr.box {
+		return w.TraceBox(tr.rect, tr.to, o)
+	}
+	return w.TraceLine(tr.rect.Origin, tr.to, o)
+}
+
+func traceWithAndWithoutIndex(t *testing.T, w *engine.World, tr *indexTestTrace) (engine.TraceResult, engine.TraceResult) {
+	t.Helper()
+	if err := flag.Set("entity_index", false); err != nil {
+		t.Fatalf("could not disable entity index: %v", err)
+	}
+	linear := tr.run(w)
+	if err := flag.Set("entity_index", true); err != nil {
+		t.Fatalf("could not enable entity index: %v", err)
+	}
+	indexed := tr.run(w)
+	return linear, indexed
+}
+
+func TestEntityIndexMatchesLinearScan(t *testing.T) {
+	err := flag.Set("debug_check_entity_index", true)
+	if err != nil {
+		t.Fatalf("could not enable entity index checks: %v", err)
+	}
+	defer flag.Set("debug_check_entity_index", false)
+	defer flag.Set("entity_index", true)
+	rnd := rand.New(rand.NewSource(1))
+	w, props := newIndexTestWorld(t, 200, rnd)
+	hits := 0
+	for round := 0; round < 10; round++ {
+		for i := 0; i < 100; i++ {
+			tr := &indexTestTrace{
+				box:      i%2 == 0,
+				rect:     randomIndexTestRect(rnd),
+				to:       randomIndexTestRect(rnd).Origin,
+				contents: level.SolidContents,
+			}
+			if i%4 == 1 {
+				tr.contents = level.OpaqueContents
+			}
+			if !tr.box {
+				tr.rect.Size = m.Delta{DX: 1, DY: 1}
+			}
+			linear, indexed := traceWithAndWithoutIndex(t, w, tr)
+			if !reflect.DeepEqual(linear, indexed) {
+				t.Errorf("trace %+v: got %+v with index, want %+v", tr, indexed, linear)
+			}
+			if len(linear.HitEntities) != 0 {
+				hits++
+			}
+		}
+		w.ResetTraceBuffers()
+		// Move some entities around.
+		for i, e := range props {
+			if i%5 == round%5 {
+				w.SetRect(e, randomIndexTestRect(rnd))
+			}
+		}
+	}
+	if hits == 0 {
+		t.Errorf("no trace hit any entity; test is ineffective")
+	}
+}
+
+func BenchmarkTraceManyEntities(b *testing.B) {
+	for _, indexed := range []bool{false, true} {
+		name := "linear"
+		if indexed {
+			name = "indexed"
+		}
+		b.Run(name, func(b *testing.B) {
+			err := flag.Set("entity_index", indexed)
+			if err != nil {
+				b.Fatalf("could not set entity index: %v", err)
+			}
+			defer flag.Set("entity_index", true)
+			rnd := rand.New(rand.NewSource(1))
+			w, _ := newIndexTestWorld(b, 500, rnd)
+			r := m.Rect{Origin: w.Player.Rect.Origin, Size: w.Player.Rect.Size}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w.TraceBox(r, r.Origin.Add(m.Delta{DX: 8, DY: -8}), engine.TraceOptions{
+					Contents:  level.PlayerSolidContents,
+					IgnoreEnt: w.Player,
+					ForEnt:    w.Player,
+				})
+				w.ResetTraceBuffers()
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/divVerent/aaaaxy/internal/level"
+)
+
+// InitWithLevel initializes the world using the given level instead of the precached one.
+func (w *World) InitWithLevel(lvl *level.Level) error {
+	return w.initWithLevel(lvl, 0)
+}
+
+// ResetTraceBuffers makes the per-frame trace buffers available again, as done at the start of every frame.
+func (w *World) ResetTraceBuffers() {
+	w.resetHitEntities()
+}
+
+// DeterminismChecking returns whether the determinism check is currently running.
+func (w *World) DeterminismChecking() bool {
+	return w.shadow != nil
+}
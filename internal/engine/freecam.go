@@ -0,0 +1,144 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/divVerent/aaaaxy/internal/centerprint"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+const (
+	// freeCameraSpeed is the free camera speed in pixels per frame.
+	// Must be less than level.TileSize so tiles can be loaded one step at a time.
+	freeCameraSpeed = 4
+	// freeCameraFastSpeed is the free camera speed while jump is held.
+	freeCameraFastSpeed = 12
+)
+
+// SetFreeCamera detaches or reattaches the camera from the player.
+//
+// While detached, entities do not update, and the world is loaded and
+// displayed around the camera instead of around the player. The caller is
+// responsible for restoring the world around the player when reattaching,
+// e.g. using a snapshot taken before detaching.
+func (w *World) SetFreeCamera(active bool) {
+	if active {
+		w.stopShadow("free camera")
+	}
+	w.freeCamera = active
+	w.freeCameraPos = w.scrollPos
+}
+
+// FreeCamera returns whether the camera is detached from the player.
+func (w *World) FreeCamera() bool {
+	return w.freeCamera
+}
+
+// moveFreeCamera moves the free camera by the given delta, loading the tiles on its way.
+// Tiles are loaded even through walls so the camera can fly anywhere.
+func (w *World) moveFreeCamera(d m.Delta) {
+	from := w.freeCameraPos.Div(level.TileSize)
+	target := w.freeCameraPos.Add(d)
+	to := target.Div(level.TileSize)
+	// Step one axis at a time; diagonal loading would sidestep warpzones.
+	if to.X != from.X {
+		next := m.Pos{X: to.X, Y: from.Y}
+		if w.loadFreeCameraTile(from, next) == nil {
+			return
+		}
+		from = next
+	}
+	if to.Y != from.Y {
+		if w.loadFreeCameraTile(from, to) == nil {
+			return
+		}
+	}
+	w.freeCameraPos = target
+}
+
+func (w *World) loadFreeCameraTile(from, to m.Pos) *level.Tile {
+	// The camera is noclip, so allow loading from opaque tiles.
+	return w.loadTile(from, to, to.Delta(from), true)
+}
+
+// updateFreeCamera replaces Update while the camera is detached.
+func (w *World) updateFreeCamera() error {
+	speed := freeCameraSpeed
+	if input.Jump.Held {
+		speed = freeCameraFastSpeed
+	}
+	var d m.Delta
+	if input.Left.Held {
+		d.DX -= speed
+	}
+	if input.Right.Held {
+		d.DX += speed
+	}
+	if input.Up.Held {
+		d.DY -= speed
+	}
+	if input.Down.Held {
+		d.DY += speed
+	}
+	w.moveFreeCamera(d)
+	w.setScrollPos(w.freeCameraPos)
+	w.updateVisibility(w.freeCameraPos, w.MaxVisiblePixels)
+	centerprint.Update()
+	w.AssumeChanged()
+	return nil
+}
+
+// drawFreeCamera shows what is under the mouse cursor while the camera is detached.
+func (r *renderer) drawFreeCamera(screen *ebiten.Image, scrollDelta m.Delta) {
+	if !r.world.freeCamera || r.world.isShadow {
+		// The title background flies a free camera too, but is no debugging aid.
+		return
+	}
+	pos, status := input.Mouse()
+	if status == input.NoMouse {
+		return
+	}
+	worldPos := pos.Sub(scrollDelta)
+	fg := palette.EGA(palette.White, 255)
+	bg := palette.EGA(palette.Black, 255)
+	y := 0
+	printLine := func(s string) {
+		y += font.ByName["Small"].LineHeight()
+		font.ByName["Small"].Draw(screen, s, m.Pos{X: pos.X + 8, Y: pos.Y + y}, font.Left, fg, bg)
+	}
+	tilePos := worldPos.Div(level.TileSize)
+	if tile := r.world.Tile(tilePos); tile != nil {
+		screenPos := tilePos.Mul(level.TileSize).Add(scrollDelta)
+		vector.StrokeRect(screen, float32(screenPos.X), float32(screenPos.Y), level.TileSize, level.TileSize, 1, palette.EGA(palette.Yellow, 255), false)
+		printLine(fmt.Sprintf("tile %d,%d %v", tile.LevelPos.X, tile.LevelPos.Y, tile.Transform))
+	}
+	r.world.entities.forEach(func(ent *Entity) error {
+		if !ent.Rect.Contains(worldPos) {
+			return nil
+		}
+		vector.StrokeRect(screen, float32(ent.Rect.Origin.X+scrollDelta.DX), float32(ent.Rect.Origin.Y+scrollDelta.DY), float32(ent.Rect.Size.DX), float32(ent.Rect.Size.DY), 1, palette.EGA(palette.LightCyan, 255), false)
+		printLine(fmt.Sprintf("%v %v", ent.Incarnation, ent.Rect))
+		return nil
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/level"
+)
+
+const (
+	// DefaultGameWidth is the default, speedrun legal, width of the game area.
+	DefaultGameWidth = 640
+	// DefaultGameHeight is the default, speedrun legal, height of the game area.
+	DefaultGameHeight = 360
+
+	// minGameSize is the smallest allowed width or height of the game area.
+	minGameSize = 240
+	// maxGameSize is the largest allowed width or height of the game area.
+	maxGameSize = 2048
+)
+
+var (
+	gameWidth  = flag.Int("game_width", DefaultGameWidth, "logical width of the game area in pixels; anything but the default reveals more or less of the level and is not speedrun legal")
+	gameHeight = flag.Int("game_height", DefaultGameHeight, "logical height of the game area in pixels; anything but the default reveals more or less of the level and is not speedrun legal")
+)
+
+func init() {
+	flag.RestrictSpeedrun("game_width")
+	flag.RestrictSpeedrun("game_height")
+	// Visibility and spawning depend on the game size.
+	flag.RecordInDemo("game_width")
+	flag.RecordInDemo("game_height")
+}
+
+var (
+	// GameWidth is the width of the displayed game area.
+	// Only valid after InitGameSize.
+	GameWidth = DefaultGameWidth
+	// GameHeight is the height of the displayed game area.
+	// Only valid after InitGameSize.
+	GameHeight = DefaultGameHeight
+
+	// pixelsPerSpawnFrame makes the spawn effect fully "fade in" in one second.
+	pixelsPerSpawnFrame = (DefaultGameWidth / 2) / 60
+
+	// tileWindowWidth is the maximum known width in tiles.
+	tileWindowWidth = tileWindowSize(DefaultGameWidth, borderWindowWidth)
+	// tileWindowHeight is the maximum known width in tiles.
+	tileWindowHeight = tileWindowSize(DefaultGameHeight, borderWindowHeight)
+)
+
+// tileWindowSize returns the number of tiles needed to cover the given screen size and border.
+func tileWindowSize(size, border int) int {
+	return (size+2*border+level.TileSize-2)/level.TileSize + 1
+}
+
+// InitGameSize applies the game size flags.
+// Must be called before anything that depends on the game size is initialized,
+// as buffers are allocated based on it.
+func InitGameSize() error {
+	if *gameWidth < minGameSize || *gameWidth > maxGameSize {
+		return fmt.Errorf("invalid game width %d: must be between %d and %d", *gameWidth, minGameSize, maxGameSize)
+	}
+	if *gameHeight < minGameSize || *gameHeight > maxGameSize {
+		return fmt.Errorf("invalid game height %d: must be between %d and %d", *gameHeight, minGameSize, maxGameSize)
+	}
+	GameWidth, GameHeight = *gameWidth, *gameHeight
+	pixelsPerSpawnFrame = (GameWidth / 2) / 60
+	tileWindowWidth = tileWindowSize(GameWidth, borderWindowWidth)
+	tileWindowHeight = tileWindowSize(GameHeight, borderWindowHeight)
+	return nil
+}
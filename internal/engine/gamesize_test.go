@@ -0,0 +1,87 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+)
+
+func TestGameSizeSpeedrunLegal(t *testing.T) {
+	defer func() {
+		flag.ResetFlagToDefault("game_width")
+		flag.ResetFlagToDefault("game_height")
+		engine.InitGameSize()
+	}()
+	for _, tc := range []struct {
+		width, height int
+		legal         bool
+	}{
+		{engine.DefaultGameWidth, engine.DefaultGameHeight, true},
+		{768, 480, false},
+		{1280, 360, false},
+		{360, 640, false},
+		{640, 361, false},
+	} {
+		flag.Set("game_width", tc.width)
+		flag.Set("game_height", tc.height)
+		if err := engine.InitGameSize(); err != nil {
+			t.Fatalf("InitGameSize(%dx%d): %v", tc.width, tc.height, err)
+		}
+		if engine.GameWidth != tc.width || engine.GameHeight != tc.height {
+			t.Errorf("game size after InitGameSize(%dx%d): got %dx%d", tc.width, tc.height, engine.GameWidth, engine.GameHeight)
+		}
+		if legal, reasons := flag.SpeedrunLegal(); legal != tc.legal {
+			t.Errorf("SpeedrunLegal() at %dx%d: got %v (%q), want %v", tc.width, tc.height, legal, reasons, tc.legal)
+		}
+	}
+}
+
+func TestGameSizeRejectsInvalid(t *testing.T) {
+	defer func() {
+		flag.ResetFlagToDefault("game_width")
+		engine.InitGameSize()
+	}()
+	flag.Set("game_width", 16)
+	if err := engine.InitGameSize(); err == nil {
+		t.Errorf("InitGameSize accepted a game width of 16")
+	}
+}
+
+func TestGameSizeFromDemo(t *testing.T) {
+	defer func() {
+		flag.ResetFlagToDefault("game_width")
+		flag.ResetFlagToDefault("game_height")
+		engine.InitGameSize()
+	}()
+	flag.Set("game_width", 768)
+	recorded := flag.DemoFlags()
+	if got, want := recorded["game_width"], "768"; got != want {
+		t.Errorf("DemoFlags()[game_width]: got %q, want %q", got, want)
+	}
+	flag.ResetFlagToDefault("game_width")
+	flag.Set("game_height", 480)
+	if err := flag.SetDemoFlags(recorded); err != nil {
+		t.Fatalf("SetDemoFlags(%v): %v", recorded, err)
+	}
+	if err := engine.InitGameSize(); err != nil {
+		t.Fatalf("InitGameSize: %v", err)
+	}
+	if engine.GameWidth != 768 || engine.GameHeight != engine.DefaultGameHeight {
+		t.Errorf("game size from demo: got %dx%d, want 768x%d", engine.GameWidth, engine.GameHeight, engine.DefaultGameHeight)
+	}
+}
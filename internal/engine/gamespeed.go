@@ -0,0 +1,45 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/divVerent/aaaaxy/internal/flag"
+)
+
+const (
+	// MinGameSpeed is the slowest supported game speed.
+	MinGameSpeed = 0.5
+	// MaxGameSpeed is the normal game speed.
+	MaxGameSpeed = 1.0
+)
+
+var (
+	gameSpeed = flag.Float64("game_speed", MaxGameSpeed, "speed of the game relative to normal, from 0.5 to 1; slows down the tick rate (assist option)")
+)
+
+func init() {
+	flag.MarkAssist("game_speed")
+}
+
+// GameSpeed returns the factor by which the game runs slower than normal while playing.
+func GameSpeed() float64 {
+	if *gameSpeed < MinGameSpeed {
+		return MinGameSpeed
+	}
+	if *gameSpeed > MaxGameSpeed {
+		return MaxGameSpeed
+	}
+	return *gameSpeed
+}
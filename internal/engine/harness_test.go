@@ -0,0 +1,271 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/constants"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/game/player"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// testInput is the input state of the test player for a single frame.
+type testInput struct {
+	left, right m.Fixed
+	jump        bool
+}
+
+// testPlayer is a minimal player entity driven by a scripted input sequence.
+// It uses the movement physics of the real player, so the golden traces
+// catch changes to how movement feels.
+type testPlayer struct {
+	mixins.Physics
+	player.Movement
+	World  *engine.World
+	Entity *engine.Entity
+
+	script []testInput
+	frame  int
+}
+
+func init() {
+	engine.RegisterEntityType(&testPlayer{})
+}
+
+func (p *testPlayer) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	p.World = w
+	p.Entity = e
+	p.Physics.Init(w, e, level.PlayerSolidContents, p.handleTouch)
+	return nil
+}
+
+func (p *testPlayer) Despawn() {}
+
+func (p *testPlayer) Touch(other *engine.Entity) {}
+
+func (p *testPlayer) handleTouch(trace engine.TraceResult) {
+	p.Movement.Touched(&p.Physics, trace.HitDelta)
+}
+
+func (p *testPlayer) Respawned() {
+	p.Physics.Reset()
+	p.CoyoteFrames = player.ExtraGroundFrames
+	p.Jumping = true
+	p.JumpingUp = false
+}
+
+func (p *testPlayer) EyePos() m.Pos {
+	return p.Entity.Rect.Center()
+}
+
+func (p *testPlayer) LookPos() m.Pos {
+	return p.Entity.Rect.Center()
+}
+
+func (p *testPlayer) DebugPos64() (x int64, y int64, vx int64, vy int64) {
+	x = int64(p.Entity.Rect.Origin.X)*constants.SubPixelScale + int64(p.SubPixel.DX)
+	y = int64(p.Entity.Rect.Origin.Y)*constants.SubPixelScale + int64(p.SubPixel.DY)
+	return x, y, int64(p.Velocity.DX), int64(p.Velocity.DY)
+}
+
+func (p *testPlayer) Update() {
+	var in testInput
+	if p.frame < len(p.script) {
+		in = p.script[p.frame]
+	}
+	p.frame++
+
+	p.Movement.Jump(&p.Physics, in.jump, player.JumpOptions{
+		BufferFrames: player.JumpBufferFrames,
+	})
+	p.Movement.Walk(&p.Physics, m.Delta{DX: 1, DY: 0}, in.left, in.right)
+	p.Physics.Update() // May call handleTouch.
+	p.Movement.Landed(&p.Physics, player.ExtraGroundFrames)
+}
+
+var _ engine.PlayerEntityImpl = &testPlayer{}
+
+// parseScript parses an input script.
+//
+// The script is a space separated list of steps of the form <keys>*<frames>,
+// where keys is any combination of L, R and J, or . for no input.
+// Lower case l and r push the stick only halfway.
+func parseScript(s string) ([]testInput, error) {
+	var script []testInput
+	for _, step := range strings.Fields(s) {
+		var keys string
+		var frames int
+		_, err := fmt.Sscanf(strings.Replace(step, "*", " ", 1), "%s %d", &keys, &frames)
+		if err != nil {
+			return nil, fmt.Errorf("invalid script step %q: %w", step, err)
+		}
+		var in testInput
+		for _, k := range keys {
+			switch k {
+			case 'L':
+				in.left = m.FixedOne
+			case 'l':
+				in.left = m.FixedOne / 2
+			case 'R':
+				in.right = m.FixedOne
+			case 'r':
+				in.right = m.FixedOne / 2
+			case 'J':
+				in.jump = true
+			case '.':
+			default:
+				return nil, fmt.Errorf("invalid key %q in script step %q", k, step)
+			}
+		}
+		for i := 0; i < frames; i++ {
+			script = append(script, in)
+		}
+	}
+	return script, nil
+}
+
+// parseLevel builds a level from an ASCII map.
+//
+// Each character is one tile:
+// - # is a solid wall,
+// - / is a slope going up to the right,
+// - \ is a slope going down to the right,
+// - P is the player start,
+// - everything else is empty space.
+//
+// The map must be surrounded by walls.
+func parseLevel(rows []string) (*level.Level, error) {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	lvl := level.New(width, len(rows))
+	upSlope, err := level.ParseSlope("0 16")
+	if err != nil {
+		return nil, err
+	}
+	downSlope, err := level.ParseSlope("16 0")
+	if err != nil {
+		return nil, err
+	}
+	for y, row := range rows {
+		for x := 0; x < width; x++ {
+			pos := m.Pos{X: x, Y: y}
+			c := byte('#')
+			if x < len(row) {
+				c = row[x]
+			}
+			t := level.Tile{
+				Orientation: m.Identity(),
+			}
+			switch c {
+			case '#':
+				t.Contents = level.AllContents
+			case '/':
+				t.Contents = level.SolidContents
+				t.Slope = upSlope
+			case '\\':
+				t.Contents = level.SolidContents
+				t.Slope = downSlope
+			case 'P':
+				if lvl.Player != nil {
+					return nil, fmt.Errorf("more than one player start at %v", pos)
+				}
+				lvl.Player = &level.Spawnable{
+					ID: 0,
+					SpawnableProps: level.SpawnableProps{
+						EntityType:      "testPlayer",
+						Orientation:     m.Identity(),
+						Properties:      propmap.New(),
+						PersistentState: propmap.New(),
+					},
+					LevelPos: pos,
+					// Stand on the bottom of the tile, centered.
+					RectInTile: m.Rect{
+						Origin: m.Pos{
+							X: (level.TileSize - player.PlayerWidth) / 2,
+							Y: level.TileSize - player.PlayerHeight,
+						},
+						Size: m.Delta{DX: player.PlayerWidth, DY: player.PlayerHeight},
+					},
+				}
+				lvl.Checkpoints[""] = lvl.Player
+			}
+			lvl.SetTile(pos, t)
+		}
+	}
+	if lvl.Player == nil {
+		return nil, fmt.Errorf("no player start")
+	}
+	return lvl, nil
+}
+
+// newTestWorld parses the given map and initializes a world on it.
+// If prepare is not nil, it can modify the level before the world is initialized.
+func newTestWorld(tb testing.TB, rows []string, prepare func(lvl *level.Level)) *engine.World {
+	tb.Helper()
+	lvl, err := parseLevel(rows)
+	if err != nil {
+		tb.Fatalf("could not parse level: %v", err)
+	}
+	if prepare != nil {
+		prepare(lvl)
+	}
+	w := &engine.World{}
+	err = w.InitWithLevel(lvl)
+	if err != nil {
+		tb.Fatalf("could not init world: %v", err)
+	}
+	return w
+}
+
+// traceFrame is the player state after one frame.
+type traceFrame struct {
+	x, y, vx, vy int64
+	onGround     bool
+}
+
+func (f traceFrame) String() string {
+	return fmt.Sprintf("%d %d %d %d %v", f.x, f.y, f.vx, f.vy, f.onGround)
+}
+
+// runTrace runs the given script on the given map and returns the player trace.
+func runTrace(t *testing.T, rows []string, script string) []traceFrame {
+	t.Helper()
+	inputs, err := parseScript(script)
+	if err != nil {
+		t.Fatalf("could not parse script: %v", err)
+	}
+	w := newTestWorld(t, rows, nil)
+	p := w.Player.Impl.(*testPlayer)
+	p.script = inputs
+	trace := make([]traceFrame, 0, len(inputs))
+	for range inputs {
+		err := w.Update()
+		if err != nil {
+			t.Fatalf("could not update world: %v", err)
+		}
+		x, y, vx, vy := p.DebugPos64()
+		trace = append(trace, traceFrame{x: x, y: y, vx: vx, vy: vy, onGround: p.OnGround})
+	}
+	return trace
+}
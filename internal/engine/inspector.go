@@ -0,0 +1,229 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+var (
+	cheatEntityInspector = flag.Bool("cheat_entity_inspector", false, "list the entities on screen; click one to select it and print its details, then edit it by typing commands into the terminal (type 'help' for a list)")
+)
+
+// inspectorCommands receives lines typed into the terminal.
+// It is created when the inspector is first used.
+var inspectorCommands chan string
+
+func startInspectorCommands() {
+	if inspectorCommands != nil {
+		return
+	}
+	inspectorCommands = make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			inspectorCommands <- scanner.Text()
+		}
+		if err := scanner.Err(); err != nil {
+			log.Infof("entity inspector: no more commands from the terminal: %v", err)
+		}
+	}()
+	log.Infof("entity inspector: click an entity to select it, then type commands here; type 'help' for a list")
+}
+
+// inspectorTypeName returns a short name of the type of an entity.
+func inspectorTypeName(e *Entity) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", e.Impl), "*")
+}
+
+// inspectedEntity returns the currently selected entity, if it is still spawned.
+func (w *World) inspectedEntity() *Entity {
+	if !w.inspected.IsValid() {
+		return nil
+	}
+	var found *Entity
+	w.entities.forEach(func(e *Entity) error {
+		if e.Incarnation == w.inspected {
+			found = e
+		}
+		return nil
+	})
+	return found
+}
+
+// logInspectedEntity prints all details of the selected entity.
+func (w *World) logInspectedEntity(e *Entity) {
+	log.Infof("entity inspector: %v %v name=%q rect=%v contents=%v orientation=%v", inspectorTypeName(e), e.Incarnation, e.name, e.Rect, e.contents, e.Orientation)
+	sp := w.Level.SpawnableByID(e.Incarnation.ID)
+	if sp == nil {
+		return
+	}
+	propmap.ForEach(sp.Properties, func(k, v string) error {
+		log.Infof("entity inspector:   property %s = %q", k, v)
+		return nil
+	})
+	propmap.ForEach(sp.PersistentState, func(k, v string) error {
+		log.Infof("entity inspector:   state %s = %q", k, v)
+		return nil
+	})
+}
+
+// runInspectorCommand applies a command typed into the terminal to the selected entity.
+func (w *World) runInspectorCommand(cmd string) error {
+	args := strings.Fields(cmd)
+	if len(args) == 0 {
+		return nil
+	}
+	if args[0] == "help" {
+		log.Infof("entity inspector: commands: show, move <dx> <dy>, prop <key> <value>, state <key> <value>, unstate <key>")
+		return nil
+	}
+	e := w.inspectedEntity()
+	if e == nil {
+		return fmt.Errorf("no entity selected")
+	}
+	sp := w.Level.SpawnableByID(e.Incarnation.ID)
+	switch {
+	case args[0] == "show" && len(args) == 1:
+		// Just print below.
+	case args[0] == "move" && len(args) == 3:
+		dx, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid dx: %w", err)
+		}
+		dy, err := strconv.Atoi(args[2])
+		if err != nil {
+			return fmt.Errorf("invalid dy: %w", err)
+		}
+		r := e.Rect
+		r.Origin = r.Origin.Add(m.Delta{DX: dx, DY: dy})
+		w.SetRect(e, r)
+	case args[0] == "prop" && len(args) >= 3 && sp != nil:
+		propmap.Set(sp.Properties, args[1], strings.Join(args[2:], " "))
+		// Respawn so the entity picks up the change.
+		w.Despawn(e)
+	case args[0] == "state" && len(args) >= 3 && sp != nil:
+		propmap.Set(sp.PersistentState, args[1], strings.Join(args[2:], " "))
+		w.Despawn(e)
+	case args[0] == "unstate" && len(args) == 2 && sp != nil:
+		propmap.Delete(sp.PersistentState, args[1])
+		w.Despawn(e)
+	default:
+		return fmt.Errorf("invalid command %q; type 'help' for a list", cmd)
+	}
+	if e = w.inspectedEntity(); e != nil {
+		w.logInspectedEntity(e)
+	} else {
+		log.Infof("entity inspector: %v will respawn next frame", w.inspected)
+	}
+	return nil
+}
+
+// updateInspector handles selecting entities and terminal commands.
+func (w *World) updateInspector() {
+	if !*cheatEntityInspector {
+		return
+	}
+	startInspectorCommands()
+	pos, status := input.Mouse()
+	if status == input.ClickingMouse && !w.inspectorClicking {
+		worldPos := pos.Add(w.scrollPos.Delta(m.Pos{X: GameWidth / 2, Y: GameHeight / 2}))
+		var best *Entity
+		w.entities.forEach(func(e *Entity) error {
+			if !e.Rect.Contains(worldPos) {
+				return nil
+			}
+			// Prefer the smallest entity, as large ones are usually triggers around it.
+			if best == nil || e.Rect.Size.DX*e.Rect.Size.DY < best.Rect.Size.DX*best.Rect.Size.DY {
+				best = e
+			}
+			return nil
+		})
+		if best != nil {
+			w.inspected = best.Incarnation
+			w.logInspectedEntity(best)
+		}
+	}
+	w.inspectorClicking = status == input.ClickingMouse
+	for {
+		select {
+		case cmd := <-inspectorCommands:
+			err := w.runInspectorCommand(cmd)
+			if err != nil {
+				log.Errorf("entity inspector: %v", err)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// drawInspector lists the entities on screen and highlights the selected one.
+func (r *renderer) drawInspector(screen *ebiten.Image, scrollDelta m.Delta) {
+	if !*cheatEntityInspector {
+		return
+	}
+	screenRect := m.Rect{
+		Origin: m.Pos{}.Sub(scrollDelta),
+		Size:   m.Delta{DX: GameWidth, DY: GameHeight},
+	}
+	var visible []*Entity
+	r.world.entities.forEach(func(e *Entity) error {
+		if e.Rect.Overlaps(screenRect) {
+			visible = append(visible, e)
+		}
+		return nil
+	})
+	sort.Slice(visible, func(i, j int) bool {
+		a, b := visible[i].Incarnation, visible[j].Incarnation
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		if a.TilePos.Y != b.TilePos.Y {
+			return a.TilePos.Y < b.TilePos.Y
+		}
+		return a.TilePos.X < b.TilePos.X
+	})
+	f := font.ByName["Small"]
+	y := 0
+	for _, e := range visible {
+		y += f.LineHeight()
+		if y > GameHeight {
+			break
+		}
+		fg := palette.EGA(palette.LightGrey, 255)
+		if e.Incarnation == r.world.inspected && e.Incarnation.IsValid() {
+			fg = palette.EGA(palette.Yellow, 255)
+			vector.StrokeRect(screen, float32(e.Rect.Origin.X+scrollDelta.DX), float32(e.Rect.Origin.Y+scrollDelta.DY), float32(e.Rect.Size.DX), float32(e.Rect.Size.DY), 1, fg, false)
+		}
+		f.Draw(screen, fmt.Sprintf("%s %d %v %v", inspectorTypeName(e), e.Incarnation.ID, e.Rect, e.contents), m.Pos{X: 0, Y: y}, font.Left, fg, palette.EGA(palette.Black, 255))
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+var (
+	renderInterpolation = flag.Bool("render_interpolation", false, "when the display refreshes faster than the game ticks, interpolate positions between the last two ticks for smoother motion; adds up to one tick of display latency")
+)
+
+// maxInterpolationDistance is the largest movement in one tick that is interpolated.
+// Anything moving further is assumed to have teleported, and is drawn at its current position.
+const maxInterpolationDistance = level.TileSize
+
+// RenderInterpolation returns whether render interpolation is enabled.
+func RenderInterpolation() bool {
+	return *renderInterpolation
+}
+
+// SetRenderFraction sets how far rendering is between the previous and the current game tick.
+// 0 draws everything where it was on the previous tick, 1 draws the current state.
+// This only affects rendering, never game state, so demos stay deterministic.
+func (w *World) SetRenderFraction(f float64) {
+	if f < 0 {
+		f = 0
+	}
+	if f > 1 || !*renderInterpolation {
+		f = 1
+	}
+	w.renderLag = 1 - f
+}
+
+// storePrevPositions remembers the current positions as the previous tick's for interpolation.
+func (w *World) storePrevPositions() {
+	w.prevScrollPos, w.hasPrevScrollPos = w.scrollPos, true
+	w.entities.forEach(func(ent *Entity) error {
+		ent.prevOrigin, ent.hasPrevOrigin = ent.Rect.Origin, true
+		return nil
+	})
+}
+
+// PrevOrigin returns the entity's origin as of the previous game tick.
+// Returns false if the entity did not exist then.
+func (e *Entity) PrevOrigin() (m.Pos, bool) {
+	return e.prevOrigin, e.hasPrevOrigin
+}
+
+// interpolate returns the position to render something at that moved from prev to cur during the last tick.
+func (w *World) interpolate(prev, cur m.Pos, hasPrev bool) m.Pos {
+	if !hasPrev || w.renderLag <= 0 {
+		return cur
+	}
+	d := prev.Delta(cur)
+	if d.Norm0() > maxInterpolationDistance {
+		return cur
+	}
+	return cur.Add(m.Delta{
+		DX: m.Rint(float64(d.DX) * w.renderLag),
+		DY: m.Rint(float64(d.DY) * w.renderLag),
+	})
+}
+
+// InterpolatedOrigin returns the origin to render the entity at.
+func (w *World) InterpolatedOrigin(e *Entity) m.Pos {
+	return w.interpolate(e.prevOrigin, e.Rect.Origin, e.hasPrevOrigin)
+}
+
+// interpolatedScrollPos returns the scroll position to render at.
+func (w *World) interpolatedScrollPos() m.Pos {
+	return w.interpolate(w.prevScrollPos, w.scrollPos, w.hasPrevScrollPos)
+}
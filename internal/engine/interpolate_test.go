@@ -0,0 +1,81 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+var interpolateTestMap = []string{
+	"######",
+	"#    #",
+	"# P  #",
+	"#    #",
+	"#    #",
+	"#    #",
+	"#    #",
+	"######",
+}
+
+func TestInterpolatedOrigin(t *testing.T) {
+	if err := flag.Set("render_interpolation", true); err != nil {
+		t.Fatalf("could not enable render interpolation: %v", err)
+	}
+	defer flag.Set("render_interpolation", false)
+	w := newTestWorld(t, interpolateTestMap, nil)
+	// Jump to gain some speed.
+	script, err := parseScript("J*10")
+	if err != nil {
+		t.Fatalf("could not parse script: %v", err)
+	}
+	w.Player.Impl.(*testPlayer).script = script
+	for range script {
+		err := w.Update()
+		if err != nil {
+			t.Fatalf("could not update world: %v", err)
+		}
+	}
+	prev, ok := w.Player.PrevOrigin()
+	if !ok {
+		t.Fatalf("player has no previous origin")
+	}
+	cur := w.Player.Rect.Origin
+	if prev == cur {
+		t.Fatalf("player did not move: at %v", cur)
+	}
+
+	w.SetRenderFraction(0)
+	if got := w.InterpolatedOrigin(w.Player); got != prev {
+		t.Errorf("unexpected origin at fraction 0: got %v, want %v", got, prev)
+	}
+	w.SetRenderFraction(1)
+	if got := w.InterpolatedOrigin(w.Player); got != cur {
+		t.Errorf("unexpected origin at fraction 1: got %v, want %v", got, cur)
+	}
+	w.SetRenderFraction(0.5)
+	got := w.InterpolatedOrigin(w.Player)
+	if got.Y < min(prev.Y, cur.Y) || got.Y > max(prev.Y, cur.Y) || got.X != cur.X {
+		t.Errorf("unexpected origin at fraction 0.5: got %v, want between %v and %v", got, prev, cur)
+	}
+
+	// Teleports are not interpolated.
+	w.SetOrigin(w.Player, cur.Add(m.Delta{DX: 0, DY: -100}))
+	if got := w.InterpolatedOrigin(w.Player); got != w.Player.Rect.Origin {
+		t.Errorf("unexpected origin after teleport: got %v, want %v", got, w.Player.Rect.Origin)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/offscreen"
+)
+
+var (
+	drawLights = flag.Bool("draw_lights", true, "draw dynamic lights (turn off on low-end machines)")
+)
+
+const (
+	// lightBlurSize is the blur radius applied to the light layer.
+	lightBlurSize = 4
+	// lightSegments is the number of triangles used for a full circle light.
+	lightSegments = 32
+)
+
+// Light is a point or cone light attached to an entity.
+type Light struct {
+	// Offset is the light position relative to the entity center, in entity orientation.
+	Offset m.Delta
+	// Radius is the distance at which the light has faded out.
+	Radius int
+	// Color is the light color at the center; alpha scales intensity.
+	Color color.NRGBA
+	// Direction, if not zero, makes this a cone light pointing in that direction, in entity orientation.
+	Direction m.Delta
+	// ConeAngle is half the opening angle of a cone light, in radians.
+	ConeAngle float64
+}
+
+// drawLight adds a single light to the light layer.
+func (r *renderer) drawLight(dest *ebiten.Image, ent *Entity, l *Light, scrollDelta m.Delta) {
+	center := ent.Rect.Center().Add(r.world.InterpolatedOrigin(ent).Delta(ent.Rect.Origin)).Add(ent.Orientation.Apply(l.Offset)).Add(scrollDelta)
+	alpha := float32(l.Color.A) / 255 * float32(ent.Alpha)
+	cr := float32(l.Color.R) / 255 * alpha
+	cg := float32(l.Color.G) / 255 * alpha
+	cb := float32(l.Color.B) / 255 * alpha
+
+	startAngle, endAngle := 0.0, 2*math.Pi
+	segments := lightSegments
+	if !l.Direction.IsZero() {
+		dir := ent.Orientation.Apply(l.Direction)
+		angle := math.Atan2(float64(dir.DY), float64(dir.DX))
+		startAngle, endAngle = angle-l.ConeAngle, angle+l.ConeAngle
+		segments = int(math.Ceil(lightSegments * l.ConeAngle / math.Pi))
+		if segments < 1 {
+			segments = 1
+		}
+	}
+
+	// A triangle fan from the center; the color fades out linearly towards the rim.
+	vertices := make([]ebiten.Vertex, 0, segments+2)
+	indices := make([]uint16, 0, 3*segments)
+	vertices = append(vertices, ebiten.Vertex{
+		DstX:   float32(center.X),
+		DstY:   float32(center.Y),
+		SrcX:   0.5,
+		SrcY:   0.5,
+		ColorR: cr,
+		ColorG: cg,
+		ColorB: cb,
+		ColorA: alpha,
+	})
+	for i := 0; i <= segments; i++ {
+		angle := startAngle + (endAngle-startAngle)*float64(i)/float64(segments)
+		vertices = append(vertices, ebiten.Vertex{
+			DstX: float32(float64(center.X) + float64(l.Radius)*math.Cos(angle)),
+			DstY: float32(float64(center.Y) + float64(l.Radius)*math.Sin(angle)),
+			SrcX: 0.5,
+			SrcY: 0.5,
+		})
+		if i > 0 {
+			indices = append(indices, 0, uint16(i), uint16(i+1))
+		}
+	}
+	dest.DrawTriangles(vertices, indices, r.whiteImage, &ebiten.DrawTrianglesOptions{
+		Blend:          ebiten.BlendLighter,
+		ColorScaleMode: ebiten.ColorScaleModePremultipliedAlpha,
+	})
+}
+
+// drawLights renders all entity lights, blurs them and adds them to the screen.
+func (r *renderer) drawLights(screen *ebiten.Image, scrollDelta m.Delta) {
+	if !*drawLights {
+		return
+	}
+	var lightImage *ebiten.Image
+	r.world.entities.forEach(func(ent *Entity) error {
+		if ent.Light == nil || ent.Light.Radius <= 0 || ent.Alpha == 0 {
+			return nil
+		}
+		if lightImage == nil {
+			lightImage = offscreen.New("Lights", GameWidth, GameHeight)
+			lightImage.Clear()
+		}
+		r.drawLight(lightImage, ent, ent.Light, scrollDelta)
+		return nil
+	})
+	if lightImage == nil {
+		return
+	}
+	defer offscreen.Dispose(lightImage)
+	BlurImage("BlurLights", lightImage, lightImage, lightBlurSize, 1.0, 0.0, 1.0)
+	screen.DrawImage(lightImage, &ebiten.DrawImageOptions{
+		Blend:  ebiten.BlendLighter,
+		Filter: ebiten.FilterNearest,
+	})
+}
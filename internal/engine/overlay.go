@@ -0,0 +1,27 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+// NewOverlay creates an entity that is only drawn, not simulated.
+//
+// To show it, add it to World.Overlays. Overlays are not spawned, updated,
+// touched, traced, saved or hashed by the determinism check, so they can show
+// state that does not come from the game itself, such as other players.
+func NewOverlay() *Entity {
+	return &Entity{
+		Alpha:    1.0,
+		ColorMod: [4]float64{1.0, 1.0, 1.0, 1.0},
+	}
+}
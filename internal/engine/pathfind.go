@@ -0,0 +1,113 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// pathDirs are the directions path finding may move in.
+var pathDirs = [...]m.Delta{m.North(), m.East(), m.South(), m.West()}
+
+// pathNode is a tile visited during path finding.
+type pathNode struct {
+	worldPos  m.Pos
+	levelPos  m.Pos
+	transform m.Orientation
+	parent    int
+	steps     int
+}
+
+// pathPassable returns whether path finding may enter the given tile.
+func pathPassable(t *level.Tile) bool {
+	// Slopes can be walked on, so they do not block the path.
+	return !t.Contents.PlayerSolid() || !t.Slope.IsZero()
+}
+
+// FindPath finds a shortest path of tiles the player can pass through.
+//
+// from is a tile position in the world, and must be currently loaded. to is a
+// tile position in the level. The path follows warp zones the same way tile
+// loading does, i.e. it is searched on the universal covering of the level.
+//
+// The search gives up on paths of more than maxSteps steps, as the level is
+// too large to search completely.
+//
+// Returns the path in world tile coordinates, starting with from and ending
+// with the world tile that maps to to, or nil if there is no such path.
+func (w *World) FindPath(from, to m.Pos, maxSteps int) []m.Pos {
+	start := w.Tile(from)
+	if start == nil {
+		log.Errorf("trying to find a path from a tile that is not loaded: %v", from)
+		return nil
+	}
+	nodes := []pathNode{{
+		worldPos:  from,
+		levelPos:  start.LevelPos,
+		transform: start.Transform,
+		parent:    -1,
+	}}
+	// As the neighbors of a level tile do not depend on the transform
+	// it has been reached with, each level tile only needs visiting once.
+	visited := map[m.Pos]struct{}{
+		start.LevelPos: {},
+	}
+	for i := 0; i < len(nodes); i++ {
+		node := nodes[i]
+		if node.levelPos == to {
+			path := make([]m.Pos, 0, 16)
+			for j := i; j >= 0; j = nodes[j].parent {
+				path = append(path, nodes[j].worldPos)
+			}
+			for a, b := 0, len(path)-1; a < b; a, b = a+1, b-1 {
+				path[a], path[b] = path[b], path[a]
+			}
+			return path
+		}
+		if node.steps >= maxSteps {
+			// Nodes are visited in order of steps, so all further nodes are too far too.
+			break
+		}
+		for _, d := range pathDirs {
+			newLevelPos := node.levelPos.Add(node.transform.Apply(d))
+			newLevelTile := w.Level.Tile(newLevelPos)
+			if newLevelTile == nil {
+				continue
+			}
+			newLevelTile, t, err := w.applyWarpZones(node.levelPos, newLevelTile, node.transform)
+			if err != nil {
+				log.Errorf("%v", err)
+				continue
+			}
+			if _, found := visited[newLevelTile.Tile.LevelPos]; found {
+				continue
+			}
+			if !pathPassable(&newLevelTile.Tile) {
+				continue
+			}
+			visited[newLevelTile.Tile.LevelPos] = struct{}{}
+			nodes = append(nodes, pathNode{
+				worldPos:  node.worldPos.Add(d),
+				levelPos:  newLevelTile.Tile.LevelPos,
+				transform: t,
+				parent:    i,
+				steps:     node.steps + 1,
+			})
+		}
+	}
+	return nil
+}
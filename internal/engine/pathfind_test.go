@@ -0,0 +1,115 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+var pathTestMap = []string{
+	"##########",
+	"#P #     #",
+	"##########",
+}
+
+func newPathTestWorld(t *testing.T, warp *level.WarpZone) *engine.World {
+	t.Helper()
+	return newTestWorld(t, pathTestMap, func(lvl *level.Level) {
+		if warp != nil {
+			// Entering the wall tile next to the player leads into the right room.
+			wt := lvl.Tile(m.Pos{X: 2, Y: 1})
+			wt.WarpZones = append(wt.WarpZones, warp)
+		}
+	})
+}
+
+func TestFindPath(t *testing.T) {
+	w := newPathTestWorld(t, nil)
+	got := w.FindPath(m.Pos{X: 1, Y: 1}, m.Pos{X: 1, Y: 1}, 100)
+	if want := []m.Pos{{X: 1, Y: 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("path to self: got %v, want %v", got, want)
+	}
+	got = w.FindPath(m.Pos{X: 1, Y: 1}, m.Pos{X: 8, Y: 1}, 100)
+	if got != nil {
+		t.Errorf("path into walled off room: got %v, want nil", got)
+	}
+}
+
+func TestFindPathThroughWarpZone(t *testing.T) {
+	w := newPathTestWorld(t, &level.WarpZone{
+		Name:      "test",
+		PrevTile:  m.Pos{X: 1, Y: 1},
+		ToTile:    m.Pos{X: 4, Y: 1},
+		Transform: m.Identity(),
+	})
+	got := w.FindPath(m.Pos{X: 1, Y: 1}, m.Pos{X: 8, Y: 1}, 100)
+	want := []m.Pos{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1}, {X: 4, Y: 1}, {X: 5, Y: 1}, {X: 6, Y: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFindPathMaxSteps(t *testing.T) {
+	w := newPathTestWorld(t, &level.WarpZone{
+		Name:      "test",
+		PrevTile:  m.Pos{X: 1, Y: 1},
+		ToTile:    m.Pos{X: 4, Y: 1},
+		Transform: m.Identity(),
+	})
+	if got := w.FindPath(m.Pos{X: 1, Y: 1}, m.Pos{X: 8, Y: 1}, 5); len(got) != 6 {
+		t.Errorf("path of exactly the maximum length: got %v, want 6 tiles", got)
+	}
+	if got := w.FindPath(m.Pos{X: 1, Y: 1}, m.Pos{X: 8, Y: 1}, 4); got != nil {
+		t.Errorf("path longer than the maximum: got %v, want nil", got)
+	}
+}
+
+func TestFindPathThroughFlippingWarpZone(t *testing.T) {
+	w := newPathTestWorld(t, &level.WarpZone{
+		Name:      "test",
+		PrevTile:  m.Pos{X: 1, Y: 1},
+		ToTile:    m.Pos{X: 6, Y: 1},
+		Transform: m.FlipX(),
+	})
+	// Past the warp, the level is mirrored, so reaching the right end of
+	// the room means walking back left across the tile we came from.
+	got := w.FindPath(m.Pos{X: 1, Y: 1}, m.Pos{X: 8, Y: 1}, 100)
+	want := []m.Pos{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 1, Y: 1}, {X: 0, Y: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFindPathHonorsWarpZoneState(t *testing.T) {
+	w := newPathTestWorld(t, &level.WarpZone{
+		Name:       "test",
+		Switchable: true,
+		PrevTile:   m.Pos{X: 1, Y: 1},
+		ToTile:     m.Pos{X: 4, Y: 1},
+		Transform:  m.Identity(),
+	})
+	if got := w.FindPath(m.Pos{X: 1, Y: 1}, m.Pos{X: 8, Y: 1}, 100); got != nil {
+		t.Errorf("path through disabled warp zone: got %v, want nil", got)
+	}
+	w.SetWarpZoneState("test", true)
+	if got := w.FindPath(m.Pos{X: 1, Y: 1}, m.Pos{X: 8, Y: 1}, 100); got == nil {
+		t.Errorf("no path through enabled warp zone")
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden physics traces in testdata")
+
+var physicsTests = []struct {
+	name   string
+	level  []string
+	script string
+}{
+	{
+		name: "flat_walk",
+		level: []string{
+			"##########",
+			"#        #",
+			"#        #",
+			"# P      #",
+			"##########",
+		},
+		script: ".*10 R*40 .*20 L*20",
+	},
+	{
+		name: "wall_bump",
+		level: []string{
+			"######",
+			"#    #",
+			"#    #",
+			"#P   #",
+			"######",
+		},
+		script: "R*60 L*10",
+	},
+	{
+		name: "jump",
+		level: []string{
+			"########",
+			"#      #",
+			"#      #",
+			"#      #",
+			"#      #",
+			"# P    #",
+			"########",
+		},
+		script: ".*5 J*1 .*40 RJ*20 R*30",
+	},
+	{
+		name: "ceiling_bump",
+		level: []string{
+			"#######",
+			"#     #",
+			"## ####",
+			"#     #",
+			"#     #",
+			"#P    #",
+			"#######",
+		},
+		script: ".*5 J*30 .*20",
+	},
+	{
+		name: "slope_up",
+		level: []string{
+			"##########",
+			"#        #",
+			"#        #",
+			"#        #",
+			"#      /##",
+			"#P    /###",
+			"##########",
+		},
+		script: ".*5 R*80",
+	},
+	{
+		name: "slope_down",
+		level: []string{
+			"##########",
+			"#        #",
+			"#        #",
+			"#P       #",
+			"##\\      #",
+			"###\\     #",
+			"##########",
+		},
+		script: ".*20 R*60 .*20",
+	},
+	{
+		name: "ledge_fall",
+		level: []string{
+			"########",
+			"#      #",
+			"#P     #",
+			"###    #",
+			"#      #",
+			"#      #",
+			"########",
+		},
+		script: ".*20 R*30 .*30",
+	},
+	{
+		name: "jump_buffer",
+		level: []string{
+			"########",
+			"#      #",
+			"#      #",
+			"#      #",
+			"#      #",
+			"# P    #",
+			"########",
+		},
+		script: ".*5 J*20 .*21 J*1 .*30",
+	},
+	{
+		name: "coyote_jump",
+		level: []string{
+			"########",
+			"#      #",
+			"#      #",
+			"#P     #",
+			"###    #",
+			"#      #",
+			"########",
+		},
+		script: ".*20 R*22 RJ*10 R*30",
+	},
+	{
+		name: "analog_walk",
+		level: []string{
+			"##########",
+			"#        #",
+			"#        #",
+			"# P      #",
+			"##########",
+		},
+		script: ".*10 r*40 .*20 l*20",
+	},
+}
+
+// TestPhysicsGolden runs scripted inputs on small synthetic levels and
+// compares the resulting player movement frame by frame against golden traces.
+//
+// After an intentional change to movement, regenerate the traces using:
+// go test ./internal/engine -run TestPhysicsGolden -update
+func TestPhysicsGolden(t *testing.T) {
+	for _, tc := range physicsTests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			trace := runTrace(t, tc.level, tc.script)
+			var got strings.Builder
+			fmt.Fprintf(&got, "# frame x y vx vy onground\n")
+			for i, f := range trace {
+				fmt.Fprintf(&got, "%d %v\n", i, f)
+			}
+			path := filepath.Join("testdata", "physics", tc.name+".golden")
+			if *updateGolden {
+				err := os.MkdirAll(filepath.Dir(path), 0o755)
+				if err != nil {
+					t.Fatalf("could not create golden directory: %v", err)
+				}
+				err = os.WriteFile(path, []byte(got.String()), 0o644)
+				if err != nil {
+					t.Fatalf("could not write golden trace: %v", err)
+				}
+				return
+			}
+			wantBytes, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("could not read golden trace (run with -update to create): %v", err)
+			}
+			gotLines := strings.Split(got.String(), "\n")
+			wantLines := strings.Split(string(wantBytes), "\n")
+			for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+				var gotLine, wantLine string
+				if i < len(gotLines) {
+					gotLine = gotLines[i]
+				}
+				if i < len(wantLines) {
+					wantLine = wantLines[i]
+				}
+				if gotLine != wantLine {
+					t.Fatalf("trace differs from %v at line %d: got %q, want %q", path, i+1, gotLine, wantLine)
+				}
+			}
+		})
+	}
+}
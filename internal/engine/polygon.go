@@ -56,8 +56,9 @@ func allocIndices(indices int) []uint16 {
 	return polyIndices[:indices]
 }
 
-// drawPolygonAround draws a filled polygon.
-func drawPolygonAround(dst *ebiten.Image, center m.Pos, vertices []m.Pos, src *ebiten.Image, color color.Color, geoM, texM ebiten.GeoM, options *ebiten.DrawTrianglesOptions) {
+// polygonAroundTriangles returns the triangles of a filled polygon.
+// The returned slices are only valid until the next call.
+func polygonAroundTriangles(center m.Pos, vertices []m.Pos, color color.Color, geoM, texM ebiten.GeoM) ([]ebiten.Vertex, []uint16) {
 	rI, gI, bI, aI := color.RGBA()
 	r, g, b, a := float32(rI)/65535.0, float32(gI)/65535.0, float32(bI)/65535.0, float32(aI)/65535.0
 	eVerts := allocVerts(len(vertices) + 1)
@@ -73,13 +74,20 @@ func drawPolygonAround(dst *ebiten.Image, center m.Pos, vertices []m.Pos, src *e
 		}
 		eIndices[3*i+2] = uint16(i + 1)
 	}
-	dst.DrawTriangles(eVerts, eIndices, src, options)
+	return eVerts, eIndices
 }
 
 // drawAntiPolygonAround draws all pixels except for the ones covered by the polygon.
 // The polygon must go exactly clockwise or counterclockwise from center.
 // Minkowski expanded polygons do NOT fulfill this right now, as they can contain self intersections! TODO fix this?
 func drawAntiPolygonAround(dst *ebiten.Image, center m.Pos, vertices []m.Pos, src *ebiten.Image, color color.Color, geoM, texM ebiten.GeoM, options *ebiten.DrawTrianglesOptions) {
+	eVerts, eIndices := antiPolygonAroundTriangles(center, vertices, color, geoM, texM)
+	dst.DrawTriangles(eVerts, eIndices, src, options)
+}
+
+// antiPolygonAroundTriangles returns the triangles covering everything except the polygon.
+// The returned slices are only valid until the next call.
+func antiPolygonAroundTriangles(center m.Pos, vertices []m.Pos, color color.Color, geoM, texM ebiten.GeoM) ([]ebiten.Vertex, []uint16) {
 	rI, gI, bI, aI := color.RGBA()
 	r, g, b, a := float32(rI)/65535.0, float32(gI)/65535.0, float32(bI)/65535.0, float32(aI)/65535.0
 	eVerts := allocVerts(len(vertices) * 2)
@@ -93,8 +101,8 @@ func drawAntiPolygonAround(dst *ebiten.Image, center m.Pos, vertices []m.Pos, sr
 		d2y := v.DstY - c.DstY
 		fL := -d2x / c.DstX
 		fU := -d2y / c.DstY
-		fR := d2x / (GameWidth - c.DstX)
-		fD := d2y / (GameHeight - c.DstY)
+		fR := d2x / (float32(GameWidth) - c.DstX)
+		fD := d2y / (float32(GameHeight) - c.DstY)
 		f := fL
 		if f < fU {
 			f = fU
@@ -126,7 +134,7 @@ func drawAntiPolygonAround(dst *ebiten.Image, center m.Pos, vertices []m.Pos, sr
 			eIndices[6*i+5] = uint16(2*i + 1)
 		}
 	}
-	dst.DrawTriangles(eVerts, eIndices, src, options)
+	return eVerts, eIndices
 }
 
 // expandSimpleFrac is the fraction of pixels to move. Should be odd so m.Div() is symmetric.
@@ -0,0 +1,98 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/divVerent/aaaaxy/internal/image"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// SoundPrefetcher prefetches sounds by name.
+// The engine cannot import the sound package, so the sound package registers itself here.
+type SoundPrefetcher interface {
+	Prefetching() bool
+	Prefetch(name string)
+}
+
+var soundPrefetcher SoundPrefetcher
+
+// RegisterSoundPrefetcher sets the prefetcher used for sounds of entities near the visible area.
+func RegisterSoundPrefetcher(p SoundPrefetcher) {
+	soundPrefetcher = p
+}
+
+// soundPrefetching returns whether sounds are prefetched.
+func soundPrefetching() bool {
+	return soundPrefetcher != nil && soundPrefetcher.Prefetching()
+}
+
+// prefetchTiles is how many tiles around visible tiles assets are prefetched for.
+const prefetchTiles = 3
+
+// prefetchAroundVisible starts decoding images and sounds used near the visible area,
+// so entering a new area does not hitch on slow storage when assets are not precached.
+//
+// Neighbors are taken in level space, so warpzones are not followed;
+// this is a heuristic, and anything missed is simply loaded on demand.
+func (w *World) prefetchAroundVisible() {
+	if w.isShadow || (!image.Prefetching() && !soundPrefetching()) {
+		return
+	}
+	if w.prefetchedLevelTiles == nil {
+		w.prefetchedLevelTiles = map[m.Pos]struct{}{}
+	}
+	w.forEachTile(func(_ int, tile *level.Tile) {
+		if tile.VisibilityFlags&level.FrameVis != w.frameVis {
+			return
+		}
+		if _, found := w.prefetchedLevelTiles[tile.LevelPos]; found {
+			return
+		}
+		w.prefetchedLevelTiles[tile.LevelPos] = struct{}{}
+		for dy := -prefetchTiles; dy <= prefetchTiles; dy++ {
+			for dx := -prefetchTiles; dx <= prefetchTiles; dx++ {
+				pos := tile.LevelPos.Add(m.Delta{DX: dx, DY: dy})
+				if !w.Level.InBounds(pos) {
+					continue
+				}
+				levelTile := w.Level.Tile(pos)
+				if levelTile == nil {
+					continue
+				}
+				prefetchTile(&levelTile.Tile)
+			}
+		}
+	})
+}
+
+// prefetchTile prefetches the assets of a tile and the entities on it.
+func prefetchTile(tile *level.Tile) {
+	if tile.ImageSrc != "" {
+		image.Prefetch("tiles", tile.ImageSrc)
+	}
+	for _, src := range tile.ImageSrcByOrientation() {
+		image.Prefetch("tiles", src)
+	}
+	for _, sp := range tile.Spawnables {
+		if src, err := propmap.Value(sp.Properties, "image", ""); err == nil && src != "" {
+			image.Prefetch(propmap.StringOr(sp.Properties, "image_dir", "sprites"), src)
+		}
+		if name, err := propmap.Value(sp.Properties, "sound", ""); err == nil && name != "" && soundPrefetching() {
+			soundPrefetcher.Prefetch(name)
+		}
+	}
+}
@@ -24,6 +24,8 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/vector"
 
 	"github.com/divVerent/aaaaxy/internal/centerprint"
+	"github.com/divVerent/aaaaxy/internal/dialog"
+	"github.com/divVerent/aaaaxy/internal/effects"
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/font"
 	"github.com/divVerent/aaaaxy/internal/image"
@@ -33,6 +35,7 @@ import (
 	m "github.com/divVerent/aaaaxy/internal/math"
 	"github.com/divVerent/aaaaxy/internal/offscreen"
 	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
 	"github.com/divVerent/aaaaxy/internal/shader"
 	"github.com/divVerent/aaaaxy/internal/timing"
 )
@@ -77,6 +80,27 @@ type renderer struct {
 
 	// visibilityMaskImage is an offscreen image used for masking the visible area.
 	visibilityMaskImage *ebiten.Image
+	// tileBatches are the vertex buffers for drawing tiles, by source image.
+	tileBatches map[*ebiten.Image]*tileBatch
+	// tileDraws are the tiles to draw when not batching.
+	tileDraws []imageDraw
+	// entityDraws are the entities to draw, in drawing order.
+	entityDraws []entityDraw
+}
+
+// imageDraw is a single image prepared for drawing.
+type imageDraw struct {
+	img  *ebiten.Image
+	geoM ebiten.GeoM
+}
+
+// entityDraw is a single entity prepared for drawing.
+type entityDraw struct {
+	imageDraw
+	// colormods is set if colorM is to be used instead of colorScale.
+	colormods  bool
+	colorM     colorm.ColorM
+	colorScale ebiten.ColorScale
 }
 
 func (r *renderer) Init(w *World) {
@@ -145,7 +169,13 @@ func setGeoM(geoM *ebiten.GeoM, pos m.Pos, resize bool, entSize, imgSize m.Delta
 	}
 }
 
-func (r *renderer) drawTiles(screen *ebiten.Image, scrollDelta m.Delta) {
+// prepareTiles computes where to draw the tiles.
+func (r *renderer) prepareTiles(scrollDelta m.Delta) {
+	if *batchTiles {
+		r.prepareTilesBatched(scrollDelta)
+		return
+	}
+	r.tileDraws = r.tileDraws[:0]
 	r.world.forEachTile(func(i int, tile *level.Tile) {
 		if tile.ImageSrc == "" {
 			return
@@ -157,77 +187,118 @@ func (r *renderer) drawTiles(screen *ebiten.Image, scrollDelta m.Delta) {
 			log.Errorf("could not load already cached image %q for tile: %v", tile.ImageSrc, err)
 			return
 		}
+		d := imageDraw{img: img}
+		setGeoM(&d.geoM, screenPos, false, m.Delta{DX: level.TileSize, DY: level.TileSize}, m.Delta{DX: level.TileSize, DY: level.TileSize}, tile.Orientation, 1.0, 0.0)
+		r.tileDraws = append(r.tileDraws, d)
+	})
+}
+
+func (r *renderer) drawTiles(screen *ebiten.Image) {
+	if *batchTiles {
+		r.drawTilesBatched(screen)
+		return
+	}
+	for _, d := range r.tileDraws {
 		if r.world.GlobalColorMSet {
 			opts := colorm.DrawImageOptions{
 				// Note: could be BlendCopy, but that can't be merged with entities pass.
 				Blend:  ebiten.BlendSourceOver,
 				Filter: ebiten.FilterNearest,
+				GeoM:   d.geoM,
 			}
-			setGeoM(&opts.GeoM, screenPos, false, m.Delta{DX: level.TileSize, DY: level.TileSize}, m.Delta{DX: level.TileSize, DY: level.TileSize}, tile.Orientation, 1.0, 0.0)
-			colorm.DrawImage(screen, img, r.world.GlobalColorM, &opts)
+			colorm.DrawImage(screen, d.img, r.world.GlobalColorM, &opts)
 		} else {
 			opts := ebiten.DrawImageOptions{
 				// Note: could be BlendCopy, but that can't be merged with entities pass.
 				Blend:  ebiten.BlendSourceOver,
 				Filter: ebiten.FilterNearest,
+				GeoM:   d.geoM,
 			}
-			setGeoM(&opts.GeoM, screenPos, false, m.Delta{DX: level.TileSize, DY: level.TileSize}, m.Delta{DX: level.TileSize, DY: level.TileSize}, tile.Orientation, 1.0, 0.0)
-			screen.DrawImage(img, &opts)
+			screen.DrawImage(d.img, &opts)
 		}
-	})
+	}
 }
 
-func (r *renderer) drawEntities(screen *ebiten.Image, scrollDelta m.Delta, blurFactor float64) {
+// prepareEntities computes where and how to draw the entities.
+func (r *renderer) prepareEntities(scrollDelta m.Delta, blurFactor float64) {
+	r.entityDraws = r.entityDraws[:0]
 	minZ, maxZ := zBounds(len(r.world.entitiesByZ))
 	for z := minZ; z <= maxZ; z++ {
 		for _, colormods := range []bool{false, true} {
 			r.world.entitiesByZ[encodeZ(z)].forEach(func(ent *Entity) error {
-				needColormods := (ent.ColorAdd != [4]float64{0, 0, 0, 0}) || r.world.GlobalColorMSet
-				if ent.Image == nil || ent.Alpha == 0 || needColormods != colormods {
-					return nil
-				}
-				screenPos := ent.Rect.Origin.Add(scrollDelta).Add(ent.RenderOffset)
-				sz := ent.Image.Bounds().Size()
-				imageSize := m.Delta{DX: sz.X, DY: sz.Y}
-				sizeFactor := 1.0
-				angle := 0.0
-				alphaFactor := 1.0
-				if ent == r.world.Player {
-					// Rotozoom the player when entering the menu.
-					sizeFactor = 1.0 + 3.0*blurFactor
-					angle = blurFactor * 2 * math.Pi
-					alphaFactor = 1.0 - blurFactor
-				}
-				if needColormods {
-					opts := colorm.DrawImageOptions{
-						Blend:  ebiten.BlendSourceOver,
-						Filter: ebiten.FilterNearest,
-					}
-					setGeoM(&opts.GeoM, screenPos, ent.ResizeImage, ent.Rect.Size, imageSize, ent.Orientation, sizeFactor, angle)
-					var colorM colorm.ColorM
-					colorM.Scale(ent.ColorMod[0], ent.ColorMod[1], ent.ColorMod[2], ent.ColorMod[3])
-					colorM.Translate(ent.ColorAdd[0], ent.ColorAdd[1], ent.ColorAdd[2], ent.ColorAdd[3])
-					colorM.Scale(1.0, 1.0, 1.0, ent.Alpha*alphaFactor)
-					colorM.Concat(r.world.GlobalColorM)
-					colorm.DrawImage(screen, ent.Image, colorM, &opts)
-				} else {
-					opts := ebiten.DrawImageOptions{
-						Blend:  ebiten.BlendSourceOver,
-						Filter: ebiten.FilterNearest,
-					}
-					setGeoM(&opts.GeoM, screenPos, ent.ResizeImage, ent.Rect.Size, imageSize, ent.Orientation, sizeFactor, angle)
-					alpha := ent.ColorMod[3] * ent.Alpha * alphaFactor
-					opts.ColorScale.Scale(
-						float32(ent.ColorMod[0]*alpha),
-						float32(ent.ColorMod[1]*alpha),
-						float32(ent.ColorMod[2]*alpha),
-						float32(alpha))
-					screen.DrawImage(ent.Image, &opts)
-				}
+				r.prepareEntity(ent, scrollDelta, blurFactor, colormods)
 				return nil
 			})
 		}
 	}
+	for _, colormods := range []bool{false, true} {
+		for _, ent := range r.world.Overlays {
+			r.prepareEntity(ent, scrollDelta, blurFactor, colormods)
+		}
+	}
+}
+
+// prepareEntity computes where and how to draw an entity, if it matches the given colormods setting.
+func (r *renderer) prepareEntity(ent *Entity, scrollDelta m.Delta, blurFactor float64, colormods bool) {
+	needColormods := (ent.ColorAdd != [4]float64{0, 0, 0, 0}) || r.world.GlobalColorMSet
+	if ent.Image == nil || ent.Alpha == 0 || needColormods != colormods {
+		return
+	}
+	screenPos := r.world.InterpolatedOrigin(ent).Add(scrollDelta).Add(ent.RenderOffset)
+	sz := ent.Image.Bounds().Size()
+	imageSize := m.Delta{DX: sz.X, DY: sz.Y}
+	sizeFactor := 1.0
+	angle := 0.0
+	alphaFactor := 1.0
+	if ent == r.world.Player {
+		// Rotozoom the player when entering the menu.
+		if !effects.Reduced() {
+			sizeFactor = 1.0 + 3.0*blurFactor
+			angle = blurFactor * 2 * math.Pi
+		}
+		alphaFactor = 1.0 - blurFactor
+	}
+	d := entityDraw{
+		imageDraw: imageDraw{img: ent.Image},
+		colormods: needColormods,
+	}
+	setGeoM(&d.geoM, screenPos, ent.ResizeImage, ent.Rect.Size, imageSize, ent.Orientation, sizeFactor, angle)
+	if needColormods {
+		d.colorM.Scale(ent.ColorMod[0], ent.ColorMod[1], ent.ColorMod[2], ent.ColorMod[3])
+		d.colorM.Translate(ent.ColorAdd[0], ent.ColorAdd[1], ent.ColorAdd[2], ent.ColorAdd[3])
+		d.colorM.Scale(1.0, 1.0, 1.0, ent.Alpha*alphaFactor)
+		d.colorM.Concat(r.world.GlobalColorM)
+	} else {
+		alpha := ent.ColorMod[3] * ent.Alpha * alphaFactor
+		d.colorScale.Scale(
+			float32(ent.ColorMod[0]*alpha),
+			float32(ent.ColorMod[1]*alpha),
+			float32(ent.ColorMod[2]*alpha),
+			float32(alpha))
+	}
+	r.entityDraws = append(r.entityDraws, d)
+}
+
+func (r *renderer) drawEntities(screen *ebiten.Image) {
+	for i := range r.entityDraws {
+		d := &r.entityDraws[i]
+		if d.colormods {
+			opts := colorm.DrawImageOptions{
+				Blend:  ebiten.BlendSourceOver,
+				Filter: ebiten.FilterNearest,
+				GeoM:   d.geoM,
+			}
+			colorm.DrawImage(screen, d.img, d.colorM, &opts)
+		} else {
+			opts := ebiten.DrawImageOptions{
+				Blend:      ebiten.BlendSourceOver,
+				Filter:     ebiten.FilterNearest,
+				GeoM:       d.geoM,
+				ColorScale: d.colorScale,
+			}
+			screen.DrawImage(d.img, &opts)
+		}
+	}
 }
 
 func (r *renderer) drawDebug(screen *ebiten.Image, scrollDelta m.Delta) {
@@ -336,18 +407,32 @@ func (r *renderer) offscreenDrawDest(screen *ebiten.Image) *ebiten.Image {
 	return nil
 }
 
-func (r *renderer) drawVisibilityMask(screen, drawDest *ebiten.Image, scrollDelta m.Delta) {
-	defer timing.Group()()
+// invertedVisibilityMask returns whether the visibility mask is drawn directly as black outside the visible area.
+func invertedVisibilityMask() bool {
+	return *expandUsingVertices && !*expandUsingVerticesAccurately && !*drawBlurs && !*drawOutside
+}
 
-	// Draw trace polygon to buffer.
+// visibilityMaskTriangles returns the triangles of the visibility mask.
+// If invertedVisibilityMask, they cover everything outside the visible area, otherwise the visible area.
+// The returned slices are only valid until the next polygon is computed.
+func (r *renderer) visibilityMaskTriangles(scrollDelta m.Delta) ([]ebiten.Vertex, []uint16) {
 	geoM := ebiten.GeoM{}
 	geoM.Translate(float64(scrollDelta.DX), float64(scrollDelta.DY))
 	texM := ebiten.GeoM{}
 	texM.Scale(0, 0)
+	if invertedVisibilityMask() {
+		return antiPolygonAroundTriangles(r.visiblePolygonCenter, r.expandedVisiblePolygon, color.Gray{0}, geoM, texM)
+	}
+	return polygonAroundTriangles(r.visiblePolygonCenter, r.expandedVisiblePolygon, color.Gray{255}, geoM, texM)
+}
 
-	if *expandUsingVertices && !*expandUsingVerticesAccurately && !*drawBlurs && !*drawOutside {
+func (r *renderer) drawVisibilityMask(screen, drawDest *ebiten.Image, scrollDelta m.Delta) {
+	defer timing.Group()()
+
+	if invertedVisibilityMask() {
 		timing.Section("draw_mask")
-		drawAntiPolygonAround(screen, r.visiblePolygonCenter, r.expandedVisiblePolygon, r.whiteImage, color.Gray{0}, geoM, texM, &ebiten.DrawTrianglesOptions{})
+		vertices, indices := r.visibilityMaskTriangles(scrollDelta)
+		screen.DrawTriangles(vertices, indices, r.whiteImage, &ebiten.DrawTrianglesOptions{})
 		return
 	}
 
@@ -368,7 +453,8 @@ func (r *renderer) drawVisibilityMask(screen, drawDest *ebiten.Image, scrollDelt
 			unblurred = offscreen.New("VisibilityMaskUnblurred", GameWidth, GameHeight)
 		}
 		unblurred.Clear()
-		drawPolygonAround(unblurred, r.visiblePolygonCenter, r.expandedVisiblePolygon, r.whiteImage, color.Gray{255}, geoM, texM, &ebiten.DrawTrianglesOptions{})
+		vertices, indices := r.visibilityMaskTriangles(scrollDelta)
+		unblurred.DrawTriangles(vertices, indices, r.whiteImage, &ebiten.DrawTrianglesOptions{})
 		e := expandSize
 		if *expandUsingVertices {
 			e = 0
@@ -382,7 +468,7 @@ func (r *renderer) drawVisibilityMask(screen, drawDest *ebiten.Image, scrollDelt
 	timing.Section("apply_mask")
 	if *drawOutside && r.prevImage != nil {
 		if r.visibilityMaskShader != nil {
-			delta := r.world.scrollPos.Delta(r.prevScrollPos)
+			delta := r.world.interpolatedScrollPos().Delta(r.prevScrollPos)
 			screen.DrawRectShader(GameWidth, GameHeight, r.visibilityMaskShader, &ebiten.DrawRectShaderOptions{
 				Blend: ebiten.BlendCopy,
 				Uniforms: map[string]interface{}{
@@ -403,7 +489,7 @@ func (r *renderer) drawVisibilityMask(screen, drawDest *ebiten.Image, scrollDelt
 			})
 
 			// Then draw the background.
-			delta := r.world.scrollPos.Delta(r.prevScrollPos)
+			delta := r.world.interpolatedScrollPos().Delta(r.prevScrollPos)
 			screen.DrawTriangles([]ebiten.Vertex{
 				{
 					DstX: 0, DstY: 0,
@@ -411,18 +497,18 @@ func (r *renderer) drawVisibilityMask(screen, drawDest *ebiten.Image, scrollDelt
 					ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1,
 				},
 				{
-					DstX: GameWidth, DstY: 0,
-					SrcX: GameWidth + float32(delta.DX), SrcY: float32(delta.DY),
+					DstX: float32(GameWidth), DstY: 0,
+					SrcX: float32(GameWidth) + float32(delta.DX), SrcY: float32(delta.DY),
 					ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1,
 				},
 				{
-					DstX: 0, DstY: GameHeight,
-					SrcX: float32(delta.DX), SrcY: GameHeight + float32(delta.DY),
+					DstX: 0, DstY: float32(GameHeight),
+					SrcX: float32(delta.DX), SrcY: float32(GameHeight) + float32(delta.DY),
 					ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1,
 				},
 				{
-					DstX: GameWidth, DstY: GameHeight,
-					SrcX: GameWidth + float32(delta.DX), SrcY: GameHeight + float32(delta.DY),
+					DstX: float32(GameWidth), DstY: float32(GameHeight),
+					SrcX: float32(GameWidth) + float32(delta.DX), SrcY: float32(GameHeight) + float32(delta.DY),
 					ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1,
 				},
 			}, []uint16{0, 1, 2, 1, 2, 3}, r.prevImage, &ebiten.DrawTrianglesOptions{
@@ -452,16 +538,35 @@ func (r *renderer) drawVisibilityMask(screen, drawDest *ebiten.Image, scrollDelt
 		}
 		r.prevImage = offscreen.NewExplicit("PrevImage", GameWidth, GameHeight)
 		BlurImage("BlurPrevImage", screen, r.prevImage, frameBlurSize, frameDarkenAlpha, frameDarkenAmount, 1.0)
-		r.prevScrollPos = r.world.scrollPos
+		r.prevScrollPos = r.world.interpolatedScrollPos()
 	}
 
 	r.worldChanged = false
 }
 
+// scrollDelta returns the offset from level to screen coordinates.
+func (r *renderer) scrollDelta() m.Delta {
+	return m.Pos{X: GameWidth / 2, Y: GameHeight / 2}.Delta(r.world.interpolatedScrollPos()).Add(r.world.shakeOffset)
+}
+
+// prepare performs the CPU side work of drawing tiles and entities.
+func (r *renderer) prepare(scrollDelta m.Delta, blurFactor float64) {
+	defer timing.Group()()
+
+	timing.Section("tiles")
+	r.prepareTiles(scrollDelta)
+
+	timing.Section("entities")
+	r.prepareEntities(scrollDelta, blurFactor)
+}
+
 func (r *renderer) Draw(screen *ebiten.Image, blurFactor float64) {
 	defer timing.Group()()
 
-	scrollDelta := m.Pos{X: GameWidth / 2, Y: GameHeight / 2}.Delta(r.world.scrollPos)
+	scrollDelta := r.scrollDelta()
+
+	timing.Section("prepare")
+	r.prepare(scrollDelta, blurFactor)
 	off := r.offscreenDrawDest(screen)
 	dest := screen
 	if off != nil {
@@ -471,11 +576,17 @@ func (r *renderer) Draw(screen *ebiten.Image, blurFactor float64) {
 	timing.Section("fill")
 	dest.Fill(color.Gray{0})
 
+	timing.Section("backgrounds")
+	r.drawBackgrounds(dest)
+
 	timing.Section("tiles")
-	r.drawTiles(dest, scrollDelta)
+	r.drawTiles(dest)
 
 	timing.Section("entities")
-	r.drawEntities(dest, scrollDelta, blurFactor)
+	r.drawEntities(dest)
+
+	timing.Section("lights")
+	r.drawLights(dest, scrollDelta)
 
 	if *drawVisibilityMask {
 		timing.Section("visibility_mask")
@@ -487,13 +598,59 @@ func (r *renderer) Draw(screen *ebiten.Image, blurFactor float64) {
 		offscreen.Dispose(off)
 	}
 
+	if r.world.PlayerState.Modifiers().ContainAll(playerstate.MirrorModifier) {
+		timing.Section("mirror")
+		r.drawMirrored(screen)
+	}
+
+	timing.Section("transition")
+	r.drawTransition(screen, scrollDelta)
+
 	timing.Section("input")
 	input.Draw(screen)
 
 	timing.Section("centerprint")
 	centerprint.Draw(screen)
 
+	timing.Section("dialog")
+	dialog.Draw(screen)
+
 	// Debug stuff comes last.
 	timing.Section("debug")
 	r.drawDebug(screen, scrollDelta)
+	r.drawFreeCamera(screen, scrollDelta)
+	r.drawInspector(screen, scrollDelta)
+}
+
+// drawMirrored mirrors the screen horizontally in place.
+func (r *renderer) drawMirrored(screen *ebiten.Image) {
+	tmp := offscreen.New("Mirror", GameWidth, GameHeight)
+	defer offscreen.Dispose(tmp)
+	tmp.DrawImage(screen, &ebiten.DrawImageOptions{
+		Blend:  ebiten.BlendCopy,
+		Filter: ebiten.FilterNearest,
+	})
+	options := &ebiten.DrawImageOptions{
+		Blend:  ebiten.BlendCopy,
+		Filter: ebiten.FilterNearest,
+	}
+	options.GeoM.Scale(-1, 1)
+	options.GeoM.Translate(float64(GameWidth), 0)
+	screen.DrawImage(tmp, options)
+}
+
+// prepareFrame performs the CPU side work of Draw, but issues no draw calls.
+// This allows benchmarking the software parts of rendering without a GPU.
+func (r *renderer) prepareFrame() {
+	defer timing.Group()()
+
+	scrollDelta := r.scrollDelta()
+
+	timing.Section("prepare")
+	r.prepare(scrollDelta, 0)
+
+	if *drawVisibilityMask {
+		timing.Section("visibility_mask")
+		r.visibilityMaskTriangles(scrollDelta)
+	}
 }
@@ -0,0 +1,122 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/game/trigger"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+func TestRespawnByID(t *testing.T) {
+	const propID = level.EntityID(42)
+	w := newTestWorld(t, allocTestMap, func(lvl *level.Level) {
+		props := propmap.New()
+		propmap.Set(props, "name", "prop")
+		pos := m.Pos{X: 2, Y: 2}
+		lt := lvl.Tile(pos)
+		lt.Tile.Spawnables = append(lt.Tile.Spawnables, &level.Spawnable{
+			ID: propID,
+			SpawnableProps: level.SpawnableProps{
+				EntityType:      "testProp",
+				Orientation:     m.Identity(),
+				Properties:      props,
+				PersistentState: propmap.New(),
+			},
+			LevelPos:   pos,
+			RectInTile: m.Rect{Size: m.Delta{DX: 8, DY: 8}},
+		})
+	})
+	find := func() *engine.Entity {
+		t.Helper()
+		found := w.FindName("prop")
+		if len(found) != 1 {
+			t.Fatalf("unexpected number of props: got %d, want 1", len(found))
+		}
+		return found[0]
+	}
+	err := w.Update()
+	if err != nil {
+		t.Fatalf("could not update world: %v", err)
+	}
+	e := find()
+	origin := e.Rect.Origin
+	w.SetOrigin(e, origin.Add(m.Delta{DX: 5, DY: 0}))
+	w.RespawnByID(propID)
+	err = w.Update()
+	if err != nil {
+		t.Fatalf("could not update world: %v", err)
+	}
+	e2 := find()
+	if e2 == e {
+		t.Errorf("prop was not respawned")
+	}
+	if e2.Rect.Origin != origin {
+		t.Errorf("respawned prop at wrong position: got %v, want %v", e2.Rect.Origin, origin)
+	}
+}
+
+func TestRespawnPlayerTrigger(t *testing.T) {
+	defer flag.ResetFlagToDefault("invincible")
+	for _, tc := range []struct {
+		invincible bool
+		respawned  bool
+	}{
+		{false, true},
+		{true, false},
+	} {
+		flag.Set("invincible", tc.invincible)
+		w := newAllocTestWorld(t)
+		if w.FramesSinceSpawn == 0 {
+			t.Fatalf("world did not run any frames since spawning")
+		}
+		spike := &trigger.RespawnPlayer{World: w}
+		spike.Touch(w.Player)
+		if respawned := w.FramesSinceSpawn == 0; respawned != tc.respawned {
+			t.Errorf("respawned after touching a spike with invincible=%v: got %v, want %v", tc.invincible, respawned, tc.respawned)
+		}
+	}
+}
+
+func TestDamageCooldownWithoutTimer(t *testing.T) {
+	w := newTestWorld(t, allocTestMap, func(lvl *level.Level) {
+		propmap.Set(lvl.Player.Properties, "max_hp", 3)
+	})
+	ps := &w.PlayerState
+	if ps.Frames() != 0 {
+		t.Fatalf("speedrun timer is running in the test world")
+	}
+	ps.Damage(1)
+	ps.Damage(1)
+	if got, want := ps.HP(), 2; got != want {
+		t.Errorf("unexpected hit points right after damage: got %d, want %d", got, want)
+	}
+	for i := 0; i < playerstate.DamageCooldownFrames; i++ {
+		err := w.Update()
+		if err != nil {
+			t.Fatalf("could not update world: %v", err)
+		}
+	}
+	ps.Damage(1)
+	if got, want := ps.HP(), 1; got != want {
+		t.Errorf("unexpected hit points after the cooldown: got %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+// scheduledCallback is a function to be run on a later frame.
+type scheduledCallback struct {
+	framesLeft int
+	owner      EntityIncarnation
+	hasOwner   bool
+	f          func()
+}
+
+// Schedule runs f after the given number of frames, at the end of the entity update phase.
+// If owner is an entity with a valid incarnation, the callback is dropped if the owner despawns before it is due.
+// All pending callbacks are dropped when the player respawns or a snapshot is loaded.
+func (w *World) Schedule(owner *Entity, frames int, f func()) {
+	if frames < 1 {
+		frames = 1
+	}
+	cb := scheduledCallback{
+		framesLeft: frames,
+		f:          f,
+	}
+	if owner != nil && owner.Incarnation.IsValid() {
+		cb.owner = owner.Incarnation
+		cb.hasOwner = true
+	}
+	w.scheduled = append(w.scheduled, cb)
+}
+
+// runScheduled advances all scheduled callbacks by one frame and runs the due ones.
+func (w *World) runScheduled() {
+	// Callbacks may schedule more callbacks; those only start counting next frame.
+	n := len(w.scheduled)
+	j := 0
+	for i := 0; i < n; i++ {
+		cb := w.scheduled[i]
+		if cb.hasOwner && !w.EntityIsAlive(cb.owner) {
+			continue
+		}
+		cb.framesLeft--
+		if cb.framesLeft > 0 {
+			w.scheduled[j] = cb
+			j++
+			continue
+		}
+		cb.f()
+		if w.respawned {
+			// The callback respawned the player, which dropped everything else.
+			return
+		}
+	}
+	j += copy(w.scheduled[j:], w.scheduled[n:])
+	for i := j; i < len(w.scheduled); i++ {
+		w.scheduled[i] = scheduledCallback{}
+	}
+	w.scheduled = w.scheduled[:j]
+}
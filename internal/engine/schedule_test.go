@@ -0,0 +1,71 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine_test
+
+import (
+	"testing"
+)
+
+func TestScheduleRunsAfterFrames(t *testing.T) {
+	w := newAllocTestWorld(t)
+	var ran []int
+	frame := 0
+	w.Schedule(nil, 3, func() { ran = append(ran, frame) })
+	w.Schedule(nil, 1, func() {
+		ran = append(ran, frame)
+		// Scheduling from a callback starts counting on the next frame.
+		w.Schedule(nil, 1, func() { ran = append(ran, frame) })
+	})
+	w.Schedule(w.Player, 0, func() { ran = append(ran, frame) })
+	for frame = 1; frame <= 5; frame++ {
+		err := w.Update()
+		if err != nil {
+			t.Fatalf("could not update world: %v", err)
+		}
+	}
+	want := []int{1, 1, 2, 3}
+	if len(ran) != len(want) {
+		t.Fatalf("unexpected callback frames: got %v, want %v", ran, want)
+	}
+	for i := range want {
+		if ran[i] != want[i] {
+			t.Errorf("unexpected callback frames: got %v, want %v", ran, want)
+			break
+		}
+	}
+}
+
+func TestScheduleDroppedOnLoadSnapshot(t *testing.T) {
+	w := newAllocTestWorld(t)
+	snap, err := w.SaveSnapshot()
+	if err != nil {
+		t.Fatalf("could not save snapshot: %v", err)
+	}
+	ran := false
+	w.Schedule(nil, 2, func() { ran = true })
+	err = w.LoadSnapshot(snap)
+	if err != nil {
+		t.Fatalf("could not load snapshot: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		err := w.Update()
+		if err != nil {
+			t.Fatalf("could not update world: %v", err)
+		}
+	}
+	if ran {
+		t.Errorf("callback scheduled before loading a snapshot ran after it")
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/effects"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/rng"
+)
+
+var shakeRand = rng.Get("shake")
+
+// ShakeScreen shakes the screen by up to amplitude pixels, fading out over the given duration.
+// A stronger shake replaces a weaker one. In reduced motion mode the screen does not move.
+func (w *World) ShakeScreen(amplitude int, duration time.Duration) {
+	frames := int((duration*GameTPS + (time.Second / 2)) / time.Second)
+	if amplitude <= 0 || frames <= 0 {
+		return
+	}
+	demo.InterceptEffect(fmt.Sprintf("shake %d %d", amplitude, frames))
+	if w.shakeFrame < w.shakeFrames && w.shakeAmplitude*(w.shakeFrames-w.shakeFrame)/w.shakeFrames > amplitude {
+		return
+	}
+	w.shakeAmplitude = amplitude
+	w.shakeFrames = frames
+	w.shakeFrame = 0
+}
+
+// Hitstop freezes the world for the given number of frames, e.g. to emphasize an impact.
+// As this changes gameplay timing, it happens regardless of reduced motion mode.
+func (w *World) Hitstop(frames int) {
+	if frames <= 0 {
+		return
+	}
+	demo.InterceptEffect(fmt.Sprintf("hitstop %d", frames))
+	if frames > w.hitstopFrames {
+		w.hitstopFrames = frames
+	}
+}
+
+// updateShake advances the screen shake by one frame.
+func (w *World) updateShake() {
+	if w.shakeFrame >= w.shakeFrames {
+		w.shakeOffset = m.Delta{}
+		return
+	}
+	amplitude := w.shakeAmplitude * (w.shakeFrames - w.shakeFrame) / w.shakeFrames
+	w.shakeFrame++
+	w.shakeOffset = m.Delta{
+		DX: effects.Motion(shakeRand.Intn(2*amplitude+1) - amplitude),
+		DY: effects.Motion(shakeRand.Intn(2*amplitude+1) - amplitude),
+	}
+}
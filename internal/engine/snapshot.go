@@ -0,0 +1,216 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/rng"
+)
+
+// Snapshotter is implemented by entities that can save and restore their transient state.
+type Snapshotter interface {
+	// SaveSnapshot returns a copy of the entity's transient state.
+	// The returned value must not share any mutable data with the entity.
+	SaveSnapshot() interface{}
+
+	// LoadSnapshot restores the entity's transient state from a value returned by SaveSnapshot.
+	// Called right after Spawn, or on the player.
+	// The entity's rect, orientation and alpha have already been restored.
+	LoadSnapshot(s interface{})
+}
+
+// entitySnapshot is the saved state of a single entity.
+type entitySnapshot struct {
+	rect        m.Rect
+	orientation m.Orientation
+	alpha       float64
+	state       interface{}
+}
+
+// Snapshot is an in-memory copy of the world state for practicing.
+//
+// Persistent state is saved for all entities. Transient state is only saved
+// for entities implementing Snapshotter; all others will respawn fresh.
+type Snapshot struct {
+	save              []byte
+	tilePos           m.Pos
+	levelPos          m.Pos
+	tileTransform     m.Orientation
+	playerRect        m.Rect
+	playerOrientation m.Orientation
+	playerAlpha       float64
+	playerState       interface{}
+	entities          map[EntityIncarnation]entitySnapshot
+	warpZoneStates    map[string]bool
+	scrollPos         m.Pos
+	timerStarted      bool
+	rngState          rng.State
+}
+
+// SaveSnapshot saves the current world state to memory.
+func (w *World) SaveSnapshot() (*Snapshot, error) {
+	tilePos := w.Player.Rect.Center().Div(level.TileSize)
+	tile := w.Tile(tilePos)
+	if tile == nil {
+		return nil, fmt.Errorf("could not save snapshot: no tile at player position %v", tilePos)
+	}
+	save, err := w.Level.SaveGame()
+	if err != nil {
+		return nil, fmt.Errorf("could not save snapshot: %w", err)
+	}
+	// Serialize to not alias any persistent state.
+	saveData, err := json.Marshal(save)
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize snapshot: %w", err)
+	}
+	s := &Snapshot{
+		save:              saveData,
+		tilePos:           tilePos,
+		levelPos:          tile.LevelPos,
+		tileTransform:     tile.Transform,
+		playerRect:        w.Player.Rect,
+		playerOrientation: w.Player.Orientation,
+		playerAlpha:       w.Player.Alpha,
+		entities:          map[EntityIncarnation]entitySnapshot{},
+		warpZoneStates:    make(map[string]bool, len(w.WarpZoneStates)),
+		scrollPos:         w.scrollPos,
+		timerStarted:      w.TimerStarted,
+		rngState:          rng.SaveState(),
+	}
+	if snap, ok := w.Player.Impl.(Snapshotter); ok {
+		s.playerState = snap.SaveSnapshot()
+	}
+	w.ForEachEntity(func(e *Entity) {
+		if e == w.Player || !e.Incarnation.IsValid() {
+			return
+		}
+		snap, ok := e.Impl.(Snapshotter)
+		if !ok {
+			return
+		}
+		s.entities[e.Incarnation] = entitySnapshot{
+			rect:        e.Rect,
+			orientation: e.Orientation,
+			alpha:       e.Alpha,
+			state:       snap.SaveSnapshot(),
+		}
+	})
+	for name, state := range w.WarpZoneStates {
+		s.warpZoneStates[name] = state
+	}
+	return s, nil
+}
+
+// LoadSnapshot restores the world state from a snapshot.
+// The world is rebuilt around the player like on respawn.
+func (w *World) LoadSnapshot(s *Snapshot) error {
+	// Entities without Snapshotter respawn fresh, so the shadow cannot follow.
+	w.stopShadow("loading a snapshot")
+
+	save := &level.SaveGame{}
+	err := json.Unmarshal(s.save, save)
+	if err != nil {
+		return fmt.Errorf("could not deserialize snapshot: %w", err)
+	}
+	err = w.Level.LoadGame(save)
+	if err != nil {
+		return fmt.Errorf("could not load snapshot: %w", err)
+	}
+	w.PlayerState.Init()
+	rng.LoadState(s.rngState)
+
+	// Spawn the tile the player was on.
+	tile := w.Level.Tile(s.levelPos).Tile
+	tile.Transform = s.tileTransform
+	tile.Orientation = tile.Transform.Inverse().Concat(tile.Orientation)
+	tile.Slope = tile.Slope.Transform(tile.Transform.Inverse())
+	tile.ResolveImage()
+
+	// Build a new world around it.
+	w.freeCamera = false
+	w.frameVis = 0
+	tile.VisibilityFlags = w.frameVis
+	w.clearEntities()
+	w.scheduled = nil // Scheduled callbacks refer to the old entities.
+	w.link(w.Player)
+	for i := range w.tiles {
+		w.tiles[i] = nil
+	}
+	w.setScrollPos(s.tilePos.Mul(level.TileSize))
+	w.setTile(s.tilePos, &tile)
+
+	// Reset the ending stuff.
+	w.TimerStarted = s.timerStarted
+	w.TimerStopped = false
+	w.MaxVisiblePixels = math.MaxInt32
+	w.ForceCredits = false
+
+	// Restore warpzones.
+	w.WarpZoneStates = make(map[string]bool, len(s.warpZoneStates))
+	for name, state := range s.warpZoneStates {
+		w.WarpZoneStates[name] = state
+	}
+
+	// Restore the player.
+	w.SetRect(w.Player, s.playerRect)
+	w.LoadTilesForRect(w.Player.Rect, s.tilePos)
+	w.frameVis ^= level.FrameVis
+	w.Player.Impl.(PlayerEntityImpl).Respawned()
+	w.SetRect(w.Player, s.playerRect)
+	w.Player.Orientation = s.playerOrientation
+	w.Player.Alpha = s.playerAlpha
+	if snap, ok := w.Player.Impl.(Snapshotter); ok && s.playerState != nil {
+		snap.LoadSnapshot(s.playerState)
+	}
+
+	// Other entities get their state back when they respawn.
+	w.pendingSnapshots = make(map[EntityIncarnation]entitySnapshot, len(s.entities))
+	for inc, es := range s.entities {
+		w.pendingSnapshots[inc] = es
+	}
+
+	// Show the fade in.
+	w.FramesSinceSpawn = 0
+	w.setScrollPos(s.scrollPos)
+
+	// Skip updating.
+	w.respawned = true
+	return nil
+}
+
+// restorePendingSnapshot restores a just spawned entity from the last loaded snapshot, if any.
+func (w *World) restorePendingSnapshot(e *Entity) {
+	if !e.Incarnation.IsValid() {
+		return
+	}
+	es, found := w.pendingSnapshots[e.Incarnation]
+	if !found {
+		return
+	}
+	delete(w.pendingSnapshots, e.Incarnation)
+	snap, ok := e.Impl.(Snapshotter)
+	if !ok {
+		return
+	}
+	w.SetRect(e, es.rect)
+	e.Orientation = es.orientation
+	e.Alpha = es.alpha
+	snap.LoadSnapshot(es.state)
+}
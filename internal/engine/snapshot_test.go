@@ -0,0 +1,188 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/constants"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/game/player"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// testPlayerSnapshot is the transient state of the test player.
+type testPlayerSnapshot struct {
+	physics  interface{}
+	movement player.Movement
+	frame    int
+}
+
+func (p *testPlayer) SaveSnapshot() interface{} {
+	return testPlayerSnapshot{
+		physics:  p.Physics.SaveSnapshot(),
+		movement: p.Movement,
+		frame:    p.frame,
+	}
+}
+
+func (p *testPlayer) LoadSnapshot(snap interface{}) {
+	s := snap.(testPlayerSnapshot)
+	p.Physics.LoadSnapshot(s.physics)
+	p.Movement = s.movement
+	p.frame = s.frame
+}
+
+// testBlock is an entity that moves sideways and turns around at walls.
+// Its snapshot support comes from the Physics mixin.
+type testBlock struct {
+	mixins.Physics
+}
+
+func init() {
+	engine.RegisterEntityType(&testBlock{})
+}
+
+func (b *testBlock) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	b.Physics.Init(w, e, level.ObjectSolidContents, b.handleTouch)
+	b.Velocity = m.Delta{DX: constants.SubPixelScale * 5 / 7, DY: 0}
+	return nil
+}
+
+func (b *testBlock) Despawn() {}
+
+func (b *testBlock) Touch(other *engine.Entity) {}
+
+func (b *testBlock) handleTouch(trace engine.TraceResult) {
+	b.Velocity = b.Velocity.Mul(-1)
+}
+
+var _ engine.Snapshotter = &testBlock{}
+
+// testBlockState is the state of the test block compared after restoring.
+type testBlockState struct {
+	rect     m.Rect
+	velocity m.Delta
+	subPixel m.Delta
+}
+
+// testPlayerState is the state of the test player compared after restoring.
+type testPlayerState struct {
+	x, y, vx, vy int64
+	onGround     bool
+	movement     player.Movement
+	frame        int
+}
+
+func TestSnapshotRestoresState(t *testing.T) {
+	w := newTestWorld(t, allocTestMap, func(lvl *level.Level) {
+		props := propmap.New()
+		propmap.Set(props, "name", "block")
+		pos := m.Pos{X: 6, Y: 1}
+		lt := lvl.Tile(pos)
+		lt.Tile.Spawnables = append(lt.Tile.Spawnables, &level.Spawnable{
+			ID: 42,
+			SpawnableProps: level.SpawnableProps{
+				EntityType:      "testBlock",
+				Orientation:     m.Identity(),
+				Properties:      props,
+				PersistentState: propmap.New(),
+			},
+			LevelPos:   pos,
+			RectInTile: m.Rect{Size: m.Delta{DX: 8, DY: 8}},
+		})
+	})
+	inputs, err := parseScript("R*24 RJ*10 R*20 L*30")
+	if err != nil {
+		t.Fatalf("could not parse script: %v", err)
+	}
+	p := w.Player.Impl.(*testPlayer)
+	p.script = inputs
+	update := func(frames int) {
+		t.Helper()
+		for i := 0; i < frames; i++ {
+			err := w.Update()
+			if err != nil {
+				t.Fatalf("could not update world: %v", err)
+			}
+		}
+	}
+	findBlock := func() *testBlock {
+		found := w.FindName("block")
+		if len(found) != 1 {
+			return nil
+		}
+		return found[0].Impl.(*testBlock)
+	}
+	playerState := func() testPlayerState {
+		x, y, vx, vy := p.DebugPos64()
+		return testPlayerState{
+			x: x, y: y, vx: vx, vy: vy,
+			onGround: p.OnGround,
+			movement: p.Movement,
+			frame:    p.frame,
+		}
+	}
+	blockState := func(b *testBlock) testBlockState {
+		return testBlockState{
+			rect:     b.Entity.Rect,
+			velocity: b.Velocity,
+			subPixel: b.SubPixel,
+		}
+	}
+
+	// Save in mid-jump, with the jump key still held.
+	update(30)
+	b := findBlock()
+	if b == nil {
+		t.Fatalf("block did not spawn")
+	}
+	wantPlayer, wantBlock := playerState(), blockState(b)
+	snap, err := w.SaveSnapshot()
+	if err != nil {
+		t.Fatalf("could not save snapshot: %v", err)
+	}
+
+	update(20)
+	if got := playerState(); got == wantPlayer {
+		t.Fatalf("player did not move after saving")
+	}
+	if got := blockState(findBlock()); got == wantBlock {
+		t.Fatalf("block did not move after saving")
+	}
+
+	err = w.LoadSnapshot(snap)
+	if err != nil {
+		t.Fatalf("could not load snapshot: %v", err)
+	}
+	if got := playerState(); got != wantPlayer {
+		t.Errorf("unexpected player state after loading: got %+v, want %+v", got, wantPlayer)
+	}
+
+	// The block gets its state back once it respawns; it does not move on that frame.
+	for i := 0; i < 120 && findBlock() == nil; i++ {
+		update(1)
+	}
+	b = findBlock()
+	if b == nil {
+		t.Fatalf("block did not respawn")
+	}
+	if got := blockState(b); got != wantBlock {
+		t.Errorf("unexpected block state after loading: got %+v, want %+v", got, wantBlock)
+	}
+}
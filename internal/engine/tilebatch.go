@@ -0,0 +1,137 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/image"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+var (
+	batchTiles = flag.Bool("batch_tiles", true, "draw all tiles sharing an image atlas in a single draw call (disable for debugging)")
+)
+
+// tileBatch collects the vertices of all tiles drawn from the same source image.
+// Indices restart at zero every tilesPerDrawCall tiles, so the batch can be drawn in chunks.
+type tileBatch struct {
+	vertices []ebiten.Vertex
+	indices  []uint16
+}
+
+// tileCorners are the corners of a tile image, in the order used for the vertices.
+var tileCorners = [4]m.Delta{
+	{DX: 0, DY: 0},
+	{DX: level.TileSize, DY: 0},
+	{DX: 0, DY: level.TileSize},
+	{DX: level.TileSize, DY: level.TileSize},
+}
+
+const (
+	// tilesPerDrawCall is the number of tiles that fit into a single DrawTriangles call.
+	tilesPerDrawCall = ebiten.MaxVertexCount / len(tileCorners)
+	// tileIndices is the number of indices per tile.
+	tileIndices = 6
+)
+
+// add appends the vertices of a single tile.
+func (b *tileBatch) add(img *ebiten.Image, screenPos m.Pos, orientation m.Orientation) {
+	var geoM ebiten.GeoM
+	setGeoM(&geoM, screenPos, false, m.Delta{DX: level.TileSize, DY: level.TileSize}, m.Delta{DX: level.TileSize, DY: level.TileSize}, orientation, 1.0, 0.0)
+	src := img.Bounds().Min
+	n := uint16(len(b.vertices) % (tilesPerDrawCall * len(tileCorners)))
+	for _, c := range tileCorners {
+		dx, dy := geoM.Apply(float64(c.DX), float64(c.DY))
+		b.vertices = append(b.vertices, ebiten.Vertex{
+			DstX:   float32(dx),
+			DstY:   float32(dy),
+			SrcX:   float32(src.X + c.DX),
+			SrcY:   float32(src.Y + c.DY),
+			ColorR: 1,
+			ColorG: 1,
+			ColorB: 1,
+			ColorA: 1,
+		})
+	}
+	b.indices = append(b.indices, n, n+1, n+2, n+1, n+2, n+3)
+}
+
+// prepareTilesBatched collects the vertices of all tiles by atlas page.
+func (r *renderer) prepareTilesBatched(scrollDelta m.Delta) {
+	if r.tileBatches == nil {
+		r.tileBatches = map[*ebiten.Image]*tileBatch{}
+	}
+	for _, b := range r.tileBatches {
+		b.vertices = b.vertices[:0]
+		b.indices = b.indices[:0]
+	}
+	r.world.forEachTile(func(i int, tile *level.Tile) {
+		if tile.ImageSrc == "" {
+			return
+		}
+		pos := r.world.tilePos(i)
+		screenPos := pos.Mul(level.TileSize).Add(scrollDelta)
+		img, err := image.Load("tiles", tile.ImageSrc)
+		if err != nil {
+			log.Errorf("could not load already cached image %q for tile: %v", tile.ImageSrc, err)
+			return
+		}
+		page := image.AtlasPage(img)
+		b := r.tileBatches[page]
+		if b == nil {
+			b = &tileBatch{}
+			r.tileBatches[page] = b
+		}
+		b.add(img, screenPos, tile.Orientation)
+	})
+}
+
+// drawTilesBatched draws all tiles using one DrawTriangles call per atlas page and tilesPerDrawCall tiles.
+func (r *renderer) drawTilesBatched(screen *ebiten.Image) {
+	for page, b := range r.tileBatches {
+		if len(b.vertices) == 0 {
+			// Not used this frame; the page may even be gone after a palette change.
+			delete(r.tileBatches, page)
+			continue
+		}
+		for start := 0; start < len(b.indices); start += tilesPerDrawCall * tileIndices {
+			tile := start / tileIndices
+			end := min(tile+tilesPerDrawCall, len(b.indices)/tileIndices)
+			r.drawTileBatch(screen, page, b.vertices[tile*len(tileCorners):end*len(tileCorners)], b.indices[start:end*tileIndices])
+		}
+	}
+}
+
+// drawTileBatch draws the given part of a batch.
+func (r *renderer) drawTileBatch(screen, page *ebiten.Image, vertices []ebiten.Vertex, indices []uint16) {
+	if r.world.GlobalColorMSet {
+		colorm.DrawTriangles(screen, vertices, indices, page, r.world.GlobalColorM, &colorm.DrawTrianglesOptions{
+			// Note: could be BlendCopy, but that can't be merged with entities pass.
+			Blend:  ebiten.BlendSourceOver,
+			Filter: ebiten.FilterNearest,
+		})
+	} else {
+		screen.DrawTriangles(vertices, indices, page, &ebiten.DrawTrianglesOptions{
+			// Note: could be BlendCopy, but that can't be merged with entities pass.
+			Blend:  ebiten.BlendSourceOver,
+			Filter: ebiten.FilterNearest,
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"math"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+	"github.com/divVerent/aaaaxy/internal/rng"
+)
+
+const (
+	// titleBackgroundOrbitFrames is how long the camera takes to circle a checkpoint.
+	titleBackgroundOrbitFrames = 20 * GameTPS
+	// titleBackgroundOrbitRadius is the horizontal radius of the camera path in pixels.
+	// The vertical radius is half of it.
+	titleBackgroundOrbitRadius = 4 * level.TileSize
+)
+
+// TitleBackground is a detached copy of the world shown behind the title screen.
+//
+// It slowly flies a camera around each checkpoint the player has seen in turn.
+// Visibility is computed from the camera, so the usual line of sight and fog
+// effects apply. Like the determinism shadow, it runs with sounds muted and
+// with its own centerprints and dialogs, and it never changes the random
+// number state or the save game of the real world.
+type TitleBackground struct {
+	shadow      determinismShadow
+	checkpoints []string
+	index       int
+	frame       int
+	center      m.Pos
+}
+
+// NewTitleBackground creates a title background showing the given world.
+func NewTitleBackground(w *World) (*TitleBackground, error) {
+	b := &TitleBackground{
+		shadow: determinismShadow{
+			world: &World{
+				isShadow: true,
+			},
+		},
+	}
+	for name := range w.Level.Checkpoints {
+		if name == "" || w.PlayerState.CheckpointSeen(name) != playerstate.NotSeen {
+			b.checkpoints = append(b.checkpoints, name)
+		}
+	}
+	sort.Strings(b.checkpoints)
+	// Start where the player left off.
+	b.index = sort.SearchStrings(b.checkpoints, w.PlayerState.LastCheckpoint()) % len(b.checkpoints)
+	var err error
+	b.run(func() {
+		err = b.shadow.world.initWithLevel(w.Level.Clone(), w.saveState)
+		if err != nil {
+			return
+		}
+		err = b.respawn()
+	})
+	if err != nil {
+		b.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// run calls f with the global state switched to the background world.
+func (b *TitleBackground) run(f func()) {
+	rngState := rng.SaveState()
+	b.shadow.run(f)
+	rng.LoadState(rngState)
+}
+
+// respawn moves the camera to the current checkpoint.
+func (b *TitleBackground) respawn() error {
+	w := b.shadow.world
+	w.SetFreeCamera(false)
+	err := w.RespawnPlayer(b.checkpoints[b.index], true)
+	if err != nil {
+		return err
+	}
+	w.SetFreeCamera(true)
+	b.center = w.freeCameraPos
+	b.frame = 0
+	return nil
+}
+
+// Update advances the camera by one frame.
+func (b *TitleBackground) Update() error {
+	var err error
+	b.run(func() {
+		w := b.shadow.world
+		w.storePrevPositions()
+		b.frame++
+		if b.frame >= titleBackgroundOrbitFrames && len(b.checkpoints) > 1 {
+			b.index = (b.index + 1) % len(b.checkpoints)
+			err = b.respawn()
+			return
+		}
+		// Start at the checkpoint and circle below it.
+		t := 2 * math.Pi * float64(b.frame) / titleBackgroundOrbitFrames
+		target := b.center.Add(m.Delta{
+			DX: int(math.Round(math.Sin(t) * titleBackgroundOrbitRadius)),
+			DY: int(math.Round((1 - math.Cos(t)) * titleBackgroundOrbitRadius / 2)),
+		})
+		w.moveFreeCamera(target.Delta(w.freeCameraPos))
+		w.setScrollPos(w.freeCameraPos)
+		w.updateVisibility(w.freeCameraPos, w.MaxVisiblePixels)
+		w.updateTransition()
+		w.AssumeChanged()
+	})
+	return err
+}
+
+// Draw draws the title background.
+func (b *TitleBackground) Draw(screen *ebiten.Image) {
+	b.run(func() {
+		b.shadow.world.Draw(screen, 0)
+	})
+}
+
+// Close releases all entities of the title background.
+func (b *TitleBackground) Close() {
+	b.run(b.shadow.world.clearEntities)
+}
@@ -16,7 +16,6 @@ package engine
 
 import (
 	"errors"
-	"sort"
 
 	"github.com/divVerent/aaaaxy/internal/level"
 	m "github.com/divVerent/aaaaxy/internal/math"
@@ -53,7 +52,11 @@ type TraceResult struct {
 	// HitTile *level.Tile
 	// HitEntities are all the entities that stopped the trace simultaneously, if any.
 	// They are sorted in decreasing order of closeness to the player; be aware that some code will only consider the first member.
+	// The slice lives in a per-frame buffer that is reused at the start of the next World.Update,
+	// so it must be copied if it is needed for longer than the current frame.
 	HitEntities []*Entity
+	// HitSlope is set if the trace was stopped by the solid part of a slope tile.
+	HitSlope bool
 	// // HitFogOfWar is set if the trace ended by hitting an unloaded tile.
 	// HitFogOfWar bool
 }
@@ -305,6 +308,13 @@ func (l *normalizedLine) traceEntity(ent *Entity, enlarge m.Delta, maxBorder int
 	return false, m.Pos{}, m.Delta{}
 }
 
+// entityQueryRect returns the rect an entity must overlap to possibly be hit by the trace.
+func (l *normalizedLine) entityQueryRect(enlarge m.Delta, maxBorder int) m.Rect {
+	r := m.Rect{Origin: l.Origin, Size: m.Delta{DX: 1, DY: 1}}.Union(m.Rect{Origin: l.Target, Size: m.Delta{DX: 1, DY: 1}})
+	r.Size = r.Size.Add(enlarge)
+	return r.Grow(m.Delta{DX: maxBorder, DY: maxBorder})
+}
+
 type traceHit struct {
 	endPos    m.Pos
 	hitDelta  m.Delta
@@ -318,11 +328,23 @@ func (l *normalizedLine) traceEntities(w *World, o TraceOptions, enlarge m.Delta
 	worldDist := result.EndPos.Delta(l.Origin).Norm1()
 
 	// Clip the trace to first entity hit.
-	ents := w.FindContents(o.Contents)
+	ents := &w.entities
+	if o.Contents == level.OpaqueContents {
+		ents = &w.opaqueEntities
+	}
+
+	// Only consider entities near the trace if possible.
+	candidates := ents.items
+	if indexed, ok := w.entityIndex.query(l.entityQueryRect(enlarge, maxBorder), ents); ok {
+		candidates = indexed
+	}
 
-	var hits []traceHit
+	hits := w.traceHits[:0]
 
-	for _, ent := range ents {
+	for _, ent := range candidates {
+		if ent == nil || ent.contents&o.Contents == 0 {
+			continue
+		}
 		if ent == o.IgnoreEnt {
 			continue
 		}
@@ -356,18 +378,24 @@ func (l *normalizedLine) traceEntities(w *World, o TraceOptions, enlarge m.Delta
 		}
 	}
 
+	// Keep the buffer for the next trace.
+	w.traceHits = hits[:0]
+
 	if len(hits) == 0 {
 		return
 	}
 
 	// Move the closest hit to the start.
 	// Yes, this may be more expensive, but it makes the game usually more deterministic regarding touch event ordering.
-	sort.SliceStable(hits, func(i, j int) bool {
-		return hits[i].score.CompareFine(hits[j].score) < 0
-	})
+	// Stable insertion sort, as there are usually very few hits, and sort.SliceStable allocates.
+	for i := 1; i < len(hits); i++ {
+		for j := i; j > 0 && hits[j].score.CompareFine(hits[j-1].score) < 0; j-- {
+			hits[j], hits[j-1] = hits[j-1], hits[j]
+		}
+	}
 
 	// Return all trace hits.
-	result.HitEntities = make([]*Entity, len(hits))
+	result.HitEntities = w.allocHitEntities(len(hits))
 	for i, hit := range hits {
 		result.HitEntities[i] = hit.hitEntity
 	}
@@ -375,6 +403,7 @@ func (l *normalizedLine) traceEntities(w *World, o TraceOptions, enlarge m.Delta
 	// Return the closest hit properties.
 	result.EndPos = hits[0].endPos
 	result.HitDelta = hits[0].hitDelta
+	result.HitSlope = false
 
 	// Return the end tile.
 	endTile := result.EndPos.Div(level.TileSize)
@@ -392,6 +421,24 @@ func (l *normalizedLine) traceEntities(w *World, o TraceOptions, enlarge m.Delta
 	// result.HitFogOfWar = false
 }
 
+// allocHitEntities returns a slice for n hit entities from the per-frame buffer.
+func (w *World) allocHitEntities(n int) []*Entity {
+	start := len(w.traceHitEntities)
+	if cap(w.traceHitEntities)-start < n {
+		// Start a new buffer; slices handed out before keep the old one.
+		w.traceHitEntities = make([]*Entity, 0, 2*cap(w.traceHitEntities)+n)
+		start = 0
+	}
+	w.traceHitEntities = w.traceHitEntities[:start+n]
+	return w.traceHitEntities[start : start+n : start+n]
+}
+
+// resetHitEntities makes the per-frame hit entities buffer available again.
+func (w *World) resetHitEntities() {
+	clear(w.traceHitEntities)
+	w.traceHitEntities = w.traceHitEntities[:0]
+}
+
 // traceLineBox checks if from..to intersects with box, and if so, returns the pixel right before the intersection.
 // i, j must be positive and i > j. The box is described by i0, j0, i1, j1 such that i0 <= i1 and j0 <= j1.
 func traceLineBox(i, j, i0, j0, i1, j1 int) (bool, int, int, int, int) {
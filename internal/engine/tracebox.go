@@ -20,10 +20,13 @@ import (
 	m "github.com/divVerent/aaaaxy/internal/math"
 )
 
-func (l *normalizedLine) traceBoxTiles(w *World, o TraceOptions, enlarge m.Delta, result *TraceResult) {
+// traceBoxTiles cuts the given trace by hits against the tilemap.
+// It returns the slope tiles passed through, which need to be checked separately.
+func (l *normalizedLine) traceBoxTiles(w *World, o TraceOptions, enlarge m.Delta, result *TraceResult) []slopeTile {
 	if o.PathOut != nil {
 		*o.PathOut = append(*o.PathOut, l.Origin.Div(level.TileSize))
 	}
+	slopes := appendSlopeTiles(w, o, m.Rect{Origin: l.Origin, Size: enlarge.Add(m.Delta{DX: 1, DY: 1})}, w.traceSlopes[:0])
 	// Find the corner in direction of the trace.
 	var adjustment m.Delta
 	if l.XDir > 0 {
@@ -62,13 +65,14 @@ func (l *normalizedLine) traceBoxTiles(w *World, o TraceOptions, enlarge m.Delta
 					// result.HitFogOfWar = true
 					return errTraceDone
 				}
-				if o.Contents&tile.Contents != 0 {
+				if tile.FullyBlocks(o.Contents) {
 					result.EndPos = prevPixel
 					result.HitDelta = delta
 					// result.HitTilePos = nextTile
 					// result.HitTile = tile
 					return errTraceDone
 				}
+				slopes = appendSlopeTile(o, tilePos, tile, slopes)
 			}
 		} else {
 			// Y move.
@@ -89,19 +93,21 @@ func (l *normalizedLine) traceBoxTiles(w *World, o TraceOptions, enlarge m.Delta
 					// result.HitFogOfWar = true
 					return errTraceDone
 				}
-				if o.Contents&tile.Contents != 0 {
+				if tile.FullyBlocks(o.Contents) {
 					result.EndPos = prevPixel
 					result.HitDelta = delta
 					// result.HitTilePos = nextTile
 					// result.HitTile = tile
 					return errTraceDone
 				}
+				slopes = appendSlopeTile(o, tilePos, tile, slopes)
 			}
 		}
 		return nil
 	})
 	l.Origin = prevOrigin
 	l.Target = prevTarget
+	return slopes
 }
 
 func traceBox(w *World, from m.Rect, to m.Pos, o TraceOptions) TraceResult {
@@ -130,7 +136,9 @@ func traceBox(w *World, from m.Rect, to m.Pos, o TraceOptions) TraceResult {
 	}
 
 	if !o.NoTiles {
-		l.traceBoxTiles(w, o, enlarge, &result)
+		slopes := l.traceBoxTiles(w, o, enlarge, &result)
+		l.traceSlopes(o, enlarge, slopes, &result)
+		w.traceSlopes = slopes[:0]
 	}
 
 	if !o.NoEntities {
@@ -22,10 +22,12 @@ import (
 
 // traceLineTiles cuts the given trace by hits against the tilemap.
 // l must have been initialized to finish at the current EndPos.
-func (l *normalizedLine) traceLineTiles(w *World, o TraceOptions, result *TraceResult) {
+// It returns the slope tiles passed through, which need to be checked separately.
+func (l *normalizedLine) traceLineTiles(w *World, o TraceOptions, result *TraceResult) []slopeTile {
 	if o.PathOut != nil {
 		*o.PathOut = append(*o.PathOut, l.Origin.Div(level.TileSize))
 	}
+	slopes := appendSlopeTiles(w, o, m.Rect{Origin: l.Origin, Size: m.Delta{DX: 1, DY: 1}}, w.traceSlopes[:0])
 	l.walkTiles(func(prevTile, nextTile m.Pos, delta m.Delta, prevPixel, nextPixel m.Pos) error {
 		// Check the newly hit tile(s).
 		var tile *level.Tile
@@ -40,18 +42,20 @@ func (l *normalizedLine) traceLineTiles(w *World, o TraceOptions, result *TraceR
 			// result.HitFogOfWar = true
 			return errTraceDone
 		}
-		if o.Contents&tile.Contents != 0 {
+		if tile.FullyBlocks(o.Contents) {
 			result.EndPos = prevPixel
 			result.HitDelta = delta
 			// result.HitTilePos = nextTile
 			// result.HitTile = tile
 			return errTraceDone
 		}
+		slopes = appendSlopeTile(o, nextTile, tile, slopes)
 		if o.PathOut != nil {
 			*o.PathOut = append(*o.PathOut, nextTile)
 		}
 		return nil
 	})
+	return slopes
 }
 
 // traceLine moves from from to to and yields info about where this hit solid etc.
@@ -79,7 +83,9 @@ func traceLine(w *World, from, to m.Pos, o TraceOptions) TraceResult {
 	// As from != to, we know NumSteps > 0.
 
 	if !o.NoTiles {
-		l.traceLineTiles(w, o, &result)
+		slopes := l.traceLineTiles(w, o, &result)
+		l.traceSlopes(o, m.Delta{}, slopes, &result)
+		w.traceSlopes = slopes[:0]
 	}
 
 	if !o.NoEntities {
@@ -0,0 +1,120 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// slopeTile is a slope tile that a trace passes through.
+type slopeTile struct {
+	pos  m.Pos
+	tile *level.Tile
+}
+
+// solidInRect returns whether the given rectangle hits the solid part of the slope.
+func (s *slopeTile) solidInRect(r m.Rect) bool {
+	return s.tile.Slope.SolidInRect(m.Rect{
+		Origin: r.Origin.Sub(s.pos.Mul(level.TileSize).Delta(m.Pos{})),
+		Size:   r.Size,
+	})
+}
+
+// appendSlopeTiles appends all slope tiles relevant for the given contents in the given rectangle.
+func appendSlopeTiles(w *World, o TraceOptions, r m.Rect, slopes []slopeTile) []slopeTile {
+	if o.Contents&level.SolidContents == 0 {
+		return slopes
+	}
+	tl := r.Origin.Div(level.TileSize)
+	br := r.OppositeCorner().Div(level.TileSize)
+	for y := tl.Y; y <= br.Y; y++ {
+		for x := tl.X; x <= br.X; x++ {
+			slopes = appendSlopeTile(o, m.Pos{X: x, Y: y}, w.Tile(m.Pos{X: x, Y: y}), slopes)
+		}
+	}
+	return slopes
+}
+
+// appendSlopeTile appends the given tile if it is a slope tile relevant for the given contents.
+func appendSlopeTile(o TraceOptions, pos m.Pos, tile *level.Tile, slopes []slopeTile) []slopeTile {
+	if tile == nil || !tile.SlopeBlocks(o.Contents) {
+		return slopes
+	}
+	return append(slopes, slopeTile{pos: pos, tile: tile})
+}
+
+// traceSlopes clips the given trace against slope tiles.
+// l must have been initialized to hit the current EndPos anywhere on its path.
+// slopes must contain all slope tiles the trace passes through up to EndPos.
+func (l *normalizedLine) traceSlopes(o TraceOptions, enlarge m.Delta, slopes []slopeTile, result *TraceResult) {
+	if len(slopes) == 0 {
+		return
+	}
+	size := enlarge.Add(m.Delta{DX: 1, DY: 1})
+
+	// Walk the pixels of the line one by one, in the same order the tile walk uses.
+	prevI, prevJ := 0, 0
+	for i := 0; i <= l.NumSteps; i++ {
+		i0 := 2*i - 1
+		if i0 < 0 {
+			i0 = 0
+		}
+		i1 := 2*i + 1
+		if i1 > 2*l.NumSteps {
+			i1 = 2 * l.NumSteps
+		}
+		j0 := (l.Height*i0 + l.NumSteps) / (2 * l.NumSteps)
+		j1 := (l.Height*i1 + l.NumSteps) / (2 * l.NumSteps)
+		for j := j0; j <= j1; j++ {
+			if i == prevI && j == prevJ {
+				continue
+			}
+			prevPixel := l.toPos(prevI, prevJ)
+			if prevPixel == result.EndPos {
+				return
+			}
+			nextPixel := l.toPos(i, j)
+			delta := nextPixel.Delta(prevPixel)
+			prevRect := m.Rect{Origin: prevPixel, Size: size}
+			nextRect := m.Rect{Origin: nextPixel, Size: size}
+			for k := range slopes {
+				s := &slopes[k]
+				if !s.solidInRect(nextRect) {
+					continue
+				}
+				// If we already overlap, only block moves that go deeper into solid.
+				// This way we can get out of solid.
+				if s.solidInRect(prevRect) && delta.Dot(s.tile.Slope.Normal) <= 0 {
+					continue
+				}
+				result.EndPos = prevPixel
+				result.HitDelta = delta
+				result.HitSlope = true
+				endTile := result.EndPos.Div(level.TileSize)
+				if o.PathOut != nil {
+					for i, pos := range *o.PathOut {
+						if pos == endTile {
+							*o.PathOut = (*o.PathOut)[:(i + 1)]
+							break
+						}
+					}
+				}
+				return
+			}
+			prevI, prevJ = i, j
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	go_image "image"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/effects"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/offscreen"
+	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+	"github.com/divVerent/aaaaxy/internal/rng"
+)
+
+var (
+	respawnTransition      = flag.String("respawn_transition", "none", "transition to show when respawning after dying (none, fade, iris or glitch)")
+	respawnTransitionTime  = flag.Duration("respawn_transition_time", time.Second/2, "duration of the respawn transition")
+	teleportTransition     = flag.String("teleport_transition", "none", "transition to show when teleporting or loading (none, fade, iris or glitch)")
+	teleportTransitionTime = flag.Duration("teleport_transition_time", time.Second/2, "duration of the teleport transition")
+)
+
+const (
+	// irisVertices is the number of vertices of the iris wipe circle.
+	irisVertices = 48
+	// glitchBands is the number of horizontal bands the glitch effect shifts.
+	glitchBands = 24
+	// glitchMaxShift is the maximum band shift of the glitch effect, in pixels.
+	glitchMaxShift = 48
+)
+
+var transitionRand = rng.Get("transition")
+
+// transitionKind is a kind of transition effect.
+type transitionKind int
+
+const (
+	noTransition transitionKind = iota
+	fadeTransition
+	irisTransition
+	glitchTransition
+)
+
+func parseTransitionKind(s string) (transitionKind, error) {
+	switch s {
+	case "none":
+		return noTransition, nil
+	case "fade":
+		return fadeTransition, nil
+	case "iris":
+		return irisTransition, nil
+	case "glitch":
+		return glitchTransition, nil
+	default:
+		return noTransition, fmt.Errorf("unknown transition %q: must be none, fade, iris or glitch", s)
+	}
+}
+
+// transition is the state of the transition currently being shown.
+// Transitions are purely visual; the game keeps running, and the speedrun timer with it.
+type transition struct {
+	kind   transitionKind
+	frames int
+	frame  int
+}
+
+// startTransition begins showing the transition configured by the given flags.
+func (w *World) startTransition(kindFlag string, duration time.Duration) {
+	kind, err := parseTransitionKind(kindFlag)
+	if err != nil {
+		log.Errorf("invalid transition: %v", err)
+		return
+	}
+	if kind == glitchTransition && effects.Reduced() {
+		kind = fadeTransition
+	}
+	w.transition = transition{
+		kind:   kind,
+		frames: int((duration*GameTPS + (time.Second / 2)) / time.Second),
+	}
+}
+
+// updateTransition advances the current transition by one frame.
+func (w *World) updateTransition() {
+	if w.transition.frame < w.transition.frames {
+		w.transition.frame++
+	}
+}
+
+// drawTransition draws the current transition on top of the world.
+func (r *renderer) drawTransition(screen *ebiten.Image, scrollDelta m.Delta) {
+	t := &r.world.transition
+	if t.kind == noTransition || t.frame >= t.frames {
+		return
+	}
+	// f goes from 0 (fully covered) to 1 (fully visible).
+	f := float64(t.frame) / float64(t.frames)
+	switch t.kind {
+	case fadeTransition:
+		screen.Fill(palette.EGA(palette.Black, uint8(255*(1-f))))
+	case irisTransition:
+		center := r.world.Player.Rect.Center().Add(scrollDelta)
+		if r.world.PlayerState.Modifiers().ContainAll(playerstate.MirrorModifier) {
+			center.X = GameWidth - 1 - center.X
+		}
+		radius := f * m.Delta{DX: GameWidth, DY: GameHeight}.Length()
+		vertices := make([]m.Pos, irisVertices)
+		for i := range vertices {
+			angle := 2 * math.Pi * float64(i) / irisVertices
+			vertices[i] = center.Add(m.Delta{
+				DX: m.Rint(radius * math.Cos(angle)),
+				DY: m.Rint(radius * math.Sin(angle)),
+			})
+		}
+		texM := ebiten.GeoM{}
+		texM.Scale(0, 0)
+		drawAntiPolygonAround(screen, center, vertices, r.whiteImage, palette.EGA(palette.Black, 255), ebiten.GeoM{}, texM, &ebiten.DrawTrianglesOptions{})
+	case glitchTransition:
+		tmp := offscreen.New("Glitch", GameWidth, GameHeight)
+		defer offscreen.Dispose(tmp)
+		tmp.DrawImage(screen, &ebiten.DrawImageOptions{
+			Blend:  ebiten.BlendCopy,
+			Filter: ebiten.FilterNearest,
+		})
+		screen.Fill(palette.EGA(palette.Black, 255))
+		shift := m.Rint(glitchMaxShift * (1 - f))
+		for i := 0; i < glitchBands; i++ {
+			y0 := GameHeight * i / glitchBands
+			y1 := GameHeight * (i + 1) / glitchBands
+			options := &ebiten.DrawImageOptions{
+				Blend:  ebiten.BlendCopy,
+				Filter: ebiten.FilterNearest,
+			}
+			options.GeoM.Translate(float64(transitionRand.Intn(2*shift+1)-shift), float64(y0))
+			screen.DrawImage(tmp.SubImage(go_image.Rect(0, y0, GameWidth, y1)).(*ebiten.Image), options)
+		}
+	}
+}
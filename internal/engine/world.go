@@ -26,6 +26,7 @@ import (
 
 	"github.com/divVerent/aaaaxy/internal/centerprint"
 	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/dialog"
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/level"
 	"github.com/divVerent/aaaaxy/internal/locale"
@@ -33,7 +34,9 @@ import (
 	m "github.com/divVerent/aaaaxy/internal/math"
 	"github.com/divVerent/aaaaxy/internal/playerstate"
 	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/rng"
 	"github.com/divVerent/aaaaxy/internal/splash"
+	"github.com/divVerent/aaaaxy/internal/telemetry"
 	"github.com/divVerent/aaaaxy/internal/timing"
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
@@ -46,14 +49,19 @@ var (
 	debugCheckEntitySpawn            = flag.Bool("debug_check_entity_spawn", false, "if set, crash if an entity fails to spawn")
 )
 
+// lowVisibilityPixels is how far the player can see with the low visibility modifier.
+const lowVisibilityPixels = 6 * level.TileSize
+
 // World represents the current game state including its entities.
 type World struct {
 	renderer renderer
 
 	// tiles are all tiles currently loaded.
-	tiles [tileWindowWidth * tileWindowHeight]*level.Tile
+	tiles []*level.Tile
 	// markedTilesBuffer has the same size as tiles and is used when updating visibility.
-	markedTilesBuffer [tileWindowWidth * tileWindowHeight]m.Pos
+	markedTilesBuffer []m.Pos
+	// prefetchedLevelTiles are the level positions around which assets have already been prefetched.
+	prefetchedLevelTiles map[m.Pos]struct{}
 	// incarnations are all currently existing entity incarnations.
 	incarnations map[EntityIncarnation]struct{}
 	// entities are all entities currently loaded.
@@ -64,8 +72,12 @@ type World struct {
 	opaqueEntities entityList
 	// Player is the player entity.
 	Player *Entity
+	// Overlays are drawn on top of all entities; see NewOverlay.
+	Overlays []*Entity
 	// PlayerState is the managed persistent state of the player.
 	PlayerState playerstate.PlayerState
+	// Camera decides which part of the world is shown.
+	Camera Camera
 	// Level is the current tilemap (universal covering with warpZones).
 	Level *level.Level
 	// Frame since last spawn. Used to let the world slowly "fade in".
@@ -94,6 +106,12 @@ type World struct {
 	// scrollPos is the current screen scrolling position.
 	scrollPos m.Pos
 
+	// prevScrollPos is the scroll position of the previous tick, for render interpolation.
+	prevScrollPos    m.Pos
+	hasPrevScrollPos bool
+	// renderLag is how far rendering is behind the current tick, as a fraction of a tick.
+	renderLag float64
+
 	// bottomRightTile is the tile at scrollPos.
 	bottomRightTile m.Pos
 	// frameVis is the current mark value to detect visible tiles/objects.
@@ -104,6 +122,31 @@ type World struct {
 	// respawned is set if the player got respawned this frame.
 	respawned bool
 
+	// hitstopFrames is how many more frames the world stays frozen.
+	hitstopFrames int
+	// shakeAmplitude is the initial amplitude of the current screen shake.
+	shakeAmplitude int
+	// shakeFrames is the duration of the current screen shake.
+	shakeFrames int
+	// shakeFrame is the current frame of the screen shake.
+	shakeFrame int
+	// shakeOffset is how far the screen is currently moved by shaking.
+	shakeOffset m.Delta
+	// transition is the respawn or teleport transition being shown.
+	transition transition
+	// scheduled are callbacks to run on later frames.
+	scheduled []scheduledCallback
+
+	// freeCamera is set while the camera is detached from the player.
+	freeCamera bool
+	// freeCameraPos is the position of the detached camera.
+	freeCameraPos m.Pos
+
+	// inspected is the entity selected in the entity inspector.
+	inspected EntityIncarnation
+	// inspectorClicking is set while the mouse button is held in the entity inspector.
+	inspectorClicking bool
+
 	// traceLineAndMarkPath receives the path from tracing visibility.
 	// Exists to reduce memory allocation.
 	traceLineAndMarkPath []m.Pos
@@ -117,6 +160,29 @@ type World struct {
 
 	// Name of the save state.
 	saveState int
+
+	// rngSeed is the seed of the random number generators, saved with the game.
+	rngSeed   int
+	rngSeeded bool
+
+	// shadow is the world simulated in lockstep by the determinism check, if any.
+	shadow *determinismShadow
+	// isShadow is set on the shadow world itself, and on the title background.
+	isShadow bool
+	// inUpdate is set while updating, to tell gameplay respawns from external ones.
+	inUpdate bool
+
+	// pendingSnapshots are entity states from the last loaded snapshot to apply on respawn.
+	pendingSnapshots map[EntityIncarnation]entitySnapshot
+
+	// entityIndex is a spatial hash of all entities, used by traces.
+	entityIndex entityIndex
+
+	// traceHits, traceSlopes and traceHitEntities are scratch buffers for tracing.
+	// Exist to reduce memory allocation.
+	traceHits        []traceHit
+	traceSlopes      []slopeTile
+	traceHitEntities []*Entity
 }
 
 // Initialized returns whether Init() has been called on this World before.
@@ -179,6 +245,19 @@ func (w *World) ForEachEntity(f func(e *Entity)) {
 	})
 }
 
+// EntityCounts returns the number of loaded entities, and how many of them are opaque.
+func (w *World) EntityCounts() (all, opaque int) {
+	w.entities.forEach(func(e *Entity) error {
+		all++
+		return nil
+	})
+	w.opaqueEntities.forEach(func(e *Entity) error {
+		opaque++
+		return nil
+	})
+	return all, opaque
+}
+
 func (w *World) PreDespawn() {
 	w.ForEachEntity(func(e *Entity) {
 		if ed, ok := e.Impl.(PreDespawner); ok {
@@ -212,16 +291,23 @@ func loadLevel() (*level.Level, error) {
 	return loadLevelCache.Clone(), nil
 }
 
-var levelLoader *level.Loader = level.NewLoader("level")
+var (
+	levelLoader      *level.Loader
+	levelLoaderAsync *splash.Async
+)
 
 func Precache(s *splash.State) (splash.Status, error) {
-	status, err := s.Enter("loading level", locale.G.Get("loading level"), "failed to load level", levelLoader.LoadStepwise)
+	status, err := s.Enter("loading level", locale.G.Get("loading level"), "failed to load level", precacheLevel)
 	if status != splash.Continue {
 		return status, err
 	}
 
 	status, err = s.Enter("precaching entities", locale.G.Get("precaching entities"), "failed to precache entities", splash.Single(func() error {
-		return precacheEntities(levelLoader.Level())
+		err := precacheEntities(levelLoader.Level())
+		if err != nil {
+			return err
+		}
+		return precacheBackgrounds(levelLoader.Level())
 	}))
 	if status != splash.Continue {
 		return status, err
@@ -229,6 +315,27 @@ func Precache(s *splash.State) (splash.Status, error) {
 
 	loadLevelCache = levelLoader.Level()
 	levelLoader = nil // After returning Continue, this will never be called again.
+	levelLoaderAsync = nil
+	return splash.Continue, nil
+}
+
+// precacheLevel loads the level in the background so the loading screen stays responsive.
+func precacheLevel(s *splash.State) (splash.Status, error) {
+	if levelLoader == nil {
+		levelLoader = level.NewLoader("level")
+	}
+	if s == nil {
+		// No loading screen - just load right away.
+		return levelLoader.LoadStepwise(nil)
+	}
+	if levelLoaderAsync == nil {
+		levelLoaderAsync = splash.RunAsync(s.Fractions(), levelLoader.LoadStepwise)
+	}
+	s.Progress(levelLoaderAsync.Current())
+	done, err := levelLoaderAsync.Poll()
+	if !done || err != nil {
+		return splash.EndFrame, err
+	}
 	return splash.Continue, nil
 }
 
@@ -246,6 +353,114 @@ func ReloadLevel() error {
 	return nil
 }
 
+// levelReloadFractions are the progress bar fractions of the steps of a level reload.
+var levelReloadFractions = map[string]float64{
+	"loading level file":  0,
+	"parsing level data":  0.1,
+	"loading checkpoints": 0.7,
+	"hashing level":       0.8,
+}
+
+// precachingEntitiesFraction is the progress bar fraction of precaching entities after a level reload.
+const precachingEntitiesFraction = 0.9
+
+// LevelLoader reloads the level in the background.
+type LevelLoader struct {
+	prev       *LevelLoader
+	async      *splash.Async
+	loader     *level.Loader
+	precaching bool
+	done       bool
+}
+
+// ReloadLevelAsync starts reloading the level in the background.
+// prev is the previous LevelLoader, if any; it is cancelled, and the new reload only starts once it has stopped.
+// Poll Done every frame; once it returns true, the next world initialization uses the new level.
+func ReloadLevelAsync(prev *LevelLoader) *LevelLoader {
+	if prev != nil {
+		prev.cancel()
+	}
+	l := &LevelLoader{
+		prev: prev,
+	}
+	l.start()
+	return l
+}
+
+// start starts the background goroutine unless a previous one is still running.
+func (l *LevelLoader) start() {
+	if l.async != nil {
+		return
+	}
+	if l.prev != nil {
+		if l.prev.running() {
+			return
+		}
+		l.prev = nil
+	}
+	// Creating the loader captures the current language, so it must happen here on the main thread.
+	l.loader = level.NewLoader("level")
+	l.async = splash.RunAsync(levelReloadFractions, l.loader.LoadStepwise)
+}
+
+// cancel stops this and all previous reloads as soon as possible.
+func (l *LevelLoader) cancel() {
+	if l.async != nil {
+		l.async.Cancel()
+	}
+	if l.prev != nil {
+		l.prev.cancel()
+	}
+}
+
+// running returns whether this or a previous reload still has a goroutine running.
+func (l *LevelLoader) running() bool {
+	if l.async != nil {
+		done, _ := l.async.Poll()
+		return !done
+	}
+	return l.prev != nil && l.prev.running()
+}
+
+// Done returns whether the level has been reloaded.
+func (l *LevelLoader) Done() (bool, error) {
+	if l.done {
+		return true, nil
+	}
+	l.start()
+	if l.async == nil {
+		return false, nil
+	}
+	done, err := l.async.Poll()
+	if !done || err != nil {
+		return false, err
+	}
+	if !l.precaching {
+		// Precaching entities may render text and thus has to run on the main thread.
+		// Do it next frame so the loading screen can show it.
+		l.precaching = true
+		return false, nil
+	}
+	err = precacheEntities(l.loader.Level())
+	if err != nil {
+		return false, fmt.Errorf("failed to precache entities: %w", err)
+	}
+	loadLevelCache = l.loader.Level()
+	l.done = true
+	return true, nil
+}
+
+// Current returns the localized name of the current loading step and the progress bar fraction.
+func (l *LevelLoader) Current() (string, float64) {
+	if l.precaching {
+		return locale.G.Get("precaching entities"), precachingEntitiesFraction
+	}
+	if l.async == nil {
+		return "", 0
+	}
+	return l.async.Current()
+}
+
 func PaletteChanged() error {
 	loaded, err := level.NewLoader("level").Load()
 	if err != nil {
@@ -267,23 +482,33 @@ func (w *World) Init(saveState int) error {
 	if err != nil {
 		return err
 	}
+	return w.initWithLevel(lvl, saveState)
+}
 
+// initWithLevel brings a world into a working state using the given level.
+func (w *World) initWithLevel(lvl *level.Level, saveState int) error {
 	// Allow reiniting if already done.
+	w.stopShadow("")
 	w.clearEntities()
 
 	*w = World{
-		incarnations:   map[EntityIncarnation]struct{}{},
-		entities:       makeList(allList),
-		opaqueEntities: makeList(opaqueList),
-		Level:          lvl,
+		tiles:             make([]*level.Tile, tileWindowWidth*tileWindowHeight),
+		markedTilesBuffer: make([]m.Pos, 0, tileWindowWidth*tileWindowHeight),
+		incarnations:      map[EntityIncarnation]struct{}{},
+		entities:          makeList(allList),
+		opaqueEntities:    makeList(opaqueList),
+		Level:             lvl,
 		PlayerState: playerstate.PlayerState{
 			Level: lvl,
 		},
 		prevCpID:  level.InvalidEntityID,
 		saveState: saveState,
+		isShadow:  w.isShadow,
 	}
 	w.PlayerState.Init()
+	w.initRNG(nil)
 	w.renderer.Init(w)
+	w.switchAmbient()
 
 	// Load tile the player starts on.
 	w.setScrollPos(w.Level.Player.LevelPos.Mul(level.TileSize)) // Needed so we can set the tile.
@@ -292,6 +517,7 @@ func (w *World) Init(saveState int) error {
 	w.setTile(w.Level.Player.LevelPos, &tile)
 
 	// Create player entity.
+	var err error
 	w.Player, err = w.Spawn(w.Level.Player, w.Level.Player.LevelPos, &tile)
 	if err != nil {
 		return fmt.Errorf("could not spawn player: %w", err)
@@ -301,6 +527,25 @@ func (w *World) Init(saveState int) error {
 	return w.RespawnPlayer("", true)
 }
 
+// initRNG seeds the random number generators.
+// If seed is nil, the current seed is kept, or a new one is picked if there is none yet.
+func (w *World) initRNG(seed *int) {
+	if seed != nil {
+		w.rngSeed = *seed
+		w.rngSeeded = true
+	}
+	if !w.rngSeeded && w.isShadow {
+		// The shadow world shares the seed of the real world.
+		w.rngSeed = rng.Seed()
+		w.rngSeeded = true
+	}
+	if !w.rngSeeded {
+		w.rngSeed = demo.InterceptNewRNGSeed(rng.NewSeed())
+		w.rngSeeded = true
+	}
+	rng.SetSeed(w.rngSeed)
+}
+
 // Load loads the current savegame.
 // If this fails, the world may be in an undefined state; call w.Init() or w.Load() to resume.
 func (w *World) Load() error {
@@ -355,15 +600,26 @@ func (w *World) loadUnchecked(saveName string) error {
 		return err
 	}
 	w.PlayerState.Init()
+	// Save games from before seeds were saved keep the current seed.
+	w.initRNG(save.RNGSeed)
 	return w.RespawnPlayer(w.PlayerState.LastCheckpoint(), true)
 }
 
 // Save saves the current savegame.
 func (w *World) Save() error {
+	if assisted, _ := flag.Assisted(); assisted {
+		w.PlayerState.SetAssisted()
+	}
+	if w.isShadow {
+		// Only the real world may save.
+		return nil
+	}
 	save, err := w.Level.SaveGame()
 	if err != nil {
 		return err
 	}
+	seed := w.rngSeed
+	save.RNGSeed = &seed
 	if demo.InterceptSaveGame(save) {
 		return nil
 	}
@@ -381,6 +637,19 @@ func (w *World) Save() error {
 // As a side effect, it unloads all tiles.
 // Spawning at checkpoint "" means the initial player location.
 func (w *World) RespawnPlayer(checkpointName string, newGameSection bool) error {
+	if !w.inUpdate {
+		// Respawns from outside gameplay restart the determinism check.
+		defer w.startShadow(checkpointName, newGameSection)
+	}
+
+	if newGameSection {
+		w.startTransition(*teleportTransition, *teleportTransitionTime)
+	} else if !w.isShadow {
+		// Respawning in game means the player died.
+		telemetry.RecordDeath(w.Level.Title, checkpointName)
+		w.startTransition(*respawnTransition, *respawnTransitionTime)
+	}
+
 	// Load whether we've seen this checkpoint in flipped state.
 	flipped := w.PlayerState.CheckpointSeen(checkpointName) == playerstate.SeenFlipped
 
@@ -406,14 +675,17 @@ func (w *World) RespawnPlayer(checkpointName string, newGameSection bool) error
 	tile := w.Level.Tile(cpSp.LevelPos).Tile
 	tile.Transform = cpTransform
 	tile.Orientation = tile.Transform.Inverse().Concat(tile.Orientation)
+	tile.Slope = tile.Slope.Transform(tile.Transform.Inverse())
 	tile.ResolveImage()
 
 	// Build a new world around the CP tile and the player.
 	w.frameVis = 0
 	tile.VisibilityFlags = w.frameVis
 	w.clearEntities()
+	w.pendingSnapshots = nil // Forget about any previously loaded snapshot.
+	w.scheduled = nil        // Scheduled callbacks refer to the old entities.
 	w.link(w.Player)
-	for i := range w.tiles[:] {
+	for i := range w.tiles {
 		w.tiles[i] = nil
 	}
 	w.setScrollPos(cpSp.LevelPos.Mul(level.TileSize)) // Scroll the tile into view.
@@ -438,6 +710,7 @@ func (w *World) RespawnPlayer(checkpointName string, newGameSection bool) error
 		// Clear all centerprints.
 		// But only when coming from menu, not when respawning/teleporting in game.
 		centerprint.Reset()
+		dialog.Reset()
 	}
 
 	// Reset the ending stuff.
@@ -445,11 +718,11 @@ func (w *World) RespawnPlayer(checkpointName string, newGameSection bool) error
 	w.MaxVisiblePixels = math.MaxInt32
 	w.ForceCredits = false
 
-	// Reset all warpzones.
-	w.WarpZoneStates = map[string]bool{}
+	// Reset all warpzones to their saved state.
+	w.WarpZoneStates = w.PlayerState.WarpZoneStates()
 
 	// Move the player to the center of the checkpoint.
-	w.Player.Rect.Origin = cp.Rect.Origin.Add(cp.Rect.Size.Div(2)).Sub(w.Player.Rect.Size.Div(2))
+	w.SetOrigin(w.Player, cp.Rect.Origin.Add(cp.Rect.Size.Div(2)).Sub(w.Player.Rect.Size.Div(2)))
 
 	// Load all the stuff that the player needs.
 	w.LoadTilesForRect(w.Player.Rect, cpSp.LevelPos)
@@ -470,7 +743,7 @@ func (w *World) RespawnPlayer(checkpointName string, newGameSection bool) error
 			LoadTiles:  true,
 			ForEnt:     w.Player,
 		})
-		w.Player.Rect.Origin = trace.EndPos
+		w.SetOrigin(w.Player, trace.EndPos)
 	}
 
 	// Note that TraceBox must have loaded all tiles the player needs.
@@ -482,9 +755,11 @@ func (w *World) RespawnPlayer(checkpointName string, newGameSection bool) error
 
 	// Notify the player, reset animation state.
 	w.Player.Impl.(PlayerEntityImpl).Respawned()
+	w.SetRect(w.Player, w.Player.Rect) // May have changed the hitbox.
 
 	// Scroll the player in view right away.
 	w.setScrollPos(w.Player.Impl.(PlayerEntityImpl).LookPos())
+	w.Camera.reset(w.Player.Rect.Origin)
 
 	// Adjust previous scroll position by how much the CP "moved".
 	// That way, respawning right after touching a CP will retain CP-near screen content.
@@ -556,6 +831,9 @@ func (w *World) updateEntities() {
 		}
 		return nil
 	})
+	if !w.respawned {
+		w.runScheduled()
+	}
 
 	// Clean up newly spawned or despawned stuff.
 	w.entities.compact()
@@ -565,44 +843,6 @@ func (w *World) updateEntities() {
 	w.opaqueEntities.compact()
 }
 
-// updateScrollPos updates the current scroll position.
-func (w *World) updateScrollPos(target m.Pos) {
-	// Slowly move towards focus point.
-	targetDelta := target.Delta(w.scrollPos)
-	scrollDelta := targetDelta.MulFixed(m.NewFixedFloat64(scrollPerFrame))
-	if scrollDelta.DX == 0 {
-		if targetDelta.DX > 0 {
-			scrollDelta.DX = +1
-		}
-		if targetDelta.DX < 0 {
-			scrollDelta.DX = -1
-		}
-	}
-	if scrollDelta.DY == 0 {
-		if targetDelta.DY > 0 {
-			scrollDelta.DY = +1
-		}
-		if targetDelta.DY < 0 {
-			scrollDelta.DY = -1
-		}
-	}
-	target = w.scrollPos.Add(scrollDelta)
-	// Ensure player is onscreen.
-	if target.X < w.Player.Rect.OppositeCorner().X-GameWidth/2+scrollMinDistance {
-		target.X = w.Player.Rect.OppositeCorner().X - GameWidth/2 + scrollMinDistance
-	}
-	if target.X > w.Player.Rect.Origin.X+GameWidth/2-scrollMinDistance {
-		target.X = w.Player.Rect.Origin.X + GameWidth/2 - scrollMinDistance
-	}
-	if target.Y < w.Player.Rect.OppositeCorner().Y-GameHeight/2+scrollMinDistance {
-		target.Y = w.Player.Rect.OppositeCorner().Y - GameHeight/2 + scrollMinDistance
-	}
-	if target.Y > w.Player.Rect.Origin.Y+GameHeight/2-scrollMinDistance {
-		target.Y = w.Player.Rect.Origin.Y + GameHeight/2 - scrollMinDistance
-	}
-	w.setScrollPos(target)
-}
-
 func (w *World) setScrollPos(pos m.Pos) {
 	w.scrollPos = pos
 	w.bottomRightTile = pos.Div(level.TileSize).Add(m.Delta{DX: tileWindowWidth / 2, DY: tileWindowHeight / 2})
@@ -723,8 +963,15 @@ func (w *World) updateVisibility(eye m.Pos, maxDist int) {
 		}
 	})
 
+	timing.Section("prefetch")
+	w.prefetchAroundVisible()
+
 	timing.Section("despawn_search")
 	w.entities.forEach(func(ent *Entity) error {
+		if w.freeCamera && ent == w.Player {
+			// The player stays where it was while the camera is detached.
+			return nil
+		}
 		tp0, tp1 := tilesBox(ent.Rect.Grow(ent.SpawnTilesGrowth))
 		if !ent.RequireTiles {
 			// Non-RequireTiles entities are allowed to sit on tiles outside the tiles window.
@@ -812,7 +1059,7 @@ func (w *World) checkEntityOverlaps() error {
 				return nil
 			}
 			for _, box := range boxes {
-				if box.Rect.Delta(ent.Rect).IsZero() {
+				if box.Rect.Overlaps(ent.Rect) {
 					if box.Image == ent.Image {
 						// Exception: permit overlap of same image - it's likely intentional.
 						// Exists in "Nine Boxes In Sight" around Box5N and seems hard to fix.
@@ -833,9 +1080,46 @@ func (w *World) checkEntityOverlaps() error {
 }
 
 func (w *World) Update() error {
+	w.inUpdate = true
+	defer func() {
+		w.inUpdate = false
+	}()
+	if w.shadow != nil {
+		return w.updateWithShadow()
+	}
+	return w.update()
+}
+
+// update runs a single game tick.
+func (w *World) update() error {
 	defer timing.Group()()
 	w.FramesSinceSpawn++
 
+	// Trace results of last frame are no longer valid.
+	w.resetHitEntities()
+
+	// Rendering interpolates from here.
+	w.storePrevPositions()
+
+	// Catch up with entities that moved without telling the index.
+	w.entityIndex.resync(&w.entities, false)
+
+	// Debugging aids.
+	w.updateInspector()
+	if w.freeCamera {
+		return w.updateFreeCamera()
+	}
+
+	timing.Section("shake")
+	w.updateShake()
+	w.updateTransition()
+	if w.hitstopFrames > 0 {
+		w.hitstopFrames--
+		return nil
+	}
+
+	w.PlayerState.Tick()
+
 	// Let everything move.
 	timing.Section("entities")
 	w.updateEntities()
@@ -850,7 +1134,7 @@ func (w *World) Update() error {
 	playerImpl := w.Player.Impl.(PlayerEntityImpl)
 
 	// Scroll towards the focus point.
-	w.updateScrollPos(playerImpl.LookPos())
+	w.updateCamera(playerImpl.LookPos())
 
 	// Update visibility and spawn/despawn entities.
 	timing.Section("visibility")
@@ -858,11 +1142,17 @@ func (w *World) Update() error {
 	if pixels > w.MaxVisiblePixels {
 		pixels = w.MaxVisiblePixels
 	}
+	if w.PlayerState.Modifiers().ContainAll(playerstate.LowVisibilityModifier) && pixels > lowVisibilityPixels {
+		pixels = lowVisibilityPixels
+	}
 	w.updateVisibility(playerImpl.EyePos(), pixels)
 
 	// Update centerprints.
 	centerprint.Update()
 
+	// Update dialogs.
+	dialog.Update()
+
 	if *debugCountTiles {
 		log.Infof("%d tiles set, %d tiles cleared", w.tilesSet, w.tilesCleared)
 	}
@@ -879,9 +1169,27 @@ func (w *World) SetWarpZoneState(name string, state bool) {
 	w.warpzoneStatesChanged = true
 }
 
+// EnableWarpZone sets the enabled/disabled state of a warpzone.
+// Unlike SetWarpZoneState, this state is saved and survives respawning.
+func (w *World) EnableWarpZone(name string, state bool) {
+	w.PlayerState.SetWarpZoneState(name, state)
+	w.SetWarpZoneState(name, state)
+}
+
+// WarpZoneState returns the current enabled/disabled state of a warpzone.
+// Warpzones with the invert property are active when this is false.
+func (w *World) WarpZoneState(name string) bool {
+	return w.WarpZoneStates[name]
+}
+
 // LoadTile loads the next tile into the current world based on a currently
 // known tile and its neighbor. Respects and applies warps.
 func (w *World) LoadTile(p, newPos m.Pos, d m.Delta) *level.Tile {
+	return w.loadTile(p, newPos, d, false)
+}
+
+// loadTile loads a tile from a neighbor; allowOpaque permits loading from opaque neighbors.
+func (w *World) loadTile(p, newPos m.Pos, d m.Delta, allowOpaque bool) *level.Tile {
 	tile := w.Tile(newPos)
 	if tile != nil {
 		if tile.VisibilityFlags&level.FrameVis == w.frameVis {
@@ -901,7 +1209,7 @@ func (w *World) LoadTile(p, newPos m.Pos, d m.Delta) *level.Tile {
 		log.Errorf("trying to load with nonexisting neighbor tile at %v", p)
 		return nil // Can't load.
 	}
-	if neighborTile.Contents.Opaque() {
+	if neighborTile.Contents.Opaque() && !allowOpaque {
 		log.Errorf("trying to load from an opaque tile at %v", p)
 		return nil // Can't load.
 	}
@@ -920,30 +1228,10 @@ func (w *World) LoadTile(p, newPos m.Pos, d m.Delta) *level.Tile {
 		w.setTile(newPos, newTile)
 		return newTile
 	}
-	warped := false
-	for _, warp := range newLevelTile.WarpZones {
-		// Don't enter warps from behind.
-		if warp.PrevTile != neighborLevelPos {
-			continue
-		}
-		// Honor the warpzone toggle state.
-		if warp.Switchable {
-			if w.WarpZoneStates[warp.Name] == warp.Invert {
-				continue
-			}
-		}
-		if warped {
-			log.Errorf("more than one active warpzone on %v", newLevelTile)
-			return nil // Can't load.
-		}
-		warped = true
-		t = warp.Transform.Concat(t)
-		tile := w.Level.Tile(warp.ToTile)
-		if tile == nil {
-			log.Errorf("nil new tile after warping to %v", warp)
-			return nil // Can't load.
-		}
-		newLevelTile = tile
+	newLevelTile, t, err := w.applyWarpZones(neighborLevelPos, newLevelTile, t)
+	if err != nil {
+		log.Errorf("%v", err)
+		return nil // Can't load.
 	}
 	if tile != nil {
 		if tile.LevelPos == newLevelTile.Tile.LevelPos && tile.Transform == t {
@@ -959,6 +1247,8 @@ func (w *World) LoadTile(p, newPos m.Pos, d m.Delta) *level.Tile {
 	// new tiles ("which tilemap direction is looking right on the screen") and
 	// the orientation is for rendering ("how to rotate the sprite").
 	newTile.Orientation = t.Inverse().Concat(newTile.Orientation)
+	// The slope is in level space too and needs to be rotated the same way.
+	newTile.Slope = newTile.Slope.Transform(t.Inverse())
 	newTile.ResolveImage()
 	newTile.LoadedFromNeighbor = p
 	newTile.VisibilityFlags = w.frameVis
@@ -966,6 +1256,34 @@ func (w *World) LoadTile(p, newPos m.Pos, d m.Delta) *level.Tile {
 	return &newTile
 }
 
+// applyWarpZones returns the tile actually reached when entering newLevelTile from prevLevelPos with transform t.
+func (w *World) applyWarpZones(prevLevelPos m.Pos, newLevelTile *level.LevelTile, t m.Orientation) (*level.LevelTile, m.Orientation, error) {
+	warped := false
+	for _, warp := range newLevelTile.WarpZones {
+		// Don't enter warps from behind.
+		if warp.PrevTile != prevLevelPos {
+			continue
+		}
+		// Honor the warpzone toggle state.
+		if warp.Switchable {
+			if w.WarpZoneStates[warp.Name] == warp.Invert {
+				continue
+			}
+		}
+		if warped {
+			return nil, t, fmt.Errorf("more than one active warpzone on %v", newLevelTile)
+		}
+		warped = true
+		t = warp.Transform.Concat(t)
+		tile := w.Level.Tile(warp.ToTile)
+		if tile == nil {
+			return nil, t, fmt.Errorf("nil new tile after warping to %v", warp)
+		}
+		newLevelTile = tile
+	}
+	return newLevelTile, t, nil
+}
+
 // tilesBox returns corner coordinates for all tiles in a given box.
 func tilesBox(r m.Rect) (m.Pos, m.Pos) {
 	tp0 := r.Origin.Div(level.TileSize)
@@ -1016,6 +1334,11 @@ func (w *World) Draw(screen *ebiten.Image, blurFactor float64) {
 	w.renderer.Draw(screen, blurFactor)
 }
 
+// PrepareDraw performs the CPU side work of Draw without drawing anything.
+func (w *World) PrepareDraw() {
+	w.renderer.prepareFrame()
+}
+
 func encodeZ(z int) int {
 	if z < 0 {
 		return -1 - 2*z
@@ -1038,6 +1361,7 @@ func (w *World) unlink(e *Entity) {
 		w.opaqueEntities.remove(e)
 	}
 	w.entities.remove(e)
+	w.entityIndex.remove(e)
 	if e.Incarnation.IsValid() {
 		delete(w.incarnations, e.Incarnation)
 	}
@@ -1048,6 +1372,7 @@ func (w *World) link(e *Entity) {
 		w.incarnations[e.Incarnation] = struct{}{}
 	}
 	w.entities.insert(e)
+	w.entityIndex.insert(e)
 	if e.contents.Opaque() {
 		w.opaqueEntities.insert(e)
 	}
@@ -0,0 +1,48 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// World flags are typed values stored in the savegame that are not tied to
+// any entity. They allow puzzles spanning multiple rooms to communicate.
+
+// WorldFlag returns the value of the given world flag, or def if not set.
+func WorldFlag[V any](w *World, name string, def V) V {
+	v, err := propmap.ValueOr(w.Level.WorldFlags, name, def)
+	if err != nil {
+		log.Errorf("could not parse world flag %q: %v", name, err)
+	}
+	return v
+}
+
+// HasWorldFlag returns whether the given world flag is set.
+func HasWorldFlag(w *World, name string) bool {
+	_, err := propmap.Value(w.Level.WorldFlags, name, "")
+	return err == nil
+}
+
+// SetWorldFlag sets the given world flag.
+func SetWorldFlag[V any](w *World, name string, value V) {
+	propmap.Set(w.Level.WorldFlags, name, value)
+}
+
+// ClearWorldFlag removes the given world flag.
+func ClearWorldFlag(w *World, name string) {
+	propmap.Delete(w.Level.WorldFlags, name)
+}
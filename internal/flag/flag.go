@@ -34,6 +34,16 @@ var (
 	batch             = Bool("batch", false, "if set, show no alert boxes") // Must be declared here to prevent cycle.
 	loadConfig        = Bool("load_config", true, "enable processing of the configuration file")
 	debugPersistFlags = Bool("debug_persist_flags", false, "persist debug_* flags to config (including this one); BEWARE: this can degrade game performance")
+
+	// speedrunRestricted are the flags that must be at their default value for speedruns.
+	speedrunRestricted = map[string]struct{}{}
+
+	// assistFlags are the flags that mark a game as assisted when set to a non-default value.
+	assistFlags = map[string]struct{}{}
+
+	// demoFlags are the flags that affect gameplay and thus are stored in demos.
+	// They map to the value to play demos with that do not store them; nil means the default.
+	demoFlags = map[string]*string{}
 )
 
 // SystemDefault performs a GOOS/GOARCH dependent value lookup to be used in flag defaults.
@@ -172,6 +182,23 @@ func Marshal() *Config {
 	return c
 }
 
+// Sanitized returns all flags with non-default values for diagnostics.
+// Values that look like file paths are redacted, as they may contain personal information.
+func Sanitized() map[string]string {
+	flags := map[string]string{}
+	flagSet.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if value == f.DefValue {
+			return
+		}
+		if strings.ContainsAny(value, "/\\") {
+			value = "(redacted)"
+		}
+		flags[f.Name] = value
+	})
+	return flags
+}
+
 // Cheating returns if any cheats are enabled, and what they are.
 func Cheating() (bool, string) {
 	cheating := false
@@ -185,6 +212,112 @@ func Cheating() (bool, string) {
 	return cheating, strings.Join(cheats, " ")
 }
 
+// RestrictSpeedrun marks a flag as not speedrun legal when set to a non-default value.
+// Unlike cheats, such flags still allow saving and demo recording.
+func RestrictSpeedrun(name string) {
+	speedrunRestricted[name] = struct{}{}
+}
+
+// MarkAssist marks a flag as an assist option.
+// Such flags keep speedruns legal, but the run is then reported as assisted.
+func MarkAssist(name string) {
+	assistFlags[name] = struct{}{}
+}
+
+// RecordInDemo marks a flag as affecting gameplay.
+// Demos store the values of such flags, and playback applies them.
+func RecordInDemo(name string) {
+	demoFlags[name] = nil
+}
+
+// RecordInDemoWithLegacyValue marks a flag as affecting gameplay, like RecordInDemo.
+// Demos that do not store the flag yet are played back with the given legacy value,
+// which must match how the game behaved when they were recorded.
+func RecordInDemoWithLegacyValue(name string, legacyValue interface{}) {
+	value := fmt.Sprint(legacyValue)
+	demoFlags[name] = &value
+}
+
+// DemoFlags returns the values of all flags stored in demos.
+func DemoFlags() map[string]string {
+	flags := map[string]string{}
+	flagSet.VisitAll(func(f *flag.Flag) {
+		if _, demo := demoFlags[f.Name]; !demo {
+			return
+		}
+		flags[f.Name] = f.Value.String()
+	})
+	return flags
+}
+
+// SetDemoFlags applies flag values stored in a demo.
+// Flags stored in demos but missing in the given values are set to their legacy value,
+// as the demo was then recorded before they were stored in demos.
+func SetDemoFlags(flags map[string]string) error {
+	for name, value := range flags {
+		if _, demo := demoFlags[name]; !demo {
+			log.Warningf("demo sets unknown flag --%s=%s; ignoring", name, value)
+		}
+	}
+	var err error
+	flagSet.VisitAll(func(f *flag.Flag) {
+		legacyValue, demo := demoFlags[f.Name]
+		if !demo || err != nil {
+			return
+		}
+		value, found := flags[f.Name]
+		if !found {
+			value = f.DefValue
+			if legacyValue != nil {
+				value = *legacyValue
+			}
+		}
+		if value == f.Value.String() {
+			return
+		}
+		err = f.Value.Set(value)
+		if err != nil {
+			err = fmt.Errorf("could not set flag %v from demo: %w", f.Name, err)
+		}
+	})
+	return err
+}
+
+// Assisted returns if any assist options are enabled, and what they are.
+func Assisted() (bool, string) {
+	assisted := false
+	assists := []string{}
+	flagSet.VisitAll(func(f *flag.Flag) {
+		if _, assist := assistFlags[f.Name]; !assist {
+			return
+		}
+		if f.Value.String() == f.DefValue {
+			return
+		}
+		assisted = true
+		assists = append(assists, fmt.Sprintf("--%s=%s", f.Name, f.Value.String()))
+	})
+	return assisted, strings.Join(assists, " ")
+}
+
+// SpeedrunLegal returns whether the current flags are speedrun legal, and if not, which ones break it.
+func SpeedrunLegal() (bool, string) {
+	legal := true
+	reasons := []string{}
+	flagSet.VisitAll(func(f *flag.Flag) {
+		_, restricted := speedrunRestricted[f.Name]
+		if !strings.HasPrefix(f.Name, "cheat_") && !restricted {
+			return
+		}
+		if f.Value.String() == f.DefValue {
+			return
+		}
+		legal = false
+		reasons = append(reasons, fmt.Sprintf("--%s=%s", f.Name, f.Value.String()))
+	})
+	return legal, strings.Join(reasons, " ")
+}
+
 // ResetToDefaults returns all flags to their default value.
 func ResetToDefaults() {
 	flagSet.Visit(func(f *flag.Flag) {
@@ -258,6 +391,11 @@ func Parse(getSystemDefaults func() (*Config, error)) {
 	applyConfig()
 }
 
+// Args returns the non-flag command-line arguments.
+func Args() []string {
+	return flagSet.Args()
+}
+
 // NoConfig can be passed to Parse if the binary wants to do no config file processing.
 func NoConfig() (*Config, error) {
 	return nil, nil
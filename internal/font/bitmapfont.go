@@ -18,22 +18,23 @@ import (
 	"github.com/hajimehoshi/bitmapfont/v3"
 )
 
-func initBitmapfont() error {
+func initBitmapfont(faces map[string]*Face) error {
 	// 14, which is 16 when adding back the outline.
 	face := makeFace(bitmapfont.Face, 14)
 
-	ByName["Small"] = face
-	ByName["Regular"] = face
-	ByName["Italic"] = face
-	ByName["Bold"] = face
-	ByName["Mono"] = face
-	ByName["MonoSmall"] = face
-	ByName["SmallCaps"] = face
-	ByName["Centerprint"] = face
-	ByName["CenterprintBig"] = face
-	ByName["Menu"] = face
-	ByName["MenuBig"] = face
-	ByName["MenuSmall"] = face
+	faces["Small"] = face
+	faces["Regular"] = face
+	faces["Italic"] = face
+	faces["Bold"] = face
+	faces["Mono"] = face
+	faces["MonoSmall"] = face
+	faces["SmallCaps"] = face
+	faces["Centerprint"] = face
+	faces["CenterprintBig"] = face
+	faces["Menu"] = face
+	faces["MenuBig"] = face
+	faces["MenuSmall"] = face
+	faces["DebugSmall"] = face
 
 	return nil
 }
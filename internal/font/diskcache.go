@@ -0,0 +1,279 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package font
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/math/fixed"
+
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// glyphKey identifies a rendered glyph within a face.
+// The dot position is only stored as its fractional part.
+type glyphKey struct {
+	R          rune
+	DotX, DotY fixed.Int26_6
+}
+
+// glyphEntry is a rendered glyph.
+// DR is relative to the integer part of the dot position.
+// The mask is an alpha image of the size of DR with origin at zero.
+type glyphEntry struct {
+	DR      image.Rectangle
+	Pix     []byte
+	Advance fixed.Int26_6
+	OK      bool
+
+	lastUse uint64
+}
+
+// glyphCache caches rendered glyphs of a face so they can be stored on disk.
+type glyphCache struct {
+	entries map[glyphKey]*glyphEntry
+	dirty   bool
+	clock   uint64
+}
+
+func newGlyphCache() *glyphCache {
+	return &glyphCache{
+		entries: map[glyphKey]*glyphEntry{},
+	}
+}
+
+func splitDot(dot fixed.Point26_6) (image.Point, glyphKey) {
+	return image.Point{X: dot.X.Floor(), Y: dot.Y.Floor()}, glyphKey{
+		DotX: dot.X & 63,
+		DotY: dot.Y & 63,
+	}
+}
+
+// get returns a cached glyph, if any.
+func (c *glyphCache) get(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool, bool) {
+	if c == nil || *fontFractionalSpacing {
+		return image.Rectangle{}, nil, image.Point{}, 0, false, false
+	}
+	origin, key := splitDot(dot)
+	key.R = r
+	e := c.entries[key]
+	if e == nil {
+		return image.Rectangle{}, nil, image.Point{}, 0, false, false
+	}
+	c.clock++
+	e.lastUse = c.clock
+	mask := &image.Alpha{
+		Pix:    e.Pix,
+		Stride: e.DR.Dx(),
+		Rect:   image.Rectangle{Max: e.DR.Size()},
+	}
+	return e.DR.Add(origin), mask, image.Point{}, e.Advance, e.OK, true
+}
+
+// put adds a glyph to the cache and returns it in normalized form.
+func (c *glyphCache) put(dot fixed.Point26_6, r rune, dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	if c == nil || *fontFractionalSpacing || mask == nil {
+		return dr, mask, maskp, advance, ok
+	}
+	origin, key := splitDot(dot)
+	key.R = r
+	size := dr.Size()
+	pix := make([]byte, size.X*size.Y)
+	p := 0
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			_, _, _, a := mask.At(maskp.X+x, maskp.Y+y).RGBA()
+			pix[p] = uint8(a >> 8)
+			p++
+		}
+	}
+	c.clock++
+	c.entries[key] = &glyphEntry{
+		DR:      dr.Sub(origin),
+		Pix:     pix,
+		Advance: advance,
+		OK:      ok,
+		lastUse: c.clock,
+	}
+	c.dirty = true
+	c.evict()
+	normalized := &image.Alpha{
+		Pix:    pix,
+		Stride: size.X,
+		Rect:   image.Rectangle{Max: size},
+	}
+	return dr, normalized, image.Point{}, advance, ok
+}
+
+// glyphCacheLimit returns the maximum number of glyphs to keep per face.
+// It grows with the pinned character set so large charsets never thrash.
+func glyphCacheLimit() int {
+	limit := *fontGlyphCacheSize
+	if limit <= 0 {
+		return 0
+	}
+	if pinned := 4 * len(charSet); limit < pinned {
+		limit = pinned
+	}
+	return limit
+}
+
+// evict removes the least recently used glyphs not in the pinned character set
+// once the cache exceeds its limit.
+func (c *glyphCache) evict() {
+	limit := glyphCacheLimit()
+	if limit == 0 || len(c.entries) <= limit {
+		return
+	}
+	pinned := make(map[rune]struct{}, len(charSet))
+	for _, r := range charSet {
+		pinned[r] = struct{}{}
+	}
+	keys := make([]glyphKey, 0, len(c.entries))
+	for k := range c.entries {
+		if _, found := pinned[k.R]; !found {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		return c.entries[keys[a]].lastUse < c.entries[keys[b]].lastUse
+	})
+	// Evict down to three quarters of the limit to not do this on every new glyph.
+	excess := len(c.entries) - limit*3/4
+	if excess > len(keys) {
+		excess = len(keys)
+	}
+	for _, k := range keys[:excess] {
+		delete(c.entries, k)
+	}
+}
+
+// glyphCacheFile is the on-disk form of all glyph caches of a font.
+type glyphCacheFile map[string]map[glyphKey]*glyphEntry
+
+// glyphCacheName returns the cache entry name for the given font.
+func glyphCacheName(font string) string {
+	return "glyphs_" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, font)
+}
+
+// fontDataKeys are the cache keys of the data of fonts loaded from assets, by font name.
+// Fonts built into the binary are already covered by the cache salt.
+var fontDataKeys = map[string]uint64{}
+
+// glyphCacheKey returns the cache key for the given font chain.
+// It covers every font of the chain in fallback order, including its data.
+func glyphCacheKey(chain string) uint64 {
+	parts := [][]byte{[]byte(fmt.Sprintf("%s %d", chain, *fontThreshold))}
+	for _, name := range strings.Split(chain, ",") {
+		var key [8]byte
+		binary.LittleEndian.PutUint64(key[:], fontDataKeys[name])
+		parts = append(parts, []byte(name), key[:])
+	}
+	return vfs.CacheKey(parts...)
+}
+
+// glyphCaches returns all glyph caches of the current font by name.
+func glyphCaches() map[string]*glyphCache {
+	names := make([]string, 0, len(ByName))
+	for name := range ByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	caches := map[string]*glyphCache{}
+	done := map[*Face]struct{}{}
+	for _, name := range names {
+		f := ByName[name]
+		if _, found := done[f]; found {
+			continue
+		}
+		done[f] = struct{}{}
+		caches[name+"/fill"] = f.Face.glyphs
+		caches[name+"/outline"] = f.Outline.glyphs
+	}
+	return caches
+}
+
+// loadGlyphCache loads the rendered glyphs of the current font from disk.
+func loadGlyphCache() {
+	if *fontFractionalSpacing {
+		return
+	}
+	data, found := vfs.ReadCache(glyphCacheName(currentFont), glyphCacheKey(currentFont))
+	if !found {
+		return
+	}
+	var file glyphCacheFile
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&file)
+	if err != nil {
+		log.Warningf("could not decode cached glyphs: %v", err)
+		return
+	}
+	for name, c := range glyphCaches() {
+		entries := file[name]
+		if entries == nil {
+			continue
+		}
+		for k, e := range entries {
+			if len(e.Pix) != e.DR.Dx()*e.DR.Dy() {
+				log.Warningf("cached glyph %v of %v has wrong size", k.R, name)
+				continue
+			}
+			c.entries[k] = e
+		}
+	}
+}
+
+// saveGlyphCache writes the rendered glyphs of the current font to disk if any changed.
+func saveGlyphCache() {
+	if *fontFractionalSpacing {
+		return
+	}
+	caches := glyphCaches()
+	dirty := false
+	for _, c := range caches {
+		if c.dirty {
+			dirty = true
+		}
+	}
+	if !dirty {
+		return
+	}
+	file := glyphCacheFile{}
+	for name, c := range caches {
+		file[name] = c.entries
+	}
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(file)
+	if err != nil {
+		log.Warningf("could not encode glyphs for caching: %v", err)
+		return
+	}
+	vfs.WriteCache(glyphCacheName(currentFont), glyphCacheKey(currentFont), buf.Bytes())
+	for _, c := range caches {
+		c.dirty = false
+	}
+}
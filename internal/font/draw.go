@@ -75,6 +75,21 @@ func (f Face) BoundString(str string) m.Rect {
 	return totalBounds
 }
 
+// Advance returns the horizontal advance of the given single line of text.
+func (f Face) Advance(str string) int {
+	return font.MeasureString(f.Outline.GoX, locale.ActiveShape(str)).Ceil()
+}
+
+// LineHeight returns the distance between two lines of text.
+func (f Face) LineHeight() int {
+	return f.Outline.GoX.Metrics().Height.Ceil()
+}
+
+// Ascent returns the height of the font above the baseline.
+func (f Face) Ascent() int {
+	return f.Outline.GoX.Metrics().Ascent.Ceil()
+}
+
 // drawLine draws one line of text.
 func drawLine(f *faceWrapper, dst *ebiten.Image, line string, x, y int, align text.Align, fg color.Color) {
 	// Use Ebitengine's glyph cache.
@@ -98,8 +113,25 @@ const (
 	Right
 )
 
-// Draw draws the given text.
+// Mirror returns the alignment for a horizontally mirrored layout.
+func (a Align) Mirror() Align {
+	switch a {
+	case Left:
+		return Right
+	case Right:
+		return Left
+	default:
+		return a
+	}
+}
+
+// Draw draws the given text with a plain outline.
 func (f Face) Draw(dst *ebiten.Image, str string, pos m.Pos, boxAlign Align, fg, bg color.Color) {
+	f.DrawStyled(dst, str, pos, boxAlign, Style{FG: fg, Outline: bg})
+}
+
+// forEachLine calls draw with the position and alignment of each line of the given text.
+func (f Face) forEachLine(str string, pos m.Pos, boxAlign Align, draw func(line string, x, y int, align text.Align)) {
 	// We need to do our own line splitting because
 	// we always want to center and Ebitengine would left adjust.
 	lines := strings.Split(str, "\n")
@@ -118,10 +150,7 @@ func (f Face) Draw(dst *ebiten.Image, str string, pos m.Pos, boxAlign Align, fg,
 		align = text.AlignEnd
 	}
 	for _, line := range lines {
-		if _, _, _, a := bg.RGBA(); a != 0 {
-			drawLine(f.Outline, dst, line, pos.X, y, align, bg)
-		}
-		drawLine(f.Face, dst, line, pos.X, y, align, fg)
+		draw(line, pos.X, y, align)
 		y += lineHeight
 	}
 }
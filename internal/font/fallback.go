@@ -0,0 +1,133 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package font
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// fallbackFace renders each glyph using the first face that has it.
+type fallbackFace struct {
+	faces []font.Face
+}
+
+func (f *fallbackFace) pick(r rune) font.Face {
+	for _, face := range f.faces {
+		if _, ok := face.GlyphAdvance(r); ok {
+			return face
+		}
+	}
+	return f.faces[0]
+}
+
+func (f *fallbackFace) Close() error {
+	return nil
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return f.pick(r).Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return f.pick(r).GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return f.pick(r).GlyphAdvance(r)
+}
+
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face := f.pick(r0)
+	if f.pick(r1) != face {
+		return 0
+	}
+	return face.Kern(r0, r1)
+}
+
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
+}
+
+// raw returns the underlying font face without effects.
+func (f *Face) raw() (font.Face, int) {
+	e := f.Face.GoX.(*fontEffects)
+	return e.Face, e.LineHeight
+}
+
+// loadFont creates the faces of a single font.
+func loadFont(name string) (map[string]*Face, error) {
+	if faces := ByFont[name]; faces != nil {
+		return faces, nil
+	}
+	faces := map[string]*Face{}
+	var err error
+	switch {
+	case name == "bitmapfont":
+		err = initBitmapfont(faces)
+	case name == "unifont":
+		err = initUnifont(faces)
+	case strings.HasPrefix(name, "ttf:"):
+		err = initTTF(faces, strings.TrimPrefix(name, "ttf:"))
+	default:
+		err = initGoFont(faces)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ByFont[name] = faces
+	return faces, nil
+}
+
+// loadFontChain creates the faces of a comma separated list of fonts.
+// Glyphs missing in a font are taken from the next font that has them.
+func loadFontChain(chain string) (map[string]*Face, error) {
+	names := strings.Split(chain, ",")
+	if len(names) == 1 {
+		return loadFont(names[0])
+	}
+	fonts := make([]map[string]*Face, len(names))
+	for i, name := range names {
+		var err error
+		fonts[i], err = loadFont(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not load font %v of %v: %w", name, chain, err)
+		}
+	}
+	faces := map[string]*Face{}
+	done := map[*Face]*Face{}
+	for name, first := range fonts[0] {
+		// Keep faces shared between names shared.
+		if f, found := done[first]; found {
+			faces[name] = f
+			continue
+		}
+		_, lineHeight := first.raw()
+		fallback := &fallbackFace{}
+		for _, f := range fonts {
+			if face := f[name]; face != nil {
+				raw, _ := face.raw()
+				fallback.faces = append(fallback.faces, raw)
+			}
+		}
+		faces[name] = makeFace(fallback, lineHeight)
+		done[first] = faces[name]
+	}
+	return faces, nil
+}
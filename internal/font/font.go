@@ -39,6 +39,7 @@ var (
 	fontThreshold             = flag.Int("font_threshold", 0x7800, "threshold for font rendering; lower values are bolder; 0 means antialias as usual; threshold range is 1 to 65535 inclusive; set to 0 to use smooth font rendering instead")
 	fontExtraSpacing          = flag.Int("font_extra_spacing", 31, "additional spacing for fonts in 64th pixels; should help with outline effect")
 	fontFractionalSpacing     = flag.Bool("font_fractional_spacing", false, "allow fractional font spacing; looks better but may be slower; makes --pin_fonts_to_cache less effective")
+	fontGlyphCacheSize        = flag.Int("font_glyph_cache_size", 8192, "maximum number of rendered glyphs to keep per font face; grows with the pinned character set; 0 means unlimited")
 	debugFontOverride         = flag.String("debug_font_override", "", "name of font to use instead of the intended font")
 	debugFontProfiling        = flag.Bool("debug_font_profiling", false, "measure how long font caching took")
 )
@@ -46,6 +47,8 @@ var (
 type faceWrapper struct {
 	GoX font.Face
 	Ebi text.Face
+
+	glyphs *glyphCache
 }
 
 // Face is an alias to font.Face so users do not need to import the font package.
@@ -58,13 +61,17 @@ func makeFace(f font.Face, size int) *Face {
 	effect := &fontEffects{
 		Face:       f,
 		LineHeight: size,
+		glyphs:     newGlyphCache(),
+	}
+	outline := &fontOutline{
+		Face:   effect,
+		glyphs: newGlyphCache(),
 	}
-	outline := &fontOutline{effect}
 	ebiEffect := text.NewGoXFace(effect)
 	ebiOutline := text.NewGoXFace(outline)
 	face := &Face{
-		Face:    &faceWrapper{GoX: effect, Ebi: ebiEffect},
-		Outline: &faceWrapper{GoX: outline, Ebi: ebiOutline},
+		Face:    &faceWrapper{GoX: effect, Ebi: ebiEffect, glyphs: effect.glyphs},
+		Outline: &faceWrapper{GoX: outline, Ebi: ebiOutline, glyphs: outline.glyphs},
 	}
 	return face
 }
@@ -110,6 +117,13 @@ func LoadIntoCacheStepwise() func(s *splash.State) (splash.Status, error) {
 				return status, err
 			}
 		}
+		status, err := s.Enter("saving glyph cache", locale.G.Get("saving glyph cache"), "could not save glyph cache", splash.Single(func() error {
+			saveGlyphCache()
+			return nil
+		}))
+		if status != splash.Continue {
+			return status, err
+		}
 		return splash.Continue, nil
 	}
 }
@@ -149,6 +163,8 @@ var (
 type fontEffects struct {
 	font.Face
 	LineHeight int
+
+	glyphs *glyphCache
 }
 
 func roundFixed(f fixed.Int26_6) fixed.Int26_6 {
@@ -160,8 +176,11 @@ func roundFixed(f fixed.Int26_6) fixed.Int26_6 {
 
 func (e *fontEffects) Glyph(dot fixed.Point26_6, r rune) (
 	image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	if dr, mask, maskp, advance, ok, found := e.glyphs.get(dot, r); found {
+		return dr, mask, maskp, advance, ok
+	}
 	dr, mask, maskp, advance, ok := e.Face.Glyph(dot, r)
-	return dr, fontEffectsMask(mask), maskp, advance, ok
+	return e.glyphs.put(dot, r, dr, fontEffectsMask(mask), maskp, advance, ok)
 }
 
 func (e *fontEffects) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
@@ -208,10 +227,15 @@ func fontEffectsMask(src image.Image) image.Image {
 
 type fontOutline struct {
 	font.Face
+
+	glyphs *glyphCache
 }
 
 func (o *fontOutline) Glyph(dot fixed.Point26_6, r rune) (
 	image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	if dr, mask, maskp, advance, ok, found := o.glyphs.get(dot, r); found {
+		return dr, mask, maskp, advance, ok
+	}
 	dr, mask, maskp, advance, ok := o.Face.Glyph(dot, r)
 	drExpanded := image.Rectangle{
 		Min: image.Point{
@@ -227,7 +251,7 @@ func (o *fontOutline) Glyph(dot fixed.Point26_6, r rune) (
 		X: maskp.X - 1,
 		Y: maskp.Y - 1,
 	}
-	return drExpanded, fontOutlineMask(mask), maskpExpanded, advance, ok
+	return o.glyphs.put(dot, r, drExpanded, fontOutlineMask(mask), maskpExpanded, advance, ok)
 }
 
 func (o *fontOutline) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
@@ -359,20 +383,30 @@ func SetFont(font string) error {
 	ByName = ByFont[font]
 	currentFont = font
 	if ByName == nil {
-		ByName = map[string]*Face{}
-		ByFont[font] = ByName
-		switch font {
-		case "bitmapfont":
-			return initBitmapfont()
-		case "unifont":
-			return initUnifont()
-		default:
-			return initGoFont()
+		var err error
+		ByName, err = loadFontChain(font)
+		if err != nil {
+			return err
 		}
+		ByFont[font] = ByName
+		loadGlyphCache()
 	}
 	return nil
 }
 
+// Names returns the names of all font faces, in sorted order.
+// Every font provides the same faces, so this does not require loading a font.
+func Names() []string {
+	faces := map[string]*Face{}
+	initBitmapfont(faces)
+	names := make([]string, 0, len(faces))
+	for name := range faces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func CurrentFont() string {
 	return currentFont
 }
@@ -38,7 +38,7 @@ func makeGoFontFace(fnt *opentype.Font, size int) (*Face, error) {
 	return makeFace(f, size), nil
 }
 
-func initGoFont() error {
+func initGoFont(faces map[string]*Face) error {
 	// Load the fonts.
 	regular, err := opentype.Parse(gomedium.TTF)
 	if err != nil {
@@ -61,48 +61,49 @@ func initGoFont() error {
 		return fmt.Errorf("could not load gosmallcaps font: %w", err)
 	}
 
-	ByName["Small"], err = makeGoFontFace(regular, 10)
+	faces["Small"], err = makeGoFontFace(regular, 10)
 	if err != nil {
 		return fmt.Errorf("could not create face: %w", err)
 	}
-	ByName["Regular"], err = makeGoFontFace(regular, 14)
+	faces["Regular"], err = makeGoFontFace(regular, 14)
 	if err != nil {
 		return fmt.Errorf("could not create face: %w", err)
 	}
-	ByName["Italic"], err = makeGoFontFace(italic, 14)
+	faces["Italic"], err = makeGoFontFace(italic, 14)
 	if err != nil {
 		return fmt.Errorf("could not create face: %w", err)
 	}
-	ByName["Bold"], err = makeGoFontFace(bold, 14)
+	faces["Bold"], err = makeGoFontFace(bold, 14)
 	if err != nil {
 		return fmt.Errorf("could not create face: %w", err)
 	}
-	ByName["Mono"], err = makeGoFontFace(mono, 14)
+	faces["Mono"], err = makeGoFontFace(mono, 14)
 	if err != nil {
 		return fmt.Errorf("could not create face: %w", err)
 	}
-	ByName["MonoSmall"], err = makeGoFontFace(mono, 10)
+	faces["MonoSmall"], err = makeGoFontFace(mono, 10)
 	if err != nil {
 		return fmt.Errorf("could not create face: %w", err)
 	}
-	ByName["SmallCaps"], err = makeGoFontFace(smallcaps, 14)
+	faces["SmallCaps"], err = makeGoFontFace(smallcaps, 14)
 	if err != nil {
 		return fmt.Errorf("could not create face: %w", err)
 	}
-	ByName["Centerprint"] = ByName["Italic"]
-	ByName["CenterprintBig"], err = makeGoFontFace(smallcaps, 24)
+	faces["Centerprint"] = faces["Italic"]
+	faces["CenterprintBig"], err = makeGoFontFace(smallcaps, 24)
 	if err != nil {
 		return fmt.Errorf("could not create face: %w", err)
 	}
-	ByName["Menu"], err = makeGoFontFace(smallcaps, 18)
+	faces["Menu"], err = makeGoFontFace(smallcaps, 18)
 	if err != nil {
 		return fmt.Errorf("could not create face: %w", err)
 	}
-	ByName["MenuBig"] = ByName["CenterprintBig"]
-	ByName["MenuSmall"], err = makeGoFontFace(smallcaps, 12)
+	faces["MenuBig"] = faces["CenterprintBig"]
+	faces["MenuSmall"], err = makeGoFontFace(smallcaps, 12)
 	if err != nil {
 		return fmt.Errorf("could not create face: %w", err)
 	}
+	faces["DebugSmall"] = faces["MonoSmall"]
 
 	return nil
 }
@@ -0,0 +1,127 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package font
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// Style describes how text is drawn.
+type Style struct {
+	FG      color.Color // Text color.
+	Outline color.Color // Color of the 1px outline; nil or transparent for none.
+	Shadow  color.Color // Color of the drop shadow; nil or transparent for none.
+
+	// ShadowOffset is where the shadow is drawn relative to the text; zero means one pixel down and right.
+	ShadowOffset m.Delta
+
+	// Gradient, if set, is the text color at the bottom of each line, fading from FG at the top.
+	// Both ends should be palette colors; palette reduction dithers the shades in between.
+	Gradient color.Color
+}
+
+var (
+	gradientImg *ebiten.Image
+)
+
+// visible returns whether a style color draws anything.
+func visible(c color.Color) bool {
+	if c == nil {
+		return false
+	}
+	_, _, _, a := c.RGBA()
+	return a != 0
+}
+
+// DrawStyled draws the given text in the given style.
+func (f Face) DrawStyled(dst *ebiten.Image, str string, pos m.Pos, boxAlign Align, style Style) {
+	f.forEachLine(str, pos, boxAlign, func(line string, x, y int, align text.Align) {
+		if visible(style.Shadow) {
+			offset := style.ShadowOffset
+			if offset.IsZero() {
+				offset = m.Delta{DX: 1, DY: 1}
+			}
+			shadowFace := f.Face
+			if visible(style.Outline) {
+				shadowFace = f.Outline
+			}
+			drawLine(shadowFace, dst, line, x+offset.DX, y+offset.DY, align, style.Shadow)
+		}
+		if visible(style.Outline) {
+			drawLine(f.Outline, dst, line, x, y, align, style.Outline)
+		}
+		if visible(style.Gradient) {
+			drawGradientLine(f.Face, dst, line, x, y, align, style.FG, style.Gradient)
+		} else {
+			drawLine(f.Face, dst, line, x, y, align, style.FG)
+		}
+	})
+}
+
+// drawGradientLine draws one line of text with a vertical color gradient.
+func drawGradientLine(f *faceWrapper, dst *ebiten.Image, line string, x, y int, align text.Align, top, bottom color.Color) {
+	// Render in white to a scratch image in the same coordinates as dst,
+	// then copy it over with vertex colors doing the fade.
+	b := dst.Bounds()
+	if gradientImg == nil || gradientImg.Bounds().Dx() < b.Max.X || gradientImg.Bounds().Dy() < b.Max.Y {
+		if gradientImg != nil {
+			gradientImg.Deallocate()
+		}
+		gradientImg = ebiten.NewImage(b.Max.X, b.Max.Y)
+	}
+	metrics := f.GoX.Metrics()
+	fadeY := y - metrics.Ascent.Ceil()
+	fadeHeight := metrics.Ascent.Ceil() + metrics.Descent.Ceil()
+	band := image.Rectangle{
+		Min: image.Point{X: b.Min.X, Y: fadeY},
+		Max: image.Point{X: b.Max.X, Y: fadeY + fadeHeight},
+	}.Intersect(b)
+	if band.Empty() {
+		return
+	}
+	scratch := gradientImg.SubImage(band).(*ebiten.Image)
+	scratch.Clear()
+	drawLine(f, scratch, line, x, y, align, color.White)
+	x0, y0 := float32(band.Min.X), float32(band.Min.Y)
+	x1, y1 := float32(band.Max.X), float32(band.Max.Y)
+	// The fade covers ascent to descent, regardless of clipping.
+	t0 := float32(band.Min.Y-fadeY) / float32(fadeHeight)
+	t1 := float32(band.Max.Y-fadeY) / float32(fadeHeight)
+	r0, g0, b0, a0 := mixColor(top, bottom, t0)
+	r1, g1, b1, a1 := mixColor(top, bottom, t1)
+	vertices := []ebiten.Vertex{
+		{DstX: x0, DstY: y0, SrcX: x0, SrcY: y0, ColorR: r0, ColorG: g0, ColorB: b0, ColorA: a0},
+		{DstX: x1, DstY: y0, SrcX: x1, SrcY: y0, ColorR: r0, ColorG: g0, ColorB: b0, ColorA: a0},
+		{DstX: x0, DstY: y1, SrcX: x0, SrcY: y1, ColorR: r1, ColorG: g1, ColorB: b1, ColorA: a1},
+		{DstX: x1, DstY: y1, SrcX: x1, SrcY: y1, ColorR: r1, ColorG: g1, ColorB: b1, ColorA: a1},
+	}
+	dst.DrawTriangles(vertices, []uint16{0, 1, 2, 1, 2, 3}, scratch, &ebiten.DrawTrianglesOptions{})
+}
+
+// mixColor returns the straight alpha color t of the way from a to b.
+func mixColor(a, b color.Color, t float32) (float32, float32, float32, float32) {
+	ca := color.NRGBAModel.Convert(a).(color.NRGBA)
+	cb := color.NRGBAModel.Convert(b).(color.NRGBA)
+	mix := func(x, y uint8) float32 {
+		return (float32(x)*(1-t) + float32(y)*t) / 255
+	}
+	return mix(ca.R, cb.R), mix(ca.G, cb.G), mix(ca.B, cb.B), mix(ca.A, cb.A)
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package font
+
+import (
+	"fmt"
+	"io"
+
+	"golang.org/x/image/font/opentype"
+
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// ttfFaceSizes are the sizes of the faces created from a single TTF/OTF font.
+var ttfFaceSizes = map[string]int{
+	"Small":          10,
+	"Regular":        14,
+	"Italic":         14,
+	"Bold":           14,
+	"Mono":           14,
+	"MonoSmall":      10,
+	"SmallCaps":      14,
+	"Centerprint":    14,
+	"CenterprintBig": 24,
+	"Menu":           18,
+	"MenuBig":        24,
+	"MenuSmall":      12,
+	"DebugSmall":     10,
+}
+
+// initTTF loads a TTF, OTF or TTC font from the fonts directory.
+// Collections use their first font.
+func initTTF(faces map[string]*Face, name string) error {
+	handle, err := vfs.Load("fonts", name)
+	if err != nil {
+		return fmt.Errorf("could not open font %v: %w", name, err)
+	}
+	defer handle.Close()
+	data, err := io.ReadAll(handle)
+	if err != nil {
+		return fmt.Errorf("could not read font %v: %w", name, err)
+	}
+	fontDataKeys["ttf:"+name] = vfs.CacheKey(data)
+	collection, err := opentype.ParseCollection(data)
+	if err != nil {
+		return fmt.Errorf("could not parse font %v: %w", name, err)
+	}
+	fnt, err := collection.Font(0)
+	if err != nil {
+		return fmt.Errorf("could not get font from %v: %w", name, err)
+	}
+	bySize := map[int]*Face{}
+	for faceName, size := range ttfFaceSizes {
+		if f := bySize[size]; f != nil {
+			faces[faceName] = f
+			continue
+		}
+		f, err := makeGoFontFace(fnt, size)
+		if err != nil {
+			return fmt.Errorf("could not create face: %w", err)
+		}
+		bySize[size] = f
+		faces[faceName] = f
+	}
+	return nil
+}
@@ -24,7 +24,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
 
-func initUnifont() error {
+func initUnifont(faces map[string]*Face) error {
 	unifontRawHandle, err := vfs.Load("generated", "unifont.bdf.gz")
 	if err != nil {
 		return fmt.Errorf("could not open unifont: %w", err)
@@ -39,6 +39,7 @@ func initUnifont() error {
 	if err != nil {
 		return fmt.Errorf("could not read unifont: %w", err)
 	}
+	fontDataKeys["unifont"] = vfs.CacheKey(unifontData)
 	unifont, err := bdf.Parse(unifontData)
 	if err != nil {
 		return fmt.Errorf("could not parse unifont: %w", err)
@@ -46,18 +47,19 @@ func initUnifont() error {
 	// 14, which is 16 when adding back the outline.
 	face := makeFace(unifont.NewFace(), 14)
 
-	ByName["Small"] = face
-	ByName["Regular"] = face
-	ByName["Italic"] = face
-	ByName["Bold"] = face
-	ByName["Mono"] = face
-	ByName["MonoSmall"] = face
-	ByName["SmallCaps"] = face
-	ByName["Centerprint"] = face
-	ByName["CenterprintBig"] = face
-	ByName["Menu"] = face
-	ByName["MenuBig"] = face
-	ByName["MenuSmall"] = face
+	faces["Small"] = face
+	faces["Regular"] = face
+	faces["Italic"] = face
+	faces["Bold"] = face
+	faces["Mono"] = face
+	faces["MonoSmall"] = face
+	faces["SmallCaps"] = face
+	faces["Centerprint"] = face
+	faces["CenterprintBig"] = face
+	faces["Menu"] = face
+	faces["MenuBig"] = face
+	faces["MenuSmall"] = face
+	faces["DebugSmall"] = face
 
 	return nil
 }
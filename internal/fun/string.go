@@ -43,6 +43,12 @@ func SetTimeZoneHours(h int) {
 
 // TryFormatText replaces placeholders in the given text.
 func TryFormatText(ps *playerstate.PlayerState, s string) (string, error) {
+	return tryFormatText(ps, s, false)
+}
+
+// tryFormatText replaces placeholders in the given text.
+// If markup is set, buttons are replaced by centerprint markup to show them as icons.
+func tryFormatText(ps *playerstate.PlayerState, s string, markup bool) (string, error) {
 	// Fast path if the template is trivial.
 	if !strings.Contains(s, "{{") {
 		return s, nil
@@ -128,11 +134,13 @@ func TryFormatText(ps *playerstate.PlayerState, s string) (string, error) {
 			if ps == nil {
 				return "", errors.New("cannot use {{GameTime}} in static elements")
 			}
-			frames := ps.Frames()
-			ss, ms := frames/60, (frames%60)*1000/60
-			mm, ss := ss/60, ss%60
-			hh, mm := mm/60, mm%60
-			return locale.G.Get("%d:%02d:%02d.%03d", hh, mm, ss, ms), nil
+			return FormatGameTime(ps.Frames()), nil
+		},
+		"Collectibles": func(counter string) (string, error) {
+			if ps == nil {
+				return "", errors.New("cannot use {{Collectibles}} in static elements")
+			}
+			return fmt.Sprint(ps.Collectibles(counter)), nil
 		},
 		"Score": func() (string, error) {
 			if ps == nil {
@@ -178,37 +186,19 @@ func TryFormatText(ps *playerstate.PlayerState, s string) (string, error) {
 			if ps == nil {
 				return "", errors.New("cannot use {{ExitButton}} in static elements")
 			}
-			switch input.ExitButton() {
-			case input.Start:
-				return locale.G.Get("Start"), nil
-			case input.Back:
-				return locale.G.Get("Back"), nil
-			case input.Escape:
-				return locale.G.Get("Escape"), nil
-			default: // case input.Backspace:
-				return locale.G.Get("Backspace"), nil
+			if markup {
+				return "[button=exit]", nil
 			}
+			return ExitButtonName(), nil
 		},
 		"ActionButton": func() (string, error) {
 			if ps == nil {
 				return "", errors.New("cannot use {{ActionButton}} in static elements")
 			}
-			switch input.ActionButton() {
-			case input.BX:
-				return locale.G.Get("B/X"), nil
-			case input.Elsewhere:
-				return locale.G.Get("elsewhere"), nil
-			case input.B:
-				return locale.G.Get("B"), nil
-			case input.CtrlShift:
-				return locale.G.Get("Ctrl/Shift"), nil
-			case input.Z:
-				return locale.G.Get("Z"), nil
-			case input.ShiftETab:
-				return locale.G.Get("Shift/E/Tab"), nil
-			default: // case input.EnterShift:
-				return locale.G.Get("Enter/Shift"), nil
+			if markup {
+				return "[button=action]", nil
 			}
+			return ActionButtonName(), nil
 		},
 		"SpeedrunCategories": func() (string, error) {
 			if ps == nil {
@@ -258,6 +248,48 @@ func TryFormatText(ps *playerstate.PlayerState, s string) (string, error) {
 	return res, nil
 }
 
+// ExitButtonName returns the localized name of the button to leave the game with the current input device.
+func ExitButtonName() string {
+	switch input.PreferredPrompts().Exit {
+	case input.Start:
+		return locale.G.Get("Start")
+	case input.Back:
+		return locale.G.Get("Back")
+	case input.Escape:
+		return locale.G.Get("Escape")
+	default: // case input.Backspace:
+		return locale.G.Get("Backspace")
+	}
+}
+
+// ActionButtonName returns the localized name of the action button with the current input device.
+func ActionButtonName() string {
+	switch input.PreferredPrompts().Action {
+	case input.BX:
+		return locale.G.Get("B/X")
+	case input.Elsewhere:
+		return locale.G.Get("elsewhere")
+	case input.B:
+		return locale.G.Get("B")
+	case input.CtrlShift:
+		return locale.G.Get("Ctrl/Shift")
+	case input.Z:
+		return locale.G.Get("Z")
+	case input.ShiftETab:
+		return locale.G.Get("Shift/E/Tab")
+	default: // case input.EnterShift:
+		return locale.G.Get("Enter/Shift")
+	}
+}
+
+// FormatGameTime formats a number of game frames like the {{GameTime}} placeholder.
+func FormatGameTime(frames int) string {
+	ss, ms := frames/60, (frames%60)*1000/60
+	mm, ss := ss/60, ss%60
+	hh, mm := mm/60, mm%60
+	return locale.G.Get("%d:%02d:%02d.%03d", hh, mm, ss, ms)
+}
+
 // FormatText replaces placeholders in the given text.
 func FormatText(ps *playerstate.PlayerState, s string) string {
 	result, err := TryFormatText(ps, s)
@@ -267,3 +299,14 @@ func FormatText(ps *playerstate.PlayerState, s string) string {
 	}
 	return result
 }
+
+// FormatMarkup replaces placeholders in the given text for display as a centerprint.
+// Unlike FormatText, buttons are shown as icons where the input device has them.
+func FormatMarkup(ps *playerstate.PlayerState, s string) string {
+	result, err := tryFormatText(ps, s, true)
+	if err != nil {
+		log.Warningf("failed to execute text template: %v: %v", s, err)
+		return s
+	}
+	return result
+}
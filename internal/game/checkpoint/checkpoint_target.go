@@ -60,9 +60,9 @@ func (c *CheckpointTarget) Precache(sp *level.Spawnable) {
 		return
 	}
 	bounds := centerprint.BigFont().BoundString(txt)
-	if bounds.Size.DX > engine.GameWidth {
+	if bounds.Size.DX > engine.DefaultGameWidth {
 		locale.Errorf("text too big: entity %v must fit in width %v but text needs %v: %v",
-			sp.ID, engine.GameWidth, bounds.Size, txtOrig)
+			sp.ID, engine.DefaultGameWidth, bounds.Size, txtOrig)
 	}
 }
 
@@ -126,11 +126,11 @@ func (c *CheckpointTarget) SetState(originator, predecessor *engine.Entity, stat
 	if err != nil {
 		log.Errorf("could not save game: %v", err)
 		str := locale.G.Get("Error:\ncould not save game:\n%s", err)
-		centerprint.New(fun.FormatText(&c.World.PlayerState, str), centerprint.Important, centerprint.Top, centerprint.NormalFont(), palette.EGA(palette.LightRed, 255), 5*time.Second).SetFadeOut(true)
+		centerprint.New(fun.FormatMarkup(&c.World.PlayerState, str), centerprint.Important, centerprint.Top, centerprint.NormalFont(), palette.EGA(palette.LightRed, 255), 5*time.Second).SetFadeOut(true)
 		return
 	}
 	if c.Text != "" {
-		centerprint.New(fun.FormatText(&c.World.PlayerState, c.Text), centerprint.Important, centerprint.Middle, centerprint.BigFont(), palette.EGA(palette.White, 255), time.Second).SetFadeOut(true)
+		centerprint.New(fun.FormatMarkup(&c.World.PlayerState, c.Text), centerprint.Important, centerprint.Middle, centerprint.BigFont(), palette.EGA(palette.White, 255), time.Second).SetFadeOut(true)
 		c.Sound.Play()
 	}
 }
@@ -51,7 +51,7 @@ func (z *ZoomTarget) Despawn() {}
 func (z *ZoomTarget) Update() {
 	if z.Frame > 0 {
 		z.Frame--
-		z.World.MaxVisiblePixels = int(m.Delta{DX: engine.GameWidth, DY: engine.GameHeight}.Length() * 0.5 * float64(z.Frame) / float64(z.Frames))
+		z.World.MaxVisiblePixels = int(m.Delta{DX: engine.GameWidth, DY: engine.GameHeight}.LengthFixed().MulFrac(m.NewFixed(z.Frame), m.NewFixed(2*z.Frames)) / m.FixedOne)
 	}
 }
 
@@ -0,0 +1,145 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enemy
+
+import (
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/misc"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// Turret is an enemy that periodically fires projectiles, which kill the player on touch.
+// The turret itself also kills the player on touch.
+type Turret struct {
+	misc.Animation
+	mixins.Hazard
+
+	World  *engine.World
+	Entity *engine.Entity
+
+	FireFrames      int
+	FramesUntilFire int
+	Projectile      *level.SpawnableProps
+	ProjectileSize  m.Delta
+}
+
+func (t *Turret) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	t.World = w
+	t.Entity = e
+	err := t.Animation.Spawn(w, sp, e)
+	if err != nil {
+		return err
+	}
+	w.SetSolid(e, true)
+	err = t.Hazard.Init(w, sp, e)
+	if err != nil {
+		return err
+	}
+
+	var parseErr error
+	interval := propmap.ValueOrP(sp.Properties, "fire_interval", time.Second, &parseErr)
+	t.FireFrames = int((interval*engine.GameTPS + (time.Second / 2)) / time.Second)
+	if t.FireFrames < 1 {
+		t.FireFrames = 1
+	}
+	delay := propmap.ValueOrP(sp.Properties, "fire_delay", time.Duration(0), &parseErr)
+	t.FramesUntilFire = int((delay*engine.GameTPS+(time.Second/2))/time.Second) + 1
+	t.ProjectileSize = propmap.ValueOrP(sp.Properties, "projectile_size", m.Delta{DX: 6, DY: 6}, &parseErr)
+
+	// The projectile is a MovingAnimation, configured like the bullets in the map editor.
+	properties := propmap.New()
+	propmap.Set(properties, "animation", propmap.StringOr(sp.Properties, "projectile_animation", "bullet8s"))
+	propmap.Set(properties, "animation_frame_interval", propmap.ValueOrP(sp.Properties, "projectile_animation_frame_interval", 2, &parseErr))
+	propmap.Set(properties, "animation_frames", propmap.ValueOrP(sp.Properties, "projectile_animation_frames", 2, &parseErr))
+	propmap.Set(properties, "animation_group", propmap.StringOr(sp.Properties, "projectile_animation_group", "idle"))
+	propmap.Set(properties, "animation_repeat_interval", propmap.ValueOrP(sp.Properties, "projectile_animation_repeat_interval", 4, &parseErr))
+	propmap.Set(properties, "fade_despawn", true)
+	propmap.Set(properties, "fade_time", 500*time.Millisecond)
+	propmap.Set(properties, "fade_on_touch", true)
+	propmap.Set(properties, "invert", true)
+	propmap.Set(properties, "render_offset", propmap.ValueOrP(sp.Properties, "projectile_render_offset", m.Delta{DX: -1, DY: -1}, &parseErr))
+	propmap.Set(properties, "respawn_on_touch", true)
+	propmap.Set(properties, "stop_on_touch", true)
+	// The projectile velocity is relative to the turret, but MovingAnimation wants it in level space.
+	velocity := propmap.ValueOrP(sp.Properties, "projectile_velocity", m.Delta{DX: 60, DY: 0}, &parseErr)
+	propmap.Set(properties, "velocity", sp.Orientation.Apply(velocity))
+	t.Projectile = &level.SpawnableProps{
+		EntityType:      "MovingAnimation",
+		Orientation:     sp.Orientation,
+		Properties:      properties,
+		PersistentState: propmap.New(),
+	}
+
+	return parseErr
+}
+
+func (t *Turret) Despawn() {
+	t.Hazard.Despawn()
+}
+
+func (t *Turret) fire() {
+	rect := m.Rect{
+		Origin: t.Entity.Rect.Center().Sub(t.ProjectileSize.Div(2)),
+		Size:   t.ProjectileSize,
+	}
+	_, err := t.World.SpawnDetached(t.Projectile, rect, m.Identity(), t.Entity)
+	if err != nil {
+		log.Errorf("could not spawn turret projectile: %v", err)
+	}
+}
+
+func (t *Turret) Update() {
+	t.Hazard.Update()
+	t.FramesUntilFire--
+	if t.FramesUntilFire <= 0 {
+		t.fire()
+		t.FramesUntilFire = t.FireFrames
+	}
+	t.Animation.Update()
+}
+
+func (t *Turret) Touch(other *engine.Entity) {
+	t.HazardTouch(other)
+}
+
+// turretSnapshot is the transient turret state saved in snapshots.
+// Projectiles in flight are not restored.
+type turretSnapshot struct {
+	Hazard          interface{}
+	FramesUntilFire int
+}
+
+func (t *Turret) SaveSnapshot() interface{} {
+	return turretSnapshot{
+		Hazard:          t.Hazard.SaveSnapshot(),
+		FramesUntilFire: t.FramesUntilFire,
+	}
+}
+
+func (t *Turret) LoadSnapshot(snap interface{}) {
+	s := snap.(turretSnapshot)
+	t.Hazard.LoadSnapshot(s.Hazard)
+	t.FramesUntilFire = s.FramesUntilFire
+}
+
+func init() {
+	engine.RegisterEntityType(&Turret{})
+}
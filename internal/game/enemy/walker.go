@@ -0,0 +1,170 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enemy
+
+import (
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/constants"
+	"github.com/divVerent/aaaaxy/internal/game/misc"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// Walker is an enemy that patrols back and forth, turning around at walls and optionally at ledges.
+// It kills the player on touch.
+type Walker struct {
+	misc.Animation
+	mixins.Physics
+	mixins.Hazard
+
+	World  *engine.World
+	Entity *engine.Entity
+
+	Speed        int // In subpixels per frame.
+	Dir          int // 1 for right, -1 for left.
+	StartDir     int
+	TurnAtLedges bool
+	Orientation  m.Orientation // When walking in StartDir.
+}
+
+const (
+	// WalkerMaxFallSpeed is the terminal velocity of a walker.
+	WalkerMaxFallSpeed = 2 * level.TileSize * constants.SubPixelScale
+)
+
+func (w *Walker) Spawn(world *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	w.World = world
+	w.Entity = e
+	err := w.Animation.Spawn(world, sp, e)
+	if err != nil {
+		return err
+	}
+	world.SetSolid(e, true)
+	w.Physics.Init(world, e, level.ObjectSolidContents, w.handleTouch)
+	err = w.Hazard.Init(world, sp, e)
+	if err != nil {
+		return err
+	}
+	var parseErr error
+	speed := propmap.ValueOrP(sp.Properties, "speed", 30, &parseErr)
+	w.Speed = speed * constants.SubPixelScale / engine.GameTPS
+	w.TurnAtLedges = propmap.ValueOrP(sp.Properties, "turn_at_ledges", true, &parseErr)
+	// Walk in the direction the entity faces in the editor.
+	w.StartDir = 1
+	if propmap.ValueOrP(sp.Properties, "start_left", false, &parseErr) {
+		w.StartDir = -1
+	}
+	if e.Transform.Inverse().Apply(m.East()).DX < 0 {
+		w.StartDir = -w.StartDir
+	}
+	w.Dir = w.StartDir
+	w.Orientation = e.Orientation
+	return parseErr
+}
+
+func (w *Walker) Despawn() {
+	w.Hazard.Despawn()
+}
+
+// atLedge returns whether the walker would lose the ground by moving on.
+func (w *Walker) atLedge() bool {
+	var x int
+	if w.Dir > 0 {
+		x = w.Entity.Rect.OppositeCorner().X + 1
+	} else {
+		x = w.Entity.Rect.Origin.X - 1
+	}
+	probe := m.Rect{
+		Origin: m.Pos{X: x, Y: w.Entity.Rect.Origin.Y},
+		Size:   m.Delta{DX: 1, DY: w.Entity.Rect.Size.DY},
+	}
+	trace := w.World.TraceBox(probe, probe.Origin.Add(w.OnGroundVec), engine.TraceOptions{
+		Contents:  w.Contents,
+		IgnoreEnt: w.Entity,
+		ForEnt:    w.Entity,
+		LoadTiles: true,
+	})
+	return trace.EndPos != probe.Origin
+}
+
+func (w *Walker) turn() {
+	w.Dir = -w.Dir
+	if w.Dir == w.StartDir {
+		w.Entity.Orientation = w.Orientation
+	} else {
+		w.Entity.Orientation = w.Orientation.Concat(m.FlipX())
+	}
+}
+
+func (w *Walker) Update() {
+	w.Hazard.Update()
+
+	if w.OnGround && w.TurnAtLedges && w.atLedge() {
+		w.turn()
+	}
+
+	// Walk and fall.
+	w.Velocity.DX = w.Dir * w.Speed
+	if !w.OnGround {
+		w.Velocity = w.Velocity.Add(w.OnGroundVec.Mul(constants.Gravity))
+		if w.Velocity.DY > WalkerMaxFallSpeed {
+			w.Velocity.DY = WalkerMaxFallSpeed
+		}
+	}
+	w.Physics.Update()
+
+	// Physics stops us when hitting a wall.
+	if w.Velocity.DX == 0 {
+		w.turn()
+	}
+
+	w.Animation.Update()
+}
+
+func (w *Walker) Touch(other *engine.Entity) {
+	w.HazardTouch(other)
+}
+
+func (w *Walker) handleTouch(trace engine.TraceResult) {
+	w.World.TouchEvent(w.Entity, trace.HitEntities)
+}
+
+// walkerSnapshot is the transient walker state saved in snapshots.
+type walkerSnapshot struct {
+	Physics interface{}
+	Hazard  interface{}
+	Dir     int
+}
+
+func (w *Walker) SaveSnapshot() interface{} {
+	return walkerSnapshot{
+		Physics: w.Physics.SaveSnapshot(),
+		Hazard:  w.Hazard.SaveSnapshot(),
+		Dir:     w.Dir,
+	}
+}
+
+func (w *Walker) LoadSnapshot(snap interface{}) {
+	s := snap.(walkerSnapshot)
+	w.Physics.LoadSnapshot(s.Physics)
+	w.Hazard.LoadSnapshot(s.Hazard)
+	w.Dir = s.Dir
+}
+
+func init() {
+	engine.RegisterEntityType(&Walker{})
+}
@@ -17,6 +17,7 @@ package game
 import (
 	_ "github.com/divVerent/aaaaxy/internal/game/checkpoint"
 	_ "github.com/divVerent/aaaaxy/internal/game/ending"
+	_ "github.com/divVerent/aaaaxy/internal/game/enemy"
 	_ "github.com/divVerent/aaaaxy/internal/game/misc"
 	_ "github.com/divVerent/aaaaxy/internal/game/player"
 	_ "github.com/divVerent/aaaaxy/internal/game/riser"
@@ -0,0 +1,105 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package misc
+
+import (
+	"fmt"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/sound"
+)
+
+// AmbientSound is a looping sound source in the map, such as a waterfall or a machine.
+// It gets quieter with distance from the player and can be muffled by opaque tiles in between.
+// As it only plays while spawned, its spawn_tiles_growth should cover its radius.
+// Can be toggled from outside.
+type AmbientSound struct {
+	World  *engine.World
+	Entity *engine.Entity
+
+	Emitter        *sound.Emitter
+	Volume         float64
+	Radius         float64
+	Occlusion      bool
+	OccludedVolume float64
+	State          bool
+}
+
+func (s *AmbientSound) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	s.World = w
+	s.Entity = e
+	var parseErr error
+	name := propmap.ValueP(sp.Properties, "sound", "", &parseErr)
+	snd, err := sound.Load(name)
+	if err != nil {
+		return fmt.Errorf("could not load sound: %w", err)
+	}
+	if !snd.Looping() {
+		return fmt.Errorf("ambient sound %q must have a loop_start", name)
+	}
+	s.Emitter = snd.Emitter()
+	s.Volume = propmap.ValueOrP(sp.Properties, "volume", 1.0, &parseErr)
+	s.Radius = float64(propmap.ValueOrP(sp.Properties, "radius", 8*level.TileSize, &parseErr))
+	s.Occlusion = propmap.ValueOrP(sp.Properties, "occlusion", false, &parseErr)
+	s.OccludedVolume = propmap.ValueOrP(sp.Properties, "occluded_volume", 0.25, &parseErr)
+	s.State = propmap.ValueOrP(sp.Properties, "state", true, &parseErr)
+	return parseErr
+}
+
+func (s *AmbientSound) Despawn() {
+	s.Emitter.Close()
+}
+
+// volumeAt returns the volume the sound has when heard from the given position.
+func (s *AmbientSound) volumeAt(pos m.Pos) float64 {
+	center := s.Entity.Rect.Center()
+	dist := pos.Delta(center).Length()
+	if dist >= s.Radius {
+		return 0
+	}
+	vol := s.Volume * (1 - dist/s.Radius)
+	if s.Occlusion {
+		trace := s.World.TraceLine(center, pos, engine.TraceOptions{
+			Contents:   level.OpaqueContents,
+			NoEntities: true,
+			ForEnt:     s.Entity,
+		})
+		if trace.EndPos != pos {
+			vol *= s.OccludedVolume
+		}
+	}
+	return vol
+}
+
+func (s *AmbientSound) Update() {
+	if !s.State {
+		s.Emitter.SetVolume(0)
+		return
+	}
+	s.Emitter.SetVolume(s.volumeAt(s.World.Player.Impl.(engine.PlayerEntityImpl).EyePos()))
+}
+
+func (s *AmbientSound) Touch(other *engine.Entity) {}
+
+func (s *AmbientSound) SetState(originator, predecessor *engine.Entity, state bool) {
+	s.State = state
+}
+
+func init() {
+	engine.RegisterEntityType(&AmbientSound{})
+}
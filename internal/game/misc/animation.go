@@ -45,11 +45,13 @@ func (a *Animation) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.E
 	group.FrameInterval = propmap.ValueP(sp.Properties, "animation_frame_interval", 0, &parseErr)
 	group.NextInterval = propmap.ValueP(sp.Properties, "animation_repeat_interval", 0, &parseErr)
 	group.SyncToMusicOffset = propmap.ValueOrP(sp.Properties, "animation_sync_to_music_offset", time.Duration(0), &parseErr)
+	group.SyncGroup = propmap.StringOr(sp.Properties, "animation_sync_group", "")
 	e.RenderOffset = propmap.ValueOrP(sp.Properties, "render_offset", m.Delta{}, &parseErr)
 	if e.RenderOffset.IsZero() {
 		e.ResizeImage = true
 	}
 	e.BorderPixels = propmap.ValueOrP(sp.Properties, "border_pixels", 0, &parseErr)
+	a.Anim.World = w
 	err := a.Anim.Init(prefix, map[string]*animation.Group{groupName: group}, groupName)
 	if err != nil {
 		return fmt.Errorf("could not initialize animation %v: %w", prefix, err)
@@ -32,14 +32,7 @@ func (s *ExitButton) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.
 	// HACK: adjust some defaults.
 	propmap.SetDefault(sp.Properties, "fade_time", 10*time.Second)
 	propmap.SetDefault(sp.Properties, "no_flip", "x")
-	switch input.ExitButton() {
-	default: // case input.Escape:
-		propmap.SetDefault(sp.Properties, "image", "esc.png")
-	case input.Backspace, input.Back:
-		propmap.SetDefault(sp.Properties, "image", "backspace.png")
-	case input.Start:
-		propmap.SetDefault(sp.Properties, "image", "start.png")
-	}
+	propmap.SetDefault(sp.Properties, "image", input.PreferredPrompts().ExitIcon)
 	s.SwitchableSprite.Spawn(w, sp, e)
 
 	return nil
@@ -93,7 +93,7 @@ func (s *MovingAnimation) Touch(other *engine.Entity) {
 		return
 	}
 	if other == s.World.Player {
-		if s.RespawnOnTouch {
+		if s.RespawnOnTouch && s.World.PlayerState.Damage(1) {
 			s.World.RespawnPlayer(s.World.PlayerState.LastCheckpoint(), false)
 		}
 	} else {
@@ -110,6 +110,31 @@ func (s *MovingAnimation) handleTouch(trace engine.TraceResult) {
 	s.World.TouchEvent(s.Entity, trace.HitEntities)
 }
 
+// movingAnimationSnapshot is the transient state saved in snapshots.
+type movingAnimationSnapshot struct {
+	Moving       interface{}
+	Fadable      interface{}
+	FramesToMove int
+	FramesToFade int
+}
+
+func (s *MovingAnimation) SaveSnapshot() interface{} {
+	return movingAnimationSnapshot{
+		Moving:       s.Moving.SaveSnapshot(),
+		Fadable:      s.Fadable.SaveSnapshot(),
+		FramesToMove: s.FramesToMove,
+		FramesToFade: s.FramesToFade,
+	}
+}
+
+func (s *MovingAnimation) LoadSnapshot(snap interface{}) {
+	ss := snap.(movingAnimationSnapshot)
+	s.Moving.LoadSnapshot(ss.Moving)
+	s.Fadable.LoadSnapshot(ss.Fadable)
+	s.FramesToMove = ss.FramesToMove
+	s.FramesToFade = ss.FramesToFade
+}
+
 func init() {
 	engine.RegisterEntityType(&MovingAnimation{})
 }
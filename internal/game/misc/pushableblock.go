@@ -0,0 +1,210 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package misc
+
+import (
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/constants"
+	"github.com/divVerent/aaaaxy/internal/game/interfaces"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// PushableBlock is a solid sprite the player can shove sideways by walking into it.
+// It falls with gravity and slows down by friction.
+// Its resting position is stored relative to its spawn point in level space,
+// so it survives despawning and checkpoint saves, and it moves through warpzones like any other physics object.
+type PushableBlock struct {
+	Sprite
+	mixins.Physics
+
+	World  *engine.World
+	Entity *engine.Entity
+
+	PersistentState propmap.Map
+	SpawnOrigin     m.Pos
+
+	PushSpeed int // In subpixels per frame.
+	Friction  int // In subpixels per frame squared.
+
+	Pushed  bool
+	Moving  bool
+	Resting bool
+}
+
+const (
+	// PushableBlockMaxFallSpeed is the terminal velocity of a pushable block.
+	PushableBlockMaxFallSpeed = 2 * level.TileSize * constants.SubPixelScale
+)
+
+func (b *PushableBlock) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	b.World = w
+	b.Entity = e
+	err := b.Sprite.Spawn(w, sp, e)
+	if err != nil {
+		return err
+	}
+	w.SetSolid(e, true)
+	b.Physics.Init(w, e, level.ObjectSolidContents, b.handleTouch)
+	b.PersistentState = sp.PersistentState
+	b.SpawnOrigin = e.Rect.Origin
+
+	var parseErr error
+	pushSpeed := propmap.ValueOrP(sp.Properties, "push_speed", 30, &parseErr)
+	b.PushSpeed = pushSpeed * constants.SubPixelScale / engine.GameTPS
+	friction := propmap.ValueOrP(sp.Properties, "friction", 480, &parseErr)
+	b.Friction = friction * constants.SubPixelScale / engine.GameTPS / engine.GameTPS
+	if propmap.ValueOrP(sp.Properties, "flipped", false, &parseErr) {
+		b.OnGroundVec = b.OnGroundVec.Mul(-1)
+	}
+
+	// Restore the resting position; it is stored in the level space of the spawn tile.
+	offset := propmap.ValueOrP(b.PersistentState, "offset", m.Delta{}, &parseErr)
+	if !offset.IsZero() {
+		dest := e.Rect.Origin.Add(e.Transform.Inverse().Apply(offset))
+		trace := w.TraceBox(e.Rect, dest, engine.TraceOptions{
+			Contents:  b.Contents,
+			IgnoreEnt: e,
+			ForEnt:    e,
+			LoadTiles: true,
+		})
+		w.SetOrigin(e, trace.EndPos)
+	}
+	b.Resting = true
+	return parseErr
+}
+
+func (b *PushableBlock) Despawn() {
+	b.save()
+}
+
+// save stores the current position relative to the spawn point.
+func (b *PushableBlock) save() {
+	offset := b.Entity.Transform.Apply(b.Entity.Rect.Origin.Delta(b.SpawnOrigin))
+	propmap.Set(b.PersistentState, "offset", offset)
+}
+
+// right returns the unit vector to the right of the gravity direction.
+func (b *PushableBlock) right() m.Delta {
+	return m.Left().Apply(b.OnGroundVec)
+}
+
+func (b *PushableBlock) Update() {
+	right := b.right()
+	side := b.Velocity.Dot(right)
+	down := b.Velocity.Dot(b.OnGroundVec)
+
+	// Sideways motion: pushing sets the speed, friction stops it.
+	if !b.Pushed {
+		if side > b.Friction {
+			side -= b.Friction
+		} else if side < -b.Friction {
+			side += b.Friction
+		} else {
+			side = 0
+		}
+	}
+	b.Pushed = false
+
+	// Fall.
+	if b.OnGround {
+		if down > 0 {
+			down = 0
+		}
+	} else {
+		down += constants.Gravity
+		if down > PushableBlockMaxFallSpeed {
+			down = PushableBlockMaxFallSpeed
+		}
+	}
+	b.Velocity = right.Mul(side).Add(b.OnGroundVec.Mul(down))
+
+	if !b.Velocity.IsZero() {
+		b.Moving = true
+		b.Physics.Update() // May call handleTouch.
+		b.Moving = false
+	}
+
+	resting := b.OnGround && b.Velocity.IsZero()
+	if resting && !b.Resting {
+		b.save()
+	}
+	b.Resting = resting
+}
+
+func (b *PushableBlock) handleTouch(trace engine.TraceResult) {
+	b.World.TouchEvent(b.Entity, trace.HitEntities)
+}
+
+func (b *PushableBlock) Touch(other *engine.Entity) {
+	if b.Moving || other != b.World.Player {
+		// Only the player walking into us pushes; us hitting the player does not.
+		return
+	}
+	if other.Impl.(interfaces.GroundEntityer).ReadGroundEntity() == b.Entity {
+		// Standing on top.
+		return
+	}
+	right := b.right()
+	d := other.Rect.Delta(b.Entity.Rect)
+	if d.Dot(b.OnGroundVec) != 0 || d.Dot(right) == 0 {
+		// Not touching from the side.
+		return
+	}
+	// d points from us to the player; push away from the player.
+	dir := 1
+	if d.Dot(right) > 0 {
+		dir = -1
+	}
+	side := b.Velocity.Dot(right)
+	down := b.Velocity.Dot(b.OnGroundVec)
+	b.Velocity = right.Mul(dir * b.PushSpeed).Add(b.OnGroundVec.Mul(down))
+	if side*dir < 0 {
+		// Reversing direction: stop first.
+		b.Velocity = b.OnGroundVec.Mul(down)
+	}
+	b.Pushed = true
+}
+
+// pushableBlockSnapshot is the transient block state saved in snapshots.
+type pushableBlockSnapshot struct {
+	Physics interface{}
+	Pushed  bool
+	Moving  bool
+	Resting bool
+}
+
+func (b *PushableBlock) SaveSnapshot() interface{} {
+	return pushableBlockSnapshot{
+		Physics: b.Physics.SaveSnapshot(),
+		Pushed:  b.Pushed,
+		Moving:  b.Moving,
+		Resting: b.Resting,
+	}
+}
+
+func (b *PushableBlock) LoadSnapshot(snap interface{}) {
+	s := snap.(pushableBlockSnapshot)
+	b.Physics.LoadSnapshot(s.Physics)
+	b.Pushed = s.Pushed
+	b.Moving = s.Moving
+	b.Resting = s.Resting
+}
+
+func init() {
+	engine.RegisterEntityType(&PushableBlock{})
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/image"
 	"github.com/divVerent/aaaaxy/internal/level"
 	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
 	"github.com/divVerent/aaaaxy/internal/propmap"
 )
 
@@ -39,13 +40,15 @@ type Sprite struct {
 var _ engine.Precacher = &Sprite{}
 
 func (s *Sprite) Precache(sp *level.Spawnable) error {
-	if !*checkSprites {
-		return nil
-	}
 	var parseErr error
+	tint := propmap.ValueOrP(sp.Properties, "tint", palette.Tint{}, &parseErr)
+	if !*checkSprites && tint.IsIdentity() {
+		// Untinted images are already precached.
+		return parseErr
+	}
 	directory := propmap.StringOr(sp.Properties, "image_dir", "sprites")
 	imgSrc := propmap.ValueP(sp.Properties, "image", "", &parseErr)
-	_, err := image.Load(directory, imgSrc)
+	_, err := image.LoadTinted(directory, imgSrc, tint)
 	if err != nil {
 		return err
 	}
@@ -57,7 +60,7 @@ func (s *Sprite) Precache(sp *level.Spawnable) error {
 		if thisSrc == "" {
 			continue
 		}
-		_, err := image.Load(directory, thisSrc)
+		_, err := image.LoadTinted(directory, thisSrc, tint)
 		if err != nil {
 			return err
 		}
@@ -74,7 +77,8 @@ func (s *Sprite) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Enti
 		return err
 	}
 	imgSrc, e.Orientation = level.ResolveImage(e.Transform, e.Orientation, imgSrc, imgSrcByOrientation)
-	e.Image, err = image.Load(directory, imgSrc)
+	tint := propmap.ValueOrP(sp.Properties, "tint", palette.Tint{}, &parseErr)
+	e.Image, err = image.LoadTinted(directory, imgSrc, tint)
 	if err != nil {
 		return err
 	}
@@ -84,6 +88,7 @@ func (s *Sprite) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Enti
 	}
 	region := propmap.ValueOrP(sp.Properties, "image_region", m.Rect{}, &parseErr)
 	if !region.Size.IsZero() {
+		// Images may be part of an atlas, so the region is relative to their bounds.
 		e.Image = e.Image.SubImage(go_image.Rectangle{
 			Min: go_image.Point{
 				X: region.Origin.X,
@@ -93,13 +98,17 @@ func (s *Sprite) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Enti
 				X: region.Origin.X + region.Size.DX,
 				Y: region.Origin.Y + region.Size.DY,
 			},
-		}).(*ebiten.Image)
+		}.Add(e.Image.Bounds().Min)).(*ebiten.Image)
 	}
 	e.BorderPixels = propmap.ValueOrP(sp.Properties, "border_pixels", 0, &parseErr)
 	err = s.SpriteBase.Spawn(w, sp, e)
 	if err != nil {
 		return err
 	}
+	err = applyFillMode(sp, e)
+	if err != nil {
+		return err
+	}
 	return parseErr
 }
 
@@ -17,6 +17,7 @@ package misc
 import (
 	"fmt"
 	"image/color"
+	"math"
 	"strings"
 
 	"github.com/divVerent/aaaaxy/internal/engine"
@@ -53,6 +54,15 @@ func (s *SpriteBase) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.
 	e.ColorMod[1] = float64(mapWhiteTo.G)/255.0 - e.ColorAdd[1]
 	e.ColorMod[2] = float64(mapWhiteTo.B)/255.0 - e.ColorAdd[2]
 	e.ColorMod[3] = float64(mapWhiteTo.A)/255.0 - e.ColorAdd[3]
+	if radius := propmap.ValueOrP(sp.Properties, "light_radius", 0, &parseErr); radius > 0 {
+		e.Light = &engine.Light{
+			Offset:    propmap.ValueOrP(sp.Properties, "light_offset", m.Delta{}, &parseErr),
+			Radius:    radius,
+			Color:     propmap.ValueOrP(sp.Properties, "light_color", color.NRGBA{R: 255, G: 255, B: 255, A: 255}, &parseErr),
+			Direction: propmap.ValueOrP(sp.Properties, "light_direction", m.Delta{}, &parseErr),
+			ConeAngle: propmap.ValueOrP(sp.Properties, "light_cone_angle", 45.0, &parseErr) * math.Pi / 180,
+		}
+	}
 	z := propmap.ValueOrP(sp.Properties, "z_index", s.ZDefault, &parseErr)
 	if z != s.ZDefault && (z < constants.MinSpriteZ || z > constants.MaxSpriteZ) {
 		return fmt.Errorf("z index out of range: got %v, want %v..%v", z, constants.MinSpriteZ, constants.MaxSpriteZ)
@@ -0,0 +1,141 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package misc
+
+import (
+	"fmt"
+	go_image "image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// Sprite fill modes decide how an image is fit to an entity of a different size.
+const (
+	// fillStretch scales the whole image.
+	fillStretch = "stretch"
+	// fillNineSlice keeps the corners, stretches the edges along their length and stretches the center.
+	fillNineSlice = "nine_slice"
+	// fillTile repeats the image from the top left corner.
+	fillTile = "tile"
+)
+
+type fillKey struct {
+	img    *ebiten.Image
+	mode   string
+	size   m.Delta
+	border int
+}
+
+// fillCache holds filled images, as sprites using them respawn often.
+var fillCache = map[fillKey]*ebiten.Image{}
+
+// applyFillMode replaces the entity image by one of exactly the entity size,
+// using the fill_mode and slice_border properties.
+// Must be called after the entity orientation is final.
+func applyFillMode(sp *level.SpawnableProps, e *engine.Entity) error {
+	var parseErr error
+	mode := propmap.StringOr(sp.Properties, "fill_mode", fillStretch)
+	switch mode {
+	case fillStretch:
+		return nil
+	case fillNineSlice, fillTile:
+	default:
+		return fmt.Errorf("unknown fill_mode %q: want %v, %v or %v", mode, fillStretch, fillNineSlice, fillTile)
+	}
+	if !e.ResizeImage {
+		return fmt.Errorf("fill_mode %v requires render_offset to be unset", mode)
+	}
+	// The fill is done in image space; the renderer rotates the result.
+	size := e.Rect.Size
+	if e.Orientation.Right.DX == 0 {
+		size.DX, size.DY = size.DY, size.DX
+	}
+	imgSize := e.Image.Bounds().Size()
+	border := propmap.ValueOrP(sp.Properties, "slice_border", min(imgSize.X, imgSize.Y)/3, &parseErr)
+	if border < 0 || 2*border > min(imgSize.X, imgSize.Y) {
+		return fmt.Errorf("slice_border out of range: got %v, want 0..%v", border, min(imgSize.X, imgSize.Y)/2)
+	}
+	key := fillKey{
+		img:    e.Image,
+		mode:   mode,
+		size:   size,
+		border: border,
+	}
+	img, found := fillCache[key]
+	if !found {
+		img = ebiten.NewImage(size.DX, size.DY)
+		if mode == fillTile {
+			drawTiled(img, e.Image)
+		} else {
+			drawNineSlice(img, e.Image, border)
+		}
+		fillCache[key] = img
+	}
+	e.Image = img
+	return parseErr
+}
+
+// drawTiled fills dst by repeating src.
+func drawTiled(dst, src *ebiten.Image) {
+	srcSize := src.Bounds().Size()
+	dstSize := dst.Bounds().Size()
+	for y := 0; y < dstSize.Y; y += srcSize.Y {
+		for x := 0; x < dstSize.X; x += srcSize.X {
+			opts := ebiten.DrawImageOptions{
+				Blend:  ebiten.BlendCopy,
+				Filter: ebiten.FilterNearest,
+			}
+			opts.GeoM.Translate(float64(x), float64(y))
+			dst.DrawImage(src, &opts)
+		}
+	}
+}
+
+// sliceBounds splits [0, size) into the three parts of a nine-slice with the given border.
+// If size is too small for both borders, they are shrunk evenly.
+func sliceBounds(size, border int) [4]int {
+	border = min(border, size/2)
+	return [4]int{0, border, size - border, size}
+}
+
+// drawNineSlice fills dst with src, keeping a border of the given size unscaled.
+func drawNineSlice(dst, src *ebiten.Image, border int) {
+	srcRect := src.Bounds()
+	srcSize := srcRect.Size()
+	dstSize := dst.Bounds().Size()
+	srcX, srcY := sliceBounds(srcSize.X, border), sliceBounds(srcSize.Y, border)
+	dstX, dstY := sliceBounds(dstSize.X, border), sliceBounds(dstSize.Y, border)
+	for j := 0; j < 3; j++ {
+		for i := 0; i < 3; i++ {
+			from := go_image.Rect(srcX[i], srcY[j], srcX[i+1], srcY[j+1]).Add(srcRect.Min)
+			to := go_image.Rect(dstX[i], dstY[j], dstX[i+1], dstY[j+1])
+			if from.Empty() || to.Empty() {
+				continue
+			}
+			opts := ebiten.DrawImageOptions{
+				Blend:  ebiten.BlendCopy,
+				Filter: ebiten.FilterNearest,
+			}
+			opts.GeoM.Scale(float64(to.Dx())/float64(from.Dx()), float64(to.Dy())/float64(from.Dy()))
+			opts.GeoM.Translate(float64(to.Min.X), float64(to.Min.Y))
+			dst.DrawImage(src.SubImage(from).(*ebiten.Image), &opts)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package misc
 import (
 	"fmt"
 	"image/color"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
@@ -51,9 +52,10 @@ type Text struct {
 var _ engine.Precacher = &Text{}
 
 type textCacheKey struct {
-	font   string
-	fg, bg color.NRGBA
-	text   string
+	font      string
+	fg, bg    color.NRGBA
+	text      string
+	wrapWidth int
 }
 
 var textCache = map[textCacheKey]*ebiten.Image{}
@@ -64,9 +66,34 @@ func cacheKey(sp *level.SpawnableProps) textCacheKey {
 		fg:   propmap.ValueP(sp.Properties, "text_fg", color.NRGBA{}, nil),
 		bg:   propmap.ValueP(sp.Properties, "text_bg", color.NRGBA{}, nil),
 		text: propmap.ValueP(sp.Properties, "text", "", nil),
+		// Set for Tiled text objects with word wrapping.
+		wrapWidth: propmap.ValueOrP(sp.Properties, "text_wrap_width", 0, nil),
 	}
 }
 
+// wrapText breaks the lines of the given text at spaces so they fit into the given width.
+// Words that are wider than the width on their own get a line of their own.
+func wrapText(fnt *font.Face, txt string, width int) string {
+	var out []string
+	for _, line := range strings.Split(txt, "\n") {
+		cur := ""
+		for _, word := range strings.Split(line, " ") {
+			if cur == "" {
+				cur = word
+				continue
+			}
+			if fnt.BoundString(cur+" "+word).Size.DX > width {
+				out = append(out, cur)
+				cur = word
+				continue
+			}
+			cur += " " + word
+		}
+		out = append(out, cur)
+	}
+	return strings.Join(out, "\n")
+}
+
 func (key textCacheKey) load(ps *playerstate.PlayerState) (*ebiten.Image, error) {
 	fnt := font.ByName[key.font]
 	if fnt.Face == nil {
@@ -81,6 +108,9 @@ func (key textCacheKey) load(ps *playerstate.PlayerState) (*ebiten.Image, error)
 		}
 		return nil, err
 	}
+	if key.wrapWidth > 0 {
+		txt = wrapText(fnt, txt, key.wrapWidth)
+	}
 	bounds := fnt.BoundString(txt)
 	// Fit it exactly into the box.
 	pos := bounds.Origin.Mul(-1)
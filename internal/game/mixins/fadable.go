@@ -90,7 +90,11 @@ func (f *Fadable) Update() {
 			return
 		}
 	}
+	f.apply()
+}
 
+// apply sets alpha and contents of the entity for the current animation frame.
+func (f *Fadable) apply() {
 	if f.AnimFrame <= 0 {
 		f.Entity.Alpha = 0
 		f.AnimFrame = 0
@@ -105,3 +109,25 @@ func (f *Fadable) Update() {
 	f.World.MutateContentsBool(f.Entity, f.Contents&level.SolidContents, f.AnimFrame >= solidThreshold)
 	f.World.MutateContentsBool(f.Entity, f.Contents&level.OpaqueContents, f.AnimFrame >= opaqueThreshold)
 }
+
+// fadableSnapshot is the transient fading state saved in snapshots.
+type fadableSnapshot struct {
+	Settable  interface{}
+	AnimFrame int
+}
+
+func (f *Fadable) SaveSnapshot() interface{} {
+	return fadableSnapshot{
+		Settable:  f.Settable.SaveSnapshot(),
+		AnimFrame: f.AnimFrame,
+	}
+}
+
+func (f *Fadable) LoadSnapshot(snap interface{}) {
+	s := snap.(fadableSnapshot)
+	f.Settable.LoadSnapshot(s.Settable)
+	f.AnimFrame = s.AnimFrame
+	f.apply()
+}
+
+var _ engine.Snapshotter = &Fadable{}
@@ -0,0 +1,151 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mixins
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/interfaces"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// Hazard kills the player on touch, respawning them at the last checkpoint.
+// Optionally, the respawn is delayed and a death animation is shown.
+// If the map enables hit points, the hazard only deducts them, and kills when none are left.
+// Update must be called by the entity, and Touch forwarded to HazardTouch.
+type Hazard struct {
+	World  *engine.World
+	Entity *engine.Entity
+
+	Damage         int
+	RespawnFrames  int
+	DeathAnimation *level.SpawnableProps
+
+	// KillFrames counts down the remaining frames until respawning the player; zero if not killing.
+	KillFrames int
+}
+
+func (h *Hazard) Init(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	h.World = w
+	h.Entity = e
+
+	var parseErr error
+	h.Damage = propmap.ValueOrP(sp.Properties, "damage", 1, &parseErr)
+	delay := propmap.ValueOrP(sp.Properties, "respawn_delay", time.Duration(0), &parseErr)
+	h.RespawnFrames = int((delay*engine.GameTPS + (time.Second / 2)) / time.Second)
+	anim := propmap.StringOr(sp.Properties, "death_animation", "")
+	if anim != "" {
+		if h.RespawnFrames == 0 {
+			return fmt.Errorf("death_animation %q requires a respawn_delay", anim)
+		}
+		properties := propmap.New()
+		propmap.Set(properties, "animation", anim)
+		propmap.Set(properties, "animation_frame_interval", propmap.ValueOrP(sp.Properties, "death_animation_frame_interval", 2, &parseErr))
+		propmap.Set(properties, "animation_frames", propmap.ValueP(sp.Properties, "death_animation_frames", 0, &parseErr))
+		propmap.Set(properties, "animation_group", propmap.StringOr(sp.Properties, "death_animation_group", "idle"))
+		propmap.Set(properties, "animation_repeat_interval", propmap.ValueOrP(sp.Properties, "death_animation_repeat_interval", 0, &parseErr))
+		propmap.Set(properties, "fade_despawn", true)
+		propmap.Set(properties, "fade_time", time.Duration(0))
+		propmap.Set(properties, "invert", true)
+		propmap.Set(properties, "no_transform", true)
+		propmap.Set(properties, "time_to_fade", delay)
+		h.DeathAnimation = &level.SpawnableProps{
+			EntityType:      "MovingAnimation",
+			Orientation:     m.Identity(),
+			Properties:      properties,
+			PersistentState: propmap.New(),
+		}
+	}
+	return parseErr
+}
+
+// Kill kills the player, unless already being killed.
+func (h *Hazard) Kill() {
+	if h.KillFrames > 0 {
+		return
+	}
+	if h.RespawnFrames == 0 {
+		h.World.RespawnPlayer(h.World.PlayerState.LastCheckpoint(), false)
+		return
+	}
+	h.KillFrames = h.RespawnFrames
+	if h.DeathAnimation != nil {
+		_, err := h.World.SpawnDetached(h.DeathAnimation, h.World.Player.Rect, m.Identity(), h.Entity)
+		if err != nil {
+			log.Errorf("could not spawn death animation: %v", err)
+		}
+	}
+	// Hide the player and take away control until respawning.
+	h.World.Player.Alpha = 0
+	h.World.Player.Impl.(interfaces.Freezer).Freeze()
+}
+
+// release gives the player back if they are being killed.
+func (h *Hazard) release() {
+	h.KillFrames = 0
+	h.World.Player.Alpha = 1
+	h.World.Player.Impl.(interfaces.Freezer).Unfreeze()
+}
+
+func (h *Hazard) Update() {
+	if h.KillFrames == 0 {
+		return
+	}
+	h.KillFrames--
+	if h.KillFrames > 0 {
+		return
+	}
+	h.release()
+	h.World.RespawnPlayer(h.World.PlayerState.LastCheckpoint(), false)
+}
+
+// Despawn must be called when the entity despawns.
+func (h *Hazard) Despawn() {
+	if h.KillFrames == 0 {
+		return
+	}
+	// Can't respawn the player from here, so let them live.
+	h.release()
+}
+
+// HazardTouch handles touching the hazard.
+func (h *Hazard) HazardTouch(other *engine.Entity) {
+	if other != h.World.Player {
+		return
+	}
+	if h.KillFrames > 0 {
+		return
+	}
+	if h.World.PlayerState.Damage(h.Damage) {
+		h.Kill()
+	}
+}
+
+func (h *Hazard) SaveSnapshot() interface{} {
+	return h.KillFrames
+}
+
+// LoadSnapshot restores a pending kill.
+// The player's freeze count and alpha are restored with the player, so they are not changed again.
+func (h *Hazard) LoadSnapshot(snap interface{}) {
+	h.KillFrames = snap.(int)
+}
+
+var _ engine.Snapshotter = &Hazard{}
@@ -58,7 +58,7 @@ func (v *Movable) Init(w *engine.World, sp *level.SpawnableProps, e *engine.Enti
 
 	// No animation on initial load.
 	if v.Settable.State {
-		v.Entity.Rect.Origin = v.To
+		v.World.SetOrigin(v.Entity, v.To)
 	}
 
 	v.Physics.Init(w, e, contents, func(trace engine.TraceResult) {})
@@ -89,3 +89,24 @@ func (v *Movable) Update() {
 	// Note: this object does not get pushed by other ground.
 	v.Physics.GroundEntity = nil
 }
+
+// movableSnapshot is the transient movement state saved in snapshots.
+type movableSnapshot struct {
+	Settable interface{}
+	Physics  interface{}
+}
+
+func (v *Movable) SaveSnapshot() interface{} {
+	return movableSnapshot{
+		Settable: v.Settable.SaveSnapshot(),
+		Physics:  v.Physics.SaveSnapshot(),
+	}
+}
+
+func (v *Movable) LoadSnapshot(snap interface{}) {
+	s := snap.(movableSnapshot)
+	v.Settable.LoadSnapshot(s.Settable)
+	v.Physics.LoadSnapshot(s.Physics)
+}
+
+var _ engine.Snapshotter = &Movable{}
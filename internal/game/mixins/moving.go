@@ -36,3 +36,24 @@ func (v *Moving) Init(w *engine.World, sp *level.SpawnableProps, e *engine.Entit
 		vel.MulFracFixed(m.NewFixed(constants.SubPixelScale), m.NewFixed(engine.GameTPS)))
 	return parseErr
 }
+
+// movingSnapshot is the transient movement state saved in snapshots.
+type movingSnapshot struct {
+	Physics          interface{}
+	TouchedSomething bool
+}
+
+func (v *Moving) SaveSnapshot() interface{} {
+	return movingSnapshot{
+		Physics:          v.Physics.SaveSnapshot(),
+		TouchedSomething: v.TouchedSomething,
+	}
+}
+
+func (v *Moving) LoadSnapshot(snap interface{}) {
+	s := snap.(movingSnapshot)
+	v.Physics.LoadSnapshot(s.Physics)
+	v.TouchedSomething = s.TouchedSomething
+}
+
+var _ engine.Snapshotter = &Moving{}
@@ -46,6 +46,7 @@ func (t *trivialPhysics) Update() {
 }
 
 var _ interfaces.Physics = &trivialPhysics{}
+var _ engine.Snapshotter = &Physics{}
 
 func (p *Physics) Init(w *engine.World, e *engine.Entity, contents level.Contents, handleTouch func(trace engine.TraceResult)) {
 	p.World = w
@@ -68,6 +69,34 @@ func (p *Physics) Reset() {
 	p.SubPixel = m.Delta{DX: constants.SubPixelScale / 2, DY: constants.SubPixelScale / 2}
 }
 
+// physicsSnapshot is the transient physics state saved in snapshots.
+type physicsSnapshot struct {
+	OnGround    bool
+	OnGroundVec m.Delta
+	Velocity    m.Delta
+	SubPixel    m.Delta
+}
+
+func (p *Physics) SaveSnapshot() interface{} {
+	return physicsSnapshot{
+		OnGround:    p.OnGround,
+		OnGroundVec: p.OnGroundVec,
+		Velocity:    p.Velocity,
+		SubPixel:    p.SubPixel,
+	}
+}
+
+// LoadSnapshot restores the physics state.
+// The ground entity is found again on the next move.
+func (p *Physics) LoadSnapshot(snap interface{}) {
+	s := snap.(physicsSnapshot)
+	p.OnGround = s.OnGround
+	p.OnGroundVec = s.OnGroundVec
+	p.GroundEntity = nil
+	p.Velocity = s.Velocity
+	p.SubPixel = s.SubPixel
+}
+
 func (p *Physics) tryMove(move m.Delta) (m.Delta, bool) {
 	groundChecked := false
 	dest := p.Entity.Rect.Origin.Add(move)
@@ -81,7 +110,7 @@ func (p *Physics) tryMove(move m.Delta) (m.Delta, bool) {
 		// Nothing hit. We're done.
 		p.SubPixel.DX -= move.DX * constants.SubPixelScale
 		p.SubPixel.DY -= move.DY * constants.SubPixelScale
-		p.Entity.Rect.Origin = trace.EndPos
+		p.World.SetOrigin(p.Entity, trace.EndPos)
 		if move.Dot(p.OnGroundVec) != 0 {
 			// If move had a Y component, we're flying.
 			p.OnGround, p.GroundEntity, groundChecked = false, nil, true
@@ -92,6 +121,17 @@ func (p *Physics) tryMove(move m.Delta) (m.Delta, bool) {
 	if len(trace.HitEntities) != 0 {
 		hitEntity = trace.HitEntities[0]
 	}
+	if trace.HitSlope && p.OnGround && trace.HitDelta.Dot(p.OnGroundVec) == 0 {
+		// Walking into a slope. Move up to it, then try stepping up.
+		moved := trace.EndPos.Delta(p.Entity.Rect.Origin)
+		p.SubPixel = p.SubPixel.Sub(moved.Mul(constants.SubPixelScale))
+		move = move.Sub(moved)
+		p.World.SetOrigin(p.Entity, trace.EndPos)
+		if p.stepUpSlope(trace.HitDelta) {
+			p.SubPixel = p.SubPixel.Sub(trace.HitDelta.Mul(constants.SubPixelScale))
+			return move.Sub(trace.HitDelta), groundChecked
+		}
+	}
 	if trace.HitDelta.DX != 0 {
 		// An X hit. Just adjust X subpixel to be as close to the hit as possible.
 		if p.SubPixel.DX > constants.SubPixelScale-1 {
@@ -100,10 +140,13 @@ func (p *Physics) tryMove(move m.Delta) (m.Delta, bool) {
 			p.SubPixel.DX = 0
 		}
 		p.SubPixel.DY -= (trace.EndPos.Y - p.Entity.Rect.Origin.Y) * constants.SubPixelScale
-		p.Velocity.DX = 0
+		if !trace.HitSlope {
+			// Slopes may still let us through after the other component of the move.
+			p.Velocity.DX = 0
+		}
 		move.DX = 0
 		move.DY -= trace.EndPos.Y - p.Entity.Rect.Origin.Y
-		p.Entity.Rect.Origin = trace.EndPos
+		p.World.SetOrigin(p.Entity, trace.EndPos)
 
 		// Just in case we have left/right gravity... (not yet).
 		if trace.HitDelta.Dot(p.OnGroundVec) > 0 {
@@ -124,7 +167,7 @@ func (p *Physics) tryMove(move m.Delta) (m.Delta, bool) {
 		p.Velocity.DY = 0
 		move.DX -= trace.EndPos.X - p.Entity.Rect.Origin.X
 		move.DY = 0
-		p.Entity.Rect.Origin = trace.EndPos
+		p.World.SetOrigin(p.Entity, trace.EndPos)
 
 		if trace.HitDelta.Dot(p.OnGroundVec) > 0 {
 			p.OnGround, p.GroundEntity, groundChecked = true, hitEntity, true
@@ -137,8 +180,56 @@ func (p *Physics) tryMove(move m.Delta) (m.Delta, bool) {
 	return move, groundChecked
 }
 
+// stepUpSlope tries to continue a move blocked by a slope by stepping up one pixel.
+func (p *Physics) stepUpSlope(delta m.Delta) bool {
+	o := engine.TraceOptions{
+		Contents:  p.Contents,
+		IgnoreEnt: p.IgnoreEnt,
+		ForEnt:    p.Entity,
+		LoadTiles: true,
+	}
+	up := p.Entity.Rect.Origin.Sub(p.OnGroundVec)
+	trace := p.World.TraceBox(p.Entity.Rect, up, o)
+	if trace.EndPos != up {
+		return false
+	}
+	upRect := m.Rect{Origin: up, Size: p.Entity.Rect.Size}
+	dest := up.Add(delta)
+	trace = p.World.TraceBox(upRect, dest, o)
+	if trace.EndPos != dest {
+		return false
+	}
+	p.World.SetOrigin(p.Entity, dest)
+	return true
+}
+
+// snapToSlope keeps an entity that walked down a slope on the ground.
+func (p *Physics) snapToSlope(delta m.Delta) {
+	// A walkable slope goes down by at most one pixel per pixel walked.
+	walked := delta.Sub(p.OnGroundVec.Mul(delta.Dot(p.OnGroundVec))).Norm1()
+	if walked == 0 {
+		return
+	}
+	dest := p.Entity.Rect.Origin.Add(p.OnGroundVec.Mul(walked + 1))
+	trace := p.World.TraceBox(p.Entity.Rect, dest, engine.TraceOptions{
+		Contents:  p.Contents,
+		IgnoreEnt: p.IgnoreEnt,
+		ForEnt:    p.Entity,
+		LoadTiles: true,
+	})
+	if !trace.HitSlope || trace.HitDelta.Dot(p.OnGroundVec) <= 0 {
+		// Walked off a ledge.
+		return
+	}
+	p.World.SetOrigin(p.Entity, trace.EndPos)
+	p.Velocity = p.Velocity.Sub(p.OnGroundVec.Mul(p.Velocity.Dot(p.OnGroundVec)))
+	p.OnGround, p.GroundEntity = true, nil
+	p.handleTouchFunc(trace)
+}
+
 func (p *Physics) Update() {
 	oldOrigin := p.Entity.Rect.Origin
+	wasOnGround := p.OnGround
 
 	p.SubPixel = p.SubPixel.Add(p.Velocity)
 	move := p.SubPixel.Div(constants.SubPixelScale)
@@ -170,6 +261,11 @@ func (p *Physics) Update() {
 		}
 	}
 
+	if wasOnGround && !p.OnGround && p.Velocity.Dot(p.OnGroundVec) >= 0 {
+		// Not jumping - follow slopes downwards instead of falling off them.
+		p.snapToSlope(p.Entity.Rect.Origin.Delta(oldOrigin))
+	}
+
 	// Now if I am the ground, push everyone on me.
 	delta := p.Entity.Rect.Origin.Delta(oldOrigin)
 	if !delta.IsZero() {
@@ -185,7 +281,7 @@ func (p *Physics) Update() {
 					ForEnt:    other,
 					LoadTiles: true,
 				})
-				other.Rect.Origin = trace.EndPos
+				p.World.SetOrigin(other, trace.EndPos)
 				if !trace.HitDelta.IsZero() {
 					otherP.HandleTouch(trace)
 				}
@@ -242,6 +338,9 @@ func (p *Physics) ModifyHitBoxCentered(bySize m.Delta) m.Delta {
 	prevSize := p.Entity.Rect.Size
 	targetSize := prevSize.Add(bySize)
 
+	// NOTE: the rect is changed in place in multiple steps below;
+	// SetRect is called with the current rect before each trace so the entity index stays in sync.
+
 	// First grow in minus directions.
 	topLeftDelta := bySize.Div(2)
 	if topLeftDelta.DX > 0 {
@@ -250,12 +349,14 @@ func (p *Physics) ModifyHitBoxCentered(bySize m.Delta) m.Delta {
 		p.Entity.Rect.Origin.X -= topLeftDelta.DX
 	}
 	p.Entity.Rect.Size.DX += prevOrigin.X - p.Entity.Rect.Origin.X
+	p.World.SetRect(p.Entity, p.Entity.Rect)
 	if topLeftDelta.DY > 0 {
 		p.tryMove(m.Delta{DX: 0, DY: -topLeftDelta.DY})
 	} else {
 		p.Entity.Rect.Origin.Y -= topLeftDelta.DY
 	}
 	p.Entity.Rect.Size.DY += prevOrigin.Y - p.Entity.Rect.Origin.Y
+	p.World.SetRect(p.Entity, p.Entity.Rect)
 
 	// Then grow in plus directions.
 	prevOrigin2 := p.Entity.Rect.Origin
@@ -267,6 +368,7 @@ func (p *Physics) ModifyHitBoxCentered(bySize m.Delta) m.Delta {
 	} else {
 		p.Entity.Rect.Size.DX += bottomRightDelta.DX
 	}
+	p.World.SetRect(p.Entity, p.Entity.Rect)
 	if bottomRightDelta.DY > 0 {
 		p.tryMove(m.Delta{DX: 0, DY: bottomRightDelta.DY})
 		p.Entity.Rect.Size.DY += p.Entity.Rect.Origin.Y - prevOrigin2.Y
@@ -274,6 +376,7 @@ func (p *Physics) ModifyHitBoxCentered(bySize m.Delta) m.Delta {
 	} else {
 		p.Entity.Rect.Size.DY += bottomRightDelta.DY
 	}
+	p.World.SetRect(p.Entity, p.Entity.Rect)
 
 	// Grow remaining amount in minus directions again.
 	prevOrigin3 := p.Entity.Rect.Origin
@@ -284,12 +387,14 @@ func (p *Physics) ModifyHitBoxCentered(bySize m.Delta) m.Delta {
 		p.Entity.Rect.Origin.X -= topLeftDelta3.DX
 	}
 	p.Entity.Rect.Size.DX += prevOrigin3.X - p.Entity.Rect.Origin.X
+	p.World.SetRect(p.Entity, p.Entity.Rect)
 	if topLeftDelta3.DY > 0 {
 		p.tryMove(m.Delta{DX: 0, DY: -topLeftDelta3.DY})
 	} else {
 		p.Entity.Rect.Origin.Y -= topLeftDelta3.DY
 	}
 	p.Entity.Rect.Size.DY += prevOrigin3.Y - p.Entity.Rect.Origin.Y
+	p.World.SetRect(p.Entity, p.Entity.Rect)
 
 	// Adjust render offset.
 	p.Entity.RenderOffset = p.Entity.RenderOffset.Add(topLeftDelta)
@@ -0,0 +1,55 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mixins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+)
+
+// Requirement is a condition on a collectibles counter of the player, like "coins >= 10".
+// The zero value is always met.
+type Requirement struct {
+	Counter string
+	Min     int
+}
+
+// ParseRequirement parses a requirement of the form "counter >= n".
+// An empty string yields a requirement that is always met.
+func ParseRequirement(s string) (Requirement, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return Requirement{}, nil
+	}
+	if len(fields) != 3 || fields[1] != ">=" {
+		return Requirement{}, fmt.Errorf("invalid requirement %q: want counter >= n", s)
+	}
+	n, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return Requirement{}, fmt.Errorf("invalid requirement %q: %w", s, err)
+	}
+	return Requirement{Counter: fields[0], Min: n}, nil
+}
+
+// Met returns whether the player currently fulfills the requirement.
+func (r Requirement) Met(ps *playerstate.PlayerState) bool {
+	if r.Counter == "" {
+		return true
+	}
+	return ps.Collectibles(r.Counter) >= r.Min
+}
@@ -0,0 +1,240 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mixins
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/constants"
+	"github.com/divVerent/aaaaxy/internal/game/interfaces"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// Easing describes how a keyframe move accelerates.
+type Easing int
+
+const (
+	EaseLinear Easing = iota
+	EaseIn
+	EaseOut
+	EaseInOut
+)
+
+func parseEasing(s string) (Easing, error) {
+	switch s {
+	case "", "linear":
+		return EaseLinear, nil
+	case "in":
+		return EaseIn, nil
+	case "out":
+		return EaseOut, nil
+	case "in_out":
+		return EaseInOut, nil
+	default:
+		return EaseLinear, fmt.Errorf("invalid easing: got %q, want one of linear, in, out, in_out", s)
+	}
+}
+
+// apply returns how much of d is done after n of total frames.
+// Integer math keeps this deterministic for demos.
+func (e Easing) apply(d m.Delta, n, total int) m.Delta {
+	num, denom := int64(n), int64(total)
+	switch e {
+	case EaseIn:
+		num, denom = num*num, denom*denom
+	case EaseOut:
+		r := denom - num
+		num, denom = denom*denom-r*r, denom*denom
+	case EaseInOut:
+		if 2*n < total {
+			num, denom = 2*num*num, denom*denom
+		} else {
+			r := denom - num
+			num, denom = denom*denom-2*r*r, denom*denom
+		}
+	}
+	return m.Delta{
+		DX: int(int64(d.DX) * num / denom),
+		DY: int(int64(d.DY) * num / denom),
+	}
+}
+
+// Keyframe is one leg of a scripted move.
+type Keyframe struct {
+	Delta  m.Delta
+	Frames int
+	Easing Easing
+}
+
+// Scripted is a mixin to make an object follow keyframed moves when switched on.
+// Moves are given as move_1, move_2, ... relative to the previous keyframe,
+// each with a move_time_N and an optional move_easing_N.
+// It can freeze the player and make the camera follow the object while moving,
+// and switches on its target when done. Meant for short setpieces like revealing a new area.
+type Scripted struct {
+	Physics
+	World  *engine.World
+	Entity *engine.Entity
+
+	Keyframes    []Keyframe
+	FreezePlayer bool
+	CameraFollow bool
+	Target       TargetSelection
+
+	Running bool
+	Index   int
+	Frame   int
+	From    m.Pos
+	Frozen  bool
+}
+
+func (s *Scripted) Init(w *engine.World, sp *level.SpawnableProps, e *engine.Entity, contents level.Contents) error {
+	s.World = w
+	s.Entity = e
+
+	var parseErr error
+	tInv := e.Transform.Inverse()
+	for i := 1; ; i++ {
+		delta := propmap.ValueOrP(sp.Properties, fmt.Sprintf("move_%d", i), m.Delta{}, &parseErr)
+		moveTime := propmap.ValueOrP(sp.Properties, fmt.Sprintf("move_time_%d", i), time.Duration(0), &parseErr)
+		if delta.IsZero() && moveTime == 0 {
+			break
+		}
+		frames := int((moveTime*engine.GameTPS + (time.Second / 2)) / time.Second)
+		if frames < 1 {
+			frames = 1
+		}
+		easing, err := parseEasing(propmap.StringOr(sp.Properties, fmt.Sprintf("move_easing_%d", i), ""))
+		if err != nil {
+			return err
+		}
+		s.Keyframes = append(s.Keyframes, Keyframe{
+			Delta:  tInv.Apply(delta),
+			Frames: frames,
+			Easing: easing,
+		})
+	}
+	s.FreezePlayer = propmap.ValueOrP(sp.Properties, "freeze_player", false, &parseErr)
+	s.CameraFollow = propmap.ValueOrP(sp.Properties, "camera_follow", false, &parseErr)
+	s.Target = ParseTarget(propmap.StringOr(sp.Properties, "target", ""))
+
+	s.Physics.Init(w, e, contents, func(trace engine.TraceResult) {})
+
+	return parseErr
+}
+
+// SetState starts the move when switched on, and aborts it when switched off.
+func (s *Scripted) SetState(originator, predecessor *engine.Entity, state bool) {
+	if !state {
+		s.stop()
+		return
+	}
+	if s.Running || len(s.Keyframes) == 0 {
+		return
+	}
+	s.Running = true
+	s.Index = 0
+	s.Frame = 0
+	s.From = s.Entity.Rect.Origin
+	if s.FreezePlayer {
+		s.World.Player.Impl.(interfaces.Freezer).Freeze()
+		s.Frozen = true
+	}
+}
+
+// stop ends the move and gives control back to the player.
+func (s *Scripted) stop() {
+	s.Running = false
+	s.Physics.Velocity = m.Delta{}
+	if s.Frozen {
+		s.World.Player.Impl.(interfaces.Freezer).Unfreeze()
+		s.Frozen = false
+	}
+}
+
+func (s *Scripted) Despawn() {
+	s.stop()
+}
+
+func (s *Scripted) Update() {
+	if !s.Running {
+		return
+	}
+	kf := s.Keyframes[s.Index]
+	s.Frame++
+	target := s.From.Add(kf.Easing.apply(kf.Delta, s.Frame, kf.Frames))
+
+	// Move by physics so riders get carried along.
+	deltaSub := target.Delta(s.Entity.Rect.Origin).Mul(constants.SubPixelScale)
+	deltaSub = deltaSub.Add(m.Delta{DX: constants.SubPixelScale / 2, DY: constants.SubPixelScale / 2}).Sub(s.SubPixel)
+	s.Physics.Velocity = deltaSub
+	s.Physics.Update()
+	s.Physics.GroundEntity = nil
+
+	if s.CameraFollow {
+		s.World.Camera.Lock(s.Entity.Rect.Center())
+	}
+
+	if s.Frame < kf.Frames {
+		return
+	}
+	s.Index++
+	s.Frame = 0
+	s.From = s.From.Add(kf.Delta)
+	if s.Index < len(s.Keyframes) {
+		return
+	}
+	s.stop()
+	SetStateOfTarget(s.World, s.Entity, s.Entity, s.Target, true)
+}
+
+// scriptedSnapshot is the transient state of a running move saved in snapshots.
+type scriptedSnapshot struct {
+	Physics interface{}
+	Running bool
+	Index   int
+	Frame   int
+	From    m.Pos
+	Frozen  bool
+}
+
+func (s *Scripted) SaveSnapshot() interface{} {
+	return scriptedSnapshot{
+		Physics: s.Physics.SaveSnapshot(),
+		Running: s.Running,
+		Index:   s.Index,
+		Frame:   s.Frame,
+		From:    s.From,
+		Frozen:  s.Frozen,
+	}
+}
+
+// LoadSnapshot restores a running move.
+// The player's freeze count is part of the player's snapshot, so it is not frozen again.
+func (s *Scripted) LoadSnapshot(snap interface{}) {
+	ss := snap.(scriptedSnapshot)
+	s.Physics.LoadSnapshot(ss.Physics)
+	s.Running = ss.Running
+	s.Index = ss.Index
+	s.Frame = ss.Frame
+	s.From = ss.From
+	s.Frozen = ss.Frozen
+}
+
+var _ engine.Snapshotter = &Scripted{}
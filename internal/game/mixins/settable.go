@@ -47,6 +47,16 @@ func (s *Settable) Init(sp *level.SpawnableProps) error {
 	return parseErr
 }
 
+func (s *Settable) SaveSnapshot() interface{} {
+	return s.State
+}
+
+func (s *Settable) LoadSnapshot(snap interface{}) {
+	s.State = snap.(bool)
+}
+
+var _ engine.Snapshotter = &Settable{}
+
 // stateSetter is an entity that contains this mixin.
 type stateSetter interface {
 	SetState(originator, predecessor *engine.Entity, state bool)
@@ -0,0 +1,193 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mixins
+
+import (
+	"fmt"
+
+	"github.com/divVerent/aaaaxy/internal/animation"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/sound"
+)
+
+// MachineState is a single state of a StateMachine.
+type MachineState struct {
+	Anim   string // Animation group to force when entering; empty keeps the current one.
+	Sound  string // Sound to play when entering; empty plays none.
+	Frames int    // If nonzero, time till switching to Next.
+	Next   string // Name of the state to switch to after Frames.
+
+	// Hooks; all optional.
+	Enter  func() // Called when entering the state.
+	Update func() // Called every frame while in the state; may call Goto.
+	Exit   func() // Called when leaving the state.
+}
+
+// StateMachine is a mixin for entities that go through distinct states,
+// such as bosses or elaborate contraptions.
+//
+// States are declared as data by the entity; the machine takes care of
+// timed transitions, animations and sounds. The entity is divided into
+// phases, each of which has a state to start in; the current phase is
+// kept in the persistent state, so progress survives respawning.
+type StateMachine struct {
+	World  *engine.World
+	Entity *engine.Entity
+	Anim   *animation.State // May be nil if no state uses animations.
+
+	States      map[string]*MachineState
+	PhaseStates []string // The state to enter for each phase.
+
+	Current string // Name of the current state.
+	Frame   int    // Frames since entering the current state.
+	Phase   int    // The current phase.
+
+	persistentState propmap.Map
+	sounds          map[string]*sound.Sound
+}
+
+// Init validates the states and enters the initial state of the persisted phase.
+// The animation, if any, must already be initialized.
+func (s *StateMachine) Init(w *engine.World, sp *level.SpawnableProps, e *engine.Entity, anim *animation.State, states map[string]*MachineState, phaseStates []string) error {
+	s.World = w
+	s.Entity = e
+	s.Anim = anim
+	s.States = states
+	s.PhaseStates = phaseStates
+	s.persistentState = sp.PersistentState
+	s.sounds = map[string]*sound.Sound{}
+
+	if len(phaseStates) == 0 {
+		return fmt.Errorf("state machine has no phases")
+	}
+	for _, name := range phaseStates {
+		if states[name] == nil {
+			return fmt.Errorf("phase references nonexisting state %q", name)
+		}
+	}
+	for name, state := range states {
+		if state.Frames != 0 && states[state.Next] == nil {
+			return fmt.Errorf("state %q references nonexisting next state %q", name, state.Next)
+		}
+		if state.Anim != "" && (anim == nil || anim.Groups[state.Anim] == nil) {
+			return fmt.Errorf("state %q references nonexisting animation group %q", name, state.Anim)
+		}
+		if state.Sound != "" {
+			var err error
+			s.sounds[name], err = sound.Load(state.Sound)
+			if err != nil {
+				return fmt.Errorf("could not load sound for state %q: %w", name, err)
+			}
+		}
+	}
+
+	var parseErr error
+	phase := propmap.ValueOrP(s.persistentState, "phase", 0, &parseErr)
+	if phase < 0 || phase >= len(phaseStates) {
+		return fmt.Errorf("invalid persisted phase %d: must be between 0 and %d", phase, len(phaseStates)-1)
+	}
+	s.Phase = phase
+	s.Goto(phaseStates[phase])
+	return parseErr
+}
+
+// Goto switches to the given state, even if already in it.
+func (s *StateMachine) Goto(name string) {
+	next := s.States[name]
+	if next == nil {
+		log.Errorf("state machine of %v tried to switch to nonexisting state %q", s.Entity.Incarnation, name)
+		return
+	}
+	if cur := s.States[s.Current]; cur != nil && cur.Exit != nil {
+		cur.Exit()
+	}
+	s.Current = name
+	s.Frame = 0
+	if next.Anim != "" {
+		s.Anim.ForceGroup(next.Anim)
+	}
+	if snd := s.sounds[name]; snd != nil {
+		snd.Play()
+	}
+	if next.Enter != nil {
+		next.Enter()
+	}
+}
+
+// In returns whether the machine is in the given state.
+func (s *StateMachine) In(name string) bool {
+	return s.Current == name
+}
+
+// SetPhase persistently advances to the given phase and enters its initial state.
+func (s *StateMachine) SetPhase(phase int) {
+	if phase < 0 || phase >= len(s.PhaseStates) {
+		log.Errorf("state machine of %v tried to switch to nonexisting phase %d", s.Entity.Incarnation, phase)
+		return
+	}
+	s.Phase = phase
+	propmap.Set(s.persistentState, "phase", phase)
+	s.Goto(s.PhaseStates[phase])
+}
+
+// Update runs the current state and performs timed transitions.
+// Should be called before updating the animation.
+func (s *StateMachine) Update() {
+	s.Frame++
+	state := s.States[s.Current]
+	if state.Update != nil {
+		state.Update()
+		if s.Frame == 0 {
+			// The hook switched states.
+			return
+		}
+	}
+	if state.Frames != 0 && s.Frame >= state.Frames {
+		s.Goto(state.Next)
+	}
+}
+
+// stateMachineSnapshot is the transient state machine state saved in snapshots.
+// The phase is persistent and restored by Init already.
+type stateMachineSnapshot struct {
+	Current string
+	Frame   int
+}
+
+func (s *StateMachine) SaveSnapshot() interface{} {
+	return stateMachineSnapshot{
+		Current: s.Current,
+		Frame:   s.Frame,
+	}
+}
+
+// LoadSnapshot returns to the saved state without running any hooks.
+func (s *StateMachine) LoadSnapshot(snap interface{}) {
+	ss := snap.(stateMachineSnapshot)
+	if s.States[ss.Current] == nil {
+		log.Errorf("state machine of %v cannot restore nonexisting state %q", s.Entity.Incarnation, ss.Current)
+		return
+	}
+	s.Current = ss.Current
+	s.Frame = ss.Frame
+	if anim := s.States[ss.Current].Anim; anim != "" {
+		s.Anim.ForceGroup(anim)
+	}
+}
+
+var _ engine.Snapshotter = &StateMachine{}
@@ -0,0 +1,81 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package player
+
+import (
+	"github.com/divVerent/aaaaxy/internal/engine"
+)
+
+// An Ability is an active player ability, such as a dash, that acts on its own each frame.
+// Abilities are unlocked by name in the player state, usually by a Give entity placed in the map.
+type Ability interface {
+	// Name returns the name the ability is unlocked by.
+	Name() string
+
+	// Update runs once per frame while the ability is unlocked.
+	// It is called after walking and jumping and before physics, so it may override the velocity.
+	// action is whether the action button was just pressed.
+	Update(p *Player, action bool)
+
+	// Reset forgets all transient state; called when the player respawns.
+	Reset()
+
+	// SaveSnapshot and LoadSnapshot save and restore the transient state for practice snapshots.
+	engine.Snapshotter
+}
+
+// abilityTypes are constructors of all known abilities.
+var abilityTypes []func() Ability
+
+// RegisterAbility adds an ability type to the player.
+// To be called from init() functions of ability implementations.
+func RegisterAbility(newAbility func() Ability) {
+	abilityTypes = append(abilityTypes, newAbility)
+}
+
+// initAbilities creates one instance of each ability for this player.
+func (p *Player) initAbilities() {
+	p.Abilities = make([]Ability, 0, len(abilityTypes))
+	for _, newAbility := range abilityTypes {
+		p.Abilities = append(p.Abilities, newAbility())
+	}
+}
+
+// hasActionAbility returns whether any ability is unlocked, as they all use the action button.
+func (p *Player) hasActionAbility() bool {
+	for _, a := range p.Abilities {
+		if p.HasAbility(a.Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateAbilities runs all unlocked abilities.
+func (p *Player) updateAbilities(action bool) {
+	for _, a := range p.Abilities {
+		if !p.HasAbility(a.Name()) {
+			continue
+		}
+		a.Update(p, action)
+	}
+}
+
+// resetAbilities resets all abilities after respawning.
+func (p *Player) resetAbilities() {
+	for _, a := range p.Abilities {
+		a.Reset()
+	}
+}
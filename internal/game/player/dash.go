@@ -0,0 +1,83 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package player
+
+import (
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/constants"
+)
+
+const (
+	// DashSpeed is the speed of a dash.
+	DashSpeed = 480 * constants.SubPixelScale / engine.GameTPS
+
+	// DashFrames is how long a dash lasts. Together with DashSpeed this is a 5 tile dash.
+	DashFrames = 10
+)
+
+// dash is an ability that lets the player dash horizontally once per jump.
+// It moves by regular physics, so it collides with walls and passes warpzones like walking does.
+type dash struct {
+	FramesLeft int  // Frames of the current dash still to go.
+	Dir        int  // Direction of the current dash along the walking direction.
+	Used       bool // Whether the dash was used since last touching the ground.
+}
+
+func (d *dash) Name() string {
+	return "dash"
+}
+
+func (d *dash) Update(p *Player, action bool) {
+	if p.OnGround && d.FramesLeft == 0 {
+		d.Used = false
+	}
+	if action && !d.Used {
+		d.Used = true
+		d.FramesLeft = DashFrames
+		// The player sprite looks left when its orientation is the plain gravity frame.
+		d.Dir = 1
+		if p.Entity.Orientation == p.Gravity {
+			d.Dir = -1
+		}
+		p.JumpSound.Play()
+	}
+	if d.FramesLeft == 0 {
+		return
+	}
+	d.FramesLeft--
+	right := p.Gravity.Right
+	// No gravity while dashing.
+	p.Velocity = right.Mul(d.Dir * DashSpeed)
+	if d.FramesLeft == 0 {
+		// Leave the dash with no more than regular air speed.
+		p.Velocity = right.Mul(d.Dir * MaxAirSpeed)
+	}
+}
+
+func (d *dash) Reset() {
+	*d = dash{}
+}
+
+func (d *dash) SaveSnapshot() interface{} {
+	return *d
+}
+
+func (d *dash) LoadSnapshot(s interface{}) {
+	*d = s.(dash)
+}
+
+func init() {
+	RegisterAbility(func() Ability { return &dash{} })
+}
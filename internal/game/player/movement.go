@@ -0,0 +1,126 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package player
+
+import (
+	"github.com/divVerent/aaaaxy/internal/game/constants"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// Movement is the part of the player state that governs walking and jumping.
+// It is kept separate from input, sound and animation so the movement physics can be tested on its own.
+type Movement struct {
+	CoyoteFrames int // Number of frames w/o gravity and w/ jumping. Goes down to -1 (0 is just timed out, -1 is normal)
+	Jumping      bool
+	JumpingUp    bool
+	JumpHeld     bool // Whether jump was held in the previous frame.
+	JumpBuffered int  // Number of frames a jump press is still remembered for.
+}
+
+// JumpOptions tune how jumping reacts to input.
+type JumpOptions struct {
+	InAirJump    bool // Allow jumping while in air.
+	AutoJump     bool // Jump again when landing while jump is still held.
+	BufferFrames int  // Number of frames a jump press is remembered while jumping is not possible.
+}
+
+// Jump starts a jump if jump is held and jumping is possible.
+// Returns whether a jump was started.
+func (mv *Movement) Jump(p *mixins.Physics, jump bool, opts JumpOptions) bool {
+	if jump && !mv.JumpHeld {
+		mv.JumpBuffered = opts.BufferFrames
+	} else if mv.JumpBuffered > 0 {
+		mv.JumpBuffered--
+	}
+	mv.JumpHeld = jump
+	if !jump {
+		mv.Jumping = false
+	}
+	canJump := mv.CoyoteFrames > 0 || opts.InAirJump
+	wantJump := mv.JumpBuffered > 0
+	if jump && (!mv.Jumping || (opts.AutoJump && mv.CoyoteFrames > 0)) {
+		wantJump = true
+	}
+	if !canJump || !wantJump {
+		return false
+	}
+	p.Velocity = p.Velocity.Add(p.OnGroundVec.Mul(-JumpVelocity))
+	p.OnGround = false
+	mv.CoyoteFrames = -1
+	mv.Jumping = true
+	mv.JumpingUp = true
+	mv.JumpBuffered = 0
+	return true
+}
+
+// Walk applies walking acceleration, friction and gravity for one frame.
+// Walking happens along right, which must be orthogonal to the gravity direction.
+// moveLeft and moveRight are how far the player pushes into either direction, from 0 to 1;
+// less than 1 scales down both acceleration and maximum walking speed.
+func (mv *Movement) Walk(p *mixins.Physics, right m.Delta, moveLeft, moveRight m.Fixed) {
+	prevWalkVel := p.Velocity.Dot(right)
+	walkVel := prevWalkVel
+	if p.OnGround {
+		if moveLeft > 0 {
+			accelerate(&walkVel, GroundFriction+scale(AirAccel, moveLeft), scale(MaxGroundSpeed, moveLeft)+GroundFriction, -1)
+		}
+		if moveRight > 0 {
+			accelerate(&walkVel, GroundFriction+scale(AirAccel, moveRight), scale(MaxGroundSpeed, moveRight)+GroundFriction, +1)
+		}
+		friction(&walkVel, GroundFriction)
+	} else {
+		if moveLeft > 0 {
+			accelerate(&walkVel, scale(AirAccel, moveLeft), scale(MaxAirSpeed, moveLeft), -1)
+		}
+		if moveRight > 0 {
+			accelerate(&walkVel, scale(AirAccel, moveRight), scale(MaxAirSpeed, moveRight), +1)
+		}
+		if p.Velocity.Dot(p.OnGroundVec) < 0 && mv.JumpingUp && !mv.Jumping {
+			p.Velocity = p.Velocity.Add(p.OnGroundVec.Mul(JumpExtraGravity))
+		}
+	}
+	p.Velocity = p.Velocity.Add(right.Mul(walkVel - prevWalkVel))
+	if mv.CoyoteFrames <= 0 {
+		// No gravity while we still can jump.
+		p.Velocity = p.Velocity.Add(p.OnGroundVec.Mul(constants.Gravity))
+	}
+	p.Velocity = p.Velocity.WithMaxLengthFixed(m.NewFixed(MaxSpeed))
+}
+
+// scale scales a speed or acceleration by an input amount.
+func scale(v int, amount m.Fixed) int {
+	if amount >= m.FixedOne {
+		return v
+	}
+	return m.NewFixed(v).Mul(amount).Rint()
+}
+
+// Touched updates the jump state when physics hit something.
+func (mv *Movement) Touched(p *mixins.Physics, hitDelta m.Delta) {
+	if hitDelta.Dot(p.OnGroundVec) > 0 {
+		mv.JumpingUp = false
+	}
+}
+
+// Landed updates the coyote time after physics ran.
+// coyoteFrames is the number of frames jumping is still possible after leaving ground.
+func (mv *Movement) Landed(p *mixins.Physics, coyoteFrames int) {
+	if p.OnGround {
+		mv.CoyoteFrames = coyoteFrames
+	} else if mv.CoyoteFrames >= 0 {
+		mv.CoyoteFrames--
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package player
+
+import (
+	"fmt"
+
+	"github.com/divVerent/aaaaxy/internal/animation"
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/netghost"
+)
+
+const (
+	// netGhostAlpha is the opacity of the other player's ghost.
+	netGhostAlpha = 0.5
+)
+
+// netGhostAnims are the animation groups that are sent to the other player, by index.
+var netGhostAnims = []string{"idle", "walk", "jump"}
+
+// NetGhost shows the other player when ghost racing.
+// It is drawn as an overlay and not part of the world, so it cannot affect the game.
+type NetGhost struct {
+	Entity *engine.Entity
+	Anim   animation.State
+}
+
+func newNetGhost() (*NetGhost, error) {
+	g := &NetGhost{
+		Entity: engine.NewOverlay(),
+	}
+	g.Entity.Rect.Size = m.Delta{DX: PlayerWidth, DY: PlayerHeight}
+	g.Entity.RenderOffset = m.Delta{DX: PlayerOffsetDX, DY: PlayerOffsetDY}
+	g.Entity.Alpha = 0
+	err := g.Anim.Init("player", map[string]*animation.Group{
+		"idle": {
+			Frames:        2,
+			FrameInterval: 172,
+			NextInterval:  180,
+			NextAnim:      "idle",
+		},
+		"walk": {
+			Frames:        6,
+			FrameInterval: 4,
+			NextInterval:  4 * 6,
+			NextAnim:      "walk",
+		},
+		"jump": {
+			Frames:       1,
+			NextInterval: 8,
+			NextAnim:     "jump",
+		}}, "idle")
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize ghost animation: %w", err)
+	}
+	return g, nil
+}
+
+// update moves the ghost to where the other player is, and hides it when not in view.
+func (g *NetGhost) update(w *engine.World) {
+	s, ok := netghost.Remote()
+	if !ok || s.Category != uint32(w.PlayerState.SpeedrunCategories()) {
+		g.Entity.Alpha = 0
+		return
+	}
+	center, orientation, found := netGhostWorldPos(w, s, w.Player.Rect.Center())
+	if !found {
+		g.Entity.Alpha = 0
+		return
+	}
+	g.Entity.Rect.Origin = center.Sub(g.Entity.Rect.Size.Div(2))
+	g.Entity.Orientation = orientation
+	g.Entity.Alpha = netGhostAlpha
+	if int(s.Anim) < len(netGhostAnims) {
+		g.Anim.SetGroup(netGhostAnims[s.Anim])
+	}
+	g.Anim.Update(g.Entity)
+}
+
+// netGhostWorldPos finds where a remote player state is in the world.
+// As warpzones can make a level tile show up more than once, the one closest to near is used.
+// Only tiles currently in view are considered.
+func netGhostWorldPos(w *engine.World, s netghost.State, near m.Pos) (m.Pos, m.Orientation, bool) {
+	nearTile := near.Div(level.TileSize)
+	rx := engine.GameWidth/level.TileSize/2 + 2
+	ry := engine.GameHeight/level.TileSize/2 + 2
+	var bestPos m.Pos
+	var bestTile *level.Tile
+	bestDist := int64(0)
+	for y := nearTile.Y - ry; y <= nearTile.Y+ry; y++ {
+		for x := nearTile.X - rx; x <= nearTile.X+rx; x++ {
+			pos := m.Pos{X: x, Y: y}
+			tile := w.Tile(pos)
+			if tile == nil || tile.LevelPos != s.LevelPos {
+				continue
+			}
+			dist := pos.Delta(nearTile).Length2()
+			if bestTile == nil || dist < bestDist {
+				bestPos, bestTile, bestDist = pos, tile, dist
+			}
+		}
+	}
+	if bestTile == nil {
+		return m.Pos{}, m.Orientation{}, false
+	}
+	toWorld := bestTile.Transform.Inverse()
+	d2 := toWorld.Apply(s.Offset2)
+	origin := bestPos.Mul(level.TileSize)
+	center := m.Pos{
+		X: origin.X + (d2.DX+level.TileSize-1)/2,
+		Y: origin.Y + (d2.DY+level.TileSize-1)/2,
+	}
+	return center, toWorld.Concat(s.Orientation), true
+}
+
+// updateNetGhost sends the player position when ghost racing, and shows the other player's ghost.
+func (p *Player) updateNetGhost() {
+	if !netghost.Active() {
+		return
+	}
+	if p.NetGhost == nil {
+		g, err := newNetGhost()
+		if err != nil {
+			log.Errorf("could not create ghost: %v", err)
+			return
+		}
+		p.NetGhost = g
+		p.World.Overlays = append(p.World.Overlays, g.Entity)
+	}
+	p.NetGhost.update(p.World)
+	// Demo playback is not the player racing, so do not show it to others.
+	if demo.Playing() {
+		return
+	}
+	center := p.Entity.Rect.Center()
+	tilePos := center.Div(level.TileSize)
+	tile := p.World.Tile(tilePos)
+	if tile == nil {
+		return
+	}
+	origin := tilePos.Mul(level.TileSize)
+	d2 := m.Delta{
+		DX: 2*(center.X-origin.X) - (level.TileSize - 1),
+		DY: 2*(center.Y-origin.Y) - (level.TileSize - 1),
+	}
+	anim := byte(0)
+	for i, name := range netGhostAnims {
+		if p.Anim.Group == p.Anim.Groups[name] {
+			anim = byte(i)
+		}
+	}
+	netghost.Send(netghost.State{
+		Category:    uint32(p.World.PlayerState.SpeedrunCategories()),
+		LevelPos:    tile.LevelPos,
+		Offset2:     tile.Transform.Apply(d2),
+		Orientation: tile.Transform.Concat(p.Entity.Orientation),
+		Anim:        anim,
+	})
+}
@@ -33,34 +33,52 @@ import (
 	m "github.com/divVerent/aaaaxy/internal/math"
 	"github.com/divVerent/aaaaxy/internal/noise"
 	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
 	"github.com/divVerent/aaaaxy/internal/sound"
 )
 
 var (
-	cheatInAirJump = flag.Bool("cheat_in_air_jump", false, "allow jumping while in air (allows getting anywhere)")
-	cheatVVVVVV    = flag.Bool("cheat_vvvvvv", false, "play VVVVVV, not AAAAXY")
+	cheatInAirJump   = flag.Bool("cheat_in_air_jump", false, "allow jumping while in air (allows getting anywhere)")
+	cheatVVVVVV      = flag.Bool("cheat_vvvvvv", false, "play VVVVVV, not AAAAXY")
+	autoJump         = flag.Bool("auto_jump", false, "keep jumping while the jump button is held (assist option)")
+	jumpBufferFrames = flag.Int("jump_buffer_frames", JumpBufferFrames, "number of frames a jump press is remembered for when jumping is not possible yet (assist option if changed)")
+	coyoteFrames     = flag.Int("coyote_frames", ExtraGroundFrames, "number of frames jumping is still possible after leaving the ground (assist option if changed)")
+	inAirJump        = flag.Bool("in_air_jump", false, "allow jumping again while in air (assist option)")
 )
 
+func init() {
+	flag.MarkAssist("auto_jump")
+	flag.MarkAssist("jump_buffer_frames")
+	flag.MarkAssist("coyote_frames")
+	flag.MarkAssist("in_air_jump")
+	flag.RecordInDemo("auto_jump")
+	// Demos recorded before the jump buffer existed must be played back without it.
+	flag.RecordInDemoWithLegacyValue("jump_buffer_frames", 0)
+	flag.RecordInDemo("coyote_frames")
+	flag.RecordInDemo("in_air_jump")
+}
+
 type Player struct {
 	mixins.Physics
 	World  *engine.World
 	Entity *engine.Entity
 
-	CoyoteFrames   int // Number of frames w/o gravity and w/ jumping. Goes down to -1 (0 is just timed out, -1 is normal)
+	Movement
 	LastGroundPos  m.Pos
-	Jumping        bool
-	JumpingUp      bool
 	LookUp         bool
 	LookDown       bool
 	Respawning     bool
 	WasOnGround    bool
 	PrevVelocity   m.Delta
 	VVVVVV         bool
+	Gravity        m.Orientation // Frame of reference for walking; Down is the gravity direction.
 	JustSpawned    bool
 	Goal           *engine.Entity
+	Frozen         int // Number of Freeze calls not yet undone by Unfreeze.
 	EasterEggCount int
 
-	Anim animation.State
+	Anim      animation.State
+	Abilities []Ability
 
 	JumpSound       *sound.Sound
 	VVVVVVSound     *sound.Sound
@@ -68,11 +86,16 @@ type Player struct {
 	HitHeadSound    *sound.Sound
 	HitWallSound    *sound.Sound
 	GotAbilitySound *sound.Sound
+
+	NetGhost *NetGhost // Ghost of the other player when ghost racing, if created.
 }
 
 var _ interfaces.Abilityer = &Player{}
 var _ interfaces.ActionPresseder = &Player{}
 var _ interfaces.VVVVVVer = &Player{}
+var _ interfaces.Gravityer = &Player{}
+var _ interfaces.Freezer = &Player{}
+var _ engine.Snapshotter = &Player{}
 
 // Player height is 30 px.
 // So 30 px ~ 180 cm.
@@ -139,6 +162,10 @@ const (
 	// 1 allows some walking over 1 tile gaps.
 	ExtraGroundFrames = 5
 
+	// Number of frames a jump press is remembered while jumping is not possible yet.
+	// This lets a jump pressed slightly before landing still happen.
+	JumpBufferFrames = 3
+
 	// Animation tuning.
 	AnimGroundSpeed = 20 * constants.SubPixelScale / engine.GameTPS
 )
@@ -148,7 +175,7 @@ func (p *Player) SetVVVVVV(vvvvvv bool, up m.Delta, factor float64) {
 		return
 	}
 	if !up.IsZero() {
-		p.OnGroundVec = up
+		p.setGravity(up)
 	}
 	p.VVVVVV = vvvvvv
 	if !p.JustSpawned {
@@ -167,6 +194,83 @@ func (p *Player) SetVVVVVV(vvvvvv bool, up m.Delta, factor float64) {
 	// A place in Vae Victis is much easier this way.
 }
 
+// ReadGravity returns the player's current frame of reference.
+func (p *Player) ReadGravity() m.Orientation {
+	return p.Gravity
+}
+
+// SetGravity changes the player's frame of reference.
+// Gravity will point in g.Down direction, and walking right will move in g.Right direction.
+func (p *Player) SetGravity(g m.Orientation) {
+	if p.Entity.Orientation == p.Gravity {
+		p.Entity.Orientation = g
+	} else {
+		p.Entity.Orientation = g.Concat(m.FlipX())
+	}
+	p.Gravity = g
+	p.OnGroundVec = g.Down
+}
+
+// setGravity changes the gravity direction, keeping the walking direction if possible.
+func (p *Player) setGravity(down m.Delta) {
+	if down == p.Gravity.Down {
+		return
+	}
+	g := m.Orientation{Right: p.Gravity.Right, Down: down}
+	if g.Right.Dot(down) != 0 {
+		// Turned by 90 degrees. Turn the walking direction along.
+		g.Right = m.Delta{DX: down.DY, DY: -down.DX}
+	}
+	p.SetGravity(g)
+}
+
+// hitboxSize returns the desired hitbox size in the current frame of reference.
+func (p *Player) hitboxSize() m.Delta {
+	return m.Rect{Size: p.Gravity.Apply(m.Delta{DX: PlayerWidth, DY: PlayerHeight})}.Normalized().Size
+}
+
+// frameDelta returns the world space offset of the given point of the upright player relative to the hitbox origin.
+func (p *Player) frameDelta(r m.Rect) m.Delta {
+	hitbox := p.Gravity.ApplyToRect2(m.Pos{}, m.Rect{Size: m.Delta{DX: PlayerWidth, DY: PlayerHeight}})
+	return p.Gravity.ApplyToRect2(m.Pos{}, r).Origin.Delta(hitbox.Origin)
+}
+
+// screenDelta maps a world space direction to where it is shown on the screen.
+func (p *Player) screenDelta(d m.Delta) m.Delta {
+	if p.World.PlayerState.Modifiers().ContainAll(playerstate.MirrorModifier) {
+		d.DX = -d.DX
+	}
+	return d
+}
+
+// inputAlong returns whether input towards and away from the given screen direction is held.
+func inputAlong(d m.Delta) (towards, away bool) {
+	switch {
+	case d.DX > 0:
+		return input.Right.Held, input.Left.Held
+	case d.DX < 0:
+		return input.Left.Held, input.Right.Held
+	case d.DY > 0:
+		return input.Down.Held, input.Up.Held
+	default:
+		return input.Up.Held, input.Down.Held
+	}
+}
+
+// amountAlong returns how far input towards and away from the given screen direction is pushed.
+func amountAlong(d m.Delta) (towards, away m.Fixed) {
+	switch {
+	case d.DX > 0:
+		return input.Right.Amount(), input.Left.Amount()
+	case d.DX < 0:
+		return input.Left.Amount(), input.Right.Amount()
+	case d.DY > 0:
+		return input.Down.Amount(), input.Up.Amount()
+	default:
+		return input.Up.Amount(), input.Down.Amount()
+	}
+}
+
 func (p *Player) HasAbility(name string) bool {
 	return p.World.PlayerState.HasAbility(name)
 }
@@ -257,6 +361,8 @@ func (p *Player) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Enti
 		return fmt.Errorf("could not load got_ability sound: %w", err)
 	}
 
+	p.initAbilities()
+
 	// Reset as if after respawn.
 	p.Respawned()
 
@@ -283,95 +389,82 @@ func friction(vel *int, friction int) {
 }
 
 func (p *Player) setActionButtonAvailable() {
-	wantButton := p.HasAbility("carry") || p.HasAbility("push") || p.HasAbility("control")
+	wantButton := p.HasAbility("carry") || p.HasAbility("push") || p.HasAbility("control") || p.hasActionAbility()
 	input.SetActionButtonAvailable(wantButton)
 }
 
 func (p *Player) Update() {
 	p.JustSpawned = false
-	var moveLeft, moveRight, jump bool
-	if p.Goal == nil {
-		p.LookUp = input.Up.Held
-		p.LookDown = input.Down.Held
-		moveLeft = input.Left.Held
-		moveRight = input.Right.Held
+	var moveLeft, moveRight m.Fixed
+	var jump, actionHit bool
+	if p.Frozen > 0 {
+		// No input at all.
+		p.LookUp = false
+		p.LookDown = false
+		p.JumpBuffered = 0
+	} else if p.Goal == nil {
+		p.LookDown, p.LookUp = inputAlong(p.screenDelta(p.Gravity.Down))
+		moveRight, moveLeft = amountAlong(p.screenDelta(p.Gravity.Right))
 		jump = input.Jump.Held
 		action := input.Action.Held
-		if p.LookUp || p.LookDown || moveLeft || moveRight || jump || action {
+		actionHit = input.Action.JustHit
+		if p.LookUp || p.LookDown || moveLeft > 0 || moveRight > 0 || jump || action {
 			p.World.TimerStarted = true
 		}
 	} else {
 		// Walk towards goal!
 		p.LookUp = false
 		p.LookDown = false
-		delta := p.Goal.Rect.Center().Delta(p.Entity.Rect.Center())
-		moveLeft = delta.DX < 0
-		moveRight = delta.DX > 0
-		jump = false
-	}
-	if jump {
-		if !p.Jumping && (p.CoyoteFrames > 0 || *cheatInAirJump) {
-			p.Velocity = p.Velocity.Add(p.OnGroundVec.Mul(-JumpVelocity))
-			p.OnGround = false
-			p.CoyoteFrames = -1
-			p.Jumping = true
-			p.JumpingUp = true
-			if p.VVVVVV || *cheatVVVVVV {
-				p.OnGroundVec = p.OnGroundVec.Mul(-1)
-			}
-			p.JumpSound.Play()
+		delta := p.Goal.Rect.Center().Delta(p.Entity.Rect.Center()).Dot(p.Gravity.Right)
+		if delta < 0 {
+			moveLeft = m.FixedOne
 		}
-	} else {
-		p.Jumping = false
-	}
-	if p.OnGround {
-		maxSpeed := MaxGroundSpeed + GroundFriction
-		if moveLeft {
-			accelerate(&p.Velocity.DX, GroundAccel, maxSpeed, -1)
+		if delta > 0 {
+			moveRight = m.FixedOne
 		}
-		if moveRight {
-			accelerate(&p.Velocity.DX, GroundAccel, maxSpeed, +1)
-		}
-		friction(&p.Velocity.DX, GroundFriction)
-	} else {
-		if moveLeft {
-			accelerate(&p.Velocity.DX, AirAccel, MaxAirSpeed, -1)
-		}
-		if moveRight {
-			accelerate(&p.Velocity.DX, AirAccel, MaxAirSpeed, +1)
-		}
-		if p.Velocity.Dot(p.OnGroundVec) < 0 && p.JumpingUp && !p.Jumping {
-			p.Velocity = p.Velocity.Add(p.OnGroundVec.Mul(JumpExtraGravity))
+		jump = false
+		p.JumpBuffered = 0
+	}
+	if p.Movement.Jump(&p.Physics, jump, JumpOptions{
+		InAirJump:    *cheatInAirJump || *inAirJump,
+		AutoJump:     *autoJump,
+		BufferFrames: *jumpBufferFrames,
+	}) {
+		if p.VVVVVV || *cheatVVVVVV {
+			p.setGravity(p.OnGroundVec.Mul(-1))
 		}
+		p.JumpSound.Play()
 	}
-	if p.CoyoteFrames <= 0 {
-		// No gravity while we still can jump.
-		p.Velocity = p.Velocity.Add(p.OnGroundVec.Mul(constants.Gravity))
+	// Walking happens along the Right vector of our frame of reference.
+	p.Movement.Walk(&p.Physics, p.Gravity.Right, moveLeft, moveRight)
+
+	// Abilities may override the walking and jumping velocity.
+	p.updateAbilities(actionHit)
+
+	if size := p.hitboxSize(); p.Entity.Rect.Size != size {
+		// Gravity turned sideways. Rotate the hitbox as soon as there is room.
+		p.ModifyHitBoxCentered(size.Sub(p.Entity.Rect.Size))
 	}
-	p.Velocity = p.Velocity.WithMaxLengthFixed(m.NewFixed(MaxSpeed))
 
 	// Run physics.
 	p.WasOnGround = p.OnGround
 	p.PrevVelocity = p.Velocity
 	p.Physics.Update() // May call handleTouch.
 
-	if moveLeft && !moveRight {
-		p.Entity.Orientation = m.Identity()
+	if moveLeft > 0 && moveRight == 0 {
+		p.Entity.Orientation = p.Gravity
 	}
-	if moveRight && !moveLeft {
-		p.Entity.Orientation = m.FlipX()
-	}
-	if p.OnGroundVec.Dot(p.Entity.Orientation.Down) < 0 {
-		p.Entity.Orientation = p.Entity.Orientation.Concat(m.FlipY())
-	}
-	if p.OnGroundVec.DY < 0 {
-		p.Entity.RenderOffset.DY = PlayerFlippedOffsetDY
-	} else {
-		p.Entity.RenderOffset.DY = PlayerOffsetDY
+	if moveRight > 0 && moveLeft == 0 {
+		p.Entity.Orientation = p.Gravity.Concat(m.FlipX())
 	}
+	p.Entity.RenderOffset = p.frameDelta(m.Rect{
+		Origin: m.Pos{X: PlayerOffsetDX, Y: PlayerOffsetDY},
+		Size:   m.Delta{DX: PlayerWidth - 2*PlayerOffsetDX, DY: PlayerHeight - PlayerOffsetDY - PlayerFlippedOffsetDY},
+	})
 	if p.OnGround {
 		p.LastGroundPos = p.Entity.Rect.Origin
-		if p.Velocity.DX > -AnimGroundSpeed && p.Velocity.DX < AnimGroundSpeed {
+		walkVel := p.Velocity.Dot(p.Gravity.Right)
+		if walkVel > -AnimGroundSpeed && walkVel < AnimGroundSpeed {
 			p.Anim.SetGroup("idle")
 		} else {
 			p.Anim.SetGroup("walk")
@@ -384,16 +477,13 @@ func (p *Player) Update() {
 		p.Anim.SetGroup("jump")
 	}
 	p.Anim.Update(p.Entity)
+	p.updateNetGhost()
 	speed := p.Velocity.Length()
 	if speed >= NoiseMinSpeed {
 		amount := math.Pow((speed-NoiseMinSpeed)/(NoiseMaxSpeed-NoiseMinSpeed), NoisePower)
 		noise.Set(amount)
 	}
-	if p.OnGround {
-		p.CoyoteFrames = ExtraGroundFrames
-	} else if p.CoyoteFrames >= 0 {
-		p.CoyoteFrames--
-	}
+	p.Movement.Landed(&p.Physics, *coyoteFrames)
 
 	// Easter egg.
 	// Doing this in player code so it only runs while the game is active.
@@ -417,9 +507,7 @@ func (p *Player) Update() {
 }
 
 func (p *Player) handleTouch(trace engine.TraceResult) {
-	if trace.HitDelta.Dot(p.OnGroundVec) > 0 {
-		p.JumpingUp = false
-	}
+	p.Movement.Touched(&p.Physics, trace.HitDelta)
 	if p.OnGround && !p.WasOnGround && p.CoyoteFrames < 0 {
 		p.Anim.SetGroup("land")
 		p.LandSound.Play()
@@ -450,33 +538,30 @@ func (p *Player) Touch(other *engine.Entity) {
 	// Nothing happens; we rather handle this on other's Touch event.
 }
 
-func (p *Player) eyeDY() int {
-	if p.OnGroundVec.DY < 0 {
-		return p.Entity.Rect.Size.DY - 1 - PlayerEyeDY
-	} else {
-		return PlayerEyeDY
-	}
+func (p *Player) eyeDelta() m.Delta {
+	return p.frameDelta(m.Rect{
+		Origin: m.Pos{X: PlayerEyeDX, Y: PlayerEyeDY},
+		Size:   m.Delta{DX: 1, DY: 1},
+	})
 }
 
 // EyePos returns the position the player eye is at.
 func (p *Player) EyePos() m.Pos {
-	return m.Pos{
-		X: p.Entity.Rect.Origin.X + PlayerEyeDX,
-		Y: p.Entity.Rect.Origin.Y + p.eyeDY(),
-	}
+	return p.Entity.Rect.Origin.Add(p.eyeDelta())
 }
 
 // LookPos returns the position the player is focusing at.
 func (p *Player) LookPos() m.Pos {
-	focus := m.Pos{
-		X: p.Entity.Rect.Origin.X + PlayerEyeDX,
-		Y: p.LastGroundPos.Y + p.eyeDY(),
-	}
+	eye := p.EyePos()
+	// Along the gravity axis, follow the last ground position instead of every jump.
+	down := p.Gravity.Down
+	groundEye := p.LastGroundPos.Add(p.eyeDelta())
+	focus := eye.Add(down.Mul(groundEye.Delta(eye).Dot(down)))
 	if p.LookUp {
-		focus.Y -= LookDistance
+		focus = focus.Sub(down.Mul(LookDistance))
 	}
 	if p.LookDown {
-		focus.Y += LookDistance
+		focus = focus.Add(down.Mul(LookDistance))
 	}
 	return focus
 }
@@ -485,11 +570,14 @@ func (p *Player) LookPos() m.Pos {
 func (p *Player) Respawned() {
 	p.Physics.Reset()                      // Stop moving.
 	p.LastGroundPos = p.Entity.Rect.Origin // Center the camera.
-	p.CoyoteFrames = ExtraGroundFrames     // Assume on ground.
+	p.CoyoteFrames = *coyoteFrames         // Assume on ground.
 	p.WasOnGround = p.OnGround             // Back to ground.
 	p.Jumping = true                       // Jump key must be hit again.
+	p.JumpBuffered = 0                     // Forget earlier jump presses.
 	p.VVVVVV = false                       // Normal physics.
+	p.Gravity = m.Identity()               // Upright.
 	p.OnGroundVec = m.Delta{DX: 0, DY: 1}  // Gravity points down.
+	p.Entity.Rect.Size = p.hitboxSize()    // Upright hitbox.
 	p.JumpingUp = false                    // Do not assume we're in the first half of a jump (fastfall).
 	p.Respawning = true                    // Block the respawn key until released.
 	p.Anim.ForceGroup("idle")              // Reset animation.
@@ -498,10 +586,11 @@ func (p *Player) Respawned() {
 	p.Goal = nil                           // Normal input.
 	p.JustSpawned = true                   // Just respawned.
 	p.setActionButtonAvailable()           // Update abilities.
+	p.resetAbilities()                     // Stop dashing etc.
 }
 
 func (p *Player) ActionPressed() bool {
-	if p.Goal != nil {
+	if p.Goal != nil || p.Frozen > 0 {
 		return false
 	}
 	return input.Action.Held
@@ -517,6 +606,18 @@ func (p *Player) SetGoal(goal *engine.Entity) {
 	p.Goal = goal
 }
 
+func (p *Player) Freeze() {
+	p.Frozen++
+}
+
+func (p *Player) Unfreeze() {
+	if p.Frozen <= 0 {
+		// Can happen if the world was reinitialized while frozen.
+		return
+	}
+	p.Frozen--
+}
+
 func (p *Player) DebugPos64() (x int64, y int64, vx int64, vy int64) {
 	return int64(p.Entity.Rect.Origin.X)*constants.SubPixelScale + int64(p.Physics.SubPixel.DX),
 		int64(p.Entity.Rect.Origin.Y)*constants.SubPixelScale + int64(p.Physics.SubPixel.DY),
@@ -524,6 +625,55 @@ func (p *Player) DebugPos64() (x int64, y int64, vx int64, vy int64) {
 		int64(p.Velocity.DY)
 }
 
+// playerSnapshot is the transient player state saved in snapshots.
+// Hit points and unlocked abilities are persistent state and thus restored with the save game.
+type playerSnapshot struct {
+	Physics       interface{}
+	Movement      Movement
+	LastGroundPos m.Pos
+	WasOnGround   bool
+	PrevVelocity  m.Delta
+	VVVVVV        bool
+	Gravity       m.Orientation
+	Frozen        int
+	Abilities     []interface{}
+}
+
+func (p *Player) SaveSnapshot() interface{} {
+	snap := &playerSnapshot{
+		Physics:       p.Physics.SaveSnapshot(),
+		Movement:      p.Movement,
+		LastGroundPos: p.LastGroundPos,
+		WasOnGround:   p.WasOnGround,
+		PrevVelocity:  p.PrevVelocity,
+		VVVVVV:        p.VVVVVV,
+		Gravity:       p.Gravity,
+		Frozen:        p.Frozen,
+		Abilities:     make([]interface{}, len(p.Abilities)),
+	}
+	for i, a := range p.Abilities {
+		snap.Abilities[i] = a.SaveSnapshot()
+	}
+	return snap
+}
+
+// LoadSnapshot restores the player.
+// The freeze count is restored as is, as the entities that froze the player restore their state too.
+func (p *Player) LoadSnapshot(s interface{}) {
+	snap := s.(*playerSnapshot)
+	p.Physics.LoadSnapshot(snap.Physics)
+	p.Movement = snap.Movement
+	p.LastGroundPos = snap.LastGroundPos
+	p.WasOnGround = snap.WasOnGround
+	p.PrevVelocity = snap.PrevVelocity
+	p.VVVVVV = snap.VVVVVV
+	p.Gravity = snap.Gravity
+	p.Frozen = snap.Frozen
+	for i, a := range p.Abilities {
+		a.LoadSnapshot(snap.Abilities[i])
+	}
+}
+
 func init() {
 	engine.RegisterEntityType(&Player{})
 }
@@ -480,6 +480,31 @@ func (r *Riser) Touch(other *engine.Entity) {
 	// Nothing happens; we rather handle this on other's Touch event.
 }
 
+// riserSnapshot is the transient riser state saved in snapshots.
+type riserSnapshot struct {
+	Physics           interface{}
+	State             riserState
+	FadeFrame         int
+	PlayerOnGroundVec m.Delta
+}
+
+func (r *Riser) SaveSnapshot() interface{} {
+	return riserSnapshot{
+		Physics:           r.Physics.SaveSnapshot(),
+		State:             r.State,
+		FadeFrame:         r.FadeFrame,
+		PlayerOnGroundVec: r.PlayerOnGroundVec,
+	}
+}
+
+func (r *Riser) LoadSnapshot(snap interface{}) {
+	s := snap.(riserSnapshot)
+	r.Physics.LoadSnapshot(s.Physics)
+	r.State = s.State
+	r.FadeFrame = s.FadeFrame
+	r.PlayerOnGroundVec = s.PlayerOnGroundVec
+}
+
 func init() {
 	engine.RegisterEntityType(&Riser{})
 }
@@ -69,9 +69,9 @@ func (t *CenterPrintTarget) Precache(sp *level.Spawnable) error {
 		return nil
 	}
 	bounds := font.BoundString(txt)
-	if bounds.Size.DX > engine.GameWidth {
+	if bounds.Size.DX > engine.DefaultGameWidth {
 		locale.Errorf("text too big: entity %v must fit in width %v but text needs %v: %v",
-			sp.ID, engine.GameWidth, bounds.Size, txtOrig)
+			sp.ID, engine.DefaultGameWidth, bounds.Size, txtOrig)
 	}
 	return nil
 }
@@ -0,0 +1,66 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// FlagGate sends a signal along while a world flag has the given value.
+// The flag is typically set by a FlagTrigger, possibly in another room.
+type FlagGate struct {
+	World  *engine.World
+	Entity *engine.Entity
+
+	Target mixins.TargetSelection
+	Flag   string
+	Value  string
+	Invert bool
+
+	State bool
+}
+
+func (g *FlagGate) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	g.World = w
+	g.Entity = e
+	var parseErr error
+	g.Target = mixins.ParseTarget(propmap.ValueP(sp.Properties, "target", "", &parseErr))
+	g.Flag = propmap.ValueP(sp.Properties, "flag", "", &parseErr)
+	g.Value = propmap.StringOr(sp.Properties, "value", "true")
+	g.Invert = propmap.ValueOrP(sp.Properties, "invert", false, &parseErr)
+	return parseErr
+}
+
+func (g *FlagGate) Despawn() {}
+
+func (g *FlagGate) Update() {
+	newState := engine.WorldFlag(g.World, g.Flag, "") == g.Value
+	if newState == g.State {
+		return
+	}
+	g.State = newState
+	mixins.SetStateOfTarget(g.World, g.Entity, g.Entity, g.Target, newState != g.Invert)
+}
+
+func (g *FlagGate) Touch(other *engine.Entity) {}
+
+func (g *FlagGate) SetState(originator, predecessor *engine.Entity, state bool) {}
+
+func init() {
+	engine.RegisterEntityType(&FlagGate{})
+}
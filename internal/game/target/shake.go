@@ -0,0 +1,67 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// ShakeTarget shakes the screen and optionally freezes the world briefly when turned on.
+// Meant for impacts and warp transitions set up in the map.
+type ShakeTarget struct {
+	World *engine.World
+
+	Amplitude     int
+	Duration      time.Duration
+	HitstopFrames int
+
+	State bool
+}
+
+func (s *ShakeTarget) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	s.World = w
+	var parseErr error
+	s.Amplitude = propmap.ValueOrP(sp.Properties, "amplitude", 4, &parseErr)
+	s.Duration = propmap.ValueOrP(sp.Properties, "duration", time.Second/2, &parseErr)
+	hitstopTime := propmap.ValueOrP(sp.Properties, "hitstop_time", time.Duration(0), &parseErr)
+	s.HitstopFrames = int((hitstopTime*engine.GameTPS + (time.Second / 2)) / time.Second)
+	return parseErr
+}
+
+func (s *ShakeTarget) Despawn() {}
+
+func (s *ShakeTarget) Update() {}
+
+func (s *ShakeTarget) SetState(originator, predecessor *engine.Entity, state bool) {
+	if state == s.State {
+		return
+	}
+	s.State = state
+	if !state {
+		return
+	}
+	s.World.ShakeScreen(s.Amplitude, s.Duration)
+	s.World.Hitstop(s.HitstopFrames)
+}
+
+func (s *ShakeTarget) Touch(other *engine.Entity) {}
+
+func init() {
+	engine.RegisterEntityType(&ShakeTarget{})
+}
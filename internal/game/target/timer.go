@@ -0,0 +1,152 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// TimerTarget counts game frames while on and fires its targets after a delay and then at a fixed interval.
+// Each firing either toggles the targets or sends them a pulse of the given length,
+// and optionally stores the number of firings so far in a world flag.
+// Meant for timed doors and rhythm based puzzles.
+type TimerTarget struct {
+	World  *engine.World
+	Entity *engine.Entity
+
+	Target         mixins.TargetSelection
+	Flag           string
+	DelayFrames    int
+	IntervalFrames int
+	PulseFrames    int
+	Toggle         bool
+	MaxCount       int
+	Persistent     bool
+	PersistentSP   *level.SpawnableProps
+
+	Enabled bool
+	Frame   int
+	Count   int
+	State   bool
+}
+
+func durationToFrames(d time.Duration) int {
+	return int((d*engine.GameTPS + (time.Second / 2)) / time.Second)
+}
+
+func (t *TimerTarget) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	t.World = w
+	t.Entity = e
+	var parseErr error
+	t.Target = mixins.ParseTarget(propmap.ValueP(sp.Properties, "target", "", &parseErr))
+	t.Flag = propmap.StringOr(sp.Properties, "flag", "")
+	t.DelayFrames = durationToFrames(propmap.ValueOrP(sp.Properties, "delay", time.Duration(0), &parseErr))
+	t.IntervalFrames = durationToFrames(propmap.ValueOrP(sp.Properties, "interval", time.Duration(0), &parseErr))
+	t.PulseFrames = durationToFrames(propmap.ValueOrP(sp.Properties, "pulse_time", time.Second/10, &parseErr))
+	if t.PulseFrames < 1 {
+		t.PulseFrames = 1
+	}
+	t.Toggle = propmap.ValueOrP(sp.Properties, "toggle", false, &parseErr)
+	t.MaxCount = propmap.ValueOrP(sp.Properties, "count", 0, &parseErr)
+	t.Enabled = propmap.ValueOrP(sp.Properties, "start_enabled", true, &parseErr)
+	t.Persistent = propmap.ValueOrP(sp.Properties, "persistent", false, &parseErr)
+	if t.Persistent {
+		t.PersistentSP = sp
+		t.Enabled = propmap.ValueOrP(sp.PersistentState, "enabled", t.Enabled, &parseErr)
+		t.Frame = propmap.ValueOrP(sp.PersistentState, "frame", 0, &parseErr)
+		t.Count = propmap.ValueOrP(sp.PersistentState, "count", 0, &parseErr)
+		t.State = propmap.ValueOrP(sp.PersistentState, "state", false, &parseErr)
+	}
+	return parseErr
+}
+
+func (t *TimerTarget) Despawn() {
+	t.save()
+}
+
+// save writes the timer state into the persistent state, if requested.
+func (t *TimerTarget) save() {
+	if !t.Persistent {
+		return
+	}
+	propmap.Set(t.PersistentSP.PersistentState, "enabled", t.Enabled)
+	propmap.Set(t.PersistentSP.PersistentState, "frame", t.Frame)
+	propmap.Set(t.PersistentSP.PersistentState, "count", t.Count)
+	propmap.Set(t.PersistentSP.PersistentState, "state", t.State)
+}
+
+func (t *TimerTarget) Update() {
+	if !t.Enabled {
+		return
+	}
+	if t.MaxCount > 0 && t.Count >= t.MaxCount {
+		return
+	}
+	t.Frame++
+	first := t.DelayFrames
+	if first < 1 {
+		first = 1
+	}
+	due := t.Frame == first
+	if t.Frame > first && t.IntervalFrames > 0 {
+		due = (t.Frame-first)%t.IntervalFrames == 0
+	}
+	if !due {
+		return
+	}
+	t.Count++
+	t.fire()
+	t.save()
+}
+
+// fire sends the current firing to all targets.
+func (t *TimerTarget) fire() {
+	if t.Flag != "" {
+		engine.SetWorldFlag(t.World, t.Flag, t.Count)
+	}
+	if t.Toggle {
+		t.State = !t.State
+		mixins.SetStateOfTarget(t.World, t.Entity, t.Entity, t.Target, t.State)
+		return
+	}
+	mixins.SetStateOfTarget(t.World, t.Entity, t.Entity, t.Target, true)
+	t.World.Schedule(t.Entity, t.PulseFrames, func() {
+		mixins.SetStateOfTarget(t.World, t.Entity, t.Entity, t.Target, false)
+	})
+}
+
+func (t *TimerTarget) Touch(other *engine.Entity) {}
+
+// SetState turns the timer on or off. Turning it on again restarts it.
+func (t *TimerTarget) SetState(originator, predecessor *engine.Entity, state bool) {
+	if state == t.Enabled {
+		return
+	}
+	t.Enabled = state
+	if state {
+		t.Frame = 0
+		t.Count = 0
+	}
+	t.save()
+}
+
+func init() {
+	engine.RegisterEntityType(&TimerTarget{})
+}
@@ -73,6 +73,15 @@ func (a *AppearBlock) Update() {
 
 func (a *AppearBlock) Touch(other *engine.Entity) {}
 
+func (a *AppearBlock) SaveSnapshot() interface{} {
+	return a.AnimFrame
+}
+
+func (a *AppearBlock) LoadSnapshot(snap interface{}) {
+	a.AnimFrame = snap.(int)
+	a.World.MutateContentsBool(a.Entity, level.PlayerSolidContents, a.AnimFrame >= AppearSolidThreshold)
+}
+
 func init() {
 	engine.RegisterEntityType(&AppearBlock{})
 }
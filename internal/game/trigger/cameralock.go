@@ -0,0 +1,100 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// CameraLock pans the camera to its center and holds it there while its state is on.
+// Typically switched on by a SetState trigger, e.g. to show a door opening elsewhere.
+type CameraLock struct {
+	mixins.Settable
+	World  *engine.World
+	Entity *engine.Entity
+
+	Damping    m.Fixed
+	LockFrames int
+
+	Frames int
+}
+
+func (c *CameraLock) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	c.World = w
+	c.Entity = e
+	var parseErr error
+	err := c.Settable.Init(sp)
+	if err != nil {
+		return err
+	}
+	c.Damping = m.NewFixedFloat64(propmap.ValueOrP(sp.Properties, "damping", 0.0, &parseErr))
+	lockTime := propmap.ValueOrP(sp.Properties, "lock_time", time.Duration(0), &parseErr)
+	c.LockFrames = int((lockTime*engine.GameTPS + (time.Second / 2)) / time.Second)
+	return parseErr
+}
+
+func (c *CameraLock) Despawn() {}
+
+func (c *CameraLock) SetState(originator, predecessor *engine.Entity, state bool) {
+	c.Settable.SetState(originator, predecessor, state)
+	c.Frames = 0
+}
+
+func (c *CameraLock) Update() {
+	if !c.State {
+		return
+	}
+	c.Frames++
+	if c.LockFrames > 0 && c.Frames > c.LockFrames {
+		// Release automatically after lock_time.
+		c.State = false
+		return
+	}
+	c.World.Camera.Lock(c.Entity.Rect.Center())
+	if c.Damping > 0 {
+		c.World.Camera.SetDamping(c.Damping)
+	}
+}
+
+func (c *CameraLock) Touch(other *engine.Entity) {}
+
+// cameraLockSnapshot is the transient lock state saved in snapshots.
+type cameraLockSnapshot struct {
+	Settable interface{}
+	Frames   int
+}
+
+func (c *CameraLock) SaveSnapshot() interface{} {
+	return cameraLockSnapshot{
+		Settable: c.Settable.SaveSnapshot(),
+		Frames:   c.Frames,
+	}
+}
+
+func (c *CameraLock) LoadSnapshot(snap interface{}) {
+	s := snap.(cameraLockSnapshot)
+	c.Settable.LoadSnapshot(s.Settable)
+	c.Frames = s.Frames
+}
+
+func init() {
+	engine.RegisterEntityType(&CameraLock{})
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// CameraRegion keeps the camera within its area while the player is inside.
+// Optionally it also changes how the camera follows the player.
+type CameraRegion struct {
+	mixins.NonSolidTouchable
+
+	LookaheadFrames int
+	Damping         m.Fixed
+}
+
+func (c *CameraRegion) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	c.NonSolidTouchable.Init(w, e)
+	var parseErr error
+	lookahead := propmap.ValueOrP(sp.Properties, "lookahead_time", time.Duration(0), &parseErr)
+	c.LookaheadFrames = int((lookahead*engine.GameTPS + (time.Second / 2)) / time.Second)
+	c.Damping = m.NewFixedFloat64(propmap.ValueOrP(sp.Properties, "damping", 0.0, &parseErr))
+	return parseErr
+}
+
+func (c *CameraRegion) Despawn() {}
+
+func (c *CameraRegion) Touch(other *engine.Entity) {
+	if other != c.World.Player {
+		return
+	}
+	c.World.Camera.SetRegion(c.Entity.Rect)
+	if c.LookaheadFrames > 0 {
+		c.World.Camera.SetLookahead(c.LookaheadFrames)
+	}
+	if c.Damping > 0 {
+		c.World.Camera.SetDamping(c.Damping)
+	}
+}
+
+func init() {
+	engine.RegisterEntityType(&CameraRegion{})
+}
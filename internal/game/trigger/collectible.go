@@ -0,0 +1,83 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"fmt"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/image"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+const (
+	collectibleFadeFrames = 16
+)
+
+// Collectible increments a named counter of the player when touched, and then disappears for good.
+type Collectible struct {
+	mixins.NonSolidTouchable
+	PersistentState propmap.Map
+
+	Counter   string
+	Target    mixins.TargetSelection
+	Collected bool
+	AnimFrame int
+}
+
+func (c *Collectible) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	c.NonSolidTouchable.Init(w, e)
+	c.PersistentState = sp.PersistentState
+	var parseErr error
+	c.Counter = propmap.ValueOrP(sp.Properties, "counter", "coins", &parseErr)
+	c.Target = mixins.ParseTarget(propmap.StringOr(sp.Properties, "target", ""))
+	c.Collected = propmap.ValueOrP(c.PersistentState, "collected", false, &parseErr)
+	var err error
+	e.Image, err = image.Load("sprites", propmap.ValueP(sp.Properties, "image", "", &parseErr))
+	if err != nil {
+		return fmt.Errorf("could not load collectible image: %w", err)
+	}
+	if c.Collected {
+		e.Alpha = 0
+	}
+	return parseErr
+}
+
+func (c *Collectible) Despawn() {}
+
+func (c *Collectible) Update() {
+	c.NonSolidTouchable.Update()
+	if c.Collected && c.AnimFrame > 0 {
+		c.AnimFrame--
+		c.Entity.Alpha = float64(c.AnimFrame) / collectibleFadeFrames
+	}
+}
+
+func (c *Collectible) Touch(other *engine.Entity) {
+	if other != c.World.Player || c.Collected {
+		return
+	}
+	c.Collected = true
+	c.AnimFrame = collectibleFadeFrames
+	propmap.Set(c.PersistentState, "collected", true)
+	c.World.PlayerState.AddCollectible(c.Counter)
+	mixins.SetStateOfTarget(c.World, other, c.Entity, c.Target, true)
+}
+
+func init() {
+	engine.RegisterEntityType(&Collectible{})
+}
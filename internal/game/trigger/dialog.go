@@ -0,0 +1,169 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"fmt"
+
+	"github.com/divVerent/aaaaxy/internal/dialog"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/fun"
+	"github.com/divVerent/aaaaxy/internal/game/interfaces"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/image"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// Dialog plays a scripted sequence of text boxes when touched by the player.
+// Player input is paused while the dialog runs.
+//
+// Lines are given as text_1, text_2, ... with optional speaker_N and portrait_N.
+type Dialog struct {
+	mixins.NonSolidTouchable
+	World           *engine.World
+	Entity          *engine.Entity
+	PersistentState propmap.Map
+
+	Lines   []dialog.Line
+	Options dialog.Options
+	Once    bool
+	Target  mixins.TargetSelection
+
+	Touching bool
+	Touched  bool
+	Frozen   bool
+
+	Dialog *dialog.Dialog
+}
+
+func (d *Dialog) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	d.NonSolidTouchable.Init(w, e)
+	d.World = w
+	d.Entity = e
+	d.PersistentState = sp.PersistentState
+	var parseErr error
+	for i := 1; ; i++ {
+		txt := propmap.StringOr(sp.Properties, fmt.Sprintf("text_%d", i), "")
+		if txt == "" {
+			break
+		}
+		line := dialog.Line{
+			Text: locale.L.Get(txt), // "Unsupported call" warning expected here.
+		}
+		if speaker := propmap.StringOr(sp.Properties, fmt.Sprintf("speaker_%d", i), ""); speaker != "" {
+			line.Speaker = locale.L.Get(speaker) // "Unsupported call" warning expected here.
+		}
+		if portrait := propmap.StringOr(sp.Properties, fmt.Sprintf("portrait_%d", i), ""); portrait != "" {
+			var err error
+			line.Portrait, err = image.Load("sprites", portrait)
+			if err != nil {
+				return fmt.Errorf("could not load dialog portrait %q: %w", portrait, err)
+			}
+		}
+		d.Lines = append(d.Lines, line)
+	}
+	if len(d.Lines) == 0 {
+		return fmt.Errorf("dialog entity %v has no text_1 property", e.Incarnation)
+	}
+	d.Options.AutoAdvance = propmap.ValueOrP(sp.Properties, "auto_advance", d.Options.AutoAdvance, &parseErr)
+	d.Options.Skippable = propmap.ValueOrP(sp.Properties, "skippable", true, &parseErr)
+	d.Once = propmap.ValueOrP(sp.Properties, "once", true, &parseErr)
+	d.Target = mixins.ParseTarget(propmap.StringOr(sp.Properties, "target", ""))
+	return parseErr
+}
+
+func (d *Dialog) Despawn() {
+	d.Dialog.Stop()
+	d.release()
+}
+
+// release gives control back to the player.
+func (d *Dialog) release() {
+	if !d.Frozen {
+		return
+	}
+	d.World.Player.Impl.(interfaces.Freezer).Unfreeze()
+	d.Frozen = false
+}
+
+func (d *Dialog) Touch(other *engine.Entity) {
+	if other != d.World.Player {
+		return
+	}
+	d.Touching = true
+	if d.Touched || d.Frozen || dialog.Current().Active() {
+		// Only start when entering, and never while another dialog runs.
+		return
+	}
+	if d.Once && propmap.ValueOrP(d.PersistentState, "seen", false, nil) {
+		return
+	}
+	propmap.Set(d.PersistentState, "seen", true)
+	err := d.World.Save()
+	if err != nil {
+		log.Errorf("could not save game: %v", err)
+		return
+	}
+	lines := make([]dialog.Line, len(d.Lines))
+	for i, l := range d.Lines {
+		l.Text = fun.FormatText(&d.World.PlayerState, l.Text)
+		lines[i] = l
+	}
+	d.Dialog = dialog.Start(lines, d.Options)
+	d.World.Player.Impl.(interfaces.Freezer).Freeze()
+	d.Frozen = true
+}
+
+func (d *Dialog) Update() {
+	d.NonSolidTouchable.Update()
+	// Wait for the keys to be released so the last key press does not make the player jump.
+	if d.Frozen && !d.Dialog.Active() && !input.Jump.Held && !input.Action.Held {
+		d.release()
+		mixins.SetStateOfTarget(d.World, d.World.Player, d.Entity, d.Target, true)
+	}
+	d.Touching, d.Touched = false, d.Touching
+}
+
+// dialogSnapshot is the transient trigger state saved in snapshots.
+type dialogSnapshot struct {
+	Touching bool
+	Touched  bool
+	Frozen   bool
+}
+
+func (d *Dialog) SaveSnapshot() interface{} {
+	return dialogSnapshot{
+		Touching: d.Touching,
+		Touched:  d.Touched,
+		Frozen:   d.Frozen,
+	}
+}
+
+// LoadSnapshot restores the trigger state.
+// The dialog itself is not restored; if it was running, the player is released on the next update.
+func (d *Dialog) LoadSnapshot(snap interface{}) {
+	s := snap.(dialogSnapshot)
+	d.Touching = s.Touching
+	d.Touched = s.Touched
+	d.Frozen = s.Frozen
+}
+
+func init() {
+	engine.RegisterEntityType(&Dialog{})
+}
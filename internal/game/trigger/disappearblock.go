@@ -76,6 +76,29 @@ func (a *DisappearBlock) Update() {
 
 func (a *DisappearBlock) Touch(other *engine.Entity) {}
 
+// disappearBlockSnapshot is the transient block state saved in snapshots.
+type disappearBlockSnapshot struct {
+	Settable     interface{}
+	Disappearing bool
+	AnimFrame    int
+}
+
+func (a *DisappearBlock) SaveSnapshot() interface{} {
+	return disappearBlockSnapshot{
+		Settable:     a.Settable.SaveSnapshot(),
+		Disappearing: a.Disappearing,
+		AnimFrame:    a.AnimFrame,
+	}
+}
+
+func (a *DisappearBlock) LoadSnapshot(snap interface{}) {
+	s := snap.(disappearBlockSnapshot)
+	a.Settable.LoadSnapshot(s.Settable)
+	a.Disappearing = s.Disappearing
+	a.AnimFrame = s.AnimFrame
+	a.World.MutateContentsBool(a.Entity, level.PlayerSolidContents, a.AnimFrame >= DisappearSolidThreshold)
+}
+
 func init() {
 	engine.RegisterEntityType(&DisappearBlock{})
 }
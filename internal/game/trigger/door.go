@@ -0,0 +1,113 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/centerprint"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/fun"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/image"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+const (
+	doorFadeFrames = 16
+)
+
+// Door is a solid block that opens for good when the player touches it while fulfilling its requirement.
+type Door struct {
+	World           *engine.World
+	Entity          *engine.Entity
+	PersistentState propmap.Map
+
+	Requires   mixins.Requirement
+	LockedText string
+	Target     mixins.TargetSelection
+
+	Open        bool
+	AnimFrame   int
+	Centerprint *centerprint.Centerprint
+}
+
+func (d *Door) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	d.World = w
+	d.Entity = e
+	d.PersistentState = sp.PersistentState
+	var parseErr error
+	var err error
+	d.Requires, err = mixins.ParseRequirement(propmap.StringOr(sp.Properties, "requires", ""))
+	if err != nil {
+		return err
+	}
+	d.LockedText = propmap.StringOr(sp.Properties, "locked_text", "")
+	d.Target = mixins.ParseTarget(propmap.StringOr(sp.Properties, "target", ""))
+	e.Image, err = image.Load("sprites", propmap.StringOr(sp.Properties, "image", "door.png"))
+	if err != nil {
+		return fmt.Errorf("could not load door image: %w", err)
+	}
+	e.ResizeImage = true
+	d.Open = propmap.ValueOrP(d.PersistentState, "open", false, &parseErr)
+	if d.Open {
+		e.Alpha = 0
+	} else {
+		w.SetSolid(e, true)
+		w.SetOpaque(e, true)
+	}
+	return parseErr
+}
+
+func (d *Door) Despawn() {
+	if d.Centerprint.Active() {
+		d.Centerprint.SetFadeOut(true)
+	}
+}
+
+func (d *Door) Update() {
+	if d.Open && d.AnimFrame > 0 {
+		d.AnimFrame--
+		d.Entity.Alpha = float64(d.AnimFrame) / doorFadeFrames
+	}
+}
+
+func (d *Door) Touch(other *engine.Entity) {
+	if other != d.World.Player || d.Open {
+		return
+	}
+	if !d.Requires.Met(&d.World.PlayerState) {
+		if d.LockedText != "" && !d.Centerprint.Active() {
+			d.Centerprint = centerprint.New(fun.FormatMarkup(&d.World.PlayerState, d.LockedText),
+				centerprint.Important, centerprint.Middle, centerprint.NormalFont(),
+				palette.EGA(palette.White, 255), time.Second)
+			d.Centerprint.SetFadeOut(true)
+		}
+		return
+	}
+	d.Open = true
+	d.AnimFrame = doorFadeFrames
+	propmap.Set(d.PersistentState, "open", true)
+	d.World.SetSolid(d.Entity, false)
+	d.World.SetOpaque(d.Entity, false)
+	mixins.SetStateOfTarget(d.World, other, d.Entity, d.Target, true)
+}
+
+func init() {
+	engine.RegisterEntityType(&Door{})
+}
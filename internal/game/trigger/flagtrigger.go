@@ -0,0 +1,76 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// FlagTrigger sets a world flag when touched by the player or turned on.
+// Together with FlagGate this allows puzzles spanning multiple rooms.
+type FlagTrigger struct {
+	World  *engine.World
+	Entity *engine.Entity
+	mixins.NonSolidTouchable
+
+	Flag       string
+	Value      string
+	OffValue   string
+	HasOff     bool
+	PlayerOnly bool
+}
+
+func (f *FlagTrigger) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	f.World = w
+	f.Entity = e
+	f.NonSolidTouchable.Init(w, e)
+	var parseErr error
+	f.Flag = propmap.ValueP(sp.Properties, "flag", "", &parseErr)
+	f.Value = propmap.StringOr(sp.Properties, "value", "true")
+	// Without off_value, turning this off keeps the flag; an empty off_value clears it.
+	var err error
+	f.OffValue, err = propmap.Value(sp.Properties, "off_value", "")
+	f.HasOff = err == nil
+	f.PlayerOnly = propmap.ValueOrP(sp.Properties, "player_only", true, &parseErr)
+	return parseErr
+}
+
+func (f *FlagTrigger) Despawn() {}
+
+func (f *FlagTrigger) Touch(other *engine.Entity) {
+	if f.PlayerOnly && other != f.World.Player {
+		return
+	}
+	f.SetState(other, other, true)
+}
+
+func (f *FlagTrigger) SetState(originator, predecessor *engine.Entity, state bool) {
+	if state {
+		engine.SetWorldFlag(f.World, f.Flag, f.Value)
+	} else if f.HasOff {
+		if f.OffValue == "" {
+			engine.ClearWorldFlag(f.World, f.Flag)
+		} else {
+			engine.SetWorldFlag(f.World, f.Flag, f.OffValue)
+		}
+	}
+}
+
+func init() {
+	engine.RegisterEntityType(&FlagTrigger{})
+}
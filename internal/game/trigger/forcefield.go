@@ -17,10 +17,10 @@ package trigger
 import (
 	"fmt"
 	go_image "image"
-	"math/rand"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
+	"github.com/divVerent/aaaaxy/internal/effects"
 	"github.com/divVerent/aaaaxy/internal/engine"
 	"github.com/divVerent/aaaaxy/internal/game/constants"
 	"github.com/divVerent/aaaaxy/internal/game/interfaces"
@@ -29,6 +29,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/level"
 	"github.com/divVerent/aaaaxy/internal/log"
 	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/rng"
 	"github.com/divVerent/aaaaxy/internal/sound"
 )
 
@@ -56,6 +57,8 @@ const (
 	ffAlphaMax         = 1.0
 )
 
+var ffRand = rng.Get("forcefield")
+
 func (f *ForceField) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
 	f.NonSolidTouchable.Init(w, e)
 	f.World = w
@@ -97,13 +100,14 @@ func (f *ForceField) Update() {
 	}
 
 	// Brownian motion.
-	f.AlphaMod = f.AlphaMod + (2*rand.Float64()-1)*ffAlphaBrownian
+	f.AlphaMod = f.AlphaMod + (2*ffRand.Float64()-1)*ffAlphaBrownian
 	if f.AlphaMod < ffAlphaMin {
 		f.AlphaMod = ffAlphaMin
 	}
 	if f.AlphaMod > ffAlphaMax {
 		f.AlphaMod = ffAlphaMax
 	}
+	alphaMod := effects.Attenuate(f.AlphaMod, (ffAlphaMin+ffAlphaMax)/2)
 
 	if active {
 		f.AnimFrame++
@@ -114,11 +118,11 @@ func (f *ForceField) Update() {
 		f.Entity.Alpha = 0
 		f.AnimFrame = 0
 	} else if f.AnimFrame >= ffFadeFrames {
-		f.Entity.Alpha = f.AlphaMod
+		f.Entity.Alpha = alphaMod
 		f.AnimFrame = ffFadeFrames
 	} else {
 		alpha := float64(f.AnimFrame) / float64(ffFadeFrames)
-		f.Entity.Alpha = f.AlphaMod * alpha
+		f.Entity.Alpha = alphaMod * alpha
 	}
 	f.Active = f.AnimFrame >= ffActiveThreshold
 	f.World.SetSolid(f.Entity, f.Active)
@@ -130,7 +134,11 @@ func (f *ForceField) Update() {
 	if f.Entity.Orientation.Right.DX == 0 {
 		wantW, wantH = wantH, wantW
 	}
-	xOffset, yOffset := rand.Intn(got.X-wantW+1), rand.Intn(got.Y-wantH+1)
+	xOffset, yOffset := ffRand.Intn(got.X-wantW+1), ffRand.Intn(got.Y-wantH+1)
+	if effects.Reduced() {
+		// Keep the texture still instead of strobing.
+		xOffset, yOffset = 0, 0
+	}
 	f.Entity.Image = f.SourceImg.SubImage(go_image.Rectangle{
 		Min: go_image.Point{
 			X: xOffset,
@@ -140,7 +148,7 @@ func (f *ForceField) Update() {
 			X: xOffset + wantW,
 			Y: yOffset + wantH,
 		},
-	}).(*ebiten.Image)
+	}.Add(f.SourceImg.Bounds().Min)).(*ebiten.Image)
 
 	// Regular updating.
 	f.NonSolidTouchable.Update()
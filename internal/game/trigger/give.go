@@ -46,7 +46,8 @@ func (g *Give) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity
 	var parseErr error
 	g.Ability = propmap.ValueP(sp.Properties, "ability", "", &parseErr)
 	g.Text = propmap.ValueP(sp.Properties, "text", "", &parseErr)
-	err := g.Anim.Init("can_"+g.Ability, map[string]*animation.Group{
+	sprite := propmap.StringOr(sp.Properties, "sprite", "can_"+g.Ability)
+	err := g.Anim.Init(sprite, map[string]*animation.Group{
 		"default": {
 			Frames:        30,
 			Symmetric:     true,
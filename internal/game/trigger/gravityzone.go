@@ -0,0 +1,76 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/interfaces"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// GravityZone changes the player's gravity while inside.
+// Gravity points in the Down direction of the zone's orientation, and walking right goes in its Right direction.
+// For example, orientation EN allows walking on the ceiling, and NE on a wall to the right.
+type GravityZone struct {
+	mixins.NonSolidTouchable
+
+	RestoreOnExit bool
+
+	Inside      bool
+	Touched     bool
+	PrevGravity m.Orientation
+}
+
+func (g *GravityZone) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	g.NonSolidTouchable.Init(w, e)
+	var parseErr error
+	g.RestoreOnExit = propmap.ValueOrP(sp.Properties, "restore_on_exit", true, &parseErr)
+	return parseErr
+}
+
+func (g *GravityZone) Despawn() {}
+
+func (g *GravityZone) Update() {
+	g.Touched = false
+	g.NonSolidTouchable.Update()
+	if g.Inside && !g.Touched {
+		g.Inside = false
+		if g.RestoreOnExit {
+			g.World.Player.Impl.(interfaces.Gravityer).SetGravity(g.PrevGravity)
+		}
+	}
+}
+
+func (g *GravityZone) Touch(other *engine.Entity) {
+	if other != g.World.Player {
+		return
+	}
+	g.Touched = true
+	if g.Inside {
+		// Only act when entering, so VVVVVV style gravity flips keep working inside.
+		return
+	}
+	g.Inside = true
+	p := other.Impl.(interfaces.Gravityer)
+	g.PrevGravity = p.ReadGravity()
+	p.SetGravity(g.Entity.Orientation)
+}
+
+func init() {
+	engine.RegisterEntityType(&GravityZone{})
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"fmt"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/image"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// HealthPickup restores hit points of the player when touched.
+// Only has an effect if the map enables hit points.
+type HealthPickup struct {
+	mixins.NonSolidTouchable
+	PersistentState propmap.Map
+
+	Heal     int
+	Respawns bool
+	Used     bool
+}
+
+func (h *HealthPickup) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	h.NonSolidTouchable.Init(w, e)
+	h.PersistentState = sp.PersistentState
+	var parseErr error
+	h.Heal = propmap.ValueOrP(sp.Properties, "heal", 1, &parseErr)
+	h.Respawns = propmap.ValueOrP(sp.Properties, "respawns", false, &parseErr)
+	h.Used = !h.Respawns && propmap.ValueOrP(h.PersistentState, "used", false, &parseErr)
+	if h.Used || w.PlayerState.MaxHP() <= 0 {
+		e.Alpha = 0
+		return parseErr
+	}
+	var err error
+	e.Image, err = image.Load("sprites", propmap.ValueP(sp.Properties, "image", "", &parseErr))
+	if err != nil {
+		return fmt.Errorf("could not load health pickup image: %w", err)
+	}
+	return parseErr
+}
+
+func (h *HealthPickup) Despawn() {}
+
+func (h *HealthPickup) Touch(other *engine.Entity) {
+	if other != h.World.Player || h.Used || h.Entity.Alpha == 0 {
+		return
+	}
+	if !h.World.PlayerState.Heal(h.Heal) {
+		return
+	}
+	h.Used = true
+	h.Entity.Alpha = 0
+	if !h.Respawns {
+		propmap.Set(h.PersistentState, "used", true)
+	}
+}
+
+func init() {
+	engine.RegisterEntityType(&HealthPickup{})
+}
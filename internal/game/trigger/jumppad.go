@@ -16,7 +16,7 @@ package trigger
 
 import (
 	"fmt"
-	"math"
+	"math/big"
 
 	"github.com/divVerent/aaaaxy/internal/engine"
 	"github.com/divVerent/aaaaxy/internal/game/constants"
@@ -91,37 +91,92 @@ func calculateJump(delta m.Delta, heightParam int) m.Delta {
 	if targetHigher {
 		height += delta.DY
 	}
+	// All of this is computed using integers, so jumps come out the same on all
+	// platforms. The results match the floating point math this used to do
+	// exactly, so recorded demos keep working.
 	// Requirements:
 	// - vDY * tA + 1/2 * playerGravity * tA^2 = height * SubpixelScale
 	//   -> vDY = -sqrt(-2 * height * playerGravity * SubpixelScale)
 	// - vDY + playerGravity * tA = 0
 	//   -> tA = -vDY / playerGravity
-	vDY := -int(math.Sqrt(2 * float64(-height) * float64(constants.Gravity) * float64(constants.SubPixelScale)))
+	vDY := -isqrt(big.NewInt(2 * int64(-height) * constants.Gravity * constants.SubPixelScale)).Int64()
 	// Actually move downwards if requested!
+	sign := 1
 	if apexOutside && !targetHigher {
 		vDY = -vDY
+		sign = -1
 	}
 	// Finally:
 	// - vDY * t + 1/2 * playerGravity * t^2 = deltaDY * SubpixelScale
-	// - vDX * t = deltaDX
-	a := 0.5 * constants.Gravity
-	b := float64(vDY)
-	c := -float64(delta.DY) * constants.SubPixelScale
-	u := -b / (2 * a)
-	d := b*b - 4*a*c
-	v := 0.0
-	if d >= 0 {
+	//   -> t = (-vDY + sign * sqrt(d)) / playerGravity
+	//   with d = vDY^2 + 2 * playerGravity * deltaDY * SubpixelScale
+	// - vDX * t = deltaDX * SubpixelScale
+	//   -> vDX = deltaDX * SubpixelScale * playerGravity / (-vDY + sign * sqrt(d))
+	d := vDY*vDY + 2*constants.Gravity*constants.SubPixelScale*int64(delta.DY)
+	if delta.DY == 0 || d <= 0 {
+		// Here sqrt(d) is an integer, so a plain division does.
 		// Mathematically, D < 0 means the jump is impossible.
 		// However usually it just implies a roundoff error,
 		// especially when height==0. So let's just allow it.
-		v = math.Sqrt(d) / (2 * a)
+		t := -vDY
+		if d > 0 {
+			t += int64(sign) * isqrt(big.NewInt(d)).Int64()
+		}
+		if t == 0 {
+			// Degenerate jump that lands right away; no horizontal movement possible.
+			return m.Delta{DX: 0, DY: int(vDY)}
+		}
+		vDX := int64(delta.DX) * constants.SubPixelScale * constants.Gravity / t
+		return m.Delta{DX: int(vDX), DY: int(vDY)}
 	}
-	if apexOutside && !targetHigher {
-		v = -v
+	// Otherwise multiply numerator and denominator by (-vDY - sign * sqrt(d)):
+	// -> vDX = deltaDX * (vDY + sign * sqrt(d)) / (2 * deltaDY)
+	//        = (deltaDX * vDY + sign' * sqrt(deltaDX^2 * d)) / (2 * deltaDY)
+	if delta.DX < 0 {
+		sign = -sign
 	}
-	t := u + v
-	vDX := int(float64(delta.DX) * constants.SubPixelScale / t)
-	return m.Delta{DX: vDX, DY: vDY}
+	dx := big.NewInt(int64(delta.DX))
+	a := new(big.Int).Mul(dx, big.NewInt(vDY))
+	b := new(big.Int).Mul(new(big.Int).Mul(dx, dx), big.NewInt(d))
+	vDX := truncDivSqrt(a, sign, b, 2*int64(delta.DY))
+	return m.Delta{DX: int(vDX), DY: int(vDY)}
+}
+
+// isqrt returns floor(sqrt(n)).
+func isqrt(n *big.Int) *big.Int {
+	return new(big.Int).Sqrt(n)
+}
+
+// truncDivSqrt returns (a + sign * sqrt(b)) / c rounded towards zero.
+func truncDivSqrt(a *big.Int, sign int, b *big.Int, c int64) int64 {
+	r := isqrt(b)
+	inexact := new(big.Int).Mul(r, r).Cmp(b) != 0
+	// Bracket x = a + sign * sqrt(b) by floor(x) and ceil(x).
+	lo, hi := new(big.Int), new(big.Int)
+	if sign > 0 {
+		lo.Add(a, r)
+		hi.Set(lo)
+		if inexact {
+			hi.Add(hi, big.NewInt(1))
+		}
+	} else {
+		hi.Sub(a, r)
+		lo.Set(hi)
+		if inexact {
+			lo.Sub(lo, big.NewInt(1))
+		}
+	}
+	if c < 0 {
+		lo, hi = hi.Neg(hi), lo.Neg(lo)
+		c = -c
+	}
+	// With c > 0, floor(x / c) == floor(floor(x) / c) and ceil(x / c) == ceil(ceil(x) / c).
+	// big.Int.Div rounds down for positive divisors.
+	q := new(big.Int)
+	if lo.Sign() >= 0 {
+		return q.Div(lo, big.NewInt(c)).Int64()
+	}
+	return -q.Div(hi.Neg(hi), big.NewInt(c)).Int64()
 }
 
 func (j *JumpPad) Touch(other *engine.Entity) {
@@ -165,6 +220,14 @@ func (j *JumpPad) Touch(other *engine.Entity) {
 	j.JumpSound.Play()
 }
 
+func (j *JumpPad) SaveSnapshot() interface{} {
+	return j.TouchedFrame
+}
+
+func (j *JumpPad) LoadSnapshot(snap interface{}) {
+	j.TouchedFrame = snap.(int)
+}
+
 func init() {
 	engine.RegisterEntityType(&JumpPad{})
 }
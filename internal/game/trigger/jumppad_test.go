@@ -0,0 +1,97 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/game/constants"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// calculateJumpFloat is the floating point jump computation calculateJump
+// replaced. Recorded demos depend on its exact results.
+func calculateJumpFloat(delta m.Delta, heightParam int) m.Delta {
+	apexOutside := heightParam < 0
+	var height int
+	if apexOutside {
+		height = heightParam
+	} else {
+		height = -heightParam
+	}
+	targetHigher := delta.DY < 0
+	if targetHigher {
+		height += delta.DY
+	}
+	vDY := -int(math.Sqrt(2 * float64(-height) * float64(constants.Gravity) * float64(constants.SubPixelScale)))
+	if apexOutside && !targetHigher {
+		vDY = -vDY
+	}
+	a := 0.5 * constants.Gravity
+	b := float64(vDY)
+	c := -float64(delta.DY) * constants.SubPixelScale
+	u := -b / (2 * a)
+	d := b*b - 4*a*c
+	v := 0.0
+	if d >= 0 {
+		v = math.Sqrt(d) / (2 * a)
+	}
+	if apexOutside && !targetHigher {
+		v = -v
+	}
+	t := u + v
+	vDX := int(float64(delta.DX) * constants.SubPixelScale / t)
+	return m.Delta{DX: vDX, DY: vDY}
+}
+
+func TestCalculateJump(t *testing.T) {
+	for _, tc := range []struct {
+		Height         int
+		MinDY, MaxDY   int
+		MinDX, MaxDX   int
+		StepDY, StepDX int
+	}{
+		{Height: 0, MinDY: -400, MaxDY: 400, MinDX: -600, MaxDX: 600, StepDY: 1, StepDX: 7},
+		{Height: 8, MinDY: -400, MaxDY: 400, MinDX: -600, MaxDX: 600, StepDY: 1, StepDX: 7},
+		{Height: 40, MinDY: -400, MaxDY: 400, MinDX: -600, MaxDX: 600, StepDY: 1, StepDX: 7},
+		{Height: 64, MinDY: -400, MaxDY: 400, MinDX: -600, MaxDX: 600, StepDY: 1, StepDX: 7},
+		{Height: 128, MinDY: -400, MaxDY: 400, MinDX: -600, MaxDX: 600, StepDY: 1, StepDX: 7},
+		{Height: 304, MinDY: -400, MaxDY: 400, MinDX: -600, MaxDX: 600, StepDY: 1, StepDX: 7},
+		{Height: -8, MinDY: -400, MaxDY: 400, MinDX: -600, MaxDX: 600, StepDY: 1, StepDX: 7},
+		{Height: -64, MinDY: -400, MaxDY: 400, MinDX: -600, MaxDX: 600, StepDY: 1, StepDX: 7},
+		{Height: -304, MinDY: -400, MaxDY: 400, MinDX: -600, MaxDX: 600, StepDY: 1, StepDX: 7},
+		{Height: 1000, MinDY: -2000, MaxDY: 2000, MinDX: -3000, MaxDX: 3000, StepDY: 37, StepDX: 41},
+		{Height: -1000, MinDY: -2000, MaxDY: 2000, MinDX: -3000, MaxDX: 3000, StepDY: 37, StepDX: 41},
+	} {
+		t.Run(fmt.Sprintf("%+v", tc), func(t *testing.T) {
+			for dy := tc.MinDY; dy <= tc.MaxDY; dy += tc.StepDY {
+				for dx := tc.MinDX; dx <= tc.MaxDX; dx += tc.StepDX {
+					if tc.Height == 0 && dy == 0 {
+						// The floating point version divides by zero here.
+						continue
+					}
+					delta := m.Delta{DX: dx, DY: dy}
+					got := calculateJump(delta, tc.Height)
+					want := calculateJumpFloat(delta, tc.Height)
+					if got != want {
+						t.Errorf("calculateJump(%v, %v): got %v, want %v", delta, tc.Height, got, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ package trigger
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
@@ -30,7 +31,9 @@ import (
 	"github.com/divVerent/aaaaxy/internal/sound"
 )
 
-// QuestionBlock is a simple entity type that renders a static sprite. It can be optionally solid and/or opaque.
+// QuestionBlock is a block the player can hit from below.
+// When hit, it can spawn an entity above itself; spawn_type names the entity type,
+// and all properties prefixed with "spawn." are passed to it without the prefix.
 type QuestionBlock struct {
 	World           *engine.World
 	Entity          *engine.Entity
@@ -39,16 +42,27 @@ type QuestionBlock struct {
 	Kaizo  bool
 	Target mixins.TargetSelection
 
+	Content     *level.SpawnableProps
+	ContentSize m.Delta
+
 	Used         bool
 	UsedImage    *ebiten.Image
 	UseAnimFrame int
+	Popping      []poppingContent
 
 	Sound *sound.Sound
 }
 
+// poppingContent is content still rising out of the block.
+type poppingContent struct {
+	Entity     *engine.Entity
+	PixelsLeft int
+}
+
 const (
 	UseFramesPerPixel = 2
 	UsePixels         = 4
+	PopPixelsPerFrame = 2
 )
 
 func (q *QuestionBlock) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
@@ -64,6 +78,22 @@ func (q *QuestionBlock) Spawn(w *engine.World, sp *level.SpawnableProps, e *engi
 	q.Kaizo = propmap.ValueOrP(sp.Properties, "kaizo", false, &parseErr)
 	q.Target = mixins.ParseTarget(propmap.StringOr(sp.Properties, "target", ""))
 	q.Used = propmap.ValueOrP(q.PersistentState, "used", false, &parseErr)
+	if contentType := propmap.StringOr(sp.Properties, "spawn_type", ""); contentType != "" {
+		properties := propmap.New()
+		propmap.ForEach(sp.Properties, func(k, v string) error {
+			if name, found := strings.CutPrefix(k, "spawn."); found {
+				propmap.Set(properties, name, v)
+			}
+			return nil
+		})
+		q.Content = &level.SpawnableProps{
+			EntityType:      contentType,
+			Orientation:     m.Identity(),
+			Properties:      properties,
+			PersistentState: propmap.New(),
+		}
+		q.ContentSize = propmap.ValueOrP(sp.Properties, "spawn_size", e.Rect.Size, &parseErr)
+	}
 	q.UsedImage, err = image.Load("sprites", "exclamationblock.png")
 	if err != nil {
 		return err
@@ -99,6 +129,7 @@ func (q *QuestionBlock) isAboveFlying(other *engine.Entity) bool {
 }
 
 func (q *QuestionBlock) Update() {
+	q.updatePopping()
 	if q.Used {
 		if q.UseAnimFrame < UseFramesPerPixel*UsePixels {
 			q.UseAnimFrame++
@@ -168,6 +199,52 @@ func (q *QuestionBlock) Touch(other *engine.Entity) {
 	if err != nil {
 		log.Errorf("could not spawn question block effect: %v", err)
 	}
+
+	q.spawnContent()
+}
+
+// spawnContent spawns the configured content right above the block.
+func (q *QuestionBlock) spawnContent() {
+	if q.Content == nil {
+		return
+	}
+	// Center the content horizontally on the block.
+	rect := m.Rect{
+		Origin: q.Entity.Rect.Origin.Add(m.Delta{
+			DX: (q.Entity.Rect.Size.DX - q.ContentSize.DX) / 2,
+			DY: -q.ContentSize.DY,
+		}),
+		Size: q.ContentSize,
+	}
+	e, err := q.World.SpawnDetached(q.Content, rect, m.Identity(), q.Entity)
+	if err != nil {
+		log.Errorf("could not spawn question block content: %v", err)
+		return
+	}
+	// Pop out of the block.
+	e.RenderOffset = e.RenderOffset.Add(m.Delta{DX: 0, DY: q.ContentSize.DY})
+	q.Popping = append(q.Popping, poppingContent{
+		Entity:     e,
+		PixelsLeft: q.ContentSize.DY,
+	})
+}
+
+// updatePopping moves spawned content out of the block.
+func (q *QuestionBlock) updatePopping() {
+	j := 0
+	for _, p := range q.Popping {
+		n := PopPixelsPerFrame
+		if n > p.PixelsLeft {
+			n = p.PixelsLeft
+		}
+		p.Entity.RenderOffset.DY -= n
+		p.PixelsLeft -= n
+		if p.PixelsLeft > 0 {
+			q.Popping[j] = p
+			j++
+		}
+	}
+	q.Popping = q.Popping[:j]
 }
 
 func init() {
@@ -25,6 +25,7 @@ import (
 )
 
 // RespawnPlayer respawns the player when touched.
+// If the map enables hit points, it only deducts one.
 type RespawnPlayer struct {
 	World *engine.World
 }
@@ -57,6 +58,9 @@ func (r *RespawnPlayer) Touch(other *engine.Entity) {
 	if other != r.World.Player {
 		return
 	}
+	if !r.World.PlayerState.Damage(1) {
+		return
+	}
 	r.World.RespawnPlayer(r.World.PlayerState.LastCheckpoint(), false)
 }
 
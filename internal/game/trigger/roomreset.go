@@ -0,0 +1,84 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// RoomReset respawns the targeted entities in their initial state whenever the player enters it.
+// This puts moving sprites back to their spawn position and unlatches switches.
+// Dying resets them too, as respawning the player rebuilds the world anyway.
+type RoomReset struct {
+	mixins.NonSolidTouchable
+
+	Target mixins.TargetSelection
+
+	Inside  bool
+	Touched bool
+}
+
+func (r *RoomReset) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	r.NonSolidTouchable.Init(w, e)
+	var parseErr error
+	r.Target = mixins.ParseTarget(propmap.ValueP(sp.Properties, "target", "", &parseErr))
+	return parseErr
+}
+
+func (r *RoomReset) Despawn() {}
+
+func (r *RoomReset) Update() {
+	r.Touched = false
+	r.NonSolidTouchable.Update()
+	if !r.Touched {
+		r.Inside = false
+	}
+}
+
+func (r *RoomReset) Touch(other *engine.Entity) {
+	if other != r.World.Player {
+		return
+	}
+	r.Touched = true
+	if r.Inside {
+		return
+	}
+	r.Inside = true
+	r.reset()
+}
+
+// reset respawns all targeted entities.
+func (r *RoomReset) reset() {
+	var ids []level.EntityID
+	for _, target := range r.Target {
+		for _, ent := range r.World.FindName(target) {
+			if ent == r.Entity || !ent.Incarnation.IsValid() {
+				continue
+			}
+			ids = append(ids, ent.Incarnation.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	r.World.RespawnByID(ids...)
+}
+
+func init() {
+	engine.RegisterEntityType(&RoomReset{})
+}
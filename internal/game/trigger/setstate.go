@@ -96,6 +96,38 @@ func (s *SetState) Touch(other *engine.Entity) {
 	s.Touching = true
 }
 
+// setStateSnapshot is the transient trigger state saved in snapshots.
+type setStateSnapshot struct {
+	Touching           bool
+	Touched            bool
+	State              bool
+	OriginatorIsPlayer bool
+}
+
+func (s *SetState) SaveSnapshot() interface{} {
+	return setStateSnapshot{
+		Touching:           s.Touching,
+		Touched:            s.Touched,
+		State:              s.State,
+		OriginatorIsPlayer: s.Originator == s.World.Player,
+	}
+}
+
+// LoadSnapshot restores the trigger state.
+// Entity references do not survive snapshots, so an originator other than the player is replaced by the trigger itself.
+func (s *SetState) LoadSnapshot(snap interface{}) {
+	ss := snap.(setStateSnapshot)
+	s.Touching = ss.Touching
+	s.Touched = ss.Touched
+	s.State = ss.State
+	s.Originator = nil
+	if ss.OriginatorIsPlayer {
+		s.Originator = s.World.Player
+	} else if ss.State {
+		s.Originator = s.Entity
+	}
+}
+
 func init() {
 	engine.RegisterEntityType(&SetState{})
 }
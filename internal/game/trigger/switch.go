@@ -94,6 +94,30 @@ func (s *Switch) Update() {
 	s.Anim.Update(s.Entity)
 }
 
+// switchSnapshot is the transient switch state saved in snapshots.
+type switchSnapshot struct {
+	SetState  interface{}
+	AnimState bool
+}
+
+func (s *Switch) SaveSnapshot() interface{} {
+	return switchSnapshot{
+		SetState:  s.SetState.SaveSnapshot(),
+		AnimState: s.AnimState,
+	}
+}
+
+func (s *Switch) LoadSnapshot(snap interface{}) {
+	ss := snap.(switchSnapshot)
+	s.SetState.LoadSnapshot(ss.SetState)
+	s.AnimState = ss.AnimState
+	if s.AnimState {
+		s.Anim.ForceGroup("on")
+	} else {
+		s.Anim.ForceGroup("off")
+	}
+}
+
 func init() {
 	engine.RegisterEntityType(&Switch{})
 }
@@ -41,6 +41,25 @@ func (j *SwitchableJumpPad) Touch(other *engine.Entity) {
 	j.JumpPad.Touch(other)
 }
 
+// switchableJumpPadSnapshot is the transient jump pad state saved in snapshots.
+type switchableJumpPadSnapshot struct {
+	Settable interface{}
+	JumpPad  interface{}
+}
+
+func (j *SwitchableJumpPad) SaveSnapshot() interface{} {
+	return switchableJumpPadSnapshot{
+		Settable: j.Settable.SaveSnapshot(),
+		JumpPad:  j.JumpPad.SaveSnapshot(),
+	}
+}
+
+func (j *SwitchableJumpPad) LoadSnapshot(snap interface{}) {
+	s := snap.(switchableJumpPadSnapshot)
+	j.Settable.LoadSnapshot(s.Settable)
+	j.JumpPad.LoadSnapshot(s.JumpPad)
+}
+
 func init() {
 	engine.RegisterEntityType(&SwitchableJumpPad{})
 }
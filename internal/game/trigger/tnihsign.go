@@ -70,9 +70,9 @@ func (t *TnihSign) Precache(sp *level.Spawnable) error {
 		return nil
 	}
 	bounds := centerprint.NormalFont().BoundString(txt)
-	if bounds.Size.DX > engine.GameWidth {
+	if bounds.Size.DX > engine.DefaultGameWidth {
 		locale.Errorf("text too big: entity %v must fit in width %v but text needs %v: %v",
-			sp.ID, engine.GameWidth, bounds.Size, txtOrig)
+			sp.ID, engine.DefaultGameWidth, bounds.Size, txtOrig)
 	}
 	return nil
 }
@@ -162,7 +162,7 @@ func (t *TnihSign) Touch(other *engine.Entity) {
 			}
 			t.Sound.Play()
 		}
-		t.Centerprint = centerprint.New(fun.FormatText(&t.World.PlayerState, t.Text), importance, centerprint.Top, centerprint.NormalFont(), palette.EGA(palette.Yellow, 255), 2*time.Second)
+		t.Centerprint = centerprint.New(fun.FormatMarkup(&t.World.PlayerState, t.Text), importance, centerprint.Top, centerprint.NormalFont(), palette.EGA(palette.Yellow, 255), 2*time.Second)
 		t.Entity.Image = t.SeenImage
 		mixins.SetStateOfTarget(t.World, other, t.Entity, t.Target, true)
 	}
@@ -0,0 +1,115 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"fmt"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/sound"
+)
+
+// WarpSwitch opens or closes warpzones when the player enters it.
+// Unlike Switch, the warpzone state is saved and survives respawning.
+type WarpSwitch struct {
+	World  *engine.World
+	Entity *engine.Entity
+	mixins.NonSolidTouchable
+
+	WarpZones mixins.TargetSelection
+	SetTo     propmap.TriState // If not set, every touch toggles the warpzones.
+
+	Touching bool
+	Touched  bool
+
+	SwitchOn, SwitchOff *sound.Sound
+}
+
+func (s *WarpSwitch) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	s.World = w
+	s.Entity = e
+	s.NonSolidTouchable.Init(w, e)
+	var parseErr error
+	for _, name := range mixins.ParseTarget(propmap.StringOr(sp.Properties, "warpzone", "")) {
+		if name != "" && name != "!" {
+			s.WarpZones = append(s.WarpZones, name)
+		}
+	}
+	s.SetTo = propmap.ValueOrP(sp.Properties, "set_to", propmap.TriState{}, &parseErr)
+	if parseErr != nil {
+		return parseErr
+	}
+	var err error
+	s.SwitchOn, err = sound.Load("switch_on.ogg")
+	if err != nil {
+		return fmt.Errorf("could not load switch_on sound: %w", err)
+	}
+	s.SwitchOff, err = sound.Load("switch_off.ogg")
+	if err != nil {
+		return fmt.Errorf("could not load switch_off sound: %w", err)
+	}
+	return nil
+}
+
+func (s *WarpSwitch) Despawn() {}
+
+func (s *WarpSwitch) Update() {
+	s.NonSolidTouchable.Update()
+	s.Touching, s.Touched = false, s.Touching
+}
+
+func (s *WarpSwitch) Touch(other *engine.Entity) {
+	if other != s.World.Player {
+		return
+	}
+	s.Touching = true
+	if s.Touched {
+		// Only switch when entering.
+		return
+	}
+	if len(s.WarpZones) == 0 {
+		return
+	}
+	// The first warpzone decides the new state, others follow; a ! prefix inverts.
+	state := s.SetTo.Value
+	if !s.SetTo.Active {
+		first := s.WarpZones[0]
+		if first[0] == '!' {
+			state = s.World.WarpZoneState(first[1:])
+		} else {
+			state = !s.World.WarpZoneState(first)
+		}
+	}
+	for _, name := range s.WarpZones {
+		thisState := state
+		if name[0] == '!' {
+			thisState = !state
+			name = name[1:]
+		}
+		s.World.EnableWarpZone(name, thisState)
+	}
+	if state {
+		s.SwitchOn.Play()
+	} else {
+		s.SwitchOff.Play()
+	}
+}
+
+func init() {
+	engine.RegisterEntityType(&WarpSwitch{})
+}
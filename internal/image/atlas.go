@@ -0,0 +1,159 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+var (
+	imageAtlas = flag.Bool("image_atlas", true, "pack precached images into large atlas textures (reduces texture switches on weak GPUs)")
+)
+
+const (
+	// atlasSize is the width and maximum height of an atlas page.
+	atlasSize = 2048
+	// atlasMaxItemSize is the largest width or height of an image to put in the atlas.
+	// Larger images gain little from atlasing and would waste space.
+	atlasMaxItemSize = 256
+	// atlasPadding is the amount of transparent pixels between images to prevent bleeding.
+	atlasPadding = 1
+)
+
+// atlasItem is a single image to be packed into an atlas.
+type atlasItem struct {
+	path imagePath
+	img  image.Image
+	page int
+	rect image.Rectangle
+}
+
+var (
+	// atlasBuilt is set once the cache contents come from atlases.
+	atlasBuilt bool
+	// atlasPages maps each atlased image to the atlas page containing it.
+	atlasPages = map[*ebiten.Image]*ebiten.Image{}
+)
+
+// AtlasPage returns the atlas page the given image is part of.
+// Source coordinates within the page are given by img.Bounds().
+// Images not in an atlas are returned unchanged.
+func AtlasPage(img *ebiten.Image) *ebiten.Image {
+	if page, found := atlasPages[img]; found {
+		return page
+	}
+	return img
+}
+
+// deallocateAtlas frees the atlas pages and the other images created by buildAtlas.
+// The cache must be rebuilt before it is used again.
+func deallocateAtlas() {
+	deallocated := map[*ebiten.Image]struct{}{}
+	for _, img := range cache {
+		page := AtlasPage(img)
+		if _, found := deallocated[page]; found {
+			continue
+		}
+		page.Deallocate()
+		deallocated[page] = struct{}{}
+	}
+}
+
+// buildAtlas loads all given images, packs them into atlas pages and stores
+// sub-images of the pages in the cache.
+func buildAtlas(paths []imagePath) error {
+	items := make([]*atlasItem, 0, len(paths))
+	for _, ip := range paths {
+		img, err := decode(palette.Current(), ip.Purpose, ip.Name)
+		if err != nil {
+			return fmt.Errorf("could not precache %v: %w", ip, err)
+		}
+		sz := img.Bounds().Size()
+		if sz.X > atlasMaxItemSize || sz.Y > atlasMaxItemSize {
+			eImg := ebiten.NewImageFromImage(img)
+			if eImg.Bounds().Min != (image.Point{}) {
+				return fmt.Errorf("could not get zero origin: %v", eImg.Bounds())
+			}
+			cache[ip] = eImg
+			continue
+		}
+		items = append(items, &atlasItem{path: ip, img: img})
+	}
+
+	// Shelf packing works best with images sorted by height.
+	sort.SliceStable(items, func(i, j int) bool {
+		si, sj := items[i].img.Bounds().Size(), items[j].img.Bounds().Size()
+		if si.Y != sj.Y {
+			return si.Y > sj.Y
+		}
+		return si.X > sj.X
+	})
+	var pageHeights []int
+	x, y, shelfHeight := 0, 0, 0
+	for _, item := range items {
+		sz := item.img.Bounds().Size()
+		w, h := sz.X+atlasPadding, sz.Y+atlasPadding
+		if x+w > atlasSize {
+			x, y, shelfHeight = 0, y+shelfHeight, 0
+		}
+		if len(pageHeights) == 0 || y+h > atlasSize {
+			pageHeights = append(pageHeights, 0)
+			x, y, shelfHeight = 0, 0, 0
+		}
+		item.page = len(pageHeights) - 1
+		item.rect = image.Rectangle{
+			Min: image.Point{X: x, Y: y},
+			Max: image.Point{X: x + sz.X, Y: y + sz.Y},
+		}
+		x += w
+		if h > shelfHeight {
+			shelfHeight = h
+		}
+		if y+h > pageHeights[item.page] {
+			pageHeights[item.page] = y + h
+		}
+	}
+
+	// Compose the pages.
+	pages := make([]*image.NRGBA, len(pageHeights))
+	for i, h := range pageHeights {
+		pages[i] = image.NewNRGBA(image.Rect(0, 0, atlasSize, h))
+	}
+	for _, item := range items {
+		draw.Draw(pages[item.page], item.rect, item.img, item.img.Bounds().Min, draw.Src)
+	}
+	ePages := make([]*ebiten.Image, len(pages))
+	for i, page := range pages {
+		ePages[i] = ebiten.NewImageFromImage(page)
+	}
+	atlasPages = map[*ebiten.Image]*ebiten.Image{}
+	for _, item := range items {
+		img := ePages[item.page].SubImage(item.rect).(*ebiten.Image)
+		cache[item.path] = img
+		atlasPages[img] = ePages[item.page]
+	}
+	log.Infof("packed %d images into %d atlas pages", len(items), len(pages))
+	atlasBuilt = true
+	return nil
+}
@@ -47,15 +47,14 @@ var (
 	noPaletteSprites = regexp.MustCompile(`^(?:warpzone|clock|gradient|magic)_.*`)
 )
 
-func load(purpose, name string, force bool) (*ebiten.Image, error) {
-	ip := imagePath{purpose, name}
-	cachedImg, found := cache[ip]
-	if found && !force {
-		return cachedImg, nil
-	}
-	if cacheFrozen && !found {
-		return nil, fmt.Errorf("image %v was not precached", ip)
-	}
+// decode loads an image from disk and applies the given palette.
+// Safe to call from background goroutines.
+func decode(pal *palette.Palette, purpose, name string) (image.Image, error) {
+	return decodeTinted(pal, purpose, name, palette.Tint{})
+}
+
+// decodeTinted loads an image from disk and applies the given tint, then the given palette.
+func decodeTinted(pal *palette.Palette, purpose, name string, tint palette.Tint) (image.Image, error) {
 	data, err := vfs.Load(purpose, name)
 	if err != nil {
 		return nil, fmt.Errorf("could not load: %w", err)
@@ -65,6 +64,7 @@ func load(purpose, name string, force bool) (*ebiten.Image, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not decode: %w", err)
 	}
+	img = tint.ApplyToImage(img)
 	usePalette := true
 	if purpose == "sprites" {
 		if noPaletteSprites.MatchString(name) {
@@ -72,7 +72,26 @@ func load(purpose, name string, force bool) (*ebiten.Image, error) {
 		}
 	}
 	if usePalette {
-		img = palette.Current().ApplyToImage(img, name)
+		img = pal.ApplyToImage(img, name)
+	}
+	return img, nil
+}
+
+func load(purpose, name string, force bool) (*ebiten.Image, error) {
+	ip := imagePath{purpose, name}
+	cachedImg, found := cache[ip]
+	if found && !force {
+		return cachedImg, nil
+	}
+	if cacheFrozen && !found {
+		return nil, fmt.Errorf("image %v was not precached", ip)
+	}
+	img, prefetched, err := takePrefetched(ip)
+	if !prefetched || force {
+		img, err = decode(palette.Current(), purpose, name)
+	}
+	if err != nil {
+		return nil, err
 	}
 	eImg := ebiten.NewImageFromImage(img)
 	if eImg.Bounds().Min != (image.Point{}) {
@@ -108,16 +127,14 @@ func Precache() error {
 		return fmt.Errorf("could query load order: %w", err)
 	}
 	listScanner := bufio.NewScanner(listFile)
+	var ordered []imagePath
 	for listScanner.Scan() {
 		line := listScanner.Text()
 		purpose := path.Dir(line)
 		name := path.Base(line)
 		item := imagePath{Purpose: purpose, Name: name}
 		if _, found := toLoad[item]; found {
-			_, err := Load(item.Purpose, item.Name)
-			if err != nil {
-				return fmt.Errorf("could not precache %v: %w", item, err)
-			}
+			ordered = append(ordered, item)
 			delete(toLoad, item)
 		} else {
 			return fmt.Errorf("could not find file for precache item %v", item)
@@ -126,13 +143,46 @@ func Precache() error {
 	for item := range toLoad {
 		return fmt.Errorf("could not find precache item for file %v", item)
 	}
+	if *imageAtlas {
+		err := buildAtlas(ordered)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, item := range ordered {
+			_, err := Load(item.Purpose, item.Name)
+			if err != nil {
+				return fmt.Errorf("could not precache %v: %w", item, err)
+			}
+		}
+	}
 	cacheFrozen = true
 	return nil
 }
 
 func PaletteChanged() error {
-	for ip := range cache {
-		_, err := load(ip.Purpose, ip.Name, true)
+	// Prefetched images use the old palette.
+	discardPrefetched()
+	if atlasBuilt {
+		paths := make([]imagePath, 0, len(cache))
+		for ip := range cache {
+			paths = append(paths, ip)
+		}
+		deallocateAtlas()
+		err := buildAtlas(paths)
+		if err != nil {
+			return err
+		}
+	} else {
+		for ip := range cache {
+			_, err := load(ip.Purpose, ip.Name, true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for tp := range tintCache {
+		_, err := loadTinted(tp, true)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,90 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"image"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+var (
+	prefetchImages = flag.Bool("prefetch_images", true, "when not precaching, decode images the game will likely need soon in the background")
+)
+
+// maxPrefetchers is the number of images decoded in parallel in the background.
+const maxPrefetchers = 2
+
+type prefetchResult struct {
+	done chan struct{}
+	img  image.Image
+	err  error
+}
+
+var (
+	prefetching   = map[imagePath]*prefetchResult{}
+	prefetchSlots = make(chan struct{}, maxPrefetchers)
+)
+
+// Prefetching returns whether Prefetch has any effect.
+// Callers can use this to skip searching for images to prefetch.
+func Prefetching() bool {
+	return *prefetchImages && !cacheFrozen
+}
+
+// Prefetch starts decoding an image in the background, so a later Load need not wait for storage.
+// The image still is only uploaded to the GPU by Load.
+func Prefetch(purpose, name string) {
+	if !Prefetching() {
+		return
+	}
+	ip := imagePath{purpose, name}
+	if _, found := cache[ip]; found {
+		return
+	}
+	if _, found := prefetching[ip]; found {
+		return
+	}
+	r := &prefetchResult{
+		done: make(chan struct{}),
+	}
+	prefetching[ip] = r
+	pal := palette.Current()
+	go func() {
+		prefetchSlots <- struct{}{}
+		defer func() { <-prefetchSlots }()
+		r.img, r.err = decode(pal, purpose, name)
+		close(r.done)
+	}()
+}
+
+// takePrefetched returns the prefetched image, waiting for its decoding to finish if needed.
+// Returns false if the image has not been prefetched.
+func takePrefetched(ip imagePath) (image.Image, bool, error) {
+	r, found := prefetching[ip]
+	if !found {
+		return nil, false, nil
+	}
+	delete(prefetching, ip)
+	<-r.done
+	return r.img, true, r.err
+}
+
+// discardPrefetched forgets all prefetched images.
+// Decoding already in progress finishes, but its result is dropped.
+func discardPrefetched() {
+	prefetching = map[imagePath]*prefetchResult{}
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+type tintedPath struct {
+	imagePath
+	Tint palette.Tint
+}
+
+// tintCache holds the tinted variants of images.
+// These are not part of the atlas, as they are created on demand.
+var tintCache = map[tintedPath]*ebiten.Image{}
+
+// LoadTinted loads a recolored variant of an image.
+// Variants are cached, so the same image can be used in many colors without shipping duplicates.
+// As loading a variant decodes the image again, this should be done when precaching.
+func LoadTinted(purpose, name string, tint palette.Tint) (*ebiten.Image, error) {
+	if tint.IsIdentity() {
+		return Load(purpose, name)
+	}
+	return loadTinted(tintedPath{imagePath{purpose, name}, tint}, false)
+}
+
+func loadTinted(tp tintedPath, force bool) (*ebiten.Image, error) {
+	cachedImg, found := tintCache[tp]
+	if found && !force {
+		return cachedImg, nil
+	}
+	img, err := decodeTinted(palette.Current(), tp.Purpose, tp.Name, tp.Tint)
+	if err != nil {
+		return nil, err
+	}
+	eImg := ebiten.NewImageFromImage(img)
+	if eImg.Bounds().Min != (image.Point{}) {
+		return nil, fmt.Errorf("could not get zero origin: %v", eImg.Bounds())
+	}
+	tintCache[tp] = eImg
+	return eImg, nil
+}
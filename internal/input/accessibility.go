@@ -0,0 +1,62 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"github.com/divVerent/aaaaxy/internal/flag"
+)
+
+var (
+	stickyAction  = flag.Bool("sticky_action", false, "make the action button toggle instead of having to be held (assist option)")
+	menuKeyRepeat = flag.Bool("menu_key_repeat", false, "repeat held directions in menus so they need not be pressed repeatedly")
+)
+
+func init() {
+	flag.MarkAssist("sticky_action")
+}
+
+const (
+	// repeatDelayFrames is how long a direction must be held in menus before it starts repeating.
+	repeatDelayFrames = 30
+	// repeatIntervalFrames is how often a held direction repeats in menus.
+	repeatIntervalFrames = 6
+)
+
+// currentMode is the mode last passed to SetMode.
+var currentMode = PlayingMode
+
+// repeated returns whether a held impulse shall count as hit again in this frame.
+func (i *impulse) repeated(held bool) bool {
+	if !held || !i.repeatable || !*menuKeyRepeat || currentMode != MenuMode {
+		i.heldFrames = 0
+		return false
+	}
+	i.heldFrames++
+	if i.heldFrames < repeatDelayFrames {
+		return false
+	}
+	return (i.heldFrames-repeatDelayFrames)%repeatIntervalFrames == 0
+}
+
+// toggled returns the held state of an impulse after applying the sticky setting.
+func (i *impulse) toggled(held, justPressed bool) bool {
+	if !i.sticky || !*stickyAction || currentMode != PlayingMode {
+		return held
+	}
+	if justPressed {
+		return !i.Held
+	}
+	return i.Held
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+var (
+	anyKeys           []ebiten.Key
+	anyGamepadButtons []ebiten.GamepadButton
+	anyTouchIDs       []ebiten.TouchID
+)
+
+// AnyJustHit returns whether any input has just been made by the user.
+// Unlike the impulses, this also considers keys and buttons not bound to anything.
+// Must be called after Update, and is not affected by LoadFromDemo.
+func AnyJustHit() bool {
+	for _, i := range impulses {
+		if i.JustHit {
+			return true
+		}
+	}
+	if clickPos != nil {
+		return true
+	}
+	anyKeys = inpututil.AppendJustPressedKeys(anyKeys[:0])
+	if len(anyKeys) > 0 {
+		return true
+	}
+	if *gamepad {
+		for p := range gamepads {
+			anyGamepadButtons = inpututil.AppendJustPressedGamepadButtons(p, anyGamepadButtons[:0])
+			if len(anyGamepadButtons) > 0 {
+				return true
+			}
+		}
+	}
+	anyTouchIDs = inpututil.AppendJustPressedTouchIDs(anyTouchIDs[:0])
+	return len(anyTouchIDs) > 0
+}
+
+// JustPressedKey returns a keyboard key that has just been pressed, if any.
+// Like AnyJustHit, this considers keys not bound to anything.
+func JustPressedKey() (ebiten.Key, bool) {
+	anyKeys = inpututil.AppendJustPressedKeys(anyKeys[:0])
+	if len(anyKeys) == 0 {
+		return 0, false
+	}
+	return anyKeys[0], true
+}
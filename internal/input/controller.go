@@ -25,10 +25,25 @@ import (
 type ImpulseState struct {
 	Held    bool `json:",omitempty"`
 	JustHit bool `json:",omitempty"`
+	// Analog is how far an analog control is pushed, from 0 to 1.
+	// Zero if fully pushed or held by a digital control; can be nonzero while not held.
+	Analog float64 `json:",omitempty"`
 }
 
 func (i *ImpulseState) Empty() bool {
-	return !i.Held && !i.JustHit
+	return !i.Held && !i.JustHit && i.Analog == 0
+}
+
+// Amount returns how far the impulse is pushed, from 0 to 1.
+// Digital controls always return either 0 or 1.
+func (i *ImpulseState) Amount() m.Fixed {
+	if i.Analog > 0 {
+		return m.NewFixedFloat64(i.Analog)
+	}
+	if i.Held {
+		return m.FixedOne
+	}
+	return 0
 }
 
 func (i *ImpulseState) OrEmpty() ImpulseState {
@@ -61,6 +76,10 @@ type impulse struct {
 	touchRect         *m.Rect
 	touchImage        *ebiten.Image
 	externallyPressed bool
+	repeatable        bool // Repeats when held in menus (see menu_key_repeat).
+	sticky            bool // Toggles instead of being held (see sticky_action).
+	pressed           bool // Whether physically held, regardless of sticky.
+	heldFrames        int  // Number of frames held, for repeating.
 }
 
 const (
@@ -90,12 +109,12 @@ const (
 )
 
 var (
-	Left       = (&impulse{Name: "Left", keys: leftKeys, padControls: leftPad, touchRect: touchRectLeft}).register()
-	Right      = (&impulse{Name: "Right", keys: rightKeys, padControls: rightPad, touchRect: touchRectRight}).register()
-	Up         = (&impulse{Name: "Up", keys: upKeys, padControls: upPad, touchRect: touchRectUp}).register()
-	Down       = (&impulse{Name: "Down", keys: downKeys, padControls: downPad, touchRect: touchRectDown}).register()
+	Left       = (&impulse{Name: "Left", keys: leftKeys, padControls: leftPad, touchRect: touchRectLeft, repeatable: true}).register()
+	Right      = (&impulse{Name: "Right", keys: rightKeys, padControls: rightPad, touchRect: touchRectRight, repeatable: true}).register()
+	Up         = (&impulse{Name: "Up", keys: upKeys, padControls: upPad, touchRect: touchRectUp, repeatable: true}).register()
+	Down       = (&impulse{Name: "Down", keys: downKeys, padControls: downPad, touchRect: touchRectDown, repeatable: true}).register()
 	Jump       = (&impulse{Name: "Jump", keys: jumpKeys, padControls: jumpPad, touchRect: touchRectJump}).register()
-	Action     = (&impulse{Name: "Action", keys: actionKeys, padControls: actionPad, touchRect: touchRectAction}).register()
+	Action     = (&impulse{Name: "Action", keys: actionKeys, padControls: actionPad, touchRect: touchRectAction, sticky: true}).register()
 	Exit       = (&impulse{Name: "Exit", keys: exitKeys, padControls: exitPad, mouseControl: true, touchRect: touchRectExit}).register()
 	Fullscreen = (&impulse{Name: "Fullscreen", keys: fullscreenKeys /* no padControls */}).register()
 
@@ -103,6 +122,12 @@ var (
 
 	inputMap InputMap
 
+	// Input devices that held any impulse in the current frame.
+	usedInputMap InputMap
+
+	// Whether the player pressed anything yet.
+	anyInputSeen bool
+
 	// Wait for first frame to detect initial gamepad situation.
 	firstUpdate = true
 
@@ -125,12 +150,15 @@ func (i *impulse) update() {
 	mouseHolders := i.mousePressed()
 	holders := keyboardHolders | gamepadHolders | touchHolders | mouseHolders
 	held := holders != NoInput || i.externallyPressed
-	if held && !i.Held {
-		i.JustHit = true
+	// The mouse does not tell which device the player prefers.
+	usedInputMap |= keyboardHolders | gamepadHolders | touchHolders
+	justPressed := held && !i.pressed
+	if justPressed {
 		// Whenever a new key is pressed, update the flag whether we're actually
 		// _using_ the gamepad. Used for some in-game text messages.
 		if holders != NoInput {
 			inputMap &= holders
+			anyInputSeen = true
 		}
 		if inputMap == NoInput {
 			inputMap = holders
@@ -139,10 +167,14 @@ func (i *impulse) update() {
 		if mouseHolders == NoInput {
 			mouseCancel()
 		}
-	} else {
-		i.JustHit = false
 	}
-	i.Held = held
+	i.JustHit = justPressed || i.repeated(held)
+	i.Held = i.toggled(held, justPressed)
+	i.Analog = 0
+	if keyboardHolders|touchHolders|mouseHolders == NoInput && !i.externallyPressed {
+		i.Analog = i.gamepadAnalog()
+	}
+	i.pressed = held
 	i.externallyPressed = false
 }
 
@@ -151,7 +183,9 @@ func Init() error {
 	return touchInit()
 }
 
-func Update(screenWidth, screenHeight, gameWidth, gameHeight int, crtK1, crtK2 float64) {
+// Update updates the input state.
+// zoom is how much larger the letterboxed screen area is than the area actually showing the game, e.g. due to integer scaling.
+func Update(screenWidth, screenHeight, gameWidth, gameHeight int, zoom, crtK1, crtK2 float64) {
 	gamepadScan()
 	if firstUpdate {
 		// At first, assume gamepad whenever one is present.
@@ -170,8 +204,9 @@ func Update(screenWidth, screenHeight, gameWidth, gameHeight int, crtK1, crtK2 f
 		firstUpdate = false
 	}
 	clickPos, hoverPos = nil, nil
-	mouseUpdate(screenWidth, screenHeight, gameWidth, gameHeight, crtK1, crtK2)
-	touchUpdate(screenWidth, screenHeight, gameWidth, gameHeight, crtK1, crtK2)
+	usedInputMap = NoInput
+	mouseUpdate(screenWidth, screenHeight, gameWidth, gameHeight, zoom, crtK1, crtK2)
+	touchUpdate(screenWidth, screenHeight, gameWidth, gameHeight, zoom, crtK1, crtK2)
 	for _, i := range impulses {
 		i.update()
 	}
@@ -188,6 +223,7 @@ const (
 )
 
 func SetMode(mode Mode) {
+	currentMode = mode
 	switch mode {
 	case PlayingMode:
 		mouseSetWantClicks(false)
@@ -324,6 +360,7 @@ func Mouse() (m.Pos, MouseStatus) {
 
 type DemoState struct {
 	InputMap          InputMap      `json:",omitempty"`
+	UsedInputMap      InputMap      `json:",omitempty"`
 	Left              *ImpulseState `json:",omitempty"`
 	Right             *ImpulseState `json:",omitempty"`
 	Up                *ImpulseState `json:",omitempty"`
@@ -342,6 +379,7 @@ func LoadFromDemo(state *DemoState) {
 		state = &DemoState{}
 	}
 	inputMap = state.InputMap
+	usedInputMap = state.UsedInputMap
 	Left.ImpulseState = state.Left.OrEmpty()
 	Right.ImpulseState = state.Right.OrEmpty()
 	Up.ImpulseState = state.Up.OrEmpty()
@@ -362,6 +400,7 @@ func LoadFromDemo(state *DemoState) {
 func SaveToDemo() *DemoState {
 	return &DemoState{
 		InputMap:          inputMap,
+		UsedInputMap:      usedInputMap,
 		Left:              Left.ImpulseState.UnlessEmpty(),
 		Right:             Right.ImpulseState.UnlessEmpty(),
 		Up:                Up.ImpulseState.UnlessEmpty(),
@@ -17,6 +17,7 @@ package input
 import (
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"regexp"
 	"strings"
@@ -29,13 +30,18 @@ import (
 )
 
 var (
-	gamepad                 = flag.Bool("gamepad", true, "enable gamepad input")
-	gamepadAxisOnThreshold  = flag.Float64("gamepad_axis_on_threshold", 0.6, "minimum amount to push the game pad for registering an action; can be zero to accept any movement")
-	gamepadAxisOffThreshold = flag.Float64("gamepad_axis_off_threshold", 0.4, "maximum amount to push the game pad for unregistering an action; can be zero to accept any movement")
-	gamepadOverride         = flag.String("gamepad_override", "", "entries in SDL_GameControllerDB format to add/override gamepad support; multiple entries are permitted and can be separated by newlines or semicolons; can also be provided via $SDL_GAMECONTROLLERCONFIG environment variable")
-	debugGamepadLogging     = flag.Bool("debug_gamepad_logging", false, "log all gamepad states (spammy)")
+	gamepad                  = flag.Bool("gamepad", true, "enable gamepad input")
+	gamepadAxisOnThreshold   = flag.Float64("gamepad_axis_on_threshold", 0.6, "minimum amount to push the game pad for registering an action; can be zero to accept any movement")
+	gamepadAxisOffThreshold  = flag.Float64("gamepad_axis_off_threshold", 0.4, "maximum amount to push the game pad for unregistering an action; can be zero to accept any movement")
+	gamepadAxisWalkThreshold = flag.Float64("gamepad_axis_walk_threshold", 0.2, "minimum amount to push the game pad for walking slowly; set to 1 to always walk at full speed")
+	gamepadAxisFullThreshold = flag.Float64("gamepad_axis_full_threshold", 0.9, "minimum amount to push the game pad for walking at full speed")
+	gamepadOverride          = flag.String("gamepad_override", "", "entries in SDL_GameControllerDB format to add/override gamepad support; multiple entries are permitted and can be separated by newlines or semicolons; can also be provided via $SDL_GAMECONTROLLERCONFIG environment variable")
+	debugGamepadLogging      = flag.Bool("debug_gamepad_logging", false, "log all gamepad states (spammy)")
 )
 
+// gamepadAnalogSteps is the number of distinct analog input amounts.
+const gamepadAnalogSteps = 16
+
 type (
 	padControls struct {
 		name          string
@@ -149,7 +155,7 @@ func (i *impulse) gamepadPressed() InputMap {
 	if i.Held {
 		t = *gamepadAxisOffThreshold
 	}
-	for p := range gamepads {
+	for _, p := range activeGamepadList {
 		for _, b := range i.padControls.buttons {
 			if ignoredGamepadButtons[b] {
 				continue
@@ -162,7 +168,7 @@ func (i *impulse) gamepadPressed() InputMap {
 			if ignoredGamepadAxes[a] {
 				continue
 			}
-			if ebiten.StandardGamepadAxisValue(p, a)*i.padControls.axisDirection >= t {
+			if gamepadAxisValue(p, a)*i.padControls.axisDirection >= t {
 				return Gamepad
 			}
 		}
@@ -170,6 +176,33 @@ func (i *impulse) gamepadPressed() InputMap {
 	return NoInput
 }
 
+// gamepadAnalog returns how far the impulse is pushed on an analog stick, from 0 to 1.
+// Returns zero if a button is held, the stick is fully pushed or below the walk threshold.
+// The result is a multiple of 1/gamepadAnalogSteps, so physics and demos only get exact values.
+func (i *impulse) gamepadAnalog() float64 {
+	amount := 0.0
+	for _, p := range activeGamepadList {
+		for _, b := range i.padControls.buttons {
+			if ignoredGamepadButtons[b] {
+				continue
+			}
+			if ebiten.IsStandardGamepadButtonPressed(p, b) {
+				return 0
+			}
+		}
+		for _, a := range i.padControls.axes {
+			if ignoredGamepadAxes[a] {
+				continue
+			}
+			amount = math.Max(amount, gamepadAxisValue(p, a)*i.padControls.axisDirection)
+		}
+	}
+	if amount < *gamepadAxisWalkThreshold || amount >= *gamepadAxisFullThreshold {
+		return 0
+	}
+	return math.Ceil(amount / *gamepadAxisFullThreshold * gamepadAnalogSteps) / gamepadAnalogSteps
+}
+
 func encodeAxis[K comparable](f float64, m map[K]string, i K) {
 	if f < -0.333 {
 		m[i] = "-"
@@ -284,7 +317,9 @@ func gamepadScan() {
 	if !*gamepad {
 		for p := range gamepads {
 			delete(gamepads, p)
+			delete(gamepadCalibrationByID, p)
 		}
+		updateActiveGamepads()
 		return
 	}
 
@@ -316,6 +351,10 @@ NextGamepad:
 		// TODO also check button/axis existence.
 		// A good gamepad! Add it.
 		gamepads[p] = struct{}{}
+		if c, found := gamepadCalibrations[ebiten.GamepadSDLID(p)]; found {
+			log.Infof("gamepad %v (%v) uses calibration %+v", ebiten.GamepadName(p), ebiten.GamepadSDLID(p), c)
+			gamepadCalibrationByID[p] = c
+		}
 	}
 	for p, stillThere := range allGamepads {
 		if stillThere {
@@ -324,7 +363,9 @@ NextGamepad:
 		log.Infof("gamepad removed")
 		delete(allGamepads, p)
 		delete(gamepads, p)
+		delete(gamepadCalibrationByID, p)
 	}
+	updateActiveGamepads()
 
 	gamepadLog()
 }
@@ -398,11 +439,13 @@ func gamepadInit() {
 	// Also support the flag. Note that the flag value is saved.
 	config = semiRE.ReplaceAllString(*gamepadOverride, "\n")
 	applyAndLogGameControllerDb(config, nil, "gamepad mappings from --gamepad_override")
+
+	loadGamepadCalibrations()
 }
 
 func gamepadEasterEggKeyState() int {
 	state := 0
-	for p := range gamepads {
+	for _, p := range activeGamepadList {
 		if ebiten.IsStandardGamepadButtonPressed(p, ebiten.StandardGamepadButtonRightBottom) {
 			state |= easterEggA
 		}
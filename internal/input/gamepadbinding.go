@@ -0,0 +1,127 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+var (
+	gamepadBind = flag.String("gamepad_bind", "", "GUID of the only gamepad to take input from, optionally followed by /N to pick the N-th (counting from 0) of several connected gamepads with that GUID in the order they got connected; while no such gamepad is connected, all gamepads are used")
+)
+
+var (
+	// boundGamepad is the gamepad bound to, if boundGamepadSet.
+	boundGamepad    ebiten.GamepadID
+	boundGamepadSet bool
+	// activeGamepadList are the gamepads input is taken from. Global to reduce allocation.
+	activeGamepadList []ebiten.GamepadID
+)
+
+// updateActiveGamepads applies the gamepad binding after gamepads were added or removed.
+func updateActiveGamepads() {
+	if _, found := gamepads[boundGamepad]; boundGamepadSet && !found {
+		log.Infof("bound gamepad disconnected - using all gamepads until it is back")
+		boundGamepadSet = false
+	}
+	if !boundGamepadSet && *gamepadBind != "" {
+		// Bind again once a gamepad with the same GUID and index shows up.
+		guid, index := parseGamepadBind(*gamepadBind)
+		for _, pad := range Gamepads() {
+			if pad.GUID == guid && pad.Index == index {
+				log.Infof("binding to gamepad %v (%v/%d)", pad.Name, pad.GUID, pad.Index)
+				boundGamepad = pad.id
+				boundGamepadSet = true
+				break
+			}
+		}
+	}
+	activeGamepadList = activeGamepadList[:0]
+	for p := range gamepads {
+		if !boundGamepadSet || p == boundGamepad {
+			activeGamepadList = append(activeGamepadList, p)
+		}
+	}
+}
+
+// gamepadIndex returns the index of the given gamepad among the connected gamepads with the same GUID.
+// Gamepad IDs are handed out in connection order.
+func gamepadIndex(id ebiten.GamepadID) int {
+	guid := ebiten.GamepadSDLID(id)
+	index := 0
+	for p := range gamepads {
+		if p < id && ebiten.GamepadSDLID(p) == guid {
+			index++
+		}
+	}
+	return index
+}
+
+// parseGamepadBind splits the value of --gamepad_bind into GUID and index.
+func parseGamepadBind(bind string) (string, int) {
+	guid, indexStr, found := strings.Cut(bind, "/")
+	if !found {
+		return guid, 0
+	}
+	index, err := strconv.Atoi(indexStr)
+	if err != nil || index < 0 {
+		log.Errorf("invalid gamepad index in --gamepad_bind=%q - using the first gamepad with that GUID", bind)
+		return guid, 0
+	}
+	return guid, index
+}
+
+// formatGamepadBind returns the value of --gamepad_bind for the given gamepad.
+func formatGamepadBind(pad GamepadInfo) string {
+	if pad.Index == 0 {
+		return pad.GUID
+	}
+	return fmt.Sprintf("%s/%d", pad.GUID, pad.Index)
+}
+
+// BindGamepad makes the given gamepad the only one input is taken from.
+func BindGamepad(pad GamepadInfo) {
+	flag.Set("gamepad_bind", formatGamepadBind(pad))
+	boundGamepad = pad.id
+	boundGamepadSet = true
+	updateActiveGamepads()
+}
+
+// UnbindGamepad takes input from all gamepads again.
+func UnbindGamepad() {
+	flag.Set("gamepad_bind", "")
+	boundGamepadSet = false
+	updateActiveGamepads()
+}
+
+// BoundGamepad returns the gamepad input is exclusively taken from, if any.
+func BoundGamepad() (GamepadInfo, bool) {
+	if !boundGamepadSet {
+		return GamepadInfo{}, false
+	}
+	return GamepadInfo{
+		GUID:  ebiten.GamepadSDLID(boundGamepad),
+		Name:  ebiten.GamepadName(boundGamepad),
+		Index: gamepadIndex(boundGamepad),
+		id:    boundGamepad,
+	}, true
+}
@@ -0,0 +1,164 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// gamepadCalibrationFile is the config state file calibrations are stored in.
+const gamepadCalibrationFile = "gamepad_calibration.json"
+
+// GamepadCalibration corrects the axes of a gamepad whose sticks drift or are mapped upside down.
+type GamepadCalibration struct {
+	// Deadzone is the stick deflection below which the stick counts as centered.
+	// The range above it gets stretched to the full range again.
+	Deadzone float64 `json:",omitempty"`
+	// InvertX flips the horizontal stick axes.
+	InvertX bool `json:",omitempty"`
+	// InvertY flips the vertical stick axes.
+	InvertY bool `json:",omitempty"`
+}
+
+// GamepadInfo describes a connected gamepad.
+type GamepadInfo struct {
+	// GUID identifies the gamepad model; calibrations are stored by it.
+	GUID string
+	// Name is the human readable name of the gamepad.
+	Name string
+	// Index tells apart connected gamepads with the same GUID, in the order they got connected.
+	Index int
+
+	id ebiten.GamepadID
+}
+
+var (
+	// gamepadCalibrations are the calibrations by gamepad GUID.
+	gamepadCalibrations = map[string]GamepadCalibration{}
+	// gamepadCalibrationByID caches the calibration of each active gamepad.
+	gamepadCalibrationByID = map[ebiten.GamepadID]GamepadCalibration{}
+)
+
+func loadGamepadCalibrations() {
+	data, err := vfs.ReadState(vfs.Config, gamepadCalibrationFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Errorf("could not load gamepad calibration: %v", err)
+		return
+	}
+	err = json.Unmarshal(data, &gamepadCalibrations)
+	if err != nil {
+		log.Errorf("could not parse gamepad calibration: %v", err)
+		gamepadCalibrations = map[string]GamepadCalibration{}
+	}
+}
+
+// Gamepads returns the usable connected gamepads, sorted by name.
+func Gamepads() []GamepadInfo {
+	var pads []GamepadInfo
+	for p := range gamepads {
+		pads = append(pads, GamepadInfo{
+			GUID:  ebiten.GamepadSDLID(p),
+			Name:  ebiten.GamepadName(p),
+			Index: gamepadIndex(p),
+			id:    p,
+		})
+	}
+	sort.Slice(pads, func(i, j int) bool {
+		if pads[i].Name != pads[j].Name {
+			return pads[i].Name < pads[j].Name
+		}
+		return pads[i].id < pads[j].id
+	})
+	return pads
+}
+
+// HaveGamepad returns whether any usable gamepad is connected.
+func HaveGamepad() bool {
+	return len(gamepads) != 0
+}
+
+// GamepadCalibrationFor returns the calibration of the gamepads with the given GUID.
+func GamepadCalibrationFor(guid string) GamepadCalibration {
+	return gamepadCalibrations[guid]
+}
+
+// SetGamepadCalibration changes and saves the calibration of the gamepads with the given GUID.
+func SetGamepadCalibration(guid string, c GamepadCalibration) error {
+	if c == (GamepadCalibration{}) {
+		delete(gamepadCalibrations, guid)
+	} else {
+		gamepadCalibrations[guid] = c
+	}
+	for p := range gamepads {
+		if ebiten.GamepadSDLID(p) == guid {
+			gamepadCalibrationByID[p] = c
+		}
+	}
+	data, err := json.MarshalIndent(gamepadCalibrations, "", "\t")
+	if err != nil {
+		return err
+	}
+	return vfs.WriteState(vfs.Config, gamepadCalibrationFile, data)
+}
+
+// StickPosition returns the calibrated position of the left stick of the given gamepad.
+func (g GamepadInfo) StickPosition() (float64, float64) {
+	return gamepadAxisValue(g.id, ebiten.StandardGamepadAxisLeftStickHorizontal),
+		gamepadAxisValue(g.id, ebiten.StandardGamepadAxisLeftStickVertical)
+}
+
+// RawStickPosition returns the uncalibrated position of the left stick of the given gamepad.
+func (g GamepadInfo) RawStickPosition() (float64, float64) {
+	return ebiten.StandardGamepadAxisValue(g.id, ebiten.StandardGamepadAxisLeftStickHorizontal),
+		ebiten.StandardGamepadAxisValue(g.id, ebiten.StandardGamepadAxisLeftStickVertical)
+}
+
+// gamepadAxisValue returns the value of a standard axis with calibration applied.
+func gamepadAxisValue(p ebiten.GamepadID, a ebiten.StandardGamepadAxis) float64 {
+	v := ebiten.StandardGamepadAxisValue(p, a)
+	c := gamepadCalibrationByID[p]
+	switch a {
+	case ebiten.StandardGamepadAxisLeftStickHorizontal, ebiten.StandardGamepadAxisRightStickHorizontal:
+		if c.InvertX {
+			v = -v
+		}
+	case ebiten.StandardGamepadAxisLeftStickVertical, ebiten.StandardGamepadAxisRightStickVertical:
+		if c.InvertY {
+			v = -v
+		}
+	}
+	if c.Deadzone <= 0 || c.Deadzone >= 1 {
+		return v
+	}
+	switch {
+	case v > c.Deadzone:
+		return (v - c.Deadzone) / (1 - c.Deadzone)
+	case v < -c.Deadzone:
+		return (v + c.Deadzone) / (1 - c.Deadzone)
+	default:
+		return 0
+	}
+}
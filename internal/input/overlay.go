@@ -0,0 +1,73 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/divVerent/aaaaxy/internal/font"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+const (
+	overlayCellSize = 10
+	overlayCellGap  = 2
+)
+
+// overlayCell is a single element of the input overlay, in cell coordinates.
+type overlayCell struct {
+	impulse *impulse
+	pos     m.Pos
+	label   string
+}
+
+var overlayCells = []overlayCell{
+	{impulse: Up, pos: m.Pos{X: 1, Y: 0}},
+	{impulse: Left, pos: m.Pos{X: 0, Y: 1}},
+	{impulse: Right, pos: m.Pos{X: 2, Y: 1}},
+	{impulse: Down, pos: m.Pos{X: 1, Y: 2}},
+	{impulse: Jump, pos: m.Pos{X: 4, Y: 1}, label: "J"},
+	{impulse: Action, pos: m.Pos{X: 5, Y: 1}, label: "A"},
+}
+
+// DrawOverlay draws the current impulse states as a small widget with the given top left corner.
+//
+// During demo playback, the impulse states are those loaded from the demo,
+// so this shows the recorded input.
+func DrawOverlay(screen *ebiten.Image, origin m.Pos) {
+	for _, c := range overlayCells {
+		var fg, bg color.NRGBA
+		switch {
+		case c.impulse.JustHit:
+			fg, bg = palette.EGA(palette.Black, 255), palette.EGA(palette.Yellow, 255)
+		case c.impulse.Held:
+			fg, bg = palette.EGA(palette.Black, 255), palette.EGA(palette.White, 255)
+		default:
+			fg, bg = palette.EGA(palette.LightGrey, 255), palette.EGA(palette.DarkGrey, 160)
+		}
+		p := origin.Add(c.pos.Delta(m.Pos{}).Mul(overlayCellSize + overlayCellGap))
+		vector.DrawFilledRect(screen, float32(p.X), float32(p.Y), overlayCellSize, overlayCellSize, bg, false)
+		if c.label != "" {
+			font.ByName["Small"].Draw(screen, c.label, m.Pos{
+				X: p.X + overlayCellSize/2,
+				Y: p.Y + overlayCellSize - 2,
+			}, font.Center, fg, bg)
+		}
+	}
+}
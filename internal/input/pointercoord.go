@@ -20,9 +20,13 @@ import (
 	m "github.com/divVerent/aaaaxy/internal/math"
 )
 
-func pointerCoords(screenWidth, screenHeight, gameWidth, gameHeight int, crtK1, crtK2 float64, x, y int) m.Pos {
+// pointerCoords maps a pointer position on the screen to game coordinates.
+// zoom is how much larger the screen area is than the area the game is actually drawn to, centered.
+func pointerCoords(screenWidth, screenHeight, gameWidth, gameHeight int, zoom, crtK1, crtK2 float64, x, y int) m.Pos {
 	inX := float64(x)*float64(gameWidth)/float64(screenWidth) + 0.5
 	inY := float64(y)*float64(gameHeight)/float64(screenHeight) + 0.5
+	inX = float64(gameWidth)/2 + (inX-float64(gameWidth)/2)*zoom
+	inY = float64(gameHeight)/2 + (inY-float64(gameHeight)/2)*zoom
 
 	// Straight ported from linear2xcrt.kage.tmpl.
 	// Assume srcImageSize is 1:1 -> "square pixels".
@@ -0,0 +1,66 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+// Prompts describes how to present the buttons of the preferred input device.
+type Prompts struct {
+	// Exit is the button to leave the game with.
+	Exit ExitButtonID
+	// ExitIcon is the sprite image showing the exit button, or empty if there is none.
+	ExitIcon string
+	// Action is the button to perform an action with.
+	Action ActionButtonID
+	// ActionIcon is the sprite image showing the action button, or empty if there is none.
+	ActionIcon string
+}
+
+// PreferredPrompts returns the button prompts for the input device the player is using.
+func PreferredPrompts() Prompts {
+	p := Prompts{
+		Exit:   ExitButton(),
+		Action: ActionButton(),
+	}
+	switch p.Exit {
+	case Start:
+		p.ExitIcon = "start.png"
+	case Escape:
+		p.ExitIcon = "esc.png"
+	default: // case Backspace, Back:
+		p.ExitIcon = "backspace.png"
+	}
+	if p.Action == B {
+		p.ActionIcon = "touch_action.png"
+	}
+	return p
+}
+
+// UsedInputMap returns the input devices that held any button in the current frame.
+func UsedInputMap() InputMap {
+	return usedInputMap
+}
+
+// SetPreferredInputMap makes prompts assume the given input devices.
+// This only has an effect until the player presses a button, which then selects the actual input device.
+func SetPreferredInputMap(m InputMap) {
+	if m == NoInput || anyInputSeen {
+		return
+	}
+	if m.ContainsAny(Gamepad) && len(gamepads) == 0 {
+		// Nothing to show gamepad buttons for.
+		return
+	}
+	inputMap = m
+	firstUpdate = false
+}
@@ -82,7 +82,7 @@ func touchEmulateMouse() {
 	}
 }
 
-func touchUpdate(screenWidth, screenHeight, gameWidth, gameHeight int, crtK1, crtK2 float64) {
+func touchUpdate(screenWidth, screenHeight, gameWidth, gameHeight int, zoom, crtK1, crtK2 float64) {
 	if !*touch {
 		return
 	}
@@ -104,7 +104,7 @@ func touchUpdate(screenWidth, screenHeight, gameWidth, gameHeight int, crtK1, cr
 		t.clickFrames++
 		t.prevPos = t.pos
 		x, y := ebiten.TouchPosition(id)
-		t.pos = pointerCoords(screenWidth, screenHeight, gameWidth, gameHeight, crtK1, crtK2, x, y)
+		t.pos = pointerCoords(screenWidth, screenHeight, gameWidth, gameHeight, zoom, crtK1, crtK2, x, y)
 	}
 	if touchEditUpdate(gameWidth, gameHeight) {
 		// log.Infof("touchEditUpdate returned true - not emulating mouse")
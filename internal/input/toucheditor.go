@@ -50,10 +50,8 @@ const (
 var (
 	touchEditPad bool = false
 
-	touchReservedArea = m.Rect{
-		Origin: m.Pos{X: 192, Y: 64},
-		Size:   m.Delta{DX: 640 - 192 - 192, DY: 360 - 64},
-	}
+	// touchReservedArea is where touch controls cannot be placed. Updated for the game size on each frame.
+	touchReservedArea = reservedArea(640, 360)
 
 	snaps = []m.Delta{
 		// Distance: 0
@@ -213,7 +211,22 @@ func touchToggleActionButton(gameWidth, gameHeight int) {
 	}
 }
 
+// reservedArea returns the area touch controls must keep clear of for the given game size.
+func reservedArea(gameWidth, gameHeight int) m.Rect {
+	r := m.Rect{
+		Origin: m.Pos{X: 192, Y: 64},
+		Size:   m.Delta{DX: gameWidth - 192 - 192, DY: gameHeight - 64},
+	}
+	if r.Size.DX < 0 {
+		// Narrow screens have no room for controls at the sides, so reserve nothing.
+		r.Origin.X = gameWidth / 2
+		r.Size.DX = 0
+	}
+	return r
+}
+
 func touchEditUpdate(gameWidth, gameHeight int) bool {
+	touchReservedArea = reservedArea(gameWidth, gameHeight)
 	if !touchEditPad {
 		for _, t := range touches {
 			t.edit.active = false
@@ -0,0 +1,146 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/fardog/tmx"
+
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// Background is an image drawn behind the tiles, scrolling at its own speed.
+type Background struct {
+	ImageDir string
+	ImageSrc string
+	// Offset is where the image is drawn when the screen is centered on the origin.
+	Offset m.Delta
+	// ParallaxX and ParallaxY are the scroll factors; 1 scrolls with the tiles, 0 stays fixed on the screen.
+	ParallaxX, ParallaxY float64
+	// WrapY makes the image repeat vertically. It always repeats horizontally.
+	WrapY bool
+	Alpha float64
+}
+
+// newBackground creates a Background from an image path and its properties.
+func newBackground(what, src string, offset m.Delta, alpha float64, properties propmap.Map) (*Background, error) {
+	dir := path.Base(path.Dir(src))
+	if dir != "sprites" && dir != "tiles" {
+		return nil, fmt.Errorf("unsupported map: %s must use an image from sprites or tiles, got %q", what, src)
+	}
+	if alpha < 0 || alpha > 1 {
+		return nil, fmt.Errorf("unsupported map: %s has invalid opacity %v", what, alpha)
+	}
+	var parseErr error
+	bg := &Background{
+		ImageDir:  dir,
+		ImageSrc:  path.Base(src),
+		Offset:    offset,
+		ParallaxX: propmap.ValueOrP(properties, "parallax_x", 1.0, &parseErr),
+		ParallaxY: propmap.ValueOrP(properties, "parallax_y", 1.0, &parseErr),
+		WrapY:     propmap.ValueOrP(properties, "wrap_y", false, &parseErr),
+		Alpha:     alpha,
+	}
+	if parseErr != nil {
+		return nil, fmt.Errorf("invalid %s: %w", what, parseErr)
+	}
+	return bg, nil
+}
+
+// parseImageLayer converts a TMX image layer to a Background.
+// The opacity must have been normalized by fixImageLayerOpacity.
+func parseImageLayer(il *tmx.ImageLayer) (*Background, error) {
+	// il.Name not used (editor only).
+	// il.Width, il.Height not used (editor only).
+	// il.Visible not used (we allow it though as it may help in the editor).
+	if il.Image.Source == "" {
+		return nil, fmt.Errorf("unsupported map: image layer %q has no image", il.Name)
+	}
+	properties := propmap.New()
+	for i := range il.Properties {
+		prop := &il.Properties[i]
+		propmap.Set(properties, prop.Name, prop.Value)
+	}
+	return newBackground(fmt.Sprintf("image layer %q", il.Name), il.Image.Source,
+		m.Delta{DX: il.X + il.OffsetX, DY: il.Y + il.OffsetY}, float64(il.Opacity), properties)
+}
+
+// parseMapBackgrounds reads backgrounds declared in map properties.
+//
+// Each background N is declared by background_N_image, with optional
+// background_N_offset_x, background_N_offset_y, background_N_opacity,
+// background_N_parallax_x, background_N_parallax_y and background_N_wrap_y.
+// Numbering starts at 0 and ends at the first missing image.
+// These are drawn behind the image layers.
+func parseMapBackgrounds(mapProps tmx.Properties) ([]*Background, error) {
+	var bgs []*Background
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("background_%d_", i)
+		src := mapProps.WithName(prefix + "image")
+		if src == nil {
+			return bgs, nil
+		}
+		properties := propmap.New()
+		for j := range mapProps {
+			prop := &mapProps[j]
+			if name, ok := strings.CutPrefix(prop.Name, prefix); ok {
+				propmap.Set(properties, name, prop.Value)
+			}
+		}
+		var parseErr error
+		offset := m.Delta{
+			DX: propmap.ValueOrP(properties, "offset_x", 0, &parseErr),
+			DY: propmap.ValueOrP(properties, "offset_y", 0, &parseErr),
+		}
+		alpha := propmap.ValueOrP(properties, "opacity", 1.0, &parseErr)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid map background %d: %w", i, parseErr)
+		}
+		bg, err := newBackground(fmt.Sprintf("map background %d", i), src.Value, offset, alpha, properties)
+		if err != nil {
+			return nil, err
+		}
+		bgs = append(bgs, bg)
+	}
+}
+
+// fixImageLayerOpacity sets the opacity of image layers that do not specify one to 1.
+// Tiled omits the attribute at full opacity, which the TMX decoder cannot tell apart from an explicit 0.
+func fixImageLayerOpacity(tmxBytes []byte, t *tmx.Map) error {
+	var raw struct {
+		ImageLayers []struct {
+			Opacity *float32 `xml:"opacity,attr"`
+		} `xml:"imagelayer"`
+	}
+	err := xml.NewDecoder(bytes.NewReader(tmxBytes)).Decode(&raw)
+	if err != nil {
+		return err
+	}
+	if len(raw.ImageLayers) != len(t.ImageLayers) {
+		return fmt.Errorf("image layer count mismatch: got %d, want %d", len(raw.ImageLayers), len(t.ImageLayers))
+	}
+	for i := range t.ImageLayers {
+		if raw.ImageLayers[i].Opacity == nil {
+			t.ImageLayers[i].Opacity = 1
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// cacheInputs collects the contents of everything a level is loaded from.
+type cacheInputs [][]byte
+
+// add records some input data. It is a no-op on a nil receiver.
+func (c *cacheInputs) add(data []byte) {
+	if c == nil {
+		return
+	}
+	*c = append(*c, data)
+}
+
+// hashCacheKey returns the disk cache key for the level hash.
+func hashCacheKey(inputs cacheInputs, tr *translation, withCheckpointLocations bool) uint64 {
+	flags := fmt.Sprintf("%v %v %v", tr.active, tr.verticalText, withCheckpointLocations)
+	return vfs.CacheKey(append(inputs[:len(inputs):len(inputs)], []byte(flags))...)
+}
+
+// loadCachedHash returns the level hash from the disk cache, if any.
+func loadCachedHash(key uint64) (uint64, bool) {
+	data, found := vfs.ReadCache("level_hash", key)
+	if !found || len(data) != 8 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(data), true
+}
+
+// storeCachedHash writes the level hash to the disk cache.
+func storeCachedHash(key uint64, hash uint64) {
+	var data [8]byte
+	binary.LittleEndian.PutUint64(data[:], hash)
+	vfs.WriteCache("level_hash", key, data[:])
+}
+
+// checkpointLocationsCacheKey returns the disk cache key for the checkpoint locations.
+func checkpointLocationsCacheKey(inputs cacheInputs) uint64 {
+	return vfs.CacheKey(inputs...)
+}
+
+// loadCachedCheckpointLocations returns the checkpoint locations from the disk cache, if any.
+func loadCachedCheckpointLocations(key uint64) (*CheckpointLocations, bool) {
+	data, found := vfs.ReadCache("checkpoint_locations", key)
+	if !found {
+		return nil, false
+	}
+	var loc CheckpointLocations
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&loc)
+	if err != nil {
+		log.Warningf("could not decode cached checkpoint locations: %v", err)
+		return nil, false
+	}
+	return &loc, true
+}
+
+// storeCachedCheckpointLocations writes the checkpoint locations to the disk cache.
+func storeCachedCheckpointLocations(key uint64, loc *CheckpointLocations) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(loc)
+	if err != nil {
+		log.Warningf("could not encode checkpoint locations for caching: %v", err)
+		return
+	}
+	vfs.WriteCache("checkpoint_locations", key, buf.Bytes())
+}
@@ -0,0 +1,283 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/hashstructure/v2"
+
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// searchNode is a tile reached by the checkpoint graph search, together with
+// the transform it was entered with.
+type searchNode struct {
+	levelPos  m.Pos
+	transform m.Orientation
+}
+
+// passable returns whether the checkpoint graph search may enter the given tile.
+func passable(t *LevelTile) bool {
+	return !t.Tile.FullyBlocks(PlayerSolidContents)
+}
+
+// step returns the tiles reached by moving from n in screen direction d.
+// Switchable warpzones may be in either state, so all outcomes are returned.
+func (l *Level) step(n searchNode, d m.Delta) []searchNode {
+	newPos := n.levelPos.Add(n.transform.Apply(d))
+	if !l.InBounds(newPos) {
+		return nil
+	}
+	tile := l.Tile(newPos)
+	if tile == nil {
+		return nil
+	}
+	var out []searchNode
+	unwarped := true
+	for _, warp := range tile.WarpZones {
+		// Don't enter warps from behind.
+		if warp.PrevTile != n.levelPos {
+			continue
+		}
+		if !warp.Switchable {
+			unwarped = false
+		}
+		out = append(out, searchNode{
+			levelPos:  warp.ToTile,
+			transform: warp.Transform.Concat(n.transform),
+		})
+	}
+	if unwarped {
+		out = append(out, searchNode{
+			levelPos:  newPos,
+			transform: n.transform,
+		})
+	}
+	return out
+}
+
+// checkpointNames returns the names of all checkpoints in sorted order.
+func (l *Level) checkpointNames() []string {
+	names := make([]string, 0, len(l.Checkpoints))
+	for name := range l.Checkpoints {
+		if name == "" {
+			// Not a real CP, but the player initial spawn.
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GenerateCheckpointGraph derives the checkpoint graph from the level itself.
+//
+// Positions are found by a breadth first search through all tiles the player
+// can pass, starting at the player spawn and following warpzones; a checkpoint
+// is placed where it shows up on screen relative to the spawn when following
+// the shortest path to it.
+//
+// Edges connect checkpoints whose areas touch, where the area of a checkpoint
+// is the set of tiles closer to it than to any other checkpoint. They point
+// away from the player spawn. The loader only uses them if no checkpoint has
+// next_* properties.
+func (l *Level) GenerateCheckpointGraph() (*JSONCheckpointGraph, error) {
+	if l.Player == nil {
+		return nil, fmt.Errorf("level has no player spawn")
+	}
+
+	// Find screen positions and distances from the player spawn.
+	// Checkpoints only reachable by other means than walking (e.g. by
+	// teleporters) are found by a second search that goes through walls.
+	type visit struct {
+		screenPos m.Pos
+		distance  int
+	}
+	visits := map[m.Pos]visit{
+		l.Player.LevelPos: {},
+	}
+	start := searchNode{levelPos: l.Player.LevelPos, transform: m.Identity()}
+	var reached []searchNode
+	for _, throughWalls := range []bool{false, true} {
+		queue := []searchNode{start}
+		if throughWalls {
+			queue = reached
+		}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			if !throughWalls {
+				reached = append(reached, n)
+			}
+			v := visits[n.levelPos]
+			for _, d := range AllCheckpointDirs {
+				for _, next := range l.step(n, d) {
+					if _, found := visits[next.levelPos]; found {
+						continue
+					}
+					if !throughWalls && !passable(l.Tile(next.levelPos)) {
+						continue
+					}
+					visits[next.levelPos] = visit{
+						screenPos: v.screenPos.Add(d),
+						distance:  v.distance + 1,
+					}
+					queue = append(queue, next)
+				}
+			}
+		}
+		if !throughWalls {
+			var unreachable []string
+			for _, name := range l.checkpointNames() {
+				if _, found := visits[l.Checkpoints[name].LevelPos]; !found {
+					unreachable = append(unreachable, name)
+				}
+			}
+			if len(unreachable) == 0 {
+				break
+			}
+			log.Warningf("checkpoints not reachable by walking from the player spawn: %s", strings.Join(unreachable, ", "))
+		}
+	}
+
+	names := l.checkpointNames()
+	var unreachable []string
+	g := &JSONCheckpointGraph{}
+	gvids := make(map[string]int, len(names))
+	for _, name := range names {
+		v, found := visits[l.Checkpoints[name].LevelPos]
+		if !found {
+			unreachable = append(unreachable, name)
+			continue
+		}
+		pos := v.screenPos.Mul(TileSize).Add(m.Delta{DX: TileSize / 2, DY: TileSize / 2})
+		gvids[name] = len(g.Objects)
+		g.Objects = append(g.Objects, JSONCheckpointObject{
+			GVID: len(g.Objects),
+			Name: name,
+			// Note: reverse Y coordinate between graphviz and ebiten.
+			Pos: fmt.Sprintf("%d,%d", pos.X, -pos.Y),
+		})
+	}
+	if len(unreachable) != 0 {
+		return nil, fmt.Errorf("checkpoints not reachable from the player spawn: %s", strings.Join(unreachable, ", "))
+	}
+
+	// Grow the areas of all checkpoints at once; where two areas meet, the checkpoints are neighbors.
+	owners := map[m.Pos]string{}
+	type link struct {
+		a, b string
+	}
+	links := map[link]bool{}
+	addLink := func(a, b string) {
+		if a > b {
+			a, b = b, a
+		}
+		links[link{a, b}] = true
+	}
+	var queue []searchNode
+	for _, name := range names {
+		pos := l.Checkpoints[name].LevelPos
+		if other, found := owners[pos]; found {
+			addLink(other, name)
+			continue
+		}
+		owners[pos] = name
+		queue = append(queue, searchNode{levelPos: pos, transform: m.Identity()})
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		owner := owners[n.levelPos]
+		for _, d := range AllCheckpointDirs {
+			for _, next := range l.step(n, d) {
+				if other, found := owners[next.levelPos]; found {
+					if other != owner {
+						addLink(owner, other)
+					}
+					continue
+				}
+				if !passable(l.Tile(next.levelPos)) {
+					continue
+				}
+				owners[next.levelPos] = owner
+				// Connectivity does not depend on the transform, so keep working in level space.
+				queue = append(queue, searchNode{levelPos: next.levelPos, transform: m.Identity()})
+			}
+		}
+	}
+	sortedLinks := make([]link, 0, len(links))
+	for lnk := range links {
+		sortedLinks = append(sortedLinks, lnk)
+	}
+	sort.Slice(sortedLinks, func(i, j int) bool {
+		if sortedLinks[i].a != sortedLinks[j].a {
+			return sortedLinks[i].a < sortedLinks[j].a
+		}
+		return sortedLinks[i].b < sortedLinks[j].b
+	})
+	for _, lnk := range sortedLinks {
+		tail, head := lnk.a, lnk.b
+		if visits[l.Checkpoints[head].LevelPos].distance < visits[l.Checkpoints[tail].LevelPos].distance {
+			tail, head = head, tail
+		}
+		g.Edges = append(g.Edges, JSONCheckpointEdge{
+			Tail: gvids[tail],
+			Head: gvids[head],
+		})
+	}
+	return g, nil
+}
+
+// DumpCheckpointLocations generates the checkpoint graph of the given level and writes it to path.
+// Returns the checkpoint_locations_hash the map should have.
+// The file is written even if the game cannot lay out the checkpoints, so it can be inspected.
+func DumpCheckpointLocations(filename, path string) (uint64, error) {
+	lvl, err := NewLoader(filename).SkipCheckpointLocations(true).Load()
+	if err != nil {
+		return 0, fmt.Errorf("could not load level: %w", err)
+	}
+	g, err := lvl.GenerateCheckpointGraph()
+	if err != nil {
+		return 0, fmt.Errorf("could not generate checkpoint graph: %w", err)
+	}
+	f, err := vfs.OSCreate(vfs.WorkDir, path)
+	if err != nil {
+		return 0, fmt.Errorf("could not create %q: %w", path, err)
+	}
+	j := json.NewEncoder(f)
+	j.SetIndent("", "\t")
+	err = j.Encode(g)
+	if err != nil {
+		f.Close()
+		return 0, fmt.Errorf("could not write %q: %w", path, err)
+	}
+	err = f.Close()
+	if err != nil {
+		return 0, fmt.Errorf("could not close %q: %w", path, err)
+	}
+	log.Infof("wrote checkpoint locations of %q to %q", filename, path)
+	loc, err := lvl.checkpointLocationsFromGraph(filename, *g)
+	if err != nil {
+		return 0, fmt.Errorf("generated checkpoint graph in %q is not usable, consider setting next_* properties: %w", path, err)
+	}
+	return hashstructure.Hash(loc, hashstructure.FormatV2, nil)
+}
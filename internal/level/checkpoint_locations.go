@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 
@@ -120,15 +121,39 @@ func possibleDirs(d m.Delta) (m.Delta, m.Delta) {
 	}
 }
 
-func (l *Level) LoadCheckpointLocations(filename string) (*CheckpointLocations, error) {
+// loadCheckpointGraphData loads the raw checkpoint graph JSON for the given level.
+func loadCheckpointGraphData(filename string) ([]byte, error) {
 	r, err := vfs.Load("generated", filename+".cp.json")
 	if err != nil {
 		return nil, fmt.Errorf("could not load checkpoint locations for %q: %w", filename, err)
 	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read checkpoint locations for %q: %w", filename, err)
+	}
+	return data, nil
+}
+
+func (l *Level) LoadCheckpointLocations(filename string) (*CheckpointLocations, error) {
+	data, err := loadCheckpointGraphData(filename)
+	if err != nil {
+		return nil, err
+	}
+	return l.checkpointLocationsFromData(filename, data)
+}
+
+// checkpointLocationsFromData computes the checkpoint locations from the raw checkpoint graph JSON.
+func (l *Level) checkpointLocationsFromData(filename string, data []byte) (*CheckpointLocations, error) {
 	var g JSONCheckpointGraph
-	if err := json.NewDecoder(r).Decode(&g); err != nil {
+	if err := json.Unmarshal(data, &g); err != nil {
 		return nil, fmt.Errorf("could not decode checkpoint locations for %q: %w", filename, err)
 	}
+	return l.checkpointLocationsFromGraph(filename, g)
+}
+
+// checkpointLocationsFromGraph computes the checkpoint locations from a checkpoint graph.
+func (l *Level) checkpointLocationsFromGraph(filename string, g JSONCheckpointGraph) (*CheckpointLocations, error) {
 	var loc0 *CheckpointLocations
 	var err0 error
 	tryAtAngle := func(x, y int) {
@@ -206,6 +231,32 @@ func (l *Level) loadCheckpointLocations(filename string, g JSONCheckpointGraph,
 		}
 		id2name[cp.ID] = name
 	}
+	// Maps without any next_* properties use the edges of the checkpoint graph instead.
+	useGraphEdges := true
+	for name, cp := range l.Checkpoints {
+		if name == "" {
+			continue
+		}
+		for _, propname := range []string{"next_left", "next_right", "next_up", "next_down"} {
+			if propmap.StringOr(cp.Properties, propname, "") != "" {
+				useGraphEdges = false
+			}
+		}
+	}
+	graphNext := map[string][]string{}
+	if useGraphEdges {
+		gvid2name := make(map[int]string, len(g.Objects))
+		for _, o := range g.Objects {
+			gvid2name[o.GVID] = o.Name
+		}
+		for _, e := range g.Edges {
+			tail, head := gvid2name[e.Tail], gvid2name[e.Head]
+			if l.Checkpoints[tail] == nil || l.Checkpoints[head] == nil || tail == "" || head == "" {
+				return nil, fmt.Errorf("checkpoint graph edge %d -> %d in %q does not connect two checkpoints", e.Tail, e.Head, filename)
+			}
+			graphNext[tail] = append(graphNext[tail], head)
+		}
+	}
 	edges := []edge{}
 	nodeDegrees := make(map[string]int, len(l.Checkpoints))
 	var parseErr error
@@ -219,6 +270,10 @@ func (l *Level) loadCheckpointLocations(filename string, g JSONCheckpointGraph,
 			return nil, fmt.Errorf("could not find checkpoint location for %q in %q", name, filename)
 		}
 		cpDeadEnd := propmap.ValueOrP(cp.Properties, "dead_end", false, &parseErr)
+		var nextNames []string
+		if useGraphEdges {
+			nextNames = graphNext[name]
+		}
 		for _, propname := range []string{"next_left", "next_right", "next_up", "next_down"} {
 			id := propmap.ValueOrP(cp.Properties, propname, -1, &parseErr)
 			if id == -1 {
@@ -229,6 +284,9 @@ func (l *Level) loadCheckpointLocations(filename string, g JSONCheckpointGraph,
 			if other == "" {
 				return nil, fmt.Errorf("next checkpoint ID for %q property %q in %q is not a checkpoint", name, propname, filename)
 			}
+			nextNames = append(nextNames, other)
+		}
+		for _, other := range nextNames {
 			otherDeadEnd := propmap.ValueOrP(l.Checkpoints[other].Properties, "dead_end", false, &parseErr)
 			otherLoc := loc.Locs[other]
 			if otherLoc == nil {
@@ -468,13 +526,22 @@ reprioritize:
 
 type JSONCheckpointGraph struct {
 	Objects []JSONCheckpointObject
+	Edges   []JSONCheckpointEdge `json:",omitempty"`
 }
 
 type JSONCheckpointObject struct {
+	GVID int `json:"_gvid"`
 	Name string
 	Pos  string
 }
 
+// JSONCheckpointEdge is a directed edge between two objects, identified by their GVID.
+// The edges are only used if no checkpoint has next_* properties.
+type JSONCheckpointEdge struct {
+	Tail int
+	Head int
+}
+
 func (o *JSONCheckpointObject) MapPos() (m.Pos, error) {
 	var x, y float64
 	if _, err := fmt.Sscanf(o.Pos, "%f,%f", &x, &y); err != nil {
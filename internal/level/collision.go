@@ -0,0 +1,211 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"fmt"
+
+	"github.com/fardog/tmx"
+
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// segment is a directed line segment in map pixel coordinates.
+// The solid side is to the right when walking from a to b.
+type segment struct {
+	a, b m.Pos
+}
+
+// clippedLength returns the length of the part of the segment inside the given tile.
+func (s segment) clippedLength(tile m.Pos) float64 {
+	x0, y0 := float64(tile.X*TileSize), float64(tile.Y*TileSize)
+	x1, y1 := x0+TileSize, y0+TileSize
+	ax, ay := float64(s.a.X), float64(s.a.Y)
+	dx, dy := float64(s.b.X-s.a.X), float64(s.b.Y-s.a.Y)
+	// Liang-Barsky clipping.
+	t0, t1 := 0.0, 1.0
+	for _, c := range []struct{ p, q float64 }{
+		{-dx, ax - x0},
+		{dx, x1 - ax},
+		{-dy, ay - y0},
+		{dy, y1 - ay},
+	} {
+		if c.p == 0 {
+			if c.q < 0 {
+				return 0
+			}
+			continue
+		}
+		r := c.q / c.p
+		if c.p < 0 {
+			if r > t1 {
+				return 0
+			}
+			if r > t0 {
+				t0 = r
+			}
+		} else {
+			if r < t0 {
+				return 0
+			}
+			if r < t1 {
+				t1 = r
+			}
+		}
+	}
+	return (t1 - t0) * s.b.Delta(s.a).Length()
+}
+
+// slope returns the slope of the given tile that has the segment's solid side solid.
+func (s segment) slope(tile m.Pos) Slope {
+	d := s.b.Delta(s.a)
+	normal := m.Delta{DX: -d.DY, DY: d.DX}
+	center2 := tile.Mul(2 * TileSize).Add(m.Delta{DX: TileSize, DY: TileSize})
+	return Slope{
+		Normal: normal,
+		Offset: normal.Dot(s.a.Mul(2).Delta(center2)),
+	}
+}
+
+// coverage returns whether any and whether all pixels of a tile with the given slope are solid.
+func (s Slope) coverage() (some, all bool) {
+	full := m.Rect{Size: m.Delta{DX: TileSize, DY: TileSize}}
+	inverse := Slope{
+		Normal: s.Normal.Mul(-1),
+		Offset: 1 - s.Offset,
+	}
+	return s.SolidInRect(full), !inverse.SolidInRect(full)
+}
+
+// inside returns whether the given point is inside the polygon given by the segments.
+func inside(segs []segment, p m.Pos) bool {
+	in := false
+	for _, s := range segs {
+		if (s.a.Y > p.Y) != (s.b.Y > p.Y) {
+			// X coordinate of the crossing, compared without dividing.
+			lhs := (p.X - s.a.X) * (s.b.Y - s.a.Y)
+			rhs := (p.Y - s.a.Y) * (s.b.X - s.a.X)
+			if (s.b.Y > s.a.Y) == (lhs < rhs) {
+				in = !in
+			}
+		}
+	}
+	return in
+}
+
+// collisionSegments converts a polygon or polyline of an object to segments in map coordinates.
+func collisionSegments(origin m.Pos, rotation m.Orientation, poly *tmx.Poly, closed bool) ([]segment, error) {
+	pts, err := poly.Points()
+	if err != nil {
+		return nil, err
+	}
+	if len(pts) < 2 {
+		return nil, fmt.Errorf("got %d points, want at least 2", len(pts))
+	}
+	pos := make([]m.Pos, len(pts))
+	for i, p := range pts {
+		pos[i] = origin.Add(rotation.Apply(m.Delta{DX: p.X, DY: p.Y}))
+	}
+	if closed {
+		// Polygons are solid inside; orient them clockwise so the inside is to the right.
+		area := 0
+		for i := range pos {
+			j := (i + 1) % len(pos)
+			area += pos[i].X*pos[j].Y - pos[j].X*pos[i].Y
+		}
+		if area < 0 {
+			for i, j := 0, len(pos)-1; i < j; i, j = i+1, j-1 {
+				pos[i], pos[j] = pos[j], pos[i]
+			}
+		}
+		pos = append(pos, pos[0])
+	}
+	segs := make([]segment, 0, len(pos)-1)
+	for i := 0; i+1 < len(pos); i++ {
+		if pos[i] == pos[i+1] {
+			continue
+		}
+		segs = append(segs, segment{a: pos[i], b: pos[i+1]})
+	}
+	return segs, nil
+}
+
+// applyCollision makes the tiles covered by the given segments solid.
+//
+// Tiles crossed by a segment get a slope for the solid side of the segment;
+// where several segments cross a tile, the longest one wins. If closed, the
+// tiles inside the polygon become fully solid too.
+func (l *Level) applyCollision(segs []segment, closed bool) {
+	type crossing struct {
+		seg    segment
+		length float64
+	}
+	crossings := map[m.Pos]crossing{}
+	var minTile, maxTile m.Pos
+	for i, s := range segs {
+		a, b := s.a.Div(TileSize), s.b.Div(TileSize)
+		lo := m.Pos{X: min(a.X, b.X), Y: min(a.Y, b.Y)}
+		hi := m.Pos{X: max(a.X, b.X), Y: max(a.Y, b.Y)}
+		if i == 0 {
+			minTile, maxTile = lo, hi
+		} else {
+			minTile = m.Pos{X: min(minTile.X, lo.X), Y: min(minTile.Y, lo.Y)}
+			maxTile = m.Pos{X: max(maxTile.X, hi.X), Y: max(maxTile.Y, hi.Y)}
+		}
+		for y := lo.Y; y <= hi.Y; y++ {
+			for x := lo.X; x <= hi.X; x++ {
+				pos := m.Pos{X: x, Y: y}
+				length := s.clippedLength(pos)
+				if length > crossings[pos].length {
+					crossings[pos] = crossing{seg: s, length: length}
+				}
+			}
+		}
+	}
+	for y := minTile.Y; y <= maxTile.Y; y++ {
+		for x := minTile.X; x <= maxTile.X; x++ {
+			pos := m.Pos{X: x, Y: y}
+			if !l.InBounds(pos) {
+				continue
+			}
+			t := l.Tile(pos)
+			if t == nil {
+				continue
+			}
+			if t.Tile.Contents&SolidContents == SolidContents && t.Tile.Slope.IsZero() {
+				// Already fully solid.
+				continue
+			}
+			var slope Slope
+			if c, found := crossings[pos]; found {
+				slope = c.seg.slope(pos)
+				some, all := slope.coverage()
+				if !some {
+					continue
+				}
+				if all {
+					slope = Slope{}
+				}
+			} else {
+				center := pos.Mul(TileSize).Add(m.Delta{DX: TileSize / 2, DY: TileSize / 2})
+				if !closed || !inside(segs, center) {
+					continue
+				}
+			}
+			t.Tile.Contents |= SolidContents
+			t.Tile.Slope = slope
+		}
+	}
+}
@@ -74,6 +74,10 @@ type EntityID int
 // Tiled's first entity has ID 1.
 const InvalidEntityID EntityID = 0
 
+// WorldFlagsEntityID is the pseudo entity ID under which world flags are saved.
+// Tiled never assigns negative IDs, so this cannot collide with a real entity.
+const WorldFlagsEntityID EntityID = -1
+
 // IsValid returns whether an EntityID is valid for an actual entity.
 func (e EntityID) IsValid() bool {
 	return e != InvalidEntityID
@@ -15,8 +15,13 @@
 package level
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 
 	"github.com/fardog/tmx"
 	"github.com/mitchellh/hashstructure/v2"
@@ -44,11 +49,23 @@ type Level struct {
 	CheckpointLocationsHash uint64
 	SaveGameVersion         int
 	CreditsMusic            string
+	Ambient                 string `hash:"-"` // Looping ambient sound played while the level is loaded.
+	Title                   string `hash:"-"`
 	Hash                    uint64 `hash:"-"`
 	QuestionBlocks          []*Spawnable
+	Backgrounds             []*Background `hash:"-"`
+
+	// WorldFlags is level-wide persistent state not tied to any entity.
+	// It is used by puzzles that span multiple rooms.
+	WorldFlags PersistentState `hash:"-"`
 
 	tiles []LevelTile
 	width int
+
+	// hashCached is set while Hash came from the disk cache and was not computed yet.
+	// VerifyHash then computes it on first use, when nothing can have modified the level yet.
+	hashCached   bool
+	hashCacheKey uint64
 }
 
 // Tile returns the tile at the given position.
@@ -65,6 +82,12 @@ func (l *Level) tilePos(pos m.Pos) int {
 	return pos.X + pos.Y*l.width
 }
 
+// InBounds returns whether the given position is inside the level rectangle.
+// Only positions inside may be passed to Tile.
+func (l *Level) InBounds(pos m.Pos) bool {
+	return pos.X >= 0 && pos.X < l.width && pos.Y >= 0 && l.tilePos(pos) < len(l.tiles)
+}
+
 // ForEachTile iterates over all tiles in the level.
 func (l *Level) ForEachTile(f func(pos m.Pos, t *LevelTile)) {
 	for i := range l.tiles {
@@ -72,6 +95,43 @@ func (l *Level) ForEachTile(f func(pos m.Pos, t *LevelTile)) {
 	}
 }
 
+// SpawnableByID returns the spawnable with the given ID, if any.
+// This scans the whole level and is meant for debugging only.
+func (l *Level) SpawnableByID(id EntityID) *Spawnable {
+	for i := range l.tiles {
+		if !l.tiles[i].Valid {
+			continue
+		}
+		for _, sp := range l.tiles[i].Tile.Spawnables {
+			if sp.ID == id {
+				return sp
+			}
+		}
+	}
+	return nil
+}
+
+// New creates an empty level of the given size.
+// Mainly useful for tests; the caller has to set Player and add tiles.
+func New(width, height int) *Level {
+	return &Level{
+		Checkpoints:           map[string]*Spawnable{},
+		TnihSignsByCheckpoint: map[string][]*Spawnable{},
+		SaveGameVersion:       1,
+		tiles:                 make([]LevelTile, width*height),
+		width:                 width,
+	}
+}
+
+// SetTile sets the tile at the given position.
+func (l *Level) SetTile(pos m.Pos, t Tile) {
+	t.LevelPos = pos
+	l.tiles[l.tilePos(pos)] = LevelTile{
+		Tile:  t,
+		Valid: true,
+	}
+}
+
 // LevelTile is a single tile in the level.
 type LevelTile struct {
 	Tile      Tile
@@ -111,6 +171,10 @@ type SaveGame struct {
 
 	// Legacy hash for v0 save games.
 	Hash uint64 `json:",omitempty"`
+
+	// RNGSeed is the seed of the random number generators.
+	// It is deliberately not hashed so demo regression testing does not depend on it.
+	RNGSeed *int `json:",omitempty"`
 }
 
 // SaveGameData is a not-yet-hashed SaveGame.
@@ -122,7 +186,7 @@ type SaveGameData struct {
 
 // SaveGame returns the current state as a SaveGame.
 func (l *Level) SaveGame() (*SaveGame, error) {
-	if l.SaveGameVersion != 1 {
+	if l.SaveGameVersion != CurrentSaveGameVersion {
 		return nil, errors.New("please FIXME! On the next SaveGameVersion, please remove the SaveGameData v0 support, make all uint64 hashes `json:\",string\"`, and remove this check too")
 	}
 	save := &SaveGame{
@@ -144,6 +208,9 @@ func (l *Level) SaveGame() (*SaveGame, error) {
 		}
 	})
 	saveOne(l.Player)
+	if !propmap.Empty(l.WorldFlags) {
+		save.State[WorldFlagsEntityID] = l.WorldFlags
+	}
 	var err error
 	save.StateHash, err = hashstructure.Hash(save.State, hashstructure.FormatV2, nil)
 	if err != nil {
@@ -185,6 +252,11 @@ func (l *Level) Clone() *Level {
 			outSigns[i] = clone(sign)
 		}
 	}
+	out.WorldFlags = propmap.New()
+	propmap.ForEach(l.WorldFlags, func(k, v string) error {
+		propmap.Set(out.WorldFlags, k, v)
+		return nil
+	})
 	out.QuestionBlocks = make([]*Spawnable, len(l.QuestionBlocks))
 	for i, q := range l.QuestionBlocks {
 		out.QuestionBlocks[i] = clone(q)
@@ -243,24 +315,28 @@ func (l *Level) LoadGame(save *SaveGame) error {
 	if save.LevelHash != l.Hash {
 		log.Warningf("save game does not match level hash: got %v, want %v; trying to load anyway", save.LevelHash, l.Hash)
 	}
-	loadOne := func(sp *Spawnable) {
+	loadState := func(state PersistentState, id EntityID) {
 		// Do not reallocate the map! Works better with already loaded entities.
-		propmap.ForEach(sp.PersistentState, func(k, _ string) error {
-			propmap.Delete(sp.PersistentState, k)
+		propmap.ForEach(state, func(k, _ string) error {
+			propmap.Delete(state, k)
 			return nil
 		})
-		// Due to aliasing, we can't just do sp.PersistentState = save.State[sp.ID].
-		propmap.ForEach(save.State[sp.ID], func(k, v string) error {
-			propmap.Set(sp.PersistentState, k, v)
+		// Due to aliasing, we can't just do state = save.State[id].
+		propmap.ForEach(save.State[id], func(k, v string) error {
+			propmap.Set(state, k, v)
 			return nil
 		})
 	}
+	loadOne := func(sp *Spawnable) {
+		loadState(sp.PersistentState, sp.ID)
+	}
 	l.ForEachTile(func(_ m.Pos, tile *LevelTile) {
 		for _, sp := range tile.Tile.Spawnables {
 			loadOne(sp)
 		}
 	})
 	loadOne(l.Player)
+	loadState(l.WorldFlags, WorldFlagsEntityID)
 	return nil
 }
 
@@ -307,13 +383,23 @@ func (l *Level) applyTileMod(startTile, endTile m.Pos, mods propmap.Map) {
 }
 
 func FetchTileset(ts *tmx.TileSet) error {
+	return fetchTileset(ts, nil)
+}
+
+// fetchTileset loads an external tileset, and records its file content in inputs.
+func fetchTileset(ts *tmx.TileSet, inputs *cacheInputs) error {
 	if ts.Source != "" {
 		r, err := vfs.LoadPath("tiles", ts.Source)
 		if err != nil {
 			return fmt.Errorf("could not open tileset: %w", err)
 		}
 		defer r.Close()
-		decoded, err := tmx.DecodeTileset(r)
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("could not read tileset: %w", err)
+		}
+		inputs.add(data)
+		decoded, err := tmx.DecodeTileset(bytes.NewReader(data))
 		if err != nil {
 			return fmt.Errorf("could not decode tileset: %w", err)
 		}
@@ -340,7 +426,20 @@ func FetchTileset(ts *tmx.TileSet) error {
 	return nil
 }
 
-func parseTmx(t *tmx.Map) (*Level, error) {
+// CurrentSaveGameVersion is the save_game_version this code can write save games for.
+const CurrentSaveGameVersion = 1
+
+var (
+	// ErrUnpairedWarpZone is wrapped by errors about a WarpZone without exactly one partner.
+	ErrUnpairedWarpZone = errors.New("unpaired WarpZone")
+	// ErrOutsideMap is wrapped by errors about objects reaching outside the map.
+	ErrOutsideMap = errors.New("outside map bounds")
+)
+
+// parseTmx parses a decoded map, and records all further files and translations it uses in inputs.
+// Problems that do not prevent the rest of the map from loading are passed to problem;
+// parsing continues if it returns nil.
+func parseTmx(t *tmx.Map, tr *translation, inputs *cacheInputs, problem func(error) error) (*Level, error) {
 	if t.Orientation != "orthogonal" {
 		return nil, fmt.Errorf("unsupported map: got orientation %q, want orthogonal", t.Orientation)
 	}
@@ -360,11 +459,9 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 		return nil, fmt.Errorf("unsupported map: got %d layers, want 1", len(t.Layers))
 	}
 	// t.ObjectGroups used later.
-	if len(t.ImageLayers) != 0 {
-		return nil, fmt.Errorf("unsupported map: got %d image layers, want 0", len(t.ImageLayers))
-	}
+	// t.ImageLayers used later.
 	for i := range t.TileSets {
-		err := FetchTileset(&t.TileSets[i])
+		err := fetchTileset(&t.TileSets[i], inputs)
 		if err != nil {
 			return nil, fmt.Errorf("unsupported map: failed to decode tileset %d: %w", i, err)
 		}
@@ -392,6 +489,15 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 	if prop := t.Properties.WithName("credits_music"); prop != nil {
 		creditsMusic = prop.Value
 	}
+	var ambient string
+	if prop := t.Properties.WithName("ambient"); prop != nil {
+		ambient = prop.Value
+	}
+	var title string
+	if prop := t.Properties.WithName("title"); prop != nil {
+		title = tr.l.Get(prop.Value) // "Unsupported call" warning expected here.
+		inputs.add([]byte(title))
+	}
 	var checkpointLocationsHash uint64
 	if prop := t.Properties.WithName("checkpoint_locations_hash"); prop != nil {
 		_, err := fmt.Sscanf(prop.Value, "%d", &checkpointLocationsHash)
@@ -405,9 +511,23 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 		CheckpointLocationsHash: checkpointLocationsHash,
 		SaveGameVersion:         int(saveGameVersion),
 		CreditsMusic:            creditsMusic,
+		Ambient:                 ambient,
+		Title:                   title,
+		WorldFlags:              propmap.New(),
 		tiles:                   make([]LevelTile, layer.Width*layer.Height),
 		width:                   layer.Width,
 	}
+	level.Backgrounds, err = parseMapBackgrounds(t.Properties)
+	if err != nil {
+		return nil, err
+	}
+	for i := range t.ImageLayers {
+		bg, err := parseImageLayer(&t.ImageLayers[i])
+		if err != nil {
+			return nil, err
+		}
+		level.Backgrounds = append(level.Backgrounds, bg)
+	}
 	var parseErr error
 	var tnihSigns []*Spawnable
 	checkpoints := map[EntityID]*Spawnable{}
@@ -451,6 +571,13 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 		if propmap.ValueOrP(properties, "opaque", true, &parseErr) {
 			contents |= OpaqueContents
 		}
+		var slope Slope
+		if slopeStr := propmap.StringOr(properties, "slope", ""); slopeStr != "" {
+			slope, err = ParseSlope(slopeStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid map: %w", err)
+			}
+		}
 		imgSrc := td.Tile.Image.Source
 		imgSrcByOrientation, err := ParseImageSrcByOrientation(imgSrc, properties)
 		if err != nil {
@@ -459,6 +586,7 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 		level.tiles[level.tilePos(pos)] = LevelTile{
 			Tile: Tile{
 				Contents:              contents,
+				Slope:                 slope.Transform(orientation),
 				LevelPos:              pos,
 				ImageSrc:              imgSrc,
 				imageSrcByOrientation: imgSrcByOrientation,
@@ -495,9 +623,7 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 				propmap.Set(properties, "name", o.Name)
 			}
 			// o.X, o.Y, o.Width, o.Height used later.
-			if o.Rotation != 0 {
-				return nil, fmt.Errorf("unsupported map: object %v has a rotation (maybe implement this?)", o.ObjectID)
-			}
+			// o.Rotation used later.
 			if o.GlobalID != 0 {
 				var tile *tmx.Tile
 				for k := range t.TileSets {
@@ -523,12 +649,7 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 				}
 			}
 			// o.Visible not used (we allow it though as it may help in the editor).
-			if o.Polygons != nil {
-				return nil, fmt.Errorf("unsupported map: object %v has polygons", o.ObjectID)
-			}
-			if o.Polylines != nil {
-				return nil, fmt.Errorf("unsupported map: object %v has polylines", o.ObjectID)
-			}
+			// o.Polygons, o.Polylines used later.
 			if o.Image.Source != "" {
 				propmap.Set(properties, "type", "Sprite")
 				propmap.Set(properties, "image_dir", "sprites")
@@ -552,13 +673,38 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 					DY: int(o.Height),
 				},
 			}
+			// Tiled rotates objects clockwise around their top left corner.
+			rotation, err := objectRotation(o.Rotation)
+			if err != nil {
+				return nil, fmt.Errorf("unsupported map: object %v: %w", o.ObjectID, err)
+			}
+			if len(o.Polygons) != 0 || len(o.Polylines) != 0 {
+				// Polygons and polylines are static collision shapes, not entities.
+				for k := range o.Polygons {
+					segs, err := collisionSegments(entRect.Origin, rotation, &o.Polygons[k], true)
+					if err != nil {
+						return nil, fmt.Errorf("invalid polygon in object %v: %w", o.ObjectID, err)
+					}
+					level.applyCollision(segs, true)
+				}
+				for k := range o.Polylines {
+					segs, err := collisionSegments(entRect.Origin, rotation, &o.Polylines[k], false)
+					if err != nil {
+						return nil, fmt.Errorf("invalid polyline in object %v: %w", o.ObjectID, err)
+					}
+					level.applyCollision(segs, false)
+				}
+				continue
+			}
+			entRect = rotation.ApplyToRect2(entRect.Origin.Mul(2), entRect)
 			objType := propmap.ValueP(properties, "type", "", &parseErr)
 			propmap.Delete(properties, "type")
 			propmap.DebugSetType(properties, objType)
 			hasText := false
 			for _, prop := range []string{"text", "text_if_flipped"} {
 				if text, err := propmap.Value(properties, prop, ""); err == nil {
-					translated := locale.L.Get(text) // "Unsupported call" warning expected here.
+					translated := tr.l.Get(text) // "Unsupported call" warning expected here.
+					inputs.add([]byte(translated))
 					// log.Infof("translated %v -> %v", text, translated)
 					propmap.Set(properties, prop, translated)
 					hasText = true
@@ -573,7 +719,7 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 			orientation := propmap.ValueOrP(properties, "orientation", m.Identity(), &parseErr)
 			if hasText {
 				var cjkOrientation m.Orientation
-				switch locale.ActivePrefersVerticalText() {
+				switch tr.verticalText {
 				case locale.NeverPreferVerticalText:
 					cjkOrientation = m.Orientation{}
 				case locale.DefaultPreferVerticalText:
@@ -587,6 +733,8 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 					orientation = cjkOrientation
 				}
 			}
+			// The orientation property applies to the unrotated object.
+			orientation = rotation.Concat(orientation)
 			if objType == "WarpZone" {
 				// WarpZones must be paired by name.
 				name := propmap.ValueP(properties, "name", "", &parseErr)
@@ -646,7 +794,11 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 					pos := m.Pos{X: x, Y: y}
 					levelTile := level.Tile(pos)
 					if levelTile == nil {
-						return nil, fmt.Errorf("invalid entity location: outside map bounds: %v in %v", pos, ent)
+						err := problem(fmt.Errorf("invalid entity location: %w: %v in %v", ErrOutsideMap, pos, ent))
+						if err != nil {
+							return nil, err
+						}
+						continue
 					}
 					levelTile.Tile.Spawnables = append(levelTile.Tile.Spawnables, ent)
 				}
@@ -685,10 +837,15 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 	for warpname := range warpZones {
 		warpnames = append(warpnames, warpname)
 	}
+	sort.Strings(warpnames)
 	for _, warpname := range warpnames {
 		warppair := warpZones[warpname]
 		if len(warppair) != 2 {
-			return nil, fmt.Errorf("unpaired WarpZone %q: got %d, want 2", warpname, len(warppair))
+			err := problem(fmt.Errorf("%w %q: got %d, want 2", ErrUnpairedWarpZone, warpname, len(warppair)))
+			if err != nil {
+				return nil, err
+			}
+			continue
 		}
 		for a := 0; a < 2; a++ {
 			from := warppair[a]
@@ -710,11 +867,19 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 					toPos := toPos2.Div(2).Add(to.Orientation.Apply(m.West()))
 					levelTile := level.Tile(fromPos)
 					if levelTile == nil {
-						return nil, fmt.Errorf("invalid WarpZone location: outside map bounds: %v in %v", fromPos, warppair)
+						err := problem(fmt.Errorf("invalid WarpZone %q location: %w: %v", warpname, ErrOutsideMap, fromPos))
+						if err != nil {
+							return nil, err
+						}
+						continue
 					}
 					toTile := level.Tile(toPos)
 					if toTile == nil {
-						return nil, fmt.Errorf("invalid WarpZone destination location: outside map bounds: %v in %v", toPos, warppair)
+						err := problem(fmt.Errorf("invalid WarpZone %q destination location: %w: %v", warpname, ErrOutsideMap, toPos))
+						if err != nil {
+							return nil, err
+						}
+						continue
 					}
 					levelTile.WarpZones = append(levelTile.WarpZones, &WarpZone{
 						Name:       warpname,
@@ -731,17 +896,38 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 	return &level, nil
 }
 
+// translation is the language state a Loader uses.
+// It is captured when the Loader is created, so the language may change while loading runs in the background.
+type translation struct {
+	g, l         locale.Type
+	active       locale.Lingua
+	verticalText locale.VerticalTextPreference
+}
+
 type Loader struct {
 	filename                         string
+	mapFile                          string
+	problems                         func(error)
 	skipCheckpointLocations          bool
 	skipComparingCheckpointLocations bool
+	tr                               translation
 
 	level   *Level
 	tmxData *tmx.Map
+	// inputs are the contents of all files and translations the level was loaded from.
+	inputs cacheInputs
 }
 
 func NewLoader(filename string) *Loader {
-	return &Loader{filename: filename}
+	return &Loader{
+		filename: filename,
+		tr: translation{
+			g:            locale.G,
+			l:            locale.L,
+			active:       locale.Active,
+			verticalText: locale.ActivePrefersVerticalText(),
+		},
+	}
 }
 
 func (l *Loader) SkipCheckpointLocations(s bool) *Loader {
@@ -754,6 +940,28 @@ func (l *Loader) SkipComparingCheckpointLocations(s bool) *Loader {
 	return l
 }
 
+// FromFile makes the loader read the map from the given file instead of from the "maps" asset directory.
+// Tilesets are still loaded from the assets.
+func (l *Loader) FromFile(path string) *Loader {
+	l.mapFile = path
+	return l
+}
+
+// ReportProblems makes the loader pass problems that do not prevent loading the rest of the level to f
+// instead of failing.
+func (l *Loader) ReportProblems(f func(err error)) *Loader {
+	l.problems = f
+	return l
+}
+
+func (l *Loader) problem(err error) error {
+	if l.problems == nil {
+		return err
+	}
+	l.problems(err)
+	return nil
+}
+
 func (l *Loader) Level() *Level {
 	return l.level
 }
@@ -763,15 +971,48 @@ func (l *Loader) Load() (*Level, error) {
 	return l.level, err
 }
 
+// readMap returns the contents of the map file.
+func (l *Loader) readMap() ([]byte, error) {
+	if l.mapFile != "" {
+		data, err := os.ReadFile(l.mapFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read map: %w", err)
+		}
+		return data, nil
+	}
+	r, err := vfs.Load("maps", l.filename+".tmx")
+	if err != nil {
+		return nil, fmt.Errorf("could not open map: %w", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read map: %w", err)
+	}
+	return data, nil
+}
+
 // LoadStepwise loads a level in steps.
 func (l *Loader) LoadStepwise(s *splash.State) (splash.Status, error) {
-	status, err := s.Enter("loading level file", locale.G.Get("loading level file"), "could not load level file", splash.Single(func() error {
-		r, err := vfs.Load("maps", l.filename+".tmx")
+	status, err := s.Enter("loading level file", l.tr.g.Get("loading level file"), "could not load level file", splash.Single(func() error {
+		tmxBytes, err := l.readMap()
 		if err != nil {
-			return fmt.Errorf("could not open map: %w", err)
+			return err
 		}
-		defer r.Close()
-		t, err := tmx.Decode(r)
+		l.inputs.add(tmxBytes)
+		t, err := tmx.Decode(bytes.NewReader(tmxBytes))
+		if err != nil {
+			return fmt.Errorf("invalid map: %w", err)
+		}
+		err = fixImageLayerOpacity(tmxBytes, t)
+		if err != nil {
+			return fmt.Errorf("invalid map: %w", err)
+		}
+		templateTexts, err := fixTemplates(tmxBytes, t, &l.inputs)
+		if err != nil {
+			return fmt.Errorf("invalid map: %w", err)
+		}
+		err = fixTextObjects(tmxBytes, t, templateTexts)
 		if err != nil {
 			return fmt.Errorf("invalid map: %w", err)
 		}
@@ -781,8 +1022,8 @@ func (l *Loader) LoadStepwise(s *splash.State) (splash.Status, error) {
 	if status != splash.Continue {
 		return status, err
 	}
-	status, err = s.Enter("parsing level data", locale.G.Get("parsing level data"), "could not parse level data", splash.Single(func() error {
-		level, err := parseTmx(l.tmxData)
+	status, err = s.Enter("parsing level data", l.tr.g.Get("parsing level data"), "could not parse level data", splash.Single(func() error {
+		level, err := parseTmx(l.tmxData, &l.tr, &l.inputs, l.problem)
 		if err != nil {
 			return err
 		}
@@ -793,9 +1034,36 @@ func (l *Loader) LoadStepwise(s *splash.State) (splash.Status, error) {
 		return status, err
 	}
 	if !l.skipCheckpointLocations {
-		status, err = s.Enter("loading checkpoints", locale.G.Get("loading checkpoints"), "could not load checkpoint locations", splash.Single(func() error {
-			var err error
-			l.level.CheckpointLocations, err = l.level.LoadCheckpointLocations(l.filename)
+		status, err = s.Enter("loading checkpoints", l.tr.g.Get("loading checkpoints"), "could not load checkpoint locations", splash.Single(func() error {
+			cpData, err := loadCheckpointGraphData(l.filename)
+			if err != nil {
+				log.Warningf("%v - generating checkpoint locations from the level instead", err)
+				g, genErr := l.level.GenerateCheckpointGraph()
+				if genErr != nil {
+					return fmt.Errorf("%w; could not generate them either: %v", err, genErr)
+				}
+				cpData, err = json.Marshal(g)
+				if err != nil {
+					return err
+				}
+			}
+			// Maps that do not set checkpoint_locations_hash accept whatever locations they get.
+			compare := !l.skipComparingCheckpointLocations && l.level.CheckpointLocationsHash != 0
+			// Only use the cache if the result can be verified.
+			useCache := compare
+			l.inputs.add(cpData)
+			cacheKey := checkpointLocationsCacheKey(l.inputs)
+			if useCache {
+				if loc, found := loadCachedCheckpointLocations(cacheKey); found {
+					h, err := hashstructure.Hash(loc, hashstructure.FormatV2, nil)
+					if err == nil && h == l.level.CheckpointLocationsHash {
+						l.level.CheckpointLocations = loc
+						return nil
+					}
+					log.Warningf("cached checkpoint locations are invalid, recomputing")
+				}
+			}
+			l.level.CheckpointLocations, err = l.level.checkpointLocationsFromData(l.filename, cpData)
 			if err != nil {
 				return err
 			}
@@ -803,21 +1071,37 @@ func (l *Loader) LoadStepwise(s *splash.State) (splash.Status, error) {
 			if err != nil {
 				return err
 			}
-			if !l.skipComparingCheckpointLocations {
+			if compare {
 				if h != l.level.CheckpointLocationsHash {
 					return fmt.Errorf("checkpoint location hash mismatch: got %v, want %v - may need to update level file?", h, l.level.CheckpointLocationsHash)
 				}
 			}
+			if useCache {
+				storeCachedCheckpointLocations(cacheKey, l.level.CheckpointLocations)
+			}
 			return nil
 		}))
 		if status != splash.Continue {
 			return status, err
 		}
 	}
-	status, err = s.Enter("hashing level", locale.G.Get("hashing level"), "could not hash level", splash.Single(func() error {
+	status, err = s.Enter("hashing level", l.tr.g.Get("hashing level"), "could not hash level", splash.Single(func() error {
+		// The cache key covers all inputs of the level, so the cached hash is very likely right.
+		// It is still checked on first use by VerifyHash.
+		cacheKey := hashCacheKey(l.inputs, &l.tr, !l.skipCheckpointLocations)
+		if hash, found := loadCachedHash(cacheKey); found {
+			l.level.Hash = hash
+			l.level.hashCached = true
+			l.level.hashCacheKey = cacheKey
+			return nil
+		}
 		var err error
 		l.level.Hash, err = hashstructure.Hash(l.level, hashstructure.FormatV2, nil)
-		return err
+		if err != nil {
+			return err
+		}
+		storeCachedHash(cacheKey, l.level.Hash)
+		return nil
 	}))
 	if status != splash.Continue {
 		return status, err
@@ -831,6 +1115,15 @@ func (l *Level) VerifyHash() error {
 	if err != nil {
 		return fmt.Errorf("could not hash level: %w", err)
 	}
+	if l.hashCached {
+		l.hashCached = false
+		if hash != l.Hash {
+			log.Warningf("cached level hash is stale: got %v, want %v; updating the cache", l.Hash, hash)
+			l.Hash = hash
+			storeCachedHash(l.hashCacheKey, hash)
+		}
+		return nil
+	}
 	if hash != l.Hash {
 		log.Fatalf("could not verify")
 		return fmt.Errorf("level hash mismatch: got %v, want %v", hash, l.Hash)
@@ -838,6 +1131,24 @@ func (l *Level) VerifyHash() error {
 	return nil
 }
 
+// objectRotation converts a Tiled object rotation in degrees clockwise to an orientation.
+// Only multiples of 90 degrees are supported.
+func objectRotation(degrees int) (m.Orientation, error) {
+	if degrees%90 != 0 {
+		return m.Orientation{}, fmt.Errorf("got rotation %v, want a multiple of 90 degrees", degrees)
+	}
+	switch (degrees/90%4 + 4) % 4 {
+	case 1:
+		return m.Right(), nil
+	case 2:
+		return m.TurnAround(), nil
+	case 3:
+		return m.Left(), nil
+	default:
+		return m.Identity(), nil
+	}
+}
+
 // ParseImageSrcByOrientation parses the imgSrcByOrientation map.
 func ParseImageSrcByOrientation(defaultSrc string, properties propmap.Map) (map[m.Orientation]string, error) {
 	imgSrcByOrientation := make(map[m.Orientation]string, len(m.AllOrientations))
@@ -0,0 +1,113 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"bytes"
+	"fmt"
+
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// Slope describes the solid half-plane of a slope tile.
+//
+// A pixel of the tile is solid if Normal.Dot(2*pixel+1-TileSize) >= Offset,
+// i.e. the test is performed on doubled coordinates relative to the tile center.
+//
+// The zero value means the tile is not a slope, i.e. fully solid.
+type Slope struct {
+	Normal m.Delta
+	Offset int
+}
+
+// ParseSlope parses a slope from a string.
+//
+// The string contains the height of the solid part on the left and on the
+// right edge of the tile image, e.g. "0 16" for a 45 degree slope going up to
+// the right, or "0 8" and "8 16" for a 22.5 degree slope spanning two tiles.
+// Everything below the line connecting these two points is solid.
+func ParseSlope(s string) (Slope, error) {
+	var left, right int
+	_, err := fmt.Fscanf(bytes.NewReader([]byte(s)), "%d %d", &left, &right)
+	if err != nil {
+		return Slope{}, fmt.Errorf("invalid slope %q: %w", s, err)
+	}
+	if left < 0 || left > TileSize || right < 0 || right > TileSize {
+		return Slope{}, fmt.Errorf("invalid slope %q: heights must be between 0 and %d", s, TileSize)
+	}
+	if left == TileSize && right == TileSize {
+		// Fully solid; no need to special case.
+		return Slope{}, nil
+	}
+	// A pixel is solid if its center is at or below the line.
+	// Height of pixel center above tile bottom: h = (TileSize - v) / 2.
+	// Line height at pixel center: left + (right - left) * (u + TileSize) / (2 * TileSize).
+	// Multiplying by 2*TileSize and reordering yields the half-plane below.
+	return Slope{
+		Normal: m.Delta{DX: right - left, DY: TileSize},
+		Offset: TileSize*TileSize - TileSize*(left+right),
+	}, nil
+}
+
+// IsZero returns whether the slope is the zero value, i.e. no slope.
+func (s Slope) IsZero() bool {
+	return s.Normal.IsZero()
+}
+
+// Transform returns the slope as seen after applying the given orientation to the tile.
+func (s Slope) Transform(o m.Orientation) Slope {
+	if s.IsZero() {
+		return s
+	}
+	// As all orientations are orthogonal, the normal can just be rotated along.
+	return Slope{
+		Normal: o.Apply(s.Normal),
+		Offset: s.Offset,
+	}
+}
+
+// SolidInRect returns whether any pixel of the given rectangle, given relative to the tile origin, is solid.
+// Pixels outside the tile are ignored.
+func (s Slope) SolidInRect(r m.Rect) bool {
+	if r.Origin.X >= TileSize || r.Origin.Y >= TileSize || r.Origin.X+r.Size.DX <= 0 || r.Origin.Y+r.Size.DY <= 0 {
+		return false
+	}
+	// Only the pixel that is deepest in the half-plane matters.
+	var p m.Pos
+	if s.Normal.DX > 0 {
+		p.X = r.Origin.X + r.Size.DX - 1
+		if p.X >= TileSize {
+			p.X = TileSize - 1
+		}
+	} else {
+		p.X = r.Origin.X
+		if p.X < 0 {
+			p.X = 0
+		}
+	}
+	if s.Normal.DY > 0 {
+		p.Y = r.Origin.Y + r.Size.DY - 1
+		if p.Y >= TileSize {
+			p.Y = TileSize - 1
+		}
+	} else {
+		p.Y = r.Origin.Y
+		if p.Y < 0 {
+			p.Y = 0
+		}
+	}
+	d := m.Delta{DX: 2*p.X + 1 - TileSize, DY: 2*p.Y + 1 - TileSize}
+	return s.Normal.Dot(d) >= s.Offset
+}
@@ -0,0 +1,195 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/fardog/tmx"
+
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// template is a decoded Tiled object template.
+type template struct {
+	object  tmx.Object
+	text    *rawText
+	tileset *struct {
+		FirstGlobalID tmx.GlobalID `xml:"firstgid,attr"`
+		Source        string       `xml:"source,attr"`
+	}
+}
+
+// loadTemplate loads an object template, and records its file content in inputs.
+// Template paths are relative to the map.
+func loadTemplate(source string, inputs *cacheInputs) (*template, error) {
+	r, err := vfs.LoadPath("maps", source)
+	if err != nil {
+		return nil, fmt.Errorf("could not open template: %w", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read template: %w", err)
+	}
+	inputs.add(data)
+	var decoded struct {
+		TileSet *struct {
+			FirstGlobalID tmx.GlobalID `xml:"firstgid,attr"`
+			Source        string       `xml:"source,attr"`
+		} `xml:"tileset"`
+		Object tmx.Object `xml:"object"`
+	}
+	err = xml.NewDecoder(bytes.NewReader(data)).Decode(&decoded)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode template: %w", err)
+	}
+	// The TMX decoder drops the attributes of text elements, so read them separately.
+	var text struct {
+		Object struct {
+			Text *rawText `xml:"text"`
+		} `xml:"object"`
+	}
+	err = xml.NewDecoder(bytes.NewReader(data)).Decode(&text)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode template: %w", err)
+	}
+	return &template{
+		object:  decoded.Object,
+		text:    text.Object.Text,
+		tileset: decoded.TileSet,
+	}, nil
+}
+
+// globalID maps a global tile ID of the template to the given map.
+func (tpl *template) globalID(t *tmx.Map, gid tmx.GlobalID) (tmx.GlobalID, error) {
+	if gid == 0 {
+		return 0, nil
+	}
+	if tpl.tileset == nil {
+		return 0, fmt.Errorf("template references tile %d but has no tileset", gid)
+	}
+	for k := range t.TileSets {
+		ts := &t.TileSets[k]
+		if path.Base(ts.Source) != path.Base(tpl.tileset.Source) {
+			continue
+		}
+		flags := gid & tmx.TileFlipped
+		return flags | tmx.GlobalID(gid.BareID()-uint32(tpl.tileset.FirstGlobalID)+uint32(ts.FirstGlobalID)), nil
+	}
+	return 0, fmt.Errorf("template tileset %q is not used by the map", tpl.tileset.Source)
+}
+
+// fixTemplates resolves object templates.
+// Attributes and properties set on the object override those from the template.
+// Returns the text elements of objects that got one from their template.
+func fixTemplates(tmxBytes []byte, t *tmx.Map, inputs *cacheInputs) (map[tmx.ObjectID]*rawText, error) {
+	type rawObject struct {
+		ObjectID tmx.ObjectID  `xml:"id,attr"`
+		Template string        `xml:"template,attr"`
+		Name     *string       `xml:"name,attr"`
+		Type     *string       `xml:"type,attr"`
+		Width    *float64      `xml:"width,attr"`
+		Height   *float64      `xml:"height,attr"`
+		Rotation *int          `xml:"rotation,attr"`
+		GlobalID *tmx.GlobalID `xml:"gid,attr"`
+		Text     *struct{}     `xml:"text"`
+	}
+	var raw struct {
+		ObjectGroups []struct {
+			Objects []rawObject `xml:"object"`
+		} `xml:"objectgroup"`
+	}
+	err := xml.NewDecoder(bytes.NewReader(tmxBytes)).Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+	instances := map[tmx.ObjectID]*rawObject{}
+	for i := range raw.ObjectGroups {
+		for j := range raw.ObjectGroups[i].Objects {
+			o := &raw.ObjectGroups[i].Objects[j]
+			if o.Template != "" {
+				instances[o.ObjectID] = o
+			}
+		}
+	}
+	if len(instances) == 0 {
+		return nil, nil
+	}
+	templates := map[string]*template{}
+	texts := map[tmx.ObjectID]*rawText{}
+	for i := range t.ObjectGroups {
+		og := &t.ObjectGroups[i]
+		for j := range og.Objects {
+			o := &og.Objects[j]
+			inst := instances[o.ObjectID]
+			if inst == nil {
+				continue
+			}
+			tpl := templates[inst.Template]
+			if tpl == nil {
+				tpl, err = loadTemplate(inst.Template, inputs)
+				if err != nil {
+					return nil, fmt.Errorf("object %v: %w", o.ObjectID, err)
+				}
+				templates[inst.Template] = tpl
+			}
+			to := &tpl.object
+			if inst.Name == nil {
+				o.Name = to.Name
+			}
+			if inst.Type == nil {
+				o.Type = to.Type
+			}
+			if inst.Width == nil {
+				o.Width = to.Width
+			}
+			if inst.Height == nil {
+				o.Height = to.Height
+			}
+			if inst.Rotation == nil {
+				o.Rotation = to.Rotation
+			}
+			if inst.GlobalID == nil {
+				o.GlobalID, err = tpl.globalID(t, to.GlobalID)
+				if err != nil {
+					return nil, fmt.Errorf("object %v: %w", o.ObjectID, err)
+				}
+			}
+			if o.Polygons == nil && o.Polylines == nil {
+				o.Polygons = to.Polygons
+				o.Polylines = to.Polylines
+			}
+			if o.Image.Source == "" {
+				o.Image = to.Image
+			}
+			if inst.Text == nil && tpl.text != nil {
+				texts[o.ObjectID] = tpl.text
+			}
+			props := make(tmx.Properties, 0, len(to.Properties)+len(o.Properties))
+			for _, prop := range to.Properties {
+				if o.Properties.WithName(prop.Name) == nil {
+					props = append(props, prop)
+				}
+			}
+			o.Properties = append(props, o.Properties...)
+		}
+	}
+	return texts, nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+
+	"github.com/fardog/tmx"
+)
+
+// rawText is a Tiled text element, which the TMX decoder only keeps the content of.
+type rawText struct {
+	FontFamily string `xml:"fontfamily,attr"`
+	Wrap       int    `xml:"wrap,attr"`
+	Color      string `xml:"color,attr"`
+	Bold       int    `xml:"bold,attr"`
+	Italic     int    `xml:"italic,attr"`
+	Content    string `xml:",chardata"`
+}
+
+// tiledTextColor converts a Tiled text color to the #aarrggbb format used by properties.
+func tiledTextColor(c string) (string, error) {
+	switch len(c) {
+	case 0:
+		// Tiled omits the attribute for black.
+		return "#ff000000", nil
+	case 7:
+		return "#ff" + c[1:], nil
+	case 9:
+		return c, nil
+	default:
+		return "", fmt.Errorf("invalid text color %q", c)
+	}
+}
+
+// fixTextObjects turns Tiled text objects into Text entities.
+// The font, color and wrapping of the text element become text_* properties,
+// unless the object sets these properties explicitly. Alignment is ignored,
+// as Text entities always center their text.
+// Objects without a text element of their own use the one from their template.
+func fixTextObjects(tmxBytes []byte, t *tmx.Map, templateTexts map[tmx.ObjectID]*rawText) error {
+	var raw struct {
+		ObjectGroups []struct {
+			Objects []struct {
+				ObjectID tmx.ObjectID `xml:"id,attr"`
+				Text     *rawText     `xml:"text"`
+			} `xml:"object"`
+		} `xml:"objectgroup"`
+	}
+	err := xml.NewDecoder(bytes.NewReader(tmxBytes)).Decode(&raw)
+	if err != nil {
+		return err
+	}
+	texts := map[tmx.ObjectID]*rawText{}
+	for id, text := range templateTexts {
+		texts[id] = text
+	}
+	for _, og := range raw.ObjectGroups {
+		for _, o := range og.Objects {
+			if o.Text != nil {
+				texts[o.ObjectID] = o.Text
+			}
+		}
+	}
+	for i := range t.ObjectGroups {
+		og := &t.ObjectGroups[i]
+		for j := range og.Objects {
+			o := &og.Objects[j]
+			text := texts[o.ObjectID]
+			if text == nil {
+				continue
+			}
+			if o.Type == "" {
+				o.Type = "Text"
+			}
+			font := text.FontFamily
+			if font == "" {
+				switch {
+				case text.Bold != 0:
+					font = "Bold"
+				case text.Italic != 0:
+					font = "Italic"
+				default:
+					font = "Regular"
+				}
+			}
+			fg, err := tiledTextColor(text.Color)
+			if err != nil {
+				return fmt.Errorf("object %v: %w", o.ObjectID, err)
+			}
+			props := []tmx.Property{
+				{Name: "text", Value: text.Content},
+				{Name: "text_font", Value: font},
+				{Name: "text_fg", Type: "color", Value: fg},
+			}
+			if text.Wrap != 0 {
+				props = append(props, tmx.Property{Name: "text_wrap_width", Type: "int", Value: strconv.Itoa(int(o.Width))})
+			}
+			for _, prop := range props {
+				if o.Properties.WithName(prop.Name) == nil {
+					o.Properties = append(o.Properties, prop)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -15,6 +15,8 @@
 package level
 
 import (
+	"strings"
+
 	m "github.com/divVerent/aaaaxy/internal/math"
 )
 
@@ -46,6 +48,23 @@ func (c Contents) ObjectSolid() bool {
 	return c&ObjectSolidContents != 0
 }
 
+func (c Contents) String() string {
+	if c.Empty() {
+		return "none"
+	}
+	var parts []string
+	if c.Opaque() {
+		parts = append(parts, "opaque")
+	}
+	if c.PlayerSolid() {
+		parts = append(parts, "playersolid")
+	}
+	if c.ObjectSolid() {
+		parts = append(parts, "objectsolid")
+	}
+	return strings.Join(parts, "|")
+}
+
 type VisibilityFlags int
 
 const (
@@ -57,6 +76,7 @@ const (
 type Tile struct {
 	// Info needed for gameplay.
 	Contents   Contents
+	Slope      Slope        // If set, only the pixels in this half-plane are solid.
 	Spawnables []*Spawnable // NOTE: not adjusted for transform!
 
 	// Info needed for loading more tiles.
@@ -85,6 +105,27 @@ type Tile struct {
 	LoadedFromNeighbor m.Pos
 }
 
+// FullyBlocks returns whether the tile stops every trace for the given contents.
+// Slope tiles only partially block solid contents and need a per-pixel check.
+func (t *Tile) FullyBlocks(c Contents) bool {
+	hit := c & t.Contents
+	if !t.Slope.IsZero() {
+		hit &^= SolidContents
+	}
+	return hit != 0
+}
+
+// SlopeBlocks returns whether the tile's slope needs to be checked for the given contents.
+func (t *Tile) SlopeBlocks(c Contents) bool {
+	return !t.Slope.IsZero() && c&t.Contents&SolidContents != 0
+}
+
+// ImageSrcByOrientation returns the images given by img.<orientation> properties, if any.
+// Only available before ResolveImage has been called.
+func (t *Tile) ImageSrcByOrientation() map[m.Orientation]string {
+	return t.imageSrcByOrientation
+}
+
 // ResolveImage applies imageSrcByOrientation data to Image, and possibly changes Orientation when it did.
 func (t *Tile) ResolveImage() {
 	t.ImageSrc, t.Orientation = ResolveImage(t.Transform, t.Orientation, t.ImageSrc, t.imageSrcByOrientation)
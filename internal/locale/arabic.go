@@ -22,7 +22,19 @@ import (
 	"github.com/hajimehoshi/bitmapfont/v3"
 )
 
-func (l Lingua) shapeArabic(s string) string {
+// rightToLeftMirror replaces characters by their mirrored forms as required by bidi rule L4.
+var rightToLeftMirror = strings.NewReplacer(
+	"(", ")", ")", "(",
+	"[", "]", "]", "[",
+	"{", "}", "}", "{",
+	"<", ">", ">", "<",
+	"«", "»", "»", "«",
+)
+
+// shapeRightToLeft converts the given string to its visual left-to-right
+// order for a right-to-left paragraph. Arabic letters get their presentation
+// forms; embedded left-to-right runs keep their order via the bidi algorithm.
+func (l Lingua) shapeRightToLeft(s string) string {
 	// Do not shape strings that are fully ASCII.
 	// That just wrecks things.
 	needsShape := false
@@ -39,7 +51,7 @@ func (l Lingua) shapeArabic(s string) string {
 	s = bitmapfont.PresentationForms(s, bitmapfont.DirectionRightToLeft, language.MustParse(string(l)))
 
 	// Mirroring. Sadly, it's not in PresentationForms() already.
-	s = strings.NewReplacer("(", ")", ")", "(").Replace(s)
+	s = rightToLeftMirror.Replace(s)
 
 	return s
 }
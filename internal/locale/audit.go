@@ -21,6 +21,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/leonelquinteros/gotext"
+
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/log"
 )
@@ -64,44 +66,95 @@ func formats(s string) map[string]int {
 	return out
 }
 
+// auditTranslation checks all forms of a translation for problems.
+func auditTranslation(k string, t *gotext.Translation) []error {
+	var errs []error
+	kbads := map[string]struct{}{}
+	for _, kbad := range badRE.FindAllString(k, -1) {
+		kbads[kbad] = struct{}{}
+	}
+	kf := formats(k)
+	var pf map[string]int
+	if t.PluralID != "" {
+		pf = formats(t.PluralID)
+	}
+	for _, v := range t.Trs {
+		vf := formats(v)
+		// Plural forms may match either the singular or the plural source.
+		if !reflect.DeepEqual(kf, vf) && (pf == nil || !reflect.DeepEqual(pf, vf)) {
+			errs = append(errs, fmt.Errorf("translation format string mismatch: %q (%v) -> %q (%v)", k, kf, v, vf))
+		}
+		for _, vbad := range badRE.FindAllString(v, -1) {
+			if _, found := kbads[vbad]; found {
+				// Same as original - probably OK then.
+				continue
+			}
+			errs = append(errs, fmt.Errorf("translation contains bad substring: %q -> %q (%q), matched by regexp %v", k, v, vbad, badRE))
+		}
+	}
+	return errs
+}
+
 func auditPo(po Type) error {
 	for k, vs := range po.GetDomain().GetTranslations() {
 		if k == "" {
 			// Not a real string, just a header.
 			continue
 		}
-		kbads := map[string]struct{}{}
-		for _, kbad := range badRE.FindAllString(k, -1) {
-			kbads[kbad] = struct{}{}
-		}
-		kf := formats(k)
-		for _, v := range vs.Trs {
-			vf := formats(v)
-			if !reflect.DeepEqual(kf, vf) {
-				err := fmt.Errorf("translation format string mismatch: %q (%v) -> %q (%v)", k, kf, v, vf)
-				if *debugCheckTranslations {
-					return err
-				} else {
-					log.Errorf("%v", err)
-				}
-			}
-			for _, vbad := range badRE.FindAllString(v, -1) {
-				if _, found := kbads[vbad]; found {
-					// Same as original - probably OK then.
-					continue
-				}
-				err := fmt.Errorf("translation contains bad substring: %q -> %q (%q), matched by regexp %v", k, v, vbad, badRE)
-				if *debugCheckTranslations {
-					return err
-				} else {
-					log.Errorf("%v", err)
-				}
+		for _, err := range auditTranslation(k, vs) {
+			if *debugCheckTranslations {
+				return err
+			} else {
+				log.Errorf("%v", err)
 			}
 		}
 	}
 	return nil
 }
 
+// translated returns whether any form of the translation is nonempty.
+func translated(t *gotext.Translation) bool {
+	for _, v := range t.Trs {
+		if v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate compares a translation domain with its template and logs
+// missing and format mismatched strings.
+func Validate(lang Lingua, domain string, template, po Type) {
+	trs := po.GetDomain().GetTranslations()
+	total, missing, mismatched := 0, 0, 0
+	for k := range template.GetDomain().GetTranslations() {
+		if k == "" {
+			// Not a real string, just a header.
+			continue
+		}
+		total++
+		t := trs[k]
+		if t == nil || !translated(t) {
+			log.Debugf("translation %s/%s: missing %q", lang, domain, k)
+			missing++
+			continue
+		}
+		errs := auditTranslation(k, t)
+		for _, err := range errs {
+			log.Warningf("translation %s/%s: %v", lang, domain, err)
+		}
+		if len(errs) != 0 {
+			mismatched++
+		}
+	}
+	percent := 100
+	if total > 0 {
+		percent = (total - missing) * 100 / total
+	}
+	log.Infof("translation %s/%s: %d of %d strings translated (%d%%), %d missing, %d with format mismatches",
+		lang, domain, total-missing, total, percent, missing, mismatched)
+}
+
 func Audit() error {
 	err := auditPo(G)
 	if err != nil {
@@ -46,6 +46,7 @@ func InitCurrent() {
 		log.Errorf("could not detect current locales: %v", err)
 		return
 	}
+	var tags []language.Tag
 	for _, loc := range locs {
 		lang, err := language.Parse(loc)
 		if err != nil {
@@ -54,6 +55,7 @@ func InitCurrent() {
 			}
 			continue
 		}
+		tags = append(tags, lang)
 		for lang != language.Und {
 			if tryInitCurrent(lang.String()) {
 				return
@@ -61,5 +63,33 @@ func InitCurrent() {
 			lang = lang.Parent()
 		}
 	}
+	// No exact match or parent; try matching by likely subtags,
+	// e.g. to map plain "zh" to "zh-Hans".
+	if lingua, found := matchCurrent(tags); found && tryInitCurrent(string(lingua)) {
+		return
+	}
 	log.Infof("detected no supported language (not translating)")
 }
+
+// matchCurrent finds the closest available language to the given system locales.
+func matchCurrent(tags []language.Tag) (Lingua, bool) {
+	if len(tags) == 0 {
+		return "", false
+	}
+	supported := []language.Tag{language.English}
+	linguas := []Lingua{"en"}
+	for _, l := range LinguasSorted() {
+		tag, err := language.Parse(string(l))
+		if err != nil {
+			// Not a BCP 47 tag (e.g. be@tarask); only matched exactly.
+			continue
+		}
+		supported = append(supported, tag)
+		linguas = append(linguas, l)
+	}
+	_, index, confidence := language.NewMatcher(supported).Match(tags...)
+	if confidence < language.High {
+		return "", false
+	}
+	return linguas[index], true
+}
@@ -20,6 +20,9 @@ import (
 	"io"
 	"strings"
 
+	"github.com/leonelquinteros/gotext"
+	"github.com/leonelquinteros/gotext/plurals"
+
 	"github.com/divVerent/aaaaxy/internal/exitstatus"
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/font"
@@ -30,8 +33,9 @@ import (
 )
 
 var (
-	language      = flag.String("language", "auto", "language to translate the game into; if set to 'auto', it will be detected using the system locale; set to '' to not translate")
-	dumpLanguages = flag.Bool("dump_languages", false, "just print the list of languages and exit")
+	language                  = flag.String("language", "auto", "language to translate the game into; if set to 'auto', it will be detected using the system locale; set to '' to not translate")
+	dumpLanguages             = flag.Bool("dump_languages", false, "just print the list of languages and exit")
+	debugValidateTranslations = flag.Bool("debug_validate_translations", false, "at startup, check all translations against their templates and log missing and mismatching strings")
 )
 
 func initLinguas() error {
@@ -79,10 +83,8 @@ func initLinguas() error {
 	return nil
 }
 
-func initLocaleDomain(lang locale.Lingua, l locale.Type, domain string) {
-	if lang == locale.Builtin {
-		return
-	}
+// loadLocaleDomain reads the translation file of the given language and domain.
+func loadLocaleDomain(lang locale.Lingua, domain string) ([]byte, error) {
 	var data io.ReadCloser
 	var err error
 	if lang == locale.UserProvided {
@@ -91,29 +93,143 @@ func initLocaleDomain(lang locale.Lingua, l locale.Type, domain string) {
 		data, err = vfs.Load(fmt.Sprintf("locales/%s", lang.Directory()), fmt.Sprintf("%s.po", domain))
 	}
 	if err != nil {
-		log.Errorf("could not open %s translation for language %s: %v", domain, lang.Name(), err)
-		return
+		return nil, fmt.Errorf("could not open %s translation for language %s: %w", domain, lang.Name(), err)
 	}
 	defer data.Close()
 	buf, err := io.ReadAll(data)
 	if err != nil {
-		log.Errorf("could not read %s translation for language %s: %v", domain, lang.Name(), err)
+		return nil, fmt.Errorf("could not read %s translation for language %s: %w", domain, lang.Name(), err)
+	}
+	return buf, nil
+}
+
+func initLocaleDomain(lang locale.Lingua, l locale.Type, domain string) {
+	if lang == locale.Builtin {
+		return
+	}
+	buf, err := loadLocaleDomain(lang, domain)
+	if err != nil {
+		log.Errorf("%v", err)
 		return
 	}
 	l.Parse(buf)
 	log.Infof("%s translated to language %s", domain, lang.Name())
 }
 
+// validateTranslations checks all known translations against the templates.
+func validateTranslations() {
+	for _, domain := range []string{"game", "level"} {
+		data, err := vfs.Load("locales", fmt.Sprintf("%s.pot", domain))
+		if err != nil {
+			log.Errorf("could not open %s translation template: %v", domain, err)
+			continue
+		}
+		buf, err := io.ReadAll(data)
+		data.Close()
+		if err != nil {
+			log.Errorf("could not read %s translation template: %v", domain, err)
+			continue
+		}
+		template := gotext.NewPo()
+		template.Parse(buf)
+		for _, lang := range locale.LinguasSorted() {
+			if lang == locale.Builtin {
+				continue
+			}
+			buf, err := loadLocaleDomain(lang, domain)
+			if err != nil {
+				log.Errorf("%v", err)
+				continue
+			}
+			po := gotext.NewPo()
+			po.Parse(buf)
+			locale.Validate(lang, domain, template, po)
+		}
+	}
+}
+
 func Init() error {
 	err := initLinguas()
 	if err != nil {
 		return err
 	}
 	locale.InitCurrent()
+	if *debugValidateTranslations {
+		validateTranslations()
+	}
 	_, err = forceSetLanguage(locale.Lingua(*language))
 	return err
 }
 
+// pluralCounts returns, for each plural form of the domain, a count that selects it.
+func pluralCounts(d *gotext.Domain) map[int]int {
+	expr, err := plurals.Compile("n != 1")
+	if err != nil {
+		log.Fatalf("could not compile default plural expression: %v", err)
+	}
+	// Parsed like gotext does it.
+	for _, part := range strings.Split(d.PluralForms, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) != "plural" {
+			continue
+		}
+		rule := kv[1]
+		e, err := plurals.Compile(rule)
+		if err != nil {
+			log.Errorf("invalid plural expression %q: %v", rule, err)
+			break
+		}
+		expr = e
+	}
+	counts := map[int]int{}
+	for n := 0; n < 1000; n++ {
+		form := expr.Eval(uint32(n))
+		if _, found := counts[form]; !found {
+			counts[form] = n
+		}
+	}
+	return counts
+}
+
+// formatTemplates replaces all templates in the translations of the domain that do not need a player state.
+func formatTemplates(d *gotext.Domain) {
+	var counts map[int]int
+	// GetTranslations returns copies, so all changes are written back through the domain.
+	for _, t := range d.GetTranslations() {
+		if strings.Contains(t.ID, "{{") {
+			// If the LHS is a template already, no need to replace.
+			continue
+		}
+		// Plural forms are all replaced the same way.
+		for form, msgstr := range t.Trs {
+			replacement, err := fun.TryFormatText(nil, msgstr)
+			if err != nil {
+				// Failed to format? This usually means a syntax error.
+				// Format strings that fail due to no player state should not get here.
+				// They should have been caught by the {{ check above.
+				log.Fatalf("invalid msgstr: %q: %v", msgstr, err)
+			}
+			if replacement == msgstr {
+				// No change.
+				continue
+			}
+			if t.PluralID == "" {
+				d.Set(t.ID, replacement)
+				continue
+			}
+			if counts == nil {
+				counts = pluralCounts(d)
+			}
+			n, found := counts[form]
+			if !found {
+				log.Errorf("msgstr[%d] of %q is not selected by any count", form, t.ID)
+				continue
+			}
+			d.SetN(t.ID, t.PluralID, n, replacement)
+		}
+	}
+}
+
 func SetLanguage(lang locale.Lingua) (bool, error) {
 	if lang == "auto" {
 		lang = locale.Current
@@ -141,32 +257,6 @@ func forceSetLanguage(lang locale.Lingua) (bool, error) {
 	// Now perform all replacements in locale.G.
 	// In locale.L they're applied at runtime as more stuff may need filling in.
 	// This must be done after setting it active, and before auditing.
-	for _, t := range locale.G.GetDomain().GetTranslations() {
-		if len(t.Trs) != 1 {
-			// Sorry, not supporting plurals yet.
-			continue
-		}
-		if strings.Contains(t.ID, "{{") {
-			// If the LHS is a template already, no need to replace.
-			continue
-		}
-		msgstr, ok := t.Trs[0]
-		if !ok {
-			// Odd - no singular form?
-			continue
-		}
-		replacement, err := fun.TryFormatText(nil, msgstr)
-		if err != nil {
-			// Failed to format? This usually means a syntax error.
-			// Format strings that fail due to no player state should not get here.
-			// They should have been caught by the {{ check above.
-			log.Fatalf("invalid msgstr: %q: %v", msgstr, err)
-		}
-		if replacement == msgstr {
-			// No change.
-			continue
-		}
-		locale.G.Set(t.ID, replacement)
-	}
+	formatTemplates(locale.G.GetDomain())
 	return true, locale.Audit()
 }
@@ -0,0 +1,63 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package initlocale
+
+import (
+	"testing"
+
+	"github.com/leonelquinteros/gotext"
+)
+
+const testPo = `msgid ""
+msgstr ""
+"Language: xx\n"
+"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n==2 ? 1 : 2);\n"
+
+msgid "Hello"
+msgstr "Hel{{BR}}lo"
+
+msgid "Untouched"
+msgstr "Unverändert"
+
+msgid "one step"
+msgid_plural "%d steps"
+msgstr[0] "%d{{BR}}Schritt"
+msgstr[1] "%d{{BR}}Schrittchen"
+msgstr[2] "%d{{BR}}Schritte"
+`
+
+func TestFormatTemplates(t *testing.T) {
+	po := gotext.NewPo()
+	po.Parse([]byte(testPo))
+	formatTemplates(po.GetDomain())
+	if got, want := po.Get("Hello"), "Hel\nlo"; got != want {
+		t.Errorf("Get(Hello): got %q, want %q", got, want)
+	}
+	if got, want := po.Get("Untouched"), "Unverändert"; got != want {
+		t.Errorf("Get(Untouched): got %q, want %q", got, want)
+	}
+	for _, tc := range []struct {
+		n    int
+		want string
+	}{
+		{1, "1\nSchritt"},
+		{2, "2\nSchrittchen"},
+		{5, "5\nSchritte"},
+	} {
+		if got := po.GetN("one step", "%d steps", tc.n, tc.n); got != tc.want {
+			t.Errorf("GetN(%d): got %q, want %q", tc.n, got, tc.want)
+		}
+	}
+}
@@ -170,6 +170,9 @@ func LinguasSorted() []Lingua {
 
 // ActiveFont returns the font this locale uses.
 //
+// This can be a comma separated fallback chain of fonts; "ttf:<filename>"
+// loads a font from the fonts asset directory.
+//
 // This is only accessible for the active locale so it can later be defined by the language file itself.
 func ActiveFont() string {
 	po := G // Workaround for xgotext otherwise not finding the call.
@@ -180,12 +183,18 @@ func ActiveFont() string {
 	switch setting {
 	case "_locale_info:font", "default":
 		return "gofont"
-	case "bitmapfont", "gofont", "unifont":
-		return setting
-	default:
-		log.Fatalf("Invalid value of _locale_info:font: got %q, want default, bitmapfont, gofont or unifont", setting)
-		return "gofont"
 	}
+	// A comma separated list of fonts is a fallback chain.
+	for _, font := range strings.Split(setting, ",") {
+		switch {
+		case font == "bitmapfont", font == "gofont", font == "unifont":
+		case strings.HasPrefix(font, "ttf:") && len(font) > len("ttf:"):
+		default:
+			log.Fatalf("Invalid value of _locale_info:font: got %q, want default or a comma separated list of bitmapfont, gofont, unifont or ttf:<filename>", setting)
+			return "gofont"
+		}
+	}
+	return setting
 }
 
 type VerticalTextPreference int
@@ -253,13 +262,33 @@ func ActiveUsesArabicShaping() bool {
 	}
 }
 
+// ActiveIsRightToLeft returns whether this locale is written right to left.
+// Layouts should be mirrored then.
+//
+// This is only accessible for the active locale so it can later be defined by the language file itself.
+func ActiveIsRightToLeft() bool {
+	po := G // Workaround for xgotext otherwise not finding the call.
+	setting := po.Get("_locale_info:right_to_left")
+	switch setting {
+	case "_locale_info:right_to_left", "default":
+		return ActiveUsesArabicShaping()
+	case "true":
+		return true
+	case "false":
+		return false
+	default:
+		log.Fatalf("Invalid value of _locale_info:right_to_left: got %q, want default, true or false", setting)
+		return false
+	}
+}
+
 // ActiveShape performs glyph shaping on a given string.
 //
 // This is only accessible for the active locale so it can later be defined by the language file itself.
 func ActiveShape(s string) string {
 	switch {
-	case ActiveUsesArabicShaping():
-		return Active.shapeArabic(s)
+	case ActiveUsesArabicShaping(), ActiveIsRightToLeft():
+		return Active.shapeRightToLeft(s)
 	default:
 		return s
 	}
@@ -22,6 +22,7 @@ import (
 	"regexp"
 	"runtime/debug"
 	"strings"
+	"time"
 
 	"github.com/divVerent/aaaaxy/internal/alert"
 	"github.com/divVerent/aaaaxy/internal/atexit"
@@ -33,15 +34,51 @@ var (
 	defaultBatch bool  = false
 	Batch        *bool = &defaultBatch
 	usePanic     bool  = false
+	crashReport  string
 )
 
+// Level is the severity of a log message.
+type Level int
+
 const (
-	debugLevel   = 1
-	infoLevel    = 0
-	warningLevel = -1
-	errorLevel   = -2
+	FatalLevel   Level = -3
+	ErrorLevel   Level = -2
+	WarningLevel Level = -1
+	InfoLevel    Level = 0
+	DebugLevel   Level = 1
 )
 
+func (l Level) String() string {
+	switch l {
+	case FatalLevel:
+		return "FATAL"
+	case ErrorLevel:
+		return "ERROR"
+	case WarningLevel:
+		return "WARNING"
+	case InfoLevel:
+		return "INFO"
+	default: // case DebugLevel:
+		return "DEBUG"
+	}
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// Entry is a single log message.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+}
+
+// String returns the entry the way it is shown on the console.
+func (e Entry) String() string {
+	return e.Time.Format("2006/01/02 15:04:05.000000") + " [" + e.Level.String() + "] " + e.Message
+}
+
 // Do not allow exploiting log parsers or the terminal. #log4j
 var (
 	newline            = "\n"
@@ -61,49 +98,64 @@ func logSprintf(format string, v ...interface{}) string {
 	})
 }
 
+// output logs a message at the given level.
+// calldepth counts the callers to skip to find the code that logged the message.
+func output(calldepth int, level Level, msg string) {
+	log.Output(calldepth+1, "["+level.String()+"] "+msg)
+	record(Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+	})
+}
+
 func Debugf(format string, v ...interface{}) {
-	if *V < debugLevel {
+	if Level(*V) < DebugLevel {
 		return
 	}
-	log.Output(2, logSprintf("[DEBUG] "+format, v...))
+	output(2, DebugLevel, logSprintf(format, v...))
 }
 
 func Infof(format string, v ...interface{}) {
-	if *V < infoLevel {
+	if Level(*V) < InfoLevel {
 		return
 	}
-	log.Output(2, logSprintf("[INFO] "+format, v...))
+	output(2, InfoLevel, logSprintf(format, v...))
 }
 
 func Warningf(format string, v ...interface{}) {
-	if *V < warningLevel {
+	if Level(*V) < WarningLevel {
 		return
 	}
-	log.Output(2, logSprintf("[WARNING] "+format, v...))
+	output(2, WarningLevel, logSprintf(format, v...))
 }
 
 func Errorf(format string, v ...interface{}) {
-	if *V < errorLevel {
+	if Level(*V) < ErrorLevel {
 		return
 	}
-	log.Output(2, logSprintf("[ERROR] "+format, v...))
+	output(2, ErrorLevel, logSprintf(format, v...))
 }
 
 func TraceErrorf(format string, v ...interface{}) {
-	if *V < errorLevel {
+	if Level(*V) < ErrorLevel {
 		return
 	}
 	debug.PrintStack()
-	log.Output(2, logSprintf("[ERROR] "+format, v...))
+	output(2, ErrorLevel, logSprintf(format, v...))
 }
 
 func Fatalf(format string, v ...interface{}) {
 	debug.PrintStack()
 	msg := logSprintf(format, v...)
-	log.Output(2, "[FATAL] "+msg)
+	output(2, FatalLevel, msg)
 	CloseLogFile()
 	if !*Batch {
-		alert.Show(msg)
+		if crashReport != "" {
+			alert.ShowCrash(msg, crashReport)
+		} else {
+			alert.Show(msg)
+		}
 	}
 	atexit.Finish()
 	if usePanic {
@@ -116,6 +168,11 @@ func UsePanic(u bool) {
 	usePanic = u
 }
 
+// SetCrashReport makes Fatalf point the user to the given crash report.
+func SetCrashReport(path string) {
+	crashReport = path
+}
+
 var (
 	logFiles []io.Closer
 )
@@ -131,6 +188,9 @@ func AddLogFile(file string) {
 }
 
 func CloseLogFile() {
+	recordMu.Lock()
+	closeStructured()
+	recordMu.Unlock()
 	for _, wr := range logFiles {
 		err := wr.Close()
 		if err != nil {
@@ -0,0 +1,128 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// maxEntries is how many log entries Entries returns at most.
+	maxEntries = 1000
+
+	// rotateSize is the size at which the structured log file gets rotated.
+	rotateSize = 1 << 20
+
+	// rotateCount is how many old structured log files are kept.
+	rotateCount = 3
+)
+
+var (
+	recordMu sync.Mutex
+	entries  []Entry
+
+	structuredPath string
+	structuredFile *os.File
+	structuredSize int64
+)
+
+// record keeps the given entry in memory and writes it to the structured log file.
+func record(e Entry) {
+	recordMu.Lock()
+	defer recordMu.Unlock()
+	entries = append(entries, e)
+	if len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+	if structuredFile == nil {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	n, err := structuredFile.Write(append(data, '\n'))
+	structuredSize += int64(n)
+	if err != nil {
+		// Can't log this error here, as that would recurse.
+		fmt.Fprintf(os.Stderr, "could not write structured log: %v\n", err)
+		closeStructured()
+		return
+	}
+	if structuredSize >= rotateSize {
+		closeStructured()
+		openStructured()
+	}
+}
+
+// Entries returns the most recent log entries, oldest first.
+func Entries() []Entry {
+	recordMu.Lock()
+	defer recordMu.Unlock()
+	return append([]Entry(nil), entries...)
+}
+
+// SetStructuredLogFile starts writing log entries as JSON lines to the given file.
+// Previous log files are kept with numbered suffixes.
+func SetStructuredLogFile(path string) {
+	recordMu.Lock()
+	defer recordMu.Unlock()
+	closeStructured()
+	structuredPath = path
+	openStructured()
+}
+
+// openStructured rotates away old structured log files and opens a new one.
+// Must be called with recordMu held.
+func openStructured() {
+	err := os.MkdirAll(filepath.Dir(structuredPath), 0777)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not create structured log directory: %v\n", err)
+		return
+	}
+	for i := rotateCount - 1; i >= 0; i-- {
+		from := structuredPath
+		if i > 0 {
+			from = fmt.Sprintf("%s.%d", structuredPath, i)
+		}
+		err := os.Rename(from, fmt.Sprintf("%s.%d", structuredPath, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "could not rotate structured log: %v\n", err)
+		}
+	}
+	structuredFile, err = os.OpenFile(structuredPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not open structured log: %v\n", err)
+		structuredFile = nil
+	}
+	structuredSize = 0
+}
+
+// closeStructured closes the structured log file.
+// Must be called with recordMu held.
+func closeStructured() {
+	if structuredFile == nil {
+		return
+	}
+	err := structuredFile.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not close structured log: %v\n", err)
+	}
+	structuredFile = nil
+}
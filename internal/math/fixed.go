@@ -127,3 +127,232 @@ func (f Fixed) Sqrt() Fixed {
 
 	return s
 }
+
+// Lerp interpolates linearly between f and g; t=0 returns f and t=FixedOne returns g.
+func (f Fixed) Lerp(g, t Fixed) Fixed {
+	return f + (g - f).Mul(t)
+}
+
+// Trigonometry uses CORDIC in pure integer arithmetic, so results are the same on all platforms.
+// Internally, angles and coordinates are kept with cordicBits fractional bits.
+const (
+	cordicBits = 30
+	// cordicPi is pi with cordicBits fractional bits.
+	cordicPi = 3373259426
+	// cordicGain is the inverse of the CORDIC gain with cordicBits fractional bits.
+	cordicGain = 652032874
+)
+
+// cordicAtan is atan(2^-i) with cordicBits fractional bits.
+var cordicAtan = [...]int64{
+	843314857, 497837829, 263043837, 133525159, 67021687, 33543516, 16775851, 8388437,
+	4194283, 2097149, 1048576, 524288, 262144, 131072, 65536, 32768,
+	16384, 8192, 4096, 2048, 1024, 512, 256, 128,
+	64, 32, 16, 8, 4, 2, 1,
+}
+
+// FixedPi is pi, rounded to the nearest Fixed.
+const FixedPi Fixed = (cordicPi + 1<<(cordicBits-fixedBits-1)) >> (cordicBits - fixedBits)
+
+// fromCordic converts a value with cordicBits fractional bits to Fixed, rounding to nearest.
+func fromCordic(v int64) Fixed {
+	return Fixed((v + 1<<(cordicBits-fixedBits-1)) >> (cordicBits - fixedBits))
+}
+
+// SinCos returns the sine and cosine of the angle f in radians.
+// Accuracy decreases for huge angles, as pi itself is only known to cordicBits bits.
+func (f Fixed) SinCos() (sin, cos Fixed) {
+	// Reduce to -pi <= z < pi, exactly.
+	neg := f < 0
+	a := uint64(f)
+	if neg {
+		a = uint64(-f)
+	}
+	_, r := mulFracModUint64(a, 1<<(cordicBits-fixedBits), 2*cordicPi)
+	z := int64(r)
+	if neg {
+		z = -z
+	}
+	if z >= cordicPi {
+		z -= 2 * cordicPi
+	} else if z < -cordicPi {
+		z += 2 * cordicPi
+	}
+	// CORDIC only converges for -pi/2 <= z <= pi/2; rotate by pi otherwise.
+	flip := false
+	if z > cordicPi/2 {
+		z -= cordicPi
+		flip = true
+	} else if z < -cordicPi/2 {
+		z += cordicPi
+		flip = true
+	}
+	x, y := int64(cordicGain), int64(0)
+	for i, atan := range cordicAtan {
+		if z >= 0 {
+			x, y = x-y>>i, y+x>>i
+			z -= atan
+		} else {
+			x, y = x+y>>i, y-x>>i
+			z += atan
+		}
+	}
+	if flip {
+		x, y = -x, -y
+	}
+	return fromCordic(y), fromCordic(x)
+}
+
+// Sin returns the sine of the angle f in radians.
+func (f Fixed) Sin() Fixed {
+	sin, _ := f.SinCos()
+	return sin
+}
+
+// Cos returns the cosine of the angle f in radians.
+func (f Fixed) Cos() Fixed {
+	_, cos := f.SinCos()
+	return cos
+}
+
+// FixedAtan2 returns the angle of the vector (x, y) in radians, in the range -pi to pi.
+// Like math.Atan2, FixedAtan2(0, 0) is 0.
+func FixedAtan2(y, x Fixed) Fixed {
+	if x == 0 && y == 0 {
+		return 0
+	}
+	// Scale so the larger coordinate has cordicBits-1 bits; the angle does not depend on the scale.
+	xi, yi := int64(x), int64(y)
+	for abs64(xi) >= 1<<(cordicBits-1) || abs64(yi) >= 1<<(cordicBits-1) {
+		xi, yi = xi>>1, yi>>1
+	}
+	for abs64(xi) < 1<<(cordicBits-2) && abs64(yi) < 1<<(cordicBits-2) {
+		xi, yi = xi<<1, yi<<1
+	}
+	// CORDIC only converges for x >= 0; rotate by pi otherwise.
+	var z int64
+	if xi < 0 {
+		xi, yi = -xi, -yi
+		if yi > 0 {
+			z = -cordicPi
+		} else {
+			z = cordicPi
+		}
+	}
+	for i, atan := range cordicAtan {
+		if yi > 0 {
+			xi, yi = xi+yi>>i, yi-xi>>i
+			z += atan
+		} else {
+			xi, yi = xi-yi>>i, yi+xi>>i
+			z -= atan
+		}
+	}
+	return fromCordic(z)
+}
+
+func abs64(i int64) int64 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// FixedDelta is a Delta with fractional coordinates.
+type FixedDelta struct {
+	DX, DY Fixed
+}
+
+// NewFixedDelta converts a Delta to a FixedDelta.
+func NewFixedDelta(d Delta) FixedDelta {
+	return FixedDelta{DX: NewFixed(d.DX), DY: NewFixed(d.DY)}
+}
+
+// FixedDeltaFromAngle returns the vector of the given length pointing at the given angle.
+func FixedDeltaFromAngle(angle, length Fixed) FixedDelta {
+	sin, cos := angle.SinCos()
+	return FixedDelta{DX: cos.Mul(length), DY: sin.Mul(length)}
+}
+
+func (d FixedDelta) Add(d2 FixedDelta) FixedDelta {
+	return FixedDelta{DX: d.DX + d2.DX, DY: d.DY + d2.DY}
+}
+
+func (d FixedDelta) Sub(d2 FixedDelta) FixedDelta {
+	return FixedDelta{DX: d.DX - d2.DX, DY: d.DY - d2.DY}
+}
+
+func (d FixedDelta) Mul(f Fixed) FixedDelta {
+	return FixedDelta{DX: d.DX.Mul(f), DY: d.DY.Mul(f)}
+}
+
+func (d FixedDelta) MulFrac(num, denom Fixed) FixedDelta {
+	return FixedDelta{DX: d.DX.MulFrac(num, denom), DY: d.DY.MulFrac(num, denom)}
+}
+
+func (d FixedDelta) Dot(d2 FixedDelta) Fixed {
+	return d.DX.Mul(d2.DX) + d.DY.Mul(d2.DY)
+}
+
+func (d FixedDelta) Length() Fixed {
+	return d.Dot(d).Sqrt()
+}
+
+// Angle returns the direction of the vector in radians.
+func (d FixedDelta) Angle() Fixed {
+	return FixedAtan2(d.DY, d.DX)
+}
+
+// Lerp interpolates linearly between d and d2.
+func (d FixedDelta) Lerp(d2 FixedDelta, t Fixed) FixedDelta {
+	return FixedDelta{DX: d.DX.Lerp(d2.DX, t), DY: d.DY.Lerp(d2.DY, t)}
+}
+
+func (d FixedDelta) IsZero() bool {
+	return d.DX == 0 && d.DY == 0
+}
+
+// Rint rounds to the nearest Delta.
+func (d FixedDelta) Rint() Delta {
+	return Delta{DX: d.DX.Rint(), DY: d.DY.Rint()}
+}
+
+func (d FixedDelta) String() string {
+	return fmt.Sprintf("%v %v", d.DX, d.DY)
+}
+
+// FixedPos is a Pos with fractional coordinates.
+type FixedPos struct {
+	X, Y Fixed
+}
+
+// NewFixedPos converts a Pos to a FixedPos.
+func NewFixedPos(p Pos) FixedPos {
+	return FixedPos{X: NewFixed(p.X), Y: NewFixed(p.Y)}
+}
+
+func (p FixedPos) Add(d FixedDelta) FixedPos {
+	return FixedPos{X: p.X + d.DX, Y: p.Y + d.DY}
+}
+
+func (p FixedPos) Sub(d FixedDelta) FixedPos {
+	return FixedPos{X: p.X - d.DX, Y: p.Y - d.DY}
+}
+
+func (p FixedPos) Delta(p2 FixedPos) FixedDelta {
+	return FixedDelta{DX: p.X - p2.X, DY: p.Y - p2.Y}
+}
+
+// Lerp interpolates linearly between p and p2.
+func (p FixedPos) Lerp(p2 FixedPos, t Fixed) FixedPos {
+	return FixedPos{X: p.X.Lerp(p2.X, t), Y: p.Y.Lerp(p2.Y, t)}
+}
+
+// Rint rounds to the nearest Pos.
+func (p FixedPos) Rint() Pos {
+	return Pos{X: p.X.Rint(), Y: p.Y.Rint()}
+}
+
+func (p FixedPos) String() string {
+	return fmt.Sprintf("%v %v", p.X, p.Y)
+}
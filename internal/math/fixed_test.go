@@ -185,3 +185,96 @@ func TestFixedSqrt(t *testing.T) {
 		})
 	}
 }
+
+func TestFixedLerp(t *testing.T) {
+	for _, tc := range []struct {
+		A, B, T Fixed
+		Want    Fixed
+	}{
+		{A: NewFixed(2), B: NewFixed(6), T: 0, Want: NewFixed(2)},
+		{A: NewFixed(2), B: NewFixed(6), T: FixedOne, Want: NewFixed(6)},
+		{A: NewFixed(2), B: NewFixed(6), T: FixedOne / 4, Want: NewFixed(3)},
+		{A: NewFixed(6), B: NewFixed(2), T: FixedOne / 4, Want: NewFixed(5)},
+		{A: NewFixed(-2), B: NewFixed(2), T: FixedOne * 3 / 2, Want: NewFixed(4)},
+	} {
+		t.Run(fmt.Sprintf("%+v", tc), func(t *testing.T) {
+			got := tc.A.Lerp(tc.B, tc.T)
+			if got != tc.Want {
+				t.Errorf("A.Lerp(B, T): got %v, want %v", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestFixedPi(t *testing.T) {
+	if want := NewFixedFloat64(math.Pi); FixedPi != want {
+		t.Errorf("FixedPi: got %v, want %v", FixedPi, want)
+	}
+}
+
+// maxTrigError is the largest acceptable difference to the float reference, in units of 1/4096.
+const maxTrigError = 2
+
+func TestFixedSinCos(t *testing.T) {
+	// Every representable angle in a few periods, plus some large ones.
+	for a := -4 * FixedPi; a <= 4*FixedPi; a++ {
+		checkSinCos(t, a)
+	}
+	for a := NewFixed(-100000); a <= NewFixed(100000); a += NewFixed(997) + 1 {
+		checkSinCos(t, a)
+	}
+}
+
+func checkSinCos(t *testing.T, a Fixed) {
+	t.Helper()
+	sin, cos := a.SinCos()
+	wantSin, wantCos := math.Sincos(a.Float64())
+	if d := sin - NewFixedFloat64(wantSin); d > maxTrigError || d < -maxTrigError {
+		t.Errorf("%v.SinCos(): got sin %v, want %v", a, sin, NewFixedFloat64(wantSin))
+	}
+	if d := cos - NewFixedFloat64(wantCos); d > maxTrigError || d < -maxTrigError {
+		t.Errorf("%v.SinCos(): got cos %v, want %v", a, cos, NewFixedFloat64(wantCos))
+	}
+}
+
+func TestFixedAtan2(t *testing.T) {
+	// All directions on a grid, at various scales.
+	for _, scale := range []Fixed{1, FixedOne / 16, FixedOne, NewFixed(1000), NewFixed(1 << 40)} {
+		for y := -32; y <= 32; y++ {
+			for x := -32; x <= 32; x++ {
+				fy, fx := Fixed(y)*scale, Fixed(x)*scale
+				got := FixedAtan2(fy, fx)
+				want := NewFixedFloat64(math.Atan2(float64(y), float64(x)))
+				if d := got - want; d > maxTrigError || d < -maxTrigError {
+					t.Errorf("FixedAtan2(%v, %v): got %v, want %v", fy, fx, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestFixedDeltaAngle(t *testing.T) {
+	for a := -FixedPi + 16; a < FixedPi; a += 16 {
+		d := FixedDeltaFromAngle(a, NewFixed(100))
+		if got := d.Angle(); got-a > maxTrigError || got-a < -maxTrigError {
+			t.Errorf("FixedDeltaFromAngle(%v, 100).Angle(): got %v, want %v", a, got, a)
+		}
+		if got := d.Length(); got-NewFixed(100) > 100*maxTrigError || got-NewFixed(100) < -100*maxTrigError {
+			t.Errorf("FixedDeltaFromAngle(%v, 100).Length(): got %v, want 100", a, got)
+		}
+	}
+}
+
+func TestFixedPosLerp(t *testing.T) {
+	a := NewFixedPos(Pos{X: 10, Y: -20})
+	b := NewFixedPos(Pos{X: 30, Y: 20})
+	if got, want := a.Lerp(b, FixedOne/2).Rint(), (Pos{X: 20, Y: 0}); got != want {
+		t.Errorf("a.Lerp(b, 0.5): got %v, want %v", got, want)
+	}
+	if got, want := b.Delta(a).Rint(), (Delta{DX: 20, DY: 40}); got != want {
+		t.Errorf("b.Delta(a): got %v, want %v", got, want)
+	}
+	if got, want := a.Add(b.Delta(a)), b; got != want {
+		t.Errorf("a.Add(b.Delta(a)): got %v, want %v", got, want)
+	}
+}
@@ -100,7 +100,8 @@ func TurnAround() Orientation {
 	return Orientation{Right: West(), Down: North()}
 }
 
-// ParseOrientation parses an orientation from a string. It is given by the right and down directions in that order.
+// ParseOrientation parses an orientation from a string. It is given by the right and down directions in that order,
+// or by the name returned by Name.
 func ParseOrientation(s string) (Orientation, error) {
 	switch s {
 	case "EN":
@@ -120,8 +121,34 @@ func ParseOrientation(s string) (Orientation, error) {
 	case "WS":
 		return Orientation{Right: West(), Down: South()}, nil
 	default:
-		return Orientation{}, fmt.Errorf("unsupported orientation %q; want <right><down> direction like ES", s)
+		if o, found := orientationNames[s]; found {
+			return o, nil
+		}
+		return Orientation{}, fmt.Errorf("unsupported orientation %q; want <right><down> direction like ES, or a name like %s", s, strings.Join(orientationNameList, ", "))
+	}
+}
+
+// orientationNameList are the readable names ParseOrientation accepts too, in the order of AllOrientations.
+var orientationNameList = []string{"flipy", "identity", "left", "flipa", "flipd", "right", "turnaround", "flipx"}
+
+// orientationNames maps the readable names to their orientations.
+var orientationNames = map[string]Orientation{}
+
+func init() {
+	for i, name := range orientationNameList {
+		orientationNames[name] = AllOrientations[i]
+	}
+}
+
+// Name returns a readable name of the orientation, such as left or flipx.
+// ParseOrientation accepts these names too.
+func (o Orientation) Name() string {
+	for i, o2 := range AllOrientations {
+		if o == o2 {
+			return orientationNameList[i]
+		}
 	}
+	return o.String()
 }
 
 type Orientations []Orientation
@@ -0,0 +1,89 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package math
+
+import (
+	"testing"
+)
+
+func TestOrientationRoundTrip(t *testing.T) {
+	for _, o := range AllOrientations {
+		got, err := ParseOrientation(o.String())
+		if err != nil || got != o {
+			t.Errorf("ParseOrientation(%q): got %v, %v, want %v", o.String(), got, err, o)
+		}
+		got, err = ParseOrientation(o.Name())
+		if err != nil || got != o {
+			t.Errorf("ParseOrientation(%q): got %v, %v, want %v", o.Name(), got, err, o)
+		}
+	}
+	list, err := ParseOrientations("ES left WN")
+	if err != nil {
+		t.Fatalf("ParseOrientations: %v", err)
+	}
+	text, err := list.MarshalText()
+	if err != nil || string(text) != "ES NE WN" {
+		t.Errorf("Orientations.MarshalText(): got %q, %v, want %q", text, err, "ES NE WN")
+	}
+	if _, err := ParseOrientation("EE"); err == nil {
+		t.Errorf("ParseOrientation(%q): got no error", "EE")
+	}
+}
+
+func TestOrientationNames(t *testing.T) {
+	for _, tc := range []struct {
+		Name string
+		Want Orientation
+	}{
+		{Name: "identity", Want: Identity()},
+		{Name: "flipx", Want: FlipX()},
+		{Name: "flipy", Want: FlipY()},
+		{Name: "flipd", Want: FlipD()},
+		{Name: "flipa", Want: FlipX().Concat(FlipD()).Concat(FlipX())},
+		{Name: "left", Want: Left()},
+		{Name: "right", Want: Right()},
+		{Name: "turnaround", Want: TurnAround()},
+	} {
+		got, err := ParseOrientation(tc.Name)
+		if err != nil || got != tc.Want {
+			t.Errorf("ParseOrientation(%q): got %v, %v, want %v", tc.Name, got, err, tc.Want)
+		}
+	}
+}
+
+func TestOrientationComposition(t *testing.T) {
+	all := map[Orientation]bool{}
+	for _, o := range AllOrientations {
+		all[o] = true
+	}
+	for _, a := range AllOrientations {
+		if d := a.Determinant(); d != 1 && d != -1 {
+			t.Errorf("%v.Determinant(): got %v, want +-1", a, d)
+		}
+		if got := a.Concat(a.Inverse()); got != Identity() {
+			t.Errorf("%v.Concat(%v.Inverse()): got %v, want identity", a, a, got)
+		}
+		for _, b := range AllOrientations {
+			ab := a.Concat(b)
+			if !all[ab] {
+				t.Errorf("%v.Concat(%v): got %v, which is not a valid orientation", a, b, ab)
+			}
+			d := Delta{DX: 3, DY: 5}
+			if got, want := ab.Apply(d), a.Apply(b.Apply(d)); got != want {
+				t.Errorf("%v.Concat(%v).Apply(%v): got %v, want %v", a, b, d, got, want)
+			}
+		}
+	}
+}
@@ -170,6 +170,116 @@ func (r Rect) Union(other Rect) Rect {
 	}
 }
 
+// Overlaps returns whether the two rectangles share at least one pixel.
+func (r Rect) Overlaps(other Rect) bool {
+	return r.Origin.X < other.Origin.X+other.Size.DX && other.Origin.X < r.Origin.X+r.Size.DX &&
+		r.Origin.Y < other.Origin.Y+other.Size.DY && other.Origin.Y < r.Origin.Y+r.Size.DY
+}
+
+// Intersection returns the largest Rect contained in both Rects.
+// Returns false if they do not overlap.
+func (r Rect) Intersection(other Rect) (Rect, bool) {
+	if !r.Overlaps(other) {
+		return Rect{}, false
+	}
+	c0 := Pos{X: max(r.Origin.X, other.Origin.X), Y: max(r.Origin.Y, other.Origin.Y)}
+	c1 := Pos{
+		X: min(r.Origin.X+r.Size.DX, other.Origin.X+other.Size.DX),
+		Y: min(r.Origin.Y+r.Size.DY, other.Origin.Y+other.Size.DY),
+	}
+	return Rect{Origin: c0, Size: c1.Delta(c0)}, true
+}
+
+// Contains returns whether the given point is inside the rectangle.
+func (r Rect) Contains(p Pos) bool {
+	return p.X >= r.Origin.X && p.X < r.Origin.X+r.Size.DX &&
+		p.Y >= r.Origin.Y && p.Y < r.Origin.Y+r.Size.DY
+}
+
+// ContainsRect returns whether the other rectangle is entirely inside this one.
+func (r Rect) ContainsRect(other Rect) bool {
+	return other.Origin.X >= r.Origin.X && other.Origin.X+other.Size.DX <= r.Origin.X+r.Size.DX &&
+		other.Origin.Y >= r.Origin.Y && other.Origin.Y+other.Size.DY <= r.Origin.Y+r.Size.DY
+}
+
+// ClampPos returns the point inside the rectangle closest to p. Only correct on normalized, nonempty rectangles.
+func (r Rect) ClampPos(p Pos) Pos {
+	c1 := r.OppositeCorner()
+	return Pos{X: min(max(p.X, r.Origin.X), c1.X), Y: min(max(p.Y, r.Origin.Y), c1.Y)}
+}
+
+// clampInterval moves the interval [pos, pos+size) into [lo, lo+limit), or centers it if it does not fit.
+func clampInterval(pos, size, lo, limit int) int {
+	if size >= limit {
+		return lo + Div(limit-size, 2)
+	}
+	return min(max(pos, lo), lo+limit-size)
+}
+
+// ClampRect moves the other rectangle the least amount so it is inside this one.
+// Along axes where it does not fit, it is centered on this one instead.
+func (r Rect) ClampRect(other Rect) Rect {
+	return Rect{
+		Origin: Pos{
+			X: clampInterval(other.Origin.X, other.Size.DX, r.Origin.X, r.Size.DX),
+			Y: clampInterval(other.Origin.Y, other.Size.DY, r.Origin.Y, r.Size.DY),
+		},
+		Size: other.Size,
+	}
+}
+
+// DistanceFixed returns the distance between the closest pixels of two rectangles.
+func (r Rect) DistanceFixed(other Rect) Fixed {
+	return r.Delta(other).LengthFixed()
+}
+
+// DistancePosFixed returns the distance between the closest pixel of a rectangle and a point.
+func (r Rect) DistancePosFixed(p Pos) Fixed {
+	return r.DeltaPos(p).LengthFixed()
+}
+
+// rayFrac is a nonnegative fraction n/d with d > 0.
+type rayFrac struct {
+	n, d int64
+}
+
+func (f rayFrac) less(g rayFrac) bool {
+	return f.n*g.d < g.n*f.d
+}
+
+// raySlab narrows the interval [enter, exit] of a ray x0 + t*dx to where it is in [lo, hi].
+func raySlab(x0, dx, lo, hi int, enter, exit *rayFrac) bool {
+	if dx == 0 {
+		return x0 >= lo && x0 <= hi
+	}
+	tLo := rayFrac{n: int64(lo - x0), d: int64(dx)}
+	tHi := rayFrac{n: int64(hi - x0), d: int64(dx)}
+	if dx < 0 {
+		tLo, tHi = rayFrac{n: -tHi.n, d: -tHi.d}, rayFrac{n: -tLo.n, d: -tLo.d}
+	}
+	if enter.less(tLo) {
+		*enter = tLo
+	}
+	if tHi.less(*exit) {
+		*exit = tHi
+	}
+	return !exit.less(*enter)
+}
+
+// TraceRay returns where the segment from p to p+d first enters the rectangle, as a fraction of d.
+// The rectangle is treated as the continuous area from Origin to Origin+Size.
+// Returns 0 if p is already inside, and false if the segment misses the rectangle.
+func (r Rect) TraceRay(p Pos, d Delta) (Fixed, bool) {
+	enter, exit := rayFrac{n: 0, d: 1}, rayFrac{n: 1, d: 1}
+	if !raySlab(p.X, d.DX, r.Origin.X, r.Origin.X+r.Size.DX, &enter, &exit) {
+		return 0, false
+	}
+	if !raySlab(p.Y, d.DY, r.Origin.Y, r.Origin.Y+r.Size.DY, &enter, &exit) {
+		return 0, false
+	}
+	return Fixed(mulFracInt64(enter.n, int64(FixedOne), enter.d)), true
+}
+
 func (r Rect) String() string {
 	return fmt.Sprintf("%d %d %d %d", r.Origin.X, r.Origin.Y, r.Size.DX, r.Size.DY)
 }
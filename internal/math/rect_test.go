@@ -0,0 +1,187 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package math
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// smallRect is a normalized, nonempty Rect in a small area, so random rects often overlap.
+type smallRect struct {
+	Rect
+}
+
+func (smallRect) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(smallRect{Rect{
+		Origin: Pos{X: r.Intn(32) - 16, Y: r.Intn(32) - 16},
+		Size:   Delta{DX: r.Intn(16) + 1, DY: r.Intn(16) + 1},
+	}})
+}
+
+// smallPos is a Pos in the same area as smallRect.
+type smallPos struct {
+	Pos
+}
+
+func (smallPos) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(smallPos{Pos{X: r.Intn(48) - 24, Y: r.Intn(48) - 24}})
+}
+
+// pixels returns all pixels of a rect.
+func pixels(r Rect) map[Pos]struct{} {
+	out := map[Pos]struct{}{}
+	for y := r.Origin.Y; y < r.Origin.Y+r.Size.DY; y++ {
+		for x := r.Origin.X; x < r.Origin.X+r.Size.DX; x++ {
+			out[Pos{X: x, Y: y}] = struct{}{}
+		}
+	}
+	return out
+}
+
+func checkProperty(t *testing.T, f interface{}) {
+	t.Helper()
+	if err := quick.Check(f, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRectContains(t *testing.T) {
+	checkProperty(t, func(a smallRect, p smallPos) bool {
+		_, want := pixels(a.Rect)[p.Pos]
+		return a.Contains(p.Pos) == want && a.DeltaPos(p.Pos).IsZero() == want
+	})
+}
+
+func TestRectOverlaps(t *testing.T) {
+	checkProperty(t, func(a, b smallRect) bool {
+		want := false
+		bp := pixels(b.Rect)
+		for p := range pixels(a.Rect) {
+			if _, found := bp[p]; found {
+				want = true
+			}
+		}
+		return a.Overlaps(b.Rect) == want && b.Overlaps(a.Rect) == want && a.Delta(b.Rect).IsZero() == want
+	})
+}
+
+func TestRectIntersection(t *testing.T) {
+	checkProperty(t, func(a, b smallRect, p smallPos) bool {
+		i, ok := a.Intersection(b.Rect)
+		if ok != a.Overlaps(b.Rect) {
+			return false
+		}
+		want := a.Contains(p.Pos) && b.Contains(p.Pos)
+		return (ok && i.Contains(p.Pos)) == want
+	})
+}
+
+func TestRectUnion(t *testing.T) {
+	checkProperty(t, func(a, b smallRect) bool {
+		u := a.Union(b.Rect)
+		return u.ContainsRect(a.Rect) && u.ContainsRect(b.Rect)
+	})
+}
+
+func TestRectContainsRect(t *testing.T) {
+	checkProperty(t, func(a, b smallRect) bool {
+		want := true
+		ap := pixels(a.Rect)
+		for p := range pixels(b.Rect) {
+			if _, found := ap[p]; !found {
+				want = false
+			}
+		}
+		return a.ContainsRect(b.Rect) == want
+	})
+}
+
+func TestRectClampPos(t *testing.T) {
+	checkProperty(t, func(a smallRect, p smallPos) bool {
+		c := a.ClampPos(p.Pos)
+		if !a.Contains(c) {
+			return false
+		}
+		if a.Contains(p.Pos) {
+			return c == p.Pos
+		}
+		// No pixel of the rect is closer.
+		d := p.Delta(c).Length2()
+		for q := range pixels(a.Rect) {
+			if p.Delta(q).Length2() < d {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestRectClampRect(t *testing.T) {
+	checkProperty(t, func(a, b smallRect) bool {
+		c := a.ClampRect(b.Rect)
+		if c.Size != b.Size {
+			return false
+		}
+		if a.ContainsRect(b.Rect) {
+			return c == b.Rect
+		}
+		if b.Size.DX <= a.Size.DX && b.Size.DY <= a.Size.DY {
+			return a.ContainsRect(c)
+		}
+		// Does not fit: at least covers the rect in the axis where it is too big.
+		return c.ContainsRect(a.Rect) || c.Size.DX <= a.Size.DX || c.Size.DY <= a.Size.DY
+	})
+}
+
+func TestRectDistance(t *testing.T) {
+	checkProperty(t, func(a, b smallRect) bool {
+		d := a.DistanceFixed(b.Rect)
+		if d != b.DistanceFixed(a.Rect) {
+			return false
+		}
+		return (d == 0) == a.Overlaps(b.Rect)
+	})
+}
+
+func TestRectTraceRay(t *testing.T) {
+	checkProperty(t, func(a smallRect, p, q smallPos) bool {
+		d := q.Delta(p.Pos)
+		f, ok := a.TraceRay(p.Pos, d)
+		// Reference: walk the segment in small steps.
+		const steps = 4096
+		hit := -1
+		for i := 0; i <= steps; i++ {
+			x := float64(p.X) + float64(d.DX)*float64(i)/steps
+			y := float64(p.Y) + float64(d.DY)*float64(i)/steps
+			if x >= float64(a.Origin.X) && x <= float64(a.Origin.X+a.Size.DX) &&
+				y >= float64(a.Origin.Y) && y <= float64(a.Origin.Y+a.Size.DY) {
+				hit = i
+				break
+			}
+		}
+		if hit < 0 {
+			// Stepping may miss grazing hits, so anything goes.
+			return true
+		}
+		if !ok {
+			return false
+		}
+		diff := f - Fixed(hit)
+		return diff >= -1 && diff <= 1
+	})
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/effects"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+type AccessibilityScreenItem int
+
+const (
+	AccessibilityStickyAction = iota
+	AccessibilityMenuKeyRepeat
+	AccessibilityReduceFlashing
+	AccessibilityScreenReader
+	AccessibilityAssist
+	AccessibilityBack
+	AccessibilityCount
+)
+
+type AccessibilityScreen struct {
+	Controller *Controller
+	Item       AccessibilityScreenItem
+	Widgets    []Widget
+}
+
+func (s *AccessibilityScreen) Focus() int {
+	return int(s.Item)
+}
+
+func (s *AccessibilityScreen) SetFocus(item int) {
+	s.Item = AccessibilityScreenItem(item)
+}
+
+func (s *AccessibilityScreen) Init(m *Controller) error {
+	s.Controller = m
+	s.Widgets = []Widget{
+		AccessibilityStickyAction: flagToggle(m, "sticky_action", func(on bool) string {
+			if on {
+				return locale.G.Get("Toggle Action Button: On")
+			}
+			return locale.G.Get("Toggle Action Button: Off")
+		}),
+		AccessibilityMenuKeyRepeat: flagToggle(m, "menu_key_repeat", func(on bool) string {
+			if on {
+				return locale.G.Get("Menu Key Repeat: On")
+			}
+			return locale.G.Get("Menu Key Repeat: Off")
+		}),
+		AccessibilityReduceFlashing: &Toggle{
+			Label: func(on bool) string {
+				if on {
+					return locale.G.Get("Reduce Flashing and Motion: On")
+				}
+				return locale.G.Get("Reduce Flashing and Motion: Off")
+			},
+			Get: effects.Reduced,
+			Set: func(on bool) error {
+				effects.SetReduced(on)
+				return nil
+			},
+		},
+		AccessibilityScreenReader: flagToggle(m, "screen_reader", func(on bool) string {
+			if on {
+				return locale.G.Get("Screen Reader: On")
+			}
+			return locale.G.Get("Screen Reader: Off")
+		}),
+		AccessibilityAssist: &Button{
+			Label: func() string { return locale.G.Get("Assist Mode") },
+			Action: func() error {
+				return s.Controller.SwitchToScreen(&AssistScreen{})
+			},
+		},
+		AccessibilityBack: &Button{
+			Label:  func() string { return locale.G.Get("Back") },
+			Action: s.exit,
+		},
+	}
+	return nil
+}
+
+func (s *AccessibilityScreen) exit() error {
+	return s.Controller.SaveConfigAndSwitchToScreen(&ControlsScreen{})
+}
+
+func (s *AccessibilityScreen) Update() error {
+	return updateList(s.Controller, &s.Item, s.Widgets, s.exit)
+}
+
+func (s *AccessibilityScreen) Draw(screen *ebiten.Image) {
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("Accessibility"), m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+	font.ByName["MenuSmall"].DrawStyled(screen, locale.G.Get("Gameplay assists mark the save game as assisted."),
+		m.Pos{X: CenterX(), Y: ItemBaselineY(-1, AccessibilityCount)}, font.Center, textStyle())
+	drawList(screen, s.Item, s.Widgets)
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+type AssistScreenItem int
+
+const (
+	AssistGameSpeed = iota
+	AssistInAirJump
+	AssistInvincible
+	AssistAutoJump
+	AssistJumpBuffer
+	AssistCoyoteTime
+	AssistBack
+	AssistCount
+)
+
+const (
+	assistFramesStep = 1
+	maxAssistFrames  = 12
+
+	gameSpeedStep = 10
+	minGameSpeed  = int(engine.MinGameSpeed * 100)
+	maxGameSpeed  = int(engine.MaxGameSpeed * 100)
+)
+
+type AssistScreen struct {
+	Controller *Controller
+	Item       AssistScreenItem
+	Widgets    []Widget
+}
+
+func (s *AssistScreen) Focus() int {
+	return int(s.Item)
+}
+
+func (s *AssistScreen) SetFocus(item int) {
+	s.Item = AssistScreenItem(item)
+}
+
+func (s *AssistScreen) Init(m *Controller) error {
+	s.Controller = m
+	s.Widgets = []Widget{
+		AssistGameSpeed: &Slider{
+			Label: func(speed int) string { return locale.G.Get("Game Speed: %d%%", speed) },
+			Get:   gameSpeedPercent,
+			Set: func(speed int) error {
+				flag.Set("game_speed", float64(speed)/100)
+				markAssisted(m)
+				return nil
+			},
+			Min:          minGameSpeed,
+			Max:          maxGameSpeed,
+			Step:         gameSpeedStep,
+			ActivateStep: -gameSpeedStep,
+		},
+		AssistInAirJump: flagToggle(m, "in_air_jump", func(on bool) string {
+			if on {
+				return locale.G.Get("Infinite Jumps: On")
+			}
+			return locale.G.Get("Infinite Jumps: Off")
+		}),
+		AssistInvincible: flagToggle(m, "invincible", func(on bool) string {
+			if on {
+				return locale.G.Get("Invincibility: On")
+			}
+			return locale.G.Get("Invincibility: Off")
+		}),
+		AssistAutoJump: flagToggle(m, "auto_jump", func(on bool) string {
+			if on {
+				return locale.G.Get("Jump While Held: On")
+			}
+			return locale.G.Get("Jump While Held: Off")
+		}),
+		AssistJumpBuffer: framesSlider(m, "jump_buffer_frames", func(ms int) string {
+			return locale.G.Get("Early Jump Window: %d ms", ms)
+		}),
+		AssistCoyoteTime: framesSlider(m, "coyote_frames", func(ms int) string {
+			return locale.G.Get("Late Jump Window: %d ms", ms)
+		}),
+		AssistBack: &Button{
+			Label:  func() string { return locale.G.Get("Back") },
+			Action: s.exit,
+		},
+	}
+	return nil
+}
+
+// flagToggle returns a widget toggling a boolean flag.
+func flagToggle(c *Controller, name string, label func(on bool) string) *Toggle {
+	return &Toggle{
+		Label: label,
+		Get: func() bool {
+			return flag.Get[bool](name)
+		},
+		Set: func(on bool) error {
+			flag.Set(name, on)
+			markAssisted(c)
+			return nil
+		},
+	}
+}
+
+// framesSlider returns a widget selecting a frame count flag, labeled in milliseconds.
+func framesSlider(c *Controller, name string, label func(ms int) string) *Slider {
+	return &Slider{
+		Label: func(frames int) string {
+			return label(frames * 1000 / engine.GameTPS)
+		},
+		Get: func() int {
+			return flag.Get[int](name)
+		},
+		Set: func(frames int) error {
+			flag.Set(name, frames)
+			markAssisted(c)
+			return nil
+		},
+		Min:  0,
+		Max:  maxAssistFrames,
+		Step: assistFramesStep,
+	}
+}
+
+// gameSpeedPercent returns the current game speed in percent.
+func gameSpeedPercent() int {
+	return m.Rint(engine.GameSpeed() * 100)
+}
+
+// markAssisted marks the current save as assisted if any assist options are now on.
+// This is done right away so turning them off again before the next save does not hide them.
+func markAssisted(c *Controller) {
+	if assisted, _ := flag.Assisted(); assisted {
+		c.World.PlayerState.SetAssisted()
+	}
+}
+
+func (s *AssistScreen) exit() error {
+	return s.Controller.SaveConfigAndSwitchToScreen(&AccessibilityScreen{Item: AccessibilityAssist})
+}
+
+func (s *AssistScreen) Update() error {
+	return updateList(s.Controller, &s.Item, s.Widgets, s.exit)
+}
+
+func (s *AssistScreen) Draw(screen *ebiten.Image) {
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("Assist Mode"), m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+	font.ByName["MenuSmall"].DrawStyled(screen, locale.G.Get("Gameplay assists mark the save game as assisted."),
+		m.Pos{X: CenterX(), Y: ItemBaselineY(-1, AssistCount)}, font.Center, textStyle())
+	drawList(screen, s.Item, s.Widgets)
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/dump"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/music"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+var (
+	attractModeDelay = flag.Duration("attract_mode_delay", 60*time.Second, "time of inactivity on the main menu after which a demo is played; zero disables attract mode")
+	attractModeDemo  = flag.String("attract_mode_demo", "benchmark.dem", "name of the bundled demo to play in attract mode")
+)
+
+const (
+	attractBlinkFrames = 60
+)
+
+// attractModeAllowed returns whether attract mode may be entered now.
+func attractModeAllowed() bool {
+	return *attractModeDelay > 0 && *attractModeDemo != "" && !demo.Playing() && !demo.Recording() && !dump.Active()
+}
+
+// attractModeIdleFrames returns the number of idle frames after which attract mode starts.
+func attractModeIdleFrames() int {
+	return int(attractModeDelay.Seconds() * engine.GameTPS)
+}
+
+// StartAttract starts playing the attract mode demo.
+func (c *Controller) StartAttract() error {
+	err := demo.StartAttract(*attractModeDemo)
+	if err != nil {
+		return fmt.Errorf("could not start attract mode: %w", err)
+	}
+	// The world is loaded from the demo on the next frame.
+	c.attractStarting = true
+	c.attractFrame = 0
+	return nil
+}
+
+// updateAttract handles entering and leaving attract mode.
+// Returns true if regular processing should be skipped this frame.
+func (c *Controller) updateAttract() (bool, error) {
+	if c.attractStarting {
+		c.attractStarting = false
+		return true, c.InitGame(loadGame)
+	}
+	if demo.AttractJustEnded() {
+		music.Switch("")
+		// Restore the actual save game.
+		err := c.initGame(loadGame)
+		if err != nil {
+			return true, err
+		}
+		c.World.PreDespawn()
+		c.blurFrame = 0
+		c.creditsBlur = false
+		return true, c.SwitchToScreen(&MainScreen{})
+	}
+	if demo.Attracting() {
+		c.attractFrame++
+	}
+	return false, nil
+}
+
+// drawAttract draws the attract mode overlay.
+func (c *Controller) drawAttract(screen *ebiten.Image) {
+	if !demo.Attracting() {
+		return
+	}
+	if (c.attractFrame/attractBlinkFrames)%2 != 0 {
+		return
+	}
+	font.ByName["MenuSmall"].Draw(screen, locale.G.Get("Demo - press any key"),
+		m.Pos{X: CenterX(), Y: ItemBaselineY(0, 1)}, font.Center,
+		palette.EGA(palette.White, 255), palette.EGA(palette.Black, 255))
+}
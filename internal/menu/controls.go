@@ -0,0 +1,234 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+type ControlsScreenItem int
+
+const (
+	ControlsGamepad = iota
+	ControlsDeadzone
+	ControlsInvertX
+	ControlsInvertY
+	ControlsInputDisplay
+	ControlsAccessibility
+	ControlsBack
+	ControlsCount
+)
+
+const (
+	deadzoneStep = 0.05
+	maxDeadzone  = 0.5
+)
+
+type ControlsScreen struct {
+	Controller *Controller
+	Item       ControlsScreenItem
+	Pads       []input.GamepadInfo // Connected gamepads.
+	Widgets    []Widget
+}
+
+func (s *ControlsScreen) Focus() int {
+	return int(s.Item)
+}
+
+func (s *ControlsScreen) SetFocus(item int) {
+	s.Item = ControlsScreenItem(item)
+}
+
+func (s *ControlsScreen) Init(c *Controller) error {
+	s.Controller = c
+	s.Pads = input.Gamepads()
+	s.Widgets = []Widget{
+		ControlsGamepad: &Choice{
+			Label: func() string {
+				padName := locale.G.Get("None")
+				if bound, ok := input.BoundGamepad(); ok {
+					padName = gamepadName(bound)
+				} else if len(s.Pads) != 0 {
+					padName = locale.G.Get("All")
+				}
+				return locale.G.Get("Active Gamepad: %s", padName)
+			},
+			Change: s.togglePad,
+		},
+		ControlsDeadzone: &Slider{
+			Label: func(steps int) string {
+				return locale.G.Get("Deadzone: %d%%", m.Rint(float64(steps)*deadzoneStep*100))
+			},
+			Get: func() int {
+				// Work in whole steps to not accumulate rounding errors.
+				return m.Rint(s.calibration().Deadzone / deadzoneStep)
+			},
+			Set: func(steps int) error {
+				return s.changeCalibration(func(c *input.GamepadCalibration) {
+					c.Deadzone = float64(steps) * deadzoneStep
+				})
+			},
+			Min:  0,
+			Max:  m.Rint(maxDeadzone / deadzoneStep),
+			Step: 1,
+		},
+		ControlsInvertX: &Toggle{
+			Label: func(on bool) string {
+				if on {
+					return locale.G.Get("Invert Horizontal: On")
+				}
+				return locale.G.Get("Invert Horizontal: Off")
+			},
+			Get: func() bool { return s.calibration().InvertX },
+			Set: func(on bool) error {
+				return s.changeCalibration(func(c *input.GamepadCalibration) {
+					c.InvertX = on
+				})
+			},
+		},
+		ControlsInvertY: &Toggle{
+			Label: func(on bool) string {
+				if on {
+					return locale.G.Get("Invert Vertical: On")
+				}
+				return locale.G.Get("Invert Vertical: Off")
+			},
+			Get: func() bool { return s.calibration().InvertY },
+			Set: func(on bool) error {
+				return s.changeCalibration(func(c *input.GamepadCalibration) {
+					c.InvertY = on
+				})
+			},
+		},
+		ControlsInputDisplay: &Toggle{
+			Label: func(on bool) string {
+				if on {
+					return locale.G.Get("Input Display: On")
+				}
+				return locale.G.Get("Input Display: Off")
+			},
+			Get: func() bool { return flag.Get[bool]("show_input") },
+			Set: func(on bool) error {
+				return toggleInputDisplay()
+			},
+		},
+		ControlsAccessibility: &Button{
+			Label: func() string { return locale.G.Get("Accessibility") },
+			Action: func() error {
+				return s.Controller.SaveConfigAndSwitchToScreen(&AccessibilityScreen{})
+			},
+		},
+		ControlsBack: &Button{
+			Label:  func() string { return locale.G.Get("Back") },
+			Action: s.exit,
+		},
+	}
+	return nil
+}
+
+// gamepadName returns the name of a gamepad to show in the menu.
+// Identical gamepads are numbered in the order they got connected.
+func gamepadName(pad input.GamepadInfo) string {
+	if pad.Index == 0 {
+		return pad.Name
+	}
+	return locale.G.Get("%s #%d", pad.Name, pad.Index+1)
+}
+
+// pad returns the gamepad being calibrated, if any.
+// This is the bound gamepad, or the only one if there is just one.
+func (s *ControlsScreen) pad() (input.GamepadInfo, bool) {
+	if pad, ok := input.BoundGamepad(); ok {
+		return pad, true
+	}
+	if len(s.Pads) == 1 {
+		return s.Pads[0], true
+	}
+	return input.GamepadInfo{}, false
+}
+
+// calibration returns the calibration of the gamepad being calibrated, if any.
+func (s *ControlsScreen) calibration() input.GamepadCalibration {
+	pad, ok := s.pad()
+	if !ok {
+		return input.GamepadCalibration{}
+	}
+	return input.GamepadCalibrationFor(pad.GUID)
+}
+
+// togglePad cycles through binding to each gamepad and using all of them.
+func (s *ControlsScreen) togglePad(delta int) error {
+	if len(s.Pads) == 0 {
+		return nil
+	}
+	// Index -1 stands for all gamepads.
+	idx := -1
+	if bound, ok := input.BoundGamepad(); ok {
+		for i, p := range s.Pads {
+			if p == bound {
+				idx = i
+			}
+		}
+	}
+	idx = m.Mod(idx+1+delta, len(s.Pads)+1) - 1
+	if idx < 0 {
+		input.UnbindGamepad()
+	} else {
+		input.BindGamepad(s.Pads[idx])
+	}
+	return nil
+}
+
+// changeCalibration modifies the calibration of the selected gamepad.
+func (s *ControlsScreen) changeCalibration(f func(c *input.GamepadCalibration)) error {
+	pad, ok := s.pad()
+	if !ok {
+		return nil
+	}
+	c := input.GamepadCalibrationFor(pad.GUID)
+	f(&c)
+	err := input.SetGamepadCalibration(pad.GUID, c)
+	if err != nil {
+		log.Errorf("could not save gamepad calibration: %v", err)
+	}
+	return nil
+}
+
+func (s *ControlsScreen) exit() error {
+	return s.Controller.SaveConfigAndSwitchToScreen(&SettingsScreen{})
+}
+
+func (s *ControlsScreen) Update() error {
+	s.Pads = input.Gamepads()
+	return updateList(s.Controller, &s.Item, s.Widgets, s.exit)
+}
+
+func (s *ControlsScreen) Draw(screen *ebiten.Image) {
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("Controls"), m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+	if pad, ok := s.pad(); ok {
+		x, y := pad.StickPosition()
+		rawX, rawY := pad.RawStickPosition()
+		font.ByName["MenuSmall"].DrawStyled(screen, locale.G.Get("Stick: %+.2f, %+.2f (raw: %+.2f, %+.2f)", x, y, rawX, rawY),
+			m.Pos{X: CenterX(), Y: ItemBaselineY(-1, ControlsCount)}, font.Center, textStyle())
+	}
+	drawList(screen, s.Item, s.Widgets)
+}
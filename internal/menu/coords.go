@@ -15,12 +15,24 @@
 package menu
 
 import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/announce"
 	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/font"
 	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
 )
 
-const CenterX = engine.GameWidth / 2
-const HeaderY = engine.GameHeight / 4
+// CenterX returns the horizontal center of the menu.
+func CenterX() int {
+	return engine.GameWidth / 2
+}
+
+// HeaderY returns the baseline of menu headers.
+func HeaderY() int {
+	return engine.GameHeight / 4
+}
 
 type Direction int
 
@@ -35,6 +47,44 @@ func ItemBaselineY(i, n int) int {
 	return engine.GameHeight * (31 - 2*(n-i)) / 32
 }
 
+// headerStyle returns the text style of menu headers.
+func headerStyle() font.Style {
+	return font.Style{
+		FG:       palette.EGA(palette.Yellow, 255),
+		Gradient: palette.EGA(palette.Brown, 255),
+		Outline:  palette.EGA(palette.Black, 255),
+		Shadow:   palette.EGA(palette.DarkGrey, 255),
+	}
+}
+
+// textStyle returns the text style of menu items and other menu text.
+func textStyle() font.Style {
+	return font.Style{
+		FG:      palette.EGA(palette.LightGrey, 255),
+		Outline: palette.EGA(palette.DarkGrey, 255),
+		Shadow:  palette.EGA(palette.Black, 255),
+	}
+}
+
+// selectedStyle returns the text style of the selected menu item.
+func selectedStyle() font.Style {
+	return font.Style{
+		FG:      palette.EGA(palette.Yellow, 255),
+		Outline: palette.EGA(palette.Black, 255),
+		Shadow:  palette.EGA(palette.DarkGrey, 255),
+	}
+}
+
+// drawItem draws menu item i of n. The selected item is highlighted and announced to screen readers.
+func drawItem(screen *ebiten.Image, text string, i, n int, selected bool) {
+	style := textStyle()
+	if selected {
+		style = selectedStyle()
+		announce.Focus(text)
+	}
+	font.ByName["Menu"].DrawStyled(screen, text, m.Pos{X: CenterX(), Y: ItemBaselineY(i, n)}, font.Center, style)
+}
+
 func ItemClicked(pos m.Pos, n int) (int, Direction) {
 	// Clicked far at side?
 	if pos.X < engine.GameWidth/8 || pos.X > 7*engine.GameWidth/8 {
@@ -16,6 +16,7 @@ package menu
 
 import (
 	"strings"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
@@ -49,10 +50,13 @@ type CreditsScreen struct {
 	Fancy bool // With music, and constant speed - no scrolling. No exiting. Background image not needed - we use last game screen.
 
 	Controller *Controller
-	Lines      []string // Actual lines to display.
-	Frame      int      // Subpixel accumulator.
-	ScrollPos  int      // Current scroll position.
-	Exits      int      // How often exit was pressed. Need to press 7 times to leave fancy credits.
+	Lines      []string         // Actual lines to display.
+	Markers    []credits.Marker // Music sync markers, with line numbers into Lines.
+	Frame      int              // Subpixel accumulator.
+	ScrollPos  int              // Current scroll position.
+	Exits      int              // How often exit was pressed. Need to press 7 times to leave fancy credits.
+	Frames     int              // Frames since the credits started.
+	StartPos   int              // Initial scroll position.
 }
 
 func localizeCredits(line string) string {
@@ -113,7 +117,19 @@ func (s *CreditsScreen) Init(m *Controller) error {
 				locale.G.Get("For Software Licenses{{BR}}Press Right")), "\n")...),
 			"")
 	}
-	for _, line := range credits.Lines {
+	if !s.Fancy {
+		s.Lines = append(append(s.Lines,
+			strings.Split(fun.FormatText(&s.Controller.World.PlayerState,
+				locale.G.Get("For the Debug Log{{BR}}Press {{ActionButton}}")), "\n")...),
+			"")
+	}
+	lines, markers := credits.Build()
+	s.Markers = nil
+	for _, marker := range markers {
+		marker.Line += len(s.Lines)
+		s.Markers = append(s.Markers, marker)
+	}
+	for _, line := range lines {
 		s.Lines = append(s.Lines, localizeCredits(line))
 	}
 	s.Lines = append(
@@ -160,14 +176,44 @@ func (s *CreditsScreen) Init(m *Controller) error {
 			locale.G.Get("Thank You!"))
 	}
 	s.ScrollPos = textScreenScrollInPos(s.Lines, creditsLineHeight)
+	s.StartPos = s.ScrollPos
+	s.Frames = 0
 	return nil
 }
 
+// musicTime returns the playback position of the credits music.
+// If no music is playing, e.g. because it is muted, it falls back to the time since the credits started.
+func (s *CreditsScreen) musicTime() time.Duration {
+	if t := music.Now(); t > 0 {
+		return t
+	}
+	return time.Duration(s.Frames) * time.Second / engine.GameTPS
+}
+
+// syncedScrollPos returns the scroll position the music sync markers request, if any.
+func (s *CreditsScreen) syncedScrollPos() (int, bool) {
+	now := s.musicTime()
+	// Scroll position and music time of the previous marker; the start is an implicit one.
+	prevPos, prevAt := s.StartPos, time.Duration(0)
+	for _, marker := range s.Markers {
+		pos := engine.GameHeight/2 - creditsLineHeight*marker.Line
+		if marker.At <= prevAt {
+			continue
+		}
+		if now < marker.At {
+			return prevPos + int(int64(pos-prevPos)*int64(now-prevAt)/int64(marker.At-prevAt)), true
+		}
+		prevPos, prevAt = pos, marker.At
+	}
+	return 0, false
+}
+
 func (s *CreditsScreen) Update() error {
 	exit := input.Exit.JustHit || input.Left.JustHit
 	up := input.Up.Held
 	down := input.Down.Held
 	licenses := input.Right.JustHit
+	debugLog := input.Action.JustHit
 	if pos, status := input.Mouse(); status != input.NoMouse {
 		if pos.Y < engine.GameHeight/3 {
 			up = true
@@ -194,6 +240,9 @@ func (s *CreditsScreen) Update() error {
 		if exit {
 			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&MainScreen{}))
 		}
+		if debugLog {
+			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&DebugLogScreen{}))
+		}
 		if licenses {
 			if len(credits.Licenses) == 0 {
 				// Source checkout - no license info.
@@ -211,6 +260,16 @@ func (s *CreditsScreen) Update() error {
 			s.Frame = 0
 		}
 	}
+	s.Frames++
+	if s.Fancy {
+		if pos, ok := s.syncedScrollPos(); ok {
+			// Music sync overrides the constant scrolling speed.
+			if pos < s.ScrollPos {
+				s.ScrollPos = pos
+			}
+			return nil
+		}
+	}
 	s.Frame++
 	if s.Frame >= creditsFrames {
 		s.ScrollPos = textScreenAdjustScrollDown(s.Lines, s.ScrollPos, 1, creditsLineHeight)
@@ -220,13 +279,13 @@ func (s *CreditsScreen) Update() error {
 }
 
 func (s *CreditsScreen) Draw(screen *ebiten.Image) {
-	fgs := palette.EGA(palette.Yellow, 255)
-	bgs := palette.EGA(palette.Black, 255)
-	fgn := palette.EGA(palette.LightCyan, 255)
-	bgn := palette.EGA(palette.Black, 0)
+	normal := font.Style{
+		FG:     palette.EGA(palette.LightCyan, 255),
+		Shadow: palette.EGA(palette.Black, 255),
+	}
 	pos := m.Pos{
 		X: engine.GameWidth / 2,
 		Y: s.ScrollPos,
 	}
-	renderTextScreen(screen, font.ByName["MenuBig"], font.ByName["Menu"], s.Lines, pos, font.Center, creditsLineHeight, fgs, bgs, fgn, bgn)
+	renderTextScreen(screen, font.ByName["MenuBig"], font.ByName["Menu"], s.Lines, pos, font.Center, creditsLineHeight, headerStyle(), normal)
 }
@@ -0,0 +1,142 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+const (
+	debugLogLineHeight = 12
+	debugLogStep       = 5
+	debugLogMargin     = 16
+)
+
+// debugLogFilters are the selectable maximum levels to show, most verbose first.
+var debugLogFilters = []log.Level{
+	log.DebugLevel,
+	log.InfoLevel,
+	log.WarningLevel,
+	log.ErrorLevel,
+}
+
+func debugLogFilterName(l log.Level) string {
+	switch l {
+	case log.DebugLevel:
+		return locale.G.Get("All Messages")
+	case log.InfoLevel:
+		return locale.G.Get("Info and Above")
+	case log.WarningLevel:
+		return locale.G.Get("Warnings and Above")
+	default: // case log.ErrorLevel:
+		return locale.G.Get("Errors Only")
+	}
+}
+
+type DebugLogScreen struct {
+	Controller *Controller
+	Filter     int // Index into debugLogFilters.
+	Frame      int // Frames since the lines were last refreshed.
+	Pane       ScrollPane
+}
+
+func (s *DebugLogScreen) Init(m *Controller) error {
+	s.Controller = m
+	s.Filter = 0
+	s.Pane = ScrollPane{
+		LineHeight: debugLogLineHeight,
+		Step:       debugLogStep,
+	}
+	s.refresh(true)
+	return nil
+}
+
+// wrapDebugLogLine splits a log line into pieces that fit the screen.
+func wrapDebugLogLine(line string, maxChars int) []string {
+	runes := []rune(line)
+	var out []string
+	for len(runes) > maxChars {
+		out = append(out, string(runes[:maxChars]))
+		runes = runes[maxChars:]
+	}
+	return append(out, string(runes))
+}
+
+// refresh rebuilds the displayed lines from the log.
+// If the view was at the end of the log, it stays there.
+func (s *DebugLogScreen) refresh(toEnd bool) {
+	if s.Pane.AtEnd() {
+		toEnd = true
+	}
+	maxLevel := debugLogFilters[s.Filter]
+	maxChars := (engine.GameWidth - 2*debugLogMargin) / font.ByName["MonoSmall"].Advance("m")
+	s.Pane.Lines = []string{
+		locale.G.Get("Debug Log: %s", debugLogFilterName(maxLevel)),
+		"",
+	}
+	for _, e := range log.Entries() {
+		if e.Level > maxLevel {
+			continue
+		}
+		line := e.Time.Format("15:04:05") + " [" + e.Level.String() + "] " + e.Message
+		s.Pane.Lines = append(s.Pane.Lines, wrapDebugLogLine(line, maxChars)...)
+	}
+	if toEnd {
+		s.Pane.ResetToEnd()
+	}
+	s.Frame = 0
+}
+
+func (s *DebugLogScreen) Update() error {
+	_, _, clicked := s.Pane.Update()
+	if input.Exit.JustHit || clicked {
+		return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&CreditsScreen{}))
+	}
+	if input.Left.JustHit {
+		s.Filter = (s.Filter + len(debugLogFilters) - 1) % len(debugLogFilters)
+		s.refresh(true)
+		return s.Controller.MoveSound(nil)
+	}
+	if input.Right.JustHit {
+		s.Filter = (s.Filter + 1) % len(debugLogFilters)
+		s.refresh(true)
+		return s.Controller.MoveSound(nil)
+	}
+	s.Frame++
+	if s.Frame >= engine.GameTPS {
+		s.refresh(false)
+	}
+	return nil
+}
+
+func (s *DebugLogScreen) Draw(screen *ebiten.Image) {
+	title := font.Style{
+		FG:      palette.EGA(palette.Yellow, 255),
+		Outline: palette.EGA(palette.Black, 255),
+	}
+	normal := font.Style{
+		FG:      palette.EGA(palette.LightGrey, 255),
+		Outline: palette.EGA(palette.Black, 255),
+	}
+	f := font.ByName["MonoSmall"]
+	s.Pane.Draw(screen, f, f, debugLogMargin, font.Left, title, normal)
+}
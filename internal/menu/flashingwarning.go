@@ -0,0 +1,107 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/effects"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+var (
+	flashingWarningShown = flag.Bool("flashing_warning_shown", false, "whether the warning about flashing effects has been shown")
+)
+
+// needFlashingWarning returns whether the flashing warning still needs to be shown at startup.
+func needFlashingWarning() bool {
+	if *flashingWarningShown {
+		return false
+	}
+	// Never interrupt demos with a menu.
+	return !demo.Playing() && !demo.Recording()
+}
+
+type FlashingWarningScreenItem int
+
+const (
+	FlashingWarningReduce = iota
+	FlashingWarningKeep
+	FlashingWarningCount
+)
+
+// FlashingWarningScreen warns about flashing effects on first launch and offers reducing them.
+type FlashingWarningScreen struct {
+	Controller *Controller
+	Item       FlashingWarningScreenItem
+}
+
+func (s *FlashingWarningScreen) Focus() int {
+	return int(s.Item)
+}
+
+func (s *FlashingWarningScreen) SetFocus(item int) {
+	s.Item = FlashingWarningScreenItem(item)
+}
+
+func (s *FlashingWarningScreen) Init(m *Controller) error {
+	s.Controller = m
+	return nil
+}
+
+// done remembers the choice and starts the game.
+func (s *FlashingWarningScreen) done(reduce bool) error {
+	effects.SetReduced(reduce)
+	flag.Set("flashing_warning_shown", true)
+	err := engine.SaveConfig()
+	if err != nil {
+		return fmt.Errorf("could not save config: %w", err)
+	}
+	return s.Controller.SwitchToGame()
+}
+
+func (s *FlashingWarningScreen) Update() error {
+	clicked := navigate(s.Controller, &s.Item, 0, int(FlashingWarningCount))
+	if input.Exit.JustHit {
+		return s.Controller.ActivateSound(s.done(effects.Reduced()))
+	}
+	if input.Jump.JustHit || input.Action.JustHit || clicked != NotClicked {
+		switch s.Item {
+		case FlashingWarningReduce:
+			return s.Controller.ActivateSound(s.done(true))
+		case FlashingWarningKeep:
+			return s.Controller.ActivateSound(s.done(false))
+		}
+	}
+	return nil
+}
+
+func (s *FlashingWarningScreen) Draw(screen *ebiten.Image) {
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("Warning"), m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+	font.ByName["MenuSmall"].DrawStyled(screen, locale.G.Get("This game contains flashing lights and shaking effects."),
+		m.Pos{X: CenterX(), Y: ItemBaselineY(-2, FlashingWarningCount)}, font.Center, textStyle())
+	font.ByName["MenuSmall"].DrawStyled(screen, locale.G.Get("They can also be reduced later in the accessibility settings."),
+		m.Pos{X: CenterX(), Y: ItemBaselineY(-1, FlashingWarningCount)}, font.Center, textStyle())
+	drawItem(screen, locale.G.Get("Reduce Flashing and Motion"), FlashingWarningReduce, FlashingWarningCount, s.Item == FlashingWarningReduce)
+	drawItem(screen, locale.G.Get("Keep All Effects"), FlashingWarningKeep, FlashingWarningCount, s.Item == FlashingWarningKeep)
+}
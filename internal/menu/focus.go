@@ -0,0 +1,120 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"reflect"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/input"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+var (
+	menuWrap = flag.Bool("menu_wrap", true, "wrap around when moving past the first or last menu item")
+)
+
+// The focused item of a menu screen is kept in its Item field and exposed by focuser.
+// Keyboard and gamepad move the focus; the mouse moves it when entering an item.
+// When switching screens, the focused item is remembered per screen type,
+// so going back to a screen focuses the item it was left from.
+
+// navigate moves the focus of a menu screen by keyboard, gamepad and mouse.
+// item points to the focused item; items first to n-1 can be focused.
+// It returns how the focused item was clicked.
+func navigate[T ~int](c *Controller, item *T, first, n int) Direction {
+	prev := *item
+	clicked := c.QueryMouseItem(item, n)
+	if int(*item) < first {
+		*item = prev
+		clicked = NotClicked
+	}
+	if input.Down.JustHit {
+		moveFocus(c, item, first, n, +1)
+	}
+	if input.Up.JustHit {
+		moveFocus(c, item, first, n, -1)
+	}
+	*item = T(clampFocus(int(*item), first, n))
+	return clicked
+}
+
+// clampFocus brings a focused item into the range first to n-1.
+func clampFocus(item, first, n int) int {
+	if *menuWrap {
+		return m.Mod(item-first, n-first) + first
+	}
+	return max(first, min(item, n-1))
+}
+
+// moveFocus moves the focus by the given number of items.
+func moveFocus[T ~int](c *Controller, item *T, first, n, delta int) {
+	next := T(clampFocus(int(*item)+delta, first, n))
+	if next == *item {
+		return
+	}
+	*item = next
+	c.MoveSound(nil)
+}
+
+// focuser is implemented by menu screens that have a focused item.
+type focuser interface {
+	MenuScreen
+
+	// Focus returns the focused item.
+	Focus() int
+
+	// SetFocus focuses the given item.
+	SetFocus(item int)
+}
+
+var (
+	_ focuser = &AccessibilityScreen{}
+	_ focuser = &AssistScreen{}
+	_ focuser = &ControlsScreen{}
+	_ focuser = &FlashingWarningScreen{}
+	_ focuser = &JukeboxScreen{}
+	_ focuser = &MainScreen{}
+	_ focuser = &NewGamePlusScreen{}
+	_ focuser = &PauseScreen{}
+	_ focuser = &ResetScreen{}
+	_ focuser = &SaveStateScreen{}
+	_ focuser = &SettingsScreen{}
+	_ focuser = &TouchEditScreen{}
+)
+
+// rememberFocus stores the focused item of the current screen.
+func (c *Controller) rememberFocus() {
+	c.hovering = false
+	f, ok := c.Screen.(focuser)
+	if !ok {
+		return
+	}
+	if c.focus == nil {
+		c.focus = map[reflect.Type]int{}
+	}
+	c.focus[reflect.TypeOf(f)] = f.Focus()
+}
+
+// restoreFocus focuses the item a screen was last left from, unless the caller chose an item.
+func (c *Controller) restoreFocus(screen MenuScreen) {
+	f, ok := screen.(focuser)
+	if !ok || f.Focus() != 0 {
+		return
+	}
+	if item, found := c.focus[reflect.TypeOf(f)]; found {
+		f.SetFocus(item)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+var (
+	cheatFreeCamera = flag.Bool("cheat_free_camera", false, "enable the free camera for map debugging; F4 detaches the camera from the player, and the mouse shows what is under the cursor")
+)
+
+// updateFreeCamera handles the free camera hotkey.
+func (c *Controller) updateFreeCamera() error {
+	if c.freeCameraSnapshot != nil && !c.World.FreeCamera() {
+		// Something else (e.g. loading a snapshot) already reattached the camera.
+		c.freeCameraSnapshot = nil
+	}
+	if !*cheatFreeCamera {
+		if c.freeCameraSnapshot != nil {
+			return c.reattachCamera()
+		}
+		return nil
+	}
+	if !inpututil.IsKeyJustPressed(ebiten.KeyF4) {
+		return nil
+	}
+	if c.freeCameraSnapshot != nil {
+		return c.reattachCamera()
+	}
+	s, err := c.World.SaveSnapshot()
+	if err != nil {
+		log.Errorf("could not detach camera: %v", err)
+		return nil
+	}
+	c.freeCameraSnapshot = s
+	c.World.SetFreeCamera(true)
+	snapshotMessage(locale.G.Get("Free camera"))
+	return nil
+}
+
+// reattachCamera returns the camera to the player and rebuilds the world around it.
+func (c *Controller) reattachCamera() error {
+	s := c.freeCameraSnapshot
+	c.freeCameraSnapshot = nil
+	c.World.SetFreeCamera(false)
+	err := c.World.LoadSnapshot(s)
+	if err != nil {
+		return err
+	}
+	snapshotMessage(locale.G.Get("Camera follows player"))
+	return nil
+}
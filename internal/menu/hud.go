@@ -0,0 +1,78 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+)
+
+const (
+	hudX          = 4
+	hudY          = 4
+	hudPipSize    = 6
+	hudPipSpacing = 8
+	hudLineHeight = 12
+)
+
+// drawHUD draws the in-game heads-up display on top of the world.
+func drawHUD(screen *ebiten.Image, w *engine.World) {
+	if maxHP := w.PlayerState.MaxHP(); maxHP > 0 {
+		drawHP(screen, w.PlayerState.HP(), maxHP)
+	}
+	drawCollectibles(screen, &w.PlayerState)
+}
+
+// drawHP draws one pip per hit point.
+func drawHP(screen *ebiten.Image, hp, maxHP int) {
+	for i := 0; i < maxHP; i++ {
+		x := float32(layoutRectX(hudX+i*hudPipSpacing, hudPipSize))
+		vector.DrawFilledRect(screen, x, hudY, hudPipSize, hudPipSize, palette.EGA(palette.Black, 255), false)
+		c := palette.EGA(palette.DarkGrey, 255)
+		if i < hp {
+			c = palette.EGA(palette.LightRed, 255)
+		}
+		vector.DrawFilledRect(screen, x+1, hudY+1, hudPipSize-2, hudPipSize-2, c, false)
+	}
+}
+
+// drawCollectibles draws one line per collectibles counter at the top right.
+func drawCollectibles(screen *ebiten.Image, ps *playerstate.PlayerState) {
+	y := hudY + hudLineHeight - 4
+	for _, counter := range ps.CollectibleCounters() {
+		font.ByName["Small"].Draw(screen,
+			locale.G.Get("%s: %d", collectibleCounterName(counter), ps.Collectibles(counter)),
+			m.Pos{X: layoutX(engine.GameWidth - hudX), Y: y}, layoutAlign(font.Right),
+			palette.EGA(palette.White, 255), palette.EGA(palette.Black, 255))
+		y += hudLineHeight
+	}
+}
+
+// collectibleCounterName returns the localized name of a collectibles counter.
+// Counters are named in the map and thus translated like level text,
+// except for the default counter of the Collectible entity.
+func collectibleCounterName(counter string) string {
+	if counter == "coins" {
+		return locale.G.Get("coins")
+	}
+	return locale.L.Get(counter) // "Unsupported call" warning expected here.
+}
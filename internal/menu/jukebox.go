@@ -0,0 +1,226 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/music"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/sound"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+type JukeboxScreenItem int
+
+const (
+	JukeboxTrack = iota
+	JukeboxPlay
+	JukeboxPack
+	JukeboxBack
+	JukeboxCount
+)
+
+type JukeboxScreen struct {
+	Controller *Controller
+	Item       JukeboxScreenItem
+	Tracks     []music.Track
+	Unlocked   []bool
+	Track      int
+	Packs      []string // Available audio packs; the first entry is the built-in audio.
+	Pack       int
+}
+
+func (s *JukeboxScreen) Focus() int {
+	return int(s.Item)
+}
+
+func (s *JukeboxScreen) SetFocus(item int) {
+	s.Item = JukeboxScreenItem(item)
+}
+
+func (s *JukeboxScreen) Init(c *Controller) error {
+	s.Controller = c
+	packs, err := vfs.AudioPacks()
+	if err != nil {
+		log.Errorf("could not list audio packs: %v", err)
+	}
+	s.Packs = append([]string{""}, packs...)
+	for i, p := range s.Packs {
+		if p == vfs.AudioPack() {
+			s.Pack = i
+		}
+	}
+	s.loadTracks()
+	return nil
+}
+
+// loadTracks finds all music tracks and their unlock state.
+func (s *JukeboxScreen) loadTracks() {
+	c := s.Controller
+	tracks, err := music.Tracks()
+	if err != nil {
+		log.Errorf("could not list music tracks: %v", err)
+	}
+	s.Tracks = tracks
+
+	// A track is unlocked once a checkpoint playing it has been seen.
+	unlocked := map[string]bool{}
+	ps := &c.World.PlayerState
+	for name, cp := range c.World.Level.Checkpoints {
+		if ps.CheckpointSeen(name) == playerstate.NotSeen {
+			continue
+		}
+		unlocked[propmap.StringOr(cp.Properties, "music", "")] = true
+	}
+	if ps.Won() {
+		unlocked[c.World.Level.CreditsMusic] = true
+	}
+	s.Unlocked = make([]bool, len(s.Tracks))
+	for i, t := range s.Tracks {
+		s.Unlocked[i] = unlocked[t.Name]
+	}
+	if s.Track >= len(s.Tracks) {
+		s.Track = 0
+	}
+}
+
+// movePack switches to another audio pack and reloads all audio.
+func (s *JukeboxScreen) movePack(delta int) error {
+	pack := m.Mod(s.Pack+delta, len(s.Packs))
+	err := vfs.SetAudioPack(s.Packs[pack])
+	if err != nil {
+		log.Errorf("could not switch audio pack: %v", err)
+		return nil
+	}
+	s.Pack = pack
+	err = sound.Reload()
+	if err != nil {
+		log.Errorf("could not reload sounds: %v", err)
+	}
+	music.Reload()
+	s.loadTracks()
+	return nil
+}
+
+// leave stops the preview and returns to the main menu.
+func (s *JukeboxScreen) leave() error {
+	music.StopPreview()
+	return s.Controller.SaveConfigAndSwitchToScreen(&MainScreen{})
+}
+
+// togglePlay starts or stops playing the selected track.
+func (s *JukeboxScreen) togglePlay() error {
+	if len(s.Tracks) == 0 || !s.Unlocked[s.Track] {
+		return nil
+	}
+	name := s.Tracks[s.Track].Name
+	if music.Previewing() == name {
+		music.StopPreview()
+	} else {
+		music.Preview(name)
+	}
+	return nil
+}
+
+// moveTrack selects another track. A playing preview follows the selection.
+func (s *JukeboxScreen) moveTrack(delta int) error {
+	if len(s.Tracks) == 0 {
+		return nil
+	}
+	s.Track = m.Mod(s.Track+delta, len(s.Tracks))
+	if music.Previewing() != "" {
+		if s.Unlocked[s.Track] {
+			music.Preview(s.Tracks[s.Track].Name)
+		} else {
+			music.StopPreview()
+		}
+	}
+	return nil
+}
+
+func (s *JukeboxScreen) Update() error {
+	clicked := navigate(s.Controller, &s.Item, 0, int(JukeboxCount))
+	if input.Exit.JustHit {
+		return s.Controller.ActivateSound(s.leave())
+	}
+	delta := 0
+	switch {
+	case input.Jump.JustHit || input.Action.JustHit || clicked == CenterClicked:
+		delta = 0
+	case input.Left.JustHit || clicked == LeftClicked:
+		delta = -1
+	case input.Right.JustHit || clicked == RightClicked:
+		delta = +1
+	default:
+		return nil
+	}
+	switch s.Item {
+	case JukeboxTrack:
+		if delta == 0 {
+			delta = +1
+		}
+		return s.Controller.MoveSound(s.moveTrack(delta))
+	case JukeboxPlay:
+		if delta == 0 {
+			return s.Controller.ActivateSound(s.togglePlay())
+		}
+	case JukeboxPack:
+		if delta == 0 {
+			delta = +1
+		}
+		return s.Controller.ActivateSound(s.movePack(delta))
+	case JukeboxBack:
+		if delta == 0 {
+			return s.Controller.ActivateSound(s.leave())
+		}
+	}
+	return nil
+}
+
+func (s *JukeboxScreen) Draw(screen *ebiten.Image) {
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("Jukebox"), m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+	if len(s.Tracks) == 0 {
+		drawItem(screen, locale.G.Get("No Music Found"), JukeboxTrack, JukeboxCount, s.Item == JukeboxTrack)
+	} else {
+		t := s.Tracks[s.Track]
+		title, artist := "???", ""
+		if s.Unlocked[s.Track] {
+			title, artist = t.Title, t.Artist
+		}
+		drawItem(screen, locale.G.Get("Track %d/%d: %s", s.Track+1, len(s.Tracks), title), JukeboxTrack, JukeboxCount, s.Item == JukeboxTrack)
+		if artist != "" {
+			font.ByName["MenuSmall"].DrawStyled(screen, locale.G.Get("by %s", artist),
+				m.Pos{X: CenterX(), Y: ItemBaselineY(-1, JukeboxCount)}, font.Center, textStyle())
+		}
+	}
+	playText := locale.G.Get("Play")
+	if music.Previewing() != "" {
+		playText = locale.G.Get("Stop")
+	}
+	drawItem(screen, playText, JukeboxPlay, JukeboxCount, s.Item == JukeboxPlay)
+	packText := locale.G.Get("Audio Pack: Built-in")
+	if s.Packs[s.Pack] != "" {
+		packText = locale.G.Get("Audio Pack: %s", s.Packs[s.Pack])
+	}
+	drawItem(screen, packText, JukeboxPack, JukeboxCount, s.Item == JukeboxPack)
+	drawItem(screen, locale.G.Get("Main Menu"), JukeboxBack, JukeboxCount, s.Item == JukeboxBack)
+}
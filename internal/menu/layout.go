@@ -0,0 +1,48 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/locale"
+)
+
+// Layout hooks for right-to-left locales.
+// Positions are given as if for a left-to-right layout and mirrored as needed.
+
+// layoutX returns the screen X coordinate of the given point.
+func layoutX(x int) int {
+	if locale.ActiveIsRightToLeft() {
+		return engine.GameWidth - x
+	}
+	return x
+}
+
+// layoutRectX returns the screen X coordinate of the left edge of a box of the given width.
+func layoutRectX(x, w int) int {
+	if locale.ActiveIsRightToLeft() {
+		return engine.GameWidth - x - w
+	}
+	return x
+}
+
+// layoutAlign returns the text alignment to use.
+func layoutAlign(a font.Align) font.Align {
+	if locale.ActiveIsRightToLeft() {
+		return a.Mirror()
+	}
+	return a
+}
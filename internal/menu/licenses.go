@@ -18,10 +18,8 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 
 	"github.com/divVerent/aaaaxy/internal/credits"
-	"github.com/divVerent/aaaaxy/internal/engine"
 	"github.com/divVerent/aaaaxy/internal/font"
 	"github.com/divVerent/aaaaxy/internal/input"
-	m "github.com/divVerent/aaaaxy/internal/math"
 	"github.com/divVerent/aaaaxy/internal/palette"
 )
 
@@ -34,54 +32,41 @@ const (
 type LicensesScreen struct {
 	Controller *Controller
 	Frame      int // Subpixel accumulator.
-	ScrollPos  int // Current scroll position.
+	Pane       ScrollPane
 }
 
 func (s *LicensesScreen) Init(m *Controller) error {
 	s.Controller = m
-	s.ScrollPos = textScreenStartPos(credits.Licenses, licensesLineHeight)
+	s.Pane = ScrollPane{
+		Lines:      credits.Licenses,
+		LineHeight: licensesLineHeight,
+		Step:       licensesStep,
+	}
+	s.Pane.Reset()
 	return nil
 }
 
 func (s *LicensesScreen) Update() error {
-	exit := input.Exit.JustHit || input.Left.JustHit || input.Right.JustHit
-	up := input.Up.Held
-	down := input.Down.Held
-	if pos, status := input.Mouse(); status != input.NoMouse {
-		if pos.Y < engine.GameHeight/3 {
-			up = true
-		} else if pos.Y > 2*engine.GameHeight/3 {
-			down = true
-		} else if status == input.ClickingMouse {
-			exit = true
-		}
-	}
-	if exit {
+	scrolled, _, clicked := s.Pane.Update()
+	if input.Exit.JustHit || input.Left.JustHit || input.Right.JustHit || clicked {
 		return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&MainScreen{}))
 	}
-	if up {
-		s.ScrollPos = textScreenAdjustScrollUp(credits.Licenses, s.ScrollPos, licensesStep, licensesLineHeight)
-		s.Frame = 0
-	}
-	if down {
-		s.ScrollPos = textScreenAdjustScrollDown(credits.Licenses, s.ScrollPos, licensesStep, licensesLineHeight)
+	if scrolled {
 		s.Frame = 0
 	}
 	s.Frame++
 	if s.Frame >= licensesFrames {
-		s.ScrollPos = textScreenAdjustScrollDown(credits.Licenses, s.ScrollPos, 1, licensesLineHeight)
+		s.Pane.ScrollDown(1)
 		s.Frame = 0
 	}
 	return nil
 }
 
 func (s *LicensesScreen) Draw(screen *ebiten.Image) {
-	fg := palette.EGA(palette.LightGrey, 255)
-	bg := palette.EGA(palette.Black, 255)
-	pos := m.Pos{
-		X: 64,
-		Y: s.ScrollPos,
+	style := font.Style{
+		FG:      palette.EGA(palette.LightGrey, 255),
+		Outline: palette.EGA(palette.Black, 255),
 	}
 	f := font.ByName["MonoSmall"]
-	renderTextScreen(screen, f, f, credits.Licenses, pos, font.Left, licensesLineHeight, fg, bg, fg, bg)
+	s.Pane.Draw(screen, f, f, 64, font.Left, style, style)
 }
@@ -0,0 +1,58 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// LoadingScreen shows progress while the level is reloaded in the background.
+type LoadingScreen struct {
+	Controller *Controller
+	// Then is called once loading is done.
+	Then  func() error
+	Frame int
+}
+
+func (s *LoadingScreen) Init(m *Controller) error {
+	s.Controller = m
+	return nil
+}
+
+func (s *LoadingScreen) Update() error {
+	s.Frame++
+	if s.Controller.levelLoading() {
+		return nil
+	}
+	return s.Then()
+}
+
+func (s *LoadingScreen) Draw(screen *ebiten.Image) {
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("Loading"), m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+	if s.Controller.levelLoader == nil {
+		return
+	}
+	text, fraction := s.Controller.levelLoader.Current()
+	dots := strings.Repeat(".", s.Frame/15%4)
+	font.ByName["Menu"].DrawStyled(screen, text+dots, m.Pos{X: CenterX(), Y: ItemBaselineY(0, 2)}, font.Center, textStyle())
+	font.ByName["Menu"].DrawStyled(screen, fmt.Sprintf("%d%%", m.Rint(100*fraction)), m.Pos{X: CenterX(), Y: ItemBaselineY(1, 2)}, font.Center, textStyle())
+}
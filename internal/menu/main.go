@@ -15,6 +15,8 @@
 package menu
 
 import (
+	"slices"
+
 	"github.com/hajimehoshi/ebiten/v2"
 
 	"github.com/divVerent/aaaaxy/internal/flag"
@@ -23,7 +25,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/input"
 	"github.com/divVerent/aaaaxy/internal/locale"
 	m "github.com/divVerent/aaaaxy/internal/math"
-	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/updatecheck"
 )
 
 var offerQuit = flag.SystemDefault(map[string]bool{
@@ -38,36 +40,86 @@ const (
 	Play = iota
 	Settings
 	Credits
+	Jukebox
+	ReleaseNotes
 	Quit
 	MainCount
 )
 
 type MainScreen struct {
 	Controller *Controller
-	Item       MainScreenItem
+	Item       int // Index into Items.
+	Items      []MainScreenItem
 	Count      int
+	IdleFrames int
+}
+
+func (s *MainScreen) Focus() int {
+	return int(s.Item)
+}
+
+func (s *MainScreen) SetFocus(item int) {
+	s.Item = item
 }
 
 func (s *MainScreen) Init(m *Controller) error {
 	s.Controller = m
-	s.Count = MainCount
-	if !offerQuit {
-		s.Count--
-	}
+	s.initItems()
 	return nil
 }
 
+// initItems determines which items to show, keeping the selected one.
+func (s *MainScreen) initItems() {
+	var selected MainScreenItem = Play
+	if s.Item < len(s.Items) {
+		selected = s.Items[s.Item]
+	}
+	s.Items = []MainScreenItem{Play, Settings, Credits, Jukebox}
+	if _, ok := updatecheck.Available(); ok {
+		s.Items = append(s.Items, ReleaseNotes)
+	}
+	if offerQuit {
+		s.Items = append(s.Items, Quit)
+	}
+	s.Count = len(s.Items)
+	if i := slices.Index(s.Items, selected); i >= 0 {
+		s.Item = i
+	}
+}
+
+func (s *MainScreen) itemText(item MainScreenItem) string {
+	switch item {
+	case Play:
+		return locale.G.Get("Play")
+	case Settings:
+		return locale.G.Get("Settings")
+	case Credits:
+		return locale.G.Get("Credits")
+	case Jukebox:
+		return locale.G.Get("Jukebox")
+	case ReleaseNotes:
+		return locale.G.Get("What's New")
+	default: // case Quit:
+		return locale.G.Get("Quit")
+	}
+}
+
 func (s *MainScreen) Update() error {
-	clicked := s.Controller.QueryMouseItem(&s.Item, s.Count)
-	if input.Down.JustHit {
-		s.Item++
-		s.Controller.MoveSound(nil)
+	if input.AnyJustHit() {
+		s.IdleFrames = 0
+	} else {
+		s.IdleFrames++
+	}
+	if attractModeAllowed() && s.IdleFrames >= attractModeIdleFrames() {
+		return s.Controller.StartAttract()
 	}
-	if input.Up.JustHit {
-		s.Item--
-		s.Controller.MoveSound(nil)
+
+	if _, ok := updatecheck.Available(); ok != slices.Contains(s.Items, ReleaseNotes) {
+		// The update check finished while on this screen.
+		s.initItems()
 	}
-	s.Item = MainScreenItem(m.Mod(int(s.Item), int(s.Count)))
+
+	clicked := navigate(s.Controller, &s.Item, 0, s.Count)
 
 	/*
 		Actually not allowed as it could be used for pausebuffering.
@@ -76,13 +128,17 @@ func (s *MainScreen) Update() error {
 		}
 	*/
 	if input.Jump.JustHit || input.Action.JustHit || clicked != NotClicked {
-		switch s.Item {
+		switch s.Items[s.Item] {
 		case Play:
 			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&MapScreen{}))
 		case Settings:
 			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&SettingsScreen{}))
 		case Credits:
 			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&CreditsScreen{Fancy: false}))
+		case Jukebox:
+			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&JukeboxScreen{}))
+		case ReleaseNotes:
+			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&ReleaseNotesScreen{}))
 		case Quit:
 			return s.Controller.ActivateSound(s.Controller.QuitGame())
 		}
@@ -91,37 +147,14 @@ func (s *MainScreen) Update() error {
 }
 
 func (s *MainScreen) Draw(screen *ebiten.Image) {
-	fgs := palette.EGA(palette.Yellow, 255)
-	bgs := palette.EGA(palette.Black, 255)
-	fgn := palette.EGA(palette.LightGrey, 255)
-	bgn := palette.EGA(palette.DarkGrey, 255)
-	font.ByName["MenuBig"].Draw(screen, "AAAAXY", m.Pos{X: CenterX, Y: HeaderY}, font.Center, fgs, bgs)
-	fg, bg := fgn, bgn
-	if s.Item == Play {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Play"), m.Pos{X: CenterX, Y: ItemBaselineY(Play, s.Count)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == Settings {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Settings"), m.Pos{X: CenterX, Y: ItemBaselineY(Settings, s.Count)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == Credits {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Credits"), m.Pos{X: CenterX, Y: ItemBaselineY(Credits, s.Count)}, font.Center, fg, bg)
-	if offerQuit {
-		fg, bg = fgn, bgn
-		if s.Item == Quit {
-			fg, bg = fgs, bgs
-		}
-		font.ByName["Menu"].Draw(screen, locale.G.Get("Quit"), m.Pos{X: CenterX, Y: ItemBaselineY(Quit, s.Count)}, font.Center, fg, bg)
+	font.ByName["MenuBig"].DrawStyled(screen, "AAAAXY", m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+	for i, item := range s.Items {
+		drawItem(screen, s.itemText(item), i, s.Count, s.Item == i)
 	}
 
 	// Display stats.
-	font.ByName["MenuSmall"].Draw(screen, fun.FormatText(&s.Controller.World.PlayerState, locale.G.Get("Score: {{Score}}{{SpeedrunCategoriesShort}} | Time: {{GameTime}}")),
-		m.Pos{X: CenterX, Y: ItemBaselineY(-2, s.Count)}, font.Center,
-		fgn, bgn)
+	font.ByName["MenuSmall"].DrawStyled(screen, fun.FormatText(&s.Controller.World.PlayerState, locale.G.Get("Score: {{Score}}{{SpeedrunCategoriesShort}} | Time: {{GameTime}}")),
+		m.Pos{X: CenterX(), Y: ItemBaselineY(-2, s.Count)}, font.Center, textStyle())
 
+	s.Controller.drawTitleFooter(screen)
 }
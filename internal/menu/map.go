@@ -16,11 +16,12 @@ package menu
 
 import (
 	"fmt"
-	"math/rand"
 	"sort"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
+	"github.com/divVerent/aaaaxy/internal/announce"
+	"github.com/divVerent/aaaaxy/internal/effects"
 	"github.com/divVerent/aaaaxy/internal/engine"
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/font"
@@ -33,12 +34,15 @@ import (
 	"github.com/divVerent/aaaaxy/internal/palette"
 	"github.com/divVerent/aaaaxy/internal/playerstate"
 	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/rng"
 )
 
 var (
 	debugAntiAlias = flag.Bool("debug_anti_alias", true, "allow anti aliasing")
 )
 
+var mapRand = rng.Unseeded() // Only used while drawing.
+
 type MapScreen struct {
 	Controller  *Controller
 	Level       *level.Level
@@ -258,26 +262,23 @@ func (s *MapScreen) Draw(screen *ebiten.Image) {
 	h := engine.GameHeight
 	w := engine.GameWidth
 	x := w / 2
-	fgn := palette.EGA(palette.LightGrey, 255)
-	bgn := palette.EGA(palette.DarkGrey, 255)
-	fgs := palette.EGA(palette.Yellow, 255)
-	bgs := palette.EGA(palette.Black, 255)
 	takenRouteColor := palette.EGA(palette.LightGrey, 255)
 	selectedRouteColor := palette.EGA(palette.Yellow, 255)
 	unseenPathToSeenCPColor := palette.EGA(palette.White, 255)
 	unseenPathToUnseenCPColor := palette.EGA(palette.Black, 255)
 	unseenPathBlinkColor := palette.EGA(palette.DarkGrey, 255)
-	font.ByName["MenuBig"].Draw(screen, locale.G.Get("Pick-a-Path"), m.Pos{X: x, Y: h / 12}, font.Center, fgs, bgs)
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("Pick-a-Path"), m.Pos{X: x, Y: h / 12}, font.Center, headerStyle())
 	cpText := fun.FormatText(&s.Controller.World.PlayerState, propmap.ValueP(s.Controller.World.Level.Checkpoints[s.CurrentCP].Properties, "text", "", nil))
 	seen, total := s.Controller.World.PlayerState.TnihSignsSeen(s.CurrentCP)
 	if total > 0 {
 		cpText = locale.G.Get("%s (%d/%d)", cpText, seen, total)
 	}
-	fg, bg := fgn, bgn
+	style := textStyle()
 	if s.nameHovered {
-		fg, bg = fgs, bgs
+		style = selectedStyle()
 	}
-	font.ByName["Menu"].Draw(screen, cpText, m.Pos{X: x, Y: 11*h/12 + 12}, font.Center, fg, bg)
+	font.ByName["Menu"].DrawStyled(screen, cpText, m.Pos{X: x, Y: 11*h/12 + 12}, font.Center, style)
+	announce.Focus(cpText)
 
 	// Draw all known checkpoints.
 	opts := ebiten.DrawImageOptions{
@@ -293,8 +294,8 @@ func (s *MapScreen) Draw(screen *ebiten.Image) {
 	cpPos := make(map[string]m.Pos, len(s.SortedLocs))
 	for cpName, pos := range s.CPPos {
 		if propmap.ValueOrP(s.Controller.World.Level.Checkpoints[cpName].Properties, "hub", false, nil) {
-			pos.X += rand.Intn(3) - 1
-			pos.Y += rand.Intn(3) - 1
+			pos.X += effects.Motion(mapRand.Intn(3) - 1)
+			pos.Y += effects.Motion(mapRand.Intn(3) - 1)
 		}
 		cpPos[cpName] = pos
 	}
@@ -350,7 +351,7 @@ func (s *MapScreen) Draw(screen *ebiten.Image) {
 						color = unseenPathToUnseenCPColor
 					}
 
-					if focusMissing && rand.Intn(2) == 0 {
+					if focusMissing && effects.Blink(mapRand.Intn(2) == 0) {
 						if focusSecrets {
 							// Once all CPs are hit and paths are set, blink secret paths.
 							if isSecret {
@@ -432,7 +433,7 @@ func (s *MapScreen) Draw(screen *ebiten.Image) {
 		}
 		opts.GeoM.Translate(float64(pos.X-7), float64(pos.Y-7))
 		if propmap.ValueOrP(s.Controller.World.Level.Checkpoints[cpName].Properties, "final", false, nil) {
-			c := rand.Float32() * 2.0
+			c := float32(effects.Attenuate(mapRand.Float64()*2.0, 1.5))
 			opts.ColorScale.Scale(c, c, c, 1.0)
 		}
 		screen.DrawImage(sprite, &opts)
@@ -18,19 +18,26 @@ import (
 	"fmt"
 	"image/color"
 	"reflect"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
+	"github.com/divVerent/aaaaxy/internal/announce"
 	"github.com/divVerent/aaaaxy/internal/engine"
 	"github.com/divVerent/aaaaxy/internal/exitstatus"
 	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/fun"
 	_ "github.com/divVerent/aaaaxy/internal/game" // Load entities.
 	"github.com/divVerent/aaaaxy/internal/input"
 	"github.com/divVerent/aaaaxy/internal/log"
 	"github.com/divVerent/aaaaxy/internal/music"
 	"github.com/divVerent/aaaaxy/internal/offscreen"
 	"github.com/divVerent/aaaaxy/internal/playerstate"
+	"github.com/divVerent/aaaaxy/internal/presence"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/quality"
 	"github.com/divVerent/aaaaxy/internal/sound"
+	"github.com/divVerent/aaaaxy/internal/telemetry"
 	"github.com/divVerent/aaaaxy/internal/timing"
 )
 
@@ -59,9 +66,32 @@ type Controller struct {
 	blurFrame       int
 	creditsBlur     bool
 	needReloadLevel bool
+	levelLoader     *engine.LevelLoader
 	needReloadGame  bool
 	nextFrame       []func() error
 	nextFrameReady  bool
+	attractStarting bool
+	attractFrame    int
+	snapshots       [snapshotSlots]*engine.Snapshot
+	snapshotSlot    int
+
+	freeCameraSnapshot *engine.Snapshot
+
+	// titleBackground is shown behind the main menu.
+	titleBackground *engine.TitleBackground
+
+	// updateNotice is shown on the main menu when a newer release is available.
+	updateNotice string
+
+	// newGameModifiers are applied when the save game gets reset.
+	newGameModifiers playerstate.Modifiers
+
+	// focus is the item each screen type was last left from.
+	focus map[reflect.Type]int
+
+	// hoverItem is the item the mouse was last over, if hovering.
+	hoverItem int
+	hovering  bool
 
 	WhiteImage *ebiten.Image
 }
@@ -86,10 +116,21 @@ func (c *Controller) Update() error {
 		if err != nil {
 			return err
 		}
+		if needFlashingWarning() {
+			err = c.SwitchToScreen(&FlashingWarningScreen{})
+			if err != nil {
+				return err
+			}
+		}
 		input.CancelHover()
 		c.initialized = true
 	}
 
+	timing.Section("attract")
+	if skip, err := c.updateAttract(); skip {
+		return err
+	}
+
 	timing.Section("global_hotkeys")
 
 	if c.World.ForceCredits {
@@ -101,6 +142,7 @@ func (c *Controller) Update() error {
 		if c.World.PlayerState.LastCheckpoint() != "" || c.World.PlayerState.Frames() > 0 {
 			c.World.TimerStarted = true
 		}
+		prevMusic := music.Current()
 		music.Switch("")
 		if c.World.TimerStarted {
 			c.World.PlayerState.AddEscape()
@@ -108,11 +150,21 @@ func (c *Controller) Update() error {
 		c.World.PreDespawn()
 		c.blurFrame = 0
 		c.creditsBlur = false
-		return c.SwitchToScreen(&MainScreen{})
+		return c.SwitchToScreen(&PauseScreen{Music: prevMusic})
 	}
 	if input.Fullscreen.JustHit {
 		c.toggleFullscreen()
 	}
+	if c.Screen == nil {
+		err := c.updateSnapshots()
+		if err != nil {
+			return err
+		}
+		err = c.updateFreeCamera()
+		if err != nil {
+			return err
+		}
+	}
 
 	timing.Section("screen")
 	if c.Screen != nil {
@@ -145,6 +197,7 @@ func (c *Controller) Update() error {
 	} else {
 		c.blurFrame = 0
 		c.creditsBlur = false
+		announce.Reset()
 		if c.World.TimerStopped {
 			input.SetMode(input.EndingMode)
 		} else {
@@ -152,7 +205,14 @@ func (c *Controller) Update() error {
 		}
 	}
 
-	performQualityAdjustment()
+	timing.Section("title_background")
+	c.updateUpdateNotice()
+	err := c.updateTitleBackground()
+	if err != nil {
+		return err
+	}
+
+	quality.Update()
 
 	return nil
 }
@@ -165,9 +225,18 @@ func (c *Controller) toggleFullscreen() error {
 	return nil
 }
 
-func (c *Controller) toggleStretch() error {
-	flag.Set("screen_stretch", !flag.Get[bool]("screen_stretch"))
-	input.CancelHover() // Fullscreen toggle changes mouse position; ignore hover events for that.
+// toggleScreenScaling cycles through letterboxed, integer scaled and stretched screen.
+func (c *Controller) toggleScreenScaling() error {
+	switch {
+	case flag.Get[bool]("screen_stretch"):
+		flag.Set("screen_stretch", false)
+		flag.Set("screen_integer_scaling", false)
+	case flag.Get[bool]("screen_integer_scaling"):
+		flag.Set("screen_stretch", true)
+	default:
+		flag.Set("screen_integer_scaling", true)
+	}
+	input.CancelHover() // Scaling change changes mouse position; ignore hover events for that.
 	return nil
 }
 
@@ -178,13 +247,38 @@ func (c *Controller) UpdateWorld() error {
 		c.World.PlayerState.AddFrame()
 	}
 
+	c.updatePresence()
+
 	if c.Screen != nil {
 		// Game is paused while in menu.
 		return nil
 	}
+
+	// Remember which input devices are used to play this save game.
+	c.World.PlayerState.AddInputUsage(input.UsedInputMap())
+	telemetry.RecordInput(input.UsedInputMap())
 	return c.World.Update()
 }
 
+// updatePresence tells Discord what the player is doing, if enabled.
+func (c *Controller) updatePresence() {
+	if !presence.Enabled() {
+		return
+	}
+	var cpName string
+	if cp := c.World.PlayerState.LastCheckpoint(); cp != "" {
+		text := propmap.StringOr(c.World.Level.Checkpoints[cp].Properties, "text", cp)
+		cpName = strings.ReplaceAll(fun.FormatText(&c.World.PlayerState, text), "\n", " ")
+	}
+	presence.Update(presence.Activity{
+		Map:        c.World.Level.Title,
+		Checkpoint: cpName,
+		Frames:     c.World.PlayerState.Frames(),
+		Timing:     c.World.TimerStarted && !c.World.TimerStopped,
+		Paused:     c.Screen != nil,
+	})
+}
+
 func (c *Controller) Draw(screen *ebiten.Image) {
 	defer timing.Group()()
 
@@ -193,12 +287,19 @@ func (c *Controller) Draw(screen *ebiten.Image) {
 		c.Screen.Draw(screen)
 	}
 
+	timing.Section("attract")
+	c.drawAttract(screen)
+
 	if c.nextFrame != nil {
 		c.nextFrameReady = true
 	}
 }
 
 func (c *Controller) DrawWorld(screen *ebiten.Image) {
+	if c.drawTitleBackground(screen) {
+		return
+	}
+
 	f := float64(c.blurFrame) / blurFrames
 
 	dest := screen
@@ -215,6 +316,7 @@ func (c *Controller) DrawWorld(screen *ebiten.Image) {
 		fWorld = 0
 	}
 	c.World.Draw(dest, fWorld)
+	drawHUD(dest, &c.World)
 
 	if f != 0 {
 		// If a menu screen is active, just draw the previous saved bitmap, but blur it.
@@ -240,9 +342,14 @@ func (c *Controller) initGame(f resetFlag) error {
 
 	// Reload the level if really needed.
 	if c.needReloadLevel {
-		err := engine.ReloadLevel()
-		if err != nil {
-			return err
+		if c.levelLoader != nil {
+			// Already reloaded in the background.
+			c.levelLoader = nil
+		} else {
+			err := engine.ReloadLevel()
+			if err != nil {
+				return err
+			}
 		}
 		c.needReloadLevel = false
 	}
@@ -261,6 +368,14 @@ func (c *Controller) initGame(f resetFlag) error {
 		}
 	}
 
+	// Start a new game with the chosen modifiers.
+	if f == resetGame {
+		c.World.PlayerState.SetModifiers(c.newGameModifiers)
+	}
+
+	// Show the buttons of the input device this save game was played with.
+	input.SetPreferredInputMap(c.World.PlayerState.PreferredInputMap())
+
 	c.needReloadGame = false
 
 	return nil
@@ -268,12 +383,18 @@ func (c *Controller) initGame(f resetFlag) error {
 
 // InitGame is called by menu screens to load/reset the game.
 func (c *Controller) InitGame(f resetFlag) error {
+	if c.levelLoading() {
+		return c.SwitchToScreen(&LoadingScreen{Then: func() error {
+			return c.InitGame(f)
+		}})
+	}
 	err := c.initGame(f)
 	if err != nil {
 		return err
 	}
 
 	// Go to the game screen.
+	c.rememberFocus()
 	c.Screen = nil
 	return nil
 }
@@ -283,7 +404,7 @@ func (c *Controller) SwitchSaveState(state int) error {
 	// Save the game first.
 	err := c.World.Save()
 	if err != nil {
-		log.Errorf("could not save game: %w", err)
+		log.Errorf("could not save game: %v", err)
 		// Proceed anyway, as the current save state will be lost if we crash too.
 	}
 
@@ -300,18 +421,27 @@ func (c *Controller) SwitchSaveState(state int) error {
 
 // SwitchToGame switches to the game without teleporting.
 func (c *Controller) SwitchToGame() error {
+	if c.levelLoading() {
+		return c.SwitchToScreen(&LoadingScreen{Then: c.SwitchToGame})
+	}
 	if c.needReloadGame {
 		err := c.initGame(loadGame)
 		if err != nil {
 			return err
 		}
 	}
+	c.rememberFocus()
 	c.Screen = nil
 	return nil
 }
 
 // SwitchToCheckpoint switches to a specific checkpoint.
 func (c *Controller) SwitchToCheckpoint(cp string) error {
+	if c.levelLoading() {
+		return c.SwitchToScreen(&LoadingScreen{Then: func() error {
+			return c.SwitchToCheckpoint(cp)
+		}})
+	}
 	if c.needReloadGame {
 		err := c.initGame(loadGame)
 		if err != nil {
@@ -326,12 +456,16 @@ func (c *Controller) SwitchToCheckpoint(cp string) error {
 		return fmt.Errorf("could not respawn player: %w", err)
 	}
 	c.World.TimerStarted = true
+	c.rememberFocus()
 	c.Screen = nil
 	return nil
 }
 
 // SwitchToScreen is called by menu screens to go to a different menu screen.
 func (c *Controller) SwitchToScreen(screen MenuScreen) error {
+	announce.Reset()
+	c.rememberFocus()
+	c.restoreFocus(screen)
 	c.Screen = screen
 	return c.Screen.Init(c)
 }
@@ -342,6 +476,9 @@ func (c *Controller) SaveConfigAndSwitchToScreen(screen MenuScreen) error {
 	if err != nil {
 		return fmt.Errorf("could not save config: %w", err)
 	}
+	announce.Reset()
+	c.rememberFocus()
+	c.restoreFocus(screen)
 	c.Screen = screen
 	return c.Screen.Init(c)
 }
@@ -350,6 +487,7 @@ func (c *Controller) SaveConfigAndSwitchToScreen(screen MenuScreen) error {
 func (c *Controller) QuitGame() error {
 	categories, _ := (c.World.PlayerState.SpeedrunCategories() | playerstate.AnyPercentSpeedrun).Describe()
 	log.Infof("on track for %v", categories)
+	telemetry.RecordQuit(c.World.Level.Title, c.World.PlayerState.LastCheckpoint())
 	err := c.World.Save()
 	if err != nil {
 		return fmt.Errorf("could not save game: %w", err)
@@ -390,18 +528,25 @@ func (c *Controller) NextFrame(f func() error) error {
 func (c *Controller) QueryMouseItem(item interface{}, count int) Direction {
 	mousePos, mouseState := input.Mouse()
 	if mouseState == input.NoMouse {
+		c.hovering = false
 		return NotClicked
 	}
 	if idx, dir := ItemClicked(mousePos, count); dir != NotClicked {
-		v := reflect.ValueOf(item).Elem()
-		prev := v.Int()
-		if int64(idx) != prev {
-			v.SetInt(int64(idx))
-			c.MoveSound(nil)
+		// Only entering an item moves the focus, so a resting mouse does not fight keyboard and gamepad.
+		if !c.hovering || idx != c.hoverItem || mouseState == input.ClickingMouse {
+			c.hoverItem, c.hovering = idx, true
+			v := reflect.ValueOf(item).Elem()
+			prev := v.Int()
+			if int64(idx) != prev {
+				v.SetInt(int64(idx))
+				c.MoveSound(nil)
+			}
 		}
 		if mouseState == input.ClickingMouse {
 			return dir
 		}
+	} else {
+		c.hovering = false
 	}
 	return NotClicked
 }
@@ -417,5 +562,21 @@ func (c *Controller) GameChanged() error {
 func (c *Controller) LevelChanged() error {
 	c.GameChanged()
 	c.needReloadLevel = true
+	c.levelLoader = engine.ReloadLevelAsync(c.levelLoader)
 	return nil
 }
+
+// levelLoading returns whether a level reload is still running in the background.
+func (c *Controller) levelLoading() bool {
+	if c.levelLoader == nil {
+		return false
+	}
+	done, err := c.levelLoader.Done()
+	if err != nil {
+		// Retry synchronously when entering the game to report the error.
+		log.Errorf("could not reload level in the background: %v", err)
+		c.levelLoader = nil
+		return false
+	}
+	return !done
+}
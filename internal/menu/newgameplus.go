@@ -0,0 +1,111 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+)
+
+type NewGamePlusScreenItem int
+
+const (
+	NewGamePlusMirror = iota
+	NewGamePlusLowVisibility
+	NewGamePlusDoubleSpeed
+	NewGamePlusBack
+	NewGamePlusCount
+)
+
+// NewGamePlusScreen selects the modifiers the next reset of the save game starts with.
+type NewGamePlusScreen struct {
+	Controller *Controller
+	Item       NewGamePlusScreenItem
+	Widgets    []Widget
+}
+
+func (s *NewGamePlusScreen) Focus() int {
+	return int(s.Item)
+}
+
+func (s *NewGamePlusScreen) SetFocus(item int) {
+	s.Item = NewGamePlusScreenItem(item)
+}
+
+func (s *NewGamePlusScreen) Init(m *Controller) error {
+	s.Controller = m
+	s.Widgets = []Widget{
+		NewGamePlusMirror: s.modifierToggle(playerstate.MirrorModifier, func(on bool) string {
+			if on {
+				return locale.G.Get("Mirror: On")
+			}
+			return locale.G.Get("Mirror: Off")
+		}),
+		NewGamePlusLowVisibility: s.modifierToggle(playerstate.LowVisibilityModifier, func(on bool) string {
+			if on {
+				return locale.G.Get("Low Visibility: On")
+			}
+			return locale.G.Get("Low Visibility: Off")
+		}),
+		NewGamePlusDoubleSpeed: s.modifierToggle(playerstate.DoubleSpeedModifier, func(on bool) string {
+			if on {
+				return locale.G.Get("Double Speed: On")
+			}
+			return locale.G.Get("Double Speed: Off")
+		}),
+		NewGamePlusBack: &Button{
+			Label:  func() string { return locale.G.Get("Back") },
+			Action: s.exit,
+		},
+	}
+	return nil
+}
+
+// modifierToggle returns a widget turning a modifier for the next new game on or off.
+func (s *NewGamePlusScreen) modifierToggle(mod playerstate.Modifiers, label func(on bool) string) *Toggle {
+	return &Toggle{
+		Label: label,
+		Get: func() bool {
+			return s.Controller.newGameModifiers.ContainAll(mod)
+		},
+		Set: func(on bool) error {
+			if on {
+				s.Controller.newGameModifiers |= mod
+			} else {
+				s.Controller.newGameModifiers &^= mod
+			}
+			return nil
+		},
+	}
+}
+
+func (s *NewGamePlusScreen) exit() error {
+	return s.Controller.SwitchToScreen(&ResetScreen{Item: ResetNewGamePlus})
+}
+
+func (s *NewGamePlusScreen) Update() error {
+	return updateList(s.Controller, &s.Item, s.Widgets, s.exit)
+}
+
+func (s *NewGamePlusScreen) Draw(screen *ebiten.Image) {
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("New Game Plus"), m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+	font.ByName["MenuSmall"].DrawStyled(screen, locale.G.Get("Modifiers apply when resetting the save state."),
+		m.Pos{X: CenterX(), Y: ItemBaselineY(-1, NewGamePlusCount)}, font.Center, textStyle())
+	drawList(screen, s.Item, s.Widgets)
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/fun"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/music"
+)
+
+var resumeCountdown = flag.Duration("resume_countdown", 0, "time counted down before the game resumes from the pause menu; zero resumes immediately")
+
+type PauseScreenItem int
+
+const (
+	PauseResume = iota
+	PauseMainMenu
+	PauseCount
+)
+
+// PauseScreen is shown when leaving the game mid-run.
+// It shows the current run stats and resumes the game, optionally after a countdown.
+type PauseScreen struct {
+	Controller *Controller
+	Item       PauseScreenItem
+	Widgets    []Widget
+
+	// Music is the track that was playing when the game was paused.
+	Music string
+
+	// Countdown is the number of frames left until the game resumes, or zero if not counting down.
+	Countdown int
+}
+
+func (s *PauseScreen) Focus() int {
+	return int(s.Item)
+}
+
+func (s *PauseScreen) SetFocus(item int) {
+	s.Item = PauseScreenItem(item)
+}
+
+func (s *PauseScreen) Init(c *Controller) error {
+	s.Controller = c
+	s.Widgets = []Widget{
+		PauseResume: &Button{
+			Label:  func() string { return locale.G.Get("Resume") },
+			Action: s.startCountdown,
+		},
+		PauseMainMenu: &Button{
+			Label:  func() string { return locale.G.Get("Main Menu") },
+			Action: func() error { return s.Controller.SwitchToScreen(&MainScreen{}) },
+		},
+	}
+	return nil
+}
+
+// resumeCountdownFrames returns the length of the resume countdown in frames.
+func resumeCountdownFrames() int {
+	return int(resumeCountdown.Seconds() * engine.GameTPS)
+}
+
+func (s *PauseScreen) startCountdown() error {
+	s.Countdown = resumeCountdownFrames()
+	if s.Countdown > 0 {
+		return nil
+	}
+	return s.resume()
+}
+
+func (s *PauseScreen) resume() error {
+	music.Switch(s.Music)
+	return s.Controller.SwitchToGame()
+}
+
+func (s *PauseScreen) Update() error {
+	if s.Countdown > 0 {
+		if input.Exit.JustHit {
+			// Cancel the countdown.
+			s.Countdown = 0
+			return s.Controller.MoveSound(nil)
+		}
+		s.Countdown--
+		if s.Countdown%engine.GameTPS == 0 {
+			s.Controller.MoveSound(nil)
+		}
+		if s.Countdown > 0 {
+			return nil
+		}
+		return s.resume()
+	}
+	return updateList(s.Controller, &s.Item, s.Widgets, s.startCountdown)
+}
+
+func (s *PauseScreen) Draw(screen *ebiten.Image) {
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("Paused"), m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+
+	// Display run stats and the rules that apply to them.
+	ps := &s.Controller.World.PlayerState
+	font.ByName["MenuSmall"].DrawStyled(screen, fun.FormatText(ps, locale.G.Get("Time: {{GameTime}}")),
+		m.Pos{X: CenterX(), Y: ItemBaselineY(-3, PauseCount)}, font.Center, textStyle())
+	font.ByName["MenuSmall"].DrawStyled(screen, fun.FormatText(ps, locale.G.Get("Categories: {{SpeedrunCategories}}")),
+		m.Pos{X: CenterX(), Y: ItemBaselineY(-2, PauseCount)}, font.Center, textStyle())
+	font.ByName["MenuSmall"].DrawStyled(screen, locale.G.Get("The timer keeps running while paused."),
+		m.Pos{X: CenterX(), Y: ItemBaselineY(-1, PauseCount)}, font.Center, textStyle())
+
+	if s.Countdown > 0 {
+		seconds := (s.Countdown + engine.GameTPS - 1) / engine.GameTPS
+		font.ByName["MenuBig"].DrawStyled(screen, fmt.Sprint(seconds),
+			m.Pos{X: CenterX(), Y: ItemBaselineY(PauseResume, PauseCount)}, font.Center, selectedStyle())
+		return
+	}
+	drawList(screen, s.Item, s.Widgets)
+}
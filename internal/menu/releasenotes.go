@@ -0,0 +1,85 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/updatecheck"
+)
+
+const (
+	releaseNotesLineHeight = 12
+	releaseNotesStep       = 5
+	releaseNotesMargin     = 16
+)
+
+// ReleaseNotesScreen shows the release notes of a newer release.
+// The update itself is never downloaded; the player is pointed to the release page instead.
+type ReleaseNotesScreen struct {
+	Controller *Controller
+	Pane       ScrollPane
+}
+
+func (s *ReleaseNotesScreen) Init(m *Controller) error {
+	s.Controller = m
+	s.Pane = ScrollPane{
+		LineHeight: releaseNotesLineHeight,
+		Step:       releaseNotesStep,
+	}
+	r, ok := updatecheck.Available()
+	if !ok {
+		return nil
+	}
+	maxChars := (engine.GameWidth - 2*releaseNotesMargin) / font.ByName["MonoSmall"].Advance("m")
+	s.Pane.Lines = []string{
+		locale.G.Get("AAAAXY %s is available", r.Version),
+		locale.G.Get("Get it from: %s", r.URL),
+		"",
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(r.Notes, "\r", ""), "\n") {
+		s.Pane.Lines = append(s.Pane.Lines, wrapDebugLogLine(line, maxChars)...)
+	}
+	s.Pane.Reset()
+	return nil
+}
+
+func (s *ReleaseNotesScreen) Update() error {
+	_, _, clicked := s.Pane.Update()
+	if input.Exit.JustHit || input.Left.JustHit || input.Right.JustHit || clicked {
+		return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&MainScreen{}))
+	}
+	return nil
+}
+
+func (s *ReleaseNotesScreen) Draw(screen *ebiten.Image) {
+	title := font.Style{
+		FG:      palette.EGA(palette.Yellow, 255),
+		Outline: palette.EGA(palette.Black, 255),
+	}
+	normal := font.Style{
+		FG:      palette.EGA(palette.LightGrey, 255),
+		Outline: palette.EGA(palette.Black, 255),
+	}
+	f := font.ByName["MonoSmall"]
+	s.Pane.Draw(screen, f, f, releaseNotesMargin, font.Left, title, normal)
+}
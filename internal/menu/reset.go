@@ -16,10 +16,11 @@ package menu
 
 import (
 	"fmt"
-	"math/rand"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
+	"github.com/divVerent/aaaaxy/internal/announce"
+	"github.com/divVerent/aaaaxy/internal/effects"
 	"github.com/divVerent/aaaaxy/internal/engine"
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/font"
@@ -27,13 +28,17 @@ import (
 	"github.com/divVerent/aaaaxy/internal/locale"
 	m "github.com/divVerent/aaaaxy/internal/math"
 	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/rng"
 )
 
+var resetRand = rng.Unseeded() // Only used while drawing.
+
 type ResetScreenItem int
 
 const (
 	ResetNothing = iota
 	ResetConfig
+	ResetNewGamePlus
 	ResetGame
 	BackToMain
 	ResetCount
@@ -48,22 +53,21 @@ type ResetScreen struct {
 	WaitForKeyReleaseThenReset bool
 }
 
+func (s *ResetScreen) Focus() int {
+	return int(s.Item)
+}
+
+func (s *ResetScreen) SetFocus(item int) {
+	s.Item = ResetScreenItem(item)
+}
+
 func (s *ResetScreen) Init(m *Controller) error {
 	s.Controller = m
 	return nil
 }
 
 func (s *ResetScreen) Update() error {
-	clicked := s.Controller.QueryMouseItem(&s.Item, ResetCount)
-	if input.Down.JustHit {
-		s.Item++
-		s.Controller.MoveSound(nil)
-	}
-	if input.Up.JustHit {
-		s.Item--
-		s.Controller.MoveSound(nil)
-	}
-	s.Item = ResetScreenItem(m.Mod(int(s.Item), int(ResetCount)))
+	clicked := navigate(s.Controller, &s.Item, 0, int(ResetCount))
 	if s.Item == ResetGame {
 		s.ResetFrame++
 	} else {
@@ -80,6 +84,8 @@ func (s *ResetScreen) Update() error {
 		case ResetConfig:
 			flag.ResetToDefaults()
 			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&SettingsScreen{}))
+		case ResetNewGamePlus:
+			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&NewGamePlusScreen{}))
 		case ResetGame:
 			if s.ResetFrame >= resetFrames {
 				s.WaitForKeyReleaseThenReset = true
@@ -95,22 +101,12 @@ func (s *ResetScreen) Update() error {
 }
 
 func (s *ResetScreen) Draw(screen *ebiten.Image) {
-	fgs := palette.EGA(palette.Yellow, 255)
-	bgs := palette.EGA(palette.Black, 255)
-	fgn := palette.EGA(palette.LightGrey, 255)
-	bgn := palette.EGA(palette.DarkGrey, 255)
-	font.ByName["MenuBig"].Draw(screen, locale.G.Get("Reset"), m.Pos{X: CenterX, Y: HeaderY}, font.Center, fgs, bgs)
-	fg, bg := fgn, bgn
-	if s.Item == ResetNothing {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Reset Nothing"), m.Pos{X: CenterX, Y: ItemBaselineY(ResetNothing, ResetCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == ResetConfig {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Reset and Lose Settings"), m.Pos{X: CenterX, Y: ItemBaselineY(ResetConfig, ResetCount)}, font.Center, fg, bg)
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("Reset"), m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+	drawItem(screen, locale.G.Get("Reset Nothing"), ResetNothing, ResetCount, s.Item == ResetNothing)
+	drawItem(screen, locale.G.Get("Reset and Lose Settings"), ResetConfig, ResetCount, s.Item == ResetConfig)
+	drawItem(screen, locale.G.Get("New Game Plus: %s", s.Controller.newGameModifiers.Describe()), ResetNewGamePlus, ResetCount, s.Item == ResetNewGamePlus)
 	var resetText string
+	style := textStyle()
 	var dx, dy int
 	var save string
 	switch *saveState {
@@ -126,12 +122,11 @@ func (s *ResetScreen) Draw(screen *ebiten.Image) {
 		save = fmt.Sprint(*saveState)
 	}
 	if s.ResetFrame >= resetFrames && s.Item == ResetGame {
-		fg, bg = palette.EGA(palette.Red, 255), palette.EGA(palette.Black, 255)
+		style.FG, style.Outline = palette.EGA(palette.Red, 255), palette.EGA(palette.Black, 255)
 		resetText = locale.G.Get("Reset and Lose SAVE STATE %s", save)
 	} else {
-		fg, bg = fgn, bgn
 		if s.Item == ResetGame {
-			fg, bg = palette.EGA(palette.LightRed, 255), palette.EGA(palette.Red, 255)
+			style.FG, style.Outline = palette.EGA(palette.LightRed, 255), palette.EGA(palette.Red, 255)
 			if s.WaitForKeyReleaseThenReset {
 				resetText = locale.G.Get("Reset and Lose Save State %s (just release buttons)", save)
 			} else {
@@ -140,13 +135,12 @@ func (s *ResetScreen) Draw(screen *ebiten.Image) {
 		} else {
 			resetText = locale.G.Get("Reset and Lose Save State %s", save)
 		}
-		dx = rand.Intn(3) - 1
-		dy = rand.Intn(3) - 1
+		dx = effects.Motion(resetRand.Intn(3) - 1)
+		dy = effects.Motion(resetRand.Intn(3) - 1)
 	}
-	font.ByName["Menu"].Draw(screen, resetText, m.Pos{X: CenterX + dx, Y: ItemBaselineY(ResetGame, ResetCount) + dy}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == BackToMain {
-		fg, bg = fgs, bgs
+	font.ByName["Menu"].DrawStyled(screen, resetText, m.Pos{X: CenterX() + dx, Y: ItemBaselineY(ResetGame, ResetCount) + dy}, font.Center, style)
+	if s.Item == ResetGame {
+		announce.Focus(resetText)
 	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Main Menu"), m.Pos{X: CenterX, Y: ItemBaselineY(BackToMain, ResetCount)}, font.Center, fg, bg)
+	drawItem(screen, locale.G.Get("Main Menu"), BackToMain, ResetCount, s.Item == BackToMain)
 }
@@ -25,8 +25,8 @@ import (
 	"github.com/divVerent/aaaaxy/internal/input"
 	"github.com/divVerent/aaaaxy/internal/level"
 	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/log"
 	m "github.com/divVerent/aaaaxy/internal/math"
-	"github.com/divVerent/aaaaxy/internal/palette"
 	"github.com/divVerent/aaaaxy/internal/playerstate"
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
@@ -38,7 +38,9 @@ const (
 	SaveState4
 	SaveStateX
 	SaveStateY
-	SaveExit
+	SaveDynamic1
+	SaveDynamic2
+	SaveDynamic3
 	SaveStateCount
 )
 
@@ -46,6 +48,12 @@ type SaveStateScreen struct {
 	Controller *Controller
 	Item       SaveStateScreenItem
 	Text       [4]string
+	Count      int
+	Export     SaveStateScreenItem
+	Import     SaveStateScreenItem
+	Exit       SaveStateScreenItem
+	Importing  <-chan vfs.ImportResult // Set while waiting for the user to pick a file.
+	Status     string                  // Outcome of the last export or import.
 }
 
 func (s *SaveStateScreen) saveStateInfo(initLvl *level.Level, idx int) string {
@@ -75,8 +83,28 @@ func (s *SaveStateScreen) saveStateInfo(initLvl *level.Level, idx int) string {
 	return fun.FormatText(ps, format)
 }
 
+func (s *SaveStateScreen) Focus() int {
+	return int(s.Item)
+}
+
+func (s *SaveStateScreen) SetFocus(item int) {
+	s.Item = SaveStateScreenItem(item)
+}
+
 func (s *SaveStateScreen) Init(m *Controller) error {
 	s.Controller = m
+	s.Count = SaveDynamic1
+	if vfs.StateTransferSupported {
+		s.Export = SaveStateScreenItem(s.Count)
+		s.Count++
+		s.Import = SaveStateScreenItem(s.Count)
+		s.Count++
+	} else {
+		s.Export = SaveStateCount
+		s.Import = SaveStateCount
+	}
+	s.Exit = SaveStateScreenItem(s.Count)
+	s.Count++
 
 	initLvl := s.Controller.World.Level.Clone()
 
@@ -94,29 +122,56 @@ func (s *SaveStateScreen) Init(m *Controller) error {
 	case 3:
 		s.Item = SaveStateY
 	default:
-		s.Item = SaveExit
+		s.Item = s.Exit
 		return nil
 	}
 	return nil
 }
 
+func (s *SaveStateScreen) exportSaveGames() error {
+	err := vfs.ExportState(vfs.SavedGames, "aaaaxy-saves.zip")
+	if err != nil {
+		log.Errorf("could not export save games: %v", err)
+		s.Status = locale.G.Get("Export failed.")
+		return nil
+	}
+	s.Status = locale.G.Get("Exported. Extract into the save game folder to use on desktop.")
+	return nil
+}
+
+// importDone handles the result of importing save games.
+func (s *SaveStateScreen) importDone(result vfs.ImportResult) error {
+	s.Importing = nil
+	if result.Err != nil {
+		log.Errorf("could not import save games: %v", result.Err)
+		s.Status = locale.G.Get("Import failed.")
+		return nil
+	}
+	if len(result.Names) == 0 {
+		s.Status = ""
+		return nil
+	}
+	log.Infof("imported save games: %v", result.Names)
+	// Restart from the imported state without saving the current one over it.
+	return s.Controller.InitGame(loadGame)
+}
+
 func (s *SaveStateScreen) Update() error {
-	clicked := s.Controller.QueryMouseItem(&s.Item, SaveStateCount)
+	if s.Importing != nil {
+		select {
+		case result := <-s.Importing:
+			return s.importDone(result)
+		default:
+		}
+	}
+
+	clicked := navigate(s.Controller, &s.Item, 0, s.Count)
 
 	// Update so one can always see which save state is current.
 	if *saveState >= 0 && *saveState < 4 {
 		s.Text[*saveState] = s.saveStateInfo(nil, *saveState)
 	}
 
-	if input.Down.JustHit {
-		s.Item++
-		s.Controller.MoveSound(nil)
-	}
-	if input.Up.JustHit {
-		s.Item--
-		s.Controller.MoveSound(nil)
-	}
-	s.Item = SaveStateScreenItem(m.Mod(int(s.Item), int(SaveStateCount)))
 	if input.Exit.JustHit {
 		return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&SettingsScreen{}))
 	}
@@ -130,7 +185,15 @@ func (s *SaveStateScreen) Update() error {
 			return s.Controller.ActivateSound(s.Controller.SwitchSaveState(2))
 		case SaveStateY:
 			return s.Controller.ActivateSound(s.Controller.SwitchSaveState(3))
-		case SaveExit:
+		case s.Export:
+			return s.Controller.ActivateSound(s.exportSaveGames())
+		case s.Import:
+			if s.Importing == nil {
+				s.Status = locale.G.Get("Choose a file to import...")
+				s.Importing = vfs.ImportState(vfs.SavedGames)
+			}
+			return s.Controller.ActivateSound(nil)
+		case s.Exit:
 			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&SettingsScreen{}))
 		}
 	}
@@ -138,34 +201,17 @@ func (s *SaveStateScreen) Update() error {
 }
 
 func (s *SaveStateScreen) Draw(screen *ebiten.Image) {
-	fgs := palette.EGA(palette.Yellow, 255)
-	bgs := palette.EGA(palette.Black, 255)
-	fgn := palette.EGA(palette.LightGrey, 255)
-	bgn := palette.EGA(palette.DarkGrey, 255)
-	font.ByName["MenuBig"].Draw(screen, locale.G.Get("Switch Save State"), m.Pos{X: CenterX, Y: HeaderY}, font.Center, fgs, bgs)
-	fg, bg := fgn, bgn
-	if s.Item == SaveStateA {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("A: %s", s.Text[0]), m.Pos{X: CenterX, Y: ItemBaselineY(SaveStateA, SaveStateCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == SaveState4 {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("4: %s", s.Text[1]), m.Pos{X: CenterX, Y: ItemBaselineY(SaveState4, SaveStateCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == SaveStateX {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("X: %s", s.Text[2]), m.Pos{X: CenterX, Y: ItemBaselineY(SaveStateX, SaveStateCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == SaveStateY {
-		fg, bg = fgs, bgs
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("Switch Save State"), m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+	drawItem(screen, locale.G.Get("A: %s", s.Text[0]), SaveStateA, s.Count, s.Item == SaveStateA)
+	drawItem(screen, locale.G.Get("4: %s", s.Text[1]), SaveState4, s.Count, s.Item == SaveState4)
+	drawItem(screen, locale.G.Get("X: %s", s.Text[2]), SaveStateX, s.Count, s.Item == SaveStateX)
+	drawItem(screen, locale.G.Get("Y: %s", s.Text[3]), SaveStateY, s.Count, s.Item == SaveStateY)
+	if vfs.StateTransferSupported {
+		drawItem(screen, locale.G.Get("Export Save Games"), int(s.Export), s.Count, s.Item == s.Export)
+		drawItem(screen, locale.G.Get("Import Save Games"), int(s.Import), s.Count, s.Item == s.Import)
 	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Y: %s", s.Text[3]), m.Pos{X: CenterX, Y: ItemBaselineY(SaveStateY, SaveStateCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == SaveExit {
-		fg, bg = fgs, bgs
+	drawItem(screen, locale.G.Get("Main Menu"), int(s.Exit), s.Count, s.Item == s.Exit)
+	if s.Status != "" {
+		font.ByName["MenuSmall"].DrawStyled(screen, s.Status, m.Pos{X: CenterX(), Y: ItemBaselineY(s.Count, s.Count)}, font.Center, textStyle())
 	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Main Menu"), m.Pos{X: CenterX, Y: ItemBaselineY(SaveExit, SaveStateCount)}, font.Center, fg, bg)
 }
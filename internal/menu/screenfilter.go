@@ -0,0 +1,161 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/shader"
+)
+
+// builtinScreenFilters are the screen filters implemented by the game itself, in menu order.
+var builtinScreenFilters = []string{"nearest", "linear", "linear2x", "linear2xcrt"}
+
+// crtPreset is a set of parameters for the linear2xcrt screen filter.
+type crtPreset struct {
+	name        string
+	scanLines   float64
+	crtStrength float64
+	vignette    float64
+	mask        string
+}
+
+// crtPresets are offered in the menu. The first one matches the flag defaults.
+var crtPresets = []crtPreset{
+	{"Subtle", 0.1, 0.5, 0, "none"},
+	{"Flat", 0.1, 0, 0, "none"},
+	{"Curved", 0.2, 0.75, 0.2, "none"},
+	{"Arcade", 0.3, 0.75, 0.3, "aperture"},
+}
+
+func (p *crtPreset) String() string {
+	switch p.name {
+	case "Subtle":
+		return locale.G.Get("Subtle")
+	case "Flat":
+		return locale.G.Get("Flat")
+	case "Curved":
+		return locale.G.Get("Curved")
+	case "Arcade":
+		return locale.G.Get("Arcade")
+	}
+	return p.name
+}
+
+func (p *crtPreset) active() bool {
+	return flag.Get[float64]("screen_filter_scan_lines") == p.scanLines &&
+		flag.Get[float64]("screen_filter_crt_strength") == p.crtStrength &&
+		flag.Get[float64]("screen_filter_crt_k1") < 0 &&
+		flag.Get[float64]("screen_filter_crt_k2") < 0 &&
+		flag.Get[float64]("screen_filter_vignette") == p.vignette &&
+		flag.Get[string]("screen_filter_mask") == p.mask
+}
+
+func (p *crtPreset) apply() {
+	flag.Set("screen_filter_scan_lines", p.scanLines)
+	flag.Set("screen_filter_crt_strength", p.crtStrength)
+	flag.Set("screen_filter_crt_k1", -1.0)
+	flag.Set("screen_filter_crt_k2", -1.0)
+	flag.Set("screen_filter_vignette", p.vignette)
+	flag.Set("screen_filter_mask", p.mask)
+}
+
+// screenFilterChoice is one entry of the screen filter menu item.
+type screenFilterChoice struct {
+	filter string
+	preset *crtPreset // Only for linear2xcrt.
+}
+
+func (c screenFilterChoice) active() bool {
+	if flag.Get[string]("screen_filter") != c.filter {
+		return false
+	}
+	return c.preset == nil || c.preset.active()
+}
+
+type screenFilterSetting struct {
+	choices []screenFilterChoice
+}
+
+func (s *screenFilterSetting) init() {
+	s.choices = nil
+	for _, f := range builtinScreenFilters {
+		if f == "linear2xcrt" {
+			for i := range crtPresets {
+				s.choices = append(s.choices, screenFilterChoice{filter: f, preset: &crtPresets[i]})
+			}
+			continue
+		}
+		s.choices = append(s.choices, screenFilterChoice{filter: f})
+	}
+	for _, f := range shader.UserFilters() {
+		s.choices = append(s.choices, screenFilterChoice{filter: f})
+	}
+}
+
+func (s *screenFilterSetting) name() string {
+	switch f := flag.Get[string]("screen_filter"); f {
+	case "nearest":
+		return locale.G.Get("Sharp")
+	case "linear":
+		return locale.G.Get("Smooth")
+	case "linear2x":
+		return locale.G.Get("Sharp Smooth")
+	case "linear2xcrt":
+		for i := range crtPresets {
+			if crtPresets[i].active() {
+				return locale.G.Get("CRT (%s)", &crtPresets[i])
+			}
+		}
+		return locale.G.Get("CRT (%s)", locale.G.Get("Custom"))
+	default:
+		// User filters are named by their file name.
+		return f
+	}
+}
+
+func (s *screenFilterSetting) toggle(delta int) error {
+	idx := -1
+	for i, c := range s.choices {
+		if c.active() {
+			idx = i
+			break
+		}
+	}
+	switch delta {
+	case 0:
+		idx++
+		if idx >= len(s.choices) {
+			idx = 0
+		}
+	case -1:
+		if idx > 0 {
+			idx--
+		} else if idx < 0 {
+			idx = 0
+		}
+	case +1:
+		idx++
+		if idx >= len(s.choices) {
+			idx = len(s.choices) - 1
+		}
+	}
+	c := s.choices[idx]
+	flag.Set("screen_filter", c.filter)
+	if c.preset != nil {
+		c.preset.apply()
+	}
+	return nil
+}
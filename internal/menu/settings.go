@@ -29,6 +29,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/log"
 	m "github.com/divVerent/aaaaxy/internal/math"
 	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/quality"
 )
 
 var offerFullscreen = flag.SystemDefault(map[string]bool{
@@ -37,62 +38,112 @@ var offerFullscreen = flag.SystemDefault(map[string]bool{
 	"*/*":       true,
 })
 
-var offerStretch = flag.SystemDefault(map[string]bool{
-	"android/*": true,
-	"ios/*":     true,
-	"*/*":       false,
-})
-
 type SettingsScreenItem int
 
-const (
-	Dynamic1 = iota
-	Dynamic2
-	Graphics
-	Quality
-	Volume
-	Language
-	SaveState
-	Reset
-	Back
-	SettingsCount
-)
-
 type SettingsScreen struct {
 	Controller      *Controller
 	Item            SettingsScreenItem
+	Widgets         []Widget
 	CurrentGraphics graphicsSetting
 	CurrentLanguage languageSetting
-	TopItem         SettingsScreenItem
-	EditControls    SettingsScreenItem
-	Fullscreen      SettingsScreenItem
-	Stretch         SettingsScreenItem
+	CurrentFilter   screenFilterSetting
+}
+
+func (s *SettingsScreen) Focus() int {
+	return int(s.Item)
+}
+
+func (s *SettingsScreen) SetFocus(item int) {
+	s.Item = SettingsScreenItem(item)
 }
 
 func (s *SettingsScreen) Init(m *Controller) error {
 	s.Controller = m
 	s.CurrentGraphics = currentGraphics()
 	s.CurrentLanguage.init()
-	s.TopItem = Graphics
+	s.CurrentFilter.init()
+	// Which items exist depends on the platform, so the list is built item by item.
+	s.Widgets = nil
+	if input.HaveTouch() {
+		s.Widgets = append(s.Widgets, &Adjuster{
+			Label: func() string { return locale.G.Get("Edit Touch Controls") },
+			Change: func(delta int) error {
+				return s.Controller.SaveConfigAndSwitchToScreen(&TouchEditScreen{})
+			},
+		})
+	}
+	s.Widgets = append(s.Widgets, &Adjuster{
+		Label: func() string {
+			if flag.Get[bool]("screen_stretch") {
+				return locale.G.Get("Switch to Letterboxed Screen")
+			} else if flag.Get[bool]("screen_integer_scaling") {
+				return locale.G.Get("Switch to Stretched Screen")
+			}
+			return locale.G.Get("Switch to Integer Scaled Screen")
+		},
+		Change: func(delta int) error { return s.Controller.toggleScreenScaling() },
+	})
 	if offerFullscreen {
-		s.TopItem--
-		s.Fullscreen = s.TopItem
-		s.Stretch = SettingsCount
-	} else if offerStretch {
-		s.TopItem--
-		s.Fullscreen = SettingsCount
-		s.Stretch = s.TopItem
-	} else {
-		s.Fullscreen = SettingsCount
-		s.Stretch = SettingsCount
+		s.Widgets = append(s.Widgets, &Adjuster{
+			Label: func() string {
+				if ebiten.IsFullscreen() {
+					return locale.G.Get("Switch to Windowed Mode")
+				}
+				return locale.G.Get("Switch to Fullscreen Mode")
+			},
+			Change: func(delta int) error { return s.Controller.toggleFullscreen() },
+		})
 	}
-	if input.HaveTouch() {
-		s.TopItem--
-		s.EditControls = s.TopItem
-	} else {
-		s.EditControls = SettingsCount
+	if len(s.Widgets) < 3 {
+		// The controls screen has the input display and accessibility settings.
+		// It only fits if not all of the above exist.
+		s.Widgets = append([]Widget{&Adjuster{
+			Label: func() string { return locale.G.Get("Controls") },
+			Change: func(delta int) error {
+				return s.Controller.SaveConfigAndSwitchToScreen(&ControlsScreen{})
+			},
+		}}, s.Widgets...)
 	}
-	s.Item = s.TopItem
+	s.Widgets = append(s.Widgets,
+		&Adjuster{
+			Label:  func() string { return locale.G.Get("Graphics: %s", currentGraphics()) },
+			Change: s.toggleGraphics,
+		},
+		&Adjuster{
+			Label:  func() string { return locale.G.Get("Quality: %s", quality.Current()) },
+			Change: toggleQuality,
+		},
+		&Adjuster{
+			Label:  func() string { return locale.G.Get("Screen Filter: %s", s.CurrentFilter.name()) },
+			Change: s.CurrentFilter.toggle,
+		},
+		&Adjuster{
+			Label:  func() string { return locale.G.Get("Volume: %s", currentVolume()) },
+			Change: toggleVolume,
+		},
+		&Adjuster{
+			Label: func() string { return locale.G.Get("Language: %s", s.CurrentLanguage.name()) },
+			Change: func(delta int) error {
+				return s.CurrentLanguage.toggle(s.Controller, delta)
+			},
+		},
+		&Button{
+			Label: func() string { return locale.G.Get("Switch Save State") },
+			Action: func() error {
+				return s.Controller.SaveConfigAndSwitchToScreen(&SaveStateScreen{})
+			},
+		},
+		&Button{
+			Label: func() string { return locale.G.Get("Reset") },
+			Action: func() error {
+				return s.Controller.SaveConfigAndSwitchToScreen(&ResetScreen{})
+			},
+		},
+		&Button{
+			Label:  func() string { return locale.G.Get("Main Menu") },
+			Action: s.exit,
+		},
+	)
 	return nil
 }
 
@@ -266,11 +317,11 @@ func (s *SettingsScreen) toggleGraphics(delta int) error {
 }
 
 func toggleQuality(delta int) error {
-	g := currentQuality()
+	g := quality.Current()
 	switch delta {
 	case 0:
 		g++
-		if g >= qualitySettingCount {
+		if g >= quality.SettingCount {
 			g = 0
 		}
 	case -1:
@@ -279,11 +330,11 @@ func toggleQuality(delta int) error {
 		}
 	case +1:
 		g++
-		if g >= qualitySettingCount {
+		if g >= quality.SettingCount {
 			g--
 		}
 	}
-	g.apply()
+	g.Apply()
 	return nil
 }
 
@@ -315,156 +366,20 @@ func toggleVolume(delta int) error {
 	return nil
 }
 
-func (s *SettingsScreen) Update() error {
-	saveItem := s.Item
-	clicked := s.Controller.QueryMouseItem(&s.Item, SettingsCount)
-	if s.Item < s.TopItem {
-		clicked = NotClicked
-		s.Item = saveItem
-	}
-	if input.Down.JustHit {
-		s.Item++
-		s.Controller.MoveSound(nil)
-	}
-	if input.Up.JustHit {
-		s.Item--
-		s.Controller.MoveSound(nil)
-	}
-	s.Item = SettingsScreenItem(m.Mod(int(s.Item-s.TopItem), int(SettingsCount-s.TopItem))) + s.TopItem
-	if input.Exit.JustHit {
-		return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&MainScreen{}))
-	}
-	if input.Jump.JustHit || input.Action.JustHit || clicked == CenterClicked {
-		switch s.Item {
-		case s.Fullscreen:
-			return s.Controller.ActivateSound(s.Controller.toggleFullscreen())
-		case s.Stretch:
-			return s.Controller.ActivateSound(s.Controller.toggleStretch())
-		case s.EditControls:
-			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&TouchEditScreen{}))
-		case Graphics:
-			return s.Controller.ActivateSound(s.toggleGraphics(0))
-		case Quality:
-			return s.Controller.ActivateSound(toggleQuality(0))
-		case Volume:
-			return s.Controller.ActivateSound(toggleVolume(0))
-		case Language:
-			return s.Controller.ActivateSound(s.CurrentLanguage.toggle(s.Controller, 0))
-		case SaveState:
-			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&SaveStateScreen{}))
-		case Reset:
-			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&ResetScreen{}))
-		case Back:
-			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&MainScreen{}))
-		}
-	}
-	if input.Left.JustHit || clicked == LeftClicked {
-		switch s.Item {
-		case s.Fullscreen:
-			return s.Controller.ActivateSound(s.Controller.toggleFullscreen())
-		case s.Stretch:
-			return s.Controller.ActivateSound(s.Controller.toggleStretch())
-		case s.EditControls:
-			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&TouchEditScreen{}))
-		case Graphics:
-			return s.Controller.ActivateSound(s.toggleGraphics(-1))
-		case Quality:
-			return s.Controller.ActivateSound(toggleQuality(-1))
-		case Volume:
-			return s.Controller.ActivateSound(toggleVolume(-1))
-		case Language:
-			return s.Controller.ActivateSound(s.CurrentLanguage.toggle(s.Controller, -1))
-		}
-	}
-	if input.Right.JustHit || clicked == RightClicked {
-		switch s.Item {
-		case s.Fullscreen:
-			return s.Controller.ActivateSound(s.Controller.toggleFullscreen())
-		case s.Stretch:
-			return s.Controller.ActivateSound(s.Controller.toggleStretch())
-		case s.EditControls:
-			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&TouchEditScreen{}))
-		case Graphics:
-			return s.Controller.ActivateSound(s.toggleGraphics(+1))
-		case Quality:
-			return s.Controller.ActivateSound(toggleQuality(+1))
-		case Volume:
-			return s.Controller.ActivateSound(toggleVolume(+1))
-		case Language:
-			return s.Controller.ActivateSound(s.CurrentLanguage.toggle(s.Controller, +1))
-		}
-	}
+func toggleInputDisplay() error {
+	flag.Set("show_input", !flag.Get[bool]("show_input"))
 	return nil
 }
 
+func (s *SettingsScreen) exit() error {
+	return s.Controller.SaveConfigAndSwitchToScreen(&MainScreen{})
+}
+
+func (s *SettingsScreen) Update() error {
+	return updateList(s.Controller, &s.Item, s.Widgets, s.exit)
+}
+
 func (s *SettingsScreen) Draw(screen *ebiten.Image) {
-	fgs := palette.EGA(palette.Yellow, 255)
-	bgs := palette.EGA(palette.Black, 255)
-	fgn := palette.EGA(palette.LightGrey, 255)
-	bgn := palette.EGA(palette.DarkGrey, 255)
-	font.ByName["MenuBig"].Draw(screen, locale.G.Get("Settings"), m.Pos{X: CenterX, Y: HeaderY}, font.Center, fgs, bgs)
-	if s.EditControls != SettingsCount {
-		fg, bg := fgn, bgn
-		if s.Item == s.EditControls {
-			fg, bg = fgs, bgs
-		}
-		font.ByName["Menu"].Draw(screen, locale.G.Get("Edit Touch Controls"), m.Pos{X: CenterX, Y: ItemBaselineY(int(s.EditControls), SettingsCount)}, font.Center, fg, bg)
-	}
-	if s.Fullscreen != SettingsCount {
-		fg, bg := fgn, bgn
-		if s.Item == s.Fullscreen {
-			fg, bg = fgs, bgs
-		}
-		fsText := locale.G.Get("Switch to Fullscreen Mode")
-		if ebiten.IsFullscreen() {
-			fsText = locale.G.Get("Switch to Windowed Mode")
-		}
-		font.ByName["Menu"].Draw(screen, fsText, m.Pos{X: CenterX, Y: ItemBaselineY(int(s.Fullscreen), SettingsCount)}, font.Center, fg, bg)
-	}
-	if s.Stretch != SettingsCount {
-		fg, bg := fgn, bgn
-		if s.Item == s.Stretch {
-			fg, bg = fgs, bgs
-		}
-		fsText := locale.G.Get("Switch to Stretched Screen")
-		if flag.Get[bool]("screen_stretch") {
-			fsText = locale.G.Get("Switch to Letterboxed Screen")
-		}
-		font.ByName["Menu"].Draw(screen, fsText, m.Pos{X: CenterX, Y: ItemBaselineY(int(s.Stretch), SettingsCount)}, font.Center, fg, bg)
-	}
-	fg, bg := fgn, bgn
-	if s.Item == Graphics {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Graphics: %s", currentGraphics()), m.Pos{X: CenterX, Y: ItemBaselineY(Graphics, SettingsCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == Quality {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Quality: %s", currentQuality()), m.Pos{X: CenterX, Y: ItemBaselineY(Quality, SettingsCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == Volume {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Volume: %s", currentVolume()), m.Pos{X: CenterX, Y: ItemBaselineY(Volume, SettingsCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == Language {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Language: %s", s.CurrentLanguage.name()), m.Pos{X: CenterX, Y: ItemBaselineY(Language, SettingsCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == SaveState {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Switch Save State"), m.Pos{X: CenterX, Y: ItemBaselineY(SaveState, SettingsCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == Reset {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Reset"), m.Pos{X: CenterX, Y: ItemBaselineY(Reset, SettingsCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == Back {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Main Menu"), m.Pos{X: CenterX, Y: ItemBaselineY(Back, SettingsCount)}, font.Center, fg, bg)
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("Settings"), m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+	drawList(screen, s.Item, s.Widgets)
 }
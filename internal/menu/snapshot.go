@@ -0,0 +1,73 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/divVerent/aaaaxy/internal/centerprint"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+var (
+	cheatSnapshots = flag.Bool("cheat_snapshots", false, "enable in-memory world snapshots for practicing; F1 selects the slot, F2 saves and F3 restores")
+)
+
+const (
+	snapshotSlots = 4
+)
+
+func snapshotMessage(msg string) {
+	centerprint.New(msg, centerprint.NotImportant, centerprint.Top, centerprint.NormalFont(), palette.EGA(palette.White, 255), time.Second).SetFadeOut(true)
+}
+
+// updateSnapshots handles the snapshot hotkeys.
+func (c *Controller) updateSnapshots() error {
+	if !*cheatSnapshots {
+		return nil
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF1) {
+		c.snapshotSlot = (c.snapshotSlot + 1) % snapshotSlots
+		snapshotMessage(locale.G.Get("Snapshot slot %d", c.snapshotSlot+1))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF2) {
+		s, err := c.World.SaveSnapshot()
+		if err != nil {
+			log.Errorf("could not save snapshot: %v", err)
+			return nil
+		}
+		c.snapshots[c.snapshotSlot] = s
+		snapshotMessage(locale.G.Get("Saved snapshot %d", c.snapshotSlot+1))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		s := c.snapshots[c.snapshotSlot]
+		if s == nil {
+			snapshotMessage(locale.G.Get("Snapshot %d is empty", c.snapshotSlot+1))
+			return nil
+		}
+		err := c.World.LoadSnapshot(s)
+		if err != nil {
+			return err
+		}
+		snapshotMessage(locale.G.Get("Loaded snapshot %d", c.snapshotSlot+1))
+	}
+	return nil
+}
@@ -15,8 +15,6 @@
 package menu
 
 import (
-	"image/color"
-
 	"github.com/hajimehoshi/ebiten/v2"
 
 	"github.com/divVerent/aaaaxy/internal/engine"
@@ -62,7 +60,7 @@ func textScreenEndPos(text []string, lineHeight int) int {
 	return -lineHeight*len(text) + engine.GameHeight
 }
 
-func renderTextScreen(dst *ebiten.Image, titleFont, normalFont *font.Face, text []string, pos m.Pos, align font.Align, lineHeight int, titleFG, titleBG, normalFG, normalBG color.Color) {
+func renderTextScreen(dst *ebiten.Image, titleFont, normalFont *font.Face, text []string, pos m.Pos, align font.Align, lineHeight int, titleStyle, normalStyle font.Style) {
 	x := pos.X
 	nextIsTitle := true
 	for i, line := range text {
@@ -77,9 +75,9 @@ func renderTextScreen(dst *ebiten.Image, titleFont, normalFont *font.Face, text
 			continue
 		}
 		if isTitle {
-			titleFont.Draw(dst, line, m.Pos{X: x, Y: y}, align, titleFG, titleBG)
+			titleFont.DrawStyled(dst, line, m.Pos{X: x, Y: y}, align, titleStyle)
 		} else {
-			normalFont.Draw(dst, line, m.Pos{X: x, Y: y}, align, normalFG, normalBG)
+			normalFont.DrawStyled(dst, line, m.Pos{X: x, Y: y}, align, normalStyle)
 		}
 	}
 }
@@ -0,0 +1,87 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/updatecheck"
+	"github.com/divVerent/aaaaxy/internal/version"
+)
+
+var (
+	titleBackground = flag.Bool("title_background", true, "show a slowly moving view of the world behind the main menu instead of the blurred game")
+)
+
+// titleMargin is the distance of the version and update notice from the screen edges.
+const titleMargin = 8
+
+// updateTitleBackground creates, advances and releases the title background as needed.
+func (c *Controller) updateTitleBackground() error {
+	_, onTitle := c.Screen.(*MainScreen)
+	if !onTitle || !*titleBackground || c.levelLoading() {
+		if c.titleBackground != nil {
+			c.titleBackground.Close()
+			c.titleBackground = nil
+		}
+		return nil
+	}
+	if c.titleBackground == nil {
+		bg, err := engine.NewTitleBackground(&c.World)
+		if err != nil {
+			log.Errorf("could not create title background, falling back to the blurred game: %v", err)
+			// Do not retry every frame.
+			*titleBackground = false
+			return nil
+		}
+		c.titleBackground = bg
+	}
+	return c.titleBackground.Update()
+}
+
+// updateUpdateNotice shows a notice once a newer release has been found.
+func (c *Controller) updateUpdateNotice() {
+	if c.updateNotice != "" {
+		return
+	}
+	if r, ok := updatecheck.Available(); ok {
+		c.updateNotice = locale.G.Get("Update available: %s", r.Version)
+	}
+}
+
+// drawTitleBackground draws the title background if there is one.
+func (c *Controller) drawTitleBackground(screen *ebiten.Image) bool {
+	if c.titleBackground == nil {
+		return false
+	}
+	c.titleBackground.Draw(screen)
+	return true
+}
+
+// drawTitleFooter draws the game version and the update notice, if any.
+func (c *Controller) drawTitleFooter(screen *ebiten.Image) {
+	f := font.ByName["MenuSmall"]
+	y := ItemBaselineY(1, 1)
+	f.DrawStyled(screen, locale.G.Get("Build: %s", version.Revision()), m.Pos{X: titleMargin, Y: y}, font.Left, textStyle())
+	if c.updateNotice != "" {
+		f.DrawStyled(screen, c.updateNotice, m.Pos{X: engine.GameWidth - titleMargin, Y: y}, font.Right, selectedStyle())
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"github.com/divVerent/aaaaxy/internal/input"
 	"github.com/divVerent/aaaaxy/internal/locale"
 	m "github.com/divVerent/aaaaxy/internal/math"
-	"github.com/divVerent/aaaaxy/internal/palette"
 )
 
 type TouchEditScreenItem int
@@ -37,6 +36,14 @@ type TouchEditScreen struct {
 	Item       TouchEditScreenItem
 }
 
+func (s *TouchEditScreen) Focus() int {
+	return int(s.Item)
+}
+
+func (s *TouchEditScreen) SetFocus(item int) {
+	s.Item = TouchEditScreenItem(item)
+}
+
 func (s *TouchEditScreen) Init(m *Controller) error {
 	s.Controller = m
 	return nil
@@ -48,16 +55,7 @@ func touchReset() error {
 }
 
 func (s *TouchEditScreen) Update() error {
-	clicked := s.Controller.QueryMouseItem(&s.Item, TouchCount)
-	if input.Down.JustHit {
-		s.Item++
-		s.Controller.MoveSound(nil)
-	}
-	if input.Up.JustHit {
-		s.Item--
-		s.Controller.MoveSound(nil)
-	}
-	s.Item = TouchEditScreenItem(m.Mod(int(s.Item), int(TouchCount)))
+	clicked := navigate(s.Controller, &s.Item, 0, int(TouchCount))
 	if input.Exit.JustHit {
 		return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&SettingsScreen{}))
 	}
@@ -74,19 +72,7 @@ func (s *TouchEditScreen) Update() error {
 
 func (s *TouchEditScreen) Draw(screen *ebiten.Image) {
 	input.DrawEditor(screen)
-	fgs := palette.EGA(palette.Yellow, 255)
-	bgs := palette.EGA(palette.Black, 255)
-	fgn := palette.EGA(palette.LightGrey, 255)
-	bgn := palette.EGA(palette.DarkGrey, 255)
-	font.ByName["MenuBig"].Draw(screen, locale.G.Get("Edit Touch Controls"), m.Pos{X: CenterX, Y: HeaderY}, font.Center, fgs, bgs)
-	fg, bg := fgn, bgn
-	if s.Item == TouchDone {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Done"), m.Pos{X: CenterX, Y: ItemBaselineY(TouchDone, TouchCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == TouchReset {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Reset to Defaults"), m.Pos{X: CenterX, Y: ItemBaselineY(TouchReset, TouchCount)}, font.Center, fg, bg)
+	font.ByName["MenuBig"].DrawStyled(screen, locale.G.Get("Edit Touch Controls"), m.Pos{X: CenterX(), Y: HeaderY()}, font.Center, headerStyle())
+	drawItem(screen, locale.G.Get("Done"), TouchDone, TouchCount, s.Item == TouchDone)
+	drawItem(screen, locale.G.Get("Reset to Defaults"), TouchReset, TouchCount, s.Item == TouchReset)
 }
@@ -0,0 +1,375 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/announce"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// Widget is an item of a menu list.
+type Widget interface {
+	// Text returns the text to show for the widget.
+	Text() string
+
+	// Activate handles the widget being chosen.
+	// delta is -1 or +1 when moving left or right, and 0 when activating.
+	Activate(c *Controller, delta int) error
+}
+
+// drawer is implemented by widgets that draw themselves instead of showing their text as a menu item.
+type drawer interface {
+	Widget
+	draw(screen *ebiten.Image, i, n int, selected bool)
+}
+
+// capturer is implemented by widgets that take over all input while active.
+type capturer interface {
+	Widget
+	capturing() bool
+	capture(c *Controller) error
+}
+
+// updateList handles navigation and activation of a list of widgets.
+// item points to the index of the selected widget; it is kept by the screen,
+// so other screens can switch to it with a given item selected.
+// exit is called when leaving the screen.
+func updateList[T ~int](c *Controller, item *T, widgets []Widget, exit func() error) error {
+	n := len(widgets)
+	if w, ok := widgets[m.Mod(int(*item), n)].(capturer); ok && w.capturing() {
+		return w.capture(c)
+	}
+	clicked := navigate(c, item, 0, n)
+	if r, ok := widgets[*item].(*Row); ok {
+		r.hover(c, int(*item), n)
+		if clicked != NotClicked {
+			// Clicking a row activates the widget under the mouse.
+			clicked = CenterClicked
+		}
+	}
+	if input.Exit.JustHit {
+		return c.ActivateSound(exit())
+	}
+	delta := 0
+	switch {
+	case input.Jump.JustHit || input.Action.JustHit || clicked == CenterClicked:
+		delta = 0
+	case input.Left.JustHit || clicked == LeftClicked:
+		delta = -1
+	case input.Right.JustHit || clicked == RightClicked:
+		delta = +1
+	default:
+		return nil
+	}
+	return widgets[*item].Activate(c, delta)
+}
+
+// drawList draws a list of widgets with the given one selected.
+func drawList[T ~int](screen *ebiten.Image, item T, widgets []Widget) {
+	for i, w := range widgets {
+		if d, ok := w.(drawer); ok {
+			d.draw(screen, i, len(widgets), i == int(item))
+			continue
+		}
+		drawItem(screen, w.Text(), i, len(widgets), i == int(item))
+	}
+}
+
+// Button is a widget that performs an action when activated.
+type Button struct {
+	Label  func() string
+	Action func() error
+}
+
+func (b *Button) Text() string {
+	return b.Label()
+}
+
+func (b *Button) Activate(c *Controller, delta int) error {
+	if delta != 0 {
+		return nil
+	}
+	return c.ActivateSound(b.Action())
+}
+
+// Toggle is a widget that switches a setting on and off.
+type Toggle struct {
+	Label func(on bool) string
+	Get   func() bool
+	Set   func(on bool) error
+}
+
+func (t *Toggle) Text() string {
+	return t.Label(t.Get())
+}
+
+func (t *Toggle) Activate(c *Controller, delta int) error {
+	return c.ActivateSound(t.Set(!t.Get()))
+}
+
+// Choice is a widget that cycles through options.
+// Change is called with the direction to cycle in; activating counts as +1.
+type Choice struct {
+	Label  func() string
+	Change func(delta int) error
+}
+
+func (ch *Choice) Text() string {
+	return ch.Label()
+}
+
+func (ch *Choice) Activate(c *Controller, delta int) error {
+	if delta == 0 {
+		delta = +1
+	}
+	return c.ActivateSound(ch.Change(delta))
+}
+
+// Adjuster is a widget that adjusts a setting in its own way.
+// Change is called with the direction to adjust in, or 0 when activating.
+type Adjuster struct {
+	Label  func() string
+	Change func(delta int) error
+}
+
+func (a *Adjuster) Text() string {
+	return a.Label()
+}
+
+func (a *Adjuster) Activate(c *Controller, delta int) error {
+	return c.ActivateSound(a.Change(delta))
+}
+
+// Slider is a widget that selects a value from a range in steps.
+// Left and right stop at the ends of the range; activating wraps around.
+type Slider struct {
+	Label    func(value int) string
+	Get      func() int
+	Set      func(value int) error
+	Min, Max int
+	Step     int
+
+	// ActivateStep is the step to take when activating. Zero means Step.
+	ActivateStep int
+}
+
+func (s *Slider) Text() string {
+	return s.Label(s.Get())
+}
+
+func (s *Slider) Activate(c *Controller, delta int) error {
+	value := s.Get()
+	switch delta {
+	case 0:
+		step := s.ActivateStep
+		if step == 0 {
+			step = s.Step
+		}
+		value += step
+		if value > s.Max {
+			value = s.Min
+		} else if value < s.Min {
+			value = s.Max
+		}
+	case -1:
+		value = max(value-s.Step, s.Min)
+	case +1:
+		value = min(value+s.Step, s.Max)
+	}
+	return c.ActivateSound(s.Set(value))
+}
+
+// KeyCapture is a widget that assigns a key by waiting for the next key press.
+// While waiting, the exit key cancels.
+type KeyCapture struct {
+	Label func(key string) string
+	Get   func() ebiten.Key
+	Set   func(key ebiten.Key) error
+
+	active bool
+}
+
+func (k *KeyCapture) Text() string {
+	if k.active {
+		return k.Label(locale.G.Get("press a key"))
+	}
+	return k.Label(k.Get().String())
+}
+
+func (k *KeyCapture) Activate(c *Controller, delta int) error {
+	if delta != 0 {
+		return nil
+	}
+	// The key that activated us has been pressed this frame, so it is not captured.
+	k.active = true
+	return c.ActivateSound(nil)
+}
+
+func (k *KeyCapture) capturing() bool {
+	return k.active
+}
+
+func (k *KeyCapture) capture(c *Controller) error {
+	if input.Exit.JustHit {
+		k.active = false
+		return c.MoveSound(nil)
+	}
+	key, ok := input.JustPressedKey()
+	if !ok {
+		return nil
+	}
+	k.active = false
+	return c.ActivateSound(k.Set(key))
+}
+
+// Row is a horizontal group of widgets sharing one line.
+// Left and right move the focus within the row; activating activates the focused widget.
+type Row struct {
+	Widgets []Widget
+	Focus   int
+
+	// hoverColumn is the widget the mouse was last over, if hovering.
+	hoverColumn int
+	hovering    bool
+}
+
+func (r *Row) Text() string {
+	return r.Widgets[r.Focus].Text()
+}
+
+func (r *Row) Activate(c *Controller, delta int) error {
+	if delta == 0 {
+		return r.Widgets[r.Focus].Activate(c, 0)
+	}
+	var next int
+	if *menuWrap {
+		next = m.Mod(r.Focus+delta, len(r.Widgets))
+	} else {
+		next = max(0, min(r.Focus+delta, len(r.Widgets)-1))
+	}
+	if next == r.Focus {
+		return nil
+	}
+	r.Focus = next
+	return c.MoveSound(nil)
+}
+
+// rowColumnX returns the center of column j of k, within the clickable area of menu items.
+func rowColumnX(j, k int) int {
+	return layoutX(engine.GameWidth/8 + (2*j+1)*3*engine.GameWidth/(8*k))
+}
+
+// hover focuses the widget the mouse enters while the row is menu item i of n.
+func (r *Row) hover(c *Controller, i, n int) {
+	pos, status := input.Mouse()
+	if status == input.NoMouse {
+		r.hovering = false
+		return
+	}
+	if idx, dir := ItemClicked(pos, n); dir == NotClicked || idx != i {
+		r.hovering = false
+		return
+	}
+	k := len(r.Widgets)
+	j := max(0, min((layoutX(pos.X)-engine.GameWidth/8)*k*4/(3*engine.GameWidth), k-1))
+	if r.hovering && j == r.hoverColumn && status != input.ClickingMouse {
+		return
+	}
+	r.hoverColumn, r.hovering = j, true
+	if j != r.Focus {
+		r.Focus = j
+		c.MoveSound(nil)
+	}
+}
+
+func (r *Row) draw(screen *ebiten.Image, i, n int, selected bool) {
+	for j, w := range r.Widgets {
+		text := w.Text()
+		style := textStyle()
+		if selected && j == r.Focus {
+			style = selectedStyle()
+			announce.Focus(text)
+		}
+		font.ByName["Menu"].DrawStyled(screen, text, m.Pos{X: rowColumnX(j, len(r.Widgets)), Y: ItemBaselineY(i, n)}, font.Center, style)
+	}
+}
+
+// ScrollPane is a scrollable text screen.
+// Lines following an empty line, and the first line, are titles.
+type ScrollPane struct {
+	Lines      []string
+	LineHeight int
+	Step       int // Pixels per frame to scroll while scrolling manually.
+	Pos        int // Current scroll position.
+}
+
+// Reset scrolls to the start of the text.
+func (p *ScrollPane) Reset() {
+	p.Pos = textScreenStartPos(p.Lines, p.LineHeight)
+}
+
+// ResetToEnd scrolls to the end of the text, or the start if it fits on screen.
+func (p *ScrollPane) ResetToEnd() {
+	p.Pos = min(textScreenEndPos(p.Lines, p.LineHeight), textScreenStartPos(p.Lines, p.LineHeight))
+}
+
+// AtEnd returns whether the end of the text is shown.
+func (p *ScrollPane) AtEnd() bool {
+	return textScreenAdjustScrollDown(p.Lines, p.Pos, 1, p.LineHeight) == p.Pos
+}
+
+// ScrollDown scrolls down by the given amount of pixels.
+func (p *ScrollPane) ScrollDown(d int) {
+	p.Pos = textScreenAdjustScrollDown(p.Lines, p.Pos, d, p.LineHeight)
+}
+
+// ScrollUp scrolls up by the given amount of pixels.
+func (p *ScrollPane) ScrollUp(d int) {
+	p.Pos = textScreenAdjustScrollUp(p.Lines, p.Pos, d, p.LineHeight)
+}
+
+// Update handles scrolling by keys, and by the mouse in the top and bottom thirds of the screen.
+// It returns whether the user scrolled, and the position of a mouse click in the middle third, if any.
+func (p *ScrollPane) Update() (scrolled bool, click m.Pos, clicked bool) {
+	up := input.Up.Held
+	down := input.Down.Held
+	if pos, status := input.Mouse(); status != input.NoMouse {
+		if pos.Y < engine.GameHeight/3 {
+			up = true
+		} else if pos.Y > 2*engine.GameHeight/3 {
+			down = true
+		} else if status == input.ClickingMouse {
+			click, clicked = pos, true
+		}
+	}
+	if up {
+		p.ScrollUp(p.Step)
+	}
+	if down {
+		p.ScrollDown(p.Step)
+	}
+	return up || down, click, clicked
+}
+
+// Draw draws the visible part of the text at horizontal position x.
+func (p *ScrollPane) Draw(dst *ebiten.Image, titleFont, normalFont *font.Face, x int, align font.Align, titleStyle, normalStyle font.Style) {
+	renderTextScreen(dst, titleFont, normalFont, p.Lines, m.Pos{X: x, Y: p.Pos}, align, p.LineHeight, titleStyle, normalStyle)
+}
@@ -20,6 +20,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2/audio"
@@ -28,6 +31,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/audiowrap"
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/mute"
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
 
@@ -42,6 +46,8 @@ const (
 )
 
 type musicJson struct {
+	Title      string  `json:"title"`
+	Artist     string  `json:"artist"`
 	PlayStart  int64   `json:"play_start"`
 	ReplayGain float64 `json:"replay_gain"`
 	LoopStart  int64   `json:"loop_start"`
@@ -109,10 +115,15 @@ func Now() time.Duration {
 	return 0
 }
 
+// Current returns the name of the currently playing music track.
+func Current() string {
+	return currentName
+}
+
 // Switch switches from the currently playing music to the given track.
 // Passing an empty string means fading to silence.
 func Switch(name string) {
-	if name == currentName {
+	if name == currentName || mute.Active() {
 		return
 	}
 
@@ -146,6 +157,38 @@ func Switch(name string) {
 	}
 
 	// Now load the new track.
+	var config musicJson
+	player, config = newPlayer(name)
+	if player == nil {
+		return
+	}
+
+	// We have a valid player.
+	player.SetVolume(*musicVolume * config.ReplayGain)
+	if active {
+		player.Play()
+	}
+}
+
+// Reload restarts the current music and preview, e.g. after switching audio packs.
+func Reload() {
+	// Never restore music loaded from the old assets.
+	prevName, prevMusic = "", nil
+	if player != nil {
+		name := currentName
+		player.FadeOutIn(*musicFadeTime)
+		player, currentName = nil, ""
+		Switch(name)
+	}
+	if previewPlayer != nil {
+		name := previewName
+		StopPreview()
+		Preview(name)
+	}
+}
+
+// loadConfig loads the json metadata file of a track.
+func loadConfig(name string) (musicJson, error) {
 	config := musicJson{
 		PlayStart:  0,
 		LoopStart:  0,
@@ -154,18 +197,27 @@ func Switch(name string) {
 	}
 	j, err := vfs.Load("music", name+".json")
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		log.Errorf("could not load music json config file for %q: %v", name, err)
-		return
+		return config, fmt.Errorf("could not load music json config file for %q: %w", name, err)
 	}
 	if j != nil {
 		defer j.Close()
 		err = json.NewDecoder(j).Decode(&config)
 		if err != nil {
-			log.Errorf("could not decode music json config file for %q: %v", name, err)
-			return
+			return config, fmt.Errorf("could not decode music json config file for %q: %w", name, err)
 		}
 	}
-	player, err = audiowrap.NewPlayer(func() (io.ReadCloser, error) {
+	return config, nil
+}
+
+// newPlayer creates a looping player for the given track.
+// Returns nil on error.
+func newPlayer(name string) (*audiowrap.Player, musicJson) {
+	config, err := loadConfig(name)
+	if err != nil {
+		log.Errorf("%v", err)
+		return nil, config
+	}
+	p, err := audiowrap.NewPlayer(func() (io.ReadCloser, error) {
 		handle, err := vfs.Load("music", name)
 		if err != nil {
 			return nil, fmt.Errorf("could not load music %q: %w", name, err)
@@ -182,12 +234,85 @@ func Switch(name string) {
 	})
 	if err != nil {
 		log.Errorf("could not start playing music %q: %v", name, err)
+		return nil, config
+	}
+	return p, config
+}
+
+// Track describes a music track for display.
+type Track struct {
+	Name   string // File name, as used by Switch.
+	Title  string // Display title; the file name if not set in the metadata.
+	Artist string // May be empty.
+}
+
+// Tracks lists all available music tracks, sorted by file name.
+func Tracks() ([]Track, error) {
+	paths, err := vfs.ReadDir("music")
+	if err != nil {
+		return nil, fmt.Errorf("could not list music: %w", err)
+	}
+	var tracks []Track
+	for _, p := range paths {
+		name := path.Base(p)
+		if !strings.HasSuffix(name, ".ogg") {
+			continue
+		}
+		config, err := loadConfig(name)
+		if err != nil {
+			log.Errorf("%v", err)
+		}
+		title := config.Title
+		if title == "" {
+			title = strings.TrimSuffix(name, ".ogg")
+		}
+		tracks = append(tracks, Track{
+			Name:   name,
+			Title:  title,
+			Artist: config.Artist,
+		})
+	}
+	sort.Slice(tracks, func(i, j int) bool {
+		return tracks[i].Name < tracks[j].Name
+	})
+	return tracks, nil
+}
+
+var (
+	previewName   string
+	previewPlayer *audiowrap.Player
+)
+
+// Preview plays the given track for the jukebox.
+// This uses a separate player, so the game music state is not touched;
+// call Switch("") before, and StopPreview after.
+// Passing an empty string stops the preview.
+func Preview(name string) {
+	if name == previewName {
+		return
+	}
+	StopPreview()
+	if name == "" {
 		return
 	}
+	p, config := newPlayer(name)
+	if p == nil {
+		return
+	}
+	previewName, previewPlayer = name, p
+	previewPlayer.SetVolume(*musicVolume * config.ReplayGain)
+	previewPlayer.Play()
+}
 
-	// We have a valid player.
-	player.SetVolume(*musicVolume * config.ReplayGain)
-	if active {
-		player.Play()
+// StopPreview stops the track started by Preview.
+func StopPreview() {
+	if previewPlayer != nil {
+		previewPlayer.FadeOutIn(*musicFadeTime)
 	}
+	previewName, previewPlayer = "", nil
+}
+
+// Previewing returns the name of the track being previewed, or an empty string.
+func Previewing() string {
+	return previewName
 }
@@ -0,0 +1,34 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mute suppresses side effects that leave the game, such as sounds and speech.
+// It is used while simulating a world that is never shown to the player.
+package mute
+
+// depth counts the nested Do calls.
+var depth int
+
+// Do runs f with all audible and externally visible side effects suppressed.
+func Do(f func()) {
+	depth++
+	defer func() {
+		depth--
+	}()
+	f()
+}
+
+// Active returns whether side effects are currently suppressed.
+func Active() bool {
+	return depth > 0
+}
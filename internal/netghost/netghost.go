@@ -0,0 +1,226 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package netghost streams the player position to other players racing in the same room,
+// and receives theirs so they can be shown as ghosts.
+//
+// Packets are sent over UDP to a relay (see cmd/aaaaxy-ghostrelay), which forwards them
+// to all other players in the same room.
+package netghost
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/mute"
+)
+
+var (
+	netghostRelay = flag.String("netghost_relay", "", "host:port of a ghost racing relay; when set together with -netghost_room, other players in the room are shown as ghosts")
+	netghostRoom  = flag.String("netghost_room", "", "name of the ghost racing room to join; share it with the players to race against")
+)
+
+const (
+	// PacketSize is the size of a single ghost packet.
+	PacketSize = 34
+
+	// timeout is how long a remote ghost stays visible without receiving packets.
+	timeout = 2 * time.Second
+)
+
+var magic = [4]byte{'A', 'X', 'G', '2'}
+
+// State is the position of a player at a single frame.
+// Positions are given in level coordinates, so they are independent of warpzones.
+type State struct {
+	// Category are the speedrun categories of the run; only ghosts of the same category are shown.
+	Category uint32
+	// Session identifies the sending game; frame numbers are only compared within a session.
+	// Set by Send.
+	Session uint32
+	// Frame is the frame number, used to drop reordered packets.
+	// Set by Send.
+	Frame uint32
+	// LevelPos is the level tile the player is on.
+	LevelPos m.Pos
+	// Offset2 is the player position relative to the center of the tile, in half pixels.
+	Offset2 m.Delta
+	// Orientation is the player orientation in level coordinates.
+	Orientation m.Orientation
+	// Anim is the animation group of the player.
+	Anim byte
+}
+
+// Packet encodes the state for the given room.
+func (s *State) Packet(room uint32) []byte {
+	buf := make([]byte, PacketSize)
+	copy(buf, magic[:])
+	binary.BigEndian.PutUint32(buf[4:], room)
+	binary.BigEndian.PutUint32(buf[8:], s.Category)
+	binary.BigEndian.PutUint32(buf[12:], s.Session)
+	binary.BigEndian.PutUint32(buf[16:], s.Frame)
+	binary.BigEndian.PutUint32(buf[20:], uint32(int32(s.LevelPos.X)))
+	binary.BigEndian.PutUint32(buf[24:], uint32(int32(s.LevelPos.Y)))
+	buf[28] = byte(int8(s.Offset2.DX))
+	buf[29] = byte(int8(s.Offset2.DY))
+	buf[30] = byte(s.Orientation.Right.DX+1)<<4 | byte(s.Orientation.Right.DY+1)
+	buf[31] = byte(s.Orientation.Down.DX+1)<<4 | byte(s.Orientation.Down.DY+1)
+	buf[32] = s.Anim
+	// buf[33] is reserved.
+	return buf
+}
+
+// ParsePacket decodes a packet, returning its room and state.
+func ParsePacket(buf []byte) (uint32, State, error) {
+	if len(buf) != PacketSize || [4]byte(buf[:4]) != magic {
+		return 0, State{}, errors.New("not a ghost packet")
+	}
+	s := State{
+		Category: binary.BigEndian.Uint32(buf[8:]),
+		Session:  binary.BigEndian.Uint32(buf[12:]),
+		Frame:    binary.BigEndian.Uint32(buf[16:]),
+		LevelPos: m.Pos{
+			X: int(int32(binary.BigEndian.Uint32(buf[20:]))),
+			Y: int(int32(binary.BigEndian.Uint32(buf[24:]))),
+		},
+		Offset2: m.Delta{
+			DX: int(int8(buf[28])),
+			DY: int(int8(buf[29])),
+		},
+		Orientation: m.Orientation{
+			Right: m.Delta{DX: int(buf[30]>>4) - 1, DY: int(buf[30]&0xF) - 1},
+			Down:  m.Delta{DX: int(buf[31]>>4) - 1, DY: int(buf[31]&0xF) - 1},
+		},
+		Anim: buf[32],
+	}
+	return binary.BigEndian.Uint32(buf[4:]), s, nil
+}
+
+// RoomHash returns the identifier of a room on the relay.
+func RoomHash(room string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(room))
+	return h.Sum32()
+}
+
+// conn is the platform specific connection to the relay.
+type conn interface {
+	send(packet []byte) error
+	receive() ([]byte, error)
+	close() error
+}
+
+var (
+	c        conn
+	room     uint32
+	session  uint32
+	frame    uint32
+	mu       sync.Mutex
+	remote   State
+	remoteAt time.Time
+	failed   bool
+)
+
+// Active returns whether ghost racing is enabled.
+func Active() bool {
+	return *netghostRelay != "" && *netghostRoom != "" && !failed
+}
+
+// ensureConnected connects to the relay on first use.
+func ensureConnected() bool {
+	if c != nil {
+		return true
+	}
+	if !Active() {
+		return false
+	}
+	var err error
+	c, err = dial(*netghostRelay)
+	if err != nil {
+		log.Errorf("could not connect to ghost relay %v: %v", *netghostRelay, err)
+		failed = true
+		return false
+	}
+	room = RoomHash(*netghostRoom)
+	// The frame number restarts with every game, so tag packets with a random session
+	// to not drop the packets of a restarted game as reordered.
+	session = rand.Uint32()
+	log.Infof("joined ghost racing room %q on %v", *netghostRoom, *netghostRelay)
+	go receiveLoop(c)
+	return true
+}
+
+func receiveLoop(c conn) {
+	for {
+		buf, err := c.receive()
+		if err != nil {
+			log.Infof("ghost relay connection ended: %v", err)
+			return
+		}
+		r, s, err := ParsePacket(buf)
+		if err != nil || r != room {
+			continue
+		}
+		mu.Lock()
+		if s.Session != remote.Session || s.Frame >= remote.Frame || time.Since(remoteAt) > timeout {
+			remote = s
+			remoteAt = time.Now()
+		}
+		mu.Unlock()
+	}
+}
+
+// Send sends the local player state. Errors are only logged.
+// The session and frame number of the state are filled in.
+func Send(s State) {
+	if mute.Active() || !ensureConnected() {
+		return
+	}
+	frame++
+	s.Session = session
+	s.Frame = frame
+	err := c.send(s.Packet(room))
+	if err != nil {
+		log.Warningf("could not send ghost packet: %v", err)
+	}
+}
+
+// Remote returns the most recent state of the other player, if any was received recently.
+func Remote() (State, bool) {
+	if c == nil {
+		return State{}, false
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if remoteAt.IsZero() || time.Since(remoteAt) > timeout {
+		return State{}, false
+	}
+	return remote, true
+}
+
+// Close disconnects from the relay.
+func Close() {
+	if c == nil {
+		return
+	}
+	c.close()
+	c = nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !wasm
+// +build !wasm
+
+package netghost
+
+import (
+	"net"
+)
+
+type udpConn struct {
+	conn *net.UDPConn
+	buf  []byte
+}
+
+func dial(addr string) (conn, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return nil, err
+	}
+	return &udpConn{conn: c, buf: make([]byte, PacketSize+1)}, nil
+}
+
+func (c *udpConn) send(packet []byte) error {
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+func (c *udpConn) receive() ([]byte, error) {
+	n, err := c.conn.Read(c.buf)
+	if err != nil {
+		return nil, err
+	}
+	return c.buf[:n], nil
+}
+
+func (c *udpConn) close() error {
+	return c.conn.Close()
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/divVerent/aaaaxy/internal/audiowrap"
 	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/mute"
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
 
@@ -74,6 +75,9 @@ func Update() {
 }
 
 func Set(noise float64) {
+	if mute.Active() {
+		return
+	}
 	if noise > 1 {
 		noise = 1
 	}
@@ -331,9 +331,6 @@ var (
 )
 
 func managerForSize(w, h int) manager {
-	if w != 640 || h != 360 {
-		log.Fatalf("unexpected size")
-	}
 	key := size{w: w, h: h}
 	m, found := managers[key]
 	if !found {
@@ -14,6 +14,10 @@
 
 package palette
 
+import (
+	"fmt"
+)
+
 type EGAIndex int
 
 const (
@@ -58,10 +62,52 @@ var egaColors = [EGACount]uint32{
 
 var egaColorsSet = map[uint32]bool{}
 
+// egaIndexByColor maps each reference color to its index.
+var egaIndexByColor = map[uint32]EGAIndex{}
+
+// egaNames are the names of the EGA colors as used in maps and markup.
+var egaNames = [EGACount]string{
+	"black",
+	"blue",
+	"green",
+	"cyan",
+	"red",
+	"magenta",
+	"brown",
+	"lightgrey",
+	"darkgrey",
+	"lightblue",
+	"lightgreen",
+	"lightcyan",
+	"lightred",
+	"lightmagenta",
+	"yellow",
+	"white",
+}
+
 func init() {
-	for _, c := range egaColors {
+	for i, c := range egaColors {
 		egaColorsSet[c] = true
+		egaIndexByColor[c] = EGAIndex(i)
+	}
+}
+
+// String returns the name of an EGA color.
+func (i EGAIndex) String() string {
+	if i < 0 || i >= EGACount {
+		return fmt.Sprintf("EGAIndex(%d)", int(i))
+	}
+	return egaNames[i]
+}
+
+// EGAByName returns the EGA color of the given name.
+func EGAByName(name string) (EGAIndex, bool) {
+	for i, n := range egaNames {
+		if n == name {
+			return EGAIndex(i), true
+		}
 	}
+	return 0, false
 }
 
 var nesColors = [64]uint32{
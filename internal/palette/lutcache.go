@@ -15,6 +15,8 @@
 package palette
 
 import (
+	"bytes"
+	"encoding/gob"
 	"encoding/json"
 	"fmt"
 	"image"
@@ -108,11 +110,79 @@ func (p *Palette) loadLUT(numLUTs int) (image.Image, int, int, int, error) {
 	return img, meta.Size, meta.PerRow, meta.Width, nil
 }
 
+// diskCachedLUT is the serialized form of a runtime generated LUT in the disk cache.
+type diskCachedLUT struct {
+	Meta lutMeta
+	Rect image.Rectangle
+	Pix  []byte
+}
+
+func (p *Palette) lutCacheKey(bounds image.Rectangle, numLUTs int) uint64 {
+	return vfs.CacheKey([]byte(fmt.Sprintf("%s %v %d %v", p.name, bounds, numLUTs, *paletteMaxCycles)))
+}
+
+func (p *Palette) loadDiskCachedLUT(key uint64) (image.Image, int, int, int, bool) {
+	data, found := vfs.ReadCache("lut", key)
+	if !found {
+		return nil, 0, 0, 0, false
+	}
+	var c diskCachedLUT
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&c)
+	if err != nil {
+		log.Warningf("could not decode cached palette LUT: %v", err)
+		return nil, 0, 0, 0, false
+	}
+	img := &image.NRGBA{
+		Pix:    c.Pix,
+		Stride: 4 * c.Rect.Dx(),
+		Rect:   c.Rect,
+	}
+	if len(img.Pix) != img.Stride*c.Rect.Dy() {
+		log.Warningf("cached palette LUT has wrong size")
+		return nil, 0, 0, 0, false
+	}
+	return img, c.Meta.Size, c.Meta.PerRow, c.Meta.Width, true
+}
+
+func (p *Palette) storeDiskCachedLUT(key uint64, img *image.NRGBA, size, perRow, width int) {
+	c := diskCachedLUT{
+		Meta: lutMeta{
+			Size:   size,
+			PerRow: perRow,
+			Width:  width,
+		},
+		Rect: img.Rect,
+		Pix:  img.Pix,
+	}
+	if img.Stride != 4*img.Rect.Dx() {
+		// Not tightly packed; copy.
+		c.Pix = make([]byte, 0, 4*img.Rect.Dx()*img.Rect.Dy())
+		for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+			o := img.PixOffset(img.Rect.Min.X, y)
+			c.Pix = append(c.Pix, img.Pix[o:o+4*img.Rect.Dx()]...)
+		}
+	}
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(&c)
+	if err != nil {
+		log.Warningf("could not encode palette LUT for caching: %v", err)
+		return
+	}
+	vfs.WriteCache("lut", key, buf.Bytes())
+}
+
 func (p *Palette) ToLUT(bounds image.Rectangle, numLUTs int) (image.Image, int, int, int) {
 	lut, lutSize, perRow, lutWidth, err := p.loadLUT(numLUTs)
-	if err != nil {
-		log.Warningf("cached palette data not found, generating at runtime: %v", err)
-		lut, lutSize, perRow, lutWidth = p.computeLUT(bounds, numLUTs, *paletteMaxCycles)
+	if err == nil {
+		return lut, lutSize, perRow, lutWidth
+	}
+	key := p.lutCacheKey(bounds, numLUTs)
+	lut, lutSize, perRow, lutWidth, found := p.loadDiskCachedLUT(key)
+	if found {
+		return lut, lutSize, perRow, lutWidth
 	}
-	return lut, lutSize, perRow, lutWidth
+	log.Warningf("cached palette data not found, generating at runtime: %v", err)
+	img, lutSize, perRow, lutWidth := p.computeLUT(bounds, numLUTs, *paletteMaxCycles)
+	p.storeDiskCachedLUT(key, img, lutSize, perRow, lutWidth)
+	return img, lutSize, perRow, lutWidth
 }
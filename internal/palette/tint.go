@@ -0,0 +1,159 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package palette
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Tint is a palette safe recoloring: it replaces EGA colors by other EGA colors.
+// As it is applied before palette remapping, tinted images work with every palette.
+// The zero value keeps all colors.
+type Tint struct {
+	active bool
+	to     [EGACount]EGAIndex
+}
+
+// egaHues lists the dark and light variant of each non-grey EGA color.
+var egaHues = [][2]EGAIndex{
+	{Blue, LightBlue},
+	{Green, LightGreen},
+	{Cyan, LightCyan},
+	{Red, LightRed},
+	{Magenta, LightMagenta},
+	{Brown, Yellow},
+}
+
+// NewTint returns a tint that applies the given color replacements.
+func NewTint(replace map[EGAIndex]EGAIndex) Tint {
+	var t Tint
+	for i := range t.to {
+		t.to[i] = EGAIndex(i)
+	}
+	for from, to := range replace {
+		if from != to {
+			t.to[from] = to
+			t.active = true
+		}
+	}
+	if !t.active {
+		// Keep the zero value canonical, as tints are used as cache keys.
+		return Tint{}
+	}
+	return t
+}
+
+// hueTint returns a tint that replaces all non-grey colors by the variant of c with the same brightness.
+// If c is a grey, this desaturates to the two middle greys.
+func hueTint(c EGAIndex) Tint {
+	dark, light := DarkGrey, LightGrey
+	for _, hue := range egaHues {
+		if c == hue[0] || c == hue[1] {
+			dark, light = hue[0], hue[1]
+		}
+	}
+	replace := map[EGAIndex]EGAIndex{}
+	for _, hue := range egaHues {
+		replace[hue[0]] = dark
+		replace[hue[1]] = light
+	}
+	return NewTint(replace)
+}
+
+// IsIdentity returns whether the tint keeps all colors.
+func (t Tint) IsIdentity() bool {
+	return !t.active
+}
+
+// ApplyToImage returns a tinted copy of an image.
+// Pixels that are not fully opaque EGA colors are kept.
+func (t Tint) ApplyToImage(img image.Image) image.Image {
+	if !t.active {
+		return img
+	}
+	bounds := img.Bounds()
+	newImg := image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			rgba := color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}
+			if rgba.A == 255 {
+				rgb := (uint32(rgba.R) << 16) | (uint32(rgba.G) << 8) | uint32(rgba.B)
+				if i, found := egaIndexByColor[rgb]; found {
+					rgba = toRGB(egaColors[t.to[i]]).toRGBA()
+				}
+			}
+			newImg.SetRGBA(x, y, rgba)
+		}
+	}
+	return newImg
+}
+
+// MarshalText returns the tint as a comma separated list of color replacements.
+func (t Tint) MarshalText() ([]byte, error) {
+	var parts []string
+	for from, to := range t.to {
+		if t.active && EGAIndex(from) != to {
+			parts = append(parts, fmt.Sprintf("%v=%v", EGAIndex(from), to))
+		}
+	}
+	return []byte(strings.Join(parts, ",")), nil
+}
+
+// UnmarshalText parses a tint.
+//
+// A tint is either a single color name, which replaces every non-grey color
+// by the one of the same brightness in that color's hue, or a comma separated
+// list of from=to color replacements like "red=blue,lightred=lightblue".
+// An empty string keeps all colors.
+func (t *Tint) UnmarshalText(text []byte) error {
+	s := strings.ToLower(strings.TrimSpace(string(text)))
+	if s == "" {
+		*t = Tint{}
+		return nil
+	}
+	if !strings.Contains(s, "=") {
+		c, found := EGAByName(s)
+		if !found {
+			return fmt.Errorf("unknown color %q in tint", s)
+		}
+		*t = hueTint(c)
+		return nil
+	}
+	replace := map[EGAIndex]EGAIndex{}
+	for _, item := range strings.Split(s, ",") {
+		fromName, toName, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return fmt.Errorf("invalid tint replacement %q: want from=to", item)
+		}
+		from, found := EGAByName(strings.TrimSpace(fromName))
+		if !found {
+			return fmt.Errorf("unknown color %q in tint", fromName)
+		}
+		to, found := EGAByName(strings.TrimSpace(toName))
+		if !found {
+			return fmt.Errorf("unknown color %q in tint", toName)
+		}
+		if _, found := replace[from]; found {
+			return fmt.Errorf("color %v replaced twice in tint", from)
+		}
+		replace[from] = to
+	}
+	*t = NewTint(replace)
+	return nil
+}
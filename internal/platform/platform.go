@@ -0,0 +1,149 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package platform connects the game to the platform it got distributed on, such as a game store.
+package platform
+
+import (
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/fun"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// Status is what the game tells integrations about the player's progress.
+type Status struct {
+	// Checkpoint is the display name of the last checkpoint, or empty at the start of the game.
+	Checkpoint string
+	// Categories are the speedrun categories the player has achieved so far.
+	Categories playerstate.SpeedrunCategories
+	// Cheating is set if progress must not be rewarded, e.g. due to cheats, assists or demo playback.
+	Cheating bool
+}
+
+// Integration is implemented by platform integrations.
+type Integration interface {
+	// Name returns the name of the platform for logging.
+	Name() string
+	// Init connects to the platform. On error, the integration stays disabled.
+	Init() error
+	// Update runs once per frame.
+	Update()
+	// SetStatus reports progress of the player whenever it changed.
+	SetStatus(status Status)
+	// RunnableOnUnfocused returns whether the game must keep running when unfocused, e.g. to keep drawing an overlay.
+	RunnableOnUnfocused() bool
+	// ForceFullscreen returns whether the platform expects the game to always run fullscreen.
+	ForceFullscreen() bool
+	// Shutdown disconnects from the platform.
+	Shutdown()
+}
+
+var (
+	registered []Integration
+	active     []Integration
+
+	lastCheckpoint string
+	lastWon        bool
+	statusSent     bool
+)
+
+// Register adds an integration. Must be called from an init function.
+func Register(i Integration) {
+	registered = append(registered, i)
+}
+
+// Init connects all registered integrations.
+func Init() {
+	for _, i := range registered {
+		err := i.Init()
+		if err != nil {
+			log.Infof("%s integration disabled: %v", i.Name(), err)
+			continue
+		}
+		log.Infof("%s integration enabled", i.Name())
+		active = append(active, i)
+	}
+}
+
+// RunnableOnUnfocused returns whether any active integration needs the game to keep running when unfocused.
+func RunnableOnUnfocused() bool {
+	for _, i := range active {
+		if i.RunnableOnUnfocused() {
+			return true
+		}
+	}
+	return false
+}
+
+// ForceFullscreen returns whether any active integration needs the game to run fullscreen.
+func ForceFullscreen() bool {
+	for _, i := range active {
+		if i.ForceFullscreen() {
+			return true
+		}
+	}
+	return false
+}
+
+// Update runs all active integrations and reports the player's progress to them when it changed.
+func Update(ps *playerstate.PlayerState) {
+	if len(active) == 0 {
+		return
+	}
+	for _, i := range active {
+		i.Update()
+	}
+	cp, won := ps.LastCheckpoint(), ps.Won()
+	if statusSent && cp == lastCheckpoint && won == lastWon {
+		return
+	}
+	lastCheckpoint, lastWon, statusSent = cp, won, true
+	status := Status{
+		Categories: ps.SpeedrunCategories(),
+		Cheating:   demo.Playing(),
+	}
+	if is, _ := flag.Cheating(); is {
+		status.Cheating = true
+	}
+	// Achievements are for unassisted play only.
+	if is, _ := flag.Assisted(); is {
+		status.Cheating = true
+	}
+	if legal, _ := flag.SpeedrunLegal(); !legal {
+		status.Cheating = true
+	}
+	if status.Categories.ContainAll(playerstate.AssistedSpeedrun) {
+		status.Cheating = true
+	}
+	if cp != "" {
+		text := propmap.StringOr(ps.Level.Checkpoints[cp].Properties, "text", cp)
+		status.Checkpoint = strings.ReplaceAll(fun.FormatText(ps, text), "\n", " ")
+	}
+	for _, i := range active {
+		i.SetStatus(status)
+	}
+}
+
+// Shutdown disconnects all active integrations.
+func Shutdown() {
+	for _, i := range active {
+		i.Shutdown()
+	}
+	active = nil
+}
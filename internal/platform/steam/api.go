@@ -0,0 +1,164 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build steam
+// +build steam
+
+package steam
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+// The Steamworks flat API, bound at runtime so building needs neither cgo nor the SDK.
+var (
+	libHandle uintptr
+
+	apiRestartAppIfNecessary func(appID uint32) bool
+	apiInit                  func() bool
+	apiInitFlat              func(errMsg *byte) int32
+	apiRunCallbacks          func()
+	apiShutdown              func()
+
+	apiFriends                uintptr
+	apiFriendsSetRichPresence func(self uintptr, key, value string) bool
+
+	apiUserStats               uintptr
+	apiUserStatsGetAchievement func(self uintptr, name string, achieved *bool) bool
+	apiUserStatsSetAchievement func(self uintptr, name string) bool
+	apiUserStatsStoreStats     func(self uintptr) bool
+
+	apiRemoteStorage            uintptr
+	apiRemoteStorageGetFileSize func(self uintptr, name string) int32
+	apiRemoteStorageFileRead    func(self uintptr, name string, data unsafe.Pointer, size int32) int32
+	apiRemoteStorageFileWrite   func(self uintptr, name string, data unsafe.Pointer, size int32) bool
+
+	apiUtils                          uintptr
+	apiUtilsIsSteamRunningOnSteamDeck func(self uintptr) bool
+)
+
+// libraryPath prefers the Steamworks library shipped next to the binary.
+func libraryPath(name string) string {
+	exe, err := os.Executable()
+	if err != nil {
+		return name
+	}
+	path := filepath.Join(filepath.Dir(exe), name)
+	if _, err := os.Stat(path); err != nil {
+		return name
+	}
+	return path
+}
+
+// bind sets fptr to the first of the given functions the library exports.
+func bind(lib uintptr, fptr interface{}, names ...string) error {
+	for _, name := range names {
+		sym, err := lookup(lib, name)
+		if err == nil && sym != 0 {
+			purego.RegisterFunc(fptr, sym)
+			return nil
+		}
+	}
+	return fmt.Errorf("Steamworks library lacks %v", names[0])
+}
+
+// accessor returns the interface pointer of the first of the given versioned accessors the library exports.
+func accessor(lib uintptr, names ...string) (uintptr, error) {
+	var get func() uintptr
+	err := bind(lib, &get, names...)
+	if err != nil {
+		return 0, err
+	}
+	self := get()
+	if self == 0 {
+		return 0, fmt.Errorf("%v returned no interface", names[0])
+	}
+	return self, nil
+}
+
+// loadAPI binds the functions needed before initialization.
+func loadAPI() error {
+	lib, err := openLibrary()
+	if err != nil {
+		return fmt.Errorf("could not load Steamworks library: %w", err)
+	}
+	for _, b := range []struct {
+		fptr  interface{}
+		names []string
+	}{
+		{&apiRestartAppIfNecessary, []string{"SteamAPI_RestartAppIfNecessary"}},
+		{&apiRunCallbacks, []string{"SteamAPI_RunCallbacks"}},
+		{&apiShutdown, []string{"SteamAPI_Shutdown"}},
+		{&apiFriendsSetRichPresence, []string{"SteamAPI_ISteamFriends_SetRichPresence"}},
+		{&apiUserStatsGetAchievement, []string{"SteamAPI_ISteamUserStats_GetAchievement"}},
+		{&apiUserStatsSetAchievement, []string{"SteamAPI_ISteamUserStats_SetAchievement"}},
+		{&apiUserStatsStoreStats, []string{"SteamAPI_ISteamUserStats_StoreStats"}},
+		{&apiRemoteStorageGetFileSize, []string{"SteamAPI_ISteamRemoteStorage_GetFileSize"}},
+		{&apiRemoteStorageFileRead, []string{"SteamAPI_ISteamRemoteStorage_FileRead"}},
+		{&apiRemoteStorageFileWrite, []string{"SteamAPI_ISteamRemoteStorage_FileWrite"}},
+		{&apiUtilsIsSteamRunningOnSteamDeck, []string{"SteamAPI_ISteamUtils_IsSteamRunningOnSteamDeck"}},
+	} {
+		err := bind(lib, b.fptr, b.names...)
+		if err != nil {
+			return err
+		}
+	}
+	// Newer SDKs only export the flat variant of SteamAPI_Init.
+	if bind(lib, &apiInitFlat, "SteamAPI_InitFlat") != nil {
+		err := bind(lib, &apiInit, "SteamAPI_Init")
+		if err != nil {
+			return err
+		}
+	}
+	libHandle = lib
+	return nil
+}
+
+// initAPI initializes Steamworks and fetches the interfaces the game uses.
+func initAPI() error {
+	if apiInitFlat != nil {
+		var errMsg [1024]byte
+		if result := apiInitFlat(&errMsg[0]); result != 0 {
+			n := 0
+			for n < len(errMsg) && errMsg[n] != 0 {
+				n++
+			}
+			return fmt.Errorf("SteamAPI_InitFlat failed (%d): %s", result, errMsg[:n])
+		}
+	} else if !apiInit() {
+		return fmt.Errorf("SteamAPI_Init failed")
+	}
+	for _, a := range []struct {
+		self  *uintptr
+		names []string
+	}{
+		{&apiFriends, []string{"SteamAPI_SteamFriends_v017"}},
+		{&apiUserStats, []string{"SteamAPI_SteamUserStats_v013", "SteamAPI_SteamUserStats_v012"}},
+		{&apiRemoteStorage, []string{"SteamAPI_SteamRemoteStorage_v016"}},
+		{&apiUtils, []string{"SteamAPI_SteamUtils_v010"}},
+	} {
+		self, err := accessor(libHandle, a.names...)
+		if err != nil {
+			apiShutdown()
+			return err
+		}
+		*a.self = self
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package steam integrates the game with Steam.
+//
+// It is only built with the steam build tag. It then loads the Steamworks
+// redistributable library (steam_api64.dll, libsteam_api.so or
+// libsteam_api.dylib) from next to the binary at runtime.
+// Without that tag, this package is empty.
+package steam
@@ -0,0 +1,37 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build steam && (darwin || freebsd || linux)
+// +build steam
+// +build darwin freebsd linux
+
+package steam
+
+import (
+	"runtime"
+
+	"github.com/ebitengine/purego"
+)
+
+func openLibrary() (uintptr, error) {
+	name := "libsteam_api.so"
+	if runtime.GOOS == "darwin" {
+		name = "libsteam_api.dylib"
+	}
+	return purego.Dlopen(libraryPath(name), purego.RTLD_NOW|purego.RTLD_GLOBAL)
+}
+
+func lookup(lib uintptr, name string) (uintptr, error) {
+	return purego.Dlsym(lib, name)
+}
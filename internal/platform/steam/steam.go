@@ -0,0 +1,154 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build steam
+// +build steam
+
+package steam
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unsafe"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/platform"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	steamAppID = flag.Int("steam_app_id", 0, "Steam app ID; if set, the game restarts through Steam when not started by it")
+)
+
+// achievements maps speedrun categories to Steam achievement API names.
+// All of them also require having won the game.
+var achievements = []struct {
+	categories playerstate.SpeedrunCategories
+	name       string
+}{
+	{playerstate.AnyPercentSpeedrun, "ANY_PERCENT"},
+	{playerstate.AllCheckpointsSpeedrun, "ALL_CHECKPOINTS"},
+	{playerstate.AllSignsSpeedrun, "ALL_NOTES"},
+	{playerstate.AllPathsSpeedrun, "ALL_PATHS"},
+	{playerstate.AllSecretsSpeedrun, "ALL_SECRETS"},
+	{playerstate.AllFlippedSpeedrun, "ALL_FLIPPED"},
+	{playerstate.NoTeleportsSpeedrun, "NO_TELEPORTS"},
+	{playerstate.NoEscapeSpeedrun, "NO_ESCAPE"},
+	{playerstate.NoPushSpeedrun, "NO_COIL"},
+}
+
+type steam struct{}
+
+func (steam) Name() string {
+	return "Steam"
+}
+
+func (steam) Init() error {
+	err := loadAPI()
+	if err != nil {
+		return err
+	}
+	if *steamAppID != 0 && apiRestartAppIfNecessary(uint32(*steamAppID)) {
+		log.Infof("restarting through Steam")
+		os.Exit(1)
+	}
+	err = initAPI()
+	if err != nil {
+		return err
+	}
+	vfs.SetStateSyncProvider(cloud{})
+	return nil
+}
+
+func (steam) Update() {
+	apiRunCallbacks()
+}
+
+func (steam) SetStatus(status platform.Status) {
+	apiFriendsSetRichPresence(apiFriends, "status", status.Checkpoint)
+	if status.Cheating || !status.Categories.ContainAll(playerstate.AnyPercentSpeedrun) {
+		return
+	}
+	changed := false
+	for _, a := range achievements {
+		if !status.Categories.ContainAll(a.categories) {
+			continue
+		}
+		var achieved bool
+		if !apiUserStatsGetAchievement(apiUserStats, a.name, &achieved) || achieved {
+			continue
+		}
+		if apiUserStatsSetAchievement(apiUserStats, a.name) {
+			log.Infof("unlocked Steam achievement %v", a.name)
+			changed = true
+		}
+	}
+	if changed && !apiUserStatsStoreStats(apiUserStats) {
+		log.Errorf("could not store Steam achievements")
+	}
+}
+
+func (steam) RunnableOnUnfocused() bool {
+	// The overlay may take input focus from the game window while it still draws into it.
+	return true
+}
+
+func (steam) ForceFullscreen() bool {
+	return apiUtilsIsSteamRunningOnSteamDeck(apiUtils)
+}
+
+func (steam) Shutdown() {
+	apiFriendsSetRichPresence(apiFriends, "status", "")
+	apiShutdown()
+}
+
+// cloud syncs save games with Steam Cloud.
+type cloud struct{}
+
+func (cloud) Pull(kind vfs.StateKind, name string) ([]byte, error) {
+	if kind != vfs.SavedGames {
+		return nil, os.ErrNotExist
+	}
+	size := apiRemoteStorageGetFileSize(apiRemoteStorage, name)
+	if size <= 0 {
+		return nil, os.ErrNotExist
+	}
+	data := make([]byte, size)
+	n := apiRemoteStorageFileRead(apiRemoteStorage, name, unsafe.Pointer(&data[0]), size)
+	if n != size {
+		return nil, fmt.Errorf("short read from Steam Cloud: got %d bytes, want %d", n, size)
+	}
+	return data, nil
+}
+
+func (cloud) Push(kind vfs.StateKind, name string, data []byte) error {
+	if kind != vfs.SavedGames {
+		return nil
+	}
+	if len(data) == 0 {
+		// Steam Cloud rejects empty files.
+		return nil
+	}
+	if !apiRemoteStorageFileWrite(apiRemoteStorage, name, unsafe.Pointer(&data[0]), int32(len(data))) {
+		return errors.New("could not write to Steam Cloud")
+	}
+	return nil
+}
+
+func init() {
+	platform.Register(steam{})
+}
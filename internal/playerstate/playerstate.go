@@ -16,6 +16,7 @@ package playerstate
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/divVerent/aaaaxy/internal/flag"
@@ -30,12 +31,23 @@ var (
 	cheatFullMapNormal   = flag.Bool("cheat_full_map_normal", false, "show the full map")
 	cheatFullMapFlipped  = flag.Bool("cheat_full_map_flipped", false, "show the full map")
 	cheatPlayerAbilities = flag.StringMap[bool]("cheat_player_abilities", map[string]bool{}, "override player abilities")
+	invincible           = flag.Bool("invincible", false, "hazards do not hurt the player (assist option)")
 )
 
+func init() {
+	flag.MarkAssist("invincible")
+	flag.RecordInDemo("invincible")
+}
+
 type PlayerState struct {
 	Level *level.Level
 }
 
+const (
+	// DamageCooldownFrames is how long the player is invulnerable after taking damage.
+	DamageCooldownFrames = 60
+)
+
 // Init must be called when Level got externally changed, e.g. by loading world or a save state.
 func (s *PlayerState) Init() {
 	if s.Level.SaveGameVersion != 1 {
@@ -202,6 +214,160 @@ func (s *PlayerState) SetLives(n int) {
 	propmap.Set(s.Level.Player.PersistentState, "lives", n)
 }
 
+// MaxHP returns the maximum hit points of the player, or 0 if every hit respawns the player.
+// Configured by the max_hp property of the player object in the map.
+func (s *PlayerState) MaxHP() int {
+	return propmap.ValueOrP(s.Level.Player.Properties, "max_hp", 0, nil)
+}
+
+// HP returns the current hit points of the player.
+func (s *PlayerState) HP() int {
+	maxHP := s.MaxHP()
+	hp, err := propmap.ValueOr(s.Level.Player.PersistentState, "hp", maxHP)
+	if err != nil {
+		log.Errorf("could not parse hp: %v", err)
+		return maxHP
+	}
+	return min(hp, maxHP)
+}
+
+// Damage deducts hit points from the player.
+// Returns whether the player died and should be respawned; hit points are then refilled.
+// Right after taking damage, the player is invulnerable for a short time.
+// All ways of hurting the player must go through this, as it implements the invincible assist option.
+func (s *PlayerState) Damage(n int) bool {
+	if *invincible {
+		return false
+	}
+	maxHP := s.MaxHP()
+	if maxHP <= 0 {
+		return true
+	}
+	// The cooldown is kept in the persistent state, so it survives snapshots and save games like the hit points.
+	// It is counted down by Tick, as the speedrun timer does not always run.
+	if s.damageCooldown() > 0 {
+		return false
+	}
+	propmap.Set(s.Level.Player.PersistentState, "damage_cooldown", DamageCooldownFrames)
+	hp := s.HP() - n
+	if hp <= 0 {
+		propmap.Delete(s.Level.Player.PersistentState, "damage_cooldown")
+		propmap.Set(s.Level.Player.PersistentState, "hp", maxHP)
+		return true
+	}
+	propmap.Set(s.Level.Player.PersistentState, "hp", hp)
+	return false
+}
+
+// damageCooldown returns the number of frames the player remains invulnerable for.
+func (s *PlayerState) damageCooldown() int {
+	return propmap.ValueOrP(s.Level.Player.PersistentState, "damage_cooldown", 0, nil)
+}
+
+// Tick advances timed player state by one frame.
+// Must be called on every game frame, even while the speedrun timer is stopped.
+func (s *PlayerState) Tick() {
+	switch cooldown := s.damageCooldown(); {
+	case cooldown > 1:
+		propmap.Set(s.Level.Player.PersistentState, "damage_cooldown", cooldown-1)
+	case cooldown == 1:
+		propmap.Delete(s.Level.Player.PersistentState, "damage_cooldown")
+	}
+}
+
+// Heal restores hit points of the player.
+// Returns whether anything changed.
+func (s *PlayerState) Heal(n int) bool {
+	maxHP := s.MaxHP()
+	hp := s.HP()
+	if hp >= maxHP {
+		return false
+	}
+	propmap.Set(s.Level.Player.PersistentState, "hp", min(hp+n, maxHP))
+	return true
+}
+
+// Collectibles returns how many collectibles of the given counter the player has.
+func (s *PlayerState) Collectibles(counter string) int {
+	n, err := propmap.ValueOr(s.Level.Player.PersistentState, "collectibles."+counter, 0)
+	if err != nil {
+		log.Errorf("could not parse collectibles counter %q: %v", counter, err)
+		return 0
+	}
+	return n
+}
+
+// AddCollectible increments the given collectibles counter.
+func (s *PlayerState) AddCollectible(counter string) {
+	propmap.Set(s.Level.Player.PersistentState, "collectibles."+counter, s.Collectibles(counter)+1)
+}
+
+// CollectibleCounters returns the names of all collectible counters the player has, sorted by name.
+func (s *PlayerState) CollectibleCounters() []string {
+	var counters []string
+	propmap.ForEach(s.Level.Player.PersistentState, func(k, v string) error {
+		if counter, found := strings.CutPrefix(k, "collectibles."); found {
+			counters = append(counters, counter)
+		}
+		return nil
+	})
+	sort.Strings(counters)
+	return counters
+}
+
+// WarpZoneStates returns all warpzone states saved by SetWarpZoneState.
+func (s *PlayerState) WarpZoneStates() map[string]bool {
+	states := map[string]bool{}
+	propmap.ForEach(s.Level.Player.PersistentState, func(k, v string) error {
+		if name, found := strings.CutPrefix(k, "warpzones."); found {
+			states[name] = propmap.ValueOrP(s.Level.Player.PersistentState, k, false, nil)
+		}
+		return nil
+	})
+	return states
+}
+
+// SetWarpZoneState saves the state of a warpzone so it survives respawning.
+func (s *PlayerState) SetWarpZoneState(name string, state bool) {
+	propmap.Set(s.Level.Player.PersistentState, "warpzones."+name, state)
+}
+
+// inputDevices are the input devices whose usage is tracked per save game.
+var inputDevices = []struct {
+	name string
+	m    input.InputMap
+}{
+	{"gamepad", input.Gamepad},
+	{"touchscreen", input.Touchscreen},
+	{"dos_keyboard", input.DOSKeyboard},
+	{"nes_keyboard", input.NESKeyboard},
+	{"fps_keyboard", input.FPSKeyboard},
+	{"vi_keyboard", input.ViKeyboard},
+}
+
+// AddInputUsage counts a frame of using the given input devices.
+func (s *PlayerState) AddInputUsage(used input.InputMap) {
+	for _, d := range inputDevices {
+		if !used.ContainsAny(d.m) {
+			continue
+		}
+		key := "input_usage." + d.name
+		propmap.Set(s.Level.Player.PersistentState, key, propmap.ValueOrP(s.Level.Player.PersistentState, key, 0, nil)+1)
+	}
+}
+
+// PreferredInputMap returns the input device the player used the most in this save game, or input.NoInput if unknown.
+func (s *PlayerState) PreferredInputMap() input.InputMap {
+	best, bestFrames := input.NoInput, 0
+	for _, d := range inputDevices {
+		frames := propmap.ValueOrP(s.Level.Player.PersistentState, "input_usage."+d.name, 0, nil)
+		if frames > bestFrames {
+			best, bestFrames = d.m, frames
+		}
+	}
+	return best
+}
+
 func (s *PlayerState) Won() bool {
 	return propmap.ValueOrP(s.Level.Player.PersistentState, "won", false, nil)
 }
@@ -210,6 +376,75 @@ func (s *PlayerState) SetWon() {
 	propmap.Set(s.Level.Player.PersistentState, "won", true)
 }
 
+// Assisted returns whether assist options were ever used with this save.
+func (s *PlayerState) Assisted() bool {
+	return propmap.ValueOrP(s.Level.Player.PersistentState, "assisted", false, nil)
+}
+
+// SetAssisted permanently marks this save as assisted.
+func (s *PlayerState) SetAssisted() {
+	propmap.Set(s.Level.Player.PersistentState, "assisted", true)
+}
+
+// Modifiers are New Game Plus options that change how the whole game plays.
+type Modifiers int
+
+const (
+	// MirrorModifier mirrors the screen and left/right input.
+	MirrorModifier Modifiers = 0x01
+	// LowVisibilityModifier limits how far the player can see.
+	LowVisibilityModifier Modifiers = 0x02
+	// DoubleSpeedModifier runs the game at twice the speed.
+	DoubleSpeedModifier Modifiers = 0x04
+
+	allModifiers = MirrorModifier | LowVisibilityModifier | DoubleSpeedModifier
+)
+
+// AllModifiers lists all modifiers in display order.
+var AllModifiers = []Modifiers{MirrorModifier, LowVisibilityModifier, DoubleSpeedModifier}
+
+func (m Modifiers) Name() string {
+	switch m {
+	case MirrorModifier:
+		return locale.G.Get("Mirror")
+	case LowVisibilityModifier:
+		return locale.G.Get("Low Visibility")
+	case DoubleSpeedModifier:
+		return locale.G.Get("Double Speed")
+	default:
+		return locale.G.Get("???")
+	}
+}
+
+// Describe returns a human readable list of the given modifiers.
+func (m Modifiers) Describe() string {
+	var names []string
+	for _, mod := range AllModifiers {
+		if m.ContainAll(mod) {
+			names = append(names, mod.Name())
+		}
+	}
+	if len(names) == 0 {
+		return locale.G.Get("None")
+	}
+	return strings.Join(names, locale.G.Get(", "))
+}
+
+func (m Modifiers) ContainAll(mods Modifiers) bool {
+	return (m & mods) == mods
+}
+
+// Modifiers returns the modifiers this save was started with.
+func (s *PlayerState) Modifiers() Modifiers {
+	return Modifiers(propmap.ValueOrP(s.Level.Player.PersistentState, "modifiers", 0, nil)) & allModifiers
+}
+
+// SetModifiers sets the modifiers of this save.
+// Should only be called when starting a new game.
+func (s *PlayerState) SetModifiers(mods Modifiers) {
+	propmap.Set(s.Level.Player.PersistentState, "modifiers", int(mods&allModifiers))
+}
+
 type SpeedrunCategories int
 
 const (
@@ -223,6 +458,12 @@ const (
 	NoEscapeSpeedrun       SpeedrunCategories = 0x40
 	NoTeleportsSpeedrun    SpeedrunCategories = 0x80
 	NoPushSpeedrun         SpeedrunCategories = 0x100
+	// Not a real category, but a marker that assist options were used.
+	AssistedSpeedrun SpeedrunCategories = 0x200
+	// Not real categories either, but markers of the New Game Plus modifiers.
+	MirrorSpeedrun        SpeedrunCategories = 0x400
+	LowVisibilitySpeedrun SpeedrunCategories = 0x800
+	DoubleSpeedSpeedrun   SpeedrunCategories = 0x10000
 	// Remapping (reason: one can have all CPs but not Any%, i.e. won the game yet):
 	// AnyPercent AllCheckpoints => Result
 	// false      false          => 0
@@ -266,6 +507,14 @@ func (c SpeedrunCategories) Name() string {
 		}
 	case NoPushSpeedrun:
 		return locale.G.Get("No Coil")
+	case AssistedSpeedrun:
+		return locale.G.Get("Assisted")
+	case MirrorSpeedrun:
+		return MirrorModifier.Name()
+	case LowVisibilitySpeedrun:
+		return LowVisibilityModifier.Name()
+	case DoubleSpeedSpeedrun:
+		return DoubleSpeedModifier.Name()
 	case hundredPercentSpeedrun:
 		return locale.GI.Get("100%")
 	case withoutCheatsSpeedrun:
@@ -301,6 +550,14 @@ func (c SpeedrunCategories) ShortName() string {
 		return "E"
 	case NoPushSpeedrun:
 		return "U"
+	case AssistedSpeedrun:
+		return "a"
+	case MirrorSpeedrun:
+		return "m"
+	case LowVisibilitySpeedrun:
+		return "v"
+	case DoubleSpeedSpeedrun:
+		return "d"
 	case withoutCheatsSpeedrun:
 		return "" // Never actually appears other than in tryNext.
 	case cheatingSpeedrun:
@@ -322,7 +579,15 @@ func (c SpeedrunCategories) describeCommon() (categories []SpeedrunCategories, t
 			}
 		}
 	}
-	if is, _ := flag.Cheating(); is {
+	if c.ContainAll(AssistedSpeedrun) {
+		addCategory(AssistedSpeedrun, AssistedSpeedrun /* always true */)
+	}
+	for _, marker := range []SpeedrunCategories{MirrorSpeedrun, LowVisibilitySpeedrun, DoubleSpeedSpeedrun} {
+		if c.ContainAll(marker) {
+			addCategory(marker, marker /* always true */)
+		}
+	}
+	if legal, _ := flag.SpeedrunLegal(); !legal {
 		addCategory(cheatingSpeedrun, 0)
 		addCategory(withoutCheatsSpeedrun, impossibleSpeedrun)
 	} else if c.ContainAll(AllCheckpointsSpeedrun) {
@@ -491,5 +756,18 @@ func (s *PlayerState) SpeedrunCategories() SpeedrunCategories {
 		// Probably can't be combined with much.
 		cat &^= NoPushSpeedrun
 	}
+	if assisted, _ := flag.Assisted(); assisted || s.Assisted() {
+		cat |= AssistedSpeedrun
+	}
+	mods := s.Modifiers()
+	if mods.ContainAll(MirrorModifier) {
+		cat |= MirrorSpeedrun
+	}
+	if mods.ContainAll(LowVisibilityModifier) {
+		cat |= LowVisibilitySpeedrun
+	}
+	if mods.ContainAll(DoubleSpeedModifier) {
+		cat |= DoubleSpeedSpeedrun
+	}
 	return cat
 }
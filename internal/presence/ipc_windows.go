@@ -0,0 +1,38 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package presence
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// dial connects to the IPC pipe of a running Discord client.
+func dial() (io.ReadWriteCloser, error) {
+	timeout := time.Second
+	for i := 0; i < 10; i++ {
+		conn, err := winio.DialPipe(fmt.Sprintf("\\\\.\\pipe\\discord-ipc-%d", i), &timeout)
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, errNoDiscord
+}
@@ -0,0 +1,254 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package presence publishes what the player is doing to Discord Rich Presence.
+package presence
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+var (
+	discordPresence = flag.Bool("discord_presence", false, "publish the current activity to Discord")
+	discordClientID = flag.String("discord_client_id", discordAppID, "Discord application ID to publish the activity as")
+)
+
+// discordAppID is the ID of the game's Discord application.
+// Release builds set it by linking with
+// -X=github.com/divVerent/aaaaxy/internal/presence.discordAppID=<id>.
+var discordAppID = ""
+
+const (
+	// sendInterval is how long to wait between updates; Discord rate limits them.
+	sendInterval = 15 * time.Second
+
+	opHandshake = 0
+	opFrame     = 1
+	opClose     = 2
+)
+
+// Activity describes what the player is doing.
+type Activity struct {
+	// Map is the title of the map being played, if any.
+	Map string
+	// Checkpoint is the name of the last checkpoint, if any.
+	Checkpoint string
+	// Frames is the game time so far.
+	Frames int
+	// Timing is set while the speedrun timer is running.
+	Timing bool
+	// Paused is set while a menu is open.
+	// The speedrun timer keeps running then.
+	Paused bool
+}
+
+// status is an activity formatted for Discord.
+type status struct {
+	details string
+	state   string
+	// start is when the speedrun timer started, or zero if it is not running.
+	start time.Time
+}
+
+var (
+	started bool
+	pending chan *status
+	last    Activity
+
+	errNoDiscord = errors.New("no Discord client found")
+)
+
+// Enabled returns whether activities get published.
+func Enabled() bool {
+	return *discordPresence
+}
+
+// Update publishes the given activity if it changed.
+// Does nothing unless enabled by flag.
+func Update(a Activity) {
+	if !*discordPresence {
+		return
+	}
+	if !started {
+		started = true
+		if *discordClientID == "" {
+			log.Errorf("discord_presence requires discord_client_id to be set")
+			*discordPresence = false
+			return
+		}
+		pending = make(chan *status, 1)
+		go run(pending)
+	} else if a.Map == last.Map && a.Checkpoint == last.Checkpoint && a.Timing == last.Timing && a.Paused == last.Paused {
+		return
+	}
+	last = a
+	// Format here, as the locale must only be used from the game thread.
+	st := format(&a)
+	// Replace any activity not sent yet.
+	select {
+	case <-pending:
+	default:
+	}
+	pending <- st
+}
+
+// format prepares an activity for sending.
+func format(a *Activity) *status {
+	st := &status{}
+	st.details = a.Checkpoint
+	if st.details == "" {
+		st.details = a.Map
+	} else {
+		st.state = a.Map
+	}
+	if a.Paused {
+		st.details = locale.G.Get("%s (paused)", st.details)
+	}
+	if a.Timing {
+		st.start = time.Now().Add(-time.Duration(a.Frames) * time.Second / 60)
+	}
+	return st
+}
+
+// newest returns the most recent activity that is yet to be sent.
+func newest(statuses <-chan *status, st *status) *status {
+	select {
+	case next := <-statuses:
+		return next
+	default:
+		return st
+	}
+}
+
+// run sends activities to Discord, reconnecting as needed.
+// An activity that could not be sent is retried unless a newer one replaced it.
+func run(statuses <-chan *status) {
+	var conn io.ReadWriteCloser
+	nonce := 0
+	for st := range statuses {
+		for st != nil {
+			if conn == nil {
+				var err error
+				conn, err = connect()
+				if err != nil {
+					log.Infof("could not connect to Discord: %v", err)
+					time.Sleep(sendInterval)
+					st = newest(statuses, st)
+					continue
+				}
+			}
+			nonce++
+			err := setActivity(conn, st, nonce)
+			if err != nil {
+				log.Infof("could not update Discord activity: %v", err)
+				conn.Close()
+				conn = nil
+			} else {
+				st = nil
+			}
+			time.Sleep(sendInterval)
+			if st != nil {
+				st = newest(statuses, st)
+			}
+		}
+	}
+}
+
+// connect opens the Discord IPC connection and performs the handshake.
+func connect() (io.ReadWriteCloser, error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	err = writeFrame(conn, opHandshake, map[string]interface{}{
+		"v":         1,
+		"client_id": *discordClientID,
+	})
+	if err == nil {
+		err = readFrame(conn)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func setActivity(conn io.ReadWriter, st *status, nonce int) error {
+	activity := map[string]interface{}{}
+	if st.details != "" {
+		activity["details"] = st.details
+	}
+	if st.state != "" {
+		activity["state"] = st.state
+	}
+	if !st.start.IsZero() {
+		activity["timestamps"] = map[string]interface{}{
+			"start": st.start.Unix(),
+		}
+	}
+	err := writeFrame(conn, opFrame, map[string]interface{}{
+		"cmd": "SET_ACTIVITY",
+		"args": map[string]interface{}{
+			"pid":      os.Getpid(),
+			"activity": activity,
+		},
+		"nonce": fmt.Sprint(nonce),
+	})
+	if err != nil {
+		return err
+	}
+	return readFrame(conn)
+}
+
+func writeFrame(w io.Writer, op uint32, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, 8)
+	binary.LittleEndian.PutUint32(header[0:], op)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(data)))
+	_, err = w.Write(append(header, data...))
+	return err
+}
+
+// readFrame reads and discards a response, failing if Discord closed the connection.
+func readFrame(r io.Reader) error {
+	header := make([]byte, 8)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return err
+	}
+	op := binary.LittleEndian.Uint32(header[0:])
+	data := make([]byte, binary.LittleEndian.Uint32(header[4:]))
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return err
+	}
+	if op == opClose {
+		return fmt.Errorf("closed by Discord: %s", data)
+	}
+	return nil
+}
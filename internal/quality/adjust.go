@@ -0,0 +1,113 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quality
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/dump"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+const (
+	// Must reach about 50fps in at least two of the one second intervals in every 10 seconds.
+	minFPS              = 49
+	measureInterval     = time.Second
+	measureIntervals    = 10
+	minGoodIntervals    = 2
+	maxFrameTimeCounted = time.Second // Longer gaps are hiccups (e.g. window dragging), not slow rendering.
+)
+
+var (
+	lastFrame       time.Time
+	intervalFrames  int
+	intervalTime    time.Duration
+	totalIntervals  int
+	goodIntervals   int
+	measuringActive bool
+)
+
+func resetMeasurement() {
+	lastFrame = time.Time{}
+	intervalFrames = 0
+	intervalTime = 0
+	totalIntervals = 0
+	goodIntervals = 0
+}
+
+// Frame records the time a frame was drawn. Call once per Draw.
+func Frame() {
+	if !measuringActive {
+		return
+	}
+	now := time.Now()
+	if !lastFrame.IsZero() {
+		if d := now.Sub(lastFrame); d < maxFrameTimeCounted {
+			intervalFrames++
+			intervalTime += d
+		}
+	}
+	lastFrame = now
+	if intervalTime < measureInterval {
+		return
+	}
+	fps := float64(intervalFrames) * float64(time.Second) / float64(intervalTime)
+	intervalFrames = 0
+	intervalTime = 0
+	totalIntervals++
+	if fps >= minFPS {
+		goodIntervals++
+	}
+}
+
+// Update performs automatic quality adjustment based on the measured frame times. Call once per Update.
+func Update() {
+	// Don't auto adjust if disabled, dumping, benchmarking or not having focus.
+	measuringActive = *autoAdjustQuality && !dump.Active() && !demo.Timedemo() && ebiten.IsFocused()
+	if !measuringActive {
+		resetMeasurement()
+		return
+	}
+
+	// Check if downgrade is needed.
+	if totalIntervals < measureIntervals {
+		return
+	}
+	ok := goodIntervals >= minGoodIntervals
+	totalIntervals = 0
+	goodIntervals = 0
+	if ok {
+		return
+	}
+
+	// Downgrade quality.
+	t := currentTier()
+	if t == len(ladder)-1 {
+		log.Warningf("couldn't even get good framerate at quality %v - cannot downgrade further", ladder[t].setting)
+		return
+	}
+	log.Warningf("didn't get good framerate at quality tier %d (%v) - moving to tier %d (%v)", t, ladder[t].setting, t+1, ladder[t+1].setting)
+	setTier(t + 1)
+	// Instantly save config when quality was adjusted.
+	// That way this setting isn't lost if the app is restarted.
+	err := engine.SaveConfig()
+	if err != nil {
+		log.Errorf("could not save config: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package quality manages the graphics quality settings and adjusts them automatically to keep a good framerate.
+package quality
+
+import (
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/locale"
+)
+
+var (
+	autoAdjustQuality = flag.Bool("auto_adjust_quality", true, "automatically adjust graphics quality to keep good fps")
+	qualityTier       = flag.Int("quality_tier", 0, "current step on the graphics quality ladder (0 is best); maintained by auto_adjust_quality and the settings menu")
+)
+
+// Setting is a graphics quality setting as offered in the menu.
+type Setting int
+
+const (
+	Lowest Setting = iota
+	Low
+	Medium
+	High
+	Max
+	Auto
+	SettingCount
+)
+
+func (s Setting) String() string {
+	switch s {
+	case Auto:
+		return locale.G.Get("Auto (%s)", CurrentActual())
+	case Max:
+		return locale.G.Get("Max")
+	case High:
+		return locale.G.Get("High")
+	case Medium:
+		return locale.G.Get("Medium")
+	case Low:
+		return locale.G.Get("Low")
+	case Lowest:
+		return locale.G.Get("Lowest")
+	}
+	return locale.G.Get("???")
+}
+
+// tier is a step on the quality ladder.
+type tier struct {
+	setting        Setting // Menu setting this tier is shown as.
+	lights         bool
+	blurs          bool
+	blurMaxSize    int
+	outside        bool
+	accurateExpand bool
+	screenFilter   string
+	singleLUT      bool
+}
+
+// ladder lists the quality tiers from best to worst.
+// Each step turns off one more expensive feature; auto adjustment walks down this list.
+var ladder = []tier{
+	{Max, true, true, 0, true, true, "linear2xcrt", false},
+	{High, true, true, 0, true, true, "linear2x", false},
+	{Medium, true, true, 0, false, true, "linear2x", false},
+	{Medium, true, true, 2, false, true, "linear2x", false},
+	{Medium, true, true, 2, false, true, "nearest", false},
+	{Medium, true, true, 2, false, true, "nearest", true},
+	{Low, false, true, 2, false, true, "nearest", true},
+	{Low, false, false, 0, false, true, "nearest", true},
+	{Lowest, false, false, 0, false, false, "nearest", true},
+}
+
+// settingTiers are the tiers the menu settings select.
+var settingTiers = map[Setting]int{
+	Max:    0,
+	High:   1,
+	Medium: 2,
+	Low:    7,
+	Lowest: 8,
+}
+
+// isUserScreenFilter returns whether a screen filter was chosen explicitly by the user
+// and is thus not managed by the quality settings.
+func isUserScreenFilter(name string) bool {
+	switch name {
+	case "nearest", "linear", "linear2x", "linear2xcrt":
+		return false
+	}
+	return true
+}
+
+func (t *tier) matches() bool {
+	filter := flag.Get[string]("screen_filter")
+	return flag.Get[bool]("draw_lights") == t.lights &&
+		flag.Get[bool]("draw_blurs") == t.blurs &&
+		flag.Get[int]("blur_max_size") == t.blurMaxSize &&
+		flag.Get[bool]("draw_outside") == t.outside &&
+		flag.Get[bool]("expand_using_vertices_accurately") == t.accurateExpand &&
+		(filter == t.screenFilter || isUserScreenFilter(filter)) &&
+		flag.Get[bool]("palette_single_lut") == t.singleLUT
+}
+
+func (t *tier) apply() {
+	filter := flag.Get[string]("screen_filter")
+	flag.Set("draw_lights", t.lights)
+	flag.Set("draw_blurs", t.blurs)
+	flag.Set("blur_max_size", t.blurMaxSize)
+	flag.Set("draw_outside", t.outside)
+	flag.Set("expand_using_vertices_accurately", t.accurateExpand)
+	if !isUserScreenFilter(filter) {
+		flag.Set("screen_filter", t.screenFilter)
+	}
+	flag.Set("palette_single_lut", t.singleLUT)
+}
+
+// currentTier returns the index of the active tier on the ladder.
+// If the individual flags have been changed to something not on the ladder, the persisted tier is used.
+func currentTier() int {
+	t := *qualityTier
+	if t >= 0 && t < len(ladder) && ladder[t].matches() {
+		return t
+	}
+	for i := range ladder {
+		if ladder[i].matches() {
+			return i
+		}
+	}
+	if t < 0 {
+		return 0
+	}
+	if t >= len(ladder) {
+		return len(ladder) - 1
+	}
+	return t
+}
+
+func setTier(t int) {
+	ladder[t].apply()
+	flag.Set("quality_tier", t)
+}
+
+// Current returns the current quality setting, including Auto.
+func Current() Setting {
+	if *autoAdjustQuality {
+		return Auto
+	}
+	return CurrentActual()
+}
+
+// CurrentActual returns the quality setting that is actually in effect.
+func CurrentActual() Setting {
+	return ladder[currentTier()].setting
+}
+
+// Apply switches to the given quality setting.
+// Auto starts at the best quality and lets automatic adjustment lower it as needed.
+func (s Setting) Apply() {
+	resetMeasurement()
+	if s == Auto {
+		*autoAdjustQuality = true
+		s = Max
+	} else {
+		*autoAdjustQuality = false
+	}
+	setTier(settingTiers[s])
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rng provides deterministic random number streams.
+//
+// All randomness that can affect gameplay or rendering should come from here,
+// so that demo playback is reproducible. Each user gets its own named stream,
+// so adding random calls in one place does not change the sequence elsewhere.
+package rng
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// source is a SplitMix64 random source.
+// Its state is a single integer, which makes it trivial to save and restore.
+type source struct {
+	state uint64
+}
+
+func (s *source) Uint64() uint64 {
+	s.state += 0x9E3779B97F4A7C15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+func (s *source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *source) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+var _ rand.Source64 = &source{}
+
+type stream struct {
+	src  source
+	rand *rand.Rand
+}
+
+var (
+	seed    int
+	streams = map[string]*stream{}
+)
+
+// streamSeed returns the initial state of the named stream.
+func streamSeed(name string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return h.Sum64() ^ uint64(seed)
+}
+
+// NewSeed returns a fresh, non-deterministic seed.
+// The result is non-negative and fits in 31 bits, so it can be stored everywhere.
+func NewSeed() int {
+	return int(time.Now().UnixNano() & 0x7FFFFFFF)
+}
+
+// Seed returns the current seed.
+func Seed() int {
+	return seed
+}
+
+// SetSeed sets the seed and resets all streams accordingly.
+func SetSeed(s int) {
+	seed = s
+	for name, st := range streams {
+		st.src.state = streamSeed(name)
+	}
+}
+
+// Get returns the named random stream.
+// The returned object remains valid when the seed changes.
+func Get(name string) *rand.Rand {
+	st := streams[name]
+	if st == nil {
+		st = &stream{}
+		st.src.state = streamSeed(name)
+		st.rand = rand.New(&st.src)
+		streams[name] = st
+	}
+	return st.rand
+}
+
+// Unseeded returns a new random stream that is not part of the seeded state.
+// Use it for purely visual effects computed during drawing, which runs a
+// frame rate dependent number of times and thus must not advance seeded streams.
+func Unseeded() *rand.Rand {
+	return rand.New(&source{state: uint64(time.Now().UnixNano())})
+}
+
+// State is the saved state of all streams.
+type State struct {
+	seed    int
+	streams map[string]uint64
+}
+
+// SaveState returns the current state of all streams.
+func SaveState() State {
+	s := State{
+		seed:    seed,
+		streams: make(map[string]uint64, len(streams)),
+	}
+	for name, st := range streams {
+		s.streams[name] = st.src.state
+	}
+	return s
+}
+
+// ForEachStream calls f for the current state of each named stream, sorted by name.
+func (s State) ForEachStream(f func(name string, state uint64)) {
+	names := make([]string, 0, len(s.streams))
+	for name := range s.streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f(name, s.streams[name])
+	}
+}
+
+// LoadState restores the state of all streams.
+// Streams not contained in the state are reset.
+func LoadState(s State) {
+	SetSeed(s.seed)
+	for name, state := range s.streams {
+		Get(name)
+		streams[name].src.state = state
+	}
+}
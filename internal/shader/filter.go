@@ -0,0 +1,112 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// User screen filters are plain Kage sources named <name>.kage.
+//
+// They are searched for in the "filters" directory of the config state
+// folder first, and then in the "filters" directory of the assets (so mods
+// can ship them too). Image 0 is the game screen at game resolution; the
+// uniforms ScanLineEffect and CRTStrength are provided if declared.
+const (
+	userFilterDir    = "filters"
+	userFilterSuffix = ".kage"
+)
+
+var userFilterCache = map[string]*ebiten.Shader{}
+
+func validUserFilterName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "/\\") && !strings.HasPrefix(name, ".")
+}
+
+func readUserFilter(name string) ([]byte, error) {
+	data, err := vfs.ReadState(vfs.Config, userFilterDir+"/"+name+userFilterSuffix)
+	if err == nil {
+		return data, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	handle, err := vfs.Load(userFilterDir, name+userFilterSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Close()
+	return io.ReadAll(handle)
+}
+
+// LoadUserFilter loads the user screen filter of the given name.
+func LoadUserFilter(name string) (*ebiten.Shader, error) {
+	if !*debugUseShaders {
+		return nil, errors.New("shader support has been turned off using --debug_use_shaders=false")
+	}
+	if !validUserFilterName(name) {
+		return nil, fmt.Errorf("invalid screen filter name %q", name)
+	}
+	if shader, found := userFilterCache[name]; found {
+		return shader, nil
+	}
+	shaderCode, err := readUserFilter(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not load screen filter %q: %w", name, err)
+	}
+	shader, err := ebiten.NewShader(shaderCode)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile screen filter %q: %w", name, err)
+	}
+	userFilterCache[name] = shader
+	return shader, nil
+}
+
+// UserFilters returns the names of all available user screen filters.
+func UserFilters() []string {
+	var files []string
+	stateFiles, err := vfs.ReadStateDir(vfs.Config, userFilterDir)
+	if err != nil {
+		log.Errorf("could not list user screen filters: %v", err)
+	}
+	files = append(files, stateFiles...)
+	assetFiles, err := vfs.ReadDir(userFilterDir)
+	if err != nil {
+		log.Errorf("could not list screen filters in assets: %v", err)
+	}
+	files = append(files, assetFiles...)
+	var names []string
+	seen := map[string]bool{}
+	for _, file := range files {
+		name, found := strings.CutSuffix(file, userFilterSuffix)
+		if !found || !validUserFilterName(name) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sound
+
+import (
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+)
+
+var (
+	prefetchSounds = flag.Bool("prefetch_sounds", true, "when not precaching, decode sounds the game will likely need soon in the background")
+)
+
+// maxPrefetchers is the number of sounds decoded in parallel in the background.
+const maxPrefetchers = 2
+
+type prefetchResult struct {
+	done  chan struct{}
+	sound *Sound
+	err   error
+}
+
+var (
+	prefetching   = map[string]*prefetchResult{}
+	prefetchSlots = make(chan struct{}, maxPrefetchers)
+)
+
+// Prefetching returns whether Prefetch has any effect.
+// Callers can use this to skip searching for sounds to prefetch.
+func Prefetching() bool {
+	return *prefetchSounds && !cacheFrozen
+}
+
+// Prefetch starts decoding a sound effect in the background, so a later Load need not wait for storage.
+func Prefetch(name string) {
+	if !Prefetching() {
+		return
+	}
+	if _, found := cache[name]; found {
+		return
+	}
+	if _, found := prefetching[name]; found {
+		return
+	}
+	r := &prefetchResult{
+		done: make(chan struct{}),
+	}
+	prefetching[name] = r
+	go func() {
+		prefetchSlots <- struct{}{}
+		defer func() { <-prefetchSlots }()
+		r.sound, r.err = load(name)
+		close(r.done)
+	}()
+}
+
+// takePrefetched returns the prefetched sound effect, waiting for its decoding to finish if needed.
+// Returns false if the sound has not been prefetched.
+func takePrefetched(name string) (*Sound, bool, error) {
+	r, found := prefetching[name]
+	if !found {
+		return nil, false, nil
+	}
+	delete(prefetching, name)
+	<-r.done
+	return r.sound, true, r.err
+}
+
+// discardPrefetched forgets all prefetched sound effects.
+// Decoding already in progress finishes, but its result is dropped.
+func discardPrefetched() {
+	prefetching = map[string]*prefetchResult{}
+}
+
+// prefetcher exposes prefetching to the engine, which cannot import this package.
+type prefetcher struct{}
+
+func (prefetcher) Prefetching() bool    { return Prefetching() }
+func (prefetcher) Prefetch(name string) { Prefetch(name) }
+
+func init() {
+	engine.RegisterSoundPrefetcher(prefetcher{})
+}
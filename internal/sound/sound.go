@@ -33,6 +33,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/locale"
 	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/mute"
 	"github.com/divVerent/aaaaxy/internal/splash"
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
@@ -53,6 +54,7 @@ type Sound struct {
 	groupedCount       int
 	volumeAdjust       float64
 	loopStart, loopEnd int64
+	priority           int
 }
 
 // Sounds are preloaded as byte streams.
@@ -65,6 +67,8 @@ type soundJson struct {
 	VolumeAdjust float64 `json:"volume_adjust"`
 	LoopStart    int64   `json:"loop_start"`
 	LoopEnd      int64   `json:"loop_end"`
+	// Priority decides which sounds keep playing when too many play at once; higher is more important.
+	Priority int `json:"priority"`
 }
 
 // Load loads a sound effect.
@@ -76,6 +80,20 @@ func Load(name string) (*Sound, error) {
 	if cacheFrozen {
 		return nil, fmt.Errorf("sound %v was not precached", name)
 	}
+	sound, prefetched, err := takePrefetched(name)
+	if !prefetched {
+		sound, err = load(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cache[name] = sound
+	return sound, nil
+}
+
+// load loads a sound effect, bypassing the cache.
+// Safe to call from background goroutines.
+func load(name string) (*Sound, error) {
 	data, err := vfs.Load("sounds", name)
 	if err != nil {
 		return nil, fmt.Errorf("could not load: %w", err)
@@ -110,13 +128,43 @@ func Load(name string) (*Sound, error) {
 		volumeAdjust: config.VolumeAdjust,
 		loopStart:    config.LoopStart,
 		loopEnd:      config.LoopEnd,
+		priority:     config.Priority,
 	}
-	cache[name] = sound
 	return sound, nil
 }
 
+// Reload reloads all cached sound effects, e.g. after switching audio packs.
+// The Sound objects are updated in place, so existing references pick up the new data.
+func Reload() error {
+	// Prefetched sounds may come from the previous audio pack.
+	discardPrefetched()
+	names := make([]string, 0, len(cache))
+	for name := range cache {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sound, err := load(name)
+		if err != nil {
+			return fmt.Errorf("could not reload %v: %w", name, err)
+		}
+		s := cache[name]
+		s.sound = sound.sound
+		s.volumeAdjust = sound.volumeAdjust
+		s.loopStart = sound.loopStart
+		s.loopEnd = sound.loopEnd
+		s.priority = sound.priority
+	}
+	return nil
+}
+
 // PlayAtVolume plays the given sound effect at the given volume.
+// If too many sounds are already playing, less important ones are stopped;
+// if all of them are more important, this sound does not play.
 func (s *Sound) PlayAtVolume(vol float64) *audiowrap.Player {
+	if mute.Active() || !allocateVoice(s) {
+		return audiowrap.NoPlayer()
+	}
 	var player *audiowrap.Player
 	var err error
 	if s.loopStart >= 0 {
@@ -137,6 +185,7 @@ func (s *Sound) PlayAtVolume(vol float64) *audiowrap.Player {
 	}
 	player.SetVolume(s.volumeAdjust * *soundVolume * vol)
 	player.Play()
+	addVoice(s, player)
 	return player
 }
 
@@ -222,6 +271,59 @@ func (g *GroupedSound) IsPlayingNotForGameplay() bool {
 	return g.s.groupedPlayer.IsPlaying()
 }
 
+// Emitter is a looping sound whose volume can change while playing, such as a sound source in the map.
+// It only plays while its volume is above zero.
+type Emitter struct {
+	s           *Sound
+	player      *audiowrap.Player
+	dontGCState dontgc.State
+}
+
+// Looping returns whether the sound loops forever.
+func (s *Sound) Looping() bool {
+	return s.loopStart >= 0
+}
+
+// Emitter creates an emitter for the given sound effect. It starts silent.
+func (s *Sound) Emitter() *Emitter {
+	e := &Emitter{
+		s: s,
+	}
+	e.dontGCState = dontgc.SetUp(e)
+	return e
+}
+
+// CheckGC checks if GC is currently allowed. Emitters may be GC'd only while not playing.
+func (e *Emitter) CheckGC() dontgc.State {
+	if e.player == nil {
+		return nil
+	}
+	e.Close()
+	return e.dontGCState
+}
+
+// SetVolume changes the volume of the emitter, starting or stopping it as needed.
+func (e *Emitter) SetVolume(vol float64) {
+	if vol <= 0 {
+		e.Close()
+		return
+	}
+	if e.player == nil {
+		e.player = e.s.PlayAtVolume(vol)
+		return
+	}
+	e.player.SetVolume(e.s.volumeAdjust * *soundVolume * vol)
+}
+
+// Close stops the emitter.
+func (e *Emitter) Close() {
+	if e.player == nil {
+		return
+	}
+	e.player.Close()
+	e.player = nil
+}
+
 var (
 	soundsToPrecache []string
 )
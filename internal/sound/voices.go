@@ -0,0 +1,113 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sound
+
+import (
+	"github.com/divVerent/aaaaxy/internal/audiowrap"
+	"github.com/divVerent/aaaaxy/internal/flag"
+)
+
+var (
+	maxVoices         = flag.Int("max_voices", 32, "maximum number of sound effects playing at the same time; 0 means unlimited")
+	maxVoicesPerSound = flag.Int("max_voices_per_sound", 4, "maximum number of instances of the same sound effect playing at the same time; 0 means unlimited")
+)
+
+// voice is a playing instance of a sound effect.
+type voice struct {
+	sound  *Sound
+	player *audiowrap.Player
+}
+
+// voices are the currently playing sound effects, oldest first.
+var voices []voice
+
+// pruneVoices forgets about all voices that are no longer playing.
+func pruneVoices() {
+	n := 0
+	for _, v := range voices {
+		if v.player.IsPlaying() {
+			voices[n] = v
+			n++
+		}
+	}
+	for i := n; i < len(voices); i++ {
+		voices[i] = voice{}
+	}
+	voices = voices[:n]
+}
+
+// stealVoice stops the oldest voice that can be stolen and satisfies match.
+// Looping voices are never stolen, as their owners expect them to keep playing.
+// Returns whether a voice was stolen.
+func stealVoice(match func(v *voice) bool) bool {
+	for i := range voices {
+		v := &voices[i]
+		if v.sound.Looping() || !match(v) {
+			continue
+		}
+		v.player.CloseInstantly()
+		copy(voices[i:], voices[i+1:])
+		voices[len(voices)-1] = voice{}
+		voices = voices[:len(voices)-1]
+		return true
+	}
+	return false
+}
+
+// allocateVoice makes room for playing the given sound effect.
+// When too many instances of the same sound play, the oldest one is stopped.
+// When too many sounds play overall, the oldest of the least important ones is stopped,
+// but never one that is more important than the new sound.
+// Returns false if the sound should not be played.
+func allocateVoice(s *Sound) bool {
+	pruneVoices()
+	if *maxVoicesPerSound > 0 {
+		count := 0
+		for i := range voices {
+			if voices[i].sound == s {
+				count++
+			}
+		}
+		if count >= *maxVoicesPerSound && !stealVoice(func(v *voice) bool {
+			return v.sound == s
+		}) {
+			return false
+		}
+	}
+	if *maxVoices > 0 && len(voices) >= *maxVoices {
+		lowest := s.priority
+		found := false
+		for i := range voices {
+			v := &voices[i]
+			if v.sound.Looping() || v.sound.priority > lowest {
+				continue
+			}
+			lowest = v.sound.priority
+			found = true
+		}
+		if !found {
+			return false
+		}
+		stealVoice(func(v *voice) bool {
+			return v.sound.priority == lowest
+		})
+	}
+	return true
+}
+
+// addVoice records a newly started voice.
+func addVoice(s *Sound, player *audiowrap.Player) {
+	voices = append(voices, voice{sound: s, player: player})
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package splash
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrCancelled is returned by Async.Poll after Cancel stopped loading.
+var ErrCancelled = errors.New("loading cancelled")
+
+// Async runs a stepwise loading function in the background.
+type Async struct {
+	mu       sync.Mutex
+	stepName string
+	fraction float64
+	done     bool
+	err      error
+	cancel   bool
+}
+
+// RunAsync starts running f in a goroutine until it is done.
+// fractions are the known progress bar fractions of the steps of f.
+// f must not touch any state used by the main thread while it runs.
+func RunAsync(fractions map[string]float64, f func(s *State) (Status, error)) *Async {
+	a := &Async{}
+	s := &State{}
+	s.ProvideFractions(fractions)
+	go a.run(s, f)
+	return a
+}
+
+func (a *Async) run(s *State, f func(s *State) (Status, error)) {
+	for {
+		a.mu.Lock()
+		if a.cancel {
+			a.done, a.err = true, ErrCancelled
+		}
+		done := a.done
+		a.mu.Unlock()
+		if done {
+			return
+		}
+		status, err := f(s)
+		name, fraction := s.Current()
+		a.mu.Lock()
+		a.stepName, a.fraction = name, fraction
+		if err != nil {
+			a.done, a.err = true, err
+		} else if status == Continue {
+			a.done = true
+		}
+		a.mu.Unlock()
+	}
+}
+
+// Cancel stops loading before the next step.
+// The step running at the time keeps running; Poll reports when it has stopped.
+func (a *Async) Cancel() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cancel = true
+}
+
+// Poll returns whether loading is done, and the error if it failed.
+func (a *Async) Poll() (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.done, a.err
+}
+
+// Current returns the localized name of the current step and the progress bar fraction.
+func (a *Async) Current() (string, float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.stepName, a.fraction
+}
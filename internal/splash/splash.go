@@ -158,6 +158,26 @@ func (s *State) Current() (string, float64) {
 	return s.curStepName, s.curFraction
 }
 
+// Progress updates the progress bar from a nested loading process, e.g. an Async.
+// The fraction never goes backwards.
+func (s *State) Progress(stepName string, fraction float64) {
+	if s == nil {
+		return
+	}
+	if stepName != "" {
+		s.curStepName = stepName
+	}
+	if fraction > s.curFraction {
+		s.curFraction = fraction
+	}
+}
+
+// Fractions returns the known progress bar fractions.
+// The map must not be modified.
+func (s *State) Fractions() map[string]float64 {
+	return s.knownFractions
+}
+
 // ToFractions returns the init fraction map by step. This can be provided via ProvideFractions next time.
 func (s *State) ToFractions() map[string]float64 {
 	ended := time.Now()
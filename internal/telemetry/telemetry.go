@@ -0,0 +1,299 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry collects anonymous aggregate statistics to guide difficulty and performance tuning.
+// Nothing is collected or sent unless the player opts in.
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/version"
+)
+
+var (
+	telemetry         = flag.Bool("telemetry", false, "send anonymous aggregate statistics (frame times, resolution, input devices, crash counts, where players die or quit) to telemetry_endpoint")
+	telemetryEndpoint = flag.String("telemetry_endpoint", "", "URL to POST anonymous statistics to")
+	telemetryInterval = flag.Duration("telemetry_interval", 10*time.Minute, "how often to send anonymous statistics")
+)
+
+const (
+	// frameTimeBucket is the resolution of the frame time histogram.
+	frameTimeBucket = 500 * time.Microsecond
+	// frameTimeBuckets is the number of histogram buckets; longer frames go into the last one.
+	frameTimeBuckets = 200
+	// sendTimeout limits how long a single batch may take to send.
+	sendTimeout = 10 * time.Second
+)
+
+// Batch is the data sent to the endpoint.
+// It must not contain anything that could identify a player or a machine.
+type Batch struct {
+	Version     string                    `json:"version"`
+	Platform    string                    `json:"platform"`
+	Duration    float64                   `json:"duration_seconds"`
+	Frames      int                       `json:"frames"`
+	FrameTimeMS map[string]float64        `json:"frame_time_ms,omitempty"`
+	Resolutions map[string]int            `json:"resolutions,omitempty"`
+	InputFrames map[string]int            `json:"input_frames,omitempty"`
+	Crashes     int                       `json:"crashes,omitempty"`
+	Deaths      map[string]map[string]int `json:"deaths,omitempty"`
+	Quits       map[string]map[string]int `json:"quits,omitempty"`
+}
+
+var (
+	mu          sync.Mutex
+	started     bool
+	batchStart  time.Time
+	frameTimes  [frameTimeBuckets]int
+	frames      int
+	resolution  [2]int
+	resolutions map[[2]int]int
+	inputFrames map[string]int
+	crashes     int
+	deaths      map[string]map[string]int
+	quits       map[string]map[string]int
+	pending     chan *Batch
+	done        chan struct{}
+)
+
+// Enabled returns whether statistics get collected.
+func Enabled() bool {
+	return *telemetry && *telemetryEndpoint != "" && !demo.Playing()
+}
+
+// start initializes collection on first use.
+// Must be called with mu held.
+func start() {
+	if started {
+		return
+	}
+	started = true
+	reset()
+	pending = make(chan *Batch, 1)
+	done = make(chan struct{})
+	go run(pending, done)
+}
+
+// reset clears all aggregated data.
+// Must be called with mu held.
+func reset() {
+	batchStart = time.Now()
+	frameTimes = [frameTimeBuckets]int{}
+	frames = 0
+	resolutions = map[[2]int]int{}
+	inputFrames = map[string]int{}
+	crashes = 0
+	deaths = map[string]map[string]int{}
+	quits = map[string]map[string]int{}
+}
+
+// record runs f with the collected data locked, and queues a batch if one is due.
+func record(f func()) {
+	if !Enabled() {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	start()
+	f()
+	if time.Since(batchStart) < *telemetryInterval {
+		return
+	}
+	b := makeBatch()
+	reset()
+	// Replace any batch not sent yet; losing some data is fine.
+	select {
+	case <-pending:
+	default:
+	}
+	pending <- b
+}
+
+// RecordFrame records the time a rendered frame took.
+func RecordFrame(d time.Duration) {
+	record(func() {
+		i := int(d / frameTimeBucket)
+		if i >= frameTimeBuckets {
+			i = frameTimeBuckets - 1
+		}
+		if i < 0 {
+			i = 0
+		}
+		frameTimes[i]++
+		frames++
+		resolutions[resolution]++
+	})
+}
+
+// SetResolution sets the size of the game window or screen subsequent frames are recorded with.
+func SetResolution(w, h int) {
+	if !Enabled() {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	resolution = [2]int{w, h}
+}
+
+// RecordInput records which kinds of input devices were used in a frame.
+func RecordInput(m input.InputMap) {
+	if m == input.NoInput {
+		return
+	}
+	record(func() {
+		if m.ContainsAny(input.AnyKeyboard) {
+			inputFrames["keyboard"]++
+		}
+		if m.ContainsAny(input.Gamepad) {
+			inputFrames["gamepad"]++
+		}
+		if m.ContainsAny(input.Touchscreen) {
+			inputFrames["touchscreen"]++
+		}
+	})
+}
+
+// RecordCrash records that the game crashed.
+func RecordCrash() {
+	record(func() {
+		crashes++
+	})
+}
+
+// RecordDeath records that the player died after the given checkpoint.
+func RecordDeath(mapName, checkpoint string) {
+	record(func() {
+		count(deaths, mapName, checkpoint)
+	})
+}
+
+// RecordQuit records that the player quit after the given checkpoint.
+func RecordQuit(mapName, checkpoint string) {
+	record(func() {
+		count(quits, mapName, checkpoint)
+	})
+}
+
+func count(counts map[string]map[string]int, mapName, checkpoint string) {
+	perMap := counts[mapName]
+	if perMap == nil {
+		perMap = map[string]int{}
+		counts[mapName] = perMap
+	}
+	perMap[checkpoint]++
+}
+
+// makeBatch creates a batch from the data aggregated so far.
+// Must be called with mu held.
+func makeBatch() *Batch {
+	b := &Batch{
+		Version:     version.Revision(),
+		Platform:    runtime.GOOS + "/" + runtime.GOARCH,
+		Duration:    time.Since(batchStart).Seconds(),
+		Frames:      frames,
+		Resolutions: map[string]int{},
+		InputFrames: inputFrames,
+		Crashes:     crashes,
+		Deaths:      deaths,
+		Quits:       quits,
+	}
+	for r, n := range resolutions {
+		b.Resolutions[fmt.Sprintf("%dx%d", r[0], r[1])] = n
+	}
+	if frames > 0 {
+		b.FrameTimeMS = map[string]float64{}
+		for _, p := range []int{50, 90, 95, 99} {
+			b.FrameTimeMS[fmt.Sprintf("p%d", p)] = percentile(p)
+		}
+	}
+	return b
+}
+
+// percentile returns the upper bound of the histogram bucket containing the given percentile, in milliseconds.
+// Must be called with mu held.
+func percentile(p int) float64 {
+	need := (frames*p + 99) / 100
+	sum := 0
+	for i, n := range frameTimes {
+		sum += n
+		if sum >= need {
+			return (time.Duration(i+1) * frameTimeBucket).Seconds() * 1000
+		}
+	}
+	return (frameTimeBuckets * frameTimeBucket).Seconds() * 1000
+}
+
+// run sends queued batches until the channel is closed.
+func run(batches <-chan *Batch, done chan<- struct{}) {
+	defer close(done)
+	for b := range batches {
+		err := send(b)
+		if err != nil {
+			log.Infof("could not send telemetry: %v", err)
+		}
+	}
+}
+
+// send posts a batch to the endpoint.
+func send(b *Batch) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: sendTimeout}
+	resp, err := client.Post(*telemetryEndpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected HTTP status %v", resp.Status)
+	}
+	return nil
+}
+
+// Finish sends the remaining data and waits for it to be delivered.
+func Finish() {
+	mu.Lock()
+	if !started {
+		mu.Unlock()
+		return
+	}
+	b := makeBatch()
+	reset()
+	select {
+	case <-pending:
+	default:
+	}
+	pending <- b
+	close(pending)
+	started = false
+	mu.Unlock()
+	select {
+	case <-done:
+	case <-time.After(sendTimeout):
+		log.Infof("could not send telemetry: timed out")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timing
+
+import (
+	"sort"
+	"time"
+)
+
+// HistoryLength is the number of frames kept by KeepHistory.
+const HistoryLength = 128
+
+type history struct {
+	samples [HistoryLength]time.Duration
+}
+
+var (
+	keepHistory bool
+	histories   = map[string]*history{}
+	historyPos  int
+)
+
+// KeepHistory sets whether the per-frame time of every section is recorded for the last HistoryLength frames.
+func KeepHistory(keep bool) {
+	keepHistory = keep
+}
+
+func recordHistory() {
+	historyPos = (historyPos + 1) % HistoryLength
+	for section, entry := range accumulator {
+		h := histories[section]
+		if h == nil {
+			h = &history{}
+			histories[section] = h
+		}
+		if entry.touchedThisFrame {
+			h.samples[historyPos] = entry.thisFrame
+		} else {
+			h.samples[historyPos] = 0
+		}
+	}
+	for section, h := range histories {
+		if _, found := accumulator[section]; !found {
+			h.samples[historyPos] = 0
+		}
+	}
+}
+
+// HistorySections returns the names of all sections that have a history, sorted.
+func HistorySections() []string {
+	sections := make([]string, 0, len(histories))
+	for section := range histories {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	return sections
+}
+
+// History returns the time spent in the given section for each of the last
+// HistoryLength frames, oldest first.
+func History(section string, out []time.Duration) []time.Duration {
+	out = out[:0]
+	h := histories[section]
+	if h == nil {
+		return out
+	}
+	for i := 1; i <= HistoryLength; i++ {
+		out = append(out, h.samples[(historyPos+i)%HistoryLength])
+	}
+	return out
+}
@@ -15,7 +15,9 @@
 package timing
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 	"time"
@@ -70,6 +72,9 @@ var (
 	stack       []node
 	nextReport  time.Time
 	prevFrame   time.Time
+
+	// benchmarking keeps profiling enabled and accumulates over the whole run.
+	benchmarking bool
 )
 
 func restartProfiling() {
@@ -144,14 +149,30 @@ func Update() {
 		}
 	}
 	prevFrame = now
-	if *debugProfiling != 0 && stack == nil {
+	if benchmarking {
+		accumulateFrame()
+		return
+	}
+	if (*debugProfiling != 0 || keepHistory) && stack == nil {
 		restartProfiling()
 		return
 	}
-	if *debugProfiling == 0 {
+	if *debugProfiling == 0 && !keepHistory {
 		stopProfiling()
 		return
 	}
+	accumulateFrame()
+	if *debugProfiling != 0 && now.After(nextReport) {
+		PrintReport()
+		nextReport = now.Add(*debugProfiling)
+		restartProfiling()
+	}
+}
+
+func accumulateFrame() {
+	if keepHistory {
+		recordHistory()
+	}
 	for _, entry := range accumulator {
 		if !entry.touchedThisFrame {
 			continue
@@ -164,11 +185,6 @@ func Update() {
 		entry.thisFrame = 0
 		entry.touchedThisFrame = false
 	}
-	if now.After(nextReport) {
-		PrintReport()
-		nextReport = now.Add(*debugProfiling)
-		restartProfiling()
-	}
 }
 
 func PrintReport() {
@@ -187,3 +203,34 @@ func PrintReport() {
 		log.Infof("timing report:\n%v", strings.Join(report, "\n"))
 	}
 }
+
+// StartBenchmark enables profiling for the rest of the run, regardless of flags.
+// Timing data is accumulated until WriteReportJSON is called.
+func StartBenchmark() {
+	benchmarking = true
+	restartProfiling()
+}
+
+// ReportEntry is the timing data of a single section in a JSON report.
+type ReportEntry struct {
+	TotalNs      int64 `json:"total_ns"`
+	Calls        int   `json:"calls"`
+	Frames       int   `json:"frames"`
+	WorstFrameNs int64 `json:"worst_frame_ns"`
+}
+
+// WriteReportJSON writes the accumulated timing data in JSON format, keyed by section.
+func WriteReportJSON(w io.Writer) error {
+	report := make(map[string]ReportEntry, len(accumulator))
+	for section, entry := range accumulator {
+		report[section] = ReportEntry{
+			TotalNs:      entry.total.Nanoseconds(),
+			Calls:        entry.count,
+			Frames:       entry.frames,
+			WorstFrameNs: entry.worstFrame.Nanoseconds(),
+		}
+	}
+	j := json.NewEncoder(w)
+	j.SetIndent("", "\t")
+	return j.Encode(report)
+}
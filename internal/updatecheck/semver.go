@@ -0,0 +1,90 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package updatecheck
+
+import (
+	"fmt"
+	"strings"
+)
+
+// semver is a parsed version number as produced by scripts/version.sh.
+type semver struct {
+	major, minor, patch int
+	// pre is the prerelease rank: 0 for alpha, 1 for beta, 2 for rc, 3 for a release.
+	pre    int
+	preNum int
+}
+
+// prereleaseRanks maps prerelease names to their rank.
+var prereleaseRanks = map[string]int{
+	"alpha": 0,
+	"beta":  1,
+	"rc":    2,
+}
+
+// parseSemver parses versions like 1.4.123+20230115.4567.abcdef,
+// 1.4.0-rc.5+20230115.4567.abcdef or the corresponding tags like v1.4.123 or v1.4.0-rc.5.
+func parseSemver(s string) (semver, error) {
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, hasPre := strings.Cut(s, "-")
+	var v semver
+	_, err := fmt.Sscanf(s, "%d.%d.%d", &v.major, &v.minor, &v.patch)
+	if err != nil {
+		return semver{}, fmt.Errorf("invalid version %q: %w", s, err)
+	}
+	v.pre = len(prereleaseRanks)
+	if hasPre {
+		name, num, _ := strings.Cut(pre, ".")
+		rank, found := prereleaseRanks[name]
+		if !found {
+			return semver{}, fmt.Errorf("invalid prerelease %q", pre)
+		}
+		v.pre = rank
+		if num != "" {
+			_, err := fmt.Sscanf(num, "%d", &v.preNum)
+			if err != nil {
+				return semver{}, fmt.Errorf("invalid prerelease number %q: %w", num, err)
+			}
+		}
+	}
+	return v, nil
+}
+
+// less returns whether v is an older version than w.
+func (v semver) less(w semver) bool {
+	a := [...]int{v.major, v.minor, v.patch, v.pre, v.preNum}
+	b := [...]int{w.major, w.minor, w.patch, w.pre, w.preNum}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// isNewer returns whether the release is newer than the running version.
+// Unparseable versions, e.g. of development builds, never count as newer.
+func isNewer(release, running string) bool {
+	r, err := parseSemver(release)
+	if err != nil {
+		return false
+	}
+	cur, err := parseSemver(running)
+	if err != nil {
+		return false
+	}
+	return cur.less(r)
+}
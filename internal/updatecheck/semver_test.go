@@ -0,0 +1,43 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package updatecheck
+
+import (
+	"testing"
+)
+
+func TestIsNewer(t *testing.T) {
+	for _, tc := range []struct {
+		release, running string
+		want             bool
+	}{
+		{"v1.4.124", "1.4.123+20230115.4567.abcdef", true},
+		{"v1.4.123", "1.4.123+20230115.4567.abcdef", false},
+		{"v1.4.122", "1.4.123+20230115.4567.abcdef", false},
+		{"v1.5.0", "1.4.123+20230115.4567.abcdef", true},
+		{"v2.0.0", "1.99.999+20230115.4567.abcdef", true},
+		{"v1.5.0-rc.2", "1.5.0-rc.1+20230115.4567.abcdef", true},
+		{"v1.5.0-rc.1", "1.5.0-beta.7+20230115.4567.abcdef", true},
+		{"v1.5.0", "1.5.0-rc.3+20230115.4567.abcdef", true},
+		{"v1.5.0-rc.3", "1.5.0+20230115.4567.abcdef", false},
+		{"v1.5.0", "unknown", false},
+		{"latest", "1.4.123+20230115.4567.abcdef", false},
+		{"v1.5.0-gamma.1", "1.4.123+20230115.4567.abcdef", false},
+	} {
+		if got := isNewer(tc.release, tc.running); got != tc.want {
+			t.Errorf("isNewer(%q, %q) = %v, want %v", tc.release, tc.running, got, tc.want)
+		}
+	}
+}
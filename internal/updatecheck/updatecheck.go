@@ -0,0 +1,169 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package updatecheck looks up whether a newer release of the game is available.
+// Nothing is fetched unless the player opts in, and updates are never downloaded.
+package updatecheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/version"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	updateCheck    = flag.Bool("update_check", false, "check at most once a day whether a newer release is available, and show a notice with its release notes in the main menu; updates are never downloaded")
+	updateCheckURL = flag.String("update_check_url", "https://api.github.com/repos/divVerent/aaaaxy/releases/latest", "URL of a JSON document describing the latest release, with tag_name, body and html_url fields like the GitHub releases API")
+)
+
+const (
+	// checkInterval is how long a fetched release stays valid.
+	checkInterval = 24 * time.Hour
+	// fetchTimeout limits how long fetching the release may take.
+	fetchTimeout = 10 * time.Second
+	// maxResponseSize limits how much of the response is read.
+	maxResponseSize = 1 << 20
+	// cacheName is the name of the cache file remembering the last check.
+	cacheName = "update_check.json"
+)
+
+// Release describes a released version of the game.
+type Release struct {
+	Version string `json:"tag_name"`
+	Notes   string `json:"body"`
+	URL     string `json:"html_url"`
+}
+
+// checkCache remembers the result of the last check.
+type checkCache struct {
+	URL     string    `json:"url"`
+	Checked time.Time `json:"checked"`
+	Latest  Release   `json:"latest"`
+}
+
+var (
+	mu      sync.Mutex
+	started bool
+	newer   *Release
+)
+
+// Enabled returns whether update checks are done.
+func Enabled() bool {
+	return *updateCheck && *updateCheckURL != "" && !demo.Playing()
+}
+
+// Start checks for a newer release in the background, if enabled.
+func Start() {
+	if !Enabled() {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if started {
+		return
+	}
+	started = true
+	go run()
+}
+
+// Available returns the newer release, if one was found.
+func Available() (*Release, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	return newer, newer != nil
+}
+
+func run() {
+	r, err := latestRelease()
+	if err != nil {
+		log.Infof("could not check for updates: %v", err)
+		return
+	}
+	if !isNewer(r.Version, version.Revision()) {
+		log.Infof("no update available: latest release is %v", r.Version)
+		return
+	}
+	log.Infof("update available: %v", r.Version)
+	mu.Lock()
+	defer mu.Unlock()
+	newer = r
+}
+
+// latestRelease returns the latest release, fetching it at most once per checkInterval.
+func latestRelease() (*Release, error) {
+	var c checkCache
+	data, err := vfs.ReadState(vfs.Cache, cacheName)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+		if err != nil {
+			log.Warningf("could not parse update check cache: %v", err)
+		}
+	}
+	if err == nil && c.URL == *updateCheckURL {
+		age := time.Since(c.Checked)
+		if age >= 0 && age < checkInterval {
+			return &c.Latest, nil
+		}
+	}
+	r, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	c = checkCache{
+		URL:     *updateCheckURL,
+		Checked: time.Now(),
+		Latest:  *r,
+	}
+	data, err = json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	err = vfs.WriteState(vfs.Cache, cacheName, data)
+	if err != nil {
+		// Not fatal; we will just check again next time.
+		log.Warningf("could not save update check cache: %v", err)
+	}
+	return r, nil
+}
+
+// fetch downloads the description of the latest release.
+func fetch() (*Release, error) {
+	client := http.Client{Timeout: fetchTimeout}
+	resp, err := client.Get(*updateCheckURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("unexpected HTTP status %v", resp.Status)
+	}
+	var r Release
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&r)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse release: %w", err)
+	}
+	if r.Version == "" {
+		return nil, fmt.Errorf("release has no version")
+	}
+	return &r, nil
+}
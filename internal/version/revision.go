@@ -37,6 +37,7 @@ func Init() error {
 		return err
 	}
 	revision = strings.TrimSpace(string(revStr))
+	vfs.SetCacheSalt(revision)
 	log.Infof("AAAAXY %v", revision)
 	return nil
 }
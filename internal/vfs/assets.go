@@ -31,6 +31,7 @@ type ReadSeekCloser interface {
 }
 
 func LoadPath(purpose, name string) (ReadSeekCloser, error) {
+	name = strings.ReplaceAll(name, `\`, "/")
 	override := path.Base(path.Dir(name))
 	if override != "" && override != "." {
 		purpose = override
@@ -40,7 +41,7 @@ func LoadPath(purpose, name string) (ReadSeekCloser, error) {
 }
 
 func Load(purpose, name string) (ReadSeekCloser, error) {
-	if strings.ContainsRune(name, '/') {
+	if strings.ContainsAny(name, `/\`) {
 		log.Fatalf("noncanonical path: %v %v", purpose, name)
 	}
 	vfsPath := fmt.Sprintf("/%s/%s", purpose, name)
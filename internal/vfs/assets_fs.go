@@ -23,6 +23,7 @@ import (
 	"path"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/divVerent/aaaaxy/internal/exitstatus"
 	"github.com/divVerent/aaaaxy/internal/flag"
@@ -39,6 +40,9 @@ type fsRoot struct {
 	filesys  fs.FS
 	root     string
 	toPrefix string
+	skip     map[string]struct{} // Normalized relative paths to ignore in this root.
+	files    map[string]string   // Normalized relative path to actual relative path of each file.
+	dirs     map[string]string   // Normalized relative path to actual relative path of each directory.
 }
 
 func (f fsRoot) String() string {
@@ -46,9 +50,25 @@ func (f fsRoot) String() string {
 }
 
 var (
-	assetDirs []fsRoot
+	assetDirs     []fsRoot
+	assetDirsMu   sync.RWMutex // Protects assetDirs, as assets may be loaded from background goroutines.
+	baseAssetDirs []fsRoot     // Asset dirs without any audio pack.
 )
 
+// currentAssetDirs returns the asset dirs in use.
+func currentAssetDirs() []fsRoot {
+	assetDirsMu.RLock()
+	defer assetDirsMu.RUnlock()
+	return assetDirs
+}
+
+// setAssetDirs replaces the asset dirs in use.
+func setAssetDirs(dirs []fsRoot) {
+	assetDirsMu.Lock()
+	defer assetDirsMu.Unlock()
+	assetDirs = dirs
+}
+
 func dumpAssetsFrom(dir fsRoot) error {
 	return fs.WalkDir(dir.filesys, dir.root, func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -114,6 +134,21 @@ func initAssets() error {
 		}
 	}
 
+	for i := range assetDirs {
+		err := indexRoot(&assetDirs[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	baseAssetDirs = assetDirs
+	if *audioPack != "" {
+		err := SetAudioPack(*audioPack)
+		if err != nil {
+			log.Errorf("could not use audio pack %q, using built-in audio: %v", *audioPack, err)
+		}
+	}
+
 	log.Infof("asset search path: %v", assetDirs)
 
 	if *dumpEmbeddedAssets != "" {
@@ -125,19 +160,35 @@ func initAssets() error {
 		return exitstatus.ErrRegularTermination
 	}
 
+	if *cheatReplaceEmbeddedAssets == "" {
+		err := verifyManifest()
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // load loads a file from the VFS.
 func load(vfsPath string) (ReadSeekCloser, error) {
-	var err error
-	for _, dir := range assetDirs {
-		if !strings.HasPrefix(vfsPath, dir.toPrefix) {
+	return loadFrom(currentAssetDirs(), vfsPath)
+}
+
+// loadFrom loads a file from the given asset dirs.
+func loadFrom(dirs []fsRoot, vfsPath string) (ReadSeekCloser, error) {
+	err := error(os.ErrNotExist)
+	key := normalizePath(vfsPath)
+	for _, dir := range dirs {
+		if !strings.HasPrefix(key, dir.toPrefix) {
+			continue
+		}
+		relPath := vfsPath[len(dir.toPrefix):]
+		if _, found := dir.skip[key[len(dir.toPrefix):]]; found {
 			continue
 		}
-		relPath := strings.TrimPrefix(vfsPath, dir.toPrefix)
 		var f fs.File
-		f, err = dir.filesys.Open(path.Join(dir.root, relPath))
+		f, err = dir.filesys.Open(path.Join(dir.root, resolvePath(dir.files, relPath)))
 		if err != nil {
 			continue
 		}
@@ -157,12 +208,14 @@ func load(vfsPath string) (ReadSeekCloser, error) {
 // readDir lists all files in a directory. Returns their VFS names, NOT full paths!
 func readDir(vfsPath string) ([]string, error) {
 	var results []string
-	for _, dir := range assetDirs {
-		if !strings.HasPrefix(vfsPath, dir.toPrefix) {
+	seen := map[string]struct{}{}
+	key := normalizePath(vfsPath)
+	for _, dir := range currentAssetDirs() {
+		if !strings.HasPrefix(key, dir.toPrefix) {
 			continue
 		}
-		relPath := strings.TrimPrefix(vfsPath, dir.toPrefix)
-		content, err := fs.ReadDir(dir.filesys, path.Join(dir.root, relPath))
+		relPath := strings.TrimSuffix(vfsPath[len(dir.toPrefix):], "/")
+		content, err := fs.ReadDir(dir.filesys, path.Join(dir.root, resolvePath(dir.dirs, relPath)))
 		if err != nil {
 			if !errors.Is(err, os.ErrNotExist) {
 				return nil, fmt.Errorf("could not scan %v in %v: %v", vfsPath, dir, err)
@@ -173,6 +226,12 @@ func readDir(vfsPath string) ([]string, error) {
 			if info.IsDir() {
 				continue
 			}
+			// Files can be in multiple dirs if overridden, possibly with different case.
+			key := normalizePath(info.Name())
+			if _, found := seen[key]; found {
+				continue
+			}
+			seen[key] = struct{}{}
 			results = append(results, info.Name())
 		}
 	}
@@ -26,6 +26,10 @@ import (
 	"github.com/divVerent/aaaaxy/third_party"
 )
 
+// localAssets is set if assets are loaded from the source tree.
+// Embedded assets must match the manifest.
+const localAssets = false
+
 // initAssetsFS opens the embedded file systems.
 func initAssetsFS() ([]fsRoot, error) {
 	dirs := []fsRoot{
@@ -23,6 +23,10 @@ import (
 	"path/filepath"
 )
 
+// localAssets is set if assets are loaded from the source tree.
+// Local assets may be edited, so they may not match the manifest.
+const localAssets = true
+
 // initAssets initializes the VFS.
 func initAssetsFS() ([]fsRoot, error) {
 	dirs := []fsRoot{
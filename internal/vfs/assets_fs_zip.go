@@ -28,6 +28,10 @@ import (
 	"github.com/divVerent/aaaaxy/internal/log"
 )
 
+// localAssets is set if assets are loaded from the source tree.
+// Assets from a zip file must match the manifest.
+const localAssets = false
+
 var (
 	pinAssetsToRAM = flag.Bool("pin_assets_to_ram", false, "if enabled, keep all asset data in RAM in compressed form rather than loading from the file system as needed")
 )
@@ -0,0 +1,178 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+var (
+	audioPacksDir = flag.String("audio_packs_dir", "audiopacks", "directory next to the executable containing audio packs; each subdirectory can override files in sounds/ and music/")
+	audioPack     = flag.String("audio_pack", "", "name of the audio pack to use; empty means the built-in sounds and music")
+)
+
+// audioPackPurposes are the asset directories an audio pack may override.
+var audioPackPurposes = []string{"sounds", "music"}
+
+// AudioPacks lists the names of all available audio packs.
+func AudioPacks() ([]string, error) {
+	content, err := os.ReadDir(osResolve(ExeDir, *audioPacksDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not list audio packs: %w", err)
+	}
+	var packs []string
+	for _, info := range content {
+		if !info.IsDir() {
+			continue
+		}
+		packs = append(packs, info.Name())
+	}
+	sort.Strings(packs)
+	return packs, nil
+}
+
+// AudioPack returns the name of the audio pack in use, or an empty string for none.
+func AudioPack() string {
+	return *audioPack
+}
+
+// SetAudioPack switches to the given audio pack. An empty string switches back to the built-in audio.
+//
+// Replacement files whose channel count or sample rate differ from the file
+// they replace are rejected, as loop points in the json metadata are given in
+// samples and would no longer match.
+//
+// Sounds and music that have already been loaded need to be reloaded by the caller.
+func SetAudioPack(name string) error {
+	if name == "" {
+		setAssetDirs(baseAssetDirs)
+		*audioPack = ""
+		return nil
+	}
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("invalid audio pack name %q", name)
+	}
+	dir := osResolve(ExeDir, filepath.Join(*audioPacksDir, name))
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("could not find audio pack %q: %w", name, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("audio pack %q is not a directory", name)
+	}
+	filesys := os.DirFS(dir)
+	var packDirs []fsRoot
+	for _, purpose := range audioPackPurposes {
+		content, err := fs.ReadDir(filesys, purpose)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("could not scan %v in audio pack %q: %w", purpose, name, err)
+		}
+		root := fsRoot{
+			name:     "audiopack:" + name,
+			filesys:  filesys,
+			root:     purpose,
+			toPrefix: "/" + purpose + "/",
+			skip:     map[string]struct{}{},
+		}
+		err = indexRoot(&root)
+		if err != nil {
+			return fmt.Errorf("could not scan audio pack %q: %w", name, err)
+		}
+		for _, f := range content {
+			if f.IsDir() || !strings.HasSuffix(normalizePath(f.Name()), ".ogg") {
+				continue
+			}
+			err := validateOverride(root, f.Name())
+			if err != nil {
+				log.Warningf("audio pack %q: ignoring %v/%v: %v", name, purpose, f.Name(), err)
+				root.skip[normalizePath(f.Name())] = struct{}{}
+			}
+		}
+		packDirs = append(packDirs, root)
+	}
+	dirs := append(packDirs, baseAssetDirs...)
+	setAssetDirs(dirs)
+	*audioPack = name
+	log.Infof("asset search path: %v", dirs)
+	return nil
+}
+
+// validateOverride checks that a file in an audio pack can replace the built-in file of the same name.
+func validateOverride(root fsRoot, name string) error {
+	f, err := root.filesys.Open(path.Join(root.root, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	channels, rate, err := oggVorbisInfo(f)
+	if err != nil {
+		return err
+	}
+	base, err := loadFrom(baseAssetDirs, root.toPrefix+name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// Not a replacement, but an addition.
+			return nil
+		}
+		return err
+	}
+	defer base.Close()
+	baseChannels, baseRate, err := oggVorbisInfo(base)
+	if err != nil {
+		return fmt.Errorf("could not read built-in file: %w", err)
+	}
+	if channels != baseChannels || rate != baseRate {
+		return fmt.Errorf("got %d channels at %d Hz, want %d channels at %d Hz", channels, rate, baseChannels, baseRate)
+	}
+	return nil
+}
+
+// oggVorbisInfo returns channel count and sample rate of an Ogg Vorbis stream.
+func oggVorbisInfo(r io.Reader) (channels, rate int, err error) {
+	// The identification header is the first packet, so it is within the first page.
+	var buf [512]byte
+	n, err := io.ReadFull(r, buf[:])
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return 0, 0, fmt.Errorf("could not read Ogg Vorbis header: %w", err)
+	}
+	i := bytes.Index(buf[:n], []byte("\x01vorbis"))
+	if i < 0 || n < i+16 {
+		return 0, 0, errors.New("not an Ogg Vorbis file")
+	}
+	header := buf[i+7 : i+16]
+	if version := binary.LittleEndian.Uint32(header[0:]); version != 0 {
+		return 0, 0, fmt.Errorf("unsupported Vorbis version %d", version)
+	}
+	return int(header[4]), int(binary.LittleEndian.Uint32(header[5:])), nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+var (
+	diskCache = flag.Bool("disk_cache", true, "cache expensive precomputation results (level hash, checkpoint locations, palette LUTs, font glyphs) on disk")
+)
+
+var cacheSalt string
+
+// SetCacheSalt sets a string that is mixed into every cache key.
+//
+// This should be set to the game version, so that a new build never uses
+// cache entries computed by a different build. Until it is set, the cache is
+// disabled.
+func SetCacheSalt(salt string) {
+	cacheSalt = salt
+}
+
+// CacheKey computes a cache key from the given content.
+func CacheKey(parts ...[]byte) uint64 {
+	h := fnv.New64a()
+	var n [8]byte
+	binary.LittleEndian.PutUint64(n[:], uint64(len(cacheSalt)))
+	h.Write(n[:])
+	h.Write([]byte(cacheSalt))
+	for _, p := range parts {
+		// Length prefix so that moving bytes between parts changes the key.
+		binary.LittleEndian.PutUint64(n[:], uint64(len(p)))
+		h.Write(n[:])
+		h.Write(p)
+	}
+	return h.Sum64()
+}
+
+func cacheName(name string, key uint64) string {
+	return fmt.Sprintf("%s-%016x", name, key)
+}
+
+// isCacheName returns whether file is a cache entry for name with any key.
+func isCacheName(file, name string) bool {
+	hex, found := strings.CutPrefix(file, name+"-")
+	if !found || len(hex) != 16 {
+		return false
+	}
+	_, err := strconv.ParseUint(hex, 16, 64)
+	return err == nil
+}
+
+// removeStaleCache deletes all entries for the given name except the one with the given key.
+//
+// Without this, every new game version or asset change would leave another
+// copy of each entry behind.
+func removeStaleCache(name string, key uint64) {
+	files, err := readStateDir(Cache, ".")
+	if err != nil {
+		log.Warningf("could not list cache entries: %v", err)
+		return
+	}
+	keep := cacheName(name, key)
+	for _, file := range files {
+		if file == keep || !isCacheName(file, name) {
+			continue
+		}
+		err := removeState(Cache, file)
+		if err != nil {
+			log.Warningf("could not remove stale cache entry %v: %v", file, err)
+		}
+	}
+}
+
+// ReadCache returns the cache entry for the given name and key, if any.
+func ReadCache(name string, key uint64) ([]byte, bool) {
+	if !*diskCache || !cacheSupported || cacheSalt == "" {
+		return nil, false
+	}
+	data, err := readState(Cache, cacheName(name, key))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warningf("could not read cache entry %v: %v", cacheName(name, key), err)
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+// WriteCache writes the cache entry for the given name and key.
+//
+// Failure to write is not an error, as the cache is purely an optimization.
+func WriteCache(name string, key uint64, data []byte) {
+	if !*diskCache || !cacheSupported || cacheSalt == "" {
+		return
+	}
+	if crashOnWrite != nil || *readonly {
+		// Keep the system untouched.
+		return
+	}
+	err := writeState(Cache, cacheName(name, key), data)
+	if err != nil {
+		log.Warningf("could not write cache entry %v: %v", cacheName(name, key), err)
+		return
+	}
+	removeStaleCache(name, key)
+}
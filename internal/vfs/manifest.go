@@ -0,0 +1,202 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+var (
+	verifyAssets = flag.Bool("verify_assets", false, "verify the SHA-256 checksums of all assets against the manifest at startup; without this, only presence and sizes are checked")
+)
+
+// ManifestPath is the VFS path of the asset manifest.
+const ManifestPath = "/generated/manifest.json"
+
+// ManifestEntry describes a single asset.
+type ManifestEntry struct {
+	Size   int64
+	SHA256 string
+}
+
+// Manifest maps the VFS path of every asset to its size and checksum.
+type Manifest map[string]ManifestEntry
+
+// digest is the checksum of the manifest in use.
+var digest string
+
+// Digest returns a checksum identifying the set of assets the game runs with.
+// Returns an empty string if the assets come without a manifest, e.g. in a source checkout before generating assets.
+// Audio packs are not included.
+func Digest() string {
+	return digest
+}
+
+// hashFile returns the size and SHA-256 of a file.
+func hashFile(r io.Reader) (ManifestEntry, error) {
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	return ManifestEntry{Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// ComputeManifest computes the manifest of the current assets.
+// Where a file exists in multiple asset dirs, the one that would be loaded is used.
+// The manifest itself is not included.
+func ComputeManifest() (Manifest, error) {
+	manifest := Manifest{}
+	for _, dir := range baseAssetDirs {
+		err := fs.WalkDir(dir.filesys, dir.root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			relPath := p
+			if strings.HasPrefix(p, dir.root+"/") {
+				relPath = p[len(dir.root)+1:]
+			}
+			vfsPath := normalizePath(dir.toPrefix + relPath)
+			if vfsPath == ManifestPath {
+				return nil
+			}
+			if _, found := manifest[vfsPath]; found {
+				// Overridden by an earlier dir.
+				return nil
+			}
+			f, err := dir.filesys.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			entry, err := hashFile(f)
+			if err != nil {
+				return fmt.Errorf("could not hash %v: %w", vfsPath, err)
+			}
+			manifest[vfsPath] = entry
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not scan %v: %w", dir, err)
+		}
+	}
+	return manifest, nil
+}
+
+// checkManifestEntry checks a single asset against the manifest.
+func checkManifestEntry(vfsPath string, want ManifestEntry) error {
+	f, err := loadFrom(baseAssetDirs, vfsPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if !*verifyAssets {
+		size, err := f.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+		if size != want.Size {
+			return fmt.Errorf("got size %d, want %d", size, want.Size)
+		}
+		return nil
+	}
+	got, err := hashFile(f)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("got size %d and SHA-256 %v, want size %d and SHA-256 %v", got.Size, got.SHA256, want.Size, want.SHA256)
+	}
+	return nil
+}
+
+// verifyManifest checks all assets against the manifest.
+func verifyManifest() error {
+	f, err := loadFrom(baseAssetDirs, ManifestPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.Infof("no asset manifest found, not verifying assets")
+			return nil
+		}
+		return fmt.Errorf("could not open asset manifest: %w", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return fmt.Errorf("could not read asset manifest: %w", err)
+	}
+	var manifest Manifest
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return fmt.Errorf("could not parse asset manifest: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	digest = hex.EncodeToString(sum[:])
+
+	paths := make([]string, 0, len(manifest))
+	for p := range manifest {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	var problems []string
+	for _, p := range paths {
+		err := checkManifestEntry(p, manifest[p])
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%v: %v", p, err))
+		}
+	}
+	if len(problems) == 0 {
+		log.Infof("asset digest: %v", digest)
+		return nil
+	}
+	for _, problem := range problems {
+		log.Errorf("asset does not match manifest: %v", problem)
+	}
+	if localAssets {
+		// Expected while editing assets in a source checkout.
+		log.Warningf("%d assets do not match the manifest; regenerate it with scripts/build-generated-assets.sh", len(problems))
+		return nil
+	}
+	return fmt.Errorf("the installation is corrupted: %d assets do not match the manifest, e.g. %v; please reinstall the game", len(problems), problems[0])
+}
+
+// WriteManifest writes the manifest of the current assets as JSON.
+func WriteManifest(w io.Writer) error {
+	manifest, err := ComputeManifest()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
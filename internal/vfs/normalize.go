@@ -0,0 +1,81 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// VFS paths are case insensitive and accept both slashes and backslashes as
+// separators, so that maps authored on Windows or macOS load on every platform
+// and from every backend. To keep this unambiguous, no asset root may contain
+// two files whose paths only differ in case.
+
+// normalizePath returns the canonical form of a VFS path or a path relative to an asset root.
+// Only ASCII letters are case folded, so the result has the same length as the input.
+func normalizePath(p string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\\':
+			return '/'
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return r
+		}
+	}, p)
+}
+
+// indexRoot records the actual names of all files and directories in an asset root by their normalized path.
+func indexRoot(dir *fsRoot) error {
+	files := map[string]string{}
+	dirs := map[string]string{}
+	err := fs.WalkDir(dir.filesys, dir.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath := ""
+		if p != dir.root {
+			relPath = strings.TrimPrefix(p, dir.root+"/")
+		}
+		index := files
+		if d.IsDir() {
+			index = dirs
+		}
+		key := normalizePath(relPath)
+		if prev, found := index[key]; found {
+			return fmt.Errorf("%q and %q only differ in case", prev, relPath)
+		}
+		index[key] = relPath
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not index %v: %w", dir, err)
+	}
+	dir.files = files
+	dir.dirs = dirs
+	return nil
+}
+
+// resolvePath maps a path relative to an asset root to the actual path in its file system.
+// Files not in the index, e.g. ones created after startup in a source checkout, are looked up as is.
+func resolvePath(index map[string]string, relPath string) string {
+	if actual, found := index[normalizePath(relPath)]; found {
+		return actual
+	}
+	return strings.ReplaceAll(relPath, `\`, "/")
+}
@@ -15,6 +15,12 @@
 package vfs
 
 import (
+	"errors"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/log"
 )
@@ -26,6 +32,7 @@ type StateKind int
 const (
 	Config StateKind = iota
 	SavedGames
+	Cache
 )
 
 type readonlyKey struct {
@@ -38,10 +45,26 @@ var (
 )
 
 var (
-	crashOnWrite   *string = nil
-	readonlyBuffer         = map[readonlyKey][]byte{}
+	crashOnWrite      *string = nil
+	readonlyBuffer            = map[readonlyKey][]byte{}
+	stateSyncProvider StateSyncProvider
 )
 
+// StateSyncProvider mirrors state files to external storage, such as cloud saves.
+type StateSyncProvider interface {
+	// Pull returns the synced contents of the given state file.
+	// Returns an error wrapping os.ErrNotExist if it has no copy of that file.
+	Pull(kind StateKind, name string) ([]byte, error)
+	// Push stores the contents of the given state file after it got written locally.
+	Push(kind StateKind, name string, data []byte) error
+}
+
+// SetStateSyncProvider installs a provider to sync state files with.
+// Synced copies take precedence over local files when reading.
+func SetStateSyncProvider(p StateSyncProvider) {
+	stateSyncProvider = p
+}
+
 // CrashOnWrite prevents further writing to any state.
 //
 // This is used as a safety mechanism so demo playback cannot have any
@@ -61,9 +84,39 @@ func ReadState(kind StateKind, name string) ([]byte, error) {
 			return append([]byte(nil), buf...), nil
 		}
 	}
+	if stateSyncProvider != nil {
+		data, err := stateSyncProvider.Pull(kind, name)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Errorf("could not pull state %v/%v, using local copy: %v", kind, name, err)
+		}
+	}
 	return readState(kind, name)
 }
 
+// ReadStateDir lists the state files in the given directory.
+// Returns their names relative to that directory, sorted and deduplicated.
+func ReadStateDir(kind StateKind, dir string) ([]string, error) {
+	names, err := readStateDir(kind, dir)
+	if err != nil {
+		return nil, err
+	}
+	if *readonly {
+		for key := range readonlyBuffer {
+			if key.kind != kind {
+				continue
+			}
+			if name, found := strings.CutPrefix(key.name, dir+"/"); found && !strings.ContainsRune(name, '/') {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return slices.Compact(names), nil
+}
+
 // WriteState writes the given state file.
 func WriteState(kind StateKind, name string, data []byte) error {
 	if crashOnWrite != nil {
@@ -75,5 +128,16 @@ func WriteState(kind StateKind, name string, data []byte) error {
 		readonlyBuffer[key] = append([]byte(nil), data...)
 		return nil
 	}
-	return writeState(kind, name, data)
+	err := writeState(kind, name, data)
+	if err != nil {
+		return err
+	}
+	if stateSyncProvider != nil {
+		err = stateSyncProvider.Push(kind, name, data)
+		if err != nil {
+			// The local copy is fine, so just keep going.
+			log.Errorf("could not push state %v/%v: %v", kind, name, err)
+		}
+	}
+	return nil
 }
@@ -19,6 +19,7 @@ package vfs
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
@@ -31,8 +32,12 @@ var (
 	portable   = flag.Bool("portable", false, "run as a portable program (store all data in the current directory)")
 	configPath = flag.String("config_path", "", "if set, override path to configs")
 	savePath   = flag.String("save_path", "", "if set, override path to saves")
+	cachePath  = flag.String("cache_path", "", "if set, override path to the precache cache")
 )
 
+// cacheSupported is set if the Cache state kind can be used.
+const cacheSupported = true
+
 func pathForOverride(kind StateKind) string {
 	switch kind {
 	case Config:
@@ -49,6 +54,13 @@ func pathForOverride(kind StateKind) string {
 		if *portable {
 			return "save"
 		}
+	case Cache:
+		if *cachePath != "" {
+			return *cachePath
+		}
+		if *portable {
+			return "cache"
+		}
 	}
 	return ""
 }
@@ -82,6 +94,12 @@ func initState() error {
 	} else {
 		log.Infof("save games will be written to %s", path)
 	}
+	path, err = pathForWrite(Cache, "*")
+	if err != nil {
+		log.Errorf("cache cannot be written: %v", err)
+	} else {
+		log.Infof("cache will be written to %s", path)
+	}
 	return nil
 }
 
@@ -106,6 +124,32 @@ func readState(kind StateKind, name string) ([]byte, error) {
 	return nil, lastErr
 }
 
+// readStateDir lists the state files in the given directory.
+func readStateDir(kind StateKind, dir string) ([]string, error) {
+	paths, err := pathForRead(kind, dir)
+	if err != nil {
+		log.Infof("could not find paths for folder%d/%s: %v", kind, dir, err)
+		return nil, nil
+	}
+	var names []string
+	for _, path := range paths {
+		content, err := os.ReadDir(path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("could not scan %v: %w", path, err)
+			}
+			continue
+		}
+		for _, info := range content {
+			if info.IsDir() {
+				continue
+			}
+			names = append(names, info.Name())
+		}
+	}
+	return names, nil
+}
+
 // MoveAwayState renames a detected-to-be-broken state file so it will not be used again.
 func MoveAwayState(kind StateKind, name string) error {
 	suffix := time.Now().UTC().Format(".2006-01-02T15-04-05Z")
@@ -125,6 +169,11 @@ func MoveAwayState(kind StateKind, name string) error {
 	return lastErr
 }
 
+// StatePath returns the path the given state file is written to.
+func StatePath(kind StateKind, name string) (string, error) {
+	return pathForWrite(kind, name)
+}
+
 // writeState writes the given state file.
 func writeState(kind StateKind, name string, data []byte) error {
 	path, err := pathForWrite(kind, name)
@@ -137,3 +186,33 @@ func writeState(kind StateKind, name string, data []byte) error {
 	}
 	return os.WriteFile(path, data, 0666)
 }
+
+// removeState deletes the given state file.
+func removeState(kind StateKind, name string) error {
+	path, err := pathForWrite(kind, name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// StateTransferSupported is set if ExportState and ImportState can be used.
+// Not needed here, as the state files can be copied directly.
+const StateTransferSupported = false
+
+// ExportState offers all state files of the given kind as a zip file download.
+func ExportState(kind StateKind, fileName string) error {
+	return errors.New("exporting state is not supported on this platform")
+}
+
+// ImportState lets the user upload a file made by ExportState, or a single state file, and writes its contents.
+// The result is delivered on the returned channel once the user picked a file.
+func ImportState(kind StateKind) <-chan ImportResult {
+	result := make(chan ImportResult, 1)
+	result <- ImportResult{Err: errors.New("importing state is not supported on this platform")}
+	return result
+}
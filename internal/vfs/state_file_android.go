@@ -46,6 +46,8 @@ func pathForWriteRaw(kind StateKind, name string) (string, error) {
 		return filepath.Join(filesDir, "config", name), nil
 	case SavedGames:
 		return filepath.Join(filesDir, "save", name), nil
+	case Cache:
+		return filepath.Join(filesDir, "cache", name), nil
 	default:
 		return "", fmt.Errorf("searched for unsupported state kind: %d", kind)
 	}
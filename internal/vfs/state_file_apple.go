@@ -81,6 +81,10 @@ func pathForReadRaw(kind StateKind, name string) ([]string, error) {
 			// This one matches state_file_xdg.go's for compatibility with data for releases up to 1.3.530.
 			filepath.Join(appSupportPath, "AAAAXY", name),
 		}, nil
+	case Cache:
+		return []string{
+			filepath.Join(appSupportPath, "AAAAXY", "cache", name),
+		}, nil
 	default:
 		return nil, fmt.Errorf("searched for unsupported state kind: %d", kind)
 	}
@@ -93,6 +97,8 @@ func pathForWriteRaw(kind StateKind, name string) (string, error) {
 		return filepath.Join(appSupportPath, "AAAAXY", "config", name), nil
 	case SavedGames:
 		return filepath.Join(appSupportPath, "AAAAXY", "save", name), nil
+	case Cache:
+		return filepath.Join(appSupportPath, "AAAAXY", "cache", name), nil
 	default:
 		return "", fmt.Errorf("searched for unsupported state kind: %d", kind)
 	}
@@ -30,6 +30,8 @@ func knownFolder(kind StateKind) (string, error) {
 		return windows.KnownFolderPath(windows.FOLDERID_LocalAppData, windows.KF_FLAG_CREATE)
 	case SavedGames:
 		return windows.KnownFolderPath(windows.FOLDERID_SavedGames, windows.KF_FLAG_CREATE)
+	case Cache:
+		return windows.KnownFolderPath(windows.FOLDERID_LocalAppData, windows.KF_FLAG_CREATE)
 	default:
 		return "", fmt.Errorf("searched for unsupported state kind: %d", kind)
 	}
@@ -45,5 +47,8 @@ func pathForWriteRaw(kind StateKind, name string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if kind == Cache {
+		return filepath.Join(root, gameName, "cache", name), nil
+	}
 	return filepath.Join(root, gameName, name), nil
 }
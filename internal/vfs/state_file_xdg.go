@@ -32,6 +32,9 @@ func pathForReadRaw(kind StateKind, name string) ([]string, error) {
 	case SavedGames:
 		path, err := xdg.SearchDataFile(filepath.Join(gameName, name))
 		return []string{path}, err
+	case Cache:
+		path, err := xdg.SearchCacheFile(filepath.Join(gameName, name))
+		return []string{path}, err
 	default:
 		return nil, fmt.Errorf("searched for unsupported state kind: %d", kind)
 	}
@@ -43,6 +46,8 @@ func pathForWriteRaw(kind StateKind, name string) (string, error) {
 		return xdg.ConfigFile(filepath.Join(gameName, name))
 	case SavedGames:
 		return xdg.DataFile(filepath.Join(gameName, name))
+	case Cache:
+		return xdg.CacheFile(filepath.Join(gameName, name))
 	default:
 		return "", fmt.Errorf("searched for unsupported state kind: %d", kind)
 	}
@@ -21,17 +21,190 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"syscall/js"
+	"time"
 
 	"github.com/divVerent/aaaaxy/internal/log"
 )
 
+// cacheSupported is set if the Cache state kind can be used.
+// Not on the web, as localStorage is way too small to hold the cache.
+const cacheSupported = false
+
+// StateTransferSupported is set if ExportState and ImportState can be used.
+const StateTransferSupported = true
+
+const (
+	// idbName is the name of the IndexedDB database mirroring localStorage.
+	idbName = "aaaaxy"
+	// idbStore is the object store in idbName holding the state files.
+	idbStore = "state"
+	// idbTimeout is how long to wait for IndexedDB at startup.
+	idbTimeout = 5 * time.Second
+)
+
+// idb is the IndexedDB database state files are mirrored to.
+// Browsers may clear localStorage and IndexedDB separately, so either copy can restore the other.
+var idb js.Value
+
 func initState() error {
 	log.Infof("configs will be written to localStorage['%d/*']", Config)
 	log.Infof("save games will be written to localStorage['%d/*']", SavedGames)
+	requestPersistence()
+	err := openIDB()
+	if err != nil {
+		log.Errorf("could not open IndexedDB, state will only be in localStorage: %v", err)
+		idb = js.Undefined()
+		return nil
+	}
+	err = syncIDB()
+	if err != nil {
+		log.Errorf("could not sync IndexedDB with localStorage: %v", err)
+	}
 	return nil
 }
 
+// requestPersistence asks the browser to not evict our storage when space gets low.
+func requestPersistence() {
+	err := protectJS(func() {
+		storage := js.Global().Get("navigator").Get("storage")
+		if storage.IsUndefined() || storage.Get("persist").IsUndefined() {
+			log.Infof("persistent storage not supported by this browser")
+			return
+		}
+		var then js.Func
+		then = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			if args[0].Bool() {
+				log.Infof("storage will be persistent")
+			} else {
+				log.Infof("browser declined persistent storage")
+			}
+			then.Release()
+			return nil
+		})
+		storage.Call("persist").Call("then", then)
+	})
+	if err != nil {
+		log.Errorf("could not request persistent storage: %v", err)
+	}
+}
+
+// awaitIDB waits for an IndexedDB request to finish and returns its result.
+// Must not be called from a JS callback.
+func awaitIDB(req js.Value) (js.Value, error) {
+	done := make(chan error, 1)
+	onSuccess := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- nil
+		return nil
+	})
+	defer onSuccess.Release()
+	onError := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- fmt.Errorf("IndexedDB error: %v", req.Get("error").Call("toString").String())
+		return nil
+	})
+	defer onError.Release()
+	req.Set("onsuccess", onSuccess)
+	req.Set("onerror", onError)
+	select {
+	case err := <-done:
+		if err != nil {
+			return js.Undefined(), err
+		}
+		return req.Get("result"), nil
+	case <-time.After(idbTimeout):
+		return js.Undefined(), errors.New("timed out waiting for IndexedDB")
+	}
+}
+
+func openIDB() error {
+	factory := js.Global().Get("indexedDB")
+	if factory.IsUndefined() || factory.IsNull() {
+		return errors.New("IndexedDB not supported by this browser")
+	}
+	var req js.Value
+	err := protectJS(func() {
+		req = factory.Call("open", idbName, 1)
+	})
+	if err != nil {
+		return err
+	}
+	onUpgrade := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		req.Get("result").Call("createObjectStore", idbStore)
+		return nil
+	})
+	defer onUpgrade.Release()
+	req.Set("onupgradeneeded", onUpgrade)
+	idb, err = awaitIDB(req)
+	return err
+}
+
+// syncIDB copies state files missing on either side between localStorage and IndexedDB.
+func syncIDB() error {
+	var keysReq, valuesReq js.Value
+	err := protectJS(func() {
+		store := idb.Call("transaction", idbStore, "readonly").Call("objectStore", idbStore)
+		keysReq = store.Call("getAllKeys")
+		valuesReq = store.Call("getAll")
+	})
+	if err != nil {
+		return err
+	}
+	keys, err := awaitIDB(keysReq)
+	if err != nil {
+		return err
+	}
+	values, err := awaitIDB(valuesReq)
+	if err != nil {
+		return err
+	}
+	return protectJS(func() {
+		storage := js.Global().Get("localStorage")
+		inIDB := map[string]bool{}
+		for i := 0; i < keys.Length(); i++ {
+			key := keys.Index(i).String()
+			inIDB[key] = true
+			if storage.Call("getItem", key).IsNull() {
+				log.Infof("restoring %v from IndexedDB", key)
+				storage.Call("setItem", key, values.Index(i))
+			}
+		}
+		n := storage.Get("length").Int()
+		for i := 0; i < n; i++ {
+			key := storage.Call("key", i).String()
+			if !inIDB[key] {
+				idbPut(key, storage.Call("getItem", key).String())
+			}
+		}
+	})
+}
+
+// idbPut mirrors a state file to IndexedDB in the background.
+func idbPut(path, data string) {
+	if idb.IsUndefined() {
+		return
+	}
+	err := protectJS(func() {
+		idb.Call("transaction", idbStore, "readwrite").Call("objectStore", idbStore).Call("put", data, path)
+	})
+	if err != nil {
+		log.Errorf("could not mirror %v to IndexedDB: %v", path, err)
+	}
+}
+
+// idbDelete removes a state file from IndexedDB in the background.
+func idbDelete(path string) {
+	if idb.IsUndefined() {
+		return
+	}
+	err := protectJS(func() {
+		idb.Call("transaction", idbStore, "readwrite").Call("objectStore", idbStore).Call("delete", path)
+	})
+	if err != nil {
+		log.Errorf("could not delete %v from IndexedDB: %v", path, err)
+	}
+}
+
 func protectJS(f func()) (err error) {
 	ok := false
 	defer func() {
@@ -64,6 +237,30 @@ func readState(kind StateKind, name string) ([]byte, error) {
 	return []byte(state.String()), nil
 }
 
+// readStateDir lists the state files in the given directory.
+func readStateDir(kind StateKind, dir string) ([]string, error) {
+	prefix := fmt.Sprintf("%d/%s/", kind, dir)
+	var names []string
+	err := protectJS(func() {
+		storage := js.Global().Get("localStorage")
+		n := storage.Get("length").Int()
+		for i := 0; i < n; i++ {
+			key := storage.Call("key", js.ValueOf(i))
+			if key.Type() != js.TypeString {
+				continue
+			}
+			name, found := strings.CutPrefix(key.String(), prefix)
+			if found && !strings.ContainsRune(name, '/') {
+				names = append(names, name)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
 // MoveAwayState deletes a detected-to-be-broken state file so it will not be used again.
 // It will also be printed to the console for debugging.
 func MoveAwayState(kind StateKind, name string) error {
@@ -74,15 +271,140 @@ func MoveAwayState(kind StateKind, name string) error {
 	} else {
 		log.Errorf("deleting broken state file %s with errorr: %s", path, err)
 	}
+	idbDelete(path)
 	return protectJS(func() {
 		js.Global().Get("localStorage").Call("removeItem", js.ValueOf(path))
 	})
 }
 
+// StatePath returns the path the given state file is written to.
+func StatePath(kind StateKind, name string) (string, error) {
+	return "", errors.New("state files have no path on this platform")
+}
+
 // writeState writes the given state file.
 func writeState(kind StateKind, name string, data []byte) error {
 	path := fmt.Sprintf("%d/%s", kind, name)
-	return protectJS(func() {
+	err := protectJS(func() {
 		js.Global().Get("localStorage").Call("setItem", js.ValueOf(path), js.ValueOf(string(data)))
 	})
+	if err != nil {
+		return err
+	}
+	idbPut(path, string(data))
+	return nil
+}
+
+// removeState deletes the given state file.
+func removeState(kind StateKind, name string) error {
+	path := fmt.Sprintf("%d/%s", kind, name)
+	idbDelete(path)
+	return protectJS(func() {
+		js.Global().Get("localStorage").Call("removeItem", js.ValueOf(path))
+	})
+}
+
+// ExportState offers all state files of the given kind as a zip file download.
+func ExportState(kind StateKind, fileName string) error {
+	prefix := fmt.Sprintf("%d/", kind)
+	var names []string
+	err := protectJS(func() {
+		storage := js.Global().Get("localStorage")
+		n := storage.Get("length").Int()
+		for i := 0; i < n; i++ {
+			name, found := strings.CutPrefix(storage.Call("key", i).String(), prefix)
+			if found && validStateName(name) {
+				names = append(names, name)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return errors.New("nothing to export")
+	}
+	data, err := exportStateArchive(kind, names)
+	if err != nil {
+		return err
+	}
+	return protectJS(func() {
+		bytes := js.Global().Get("Uint8Array").New(len(data))
+		js.CopyBytesToJS(bytes, data)
+		blob := js.Global().Get("Blob").New([]interface{}{bytes}, map[string]interface{}{
+			"type": "application/zip",
+		})
+		url := js.Global().Get("URL").Call("createObjectURL", blob)
+		doc := js.Global().Get("document")
+		a := doc.Call("createElement", "a")
+		a.Set("href", url)
+		a.Set("download", fileName)
+		doc.Get("body").Call("appendChild", a)
+		a.Call("click")
+		doc.Get("body").Call("removeChild", a)
+		var revoke js.Func
+		revoke = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			js.Global().Get("URL").Call("revokeObjectURL", url)
+			revoke.Release()
+			return nil
+		})
+		// The download needs the URL for a while after clicking.
+		js.Global().Call("setTimeout", revoke, 60000)
+	})
+}
+
+// ImportState lets the user upload a file made by ExportState, or a single state file, and writes its contents.
+// The result is delivered on the returned channel once the user picked a file.
+func ImportState(kind StateKind) <-chan ImportResult {
+	result := make(chan ImportResult, 1)
+	var onChange, onCancel js.Func
+	release := func() {
+		onChange.Release()
+		onCancel.Release()
+	}
+	var input js.Value
+	onChange = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		files := input.Get("files")
+		if files.Length() == 0 {
+			result <- ImportResult{}
+			release()
+			return nil
+		}
+		file := files.Index(0)
+		fileName := file.Get("name").String()
+		var onLoad js.Func
+		onLoad = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			bytes := js.Global().Get("Uint8Array").New(args[0])
+			data := make([]byte, bytes.Length())
+			js.CopyBytesToGo(data, bytes)
+			onLoad.Release()
+			release()
+			// Keep the JS callback short; unpack and write in the background.
+			go func() {
+				names, err := importStateArchive(kind, fileName, data)
+				result <- ImportResult{Names: names, Err: err}
+			}()
+			return nil
+		})
+		file.Call("arrayBuffer").Call("then", onLoad)
+		return nil
+	})
+	onCancel = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		result <- ImportResult{}
+		release()
+		return nil
+	})
+	err := protectJS(func() {
+		input = js.Global().Get("document").Call("createElement", "input")
+		input.Set("type", "file")
+		input.Set("accept", ".zip,.json")
+		input.Call("addEventListener", "change", onChange)
+		input.Call("addEventListener", "cancel", onCancel)
+		input.Call("click")
+	})
+	if err != nil {
+		release()
+		result <- ImportResult{Err: err}
+	}
+	return result
 }
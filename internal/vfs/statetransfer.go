@@ -0,0 +1,112 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ImportResult is the outcome of importing state files.
+type ImportResult struct {
+	// Names are the state files that were written. Empty if the user canceled.
+	Names []string
+	// Err is set if importing failed.
+	Err error
+}
+
+// validStateName returns whether the given name is a plain file name that can be used for a state file.
+func validStateName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\:")
+}
+
+// exportStateArchive packs the given state files into a zip archive.
+// The archive contains the files as is, so it can be extracted right into the state directory on other platforms.
+func exportStateArchive(kind StateKind, names []string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range names {
+		data, err := ReadState(kind, name)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %v: %w", name, err)
+		}
+		f, err := w.Create(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not add %v: %w", name, err)
+		}
+		_, err = f.Write(data)
+		if err != nil {
+			return nil, fmt.Errorf("could not write %v: %w", name, err)
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not finish archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// importStateArchive writes the state files from an uploaded file.
+// The file can either be an archive made by exportStateArchive, or a single state file.
+func importStateArchive(kind StateKind, fileName string, data []byte) ([]string, error) {
+	if !strings.EqualFold(path.Ext(fileName), ".zip") {
+		name := path.Base(fileName)
+		if !validStateName(name) {
+			return nil, fmt.Errorf("invalid file name %q", fileName)
+		}
+		err := WriteState(kind, name, data)
+		if err != nil {
+			return nil, fmt.Errorf("could not write %v: %w", name, err)
+		}
+		return []string{name}, nil
+	}
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("could not open archive %v: %w", fileName, err)
+	}
+	// Read everything first, so a broken archive does not get imported halfway.
+	contents := map[string][]byte{}
+	var names []string
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !validStateName(f.Name) {
+			return nil, fmt.Errorf("invalid file name %q in archive %v", f.Name, fileName)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("could not open %v in archive %v: %w", f.Name, fileName, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not read %v in archive %v: %w", f.Name, fileName, err)
+		}
+		contents[f.Name] = content
+		names = append(names, f.Name)
+	}
+	for _, name := range names {
+		err := WriteState(kind, name, contents[name])
+		if err != nil {
+			return nil, fmt.Errorf("could not write %v: %w", name, err)
+		}
+	}
+	return names, nil
+}
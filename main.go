@@ -16,7 +16,9 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"runtime"
+	"runtime/debug"
 	"runtime/pprof"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -26,6 +28,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/exitstatus"
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/telemetry"
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
 
@@ -55,6 +58,81 @@ func setProfileRates() {
 	}
 }
 
+// crashGuard catches panics in the game loop and writes a crash report for them.
+type crashGuard struct {
+	game    *aaaaxy.Game
+	drawErr error
+}
+
+// crashError is returned when the game loop panicked.
+type crashError struct {
+	reason string
+	report string
+}
+
+func (e *crashError) Error() string {
+	return e.reason
+}
+
+// crashed writes a crash report for a caught panic and returns the error to end the game with.
+func (g *crashGuard) crashed(where string, r interface{}) error {
+	reason := fmt.Sprintf("caught panic during %s: %v", where, r)
+	telemetry.RecordCrash()
+	report, err := g.game.WriteCrashReport(reason, debug.Stack())
+	if err != nil {
+		log.Errorf("could not write crash report: %v", err)
+		return errors.New(reason)
+	}
+	log.Errorf("wrote crash report to %v", report)
+	return &crashError{reason: reason, report: report}
+}
+
+func (g *crashGuard) Update() (err error) {
+	ok := false
+	defer func() {
+		if !ok {
+			err = g.crashed("update", recover())
+		}
+	}()
+	if g.drawErr != nil {
+		ok = true
+		return g.drawErr
+	}
+	err = g.game.Update()
+	ok = true
+	return err
+}
+
+func (g *crashGuard) Draw(screen *ebiten.Image) {
+	ok := false
+	defer func() {
+		if !ok {
+			g.drawErr = g.crashed("draw", recover())
+		}
+	}()
+	if g.drawErr == nil {
+		g.game.Draw(screen)
+	}
+	ok = true
+}
+
+func (g *crashGuard) DrawFinalScreen(screen ebiten.FinalScreen, offscreen *ebiten.Image, geoM ebiten.GeoM) {
+	ok := false
+	defer func() {
+		if !ok {
+			g.drawErr = g.crashed("final screen draw", recover())
+		}
+	}()
+	if g.drawErr == nil {
+		g.game.DrawFinalScreen(screen, offscreen, geoM)
+	}
+	ok = true
+}
+
+func (g *crashGuard) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return g.game.Layout(outsideWidth, outsideHeight)
+}
+
 func runGame(game *aaaaxy.Game) error {
 	if *debugLoadingCpuprofile != "" {
 		f, err := vfs.OSCreate(vfs.WorkDir, *debugLoadingCpuprofile)
@@ -85,7 +163,7 @@ func runGame(game *aaaaxy.Game) error {
 			log.Fatalf("could not start CPU profile: %v", err)
 		}
 	}
-	err = ebiten.RunGame(game)
+	err = ebiten.RunGame(&crashGuard{game: game})
 	if *debugCpuprofile != "" {
 		pprof.StopCPUProfile()
 	}
@@ -129,6 +207,18 @@ func main() {
 	defer log.CloseLogFile()
 
 	game := aaaaxy.NewGame()
+	if aaaaxy.Benchmarking() {
+		err := game.RunBenchmark()
+		errbe := game.BeforeExit()
+		ok = true
+		if err != nil && !errors.Is(err, exitstatus.ErrRegularTermination) {
+			log.Fatalf("benchmark failed: %v", err)
+		}
+		if errbe != nil {
+			log.Fatalf("BeforeExit exited abnormally: %v", errbe)
+		}
+		return
+	}
 	err := game.InitEbitengine()
 	if err != nil {
 		if errors.Is(err, exitstatus.ErrRegularTermination) {
@@ -141,6 +231,10 @@ func main() {
 	errbe := game.BeforeExit()
 	// From here on, nothing can panic.
 	ok = true
+	var crash *crashError
+	if errors.As(err, &crash) {
+		log.SetCrashReport(crash.report)
+	}
 	if err != nil && !errors.Is(err, exitstatus.ErrRegularTermination) {
 		log.Fatalf("RunGame exited abnormally: %v", err)
 	}
@@ -69,3 +69,10 @@ sh scripts/version.sh semver > assets/generated/version.txt
 
 # Prepare compressed font.
 gzip -9 < ./third_party/gnu_unifont/assets/fonts/_unifont-15.1.04.bdf > assets/generated/unifont.bdf.gz
+
+# Record asset checksums. Must be last, as it covers all generated files.
+# Using |cat> instead of > for the same reason as above.
+# Writing outside the assets first so the file being written is not scanned.
+manifest=$(mktemp)
+${GO} run ${GO_FLAGS} github.com/divVerent/aaaaxy/cmd/dumpmanifest |cat> "$manifest"
+mv "$manifest" assets/generated/manifest.json
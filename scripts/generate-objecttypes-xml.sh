@@ -17,8 +17,11 @@ while read -r type xml; do
 		fi
 		case "$type" in
 			_TileMod)             color=0000ff ;;
+			AmbientSound)         color=0000ff ;;
 			Animation)            color=ffffff ;;
 			AppearBlock)          color=00aa00 ;;
+			CameraLock)           color=ff00ff ;;
+			CameraRegion)         color=ff00ff ;;
 			Checkpoint)           color=008000 ;;
 			CheckpointTarget)     color=008000 ;;
 			CoverSprite)          color=ffffff ;;
@@ -27,6 +30,8 @@ while read -r type xml; do
 			DisappearBlock)       color=00aa00 ;;
 			ExitButton)           color=ffffff ;;
 			FadeTarget)           color=ff00ff ;;
+			FlagGate)             color=000000 ;;
+			FlagTrigger)          color=ff0000 ;;
 			ForceField)           color=ff00ff ;;
 			Give)                 color=ffff00 ;;
 			Goal)                 color=ffff00 ;;
@@ -34,17 +39,22 @@ while read -r type xml; do
 			LogicalGate)          color=000000 ;;
 			MovableSprite)        color=ffffff ;;
 			MovingAnimation)      color=ffffff ;;
+			NetGhost)             color=008000 ;;
 			OneWay)               color=0000ff ;;
 			Player)               color=008000 ;;
 			PrintToConsoleTarget) color=000000 ;;
+			PushableBlock)        color=000080 ;;
 			QuestionBlock)        color=000000 ;;
 			RespawnPlayer)        color=ff0000 ;;
 			Riser)                color=000080 ;;
+			RoomReset)            color=ff0000 ;;
+			ScriptedSprite)       color=ffffff ;;
 			RiserFsck)            color=ffff80 ;;
 			SequenceCollector)    color=00ff00 ;;
 			SequenceTarget)       color=00ff00 ;;
 			SetState)             color=ff0000 ;;
 			SetStateTarget)       color=ff0000 ;;
+			ShakeTarget)          color=ff00ff ;;
 			SoundTarget)          color=0000ff ;;
 			SpawnCounter)         color=ff0000 ;;
 			Sprite)               color=ffffff ;;
@@ -57,8 +67,10 @@ while read -r type xml; do
 			SwitchMusic)          color=00ff00 ;;
 			SwitchMusicTarget)    color=00ff00 ;;
 			Text)                 color=ffffff ;;
+			TimerTarget)          color=000000 ;;
 			TnihSign)             color=ffff00 ;;
 			VVVVVV)               color=00ff00 ;;
+			WarpSwitch)           color=ff0000 ;;
 			WarpZone)             color=ff0000 ;;
 			ZoomTarget)           color=ff00ff ;;
 			*) echo >&2 "Add type: $type"; exit 1 ;;